@@ -42,4 +42,10 @@ type Config struct {
 
 	// LDSSize is the size of the Local Data Share in bytes.
 	LDSSize int
+
+	// SIMDWidth is the number of lanes that each SIMD executes per cycle.
+	SIMDWidth int
+
+	// DualIssue tells if each SIMD can issue two VALU instructions per cycle.
+	DualIssue bool
 }
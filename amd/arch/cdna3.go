@@ -8,4 +8,6 @@ var CDNA3Config = &Config{
 	NumSGPRs:        106,
 	WavefrontSize:   64,
 	LDSSize:         65536, // 64KB
+	SIMDWidth:       16,
+	DualIssue:       false,
 }
@@ -8,4 +8,6 @@ var GCN3Config = &Config{
 	NumSGPRs:        102,
 	WavefrontSize:   64,
 	LDSSize:         65536, // 64KB
+	SIMDWidth:       16,
+	DualIssue:       false,
 }
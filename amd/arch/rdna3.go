@@ -0,0 +1,16 @@
+package arch
+
+// RDNA3Config provides the configuration for an RDNA3-class GPU, which runs
+// wave32 wavefronts on dual-issue SIMDs that are 32 lanes wide. The emulator
+// does not decode the RDNA ISA, so the GPU runs CDNA3 kernels that are
+// compiled for wave32. The kernels compiled for wave64 are rejected.
+var RDNA3Config = &Config{
+	Name:            "RDNA3",
+	Type:            CDNA3,
+	NumVGPRsPerLane: 512,
+	NumSGPRs:        106,
+	WavefrontSize:   32,
+	LDSSize:         65536, // 64KB
+	SIMDWidth:       32,
+	DualIssue:       true,
+}
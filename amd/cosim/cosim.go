@@ -0,0 +1,525 @@
+// Package cosim compares the execution of kernels in the emulator with the
+// execution of the same kernels in the timing model. The driver runs each
+// kernel on an emulated GPU first, rolls back the memory that the kernel
+// writes, and runs the kernel again on the simulated GPU. For each
+// wavefront, the checker compares the registers that each instruction
+// writes, the registers at S_ENDPGM, and the memory that the wavefront
+// writes.
+package cosim
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/sarchlab/akita/v4/mem/mem"
+	"github.com/sarchlab/akita/v4/mem/vm"
+	"github.com/sarchlab/mgpusim/v4/amd/insts"
+	"github.com/sarchlab/mgpusim/v4/amd/kernels"
+)
+
+// A WavefrontID identifies a wavefront of a kernel by its work-group and by
+// its position in the work-group.
+type WavefrontID struct {
+	WG        [3]int
+	Wavefront int
+}
+
+// IDOf returns the ID of a wavefront.
+func IDOf(wf *kernels.Wavefront) WavefrontID {
+	return WavefrontID{
+		WG:        [3]int{wf.WG.IDX, wf.WG.IDY, wf.WG.IDZ},
+		Wavefront: wf.FirstWiFlatID / wf.LaneCount(),
+	}
+}
+
+func (id WavefrontID) String() string {
+	return fmt.Sprintf("wg (%d, %d, %d), wf %d",
+		id.WG[0], id.WG[1], id.WG[2], id.Wavefront)
+}
+
+func (id WavefrontID) less(other WavefrontID) bool {
+	for i := 2; i >= 0; i-- {
+		if id.WG[i] != other.WG[i] {
+			return id.WG[i] < other.WG[i]
+		}
+	}
+
+	return id.Wavefront < other.Wavefront
+}
+
+// A Step is an instruction that a wavefront executes. The digest summarizes
+// the values that the instruction writes to the registers.
+type Step struct {
+	PC     uint64
+	Digest uint64
+}
+
+// State is the architectural state of a wavefront at S_ENDPGM. VGPRs holds
+// the values of the lanes of each vector register.
+type State struct {
+	SGPRs []uint32
+	VGPRs [][64]uint32
+	EXEC  uint64
+	VCC   uint64
+	SCC   byte
+}
+
+// A Divergence describes how the execution of a wavefront in the timing
+// model differs from the execution in the emulator.
+type Divergence struct {
+	Kernel    string
+	Wavefront WavefrontID
+
+	// Step is the index of the first instruction of the wavefront that
+	// diverges, or -1 if the instructions agree. PC, Inst, and Reason
+	// describe the instruction.
+	Step   int
+	PC     uint64
+	Inst   string
+	Reason string
+
+	// Registers and Memory describe the first difference in the registers
+	// at S_ENDPGM and in the memory that the wavefront writes. They are
+	// empty if there is no difference.
+	Registers string
+	Memory    string
+}
+
+func (d *Divergence) String() string {
+	s := fmt.Sprintf("kernel %s, %s", d.Kernel, d.Wavefront)
+
+	if d.Step >= 0 {
+		s += fmt.Sprintf(": instruction %d at pc 0x%x", d.Step, d.PC)
+		if d.Inst != "" {
+			s += " (" + d.Inst + ")"
+		}
+
+		s += ": " + d.Reason
+	}
+
+	if d.Registers != "" {
+		s += "; registers: " + d.Registers
+	}
+
+	if d.Memory != "" {
+		s += "; memory: " + d.Memory
+	}
+
+	return s
+}
+
+type wfRecord struct {
+	steps     []Step
+	final     *State
+	simulated bool
+
+	divergence *Divergence
+	memory     string
+	numWords   int
+}
+
+type wordKey struct {
+	pid  vm.PID
+	addr uint64
+}
+
+type word struct {
+	old, emulated []byte
+	writer        WavefrontID
+}
+
+// A Checker collects the execution of a kernel in the emulator and checks
+// the execution of the same kernel in the timing model against it. The
+// emulated compute units add their steps, writes, and final states, and the
+// timing compute units check theirs. It is safe to use the checker from
+// multiple goroutines.
+type Checker struct {
+	lock sync.Mutex
+
+	pageTable vm.PageTable
+	storage   *mem.Storage
+
+	kernel string
+	wfs    map[WavefrontID]*wfRecord
+	words  map[wordKey]*word
+
+	divergences []Divergence
+}
+
+// NewChecker creates a Checker for the GPUs that share the page table and
+// the storage.
+func NewChecker(pageTable vm.PageTable, storage *mem.Storage) *Checker {
+	c := &Checker{
+		pageTable: pageTable,
+		storage:   storage,
+	}
+	c.StartKernel()
+
+	return c
+}
+
+// StartKernel forgets the previous kernel. The driver calls it before an
+// emulated GPU runs a kernel.
+func (c *Checker) StartKernel() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.kernel = ""
+	c.wfs = make(map[WavefrontID]*wfRecord)
+	c.words = make(map[wordKey]*word)
+}
+
+func (c *Checker) record(wf *kernels.Wavefront) *wfRecord {
+	if c.kernel == "" {
+		c.kernel = kernelName(wf.CodeObject)
+	}
+
+	id := IDOf(wf)
+	r, ok := c.wfs[id]
+	if !ok {
+		r = &wfRecord{}
+		c.wfs[id] = r
+	}
+
+	return r
+}
+
+func kernelName(co *insts.KernelCodeObject) string {
+	if co != nil && co.Symbol != nil && co.Symbol.Name != "" {
+		return co.Symbol.Name
+	}
+
+	return "unknown kernel"
+}
+
+// AddEmulatedStep records the next instruction that an emulated wavefront
+// executes.
+func (c *Checker) AddEmulatedStep(wf *kernels.Wavefront, step Step) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	r := c.record(wf)
+	r.steps = append(r.steps, step)
+}
+
+// AddEmulatedWrite records that an emulated wavefront is about to write the
+// bytes in [addr, addr+size). The checker keeps the old values of the
+// 4-byte words that the write touches so that it can roll back the write.
+func (c *Checker) AddEmulatedWrite(
+	wf *kernels.Wavefront,
+	pid vm.PID,
+	addr, size uint64,
+) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	id := IDOf(wf)
+	c.record(wf)
+
+	for a := addr &^ 3; a < addr+size; a += 4 {
+		key := wordKey{pid: pid, addr: a}
+		w, ok := c.words[key]
+		if !ok {
+			w = &word{old: c.read(pid, a)}
+			c.words[key] = w
+		}
+
+		w.writer = id
+	}
+}
+
+// AddEmulatedFinalState records the state of an emulated wavefront at
+// S_ENDPGM.
+func (c *Checker) AddEmulatedFinalState(wf *kernels.Wavefront, state State) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.record(wf).final = &state
+}
+
+// EmulationCompleted keeps the memory that the emulated kernel writes and
+// restores the memory to the state before the kernel. The driver calls it
+// after the emulated GPUs complete the kernel.
+func (c *Checker) EmulationCompleted() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for key, w := range c.words {
+		w.emulated = c.read(key.pid, key.addr)
+		c.write(key.pid, key.addr, w.old)
+	}
+}
+
+// CheckSimulatedStep compares the instruction that a simulated wavefront
+// completes with the instruction at the same index in the emulator. The
+// instructions may complete out of order; the checker keeps the divergence
+// with the lowest index.
+func (c *Checker) CheckSimulatedStep(
+	wf *kernels.Wavefront,
+	index int,
+	step Step,
+	inst *insts.Inst,
+) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	r := c.record(wf)
+	if r.divergesBefore(index + 1) {
+		return
+	}
+
+	var reason string
+	switch {
+	case index >= len(r.steps):
+		reason = "the emulator does not execute the instruction"
+	case r.steps[index].PC != step.PC:
+		reason = fmt.Sprintf("the emulator executes pc 0x%x instead",
+			r.steps[index].PC)
+	case r.steps[index].Digest != step.Digest:
+		reason = "the instruction writes different values"
+	default:
+		return
+	}
+
+	r.divergence = withStep(r.divergence, index, step.PC,
+		insts.NewInstPrinter(nil).Print(inst), reason)
+}
+
+func (r *wfRecord) divergesBefore(index int) bool {
+	return r.divergence != nil && r.divergence.Step >= 0 &&
+		r.divergence.Step < index
+}
+
+func withStep(d *Divergence, index int, pc uint64, inst, reason string) *Divergence {
+	if d == nil {
+		d = &Divergence{}
+	}
+
+	d.Step = index
+	d.PC = pc
+	d.Inst = inst
+	d.Reason = reason
+
+	return d
+}
+
+// CheckSimulatedFinalState compares the state of a simulated wavefront at
+// S_ENDPGM with the state of the emulated wavefront. The wavefront has
+// executed numSteps instructions before S_ENDPGM.
+func (c *Checker) CheckSimulatedFinalState(
+	wf *kernels.Wavefront,
+	numSteps int,
+	state State,
+) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	r := c.record(wf)
+	r.simulated = true
+
+	if numSteps < len(r.steps) && !r.divergesBefore(numSteps+1) {
+		r.divergence = withStep(r.divergence, numSteps, r.steps[numSteps].PC,
+			"", "the timing model ends the wavefront before it")
+	}
+
+	if r.final != nil {
+		r.divergence = withRegisters(r.divergence, compareStates(r.final, &state))
+	}
+}
+
+func withRegisters(d *Divergence, registers string) *Divergence {
+	if registers == "" {
+		return d
+	}
+
+	if d == nil {
+		d = &Divergence{Step: -1}
+	}
+
+	d.Registers = registers
+
+	return d
+}
+
+// SimulationCompleted compares the memory that the emulated and the
+// simulated kernels write and reports the divergences of the kernel. The
+// driver calls it after the simulated GPUs complete the kernel and write
+// back their caches.
+func (c *Checker) SimulationCompleted() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.compareMemory()
+
+	ids := make([]WavefrontID, 0, len(c.wfs))
+	for id := range c.wfs {
+		ids = append(ids, id)
+	}
+
+	sort.Slice(ids, func(i, j int) bool { return ids[i].less(ids[j]) })
+
+	for _, id := range ids {
+		if d := c.divergence(id, c.wfs[id]); d != nil {
+			c.divergences = append(c.divergences, *d)
+		}
+	}
+}
+
+func (c *Checker) compareMemory() {
+	keys := make([]wordKey, 0, len(c.words))
+	for key := range c.words {
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool { return keys[i].addr < keys[j].addr })
+
+	for _, key := range keys {
+		w := c.words[key]
+
+		simulated := c.read(key.pid, key.addr)
+		if string(simulated) == string(w.emulated) {
+			continue
+		}
+
+		r := c.wfs[w.writer]
+		if r.numWords == 0 {
+			r.memory = fmt.Sprintf("0x%x: emulator 0x%08x, timing model 0x%08x",
+				key.addr, insts.BytesToUint32(w.emulated),
+				insts.BytesToUint32(simulated))
+		}
+
+		r.numWords++
+	}
+}
+
+func (c *Checker) divergence(id WavefrontID, r *wfRecord) *Divergence {
+	d := r.divergence
+
+	switch {
+	case r.final == nil:
+		d = &Divergence{Step: -1, Registers: "the emulator does not complete " +
+			"the wavefront"}
+	case !r.simulated:
+		d = withRegisters(d, "the timing model does not complete the wavefront")
+	}
+
+	if r.numWords > 0 {
+		if d == nil {
+			d = &Divergence{Step: -1}
+		}
+
+		d.Memory = fmt.Sprintf("%s (%d words)", r.memory, r.numWords)
+	}
+
+	if d == nil {
+		return nil
+	}
+
+	d.Kernel = c.kernel
+	d.Wavefront = id
+
+	return d
+}
+
+// compareStates describes the first difference between the states, or
+// returns an empty string if the states are the same.
+func compareStates(emulated, simulated *State) string {
+	var first string
+	count := 0
+
+	diff := func(e, s uint64, name func() string) {
+		if e == s {
+			return
+		}
+
+		if count == 0 {
+			first = fmt.Sprintf("%s: emulator 0x%x, timing model 0x%x",
+				name(), e, s)
+		}
+
+		count++
+	}
+
+	for i := 0; i < len(emulated.SGPRs) && i < len(simulated.SGPRs); i++ {
+		diff(uint64(emulated.SGPRs[i]), uint64(simulated.SGPRs[i]),
+			func() string { return fmt.Sprintf("s%d", i) })
+	}
+
+	for i := 0; i < len(emulated.VGPRs) && i < len(simulated.VGPRs); i++ {
+		for lane := 0; lane < 64; lane++ {
+			diff(uint64(emulated.VGPRs[i][lane]),
+				uint64(simulated.VGPRs[i][lane]),
+				func() string { return fmt.Sprintf("v%d lane %d", i, lane) })
+		}
+	}
+
+	diff(emulated.EXEC, simulated.EXEC, func() string { return "exec" })
+	diff(emulated.VCC, simulated.VCC, func() string { return "vcc" })
+	diff(uint64(emulated.SCC), uint64(simulated.SCC),
+		func() string { return "scc" })
+
+	if count == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("%s (%d differences)", first, count)
+}
+
+func (c *Checker) read(pid vm.PID, addr uint64) []byte {
+	pAddr, ok := c.translate(pid, addr)
+	if !ok {
+		return make([]byte, 4)
+	}
+
+	data, err := c.storage.Read(pAddr, 4)
+	if err != nil {
+		panic(err)
+	}
+
+	return data
+}
+
+func (c *Checker) write(pid vm.PID, addr uint64, data []byte) {
+	pAddr, ok := c.translate(pid, addr)
+	if !ok {
+		return
+	}
+
+	err := c.storage.Write(pAddr, data)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (c *Checker) translate(pid vm.PID, addr uint64) (uint64, bool) {
+	page, found := c.pageTable.Find(pid, addr)
+	if !found {
+		return 0, false
+	}
+
+	return page.PAddr + addr - page.VAddr, true
+}
+
+// Divergences returns the divergences in the order of the kernels and of
+// the wavefronts.
+func (c *Checker) Divergences() []Divergence {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return append([]Divergence(nil), c.divergences...)
+}
+
+// Print writes the divergences to w, one divergence per line.
+func (c *Checker) Print(w io.Writer) {
+	divergences := c.Divergences()
+	if len(divergences) == 0 {
+		fmt.Fprintln(w, "cosim: no divergence")
+		return
+	}
+
+	for _, d := range divergences {
+		fmt.Fprintf(w, "cosim: %s\n", &d)
+	}
+}
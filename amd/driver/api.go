@@ -109,9 +109,41 @@ func (d *Driver) CreateCommandQueue(c *Context) *CommandQueue {
 	c.queues = append(c.queues, q)
 	c.queueMutex.Unlock()
 
+	if d.checkpointer != nil {
+		d.checkpointer.registerQueue(q)
+	}
+
 	return q
 }
 
+// ReleaseCommandQueue frees the scratch memory of a command queue and stops
+// the driver from polling the queue. The queue must be drained and cannot be
+// used after being released.
+func (d *Driver) ReleaseCommandQueue(q *CommandQueue) {
+	if q.NumCommand() > 0 {
+		panic("cannot release a command queue that has commands")
+	}
+
+	for _, segment := range q.scratch {
+		err := d.FreeMemory(q.Context, segment.ptr)
+		if err != nil {
+			panic(err)
+		}
+	}
+	q.scratch = nil
+
+	c := q.Context
+	c.queueMutex.Lock()
+	defer c.queueMutex.Unlock()
+
+	for i, queue := range c.queues {
+		if queue == q {
+			c.queues = append(c.queues[:i], c.queues[i+1:]...)
+			return
+		}
+	}
+}
+
 // DrainCommandQueue will return when there is no command to execute
 func (d *Driver) DrainCommandQueue(q *CommandQueue) {
 	listener := q.Subscribe()
@@ -136,6 +168,10 @@ func (d *Driver) AllocateMemory(
 ) Ptr {
 	ptr := d.memAllocator.Allocate(ctx.pid, byteSize, ctx.currentGPUID)
 
+	if d.sanitizer != nil {
+		d.sanitizer.Allocate(ctx.pid, ptr, byteSize)
+	}
+
 	ctx.buffers = append(ctx.buffers, &buffer{
 		vAddr:   Ptr(ptr),
 		size:    byteSize,
@@ -154,6 +190,19 @@ func (d *Driver) AllocateUnifiedMemory(
 ) Ptr {
 	ptr := Ptr(d.memAllocator.AllocateUnified(ctx.pid, byteSize))
 
+	if d.placeUnifiedMemory {
+		gpuIDs := make([]int, len(d.GPUs))
+		for i := range gpuIDs {
+			gpuIDs[i] = i + 1
+		}
+
+		d.distributor.Distribute(ctx, uint64(ptr), byteSize, gpuIDs)
+	}
+
+	if d.sanitizer != nil {
+		d.sanitizer.Allocate(ctx.pid, uint64(ptr), byteSize)
+	}
+
 	ctx.buffers = append(ctx.buffers, &buffer{
 		vAddr:   ptr,
 		size:    byteSize,
@@ -186,6 +235,57 @@ func (d *Driver) Distribute(
 	return d.distributor.Distribute(ctx, uint64(addr), byteSize, gpuIDs)
 }
 
+// MemAdvise gives a hint about where the pages of a memory range should live.
+// The pages that are already allocated move to the preferred GPU right away.
+// Later placements honor the hint if the driver uses AdvisedPlacement. The
+// data in the pages does not move with the pages, so advising a range that
+// may already hold data panics. If the advice asks for it, the pages that move
+// are pinned so that page migration does not move them away.
+func (d *Driver) MemAdvise(
+	ctx *Context,
+	ptr Ptr,
+	byteSize uint64,
+	advice MemAdvice,
+) {
+	if advice.PreferredGPU < 0 || advice.PreferredGPU > len(d.GPUs) {
+		log.Panicf("GPU %d does not exist", advice.PreferredGPU)
+	}
+
+	pageSize := uint64(1) << d.Log2PageSize
+	start := uint64(ptr) &^ (pageSize - 1)
+	end := uint64(ptr) + byteSize
+
+	ctx.memAdvices = append(ctx.memAdvices, memAdviceRange{
+		vAddr:    start,
+		byteSize: end - start,
+		advice:   advice,
+	})
+
+	if advice.PreferredGPU == 0 {
+		return
+	}
+
+	for vAddr := start; vAddr < end; vAddr += pageSize {
+		page, found := d.pageTable.Find(ctx.pid, vAddr)
+		if !found {
+			continue
+		}
+
+		if page.DeviceID != uint64(advice.PreferredGPU) {
+			if ctx.isPopulated(vAddr, pageSize) {
+				log.Panicf("cannot move page 0x%x, which may hold data; "+
+					"advise the memory before writing to it", vAddr)
+			}
+
+			d.memAllocator.Remap(ctx.pid, vAddr, pageSize, advice.PreferredGPU)
+		}
+
+		if advice.Pin {
+			d.memAllocator.Pin(ctx.pid, vAddr, pageSize)
+		}
+	}
+}
+
 func unique(in []int) []int {
 	keys := make(map[int]bool)
 	list := make([]int, 0)
@@ -207,6 +307,10 @@ func (d *Driver) FreeMemory(ctx *Context, ptr Ptr) error {
 	// log.Printf("Free %d\n", ptr)
 	d.memAllocator.Free(uint64(ptr))
 
+	if d.sanitizer != nil {
+		d.sanitizer.Free(ctx.pid, uint64(ptr))
+	}
+
 	for i, buffer := range ctx.buffers {
 		if buffer.vAddr == ptr {
 			ctx.buffers[i].freed = true
@@ -228,6 +332,7 @@ func (d *Driver) EnqueueMemCopyH2D(
 		Src: src,
 	}
 
+	queue.Context.markBufferPopulated(dst)
 	d.Enqueue(queue, cmd)
 }
 
@@ -270,24 +375,33 @@ func (d *Driver) EnqueueMemCopyD2D(
 	d.EnqueueLaunchKernel(queue, co, gridSize, wgSize, &kernelArgs)
 }
 
-// MemCopyH2D copies a memory from the host to a GPU device.
-func (d *Driver) MemCopyH2D(ctx *Context, dst Ptr, src interface{}) {
+// MemCopyH2D copies a memory from the host to a GPU device. It returns a
+// *protocol.MemoryFault if the destination is not mapped.
+func (d *Driver) MemCopyH2D(ctx *Context, dst Ptr, src interface{}) error {
 	queue := d.CreateCommandQueue(ctx)
 	d.EnqueueMemCopyH2D(queue, dst, src)
 	d.DrainCommandQueue(queue)
+
+	return queue.Err()
 }
 
-// MemCopyD2H copies a memory from a GPU device to the host
-func (d *Driver) MemCopyD2H(ctx *Context, dst interface{}, src Ptr) {
+// MemCopyD2H copies a memory from a GPU device to the host. It returns a
+// *protocol.MemoryFault if the source is not mapped.
+func (d *Driver) MemCopyD2H(ctx *Context, dst interface{}, src Ptr) error {
 	queue := d.CreateCommandQueue(ctx)
 	d.EnqueueMemCopyD2H(queue, dst, src)
 	d.DrainCommandQueue(queue)
+
+	return queue.Err()
 }
 
 // MemCopyD2D copies a memory from a GPU device to another GPU device. num is
-// the total number of bytes.
-func (d *Driver) MemCopyD2D(ctx *Context, dst Ptr, src Ptr, num int) {
+// the total number of bytes. It returns a *protocol.MemoryFault if the copy
+// kernel accesses an unmapped address.
+func (d *Driver) MemCopyD2D(ctx *Context, dst Ptr, src Ptr, num int) error {
 	queue := d.CreateCommandQueue(ctx)
 	d.EnqueueMemCopyD2D(queue, dst, src, num)
 	d.DrainCommandQueue(queue)
+
+	return queue.Err()
 }
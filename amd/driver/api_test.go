@@ -1,6 +1,8 @@
 package driver
 
 import (
+	"errors"
+
 	"github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/rs/xid"
@@ -8,6 +10,8 @@ import (
 	"github.com/sarchlab/akita/v4/mem/vm"
 	"github.com/sarchlab/akita/v4/sim"
 	"github.com/sarchlab/mgpusim/v4/amd/driver/internal"
+	"github.com/sarchlab/mgpusim/v4/amd/protocol"
+	"github.com/sarchlab/mgpusim/v4/amd/sanitizer"
 )
 
 func enqueueNoopCommand(d *Driver, q *CommandQueue) {
@@ -90,6 +94,39 @@ var _ = ginkgo.Describe("Driver async API execution", func() {
 		Expect(context.buffers[0].l2Dirty).To(BeFalse())
 	})
 
+	ginkgo.It("should return a memory fault when copying to an unmapped "+
+		"address", func() {
+		context := driver.Init()
+
+		err := driver.MemCopyH2D(context, Ptr(0x1_0000_0000), []uint32{1, 2})
+
+		var fault *protocol.MemoryFault
+		Expect(errors.As(err, &fault)).To(BeTrue())
+		Expect(fault.Address).To(Equal(uint64(0x1_0000_0000)))
+		Expect(fault.Access).To(Equal(protocol.MemoryAccessWrite))
+		Expect(fault.Lane).To(Equal(-1))
+	})
+
+	ginkgo.It("should record the allocations in the sanitizer", func() {
+		driver.sanitizer = sanitizer.NewSanitizer()
+		context := driver.Init()
+
+		ptr := driver.AllocateMemory(context, 64)
+
+		kind, invalid := driver.sanitizer.Check(context.pid, uint64(ptr), 4, true)
+		Expect(invalid).To(BeFalse())
+		kind, invalid = driver.sanitizer.Check(context.pid, uint64(ptr)+60, 8, true)
+		Expect(invalid).To(BeTrue())
+		Expect(kind).To(Equal(sanitizer.OutOfBounds))
+
+		err := driver.FreeMemory(context, ptr)
+		Expect(err).NotTo(HaveOccurred())
+
+		kind, invalid = driver.sanitizer.Check(context.pid, uint64(ptr), 4, true)
+		Expect(invalid).To(BeTrue())
+		Expect(kind).To(Equal(sanitizer.UseAfterFree))
+	})
+
 	// ginkgo.Measure("Memory allocation", func(b ginkgo.Benchmarker) {
 	// 	context := driver.Init()
 	// 	b.Time("runtime", func() {
@@ -4,8 +4,10 @@ import (
 	"github.com/sarchlab/akita/v4/mem/mem"
 	"github.com/sarchlab/akita/v4/mem/vm"
 	"github.com/sarchlab/akita/v4/sim"
+	"github.com/sarchlab/mgpusim/v4/amd/cosim"
 	"github.com/sarchlab/mgpusim/v4/amd/driver/internal"
 	"github.com/sarchlab/mgpusim/v4/amd/insts"
+	"github.com/sarchlab/mgpusim/v4/amd/sanitizer"
 )
 
 // A Builder can build a driver.
@@ -18,6 +20,13 @@ type Builder struct {
 	useMagicMemoryCopy  bool
 	middlewareD2HCycles int
 	middlewareH2DCycles int
+	placementPolicy     PlacementPolicy
+	checkpointDir       string
+	checkpointInterval  int
+	restore             bool
+	kernelSelector      KernelSelector
+	sanitizer           *sanitizer.Sanitizer
+	coSimChecker        *cosim.Checker
 }
 
 // MakeBuilder creates a driver builder with some default configuration
@@ -75,6 +84,77 @@ func (b Builder) WithH2DCycles(h2dCycles int) Builder {
 	return b
 }
 
+// WithPlacementPolicy sets the policy that decides which GPUs hold the pages
+// of distributed memory and unified memory. Without a policy, distributed
+// memory is partitioned in blocks and unified memory stays on the first GPU.
+// Unified pages that the policy places on a GPU are pinned to the GPU, and
+// only the pages left to the first touch are migrated.
+func (b Builder) WithPlacementPolicy(p PlacementPolicy) Builder {
+	b.placementPolicy = p
+	return b
+}
+
+// WithCheckpointDir sets the directory that the driver writes checkpoints to
+// and restores checkpoints from. A checkpoint only holds the state of the
+// driver and the memory, not the state of the compute units, caches, and
+// TLBs.
+func (b Builder) WithCheckpointDir(dir string) Builder {
+	b.checkpointDir = dir
+	return b
+}
+
+// WithCheckpointInterval writes a checkpoint after every numKernels kernels
+// complete. The GPU caches are flushed before each checkpoint. Zero, the
+// default, writes no checkpoints.
+func (b Builder) WithCheckpointInterval(numKernels int) Builder {
+	b.checkpointInterval = numKernels
+	return b
+}
+
+// WithRestore resumes the simulation from the checkpoint in the checkpoint
+// directory. The program must run again with the same arguments. The driver
+// skips the commands that completed before the checkpoint and restores the
+// memory when the program issues the first command after the checkpoint.
+func (b Builder) WithRestore() Builder {
+	b.restore = true
+	return b
+}
+
+// WithFastForward runs the first numKernels kernels on emulated GPUs, which
+// must be registered with RegisterFastForwardGPU. Fast-forwarding requires
+// the global storage.
+func (b Builder) WithFastForward(numKernels int) Builder {
+	if numKernels > 0 {
+		b.kernelSelector = startKernelSelector(numKernels)
+	}
+
+	return b
+}
+
+// WithKernelSelector runs the kernels that the selector does not pick on
+// emulated GPUs, like WithFastForward.
+func (b Builder) WithKernelSelector(s KernelSelector) Builder {
+	b.kernelSelector = s
+	return b
+}
+
+// WithSanitizer records the allocations, the frees, and the memory copies in
+// the sanitizer, so that the emulated GPUs can check the memory accesses of
+// the kernels.
+func (b Builder) WithSanitizer(s *sanitizer.Sanitizer) Builder {
+	b.sanitizer = s
+	return b
+}
+
+// WithCoSimulation runs each kernel on an emulated GPU before the simulated
+// GPU and lets the checker compare the two runs. The emulated GPUs must be
+// registered with RegisterCoSimGPU. Co-simulation requires the global storage
+// and cannot be combined with fast-forwarding.
+func (b Builder) WithCoSimulation(checker *cosim.Checker) Builder {
+	b.coSimChecker = checker
+	return b
+}
+
 // Build creates a driver.
 func (b Builder) Build(name string) *Driver {
 	driver := new(Driver)
@@ -88,10 +168,15 @@ func (b Builder) Build(name string) *Driver {
 
 	distributorImpl := newDistributorImpl(memAllocatorImpl)
 	distributorImpl.pageSizeAsPowerOf2 = b.log2PageSize
+	if b.placementPolicy != nil {
+		distributorImpl.policy = b.placementPolicy
+		driver.placeUnifiedMemory = true
+	}
 	driver.distributor = distributorImpl
 
 	driver.pageTable = b.pageTable
 	driver.globalStorage = b.globalStorage
+	driver.sanitizer = b.sanitizer
 
 	if b.useMagicMemoryCopy {
 		globalStorageMemoryCopyMiddleware := &globalStorageMemoryCopyMiddleware{
@@ -116,6 +201,39 @@ func (b Builder) Build(name string) *Driver {
 	driver.driverStopped = make(chan bool)
 	driver.codeObjGPUAddrs = make(map[*insts.KernelCodeObject]Ptr)
 
+	if b.restore && b.checkpointDir == "" {
+		panic("restoring requires a checkpoint directory")
+	}
+
+	if b.checkpointInterval > 0 && b.checkpointDir == "" {
+		panic("checkpointing requires a checkpoint directory")
+	}
+
+	if b.checkpointDir != "" {
+		driver.checkpointer = newCheckpointer(
+			driver, b.checkpointDir, b.checkpointInterval, b.restore)
+	}
+
+	if b.kernelSelector != nil {
+		if b.globalStorage == nil {
+			panic("fast-forwarding requires the global storage")
+		}
+
+		driver.fastForwarder = newFastForwarder(driver, b.kernelSelector)
+	}
+
+	if b.coSimChecker != nil {
+		if b.globalStorage == nil {
+			panic("co-simulation requires the global storage")
+		}
+
+		if b.kernelSelector != nil {
+			panic("co-simulation cannot be combined with fast-forwarding")
+		}
+
+		driver.coSimulator = newCoSimulator(driver, b.coSimChecker)
+	}
+
 	b.createCPU(driver)
 
 	return driver
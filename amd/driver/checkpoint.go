@@ -0,0 +1,494 @@
+package driver
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/sarchlab/akita/v4/mem/vm"
+	"github.com/sarchlab/akita/v4/sim"
+	"github.com/sarchlab/mgpusim/v4/amd/protocol"
+)
+
+const checkpointFileName = "checkpoint.gob"
+
+// maxCheckpointD2HBytes bounds the device-to-host data that the checkpointer
+// keeps for replaying. The checkpointer stops taking checkpoints once the
+// program copies more data than this back to the host.
+const maxCheckpointD2HBytes = 1 << 30
+
+// A checkpoint is the state that the driver owns at a kernel boundary: the
+// simulated time, the progress of the command queues, and the content and
+// placement of the allocated pages. It does not include the state of the host
+// program. Instead, the host program runs again when restoring, and the driver
+// replays the commands that the host program issued before the checkpoint
+// without simulating them.
+//
+// Checkpoints are limited to the driver and the memory. The state of the GPU
+// components, such as the compute units, the caches, and the TLBs, is not
+// saved. A restored simulation produces the same results, but its caches and
+// TLBs start cold, so the kernels right after the checkpoint can take longer
+// than in an uninterrupted run.
+type checkpoint struct {
+	Time       sim.VTimeInSec
+	NumKernels int
+	Queues     []queueCheckpoint
+	Pages      []pageCheckpoint
+}
+
+// A queueCheckpoint records the commands that a command queue has completed.
+type queueCheckpoint struct {
+	NumCommands int
+	D2HData     [][]byte
+}
+
+// A pageCheckpoint records the content of a page and the device that holds
+// it, which changes when the page migrates.
+type pageCheckpoint struct {
+	PID      vm.PID
+	VAddr    uint64
+	DeviceID uint64
+	Data     []byte
+}
+
+// A queueRecord tracks the progress of a command queue.
+type queueRecord struct {
+	queue       *CommandQueue
+	index       int
+	numCommands int
+	d2hCmds     []*MemCopyD2HCommand
+	numReplayed int
+}
+
+// A checkpointer writes a checkpoint when the driver becomes idle after every
+// interval kernels. Before writing a checkpoint, it flushes the GPU caches so
+// that the storage holds the latest data. The flushes only happen at the
+// kernels that are checkpointed. An interval of 0 disables writing
+// checkpoints, which is used when only restoring.
+type checkpointer struct {
+	driver   *Driver
+	dir      string
+	interval int
+
+	queueMutex sync.Mutex
+	queues     []*queueRecord
+	records    map[*CommandQueue]*queueRecord
+	numKernels int
+	due        bool
+	flushReqs  []sim.Msg
+	d2hBytes   int
+	disabled   bool
+
+	restoreFrom *checkpoint
+	resumeTime  sim.VTimeInSec
+}
+
+func newCheckpointer(
+	d *Driver,
+	dir string,
+	interval int,
+	restore bool,
+) *checkpointer {
+	c := &checkpointer{
+		driver:     d,
+		dir:        dir,
+		interval:   interval,
+		disabled:   interval == 0,
+		resumeTime: -1,
+		records:    make(map[*CommandQueue]*queueRecord),
+	}
+
+	if restore {
+		c.restoreFrom = readCheckpoint(dir)
+	}
+
+	return c
+}
+
+func readCheckpoint(dir string) *checkpoint {
+	f, err := os.Open(filepath.Join(dir, checkpointFileName))
+	if err != nil {
+		log.Panicf("cannot open checkpoint: %v", err)
+	}
+	defer f.Close()
+
+	cp := &checkpoint{}
+	err = gob.NewDecoder(f).Decode(cp)
+	if err != nil {
+		log.Panicf("cannot decode checkpoint: %v", err)
+	}
+
+	return cp
+}
+
+// registerQueue tracks a new command queue. The queues are identified by the
+// order that they are created in, which does not change when the program runs
+// again.
+func (c *checkpointer) registerQueue(q *CommandQueue) {
+	c.queueMutex.Lock()
+	defer c.queueMutex.Unlock()
+
+	r := &queueRecord{queue: q, index: len(c.queues)}
+	c.queues = append(c.queues, r)
+	c.records[q] = r
+}
+
+func (c *checkpointer) recordOf(q *CommandQueue) *queueRecord {
+	c.queueMutex.Lock()
+	defer c.queueMutex.Unlock()
+
+	r, found := c.records[q]
+	if !found {
+		panic("command queue not registered")
+	}
+
+	return r
+}
+
+// processCommand replays the commands issued before the checkpoint to restore
+// and blocks new commands while a checkpoint is being taken. It returns
+// handled as false if the driver should process the command as usual.
+func (c *checkpointer) processCommand(
+	cmd Command,
+	q *CommandQueue,
+) (handled, madeProgress bool) {
+	if len(c.flushReqs) > 0 {
+		return true, false
+	}
+
+	if c.resumeTime > c.driver.CurrentTime() {
+		return true, false
+	}
+
+	if c.restoreFrom == nil {
+		return false, false
+	}
+
+	r := c.recordOf(q)
+	if r.numCommands < c.numCommandsToReplay(r) {
+		c.replay(cmd, r)
+		return true, true
+	}
+
+	if !c.allQueuesReplayed() {
+		return true, false
+	}
+
+	c.restore()
+
+	return true, true
+}
+
+func (c *checkpointer) numCommandsToReplay(r *queueRecord) int {
+	if r.index < len(c.restoreFrom.Queues) {
+		return c.restoreFrom.Queues[r.index].NumCommands
+	}
+
+	return 0
+}
+
+func (c *checkpointer) allQueuesReplayed() bool {
+	c.queueMutex.Lock()
+	defer c.queueMutex.Unlock()
+
+	if len(c.queues) < len(c.restoreFrom.Queues) {
+		return false
+	}
+
+	for _, r := range c.queues {
+		if r.numCommands < c.numCommandsToReplay(r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (c *checkpointer) replay(cmd Command, r *queueRecord) {
+	switch cmd := cmd.(type) {
+	case *MemCopyD2HCommand:
+		cmd.RawData = c.restoreFrom.Queues[r.index].D2HData[r.numReplayed]
+		r.numReplayed++
+
+		err := binary.Read(
+			bytes.NewReader(cmd.RawData), binary.LittleEndian, cmd.Dst)
+		if err != nil {
+			panic(err)
+		}
+
+		r.d2hCmds = append(r.d2hCmds, cmd)
+	case *LaunchKernelCommand, *LaunchUnifiedMultiGPUKernelCommand:
+		c.numKernels++
+	}
+
+	r.numCommands++
+	r.queue.Dequeue()
+}
+
+// restore moves the pages back to the devices that held them at the
+// checkpoint, writes the memory content of the checkpoint back to the storage,
+// and moves the simulation time to the time of the checkpoint.
+func (c *checkpointer) restore() {
+	for _, p := range c.restoreFrom.Pages {
+		page := c.restorePagePlacement(p)
+
+		err := c.driver.globalStorage.Write(page.PAddr, p.Data)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	log.Printf("Restored from checkpoint after %d kernels at %.9f s. "+
+		"The caches and TLBs start cold.\n",
+		c.restoreFrom.NumKernels, c.restoreFrom.Time)
+
+	if c.restoreFrom.Time > c.driver.CurrentTime() {
+		c.resumeTime = c.restoreFrom.Time
+		c.driver.Engine.Schedule(
+			sim.MakeTickEvent(c.driver, c.restoreFrom.Time))
+	}
+
+	c.restoreFrom = nil
+}
+
+// restorePagePlacement moves a page to the device that held the page at the
+// checkpoint, in case the page migrated before the checkpoint.
+func (c *checkpointer) restorePagePlacement(p pageCheckpoint) vm.Page {
+	page, found := c.driver.pageTable.Find(p.PID, p.VAddr)
+	if !found {
+		log.Panicf("the checkpoint does not match the program, "+
+			"page 0x%x of process %d is not allocated", p.VAddr, p.PID)
+	}
+
+	if page.DeviceID == p.DeviceID {
+		return page
+	}
+
+	if p.DeviceID == 0 {
+		c.driver.memAllocator.DeferPlacement(p.PID, p.VAddr, page.PageSize)
+	} else {
+		c.driver.memAllocator.Remap(
+			p.PID, p.VAddr, page.PageSize, int(p.DeviceID))
+	}
+
+	page, _ = c.driver.pageTable.Find(p.PID, p.VAddr)
+
+	return page
+}
+
+func (c *checkpointer) commandStarted(cmd Command, q *CommandQueue) {
+	r := c.recordOf(q)
+	r.numCommands++
+
+	if c.disabled {
+		return
+	}
+
+	d2hCmd, ok := cmd.(*MemCopyD2HCommand)
+	if !ok {
+		return
+	}
+
+	r.d2hCmds = append(r.d2hCmds, d2hCmd)
+	c.d2hBytes += max(binary.Size(d2hCmd.Dst), 0)
+
+	if c.d2hBytes > maxCheckpointD2HBytes {
+		log.Printf("No more checkpoints are taken, since the program has "+
+			"copied more than %d bytes back to the host.\n",
+			maxCheckpointD2HBytes)
+		c.disable()
+	}
+}
+
+// disable stops taking checkpoints and releases the data kept for them.
+func (c *checkpointer) disable() {
+	c.disabled = true
+	c.due = false
+
+	c.queueMutex.Lock()
+	defer c.queueMutex.Unlock()
+
+	for _, r := range c.queues {
+		r.d2hCmds = nil
+	}
+}
+
+func (c *checkpointer) kernelCompleted() {
+	c.numKernels++
+
+	if !c.disabled && c.numKernels%c.interval == 0 {
+		c.due = true
+	}
+}
+
+// Tick takes a checkpoint when it is due and the driver is idle.
+func (c *checkpointer) Tick() bool {
+	if len(c.flushReqs) > 0 {
+		return c.processFlushRsp()
+	}
+
+	if !c.due || !c.driverIsIdle() {
+		return false
+	}
+
+	c.due = false
+
+	for _, gpu := range c.driver.GPUs {
+		req := protocol.NewFlushReq(c.driver.gpuPort, gpu)
+		c.driver.requestsToSend = append(c.driver.requestsToSend, req)
+		c.flushReqs = append(c.flushReqs, req)
+	}
+
+	return true
+}
+
+func (c *checkpointer) driverIsIdle() bool {
+	if len(c.driver.requestsToSend) > 0 ||
+		c.driver.isCurrentlyHandlingMigrationReq {
+		return false
+	}
+
+	c.queueMutex.Lock()
+	defer c.queueMutex.Unlock()
+
+	for _, r := range c.queues {
+		if r.queue.IsRunning {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (c *checkpointer) processFlushRsp() bool {
+	rsp, ok := c.driver.gpuPort.PeekIncoming().(*sim.GeneralRsp)
+	if !ok {
+		return false
+	}
+
+	for i, req := range c.flushReqs {
+		if req != rsp.OriginalReq {
+			continue
+		}
+
+		c.driver.gpuPort.RetrieveIncoming()
+		c.flushReqs = append(c.flushReqs[:i], c.flushReqs[i+1:]...)
+
+		if len(c.flushReqs) == 0 {
+			c.write()
+		}
+
+		return true
+	}
+
+	return false
+}
+
+func (c *checkpointer) write() {
+	cp := &checkpoint{
+		Time:       c.driver.CurrentTime(),
+		NumKernels: c.numKernels,
+	}
+
+	c.queueMutex.Lock()
+	for _, r := range c.queues {
+		q := queueCheckpoint{NumCommands: r.numCommands}
+		for _, cmd := range r.d2hCmds {
+			q.D2HData = append(q.D2HData, cmd.RawData)
+		}
+
+		cp.Queues = append(cp.Queues, q)
+	}
+	c.queueMutex.Unlock()
+
+	cp.Pages = c.collectPages()
+
+	c.writeFile(cp)
+}
+
+func (c *checkpointer) collectPages() []pageCheckpoint {
+	pages := make([]pageCheckpoint, 0)
+	visited := make(map[vm.PID]map[uint64]bool)
+	pageSize := uint64(1) << c.driver.Log2PageSize
+
+	c.driver.contextMutex.Lock()
+	defer c.driver.contextMutex.Unlock()
+
+	for _, ctx := range c.driver.contexts {
+		if visited[ctx.pid] == nil {
+			visited[ctx.pid] = make(map[uint64]bool)
+		}
+
+		for _, b := range ctx.buffers {
+			if b.freed {
+				continue
+			}
+
+			start := uint64(b.vAddr) &^ (pageSize - 1)
+			end := uint64(b.vAddr) + b.size
+			for vAddr := start; vAddr < end; vAddr += pageSize {
+				if visited[ctx.pid][vAddr] {
+					continue
+				}
+				visited[ctx.pid][vAddr] = true
+
+				pages = append(pages, c.collectPage(ctx.pid, vAddr))
+			}
+		}
+	}
+
+	return pages
+}
+
+func (c *checkpointer) collectPage(pid vm.PID, vAddr uint64) pageCheckpoint {
+	page, found := c.driver.pageTable.Find(pid, vAddr)
+	if !found {
+		panic("page not found")
+	}
+
+	data, err := c.driver.globalStorage.Read(page.PAddr, page.PageSize)
+	if err != nil {
+		panic(err)
+	}
+
+	return pageCheckpoint{
+		PID:      pid,
+		VAddr:    vAddr,
+		DeviceID: page.DeviceID,
+		Data:     data,
+	}
+}
+
+// writeFile writes the checkpoint to a temporary file first, so that a crash
+// while writing does not destroy the previous checkpoint.
+func (c *checkpointer) writeFile(cp *checkpoint) {
+	err := os.MkdirAll(c.dir, 0755)
+	if err != nil {
+		log.Panicf("cannot create checkpoint directory: %v", err)
+	}
+
+	path := filepath.Join(c.dir, checkpointFileName)
+	f, err := os.Create(path + ".tmp")
+	if err != nil {
+		log.Panicf("cannot create checkpoint: %v", err)
+	}
+
+	err = gob.NewEncoder(f).Encode(cp)
+	if err != nil {
+		log.Panicf("cannot encode checkpoint: %v", err)
+	}
+
+	err = f.Close()
+	if err != nil {
+		log.Panicf("cannot write checkpoint: %v", err)
+	}
+
+	err = os.Rename(path+".tmp", path)
+	if err != nil {
+		log.Panicf("cannot write checkpoint: %v", err)
+	}
+}
@@ -0,0 +1,224 @@
+package driver
+
+import (
+	"github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/akita/v4/mem/mem"
+	"github.com/sarchlab/akita/v4/mem/vm"
+	"github.com/sarchlab/akita/v4/sim"
+	"github.com/sarchlab/mgpusim/v4/amd/protocol"
+)
+
+var _ = ginkgo.Describe("Checkpointer", func() {
+	var (
+		dir     string
+		storage *mem.Storage
+		driver  *Driver
+		ctx     *Context
+		c       *checkpointer
+	)
+
+	ginkgo.BeforeEach(func() {
+		dir = ginkgo.GinkgoT().TempDir()
+		storage = mem.NewStorage(16 * mem.GB)
+
+		driver = MakeBuilder().
+			WithEngine(sim.NewSerialEngine()).
+			WithLog2PageSize(12).
+			WithPageTable(vm.NewPageTable(12)).
+			WithGlobalStorage(storage).
+			WithMagicMemoryCopyMiddleware().
+			WithCheckpointDir(dir).
+			WithCheckpointInterval(1).
+			Build("Driver")
+		driver.RegisterGPU(
+			sim.NewPort(driver, 1, 1, "GPU"),
+			DeviceProperties{CUCount: 4, DRAMSize: 4 * mem.GB},
+		)
+
+		ctx = driver.Init()
+		c = driver.checkpointer
+	})
+
+	ginkgo.It("should flush the GPUs after a kernel completes", func() {
+		driver.CreateCommandQueue(ctx)
+
+		c.kernelCompleted()
+		madeProgress := c.Tick()
+
+		Expect(madeProgress).To(BeTrue())
+		Expect(driver.requestsToSend).To(HaveLen(1))
+		Expect(driver.requestsToSend[0]).To(BeAssignableToTypeOf(
+			&protocol.FlushReq{}))
+	})
+
+	ginkgo.It("should only checkpoint at the interval", func() {
+		driver.CreateCommandQueue(ctx)
+		c.interval = 2
+
+		c.kernelCompleted()
+		Expect(c.Tick()).To(BeFalse())
+
+		c.kernelCompleted()
+		Expect(c.Tick()).To(BeTrue())
+		Expect(driver.requestsToSend).To(HaveLen(1))
+	})
+
+	ginkgo.It("should stop checkpointing after copying too much data", func() {
+		q := driver.CreateCommandQueue(ctx)
+		cmd := &MemCopyD2HCommand{Dst: make([]byte, 4)}
+		c.d2hBytes = maxCheckpointD2HBytes
+
+		c.commandStarted(cmd, q)
+		c.kernelCompleted()
+
+		Expect(c.Tick()).To(BeFalse())
+		Expect(driver.requestsToSend).To(BeEmpty())
+		Expect(c.recordOf(q).d2hCmds).To(BeEmpty())
+	})
+
+	ginkgo.It("should not checkpoint while a queue is running", func() {
+		q := driver.CreateCommandQueue(ctx)
+		q.IsRunning = true
+
+		c.kernelCompleted()
+
+		Expect(c.Tick()).To(BeFalse())
+		Expect(driver.requestsToSend).To(BeEmpty())
+	})
+
+	ginkgo.It("should block new commands while flushing", func() {
+		q := driver.CreateCommandQueue(ctx)
+		q.Enqueue(&NoopCommand{})
+
+		c.kernelCompleted()
+		c.Tick()
+
+		Expect(driver.processOneCommand(q)).To(BeFalse())
+		Expect(q.NumCommand()).To(Equal(1))
+	})
+
+	ginkgo.It("should write and restore the memory content", func() {
+		ptr := driver.AllocateMemory(ctx, 0x2000)
+
+		q := driver.CreateCommandQueue(ctx)
+		q.Enqueue(&MemCopyH2DCommand{Dst: ptr, Src: []byte{1, 2, 3, 4}})
+		q.Enqueue(&MemCopyD2HCommand{Dst: make([]byte, 4), Src: ptr})
+		driver.processOneCommand(q)
+		driver.processOneCommand(q)
+
+		c.write()
+		cp := readCheckpoint(dir)
+
+		Expect(cp.Queues).To(HaveLen(1))
+		Expect(cp.Queues[0].NumCommands).To(Equal(2))
+		Expect(cp.Queues[0].D2HData).To(Equal([][]byte{{1, 2, 3, 4}}))
+		Expect(cp.Pages).To(HaveLen(2))
+		Expect(cp.Pages[0].VAddr).To(Equal(uint64(ptr)))
+		Expect(cp.Pages[0].DeviceID).To(Equal(uint64(1)))
+		Expect(cp.Pages[0].Data[:4]).To(Equal([]byte{1, 2, 3, 4}))
+	})
+
+	ginkgo.Context("when restoring", func() {
+		var (
+			ptr  Ptr
+			page vm.Page
+		)
+
+		ginkgo.BeforeEach(func() {
+			ptr = driver.AllocateMemory(ctx, 0x1000)
+			page, _ = driver.pageTable.Find(ctx.pid, uint64(ptr))
+
+			data := make([]byte, 0x1000)
+			data[0] = 9
+
+			c.restoreFrom = &checkpoint{
+				Time: 2,
+				Queues: []queueCheckpoint{
+					{NumCommands: 2, D2HData: [][]byte{{5, 6}}},
+				},
+				Pages: []pageCheckpoint{
+					{PID: ctx.pid, VAddr: page.VAddr, DeviceID: 1, Data: data},
+				},
+			}
+		})
+
+		ginkgo.It("should replay the completed commands", func() {
+			q := driver.CreateCommandQueue(ctx)
+			dst := make([]byte, 2)
+			q.Enqueue(&NoopCommand{})
+			q.Enqueue(&MemCopyD2HCommand{Dst: dst, Src: ptr})
+
+			Expect(driver.processOneCommand(q)).To(BeTrue())
+			Expect(driver.processOneCommand(q)).To(BeTrue())
+
+			Expect(q.NumCommand()).To(Equal(0))
+			Expect(dst).To(Equal([]byte{5, 6}))
+		})
+
+		ginkgo.It("should wait for all the queues to replay", func() {
+			q1 := driver.CreateCommandQueue(ctx)
+			q1.Enqueue(&NoopCommand{})
+			q1.Enqueue(&NoopCommand{})
+			q1.Enqueue(&NoopCommand{})
+			driver.processOneCommand(q1)
+			driver.processOneCommand(q1)
+
+			c.restoreFrom.Queues = append(c.restoreFrom.Queues,
+				queueCheckpoint{NumCommands: 1})
+
+			Expect(driver.processOneCommand(q1)).To(BeFalse())
+			Expect(q1.NumCommand()).To(Equal(1))
+		})
+
+		ginkgo.It("should restore the memory before the next command", func() {
+			q := driver.CreateCommandQueue(ctx)
+			q.Enqueue(&NoopCommand{})
+			q.Enqueue(&MemCopyD2HCommand{Dst: make([]byte, 2), Src: ptr})
+			q.Enqueue(&NoopCommand{})
+			driver.processOneCommand(q)
+			driver.processOneCommand(q)
+
+			Expect(driver.processOneCommand(q)).To(BeTrue())
+
+			data, _ := storage.Read(page.PAddr, 1)
+			Expect(data).To(Equal([]byte{9}))
+			Expect(c.restoreFrom).To(BeNil())
+			Expect(q.NumCommand()).To(Equal(1))
+		})
+
+		ginkgo.It("should move migrated pages back to their device", func() {
+			driver.RegisterGPU(
+				sim.NewPort(driver, 1, 1, "GPU"),
+				DeviceProperties{CUCount: 4, DRAMSize: 4 * mem.GB},
+			)
+			c.restoreFrom.Pages[0].DeviceID = 2
+
+			q := driver.CreateCommandQueue(ctx)
+			q.Enqueue(&NoopCommand{})
+			q.Enqueue(&MemCopyD2HCommand{Dst: make([]byte, 2), Src: ptr})
+			q.Enqueue(&NoopCommand{})
+			driver.processOneCommand(q)
+			driver.processOneCommand(q)
+			driver.processOneCommand(q)
+
+			restored, _ := driver.pageTable.Find(ctx.pid, uint64(ptr))
+			data, _ := storage.Read(restored.PAddr, 1)
+			Expect(restored.DeviceID).To(Equal(uint64(2)))
+			Expect(data).To(Equal([]byte{9}))
+		})
+
+		ginkgo.It("should not run commands before the checkpoint time", func() {
+			q := driver.CreateCommandQueue(ctx)
+			q.Enqueue(&NoopCommand{})
+			q.Enqueue(&MemCopyD2HCommand{Dst: make([]byte, 2), Src: ptr})
+			q.Enqueue(&NoopCommand{})
+			driver.processOneCommand(q)
+			driver.processOneCommand(q)
+			driver.processOneCommand(q)
+
+			Expect(driver.processOneCommand(q)).To(BeFalse())
+			Expect(q.NumCommand()).To(Equal(1))
+		})
+	})
+})
@@ -86,6 +86,16 @@ type LaunchKernelCommand struct {
 	Packet     *kernels.HsaKernelDispatchPacket
 	DPacket    Ptr
 	Reqs       []sim.Msg
+
+	// DScratch and ScratchByteSize locate the scratch segment that holds
+	// the private memory of the kernel. The size is 0 if the kernel does not
+	// use private memory.
+	DScratch        Ptr
+	ScratchByteSize uint64
+
+	// replacedScratch holds the scratch segments that the kernel replaces,
+	// which are freed when the kernel starts.
+	replacedScratch []Ptr
 }
 
 // GetID returns the ID of the command
@@ -136,6 +146,34 @@ func (c *FlushCommand) RemoveReq(req sim.Msg) {
 	c.Reqs = removeMsgFromMsgList(req, c.Reqs)
 }
 
+// A RecordEventCommand is a command that records the time when all the
+// commands enqueued before it complete.
+type RecordEventCommand struct {
+	ID    string
+	Event *Event
+}
+
+// GetID returns the ID of the command
+func (c *RecordEventCommand) GetID() string {
+	return c.ID
+}
+
+// GetReqs returns the request associated with the command
+func (c *RecordEventCommand) GetReqs() []sim.Msg {
+	return nil
+}
+
+// AddReq adds a request to the request list associated with the command
+func (c *RecordEventCommand) AddReq(req sim.Msg) {
+	// No action
+}
+
+// RemoveReq removes a request from the request list associated with the
+// command.
+func (c *RecordEventCommand) RemoveReq(req sim.Msg) {
+	// no action
+}
+
 // A NoopCommand is a command that does not do anything. It is used for testing
 // purposes.
 type NoopCommand struct {
@@ -184,6 +222,15 @@ type LaunchUnifiedMultiGPUKernelCommand struct {
 	PacketArray  []*kernels.HsaKernelDispatchPacket
 	DPacketArray []Ptr
 	Reqs         []sim.Msg
+
+	// DScratchArray and ScratchByteSizeArray locate the scratch segment of
+	// each GPU. The size is 0 if the kernel does not use private memory.
+	DScratchArray        []Ptr
+	ScratchByteSizeArray []uint64
+
+	// replacedScratch holds the scratch segments that the kernel replaces,
+	// which are freed when the kernel starts.
+	replacedScratch []Ptr
 }
 
 // GetID returns the ID of the command
@@ -4,6 +4,7 @@ import (
 	"sync"
 
 	"github.com/sarchlab/akita/v4/mem/vm"
+	"github.com/sarchlab/mgpusim/v4/amd/protocol"
 )
 
 // A CommandQueue maintains a queue of command where the commands from the
@@ -16,9 +17,19 @@ type CommandQueue struct {
 
 	commandsMutex sync.Mutex
 	commands      []Command
+	fault         *protocol.MemoryFault
 
 	listenerMutex sync.Mutex
 	listeners     []*CommandQueueStatusListener
+
+	scratch map[int]scratchSegment
+}
+
+// A scratchSegment holds the private memory of the kernels that a command
+// queue launches on a GPU.
+type scratchSegment struct {
+	ptr  Ptr
+	size uint64
 }
 
 // Subscribe returns a CommandQueueStatusListener that listens to the update
@@ -100,6 +111,28 @@ func (q *CommandQueue) NumCommand() int {
 	return l
 }
 
+// Err returns the first memory fault that the commands in the queue cause.
+// The queue keeps executing the commands after a fault.
+func (q *CommandQueue) Err() error {
+	q.commandsMutex.Lock()
+	defer q.commandsMutex.Unlock()
+
+	if q.fault == nil {
+		return nil
+	}
+
+	return q.fault
+}
+
+func (q *CommandQueue) recordFault(fault *protocol.MemoryFault) {
+	q.commandsMutex.Lock()
+	defer q.commandsMutex.Unlock()
+
+	if q.fault == nil {
+		q.fault = fault
+	}
+}
+
 // Enqueue adds a command to a command queue and triggers GPUs to start to
 // consume the command.
 func (d *Driver) Enqueue(q *CommandQueue, c Command) {
@@ -15,6 +15,10 @@ type buffer struct {
 	// to this buffer. Therefore, copying from or to this buffer triggers L2
 	// flushing.
 	l2Dirty bool
+
+	// populated marks that the buffer may hold data, because data has been
+	// copied into it or a kernel has been launched since it was allocated.
+	populated bool
 }
 
 // Context is an opaque struct that carries the information used by the driver.
@@ -27,7 +31,8 @@ type Context struct {
 	queueMutex sync.Mutex
 	queues     []*CommandQueue
 
-	buffers []*buffer
+	buffers    []*buffer
+	memAdvices []memAdviceRange
 }
 
 func (c *Context) markAllBuffersDirty() {
@@ -42,6 +47,37 @@ func (c *Context) markAllBuffersClean() {
 	}
 }
 
+func (c *Context) markAllBuffersPopulated() {
+	for _, b := range c.buffers {
+		b.populated = true
+	}
+}
+
+func (c *Context) markBufferPopulated(ptr Ptr) {
+	for _, b := range c.buffers {
+		if ptr >= b.vAddr && uint64(ptr) < uint64(b.vAddr)+b.size {
+			b.populated = true
+		}
+	}
+}
+
+// isPopulated checks if any buffer that overlaps the address range may hold
+// data.
+func (c *Context) isPopulated(vAddr, byteSize uint64) bool {
+	for _, b := range c.buffers {
+		if b.freed || !b.populated {
+			continue
+		}
+
+		bufStart := uint64(b.vAddr)
+		if memRangeOverlap(bufStart, bufStart+b.size, vAddr, vAddr+byteSize) {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (c *Context) removeFreedBuffers() {
 	for i, b := range c.buffers {
 		if b.freed {
@@ -0,0 +1,202 @@
+package driver
+
+import (
+	"log"
+
+	"github.com/sarchlab/akita/v4/sim"
+	"github.com/sarchlab/mgpusim/v4/amd/cosim"
+	"github.com/sarchlab/mgpusim/v4/amd/protocol"
+)
+
+type coSimPhase int
+
+const (
+	coSimIdle coSimPhase = iota
+	coSimEmulating
+	coSimSimulating
+	coSimFlushing
+)
+
+// A coSimulator runs each kernel twice, first on the emulated GPUs and then
+// on the GPUs registered with RegisterGPU, and lets the checker compare the
+// two runs. The emulated GPUs share the storage and the page table with the
+// simulated GPUs. Memory copies access the storage directly, and the
+// coSimulator flushes and invalidates the caches of the simulated GPUs after
+// each kernel, so both runs start from the same memory. Other commands wait
+// while a kernel is being co-simulated.
+type coSimulator struct {
+	driver  *Driver
+	checker *cosim.Checker
+
+	emulatedGPUs      []sim.Port
+	memCopyMiddleware Middleware
+
+	phase     coSimPhase
+	faulted   bool
+	cmd       Command
+	cmdQueue  *CommandQueue
+	flushReqs []sim.Msg
+}
+
+func newCoSimulator(d *Driver, checker *cosim.Checker) *coSimulator {
+	return &coSimulator{
+		driver:  d,
+		checker: checker,
+		memCopyMiddleware: &globalStorageMemoryCopyMiddleware{
+			driver: d,
+		},
+	}
+}
+
+// RegisterCoSimGPU tells the driver about the command processor of an
+// emulated GPU. The emulated GPUs run the kernels before the GPUs registered
+// with RegisterGPU, in the same order.
+func (d *Driver) RegisterCoSimGPU(commandProcessorPort sim.Port) {
+	if d.coSimulator == nil {
+		panic("co-simulation is not enabled")
+	}
+
+	d.coSimulator.emulatedGPUs = append(
+		d.coSimulator.emulatedGPUs, commandProcessorPort)
+}
+
+// CoSimChecker returns the checker that compares the emulated and the
+// simulated runs of the kernels, or nil if co-simulation is not enabled.
+func (d *Driver) CoSimChecker() *cosim.Checker {
+	if d.coSimulator == nil {
+		return nil
+	}
+
+	return d.coSimulator.checker
+}
+
+// canStart returns false while a kernel is being co-simulated.
+func (c *coSimulator) canStart(_ Command) bool {
+	return c.phase == coSimIdle
+}
+
+func (c *coSimulator) commandStarted(cmd Command, cmdQueue *CommandQueue) {
+	if !isKernelCommand(cmd) {
+		return
+	}
+
+	c.checker.StartKernel()
+
+	c.phase = coSimEmulating
+	c.faulted = false
+	c.cmd = cmd
+	c.cmdQueue = cmdQueue
+}
+
+// isEmulating returns true if the next kernel launch runs on the emulated
+// GPUs. The driver launches a kernel before it calls commandStarted, so the
+// launches outside of the timing phase are all emulated.
+func (c *coSimulator) isEmulating() bool {
+	return c.phase != coSimSimulating
+}
+
+func (c *coSimulator) gpuToLaunchKernel(gpuID int) sim.Port {
+	if gpuID > len(c.emulatedGPUs) {
+		log.Panicf("GPU %d does not have an emulated GPU", gpuID)
+	}
+
+	return c.emulatedGPUs[gpuID-1]
+}
+
+// kernelReqCompleted is called when a request of the co-simulated kernel
+// returns. It returns true if the kernel command completes.
+func (c *coSimulator) kernelReqCompleted(
+	rsp *protocol.LaunchKernelRsp,
+	cmd Command,
+) bool {
+	if rsp.Fault != nil {
+		c.faulted = true
+	}
+
+	if len(cmd.GetReqs()) > 0 {
+		return false
+	}
+
+	switch c.phase {
+	case coSimEmulating:
+		return c.emulationCompleted()
+	case coSimSimulating:
+		c.flush()
+		return false
+	default:
+		log.Panicf("unexpected kernel completion in co-simulation phase %d",
+			c.phase)
+	}
+
+	panic("never")
+}
+
+// emulationCompleted rolls back the memory and launches the kernel again on
+// the simulated GPUs. If the kernel faults in the emulator, the simulated GPUs
+// do not run it.
+func (c *coSimulator) emulationCompleted() bool {
+	if c.faulted {
+		log.Printf("cosim: the kernel faults in the emulator, " +
+			"skipping the timing model")
+
+		c.phase = coSimIdle
+
+		return true
+	}
+
+	c.checker.EmulationCompleted()
+	c.phase = coSimSimulating
+
+	switch cmd := c.cmd.(type) {
+	case *LaunchKernelCommand:
+		c.driver.processLaunchKernelCommand(cmd, c.cmdQueue)
+	case *LaunchUnifiedMultiGPUKernelCommand:
+		c.driver.processUnifiedMultiGPULaunchKernelCommand(cmd, c.cmdQueue)
+	}
+
+	return false
+}
+
+// flush writes the data that the simulated kernel leaves in the caches to
+// the storage, so that the checker can compare it.
+func (c *coSimulator) flush() {
+	c.phase = coSimFlushing
+
+	for _, gpu := range c.driver.GPUs {
+		req := protocol.NewFlushReq(c.driver.gpuPort, gpu)
+		req.InvalidateAllCacheLines = true
+		c.driver.requestsToSend = append(c.driver.requestsToSend, req)
+		c.flushReqs = append(c.flushReqs, req)
+	}
+}
+
+// Tick completes the kernel command when the caches are flushed.
+func (c *coSimulator) Tick() bool {
+	if len(c.flushReqs) == 0 {
+		return false
+	}
+
+	rsp, ok := c.driver.gpuPort.PeekIncoming().(*sim.GeneralRsp)
+	if !ok {
+		return false
+	}
+
+	for i, req := range c.flushReqs {
+		if req != rsp.OriginalReq {
+			continue
+		}
+
+		c.driver.gpuPort.RetrieveIncoming()
+		c.flushReqs = append(c.flushReqs[:i], c.flushReqs[i+1:]...)
+
+		if len(c.flushReqs) == 0 {
+			c.checker.SimulationCompleted()
+			c.phase = coSimIdle
+			c.driver.completeKernelCommand(c.cmd, c.cmdQueue)
+		}
+
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,106 @@
+package driver
+
+import (
+	"github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/akita/v4/mem/mem"
+	"github.com/sarchlab/akita/v4/mem/vm"
+	"github.com/sarchlab/akita/v4/sim"
+	"github.com/sarchlab/mgpusim/v4/amd/cosim"
+	"github.com/sarchlab/mgpusim/v4/amd/protocol"
+)
+
+var _ = ginkgo.Describe("Driver with co-simulation", func() {
+	var (
+		driver *Driver
+		gpu    sim.Port
+		emuGPU sim.Port
+		ctx    *Context
+		q      *CommandQueue
+	)
+
+	ginkgo.BeforeEach(func() {
+		storage := mem.NewStorage(8 * mem.GB)
+		pageTable := vm.NewPageTable(12)
+
+		driver = MakeBuilder().
+			WithEngine(sim.NewSerialEngine()).
+			WithLog2PageSize(12).
+			WithPageTable(pageTable).
+			WithGlobalStorage(storage).
+			WithCoSimulation(cosim.NewChecker(pageTable, storage)).
+			Build("Driver")
+
+		gpu = sim.NewPort(driver, 1, 1, "GPU")
+		emuGPU = sim.NewPort(driver, 1, 1, "EmuGPU")
+		driver.RegisterGPU(gpu,
+			DeviceProperties{CUCount: 4, DRAMSize: 4 * mem.GB})
+		driver.RegisterCoSimGPU(emuGPU)
+
+		ctx = driver.Init()
+		q = driver.CreateCommandQueue(ctx)
+	})
+
+	lastReq := func() sim.Msg {
+		return driver.requestsToSend[len(driver.requestsToSend)-1]
+	}
+
+	completeKernel := func(req sim.Msg) {
+		rsp := protocol.NewLaunchKernelRsp(
+			req.Meta().Dst, driver.gpuPort.AsRemote(), req.Meta().ID)
+		driver.processLaunchKernelReturn(rsp)
+	}
+
+	ginkgo.It("should run a kernel on both GPUs and flush", func() {
+		q.Enqueue(&LaunchKernelCommand{})
+		Expect(driver.processOneCommand(q)).To(BeTrue())
+
+		req := lastReq()
+		Expect(req.Meta().Dst).To(Equal(emuGPU.AsRemote()))
+		completeKernel(req)
+
+		req = lastReq()
+		Expect(req.Meta().Dst).To(Equal(gpu.AsRemote()))
+		completeKernel(req)
+		Expect(q.NumCommand()).To(Equal(1))
+
+		flushReq := lastReq().(*protocol.FlushReq)
+		Expect(flushReq.InvalidateAllCacheLines).To(BeTrue())
+
+		rsp := sim.GeneralRspBuilder{}.
+			WithSrc(gpu.AsRemote()).
+			WithDst(driver.gpuPort.AsRemote()).
+			WithOriginalReq(flushReq).
+			Build()
+		driver.gpuPort.Deliver(rsp)
+		Expect(driver.coSimulator.Tick()).To(BeTrue())
+
+		Expect(q.NumCommand()).To(Equal(0))
+		Expect(q.IsRunning).To(BeFalse())
+		Expect(driver.CoSimChecker().Divergences()).To(BeEmpty())
+	})
+
+	ginkgo.It("should not start other commands during a kernel", func() {
+		q2 := driver.CreateCommandQueue(ctx)
+
+		q.Enqueue(&LaunchKernelCommand{})
+		Expect(driver.processOneCommand(q)).To(BeTrue())
+
+		q2.Enqueue(&LaunchKernelCommand{})
+		Expect(driver.processOneCommand(q2)).To(BeFalse())
+	})
+
+	ginkgo.It("should skip the timing model if the emulator faults", func() {
+		q.Enqueue(&LaunchKernelCommand{})
+		Expect(driver.processOneCommand(q)).To(BeTrue())
+
+		req := lastReq()
+		rsp := protocol.NewLaunchKernelRsp(
+			req.Meta().Dst, driver.gpuPort.AsRemote(), req.Meta().ID)
+		rsp.Fault = &protocol.MemoryFault{}
+		driver.processLaunchKernelReturn(rsp)
+
+		Expect(q.NumCommand()).To(Equal(0))
+		Expect(driver.requestsToSend).To(HaveLen(1))
+	})
+})
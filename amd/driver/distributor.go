@@ -16,11 +16,13 @@ type distributor interface {
 type distributorImpl struct {
 	pageSizeAsPowerOf2 uint64
 	memAllocator       internal.MemoryAllocator
+	policy             PlacementPolicy
 }
 
 func newDistributorImpl(memAllocator internal.MemoryAllocator) *distributorImpl {
 	return &distributorImpl{
 		memAllocator: memAllocator,
+		policy:       BlockPlacement{},
 	}
 }
 
@@ -36,38 +38,23 @@ func (d *distributorImpl) Distribute(
 
 	byteAllocatedOnEachGPU = make([]uint64, len(gpuIDs))
 	numPages := (byteSize-1)/pageSize + 1
-	numGPUs := uint64(len(gpuIDs))
-	numPagesPerGPU := numPages / numGPUs
-	numGPUsToUse := uint64(0)
-	if numPagesPerGPU > 0 {
-		numGPUsToUse = numPages / numPagesPerGPU
-	}
-	if numGPUsToUse > numGPUs {
-		numGPUsToUse = numGPUs
-	}
-	remainingPages := numPages % numGPUs
+	placement := d.policy.Place(ctx, addr, pageSize, int(numPages), gpuIDs)
 
-	var i uint64
-	var lastAllocatedGPU uint64
-	for i = 0; i < numGPUsToUse; i++ {
-		d.memAllocator.Remap(
-			ctx.pid,
-			addr+i*numPagesPerGPU*pageSize,
-			numPagesPerGPU*pageSize,
-			gpuIDs[i],
-		)
-		byteAllocatedOnEachGPU[i] += numPagesPerGPU * pageSize
-		lastAllocatedGPU = i
-	}
+	for i, gpuID := range placement {
+		pageAddr := addr + uint64(i)*pageSize
+
+		if gpuID == PlaceOnFirstTouch {
+			d.memAllocator.DeferPlacement(ctx.pid, pageAddr, pageSize)
+			continue
+		}
+
+		d.memAllocator.Remap(ctx.pid, pageAddr, pageSize, gpuID)
 
-	for i := uint64(0); i < remainingPages; i++ {
-		d.memAllocator.Remap(
-			ctx.pid,
-			addr+(numPagesPerGPU*numGPUsToUse+i)*pageSize,
-			pageSize,
-			gpuIDs[lastAllocatedGPU],
-		)
-		byteAllocatedOnEachGPU[lastAllocatedGPU] += pageSize
+		for j, id := range gpuIDs {
+			if id == gpuID {
+				byteAllocatedOnEachGPU[j] += pageSize
+			}
+		}
 	}
 
 	return byteAllocatedOnEachGPU
@@ -51,4 +51,31 @@ var _ = ginkgo.Describe("Distributor", func() {
 
 		Expect(bytes).To(Equal([]uint64{4096, 4096, 12288}))
 	})
+	ginkgo.It("should distribute pages with a placement policy", func() {
+		dist.policy = RoundRobinPlacement{}
+
+		memAllocator.EXPECT().
+			Remap(vm.PID(1), uint64(0x100000000), uint64(0x1000), 1)
+		memAllocator.EXPECT().
+			Remap(vm.PID(1), uint64(0x100001000), uint64(0x1000), 2)
+		memAllocator.EXPECT().
+			Remap(vm.PID(1), uint64(0x100002000), uint64(0x1000), 1)
+
+		bytes := dist.Distribute(ctx, 0x100000000, 0x2020, []int{1, 2})
+
+		Expect(bytes).To(Equal([]uint64{8192, 4096}))
+	})
+
+	ginkgo.It("should defer pages placed on first touch", func() {
+		dist.policy = FirstTouchPlacement{}
+
+		memAllocator.EXPECT().
+			DeferPlacement(vm.PID(1), uint64(0x100000000), uint64(0x1000))
+		memAllocator.EXPECT().
+			DeferPlacement(vm.PID(1), uint64(0x100001000), uint64(0x1000))
+
+		bytes := dist.Distribute(ctx, 0x100000000, 0x2000, []int{1, 2})
+
+		Expect(bytes).To(Equal([]uint64{0, 0}))
+	})
 })
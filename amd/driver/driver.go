@@ -15,6 +15,7 @@ import (
 	"github.com/sarchlab/mgpusim/v4/amd/insts"
 	"github.com/sarchlab/mgpusim/v4/amd/kernels"
 	"github.com/sarchlab/mgpusim/v4/amd/protocol"
+	"github.com/sarchlab/mgpusim/v4/amd/sanitizer"
 	"github.com/tebeka/atexit"
 )
 
@@ -26,11 +27,18 @@ type Driver struct {
 	distributor   distributor
 	globalStorage *mem.Storage
 
+	placeUnifiedMemory bool
+
 	GPUs        []sim.Port
 	devices     []*internal.Device
 	pageTable   vm.PageTable
 	middlewares []Middleware
 
+	checkpointer  *checkpointer
+	fastForwarder *fastForwarder
+	coSimulator   *coSimulator
+	sanitizer     *sanitizer.Sanitizer
+
 	requestsToSend []sim.Msg
 
 	contextMutex sync.Mutex
@@ -60,6 +68,7 @@ type Driver struct {
 	isCurrentlyMigratingOnePage     bool
 
 	RemotePMCPorts []sim.Port
+	migratedPages  []MigratedPage
 
 	codeObjGPUAddrs map[*insts.KernelCodeObject]Ptr
 }
@@ -139,6 +148,15 @@ func (d *Driver) runEngine() {
 type DeviceProperties struct {
 	CUCount  int
 	DRAMSize uint64
+
+	// WavefrontSize is the number of lanes in each wavefront that the GPU
+	// runs. Zero means that the GPU runs the wavefront size of the kernel.
+	WavefrontSize int
+
+	// MaxWavefrontsPerCU is the number of wavefronts that each CU can run
+	// at the same time, which bounds the scratch memory of a kernel. Zero
+	// lets the scratch memory hold all the wavefronts of the kernel.
+	MaxWavefrontsPerCU int
 }
 
 // RegisterGPU tells the driver about the existence of a GPU
@@ -153,8 +171,10 @@ func (d *Driver) RegisterGPU(
 		Type:     internal.DeviceTypeGPU,
 		MemState: internal.NewDeviceMemoryState(d.Log2PageSize),
 		Properties: internal.DeviceProperties{
-			CUCount:  properties.CUCount,
-			DRAMSize: properties.DRAMSize,
+			CUCount:            properties.CUCount,
+			DRAMSize:           properties.DRAMSize,
+			WavefrontSize:      properties.WavefrontSize,
+			MaxWavefrontsPerCU: properties.MaxWavefrontsPerCU,
 		},
 	}
 	gpuDevice.SetTotalMemSize(properties.DRAMSize)
@@ -171,6 +191,18 @@ func (d *Driver) Tick() bool {
 	madeProgress = d.sendToMMU() || madeProgress
 	madeProgress = d.sendMigrationReqToCP() || madeProgress
 
+	if d.checkpointer != nil {
+		madeProgress = d.checkpointer.Tick() || madeProgress
+	}
+
+	if d.fastForwarder != nil {
+		madeProgress = d.fastForwarder.Tick() || madeProgress
+	}
+
+	if d.coSimulator != nil {
+		madeProgress = d.coSimulator.Tick() || madeProgress
+	}
+
 	for _, mw := range d.middlewares {
 		madeProgress = mw.Tick() || madeProgress
 	}
@@ -272,6 +304,44 @@ func (d *Driver) processOneCommand(
 ) bool {
 	cmd := cmdQueue.Peek()
 
+	if d.checkpointer != nil {
+		handled, madeProgress := d.checkpointer.processCommand(cmd, cmdQueue)
+		if handled {
+			return madeProgress
+		}
+	}
+
+	if d.fastForwarder != nil && !d.fastForwarder.canStart(cmd) {
+		return false
+	}
+
+	if d.coSimulator != nil && !d.coSimulator.canStart(cmd) {
+		return false
+	}
+
+	if !d.startCommand(cmd, cmdQueue) {
+		return false
+	}
+
+	if d.checkpointer != nil {
+		d.checkpointer.commandStarted(cmd, cmdQueue)
+	}
+
+	if d.fastForwarder != nil {
+		d.fastForwarder.commandStarted(cmd)
+	}
+
+	if d.coSimulator != nil {
+		d.coSimulator.commandStarted(cmd, cmdQueue)
+	}
+
+	return true
+}
+
+func (d *Driver) startCommand(
+	cmd Command,
+	cmdQueue *CommandQueue,
+) bool {
 	switch cmd := cmd.(type) {
 	case *LaunchKernelCommand:
 		d.logCmdStart(cmd)
@@ -279,6 +349,9 @@ func (d *Driver) processOneCommand(
 	case *NoopCommand:
 		d.logCmdStart(cmd)
 		return d.processNoopCommand(cmd, cmdQueue)
+	case *RecordEventCommand:
+		d.logCmdStart(cmd)
+		return d.processRecordEventCommand(cmd, cmdQueue)
 	case *LaunchUnifiedMultiGPUKernelCommand:
 		d.logCmdStart(cmd)
 		return d.processUnifiedMultiGPULaunchKernelCommand(cmd, cmdQueue)
@@ -291,7 +364,16 @@ func (d *Driver) processCommandWithMiddleware(
 	cmd Command,
 	cmdQueue *CommandQueue,
 ) bool {
-	for _, m := range d.middlewares {
+	middlewares := d.middlewares
+	if d.fastForwarder != nil && d.fastForwarder.isFastForwarding() {
+		middlewares = []Middleware{d.fastForwarder.memCopyMiddleware}
+	}
+
+	if d.coSimulator != nil {
+		middlewares = []Middleware{d.coSimulator.memCopyMiddleware}
+	}
+
+	for _, m := range middlewares {
 		processed := m.ProcessCommand(cmd, cmdQueue)
 
 		if processed {
@@ -310,7 +392,7 @@ func (d *Driver) logCmdStart(cmd Command) {
 		d,
 		"Driver Command",
 		reflect.TypeOf(cmd).String(),
-		nil,
+		cmd,
 	)
 }
 
@@ -326,6 +408,17 @@ func (d *Driver) processNoopCommand(
 	return true
 }
 
+func (d *Driver) processRecordEventCommand(
+	cmd *RecordEventCommand,
+	queue *CommandQueue,
+) bool {
+	cmd.Event.record(d.CurrentTime())
+	queue.Dequeue()
+	d.logCmdComplete(cmd)
+
+	return true
+}
+
 func (d *Driver) logTaskToGPUInitiate(
 	cmd Command,
 	req sim.Msg,
@@ -343,13 +436,15 @@ func (d *Driver) processLaunchKernelCommand(
 	cmd *LaunchKernelCommand,
 	queue *CommandQueue,
 ) bool {
-	req := protocol.NewLaunchKernelReq(d.gpuPort,
-		d.GPUs[queue.GPUID-1])
+	req := d.newLaunchKernelReq(queue.GPUID)
 	req.PID = queue.Context.pid
 	req.CodeObject = cmd.CodeObject
 
 	req.Packet = cmd.Packet
 	req.PacketAddress = uint64(cmd.DPacket)
+	req.ScratchAddress = uint64(cmd.DScratch)
+	req.ScratchByteSize = cmd.ScratchByteSize
+	d.freeScratchMemory(queue, &cmd.replacedScratch)
 
 	queue.IsRunning = true
 	cmd.Reqs = append(cmd.Reqs, req)
@@ -369,6 +464,7 @@ func (d *Driver) processUnifiedMultiGPULaunchKernelCommand(
 	queue *CommandQueue,
 ) bool {
 	wgDist := d.distributeWGToGPUs(queue, cmd)
+	d.freeScratchMemory(queue, &cmd.replacedScratch)
 
 	dev := d.devices[queue.GPUID]
 	for i, gpuID := range dev.UnifiedGPUIDs {
@@ -376,11 +472,13 @@ func (d *Driver) processUnifiedMultiGPULaunchKernelCommand(
 			continue
 		}
 
-		req := protocol.NewLaunchKernelReq(d.gpuPort, d.GPUs[gpuID-1])
+		req := d.newLaunchKernelReq(gpuID)
 		req.PID = queue.Context.pid
 		req.CodeObject = cmd.CodeObject
 		req.Packet = cmd.PacketArray[i]
 		req.PacketAddress = uint64(cmd.DPacketArray[i])
+		req.ScratchAddress = uint64(cmd.DScratchArray[i])
+		req.ScratchByteSize = cmd.ScratchByteSizeArray[i]
 
 		currentGPUIndex := i
 		req.WGFilter = func(
@@ -417,6 +515,33 @@ func (d *Driver) processUnifiedMultiGPULaunchKernelCommand(
 	return true
 }
 
+// newLaunchKernelReq creates a request that launches a kernel on a GPU, or on
+// the emulated GPU that stands in for it while fast-forwarding or
+// co-simulating.
+func (d *Driver) newLaunchKernelReq(gpuID int) *protocol.LaunchKernelReq {
+	if d.coSimulator != nil && d.coSimulator.isEmulating() {
+		return protocol.NewLaunchKernelReq(
+			d.gpuPort, d.coSimulator.gpuToLaunchKernel(gpuID))
+	}
+
+	if d.fastForwarder == nil {
+		return protocol.NewLaunchKernelReq(d.gpuPort, d.GPUs[gpuID-1])
+	}
+
+	if !d.fastForwarder.isFastForwarding() {
+		req := protocol.NewLaunchKernelReq(d.gpuPort, d.GPUs[gpuID-1])
+		d.fastForwarder.kernelReqSent(req, false)
+
+		return req
+	}
+
+	req := protocol.NewLaunchKernelReq(
+		d.gpuPort, d.fastForwarder.gpuToLaunchKernel(gpuID))
+	d.fastForwarder.kernelReqSent(req, true)
+
+	return req
+}
+
 func (d *Driver) distributeWGToGPUs(
 	queue *CommandQueue,
 	cmd *LaunchUnifiedMultiGPUKernelCommand,
@@ -457,18 +582,62 @@ func (d *Driver) processLaunchKernelReturn(
 	req, cmd, cmdQueue := d.findCommandByReqID(rsp.RspTo)
 	cmd.RemoveReq(req)
 
+	if rsp.Fault != nil {
+		rsp.Fault.GPU = d.gpuIDOfPort(req.Meta().Dst)
+		cmdQueue.recordFault(rsp.Fault)
+	}
+
 	d.logTaskToGPUClear(req)
 
-	if len(cmd.GetReqs()) == 0 {
-		cmdQueue.IsRunning = false
-		cmdQueue.Dequeue()
+	if d.fastForwarder != nil {
+		d.fastForwarder.kernelReqCompleted(req)
+	}
+
+	if d.coSimulator != nil && !d.coSimulator.kernelReqCompleted(rsp, cmd) {
+		return true
+	}
 
-		d.logCmdComplete(cmd)
+	if len(cmd.GetReqs()) == 0 {
+		d.completeKernelCommand(cmd, cmdQueue)
 	}
 
 	return true
 }
 
+func (d *Driver) completeKernelCommand(cmd Command, cmdQueue *CommandQueue) {
+	cmdQueue.IsRunning = false
+	cmdQueue.Dequeue()
+
+	d.logCmdComplete(cmd)
+
+	if d.checkpointer != nil {
+		d.checkpointer.kernelCompleted()
+	}
+}
+
+// gpuIDOfPort returns the ID of the GPU that the port belongs to. The
+// emulated GPUs take the ID of the GPU that they stand in for.
+func (d *Driver) gpuIDOfPort(port sim.RemotePort) int {
+	gpus := [][]sim.Port{d.GPUs}
+	if d.fastForwarder != nil {
+		gpus = append(gpus, d.fastForwarder.emulatedGPUs)
+	}
+
+	if d.coSimulator != nil {
+		gpus = append(gpus, d.coSimulator.emulatedGPUs)
+	}
+
+	for _, ports := range gpus {
+		for i, gpu := range ports {
+			if gpu.AsRemote() == port {
+				return i + 1
+			}
+		}
+	}
+
+	panic("GPU not found")
+}
+
 func (d *Driver) findCommandByReq(req sim.Msg) (Command, *CommandQueue) {
 	d.contextMutex.Lock()
 	defer d.contextMutex.Unlock()
@@ -589,10 +758,15 @@ func (d *Driver) sendShootDownReqs() bool {
 		}
 	}
 
-	accessingGPUs := d.currentPageMigrationReq.CurrAccessingGPUs
+	accessingGPUs := d.accessingGPUs()
 	pid := d.currentPageMigrationReq.PID
 	d.numShootDownACK = uint64(len(accessingGPUs))
 
+	if len(accessingGPUs) == 0 {
+		d.migratePages()
+		return true
+	}
+
 	for i := 0; i < len(accessingGPUs); i++ {
 		toShootdownGPU := accessingGPUs[i] - 1
 		shootDownReq := protocol.NewShootdownCommand(
@@ -604,48 +778,71 @@ func (d *Driver) sendShootDownReqs() bool {
 	return true
 }
 
+// accessingGPUs returns the GPUs that may hold the translations of the pages
+// to migrate. Pages that are not placed yet are hosted by device 0, which is
+// not a GPU and is skipped.
+func (d *Driver) accessingGPUs() []uint64 {
+	gpus := make([]uint64, 0)
+	for _, gpuID := range d.currentPageMigrationReq.CurrAccessingGPUs {
+		if gpuID != 0 {
+			gpus = append(gpus, gpuID)
+		}
+	}
+
+	return gpus
+}
+
 func (d *Driver) processShootdownCompleteRsp(
 	req *protocol.ShootDownCompleteRsp,
 ) bool {
 	d.numShootDownACK--
 
 	if d.numShootDownACK == 0 {
-		toRequestFromGPU := d.currentPageMigrationReq.CurrPageHostGPU
-		toRequestFromPMCPort := d.RemotePMCPorts[toRequestFromGPU-1]
+		d.migratePages()
+		return true
+	}
 
-		migrationInfo := d.currentPageMigrationReq.MigrationInfo
+	return false
+}
 
-		requestingGPUs := d.findRequestingGPUs(migrationInfo)
-		context := d.findContext(d.currentPageMigrationReq.PID)
+func (d *Driver) migratePages() {
+	migrationInfo := d.currentPageMigrationReq.MigrationInfo
 
-		pageVaddrs := make(map[uint64][]uint64)
+	requestingGPUs := d.findRequestingGPUs(migrationInfo)
+	context := d.findContext(d.currentPageMigrationReq.PID)
 
-		for i := 0; i < len(requestingGPUs); i++ {
-			pageVaddrs[requestingGPUs[i]] =
-				migrationInfo.GPUReqToVAddrMap[requestingGPUs[i]+1]
-		}
+	pageVaddrs := make(map[uint64][]uint64)
+
+	for i := 0; i < len(requestingGPUs); i++ {
+		pageVaddrs[requestingGPUs[i]] =
+			migrationInfo.GPUReqToVAddrMap[requestingGPUs[i]+1]
+	}
 
-		for gpuID, vAddrs := range pageVaddrs {
-			for i := 0; i < len(vAddrs); i++ {
-				vAddr := vAddrs[i]
-				page, oldPAddr :=
-					d.preparePageForMigration(vAddr, context, gpuID)
-
-				req := protocol.NewPageMigrationReqToCP(d.gpuPort,
-					d.GPUs[gpuID])
-				req.DestinationPMCPort = toRequestFromPMCPort
-				req.ToReadFromPhysicalAddress = oldPAddr
-				req.ToWriteToPhysicalAddress = page.PAddr
-				req.PageSize = d.currentPageMigrationReq.PageSize
-
-				d.migrationReqToSendToCP = append(d.migrationReqToSendToCP, req)
-				d.numPagesMigratingACK++
+	for gpuID, vAddrs := range pageVaddrs {
+		for i := 0; i < len(vAddrs); i++ {
+			vAddr := vAddrs[i]
+			page, oldPAddr, moved :=
+				d.preparePageForMigration(vAddr, context, gpuID)
+			if !moved {
+				continue
 			}
+
+			toRequestFromGPU := d.memAllocator.GetDeviceIDByPAddr(oldPAddr)
+			req := protocol.NewPageMigrationReqToCP(d.gpuPort,
+				d.GPUs[gpuID])
+			req.DestinationPMCPort = d.RemotePMCPorts[toRequestFromGPU-1]
+			req.ToReadFromPhysicalAddress = oldPAddr
+			req.ToWriteToPhysicalAddress = page.PAddr
+			req.PageSize = d.currentPageMigrationReq.PageSize
+
+			d.migrationReqToSendToCP = append(d.migrationReqToSendToCP, req)
+			d.numPagesMigratingACK++
 		}
-		return true
 	}
 
-	return false
+	if d.numPagesMigratingACK == 0 {
+		d.finishPageMigration()
+	}
 }
 
 func (d *Driver) findRequestingGPUs(
@@ -675,17 +872,30 @@ func (d *Driver) findContext(pid vm.PID) *Context {
 	return context
 }
 
+// preparePageForMigration updates the page table entry of a page that moves
+// to the given GPU. It returns false if the page is already stored in the
+// memory of the GPU, which happens when a page that is not placed yet is
+// touched by the GPU that backs it.
 func (d *Driver) preparePageForMigration(
 	vAddr uint64,
 	context *Context,
 	gpuID uint64,
-) (*vm.Page, uint64) {
+) (*vm.Page, uint64, bool) {
 	page, found := d.pageTable.Find(context.pid, vAddr)
 	if !found {
 		panic("page not founds")
 	}
 	oldPAddr := page.PAddr
 
+	if page.DeviceID == 0 &&
+		d.memAllocator.GetDeviceIDByPAddr(oldPAddr) == int(gpuID+1) {
+		page.DeviceID = gpuID + 1
+		page.IsMigrating = true
+		d.pageTable.Update(page)
+
+		return &page, oldPAddr, false
+	}
+
 	newPage := d.memAllocator.AllocatePageWithGivenVAddr(
 		context.pid, int(gpuID+1), vAddr, true)
 	newPage.DeviceID = gpuID + 1
@@ -693,7 +903,7 @@ func (d *Driver) preparePageForMigration(
 	newPage.IsMigrating = true
 	d.pageTable.Update(newPage)
 
-	return &newPage, oldPAddr
+	return &newPage, oldPAddr, true
 }
 
 func (d *Driver) sendMigrationReqToCP() bool {
@@ -724,15 +934,23 @@ func (d *Driver) processPageMigrationRspFromCP(
 	d.isCurrentlyMigratingOnePage = false
 
 	if d.numPagesMigratingACK == 0 {
-		d.prepareGPURestartReqs()
-		d.preparePageMigrationRspToMMU()
+		d.finishPageMigration()
 	}
 
 	return true
 }
 
+func (d *Driver) finishPageMigration() {
+	d.prepareGPURestartReqs()
+	d.preparePageMigrationRspToMMU()
+
+	if d.numRestartACK == 0 {
+		d.prepareRDMARestartReqs()
+	}
+}
+
 func (d *Driver) prepareGPURestartReqs() {
-	accessingGPUs := d.currentPageMigrationReq.CurrAccessingGPUs
+	accessingGPUs := d.accessingGPUs()
 
 	for i := 0; i < len(accessingGPUs); i++ {
 		restartGPUID := accessingGPUs[i] - 1
@@ -765,15 +983,39 @@ func (d *Driver) preparePageMigrationRspToMMU() {
 	req := vm.NewPageMigrationRspFromDriver(d.mmuPort.AsRemote(),
 		d.currentPageMigrationReq.Src, d.currentPageMigrationReq)
 
-	for _, vAddrs := range pageVaddrs {
+	for gpuID, vAddrs := range pageVaddrs {
 		for j := 0; j < len(vAddrs); j++ {
 			req.VAddr = append(req.VAddr, vAddrs[j])
+			d.migratedPages = append(d.migratedPages, MigratedPage{
+				PID:   d.currentPageMigrationReq.PID,
+				VAddr: vAddrs[j],
+				GPUID: gpuID + 1,
+			})
 		}
 	}
 	req.RspToTop = d.currentPageMigrationReq.RespondToTop
 	d.toSendToMMU = req
 }
 
+// MigratedPage records a page that the driver has moved to the memory of the
+// GPU that requested it.
+type MigratedPage struct {
+	PID   vm.PID
+	VAddr uint64
+	GPUID uint64
+}
+
+// MigratedPages returns the pages that the driver has migrated, in the order
+// that the migrations are completed.
+func (d *Driver) MigratedPages() []MigratedPage {
+	return d.migratedPages
+}
+
+// FindPage returns the page table entry that covers the given virtual address.
+func (d *Driver) FindPage(pid vm.PID, vAddr uint64) (vm.Page, bool) {
+	return d.pageTable.Find(pid, vAddr)
+}
+
 func (d *Driver) handleGPURestartRsp(
 	req *protocol.GPURestartRsp,
 ) bool {
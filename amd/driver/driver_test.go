@@ -6,6 +6,8 @@ import (
 	"github.com/sarchlab/akita/v4/mem/mem"
 	"github.com/sarchlab/akita/v4/mem/vm"
 	"github.com/sarchlab/akita/v4/sim"
+	"github.com/sarchlab/mgpusim/v4/amd/insts"
+	"github.com/sarchlab/mgpusim/v4/amd/kernels"
 	"github.com/sarchlab/mgpusim/v4/amd/protocol"
 	"go.uber.org/mock/gomock"
 )
@@ -389,6 +391,150 @@ var _ = ginkgo.Describe("Driver", func() {
 			Expect(req.PID).To(Equal(vm.PID(1)))
 			Expect(driver.requestsToSend).To(HaveLen(1))
 		})
+
+		ginkgo.It("should pass the scratch memory to the GPU", func() {
+			cmd := &LaunchKernelCommand{
+				CodeObject:      nil,
+				GridSize:        [3]uint32{256, 1, 1},
+				WGSize:          [3]uint16{64, 1, 1},
+				KernelArgs:      nil,
+				DScratch:        0x100000,
+				ScratchByteSize: 4096,
+			}
+			cmdQueue.Enqueue(cmd)
+			cmdQueue.IsRunning = false
+
+			toGPUs.EXPECT().PeekIncoming().Return(nil).AnyTimes()
+
+			toMMU.EXPECT().RetrieveIncoming().Return(nil)
+
+			engine.EXPECT().Schedule(
+				gomock.AssignableToTypeOf(sim.TickEvent{}))
+
+			engine.EXPECT().CurrentTime().Return(sim.VTimeInSec(11))
+
+			driver.Handle(sim.MakeTickEvent(nil, 11))
+
+			Expect(cmd.Reqs).To(HaveLen(1))
+			req := cmd.Reqs[0].(*protocol.LaunchKernelReq)
+			Expect(req.ScratchAddress).To(Equal(uint64(0x100000)))
+			Expect(req.ScratchByteSize).To(Equal(uint64(4096)))
+		})
+	})
+
+	ginkgo.Context("prepare the scratch memory", func() {
+		var (
+			co     *insts.KernelCodeObject
+			packet *kernels.HsaKernelDispatchPacket
+		)
+
+		ginkgo.BeforeEach(func() {
+			co = &insts.KernelCodeObject{
+				KernelCodeObjectMeta: &insts.KernelCodeObjectMeta{
+					PrivateSegmentByteSize: 16,
+				},
+			}
+			packet = driver.createAQLPacket(
+				[3]uint32{65536, 1, 1}, [3]uint16{256, 1, 1}, 0, 0)
+		})
+
+		ginkgo.It("should hold the wavefronts that can run at the same time",
+			func() {
+				dev := driver.devices[cmdQueue.GPUID]
+				dev.Properties.MaxWavefrontsPerCU = 10
+
+				size := driver.scratchSegmentSize(dev, co, packet)
+
+				Expect(size).To(Equal(uint64(4 * 10 * 1024)))
+			})
+
+		ginkgo.It("should hold all the wavefronts if the GPU does not "+
+			"limit them", func() {
+			dev := driver.devices[cmdQueue.GPUID]
+
+			size := driver.scratchSegmentSize(dev, co, packet)
+
+			Expect(size).To(Equal(uint64(1024 * 1024)))
+		})
+
+		ginkgo.It("should reuse the scratch memory of the queue", func() {
+			driver.devices[cmdQueue.GPUID].Properties.MaxWavefrontsPerCU = 10
+			memAllocator.EXPECT().
+				Allocate(vm.PID(1), uint64(4*10*1024), gomock.Any()).
+				Return(uint64(0x100000))
+
+			dScratch1, _, replaced1 := driver.prepareScratchMemory(
+				cmdQueue, cmdQueue.GPUID, co, packet)
+			dScratch2, _, replaced2 := driver.prepareScratchMemory(
+				cmdQueue, cmdQueue.GPUID, co, packet)
+
+			Expect(dScratch1).To(Equal(Ptr(0x100000)))
+			Expect(dScratch2).To(Equal(Ptr(0x100000)))
+			Expect(replaced1).To(BeEmpty())
+			Expect(replaced2).To(BeEmpty())
+		})
+
+		ginkgo.It("should replace the scratch memory that is too small",
+			func() {
+				driver.devices[cmdQueue.GPUID].Properties.MaxWavefrontsPerCU = 10
+				memAllocator.EXPECT().
+					Allocate(vm.PID(1), gomock.Any(), gomock.Any()).
+					Return(uint64(0x100000))
+				memAllocator.EXPECT().
+					Allocate(vm.PID(1), gomock.Any(), gomock.Any()).
+					Return(uint64(0x200000))
+
+				driver.prepareScratchMemory(
+					cmdQueue, cmdQueue.GPUID, co, packet)
+				co.PrivateSegmentByteSize = 64
+				dScratch, size, replaced := driver.prepareScratchMemory(
+					cmdQueue, cmdQueue.GPUID, co, packet)
+
+				Expect(dScratch).To(Equal(Ptr(0x200000)))
+				Expect(size).To(Equal(uint64(4 * 10 * 4096)))
+				Expect(replaced).To(Equal([]Ptr{0x100000}))
+			})
+
+		ginkgo.It("should free the replaced scratch memory when the kernel "+
+			"starts", func() {
+			cmd := &LaunchKernelCommand{
+				GridSize:        [3]uint32{256, 1, 1},
+				WGSize:          [3]uint16{64, 1, 1},
+				DScratch:        0x200000,
+				ScratchByteSize: 4096,
+				replacedScratch: []Ptr{0x100000},
+			}
+			memAllocator.EXPECT().Free(uint64(0x100000))
+
+			driver.processLaunchKernelCommand(cmd, cmdQueue)
+
+			Expect(cmd.replacedScratch).To(BeNil())
+		})
+
+		ginkgo.It("should enqueue the kernel with its scratch memory", func() {
+			driver.enqueueLaunchKernelCommand(cmdQueue, co, packet, 0,
+				0x100000, 1024*1024, []Ptr{0x80000})
+
+			cmd := cmdQueue.Peek().(*LaunchKernelCommand)
+			Expect(cmd.DScratch).To(Equal(Ptr(0x100000)))
+			Expect(cmd.ScratchByteSize).To(Equal(uint64(1024 * 1024)))
+			Expect(cmd.replacedScratch).To(Equal([]Ptr{0x80000}))
+		})
+
+		ginkgo.It("should free the scratch memory when the queue is released",
+			func() {
+				memAllocator.EXPECT().
+					Allocate(vm.PID(1), gomock.Any(), gomock.Any()).
+					Return(uint64(0x100000))
+				memAllocator.EXPECT().Free(uint64(0x100000))
+
+				driver.prepareScratchMemory(
+					cmdQueue, cmdQueue.GPUID, co, packet)
+				driver.ReleaseCommandQueue(cmdQueue)
+
+				Expect(cmdQueue.scratch).To(BeEmpty())
+				Expect(cmdQueue.Context.queues).NotTo(ContainElement(cmdQueue))
+			})
 	})
 
 	ginkgo.It("should process LaunchKernel return", func() {
@@ -420,6 +566,55 @@ var _ = ginkgo.Describe("Driver", func() {
 		Expect(cmdQueue.commands).To(HaveLen(0))
 	})
 
+	ginkgo.It("should record the memory fault of a kernel", func() {
+		nilPort := NewMockPort(mockCtrl)
+		nilPort.EXPECT().AsRemote().AnyTimes()
+
+		req := protocol.NewLaunchKernelReq(toGPUs, nilPort)
+		cmd := &LaunchKernelCommand{
+			Reqs: []sim.Msg{req},
+		}
+		cmdQueue.Enqueue(cmd)
+		cmdQueue.IsRunning = true
+		rsp := protocol.NewLaunchKernelRsp("", "", req.ID)
+		rsp.Fault = &protocol.MemoryFault{Address: 0x1000}
+
+		toGPUs.EXPECT().PeekIncoming().Return(rsp).Times(2)
+		toGPUs.EXPECT().
+			RetrieveIncoming().
+			Return(rsp)
+
+		toMMU.EXPECT().RetrieveIncoming().Return(nil)
+
+		engine.EXPECT().Schedule(gomock.AssignableToTypeOf(sim.TickEvent{}))
+
+		engine.EXPECT().CurrentTime().Return(sim.VTimeInSec(11))
+
+		driver.Handle(sim.MakeTickEvent(nil, 11))
+
+		Expect(cmdQueue.commands).To(HaveLen(0))
+		Expect(cmdQueue.Err()).To(BeIdenticalTo(rsp.Fault))
+		Expect(rsp.Fault.GPU).To(Equal(1))
+	})
+
+	ginkgo.It("should record event", func() {
+		event := NewEvent()
+		driver.EnqueueRecordEvent(cmdQueue, event)
+
+		toGPUs.EXPECT().PeekIncoming().Return(nil).AnyTimes()
+		toMMU.EXPECT().RetrieveIncoming().Return(nil)
+		engine.EXPECT().Schedule(
+			gomock.AssignableToTypeOf(sim.TickEvent{})).AnyTimes()
+		engine.EXPECT().CurrentTime().Return(sim.VTimeInSec(11)).AnyTimes()
+
+		driver.Handle(sim.MakeTickEvent(nil, 11))
+
+		time, recorded := event.Time()
+		Expect(recorded).To(BeTrue())
+		Expect(time).To(Equal(sim.VTimeInSec(11)))
+		Expect(cmdQueue.commands).To(HaveLen(0))
+	})
+
 	ginkgo.It("should handle page migration req from MMU ", func() {
 		req := vm.NewPageMigrationReqToDriver("", driver.mmuPort.AsRemote())
 		toMMU.EXPECT().RetrieveIncoming().Return(req)
@@ -524,6 +719,10 @@ var _ = ginkgo.Describe("Driver", func() {
 		memAllocator.EXPECT().
 			AllocatePageWithGivenVAddr(vm.PID(0), 2, uint64(0x100), true).
 			Return(*page2)
+		memAllocator.EXPECT().
+			GetDeviceIDByPAddr(uint64(4294967296)).
+			Return(1).
+			AnyTimes()
 
 		toGPUs.EXPECT().PeekIncoming().Return(req)
 		toGPUs.EXPECT().RetrieveIncoming().Return(req)
@@ -545,6 +744,95 @@ var _ = ginkgo.Describe("Driver", func() {
 
 	})
 
+	ginkgo.It("should place an unplaced page on the GPU that backs it", func() {
+		pageMigrationReq := vm.NewPageMigrationReqToDriver(
+			"", driver.mmuPort.AsRemote())
+		pageMigrationReq.PageSize = 4 * mem.KB
+		pageMigrationReq.CurrPageHostGPU = 0
+		pageMigrationReq.CurrAccessingGPUs = []uint64{0}
+		migrationInfo := new(vm.PageMigrationInfo)
+		migrationInfo.GPUReqToVAddrMap = map[uint64][]uint64{2: {0x100}}
+		pageMigrationReq.MigrationInfo = migrationInfo
+		driver.currentPageMigrationReq = pageMigrationReq
+
+		page := vm.Page{
+			PID:      0,
+			VAddr:    0x100,
+			PAddr:    8589934592,
+			PageSize: 0x1000,
+			Valid:    true,
+			DeviceID: 0,
+			Unified:  true,
+		}
+		pageTable.EXPECT().Find(vm.PID(0), uint64(0x100)).Return(page, true)
+		memAllocator.EXPECT().
+			GetDeviceIDByPAddr(uint64(8589934592)).
+			Return(2)
+		placedPage := page
+		placedPage.DeviceID = 2
+		placedPage.IsMigrating = true
+		pageTable.EXPECT().Update(placedPage)
+
+		driver.sendShootDownReqs()
+
+		Expect(driver.migrationReqToSendToCP).To(BeEmpty())
+		Expect(driver.numRestartACK).To(Equal(uint64(0)))
+		Expect(driver.numRDMARestartACK).To(Equal(uint64(2)))
+		Expect(driver.requestsToSend).To(HaveLen(2))
+		Expect(driver.toSendToMMU.VAddr).To(Equal([]uint64{0x100}))
+	})
+
+	ginkgo.It("should copy an unplaced page from the GPU that backs it", func() {
+		pageMigrationReq := vm.NewPageMigrationReqToDriver(
+			"", driver.mmuPort.AsRemote())
+		pageMigrationReq.PageSize = 4 * mem.KB
+		pageMigrationReq.CurrPageHostGPU = 0
+		pageMigrationReq.CurrAccessingGPUs = []uint64{0}
+		migrationInfo := new(vm.PageMigrationInfo)
+		migrationInfo.GPUReqToVAddrMap = map[uint64][]uint64{2: {0x100}}
+		pageMigrationReq.MigrationInfo = migrationInfo
+		driver.currentPageMigrationReq = pageMigrationReq
+
+		pageTable.EXPECT().
+			Find(vm.PID(0), uint64(0x100)).
+			Return(vm.Page{
+				PID:      0,
+				VAddr:    0x100,
+				PAddr:    4294967296,
+				PageSize: 0x1000,
+				Valid:    true,
+				DeviceID: 0,
+				Unified:  true,
+			}, true)
+		memAllocator.EXPECT().
+			GetDeviceIDByPAddr(uint64(4294967296)).
+			Return(1).
+			Times(2)
+		newPage := vm.Page{
+			PID:      0,
+			VAddr:    0x100,
+			PAddr:    8589934592,
+			PageSize: 0x1000,
+			Valid:    true,
+			DeviceID: 2,
+			Unified:  true,
+		}
+		memAllocator.EXPECT().
+			AllocatePageWithGivenVAddr(vm.PID(0), 2, uint64(0x100), true).
+			Return(newPage)
+		newPage.IsMigrating = true
+		pageTable.EXPECT().Update(newPage)
+
+		driver.sendShootDownReqs()
+
+		Expect(driver.requestsToSend).To(BeEmpty())
+		Expect(driver.numPagesMigratingACK).To(Equal(uint64(1)))
+		Expect(driver.migrationReqToSendToCP[0].DestinationPMCPort).
+			To(Equal(driver.RemotePMCPorts[0]))
+		Expect(driver.migrationReqToSendToCP[0].ToWriteToPhysicalAddress).
+			To(Equal(uint64(8589934592)))
+	})
+
 	ginkgo.It("should send migration req to CP", func() {
 		migrationReqToCP :=
 			protocol.NewPageMigrationReqToCP(driver.gpuPort,
@@ -674,4 +962,19 @@ var _ = ginkgo.Describe("Driver", func() {
 		Expect(madeProgress).To(BeTrue())
 		Expect(driver.toSendToMMU).To(BeNil())
 	})
+
+	ginkgo.It("should reject a kernel compiled for another wavefront size",
+		func() {
+			driver.devices[cmdQueue.GPUID].Properties.WavefrontSize = 32
+			co := &insts.KernelCodeObject{
+				KernelCodeObjectMeta: &insts.KernelCodeObjectMeta{
+					WavefrontSize: 64,
+				},
+			}
+
+			Expect(func() {
+				driver.EnqueueLaunchKernel(cmdQueue, co,
+					[3]uint32{64, 1, 1}, [3]uint16{64, 1, 1}, nil)
+			}).To(Panic())
+		})
 })
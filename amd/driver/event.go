@@ -0,0 +1,86 @@
+package driver
+
+import (
+	"sync"
+
+	"github.com/sarchlab/akita/v4/sim"
+)
+
+// An Event marks a point in a command queue. The driver records the event
+// when all the commands enqueued before it complete.
+type Event struct {
+	mutex    sync.Mutex
+	queue    *CommandQueue
+	recorded bool
+	time     sim.VTimeInSec
+}
+
+// NewEvent creates an event that is not recorded.
+func NewEvent() *Event {
+	return &Event{}
+}
+
+func (e *Event) record(time sim.VTimeInSec) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.recorded = true
+	e.time = time
+}
+
+// IsRecorded returns true if the commands enqueued before the event have
+// completed.
+func (e *Event) IsRecorded() bool {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	return e.recorded
+}
+
+// Time returns the time when the event is recorded. It returns false if the
+// event is not recorded yet.
+func (e *Event) Time() (sim.VTimeInSec, bool) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	return e.time, e.recorded
+}
+
+// EnqueueRecordEvent registers a RecordEventCommand in the queue. Recording
+// an event again resets the event.
+func (d *Driver) EnqueueRecordEvent(queue *CommandQueue, e *Event) {
+	e.mutex.Lock()
+	e.queue = queue
+	e.recorded = false
+	e.mutex.Unlock()
+
+	cmd := &RecordEventCommand{
+		ID:    sim.GetIDGenerator().Generate(),
+		Event: e,
+	}
+	d.Enqueue(queue, cmd)
+}
+
+// SynchronizeEvent returns when the event is recorded. It returns immediately
+// if the event has never been enqueued.
+func (d *Driver) SynchronizeEvent(e *Event) {
+	e.mutex.Lock()
+	q := e.queue
+	e.mutex.Unlock()
+
+	if q == nil {
+		return
+	}
+
+	listener := q.Subscribe()
+	defer q.Unsubscribe(listener)
+
+	d.enqueueSignal <- true
+
+	for {
+		if e.IsRecorded() {
+			return
+		}
+		listener.Wait()
+	}
+}
@@ -0,0 +1,208 @@
+package driver
+
+import (
+	"log"
+
+	"github.com/sarchlab/akita/v4/sim"
+	"github.com/sarchlab/mgpusim/v4/amd/protocol"
+)
+
+// A KernelSelector decides which kernels to simulate in detail. The kernels
+// are indexed in the order that the driver launches them, starting from 0.
+type KernelSelector interface {
+	SimulateInDetail(kernelIndex int) bool
+}
+
+// startKernelSelector simulates the kernels from a given index in detail.
+type startKernelSelector int
+
+func (s startKernelSelector) SimulateInDetail(kernelIndex int) bool {
+	return kernelIndex >= int(s)
+}
+
+// A fastForwarder runs the kernels that the selector does not pick on
+// emulated GPUs and the rest of the kernels on the GPUs registered with
+// RegisterGPU. The emulated GPUs share the storage and the page table with
+// the simulated GPUs. While fast-forwarding, memory copies access the storage
+// directly, so the caches of the simulated GPUs are cold when a simulated
+// kernel starts. Before switching from simulated kernels back to emulated
+// kernels, the fastForwarder flushes and invalidates the caches of the
+// simulated GPUs.
+type fastForwarder struct {
+	driver *Driver
+
+	selector           KernelSelector
+	numKernelsLaunched int
+	numEmulated        int
+	numSimulated       int
+	emulatedGPUs       []sim.Port
+	memCopyMiddleware  Middleware
+	runningReqs        map[string]bool
+	cachesDirty        bool
+	flushReqs          []sim.Msg
+}
+
+func newFastForwarder(d *Driver, selector KernelSelector) *fastForwarder {
+	return &fastForwarder{
+		driver:   d,
+		selector: selector,
+		memCopyMiddleware: &globalStorageMemoryCopyMiddleware{
+			driver: d,
+		},
+		runningReqs: make(map[string]bool),
+	}
+}
+
+// RegisterFastForwardGPU tells the driver about the command processor of an
+// emulated GPU. The emulated GPUs run the fast-forwarded kernels on behalf of
+// the GPUs registered with RegisterGPU, in the same order.
+func (d *Driver) RegisterFastForwardGPU(commandProcessorPort sim.Port) {
+	if d.fastForwarder == nil {
+		panic("fast-forwarding is not enabled")
+	}
+
+	d.fastForwarder.emulatedGPUs = append(
+		d.fastForwarder.emulatedGPUs, commandProcessorPort)
+}
+
+// isFastForwarding returns true if the next kernel runs on the emulated GPUs.
+func (f *fastForwarder) isFastForwarding() bool {
+	return !f.selector.SimulateInDetail(f.numKernelsLaunched)
+}
+
+// canStart returns false if the command cannot start before the running
+// kernels complete. Emulated and simulated kernels never run at the same
+// time, and emulated kernels wait for the caches to be flushed.
+func (f *fastForwarder) canStart(cmd Command) bool {
+	if len(f.flushReqs) > 0 {
+		return false
+	}
+
+	if f.isFastForwarding() {
+		return !f.cachesDirty
+	}
+
+	if !isKernelCommand(cmd) {
+		return true
+	}
+
+	for _, emulated := range f.runningReqs {
+		if emulated {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (f *fastForwarder) commandStarted(cmd Command) {
+	if !isKernelCommand(cmd) {
+		return
+	}
+
+	if f.isFastForwarding() {
+		f.numEmulated++
+	} else {
+		if f.numSimulated == 0 && f.numEmulated > 0 {
+			log.Printf("Fast-forwarded %d kernels, switching to detailed "+
+				"simulation.\n", f.numEmulated)
+		}
+
+		f.numSimulated++
+		f.cachesDirty = true
+	}
+
+	f.numKernelsLaunched++
+}
+
+func (f *fastForwarder) gpuToLaunchKernel(gpuID int) sim.Port {
+	if gpuID > len(f.emulatedGPUs) {
+		log.Panicf("GPU %d does not have an emulated GPU", gpuID)
+	}
+
+	return f.emulatedGPUs[gpuID-1]
+}
+
+func (f *fastForwarder) kernelReqSent(req sim.Msg, emulated bool) {
+	f.runningReqs[req.Meta().ID] = emulated
+}
+
+func (f *fastForwarder) kernelReqCompleted(req sim.Msg) {
+	delete(f.runningReqs, req.Meta().ID)
+}
+
+// Tick flushes the caches of the simulated GPUs when the next kernel is
+// emulated and the simulated kernels have completed.
+func (f *fastForwarder) Tick() bool {
+	if len(f.flushReqs) > 0 {
+		return f.processFlushRsp()
+	}
+
+	if !f.cachesDirty || !f.isFastForwarding() || !f.driverIsIdle() {
+		return false
+	}
+
+	for _, gpu := range f.driver.GPUs {
+		req := protocol.NewFlushReq(f.driver.gpuPort, gpu)
+		req.InvalidateAllCacheLines = true
+		f.driver.requestsToSend = append(f.driver.requestsToSend, req)
+		f.flushReqs = append(f.flushReqs, req)
+	}
+
+	return true
+}
+
+func (f *fastForwarder) driverIsIdle() bool {
+	if len(f.runningReqs) > 0 || len(f.driver.requestsToSend) > 0 {
+		return false
+	}
+
+	f.driver.contextMutex.Lock()
+	defer f.driver.contextMutex.Unlock()
+
+	for _, ctx := range f.driver.contexts {
+		ctx.queueMutex.Lock()
+		for _, q := range ctx.queues {
+			if q.IsRunning {
+				ctx.queueMutex.Unlock()
+				return false
+			}
+		}
+		ctx.queueMutex.Unlock()
+	}
+
+	return true
+}
+
+func (f *fastForwarder) processFlushRsp() bool {
+	rsp, ok := f.driver.gpuPort.PeekIncoming().(*sim.GeneralRsp)
+	if !ok {
+		return false
+	}
+
+	for i, req := range f.flushReqs {
+		if req != rsp.OriginalReq {
+			continue
+		}
+
+		f.driver.gpuPort.RetrieveIncoming()
+		f.flushReqs = append(f.flushReqs[:i], f.flushReqs[i+1:]...)
+
+		if len(f.flushReqs) == 0 {
+			f.cachesDirty = false
+		}
+
+		return true
+	}
+
+	return false
+}
+
+func isKernelCommand(cmd Command) bool {
+	switch cmd.(type) {
+	case *LaunchKernelCommand, *LaunchUnifiedMultiGPUKernelCommand:
+		return true
+	}
+
+	return false
+}
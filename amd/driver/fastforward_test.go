@@ -0,0 +1,128 @@
+package driver
+
+import (
+	"github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/akita/v4/mem/mem"
+	"github.com/sarchlab/akita/v4/mem/vm"
+	"github.com/sarchlab/akita/v4/sim"
+	"github.com/sarchlab/mgpusim/v4/amd/protocol"
+)
+
+var _ = ginkgo.Describe("Driver with fast-forwarding", func() {
+	var (
+		storage *mem.Storage
+		driver  *Driver
+		gpu     sim.Port
+		emuGPU  sim.Port
+		ctx     *Context
+		q       *CommandQueue
+	)
+
+	ginkgo.BeforeEach(func() {
+		storage = mem.NewStorage(8 * mem.GB)
+
+		driver = MakeBuilder().
+			WithEngine(sim.NewSerialEngine()).
+			WithLog2PageSize(12).
+			WithPageTable(vm.NewPageTable(12)).
+			WithGlobalStorage(storage).
+			WithFastForward(1).
+			Build("Driver")
+
+		gpu = sim.NewPort(driver, 1, 1, "GPU")
+		emuGPU = sim.NewPort(driver, 1, 1, "EmuGPU")
+		driver.RegisterGPU(gpu,
+			DeviceProperties{CUCount: 4, DRAMSize: 4 * mem.GB})
+		driver.RegisterFastForwardGPU(emuGPU)
+
+		ctx = driver.Init()
+		q = driver.CreateCommandQueue(ctx)
+	})
+
+	launchKernel := func() *protocol.LaunchKernelReq {
+		q.Enqueue(&LaunchKernelCommand{})
+		Expect(driver.processOneCommand(q)).To(BeTrue())
+
+		req := driver.requestsToSend[len(driver.requestsToSend)-1]
+		return req.(*protocol.LaunchKernelReq)
+	}
+
+	completeKernel := func(req *protocol.LaunchKernelReq) {
+		rsp := protocol.NewLaunchKernelRsp(
+			req.Dst, driver.gpuPort.AsRemote(), req.ID)
+		driver.processLaunchKernelReturn(rsp)
+	}
+
+	ginkgo.It("should launch the first kernels on the emulated GPUs", func() {
+		req := launchKernel()
+		Expect(req.Dst).To(Equal(emuGPU.AsRemote()))
+		completeKernel(req)
+
+		req = launchKernel()
+		Expect(req.Dst).To(Equal(gpu.AsRemote()))
+	})
+
+	ginkgo.It("should copy memory through the storage", func() {
+		ptr := driver.AllocateMemory(ctx, 4)
+		q.Enqueue(&MemCopyH2DCommand{Dst: ptr, Src: []byte{1, 2, 3, 4}})
+
+		Expect(driver.processOneCommand(q)).To(BeTrue())
+
+		page, _ := driver.pageTable.Find(ctx.pid, uint64(ptr))
+		data, _ := storage.Read(page.PAddr+uint64(ptr)-page.VAddr, 4)
+		Expect(data).To(Equal([]byte{1, 2, 3, 4}))
+		Expect(q.NumCommand()).To(Equal(0))
+	})
+
+	ginkgo.It("should wait for the emulated kernels before switching", func() {
+		q2 := driver.CreateCommandQueue(ctx)
+
+		req := launchKernel()
+
+		q2.Enqueue(&LaunchKernelCommand{})
+		Expect(driver.processOneCommand(q2)).To(BeFalse())
+
+		completeKernel(req)
+		Expect(driver.processOneCommand(q2)).To(BeTrue())
+	})
+
+	ginkgo.Context("with a kernel selector", func() {
+		ginkgo.BeforeEach(func() {
+			driver.fastForwarder.selector = kernelSet{0: true}
+		})
+
+		ginkgo.It("should flush the caches before emulating again", func() {
+			req := launchKernel()
+			Expect(req.Dst).To(Equal(gpu.AsRemote()))
+			driver.requestsToSend = nil
+			completeKernel(req)
+
+			q.Enqueue(&LaunchKernelCommand{})
+			Expect(driver.processOneCommand(q)).To(BeFalse())
+
+			Expect(driver.fastForwarder.Tick()).To(BeTrue())
+			flushReq := driver.requestsToSend[0].(*protocol.FlushReq)
+			Expect(flushReq.InvalidateAllCacheLines).To(BeTrue())
+			Expect(driver.processOneCommand(q)).To(BeFalse())
+
+			rsp := sim.GeneralRspBuilder{}.
+				WithSrc(gpu.AsRemote()).
+				WithDst(driver.gpuPort.AsRemote()).
+				WithOriginalReq(flushReq).
+				Build()
+			driver.gpuPort.Deliver(rsp)
+			Expect(driver.fastForwarder.Tick()).To(BeTrue())
+
+			Expect(driver.processOneCommand(q)).To(BeTrue())
+			req = driver.requestsToSend[1].(*protocol.LaunchKernelReq)
+			Expect(req.Dst).To(Equal(emuGPU.AsRemote()))
+		})
+	})
+})
+
+type kernelSet map[int]bool
+
+func (s kernelSet) SimulateInDetail(kernelIndex int) bool {
+	return s[kernelIndex]
+}
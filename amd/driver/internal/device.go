@@ -27,6 +27,15 @@ var MemoryAllocatorType = AllocatorTypeDefault
 type DeviceProperties struct {
 	CUCount  int
 	DRAMSize uint64
+
+	// WavefrontSize is the number of lanes in each wavefront that the GPU
+	// runs. Zero means that the GPU runs the wavefront size of the kernel.
+	WavefrontSize int
+
+	// MaxWavefrontsPerCU is the number of wavefronts that each CU can run
+	// at the same time, which bounds the scratch memory of a kernel. Zero
+	// lets the scratch memory hold all the wavefronts of the kernel.
+	MaxWavefrontsPerCU int
 }
 
 // Device is a CPU or GPU managed by the driver.
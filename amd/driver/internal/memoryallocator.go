@@ -15,6 +15,8 @@ type MemoryAllocator interface {
 	AllocateUnified(pid vm.PID, byteSize uint64) uint64
 	Free(vAddr uint64)
 	Remap(pid vm.PID, pageVAddr, byteSize uint64, deviceID int)
+	Pin(pid vm.PID, pageVAddr, byteSize uint64)
+	DeferPlacement(pid vm.PID, pageVAddr, byteSize uint64)
 	RemovePage(vAddr uint64)
 	AllocatePageWithGivenVAddr(
 		pid vm.PID,
@@ -172,6 +174,9 @@ func (a *memoryAllocatorImpl) allocatePages(
 	return nextVAddr
 }
 
+// Remap moves the pages to the given device and releases the physical pages
+// that the pages used to occupy. The data in the pages does not move. Unified
+// and pinned pages stay unified and pinned.
 func (a *memoryAllocatorImpl) Remap(
 	pid vm.PID,
 	pageVAddr, byteSize uint64,
@@ -181,14 +186,84 @@ func (a *memoryAllocatorImpl) Remap(
 	defer a.Unlock()
 
 	pageSize := uint64(1 << a.log2PageSize)
-	addr := pageVAddr
+	device := a.devices[deviceID]
+
+	for _, vAddr := range a.pageVAddrsInRange(pageVAddr, byteSize) {
+		oldPage, found := a.pageTable.Find(pid, vAddr)
+
+		page := vm.Page{
+			PID:      pid,
+			VAddr:    vAddr,
+			PAddr:    device.allocatePage(),
+			PageSize: pageSize,
+			Valid:    true,
+			DeviceID: uint64(deviceID),
+			Unified:  found && oldPage.Unified,
+			IsPinned: found && oldPage.IsPinned,
+		}
+		a.vAddrToPageMapping[page.VAddr] = page
+		a.pageTable.Update(page)
+
+		if found {
+			a.releasePAddr(oldPage.PAddr)
+		}
+	}
+}
+
+// Pin marks the pages so that page migration does not move them away from
+// the device that holds them.
+func (a *memoryAllocatorImpl) Pin(
+	pid vm.PID,
+	pageVAddr, byteSize uint64,
+) {
+	a.Lock()
+	defer a.Unlock()
+
+	for _, vAddr := range a.pageVAddrsInRange(pageVAddr, byteSize) {
+		page, found := a.pageTable.Find(pid, vAddr)
+		if !found {
+			panic("page not found")
+		}
+
+		page.IsPinned = true
+		a.vAddrToPageMapping[page.VAddr] = page
+		a.pageTable.Update(page)
+	}
+}
+
+// DeferPlacement keeps the physical pages in place, but marks them as unified
+// pages that are not owned by any GPU. The first GPU that accesses such a page
+// triggers a page migration that moves the page to the GPU.
+func (a *memoryAllocatorImpl) DeferPlacement(
+	pid vm.PID,
+	pageVAddr, byteSize uint64,
+) {
+	a.Lock()
+	defer a.Unlock()
+
+	for _, vAddr := range a.pageVAddrsInRange(pageVAddr, byteSize) {
+		page, found := a.pageTable.Find(pid, vAddr)
+		if !found {
+			panic("page not found")
+		}
+
+		page.DeviceID = 0
+		page.Unified = true
+		a.vAddrToPageMapping[page.VAddr] = page
+		a.pageTable.Update(page)
+	}
+}
+
+func (a *memoryAllocatorImpl) pageVAddrsInRange(
+	pageVAddr, byteSize uint64,
+) []uint64 {
+	pageSize := uint64(1 << a.log2PageSize)
 	vAddrs := make([]uint64, 0)
-	for addr < pageVAddr+byteSize {
+	for addr := pageVAddr; addr < pageVAddr+byteSize; addr += pageSize {
 		vAddrs = append(vAddrs, addr)
-		addr += pageSize
 	}
 
-	a.allocateMultiplePagesWithGivenVAddrs(pid, deviceID, vAddrs, false)
+	return vAddrs
 }
 
 func (a *memoryAllocatorImpl) RemovePage(vAddr uint64) {
@@ -205,13 +280,18 @@ func (a *memoryAllocatorImpl) removePage(vAddr uint64) {
 		panic("page not found")
 	}
 
-	deviceID := a.deviceIDByPAddr(page.PAddr)
-	dState := a.devices[deviceID].MemState
-	dState.addSinglePAddr(page.PAddr)
+	a.releasePAddr(page.PAddr)
 
 	a.pageTable.Remove(page.PID, page.VAddr)
 }
 
+// releasePAddr returns a physical page to the device that owns it.
+func (a *memoryAllocatorImpl) releasePAddr(pAddr uint64) {
+	deviceID := a.deviceIDByPAddr(pAddr)
+	dState := a.devices[deviceID].MemState
+	dState.addSinglePAddr(pAddr)
+}
+
 func (a *memoryAllocatorImpl) AllocatePageWithGivenVAddr(
 	pid vm.PID,
 	deviceID int,
@@ -250,35 +330,6 @@ func (a *memoryAllocatorImpl) allocatePageWithGivenVAddr(
 	return page
 }
 
-func (a *memoryAllocatorImpl) allocateMultiplePagesWithGivenVAddrs(
-	pid vm.PID,
-	deviceID int,
-	vAddrs []uint64,
-	isUnified bool,
-) (pages []vm.Page) {
-	pageSize := uint64(1 << a.log2PageSize)
-
-	device := a.devices[deviceID]
-	pAddrs := device.allocateMultiplePages(len(vAddrs))
-
-	for i, vAddr := range vAddrs {
-		page := vm.Page{
-			PID:      pid,
-			VAddr:    vAddr,
-			PAddr:    pAddrs[i],
-			PageSize: pageSize,
-			Valid:    true,
-			DeviceID: uint64(deviceID),
-			Unified:  isUnified,
-		}
-		a.vAddrToPageMapping[page.VAddr] = page
-		a.pageTable.Update(page)
-		pages = append(pages, page)
-	}
-
-	return pages
-}
-
 func (a *memoryAllocatorImpl) Free(ptr uint64) {
 	a.Lock()
 	defer a.Unlock()
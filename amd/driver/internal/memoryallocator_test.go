@@ -91,9 +91,91 @@ var _ = Describe("MemoryAllocatorImpl", func() {
 		updatedPage := page
 		updatedPage.PAddr = 0x2_0000_1000
 		updatedPage.DeviceID = 2
+		pageTable.EXPECT().Find(vm.PID(1), uint64(4096)).Return(page, true)
 		pageTable.EXPECT().Update(updatedPage)
 		allocator.Remap(1, ptr, 4000, 2)
 	})
+
+	It("should keep unified pages unpinned when remapping", func() {
+		page := vm.Page{
+			PID:      1,
+			PAddr:    0x1_0000_1000,
+			VAddr:    4096,
+			PageSize: 4096,
+			DeviceID: 1,
+			Valid:    true,
+			Unified:  true,
+		}
+		pageTable.EXPECT().Insert(page)
+		ptr := allocator.AllocateUnified(1, 4000)
+
+		updatedPage := page
+		updatedPage.PAddr = 0x3_0000_1000
+		updatedPage.DeviceID = 3
+		pageTable.EXPECT().Find(vm.PID(1), uint64(4096)).Return(page, true)
+		pageTable.EXPECT().Update(updatedPage)
+		allocator.Remap(1, ptr, 4000, 3)
+	})
+
+	It("should release the old physical page when remapping", func() {
+		page := vm.Page{
+			PID:      1,
+			PAddr:    0x1_0000_1000,
+			VAddr:    4096,
+			PageSize: 4096,
+			DeviceID: 1,
+			Valid:    true,
+		}
+		pageTable.EXPECT().Insert(page)
+		ptr := allocator.Allocate(1, 4000, 1)
+
+		pageTable.EXPECT().Find(vm.PID(1), uint64(4096)).Return(page, true)
+		pageTable.EXPECT().Update(gomock.Any())
+		allocator.Remap(1, ptr, 4000, 2)
+
+		memState := allocator.devices[1].MemState.(*deviceMemoryStateImpl)
+		Expect(memState.availablePAddrs).
+			To(ContainElement(uint64(0x1_0000_1000)))
+	})
+
+	It("should pin pages", func() {
+		page := vm.Page{
+			PID:      1,
+			PAddr:    0x1_0000_1000,
+			VAddr:    4096,
+			PageSize: 4096,
+			DeviceID: 1,
+			Valid:    true,
+		}
+		pageTable.EXPECT().Insert(page)
+		ptr := allocator.Allocate(1, 4000, 1)
+
+		pinnedPage := page
+		pinnedPage.IsPinned = true
+		pageTable.EXPECT().Find(vm.PID(1), uint64(4096)).Return(page, true)
+		pageTable.EXPECT().Update(pinnedPage)
+		allocator.Pin(1, ptr, 4000)
+	})
+
+	It("should defer the placement of pages", func() {
+		page := vm.Page{
+			PID:      1,
+			PAddr:    0x1_0000_1000,
+			VAddr:    4096,
+			PageSize: 4096,
+			DeviceID: 1,
+			Valid:    true,
+		}
+		pageTable.EXPECT().Insert(page)
+		ptr := allocator.Allocate(1, 4000, 1)
+
+		updatedPage := page
+		updatedPage.DeviceID = 0
+		updatedPage.Unified = true
+		pageTable.EXPECT().Find(vm.PID(1), uint64(4096)).Return(page, true)
+		pageTable.EXPECT().Update(updatedPage)
+		allocator.DeferPlacement(1, ptr, 4000)
+	})
 })
 
 func configAFourGPUSystem(allocator *memoryAllocatorImpl) {
@@ -2,6 +2,7 @@ package driver
 
 import (
 	"encoding/binary"
+	"log"
 	"reflect"
 
 	"github.com/sarchlab/akita/v4/sim"
@@ -19,6 +20,8 @@ func (d *Driver) EnqueueLaunchKernel(
 	kernelArgs interface{},
 ) {
 	dev := d.devices[queue.GPUID]
+	d.mustRunWavefrontSize(dev, co)
+	queue.Context.markAllBuffersPopulated()
 
 	if dev.Type == internal.DeviceTypeUnifiedGPU {
 		d.enqueueLaunchUnifiedKernel(queue, co, gridSize, wgSize, kernelArgs)
@@ -36,6 +39,8 @@ func (d *Driver) EnqueueLaunchKernel(
 
 		aqlPacket := d.createAQLPacket(gridSize, wgSize, dCoData, dKernArgData)
 		newKernelArgs := d.prepareLocalMemory(co, kernelArgs, aqlPacket)
+		dScratch, scratchSize, replacedScratch := d.prepareScratchMemory(
+			queue, queue.GPUID, co, aqlPacket)
 
 		if !cached {
 			d.EnqueueMemCopyH2D(queue, dCoData, co.Data)
@@ -43,7 +48,30 @@ func (d *Driver) EnqueueLaunchKernel(
 		d.EnqueueMemCopyH2D(queue, dKernArgData, newKernelArgs)
 		d.EnqueueMemCopyH2D(queue, dPacket, aqlPacket)
 
-		d.enqueueLaunchKernelCommand(queue, co, aqlPacket, dPacket)
+		d.enqueueLaunchKernelCommand(queue, co, aqlPacket, dPacket,
+			dScratch, scratchSize, replacedScratch)
+	}
+}
+
+// mustRunWavefrontSize rejects the kernels that are compiled for a wavefront
+// size that the GPUs of the device do not run.
+func (d *Driver) mustRunWavefrontSize(
+	dev *internal.Device,
+	co *insts.KernelCodeObject,
+) {
+	gpuIDs := []int{dev.ID}
+	if dev.Type == internal.DeviceTypeUnifiedGPU {
+		gpuIDs = dev.UnifiedGPUIDs
+	}
+
+	kernelWaveSize := kernels.KernelWavefrontSize(co)
+	for _, gpuID := range gpuIDs {
+		gpuWaveSize := d.devices[gpuID].Properties.WavefrontSize
+		if gpuWaveSize != 0 && gpuWaveSize != kernelWaveSize {
+			log.Panicf("the kernel is compiled for wave%d, "+
+				"but GPU %d runs wave%d wavefronts",
+				kernelWaveSize, gpuID, gpuWaveSize)
+		}
 	}
 }
 
@@ -65,24 +93,26 @@ func (d *Driver) prepareLocalMemory(
 	kernelArgs interface{},
 	packet *kernels.HsaKernelDispatchPacket,
 ) (newKernelArgs interface{}) {
+	if rawArgs, ok := kernelArgs.(*RawKernelArgs); ok {
+		packet.GroupSegmentSize = co.GroupSegmentByteSize +
+			rawArgs.DynamicLDSSize
+		return rawArgs.Data
+	}
+
 	newKernelArgs = reflect.New(reflect.TypeOf(kernelArgs).Elem()).Interface()
 	reflect.ValueOf(newKernelArgs).Elem().
 		Set(reflect.ValueOf(kernelArgs).Elem())
 
 	ldsSize := co.GroupSegmentByteSize
 
-	if reflect.TypeOf(newKernelArgs).Kind() == reflect.Slice {
-		// From server, do nothing
-	} else {
-		kernArgStruct := reflect.ValueOf(newKernelArgs).Elem()
-		for i := 0; i < kernArgStruct.NumField(); i++ {
-			arg := kernArgStruct.Field(i).Interface()
-
-			switch ldsPtr := arg.(type) {
-			case LocalPtr:
-				kernArgStruct.Field(i).SetUint(uint64(ldsSize))
-				ldsSize += uint32(ldsPtr)
-			}
+	kernArgStruct := reflect.ValueOf(newKernelArgs).Elem()
+	for i := 0; i < kernArgStruct.NumField(); i++ {
+		arg := kernArgStruct.Field(i).Interface()
+
+		switch ldsPtr := arg.(type) {
+		case LocalPtr:
+			kernArgStruct.Field(i).SetUint(uint64(ldsSize))
+			ldsSize += uint32(ldsPtr)
 		}
 	}
 
@@ -91,18 +121,117 @@ func (d *Driver) prepareLocalMemory(
 	return newKernelArgs
 }
 
+// prepareScratchMemory makes sure that the scratch segment that the queue
+// uses on the GPU can hold the private memory of the kernel. The segment only
+// grows. A segment that is too small is replaced, and the replaced segment is
+// returned so that it can be freed when the kernel starts, as the kernels
+// before it in the queue may still use it. The last segment is freed when the
+// queue is released.
+func (d *Driver) prepareScratchMemory(
+	queue *CommandQueue,
+	gpuID int,
+	co *insts.KernelCodeObject,
+	packet *kernels.HsaKernelDispatchPacket,
+) (dScratch Ptr, size uint64, replaced []Ptr) {
+	size = d.scratchSegmentSize(d.devices[gpuID], co, packet)
+	if size == 0 {
+		return 0, 0, nil
+	}
+
+	if queue.scratch == nil {
+		queue.scratch = make(map[int]scratchSegment)
+	}
+
+	segment := queue.scratch[gpuID]
+	if segment.size < size {
+		if segment.size > 0 {
+			replaced = append(replaced, segment.ptr)
+		}
+
+		segment = scratchSegment{
+			ptr:  d.AllocateMemory(queue.Context, size),
+			size: size,
+		}
+		queue.scratch[gpuID] = segment
+	}
+
+	return segment.ptr, segment.size, replaced
+}
+
+// scratchSegmentSize returns the number of bytes that the kernel needs to
+// hold the private memory of the wavefronts that the GPU can run at the same
+// time. It also records the per-lane private segment size in the packet.
+func (d *Driver) scratchSegmentSize(
+	dev *internal.Device,
+	co *insts.KernelCodeObject,
+	packet *kernels.HsaKernelDispatchPacket,
+) uint64 {
+	if co.PrivateSegmentByteSize == 0 {
+		return 0
+	}
+
+	packet.PrivateSegmentSize = co.PrivateSegmentByteSize
+	waveSize := kernels.ScratchWaveByteSize(packet.PrivateSegmentSize)
+
+	laneCount := kernels.KernelWavefrontSize(co)
+	numWf := packet.NumWavefronts(laneCount)
+
+	props := dev.Properties
+	if props.CUCount > 0 && props.MaxWavefrontsPerCU > 0 {
+		numResidentWf := max(props.CUCount*props.MaxWavefrontsPerCU,
+			packet.NumWavefrontsPerWG(laneCount))
+		numWf = min(numWf, numResidentWf)
+	}
+
+	return waveSize * uint64(numWf)
+}
+
+// freeScratchMemory frees the scratch segments that a kernel replaces. The
+// kernels that use them have completed, as the queue runs the kernels in
+// order.
+func (d *Driver) freeScratchMemory(queue *CommandQueue, segments *[]Ptr) {
+	for _, ptr := range *segments {
+		err := d.FreeMemory(queue.Context, ptr)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	*segments = nil
+}
+
+// LoadCodeObject copies a code object to the GPU memory. Otherwise, the code
+// object is copied when the first kernel that uses it is enqueued, and the
+// kernels that use it in the other command queues must wait for that kernel.
+func (d *Driver) LoadCodeObject(ctx *Context, co *insts.KernelCodeObject) {
+	if _, cached := d.codeObjGPUAddrs[co]; cached {
+		return
+	}
+
+	dCoData := d.AllocateMemory(ctx, uint64(len(co.Data)))
+	d.codeObjGPUAddrs[co] = dCoData
+	d.MemCopyH2D(ctx, dCoData, co.Data)
+}
+
 // LaunchKernel is an easy way to run a kernel on the GCN3 simulator. It
-// launches the kernel immediately.
+// launches the kernel immediately. It returns a *protocol.MemoryFault if the
+// kernel accesses an unmapped address, in which case the GPU aborts the
+// kernel.
 func (d *Driver) LaunchKernel(
 	ctx *Context,
 	co *insts.KernelCodeObject,
 	gridSize [3]uint32,
 	wgSize [3]uint16,
 	kernelArgs interface{},
-) {
+) error {
 	queue := d.CreateCommandQueue(ctx)
 	d.EnqueueLaunchKernel(queue, co, gridSize, wgSize, kernelArgs)
 	d.DrainCommandQueue(queue)
+
+	err := queue.Err()
+	d.ReleaseCommandQueue(queue)
+
+	return err
 }
 
 func (d *Driver) createAQLPacket(
@@ -128,12 +257,18 @@ func (d *Driver) enqueueLaunchKernelCommand(
 	co *insts.KernelCodeObject,
 	packet *kernels.HsaKernelDispatchPacket,
 	dPacket Ptr,
+	dScratch Ptr,
+	scratchSize uint64,
+	replacedScratch []Ptr,
 ) {
 	cmd := &LaunchKernelCommand{
-		ID:         sim.GetIDGenerator().Generate(),
-		CodeObject: co,
-		DPacket:    dPacket,
-		Packet:     packet,
+		ID:              sim.GetIDGenerator().Generate(),
+		CodeObject:      co,
+		DPacket:         dPacket,
+		Packet:          packet,
+		DScratch:        dScratch,
+		ScratchByteSize: scratchSize,
+		replacedScratch: replacedScratch,
 	}
 	d.Enqueue(queue, cmd)
 }
@@ -143,12 +278,18 @@ func (d *Driver) enqueueLaunchUnifiedKernelCommand(
 	co *insts.KernelCodeObject,
 	packet []*kernels.HsaKernelDispatchPacket,
 	dPacket []Ptr,
+	dScratch []Ptr,
+	scratchSize []uint64,
+	replacedScratch []Ptr,
 ) {
 	cmd := &LaunchUnifiedMultiGPUKernelCommand{
-		ID:           sim.GetIDGenerator().Generate(),
-		CodeObject:   co,
-		DPacketArray: dPacket,
-		PacketArray:  packet,
+		ID:                   sim.GetIDGenerator().Generate(),
+		CodeObject:           co,
+		DPacketArray:         dPacket,
+		PacketArray:          packet,
+		DScratchArray:        dScratch,
+		ScratchByteSizeArray: scratchSize,
+		replacedScratch:      replacedScratch,
 	}
 	d.Enqueue(queue, cmd)
 }
@@ -166,6 +307,9 @@ func (d *Driver) enqueueLaunchUnifiedKernel(
 	dKernArgDataArray := make([]Ptr, len(dev.UnifiedGPUIDs)+1)
 	dPacketArray := make([]Ptr, len(dev.UnifiedGPUIDs)+1)
 	packetArray := make([]*kernels.HsaKernelDispatchPacket, len(dev.UnifiedGPUIDs)+1)
+	dScratchArray := make([]Ptr, len(dev.UnifiedGPUIDs)+1)
+	scratchSizeArray := make([]uint64, len(dev.UnifiedGPUIDs)+1)
+	var replacedScratch []Ptr
 	// fmt.Printf("# of GPUs : %v \n", len(dev.UnifiedGPUIDs))
 
 	for i, gpuID := range dev.UnifiedGPUIDs {
@@ -176,6 +320,12 @@ func (d *Driver) enqueueLaunchUnifiedKernel(
 		packet := d.createAQLPacket(gridSize, wgSize, dCoData, dKernArgData)
 		newKernelArgs := d.prepareLocalMemory(co, kernelArgs, packet)
 
+		dScratch, scratchSize, replaced := d.prepareScratchMemory(
+			queue, gpuID, co, packet)
+		dScratchArray[i] = dScratch
+		scratchSizeArray[i] = scratchSize
+		replacedScratch = append(replacedScratch, replaced...)
+
 		d.EnqueueMemCopyH2D(queue, dCoData, co.Data)
 		d.EnqueueMemCopyH2D(queue, dKernArgData, newKernelArgs)
 		d.EnqueueMemCopyH2D(queue, dPacket, packet)
@@ -188,5 +338,6 @@ func (d *Driver) enqueueLaunchUnifiedKernel(
 	}
 
 	queue.Context.currentGPUID = initGPUID
-	d.enqueueLaunchUnifiedKernelCommand(queue, co, packetArray, dPacketArray)
+	d.enqueueLaunchUnifiedKernelCommand(queue, co, packetArray, dPacketArray,
+		dScratchArray, scratchSizeArray, replacedScratch)
 }
@@ -5,3 +5,12 @@ type Ptr uint64
 
 // LocalPtr is a type that represent a pointer to a region in the LDS memory
 type LocalPtr uint32
+
+// RawKernelArgs are kernel arguments that are already serialized, for example,
+// by a host program that runs outside of the simulator. DynamicLDSSize is the
+// number of bytes of LDS memory to allocate in addition to the LDS memory that
+// the kernel declares.
+type RawKernelArgs struct {
+	Data           []byte
+	DynamicLDSSize uint32
+}
@@ -38,10 +38,6 @@ func (m *defaultMemoryCopyMiddleware) processMemCopyH2DCommand(
 	cmd *MemCopyH2DCommand,
 	queue *CommandQueue,
 ) bool {
-	if m.needFlushing(queue.Context, cmd.Dst, uint64(binary.Size(cmd.Src))) {
-		m.sendFlushRequest(cmd)
-	}
-
 	buffer := bytes.NewBuffer(nil)
 	err := binary.Write(buffer, binary.LittleEndian, cmd.Src)
 	if err != nil {
@@ -49,35 +45,29 @@ func (m *defaultMemoryCopyMiddleware) processMemCopyH2DCommand(
 	}
 	rawBytes := buffer.Bytes()
 
-	offset := uint64(0)
-	addr := uint64(cmd.Dst)
-	sizeLeft := uint64(len(rawBytes))
-	for sizeLeft > 0 {
-		page, found := m.driver.pageTable.Find(queue.Context.pid, addr)
-		if !found {
-			panic("page not found")
-		}
+	chunks, fault := m.driver.splitByPage(queue.Context, cmd.Dst,
+		uint64(len(rawBytes)), protocol.MemoryAccessWrite)
+	if fault != nil {
+		m.driver.abortCommandWithFault(queue, fault)
+		return true
+	}
 
-		pAddr := page.PAddr + (addr - page.VAddr)
-		sizeLeftInPage := page.PageSize - (addr - page.VAddr)
-		sizeToCopy := sizeLeftInPage
-		if sizeLeft < sizeLeftInPage {
-			sizeToCopy = sizeLeft
-		}
+	m.driver.markInitialized(queue.Context, cmd.Dst, uint64(len(rawBytes)))
 
-		gpuID := m.driver.memAllocator.GetDeviceIDByPAddr(pAddr)
+	if m.needFlushing(queue.Context, cmd.Dst, uint64(len(rawBytes))) {
+		m.sendFlushRequest(cmd)
+	}
+
+	for _, c := range chunks {
+		gpuID := m.driver.memAllocator.GetDeviceIDByPAddr(c.pAddr)
 		req := protocol.NewMemCopyH2DReq(
 			m.driver.gpuPort, m.driver.GPUs[gpuID-1],
-			rawBytes[offset:offset+sizeToCopy],
-			pAddr)
+			rawBytes[c.offset:c.offset+c.size],
+			c.pAddr)
 		cmd.Reqs = append(cmd.Reqs, req)
 		m.awaitingReqs = append(m.awaitingReqs, req)
 		// m.driver.requestsToSend = append(m.driver.requestsToSend, req)
 
-		sizeLeft -= sizeToCopy
-		addr += sizeToCopy
-		offset += sizeToCopy
-
 		m.driver.logTaskToGPUInitiate(cmd, req)
 	}
 
@@ -92,41 +82,29 @@ func (m *defaultMemoryCopyMiddleware) processMemCopyD2HCommand(
 	cmd *MemCopyD2HCommand,
 	queue *CommandQueue,
 ) bool {
-	if m.needFlushing(queue.Context, cmd.Src, uint64(binary.Size(cmd.Dst))) {
-		m.sendFlushRequest(cmd)
-		queue.Context.removeFreedBuffers()
-	}
-
 	cmd.RawData = make([]byte, binary.Size(cmd.Dst))
 
-	offset := uint64(0)
-	addr := uint64(cmd.Src)
-	sizeLeft := uint64(len(cmd.RawData))
-	for sizeLeft > 0 {
-		page, found := m.driver.pageTable.Find(queue.Context.pid, addr)
-		if !found {
-			panic("page not found")
-		}
+	chunks, fault := m.driver.splitByPage(queue.Context, cmd.Src,
+		uint64(len(cmd.RawData)), protocol.MemoryAccessRead)
+	if fault != nil {
+		m.driver.abortCommandWithFault(queue, fault)
+		return true
+	}
 
-		pAddr := page.PAddr + (addr - page.VAddr)
-		sizeLeftInPage := page.PageSize - (addr - page.VAddr)
-		sizeToCopy := sizeLeftInPage
-		if sizeLeft < sizeLeftInPage {
-			sizeToCopy = sizeLeft
-		}
+	if m.needFlushing(queue.Context, cmd.Src, uint64(len(cmd.RawData))) {
+		m.sendFlushRequest(cmd)
+		queue.Context.removeFreedBuffers()
+	}
 
-		gpuID := m.driver.memAllocator.GetDeviceIDByPAddr(pAddr)
+	for _, c := range chunks {
+		gpuID := m.driver.memAllocator.GetDeviceIDByPAddr(c.pAddr)
 		req := protocol.NewMemCopyD2HReq(
 			m.driver.gpuPort, m.driver.GPUs[gpuID-1],
-			pAddr, cmd.RawData[offset:offset+sizeToCopy])
+			c.pAddr, cmd.RawData[c.offset:c.offset+c.size])
 		cmd.Reqs = append(cmd.Reqs, req)
 		m.awaitingReqs = append(m.awaitingReqs, req)
 		// m.driver.requestsToSend = append(m.driver.requestsToSend, req)
 
-		sizeLeft -= sizeToCopy
-		addr += sizeToCopy
-		offset += sizeToCopy
-
 		m.driver.logTaskToGPUInitiate(cmd, req)
 	}
 
@@ -297,3 +275,77 @@ func (m *defaultMemoryCopyMiddleware) processFlushReturn(
 
 	return true
 }
+
+// A memChunk is the part of an address range that is in one page.
+type memChunk struct {
+	pAddr  uint64
+	offset uint64
+	size   uint64
+}
+
+// splitByPage splits an address range into the parts that are in different
+// pages. It returns a memory fault if a part of the range is not mapped in the
+// page table of the context.
+func (d *Driver) splitByPage(
+	ctx *Context,
+	ptr Ptr,
+	byteSize uint64,
+	access protocol.MemoryAccessType,
+) ([]memChunk, *protocol.MemoryFault) {
+	var chunks []memChunk
+
+	addr := uint64(ptr)
+	offset := uint64(0)
+	sizeLeft := byteSize
+	for sizeLeft > 0 {
+		page, found := d.pageTable.Find(ctx.pid, addr)
+		if !found {
+			return nil, &protocol.MemoryFault{
+				Lane:    -1,
+				PID:     ctx.pid,
+				Address: addr,
+				Access:  access,
+			}
+		}
+
+		pAddr := page.PAddr + (addr - page.VAddr)
+		sizeLeftInPage := page.PageSize - (addr - page.VAddr)
+		sizeToCopy := sizeLeftInPage
+		if sizeLeft < sizeLeftInPage {
+			sizeToCopy = sizeLeft
+		}
+
+		chunks = append(chunks, memChunk{
+			pAddr:  pAddr,
+			offset: offset,
+			size:   sizeToCopy,
+		})
+
+		sizeLeft -= sizeToCopy
+		addr += sizeToCopy
+		offset += sizeToCopy
+	}
+
+	return chunks, nil
+}
+
+// abortCommandWithFault completes the command at the head of the queue
+// without executing it, as the command accesses an unmapped address.
+func (d *Driver) abortCommandWithFault(
+	queue *CommandQueue,
+	fault *protocol.MemoryFault,
+) {
+	queue.recordFault(fault)
+	queue.IsRunning = false
+	queue.Dequeue()
+}
+
+// markInitialized tells the sanitizer, if any, that a memory copy writes the
+// bytes in [ptr, ptr+byteSize).
+func (d *Driver) markInitialized(ctx *Context, ptr Ptr, byteSize uint64) {
+	if d.sanitizer == nil {
+		return
+	}
+
+	d.sanitizer.MarkInitialized(ctx.pid, uint64(ptr), byteSize)
+}
@@ -3,6 +3,8 @@ package driver
 import (
 	"bytes"
 	"encoding/binary"
+
+	"github.com/sarchlab/mgpusim/v4/amd/protocol"
 )
 
 // defaultMemoryCopyMiddleware handles memory copy commands and related
@@ -36,27 +38,18 @@ func (m *globalStorageMemoryCopyMiddleware) processMemCopyH2DCommand(
 	}
 	rawBytes := buffer.Bytes()
 
-	offset := uint64(0)
-	addr := uint64(cmd.Dst)
-	sizeLeft := uint64(len(rawBytes))
-	for sizeLeft > 0 {
-		page, found := m.driver.pageTable.Find(queue.Context.pid, addr)
-		if !found {
-			panic("page not found")
-		}
-
-		pAddr := page.PAddr + (addr - page.VAddr)
-		sizeLeftInPage := page.PageSize - (addr - page.VAddr)
-		sizeToCopy := sizeLeftInPage
-		if sizeLeft < sizeLeftInPage {
-			sizeToCopy = sizeLeft
-		}
-
-		m.driver.globalStorage.Write(pAddr, rawBytes[offset:offset+sizeToCopy])
-
-		sizeLeft -= sizeToCopy
-		addr += sizeToCopy
-		offset += sizeToCopy
+	chunks, fault := m.driver.splitByPage(queue.Context, cmd.Dst,
+		uint64(len(rawBytes)), protocol.MemoryAccessWrite)
+	if fault != nil {
+		m.driver.abortCommandWithFault(queue, fault)
+		return true
+	}
+
+	m.driver.markInitialized(queue.Context, cmd.Dst, uint64(len(rawBytes)))
+
+	for _, c := range chunks {
+		m.driver.globalStorage.Write(c.pAddr,
+			rawBytes[c.offset:c.offset+c.size])
 	}
 
 	queue.IsRunning = false
@@ -71,28 +64,16 @@ func (m *globalStorageMemoryCopyMiddleware) processMemCopyD2HCommand(
 ) bool {
 	cmd.RawData = make([]byte, binary.Size(cmd.Dst))
 
-	offset := uint64(0)
-	addr := uint64(cmd.Src)
-	sizeLeft := uint64(len(cmd.RawData))
-	for sizeLeft > 0 {
-		page, found := m.driver.pageTable.Find(queue.Context.pid, addr)
-		if !found {
-			panic("page not found")
-		}
-
-		pAddr := page.PAddr + (addr - page.VAddr)
-		sizeLeftInPage := page.PageSize - (addr - page.VAddr)
-		sizeToCopy := sizeLeftInPage
-		if sizeLeft < sizeLeftInPage {
-			sizeToCopy = sizeLeft
-		}
-
-		data, _ := m.driver.globalStorage.Read(pAddr, sizeToCopy)
-		copy(cmd.RawData[offset:], data)
-
-		sizeLeft -= sizeToCopy
-		addr += sizeToCopy
-		offset += sizeToCopy
+	chunks, fault := m.driver.splitByPage(queue.Context, cmd.Src,
+		uint64(len(cmd.RawData)), protocol.MemoryAccessRead)
+	if fault != nil {
+		m.driver.abortCommandWithFault(queue, fault)
+		return true
+	}
+
+	for _, c := range chunks {
+		data, _ := m.driver.globalStorage.Read(c.pAddr, c.size)
+		copy(cmd.RawData[c.offset:], data)
 	}
 
 	buf := bytes.NewReader(cmd.RawData)
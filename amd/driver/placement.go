@@ -0,0 +1,157 @@
+package driver
+
+// PlaceOnFirstTouch is the GPU ID that a PlacementPolicy returns for a page
+// that should not be placed yet. Such a page is placed on the first GPU that
+// accesses it, which requires the GPUs to support page migration.
+const PlaceOnFirstTouch = 0
+
+// A PlacementPolicy decides which GPU holds each page of a memory region.
+type PlacementPolicy interface {
+	// Place returns the ID of the GPU that should hold each of the numPages
+	// pages starting at vAddr. Each ID is either one of gpuIDs or
+	// PlaceOnFirstTouch.
+	Place(
+		ctx *Context,
+		vAddr, pageSize uint64,
+		numPages int,
+		gpuIDs []int,
+	) []int
+}
+
+// BlockPlacement partitions a region into consecutive blocks of pages, one
+// block per GPU. The pages that cannot be evenly divided go to the last GPU
+// that receives a block.
+type BlockPlacement struct{}
+
+// Place implements PlacementPolicy.
+func (p BlockPlacement) Place(
+	_ *Context,
+	_, _ uint64,
+	numPages int,
+	gpuIDs []int,
+) []int {
+	placement := make([]int, numPages)
+
+	numPagesPerGPU := numPages / len(gpuIDs)
+	if numPagesPerGPU == 0 {
+		for i := range placement {
+			placement[i] = gpuIDs[0]
+		}
+
+		return placement
+	}
+
+	for i := range placement {
+		block := i / numPagesPerGPU
+		if block >= len(gpuIDs) {
+			block = len(gpuIDs) - 1
+		}
+
+		placement[i] = gpuIDs[block]
+	}
+
+	return placement
+}
+
+// RoundRobinPlacement interleaves the pages of a region across the GPUs, one
+// page at a time.
+type RoundRobinPlacement struct{}
+
+// Place implements PlacementPolicy.
+func (p RoundRobinPlacement) Place(
+	_ *Context,
+	_, _ uint64,
+	numPages int,
+	gpuIDs []int,
+) []int {
+	placement := make([]int, numPages)
+	for i := range placement {
+		placement[i] = gpuIDs[i%len(gpuIDs)]
+	}
+
+	return placement
+}
+
+// FirstTouchPlacement leaves all the pages unplaced, so that each page moves
+// to the first GPU that accesses it.
+type FirstTouchPlacement struct{}
+
+// Place implements PlacementPolicy.
+func (p FirstTouchPlacement) Place(
+	_ *Context,
+	_, _ uint64,
+	numPages int,
+	_ []int,
+) []int {
+	placement := make([]int, numPages)
+	for i := range placement {
+		placement[i] = PlaceOnFirstTouch
+	}
+
+	return placement
+}
+
+// AdvisedPlacement places the pages covered by MemAdvise hints on the
+// preferred GPUs. The other pages are placed by the Fallback policy, or in
+// blocks if Fallback is nil.
+type AdvisedPlacement struct {
+	Fallback PlacementPolicy
+}
+
+// Place implements PlacementPolicy.
+func (p AdvisedPlacement) Place(
+	ctx *Context,
+	vAddr, pageSize uint64,
+	numPages int,
+	gpuIDs []int,
+) []int {
+	var fallback PlacementPolicy = BlockPlacement{}
+	if p.Fallback != nil {
+		fallback = p.Fallback
+	}
+
+	placement := fallback.Place(ctx, vAddr, pageSize, numPages, gpuIDs)
+	for i := range placement {
+		gpuID, advised := ctx.preferredGPU(vAddr + uint64(i)*pageSize)
+		if advised {
+			placement[i] = gpuID
+		}
+	}
+
+	return placement
+}
+
+// MemAdvice is a hint about where the pages of a memory range should live.
+type MemAdvice struct {
+	// PreferredGPU is the ID of the GPU that should hold the pages. Zero
+	// removes the earlier preference of the range.
+	PreferredGPU int
+
+	// Pin keeps the pages on the preferred GPU, so that page migration does
+	// not move them away.
+	Pin bool
+}
+
+type memAdviceRange struct {
+	vAddr, byteSize uint64
+	advice          MemAdvice
+}
+
+// preferredGPU returns the GPU that the most recent hint covering the given
+// address prefers.
+func (c *Context) preferredGPU(vAddr uint64) (int, bool) {
+	for i := len(c.memAdvices) - 1; i >= 0; i-- {
+		r := c.memAdvices[i]
+		if vAddr < r.vAddr || vAddr >= r.vAddr+r.byteSize {
+			continue
+		}
+
+		if r.advice.PreferredGPU == 0 {
+			return 0, false
+		}
+
+		return r.advice.PreferredGPU, true
+	}
+
+	return 0, false
+}
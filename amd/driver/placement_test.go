@@ -0,0 +1,149 @@
+package driver
+
+import (
+	"github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/akita/v4/mem/mem"
+	"github.com/sarchlab/akita/v4/mem/vm"
+	"github.com/sarchlab/akita/v4/sim"
+)
+
+var _ = ginkgo.Describe("Placement policies", func() {
+	var ctx *Context
+
+	ginkgo.BeforeEach(func() {
+		ctx = &Context{pid: 1}
+	})
+
+	ginkgo.It("should place pages in blocks", func() {
+		p := BlockPlacement{}
+
+		Expect(p.Place(ctx, 0x1000, 0x1000, 5, []int{1, 2, 3})).
+			To(Equal([]int{1, 2, 3, 3, 3}))
+		Expect(p.Place(ctx, 0x1000, 0x1000, 2, []int{1, 2, 3})).
+			To(Equal([]int{1, 1}))
+	})
+
+	ginkgo.It("should interleave pages", func() {
+		p := RoundRobinPlacement{}
+
+		Expect(p.Place(ctx, 0x1000, 0x1000, 5, []int{2, 3})).
+			To(Equal([]int{2, 3, 2, 3, 2}))
+	})
+
+	ginkgo.It("should defer pages to the first touch", func() {
+		p := FirstTouchPlacement{}
+
+		Expect(p.Place(ctx, 0x1000, 0x1000, 3, []int{1, 2})).
+			To(Equal([]int{0, 0, 0}))
+	})
+
+	ginkgo.It("should follow the latest advice", func() {
+		p := AdvisedPlacement{Fallback: RoundRobinPlacement{}}
+		ctx.memAdvices = []memAdviceRange{
+			{vAddr: 0x1000, byteSize: 0x3000, advice: MemAdvice{PreferredGPU: 4}},
+			{vAddr: 0x2000, byteSize: 0x1000, advice: MemAdvice{}},
+		}
+
+		Expect(p.Place(ctx, 0x1000, 0x1000, 4, []int{1, 2})).
+			To(Equal([]int{4, 2, 4, 2}))
+	})
+})
+
+var _ = ginkgo.Describe("Driver with placement policy", func() {
+	var (
+		pageTable vm.PageTable
+		driver    *Driver
+		ctx       *Context
+	)
+
+	buildDriver := func(policy PlacementPolicy) {
+		log2PageSize := uint64(12)
+		pageTable = vm.NewPageTable(log2PageSize)
+
+		driver = MakeBuilder().
+			WithEngine(sim.NewSerialEngine()).
+			WithLog2PageSize(log2PageSize).
+			WithPageTable(pageTable).
+			WithPlacementPolicy(policy).
+			Build("Driver")
+
+		for i := 0; i < 2; i++ {
+			driver.RegisterGPU(
+				sim.NewPort(driver, 1, 1, "GPU"),
+				DeviceProperties{CUCount: 4, DRAMSize: 1 * mem.GB},
+			)
+		}
+
+		ctx = driver.Init()
+	}
+
+	deviceIDs := func(ptr Ptr, numPages int) []uint64 {
+		ids := make([]uint64, numPages)
+		for i := range ids {
+			page, found := pageTable.Find(ctx.pid, uint64(ptr)+uint64(i)*0x1000)
+			Expect(found).To(BeTrue())
+			Expect(page.Unified).To(BeTrue())
+			ids[i] = page.DeviceID
+		}
+
+		return ids
+	}
+
+	ginkgo.It("should interleave unified memory", func() {
+		buildDriver(RoundRobinPlacement{})
+
+		ptr := driver.AllocateUnifiedMemory(ctx, 0x3000)
+
+		Expect(deviceIDs(ptr, 3)).To(Equal([]uint64{1, 2, 1}))
+	})
+
+	ginkgo.It("should leave unified memory unplaced", func() {
+		buildDriver(FirstTouchPlacement{})
+
+		ptr := driver.AllocateUnifiedMemory(ctx, 0x2000)
+
+		Expect(deviceIDs(ptr, 2)).To(Equal([]uint64{0, 0}))
+	})
+
+	ginkgo.It("should move advised pages", func() {
+		buildDriver(AdvisedPlacement{})
+
+		ptr := driver.AllocateUnifiedMemory(ctx, 0x4000)
+		driver.MemAdvise(ctx, ptr+0x2000, 0x2000, MemAdvice{PreferredGPU: 1})
+
+		Expect(deviceIDs(ptr, 4)).To(Equal([]uint64{1, 1, 1, 1}))
+
+		driver.MemAdvise(ctx, ptr, 0x1000, MemAdvice{PreferredGPU: 2})
+		driver.Distribute(ctx, ptr, 0x4000, []int{1, 2})
+
+		Expect(deviceIDs(ptr, 4)).To(Equal([]uint64{2, 1, 1, 1}))
+	})
+
+	ginkgo.It("should only pin advised pages if asked", func() {
+		buildDriver(AdvisedPlacement{})
+
+		ptr := driver.AllocateUnifiedMemory(ctx, 0x2000)
+		driver.MemAdvise(ctx, ptr, 0x1000, MemAdvice{PreferredGPU: 2})
+		driver.MemAdvise(ctx, ptr+0x1000, 0x1000,
+			MemAdvice{PreferredGPU: 2, Pin: true})
+
+		Expect(deviceIDs(ptr, 2)).To(Equal([]uint64{2, 2}))
+
+		page, _ := pageTable.Find(ctx.pid, uint64(ptr))
+		Expect(page.IsPinned).To(BeFalse())
+		page, _ = pageTable.Find(ctx.pid, uint64(ptr)+0x1000)
+		Expect(page.IsPinned).To(BeTrue())
+	})
+
+	ginkgo.It("should refuse to move pages that may hold data", func() {
+		buildDriver(AdvisedPlacement{})
+
+		ptr := driver.AllocateUnifiedMemory(ctx, 0x2000)
+		ctx.markBufferPopulated(ptr)
+
+		Expect(func() {
+			driver.MemAdvise(ctx, ptr, 0x1000, MemAdvice{PreferredGPU: 2})
+		}).To(Panic())
+	})
+})
@@ -77,6 +77,8 @@ func (u *ALUImpl) Run(state InstEmuState) {
 		u.runSOPK(state)
 	case insts.DS:
 		u.runDS(state)
+	case insts.MUBUF, insts.MTBUF:
+		RunBufferInst(state, u.storageAccessor)
 	default:
 		log.Panicf("Inst format %s is not supported", inst.Format.FormatName)
 	}
@@ -172,6 +174,8 @@ func (u *ALUImpl) runSOPP(state InstEmuState) {
 		u.runSCBRANCHEXECNZ(state)
 	case 12: // S_WAITCNT
 	// Do nothing
+	case 14, 15, 16, 17, 18: // S_SLEEP, S_SETPRIO, S_SENDMSG(HALT), S_TRAP
+		// Only change the timing of the wavefront.
 	default:
 		log.Panicf("Opcode %d for SOPP format is not implemented", inst.Opcode)
 	}
@@ -0,0 +1,235 @@
+package emu
+
+import (
+	"math"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/akita/v4/mem/mem"
+	"github.com/sarchlab/akita/v4/mem/vm"
+	"github.com/sarchlab/mgpusim/v4/amd/insts"
+	"go.uber.org/mock/gomock"
+)
+
+var _ = Describe("ALU buffer instructions", func() {
+
+	var (
+		mockCtrl  *gomock.Controller
+		pageTable *MockPageTable
+
+		alu     *ALUImpl
+		state   *mockInstState
+		storage *mem.Storage
+	)
+
+	// writeBufferResource writes a V# to s[4:7]. Words 0 and 1 hold the base
+	// address and the stride, word 2 holds the number of records, and word 3
+	// holds the destination selects and the formats.
+	writeBufferResource := func(base uint64, stride, numRecords, word3 uint32) {
+		words := []uint32{
+			uint32(base),
+			uint32(base>>32)&0xffff | stride<<16,
+			numRecords,
+			word3,
+		}
+		for i, w := range words {
+			state.WriteReg(insts.SReg(4+i), 1, 0, insts.Uint32ToBytes(w))
+		}
+	}
+
+	newBufferInst := func(format insts.FormatType, opcode insts.Opcode) {
+		state.inst = insts.NewInst()
+		state.inst.FormatType = format
+		state.inst.Opcode = opcode
+		state.inst.Base = insts.NewSRegOperand(4, 4, 4)
+		state.inst.Offset = insts.NewIntOperand(128, 0)
+		state.inst.Addr = insts.NewVRegOperand(0, 0, 1)
+		state.inst.Data = insts.NewVRegOperand(4, 4, 1)
+		state.inst.Dst = insts.NewVRegOperand(4, 4, 1)
+	}
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		pageTable = NewMockPageTable(mockCtrl)
+		pageTable.EXPECT().
+			Find(vm.PID(1), gomock.Any()).
+			Return(vm.Page{PAddr: 0, VAddr: 0}, true).
+			AnyTimes()
+
+		storage = mem.NewStorage(1 * mem.GB)
+		alu = NewALU(NewStorageAccessor(storage, pageTable, 12, nil))
+
+		state = newMockInstState()
+		state.exec = 0xffffffffffffffff
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	It("should run BUFFER_LOAD_DWORD with offen and clamp the lanes outside", func() {
+		newBufferInst(insts.MUBUF, 20)
+		state.inst.OffsetEnable = true
+		writeBufferResource(0x1000, 0, 128, 0)
+
+		for i := 0; i < 64; i++ {
+			state.WriteReg(insts.VReg(0), 1, i, insts.Uint32ToBytes(uint32(4*i)))
+			storage.Write(uint64(0x1000+4*i), insts.Uint32ToBytes(uint32(100+i)))
+		}
+
+		alu.Run(state)
+
+		for i := 0; i < 64; i++ {
+			expected := uint32(0)
+			if i < 32 {
+				expected = uint32(100 + i)
+			}
+
+			buf := state.ReadReg(insts.VReg(4), 1, i)
+			Expect(insts.BytesToUint32(buf)).To(Equal(expected))
+		}
+	})
+
+	It("should run BUFFER_LOAD_DWORDX2 with idxen and a stride", func() {
+		newBufferInst(insts.MUBUF, 21)
+		state.inst.IndexEnable = true
+		state.inst.Offset0 = 4
+		state.inst.Offset = insts.NewSRegOperand(2, 2, 1)
+		state.inst.Dst.RegCount = 2
+		state.WriteReg(insts.SReg(2), 1, 0, insts.Uint32ToBytes(8))
+		writeBufferResource(0x1000, 16, 32, 0)
+
+		for i := 0; i < 64; i++ {
+			state.WriteReg(insts.VReg(0), 1, i, insts.Uint32ToBytes(uint32(i)))
+			storage.Write(uint64(0x1000+8+16*i+4),
+				insts.Uint64ToBytes(uint64(i)<<32|uint64(i+1)))
+		}
+
+		alu.Run(state)
+
+		for i := 0; i < 64; i++ {
+			expected := uint64(0)
+			if i < 32 {
+				expected = uint64(i)<<32 | uint64(i+1)
+			}
+
+			buf := state.ReadReg(insts.VReg(4), 2, i)
+			Expect(insts.BytesToUint64(buf)).To(Equal(expected))
+		}
+	})
+
+	It("should run BUFFER_LOAD_DWORD on a swizzled buffer", func() {
+		newBufferInst(insts.MUBUF, 20)
+		state.inst.IndexEnable = true
+		state.inst.OffsetEnable = true
+		state.inst.Addr.RegCount = 2
+		// Swizzle enabled, element size 4 and index stride 8.
+		writeBufferResource(0x1000, 8|1<<15, 64, 1<<19)
+
+		for i := 0; i < 64; i++ {
+			state.WriteReg(insts.VReg(0), 2, i,
+				insts.Uint64ToBytes(uint64(4)<<32|uint64(i)))
+			addr := 0x1000 + (i/8*8+4)*8 + i%8*4
+			storage.Write(uint64(addr), insts.Uint32ToBytes(uint32(i+1)))
+		}
+
+		alu.Run(state)
+
+		for i := 0; i < 64; i++ {
+			buf := state.ReadReg(insts.VReg(4), 1, i)
+			Expect(insts.BytesToUint32(buf)).To(Equal(uint32(i + 1)))
+		}
+	})
+
+	It("should run BUFFER_LOAD_FORMAT_XYZW with destination selects", func() {
+		newBufferInst(insts.MUBUF, 3)
+		state.inst.OffsetEnable = true
+		state.inst.Dst.RegCount = 4
+		state.exec = 1
+		// Selects Y, X, 0, 1 from an 8_8 UNORM buffer.
+		writeBufferResource(0x1000, 0, 64, 5|4<<3|0<<6|1<<9|0<<12|3<<15)
+
+		state.WriteReg(insts.VReg(0), 1, 0, insts.Uint32ToBytes(2))
+		storage.Write(0x1002, []byte{0xff, 0x33})
+
+		alu.Run(state)
+
+		buf := state.ReadReg(insts.VReg(4), 4, 0)
+		Expect(math.Float32frombits(insts.BytesToUint32(buf[0:4]))).
+			To(BeNumerically("~", 0.2, 1e-6))
+		Expect(math.Float32frombits(insts.BytesToUint32(buf[4:8]))).
+			To(Equal(float32(1)))
+		Expect(insts.BytesToUint32(buf[8:12])).To(Equal(uint32(0)))
+		Expect(math.Float32frombits(insts.BytesToUint32(buf[12:16]))).
+			To(Equal(float32(1)))
+	})
+
+	It("should run TBUFFER_STORE_FORMAT_XY with 16-bit floats", func() {
+		newBufferInst(insts.MTBUF, 5)
+		state.inst.Data.RegCount = 2
+		state.inst.Addr = insts.NewVRegOperand(0, 0, 0)
+		state.inst.DataFormat = 5
+		state.inst.NumFormat = 7
+		state.exec = 1
+		writeBufferResource(0x1000, 0, 64, 0)
+
+		state.WriteReg(insts.VReg(4), 2, 0, insts.Uint64ToBytes(
+			uint64(math.Float32bits(-2))<<32|uint64(math.Float32bits(1.5))))
+
+		alu.Run(state)
+
+		buf, err := storage.Read(0x1000, 4)
+		Expect(err).To(BeNil())
+		Expect(buf).To(Equal([]byte{0x00, 0x3e, 0x00, 0xc0}))
+	})
+
+	It("should run BUFFER_STORE_BYTE", func() {
+		newBufferInst(insts.MUBUF, 24)
+		state.inst.OffsetEnable = true
+		writeBufferResource(0x1000, 0, 60, 0)
+
+		for i := 0; i < 64; i++ {
+			state.WriteReg(insts.VReg(0), 1, i, insts.Uint32ToBytes(uint32(i)))
+			state.WriteReg(insts.VReg(4), 1, i, insts.Uint32ToBytes(uint32(0x100+i)))
+		}
+
+		alu.Run(state)
+
+		buf, err := storage.Read(0x1000, 64)
+		Expect(err).To(BeNil())
+		for i := 0; i < 64; i++ {
+			expected := byte(0)
+			if i < 60 {
+				expected = byte(i)
+			}
+
+			Expect(buf[i]).To(Equal(expected))
+		}
+	})
+
+	It("should run BUFFER_ATOMIC_ADD with return", func() {
+		newBufferInst(insts.MUBUF, 66)
+		state.inst.GlobalLevelCoherent = true
+		state.inst.IndexEnable = true
+		writeBufferResource(0x1000, 4, 1, 0)
+		storage.Write(0x1000, insts.Uint32ToBytes(10))
+
+		for i := 0; i < 64; i++ {
+			// Only lane 0 and lane 1 access the buffer, at the same index.
+			state.WriteReg(insts.VReg(0), 1, i, insts.Uint32ToBytes(uint32(i/2)))
+			state.WriteReg(insts.VReg(4), 1, i, insts.Uint32ToBytes(3))
+		}
+
+		alu.Run(state)
+
+		buf, err := storage.Read(0x1000, 4)
+		Expect(err).To(BeNil())
+		Expect(insts.BytesToUint32(buf)).To(Equal(uint32(16)))
+		Expect(insts.BytesToUint32(state.ReadReg(insts.VReg(4), 1, 0))).
+			To(Equal(uint32(10)))
+		Expect(insts.BytesToUint32(state.ReadReg(insts.VReg(4), 1, 1))).
+			To(Equal(uint32(13)))
+		Expect(insts.BytesToUint32(state.ReadReg(insts.VReg(4), 1, 2))).
+			To(Equal(uint32(0)))
+	})
+})
@@ -60,6 +60,11 @@ func (u *ALUImpl) flatAddrWithScalar(
 //nolint:gocyclo
 //nolint:funlen
 func (u *ALUImpl) runFlat(state InstEmuState) {
+	if AccessesScratch(state) {
+		RunScratchInst(state, u.storageAccessor)
+		return
+	}
+
 	inst := state.Inst()
 	switch inst.Opcode {
 	case 16:
@@ -83,6 +88,11 @@ func (u *ALUImpl) runFlat(state InstEmuState) {
 	case 31:
 		u.runFlatStoreDWordX4(state)
 	default:
+		if _, ok := inst.AtomicInfo(); ok {
+			u.runFlatAtomic(state)
+			return
+		}
+
 		log.Panicf("Opcode %d for FLAT format is not implemented", inst.Opcode)
 	}
 }
@@ -257,3 +267,30 @@ func (u *ALUImpl) runFlatStoreDWordX4(state InstEmuState) {
 		u.storageAccessor.Write(pid, addr, data)
 	}
 }
+
+// runFlatAtomic performs the atomic operation lane by lane, so that the lanes
+// that access the same address see the results of the lanes before them.
+func (u *ALUImpl) runFlatAtomic(state InstEmuState) {
+	inst := state.Inst()
+	atomic, _ := inst.AtomicInfo()
+	pid := state.PID()
+	exec := state.EXEC()
+	hasSAddr, scalarBase := u.flatPrecomputeScalarBase(state)
+
+	for i := 0; i < 64; i++ {
+		if exec&(1<<uint(i)) == 0 {
+			continue
+		}
+
+		addr := u.flatAddrWithScalar(state, i, hasSAddr, scalarBase)
+		src, cmp := FlatAtomicOperands(state, atomic, i)
+
+		memory := u.storageAccessor.Read(pid, addr, uint64(atomic.ByteSize))
+		old := ApplyAtomic(atomic, memory, src, cmp)
+		u.storageAccessor.Write(pid, addr, memory)
+
+		if atomic.Return {
+			state.WriteOperandBytes(inst.Dst, i, old)
+		}
+	}
+}
@@ -353,4 +353,73 @@ var _ = Describe("ALU", func() {
 			Expect(insts.BytesToUint32(buf[12:16])).To(Equal(uint32(i)))
 		}
 	})
+
+	It("should run FLAT_ATOMIC_ADD with return", func() {
+		pageTable.EXPECT().
+			Find(vm.PID(1), uint64(64)).
+			Return(vm.Page{
+				PAddr: uint64(0),
+			}, true).
+			AnyTimes()
+		state.inst = insts.NewInst()
+		state.inst.FormatType = insts.FLAT
+		state.inst.Opcode = 66
+		state.inst.GlobalLevelCoherent = true
+		state.inst.Addr = insts.NewVRegOperand(0, 0, 2)
+		state.inst.Data = insts.NewVRegOperand(0, 4, 1)
+		state.inst.Dst = insts.NewVRegOperand(0, 6, 1)
+
+		state.exec = 0xffffffffffffffff
+		for i := 0; i < 64; i++ {
+			state.WriteReg(insts.VReg(0), 2, i, insts.Uint64ToBytes(64))
+			state.WriteReg(insts.VReg(4), 1, i, insts.Uint32ToBytes(2))
+		}
+		storage.Write(64, insts.Uint32ToBytes(10))
+
+		alu.Run(state)
+
+		buf, err := storage.Read(64, 4)
+		Expect(err).To(BeNil())
+		Expect(insts.BytesToUint32(buf)).To(Equal(uint32(10 + 2*64)))
+		for i := 0; i < 64; i++ {
+			buf := state.ReadReg(insts.VReg(6), 1, i)
+			Expect(insts.BytesToUint32(buf)).To(Equal(uint32(10 + 2*i)))
+		}
+	})
+
+	It("should run FLAT_ATOMIC_CMPSWAP_X2 without return", func() {
+		for i := 0; i < 2; i++ {
+			pageTable.EXPECT().
+				Find(vm.PID(1), uint64(i*8)).
+				Return(vm.Page{
+					PAddr: uint64(0),
+				}, true).
+				AnyTimes()
+		}
+		state.inst = insts.NewInst()
+		state.inst.FormatType = insts.FLAT
+		state.inst.Opcode = 97
+		state.inst.Addr = insts.NewVRegOperand(0, 0, 2)
+		state.inst.Data = insts.NewVRegOperand(0, 4, 4)
+		state.inst.Dst = insts.NewVRegOperand(0, 8, 2)
+
+		state.exec = 0x3
+		for i := 0; i < 2; i++ {
+			state.WriteReg(insts.VReg(0), 2, i, insts.Uint64ToBytes(uint64(i*8)))
+			state.WriteReg(insts.VReg(4), 2, i, insts.Uint64ToBytes(0x100000000))
+			state.WriteReg(insts.VReg(6), 2, i, insts.Uint64ToBytes(7))
+			state.WriteReg(insts.VReg(8), 2, i, insts.Uint64ToBytes(1))
+		}
+		storage.Write(0, insts.Uint64ToBytes(7))
+		storage.Write(8, insts.Uint64ToBytes(8))
+
+		alu.Run(state)
+
+		buf, err := storage.Read(0, 16)
+		Expect(err).To(BeNil())
+		Expect(insts.BytesToUint64(buf[0:8])).To(Equal(uint64(0x100000000)))
+		Expect(insts.BytesToUint64(buf[8:16])).To(Equal(uint64(8)))
+		Expect(insts.BytesToUint64(state.ReadReg(insts.VReg(8), 2, 0))).
+			To(Equal(uint64(1)))
+	})
 })
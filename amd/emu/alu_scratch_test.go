@@ -0,0 +1,136 @@
+package emu
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/akita/v4/mem/mem"
+	"github.com/sarchlab/akita/v4/mem/vm"
+	"github.com/sarchlab/mgpusim/v4/amd/insts"
+	"go.uber.org/mock/gomock"
+)
+
+var _ = Describe("ALU scratch instructions", func() {
+
+	var (
+		mockCtrl  *gomock.Controller
+		pageTable *MockPageTable
+
+		alu     *ALUImpl
+		state   *mockInstState
+		storage *mem.Storage
+	)
+
+	const scratchBase = uint64(0x10000)
+
+	newScratchInst := func(opcode insts.Opcode, saddr int64) {
+		state.inst = insts.NewInst()
+		state.inst.FormatType = insts.FLAT
+		state.inst.Seg = insts.FlatSegmentScratch
+		state.inst.Opcode = opcode
+		state.inst.SAddr = insts.NewIntOperand(0, saddr)
+		state.inst.Addr = insts.NewVRegOperand(0, 0, 1)
+		state.inst.Data = insts.NewVRegOperand(4, 4, 1)
+		state.inst.Dst = insts.NewVRegOperand(4, 4, 1)
+	}
+
+	// swizzled returns the address of the private memory of a lane, where
+	// the lanes interleave every 4 bytes.
+	swizzled := func(lane int, offset uint64) uint64 {
+		return scratchBase + offset/4*256 + uint64(lane)*4 + offset%4
+	}
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		pageTable = NewMockPageTable(mockCtrl)
+		pageTable.EXPECT().
+			Find(vm.PID(1), gomock.Any()).
+			Return(vm.Page{PAddr: 0, VAddr: 0}, true).
+			AnyTimes()
+
+		storage = mem.NewStorage(1 * mem.GB)
+		alu = NewALU(NewStorageAccessor(storage, pageTable, 12, nil))
+
+		state = newMockInstState()
+		state.exec = 0xffffffffffffffff
+		state.scratchBase = scratchBase
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	It("should run SCRATCH_STORE_DWORDX2 with a VGPR offset", func() {
+		newScratchInst(29, 0x7f)
+		state.inst.Data.RegCount = 2
+		state.inst.Offset0 = 4
+
+		for i := 0; i < 64; i++ {
+			state.WriteReg(insts.VReg(0), 1, i, insts.Uint32ToBytes(4))
+			state.WriteReg(insts.VReg(4), 2, i,
+				insts.Uint64ToBytes(uint64(i+1)<<32|uint64(i)))
+		}
+
+		alu.Run(state)
+
+		for i := 0; i < 64; i++ {
+			lo, err := storage.Read(swizzled(i, 8), 4)
+			Expect(err).To(BeNil())
+			Expect(insts.BytesToUint32(lo)).To(Equal(uint32(i)))
+
+			hi, err := storage.Read(swizzled(i, 12), 4)
+			Expect(err).To(BeNil())
+			Expect(insts.BytesToUint32(hi)).To(Equal(uint32(i + 1)))
+		}
+	})
+
+	It("should run SCRATCH_LOAD_SBYTE with an SGPR offset", func() {
+		newScratchInst(17, 2)
+		state.inst.Offset0 = 0xffffffff // -1
+		state.WriteReg(insts.SReg(2), 1, 0, insts.Uint32ToBytes(7))
+
+		for i := 0; i < 64; i++ {
+			storage.Write(swizzled(i, 6), []byte{byte(0x80 + i)})
+		}
+
+		alu.Run(state)
+
+		for i := 0; i < 64; i++ {
+			buf := state.ReadReg(insts.VReg(4), 1, i)
+			Expect(insts.BytesToUint32(buf)).
+				To(Equal(uint32(int32(int8(0x80 + i)))))
+		}
+	})
+
+	It("should run FLAT_LOAD_DWORD on the private aperture", func() {
+		state.inst = insts.NewInst()
+		state.inst.FormatType = insts.FLAT
+		state.inst.Opcode = 20
+		state.inst.SAddr = insts.NewIntOperand(0, 0x7f)
+		state.inst.Addr = insts.NewVRegOperand(0, 0, 2)
+		state.inst.Dst = insts.NewVRegOperand(4, 4, 1)
+
+		for i := 0; i < 64; i++ {
+			addr := uint64(0x1000 + 4*i)
+			if i%2 == 0 {
+				addr = PrivateApertureBase + 16
+				storage.Write(swizzled(i, 16), insts.Uint32ToBytes(uint32(i)))
+			} else {
+				storage.Write(addr, insts.Uint32ToBytes(uint32(100+i)))
+			}
+
+			state.WriteReg(insts.VReg(0), 2, i, insts.Uint64ToBytes(addr))
+		}
+
+		alu.Run(state)
+
+		for i := 0; i < 64; i++ {
+			expected := uint32(100 + i)
+			if i%2 == 0 {
+				expected = uint32(i)
+			}
+
+			buf := state.ReadReg(insts.VReg(4), 1, i)
+			Expect(insts.BytesToUint32(buf)).To(Equal(expected))
+		}
+	})
+})
@@ -17,6 +17,8 @@ type mockInstState struct {
 	vcc      uint64
 	scc      byte
 	pc       uint64
+
+	scratchBase uint64
 }
 
 func newMockInstState() *mockInstState {
@@ -159,6 +161,8 @@ func (s *mockInstState) SetSCC(v byte)    { s.scc = v }
 func (s *mockInstState) PC() uint64      { return s.pc }
 func (s *mockInstState) SetPC(v uint64)   { s.pc = v }
 
+func (s *mockInstState) ScratchBase() uint64 { return s.scratchBase }
+
 var _ = Describe("ALU", func() {
 
 	var (
@@ -351,6 +355,21 @@ var _ = Describe("ALU", func() {
 		Expect(state.PC()).To(Equal(uint64(1024 - 32*4)))
 	})
 
+	It("should only pass S_SLEEP, S_SETPRIO, S_SENDMSG, and S_TRAP", func() {
+		for _, opcode := range []insts.Opcode{14, 15, 16, 17, 18} {
+			state.inst = insts.NewInst()
+			state.inst.FormatType = insts.SOPP
+			state.inst.Opcode = opcode
+			state.inst.SImm16 = insts.NewIntOperand(0, 3)
+
+			state.SetPC(160)
+
+			alu.Run(state)
+
+			Expect(state.PC()).To(Equal(uint64(160)))
+		}
+	})
+
 	It("should run S_CBRANCH_SCC0", func() {
 		state.inst = insts.NewInst()
 		state.inst.FormatType = insts.SOPP
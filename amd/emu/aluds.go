@@ -24,10 +24,37 @@ func (u *ALUImpl) runDS(state InstEmuState) {
 	case 119:
 		u.runDSREAD2B64(state)
 	default:
+		if _, ok := inst.AtomicInfo(); ok {
+			u.runDSAtomic(state)
+			return
+		}
+
 		log.Panicf("Opcode %d for DS format is not implemented", inst.Opcode)
 	}
 }
 
+func (u *ALUImpl) runDSAtomic(state InstEmuState) {
+	inst := state.Inst()
+	atomic, _ := inst.AtomicInfo()
+	exec := state.EXEC()
+	lds := u.LDS()
+
+	for i := 0; i < 64; i++ {
+		if exec&(1<<uint(i)) == 0 {
+			continue
+		}
+
+		addr := uint32(state.ReadOperand(inst.Addr, i)) + inst.Offset0
+		src, cmp := DSAtomicOperands(state, atomic, i)
+		old := ApplyAtomic(atomic,
+			lds[addr:addr+uint32(atomic.ByteSize)], src, cmp)
+
+		if atomic.Return {
+			state.WriteOperandBytes(inst.Dst, i, old)
+		}
+	}
+}
+
 func (u *ALUImpl) runDSWRITEB32(state InstEmuState) {
 	inst := state.Inst()
 	exec := state.EXEC()
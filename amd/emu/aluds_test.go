@@ -200,4 +200,55 @@ var _ = Describe("ALU", func() {
 		Expect(insts.BytesToUint32(buf[8:12])).To(Equal(uint32(156)))
 	})
 
+	It("should run DS_ADD_RTN_U32", func() {
+		state.inst = insts.NewInst()
+		state.inst.FormatType = insts.DS
+		state.inst.Opcode = 32
+		state.inst.Offset0 = 4
+		state.inst.Addr = insts.NewVRegOperand(0, 0, 1)
+		state.inst.Data = insts.NewVRegOperand(0, 4, 1)
+		state.inst.Dst = insts.NewVRegOperand(0, 8, 1)
+
+		state.exec = 0x3
+		for i := 0; i < 2; i++ {
+			state.WriteReg(insts.VReg(0), 1, i, insts.Uint32ToBytes(100))
+			state.WriteReg(insts.VReg(4), 1, i, insts.Uint32ToBytes(3))
+		}
+
+		lds := alu.LDS()
+		copy(lds[104:], insts.Uint32ToBytes(5))
+
+		alu.Run(state)
+
+		Expect(insts.BytesToUint32(lds[104:])).To(Equal(uint32(11)))
+		Expect(insts.BytesToUint32(state.ReadReg(insts.VReg(8), 1, 0))).
+			To(Equal(uint32(5)))
+		Expect(insts.BytesToUint32(state.ReadReg(insts.VReg(8), 1, 1))).
+			To(Equal(uint32(8)))
+	})
+
+	It("should run DS_CMPST_B32", func() {
+		state.inst = insts.NewInst()
+		state.inst.FormatType = insts.DS
+		state.inst.Opcode = 16
+		state.inst.Addr = insts.NewVRegOperand(0, 0, 1)
+		state.inst.Data = insts.NewVRegOperand(0, 4, 1)
+		state.inst.Data1 = insts.NewVRegOperand(0, 8, 1)
+
+		state.exec = 0x3
+		for i := 0; i < 2; i++ {
+			state.WriteReg(insts.VReg(0), 1, i, insts.Uint32ToBytes(uint32(100+4*i)))
+			state.WriteReg(insts.VReg(4), 1, i, insts.Uint32ToBytes(1))
+			state.WriteReg(insts.VReg(8), 1, i, insts.Uint32ToBytes(9))
+		}
+
+		lds := alu.LDS()
+		copy(lds[100:], insts.Uint32ToBytes(1))
+		copy(lds[104:], insts.Uint32ToBytes(2))
+
+		alu.Run(state)
+
+		Expect(insts.BytesToUint32(lds[100:])).To(Equal(uint32(9)))
+		Expect(insts.BytesToUint32(lds[104:])).To(Equal(uint32(2)))
+	})
 })
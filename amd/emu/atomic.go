@@ -0,0 +1,79 @@
+package emu
+
+import (
+	"encoding/binary"
+
+	"github.com/sarchlab/mgpusim/v4/amd/insts"
+)
+
+// ApplyAtomic performs an atomic operation on the bytes of the memory location
+// in place and returns the bytes before the operation.
+func ApplyAtomic(
+	atomic insts.AtomicInfo,
+	memory []byte,
+	src, cmp uint64,
+) []byte {
+	old := make([]byte, atomic.ByteSize)
+	copy(old, memory)
+
+	value := atomic.Op.Apply(atomicBytesToUint64(old), src, cmp, atomic.ByteSize)
+
+	if atomic.ByteSize == 8 {
+		binary.LittleEndian.PutUint64(memory, value)
+	} else {
+		binary.LittleEndian.PutUint32(memory, uint32(value))
+	}
+
+	return old
+}
+
+// atomicBytesToUint64 converts up to 8 little-endian bytes to a uint64.
+func atomicBytesToUint64(data []byte) uint64 {
+	var buf [8]byte
+	copy(buf[:], data)
+
+	return binary.LittleEndian.Uint64(buf[:])
+}
+
+// FlatAtomicOperands reads the source value and the compare value of a FLAT
+// or MUBUF atomic instruction. Cmpswap places the compare value in the
+// registers after the source value.
+func FlatAtomicOperands(
+	state InstEmuState,
+	atomic insts.AtomicInfo,
+	laneID int,
+) (src, cmp uint64) {
+	inst := state.Inst()
+
+	if atomic.Op == insts.AtomicOpCmpSwap {
+		data := state.ReadOperandBytes(inst.Data, laneID, 2*atomic.ByteSize)
+		return atomicBytesToUint64(data[:atomic.ByteSize]),
+			atomicBytesToUint64(data[atomic.ByteSize:])
+	}
+
+	data := state.ReadOperandBytes(inst.Data, laneID, atomic.ByteSize)
+
+	return atomicBytesToUint64(data), 0
+}
+
+// DSAtomicOperands reads the source value and the compare value of a DS
+// atomic instruction. Cmpst and mskor read the compare value or the mask from
+// DATA0 and the source value from DATA1.
+func DSAtomicOperands(
+	state InstEmuState,
+	atomic insts.AtomicInfo,
+	laneID int,
+) (src, cmp uint64) {
+	inst := state.Inst()
+	data0 := atomicBytesToUint64(
+		state.ReadOperandBytes(inst.Data, laneID, atomic.ByteSize))
+
+	if atomic.Op != insts.AtomicOpCmpSwap && atomic.Op != insts.AtomicOpMskOr {
+		return data0, 0
+	}
+
+	data1 := atomicBytesToUint64(
+		state.ReadOperandBytes(inst.Data1, laneID, atomic.ByteSize))
+
+	return data1, data0
+}
@@ -0,0 +1,608 @@
+package emu
+
+import (
+	"encoding/binary"
+	"log"
+	"math"
+
+	"github.com/sarchlab/mgpusim/v4/amd/insts"
+)
+
+// A BufferResource is the buffer resource descriptor (V#) that the MUBUF and
+// MTBUF instructions read from four consecutive SGPRs.
+type BufferResource struct {
+	BaseAddress   uint64
+	Stride        uint64
+	SwizzleEnable bool
+	NumRecords    uint64
+	DstSel        [4]uint32
+	NumFormat     int
+	DataFormat    int
+	ElementSize   uint64
+	IndexStride   uint64
+	AddTIDEnable  bool
+}
+
+// DecodeBufferResource decodes the 16 bytes of a buffer resource descriptor.
+func DecodeBufferResource(data []byte) BufferResource {
+	w1 := binary.LittleEndian.Uint32(data[4:])
+	w3 := binary.LittleEndian.Uint32(data[12:])
+
+	r := BufferResource{
+		BaseAddress:   binary.LittleEndian.Uint64(data) & 0xffffffffffff,
+		Stride:        uint64(w1>>16) & 0x3fff,
+		SwizzleEnable: w1>>31 == 1,
+		NumRecords:    uint64(binary.LittleEndian.Uint32(data[8:])),
+		NumFormat:     int(w3>>12) & 0x7,
+		DataFormat:    int(w3>>15) & 0xf,
+		ElementSize:   2 << ((w3 >> 19) & 0x3),
+		IndexStride:   8 << ((w3 >> 21) & 0x3),
+		AddTIDEnable:  (w3>>23)&1 == 1,
+	}
+
+	for i := range r.DstSel {
+		r.DstSel[i] = (w3 >> (3 * i)) & 0x7
+	}
+
+	return r
+}
+
+// ReadBufferResource reads the buffer resource descriptor of the MUBUF or
+// MTBUF instruction that the state is executing.
+func ReadBufferResource(state InstEmuState) BufferResource {
+	return DecodeBufferResource(state.ReadOperandBytes(state.Inst().Base, 0, 16))
+}
+
+// A BufferAccess is the record that a lane of a buffer instruction accesses.
+type BufferAccess struct {
+	Index uint64
+
+	// Offset is the offset in the record, which includes the offset of the
+	// instruction but not SOFFSET.
+	Offset  uint64
+	SOffset uint64
+}
+
+// BufferLaneAccess reads the index and the offsets that a lane of a MUBUF or
+// MTBUF instruction accesses. With both IDXEN and OFFEN set, the first VADDR
+// register holds the index and the second one holds the offset.
+func BufferLaneAccess(
+	state InstEmuState,
+	rsrc BufferResource,
+	laneID int,
+) BufferAccess {
+	inst := state.Inst()
+	a := BufferAccess{
+		Offset:  uint64(inst.Offset0),
+		SOffset: uint64(uint32(state.ReadOperand(inst.Offset, laneID))),
+	}
+
+	if inst.Addr.RegCount > 0 {
+		vaddr := state.ReadOperandBytes(inst.Addr, laneID, 4*inst.Addr.RegCount)
+		first := uint64(insts.BytesToUint32(vaddr[0:4]))
+
+		switch {
+		case inst.IndexEnable && inst.OffsetEnable:
+			a.Index = first
+			a.Offset += uint64(insts.BytesToUint32(vaddr[4:8]))
+		case inst.IndexEnable:
+			a.Index = first
+		default:
+			a.Offset += first
+		}
+	}
+
+	if rsrc.AddTIDEnable {
+		a.Index += uint64(laneID)
+	}
+
+	return a
+}
+
+// Address returns the address of the bytes that are compOffset bytes after
+// the location that the access points to.
+func (r BufferResource) Address(a BufferAccess, compOffset uint64) uint64 {
+	offset := a.Offset + compOffset
+
+	if !r.SwizzleEnable {
+		return r.BaseAddress + a.SOffset + offset + r.Stride*a.Index
+	}
+
+	idxMSB := a.Index / r.IndexStride
+	idxLSB := a.Index % r.IndexStride
+	offMSB := offset / r.ElementSize
+	offLSB := offset % r.ElementSize
+
+	return r.BaseAddress + a.SOffset +
+		(idxMSB*r.Stride+offMSB*r.ElementSize)*r.IndexStride +
+		idxLSB*r.ElementSize + offLSB
+}
+
+// InRange returns true if the byteSize bytes that are compOffset bytes after
+// the location that the access points to are in the buffer. A raw buffer,
+// whose stride is 0, checks the offset against NumRecords bytes; a structured
+// buffer checks the index against NumRecords records.
+func (r BufferResource) InRange(
+	a BufferAccess,
+	compOffset, byteSize uint64,
+) bool {
+	if r.Stride == 0 {
+		return a.Offset+compOffset+byteSize <= r.NumRecords
+	}
+
+	if a.Index >= r.NumRecords {
+		return false
+	}
+
+	if r.SwizzleEnable && a.Offset+compOffset >= r.Stride {
+		return false
+	}
+
+	return true
+}
+
+// BufferConversion tells how a component converts between the memory and the
+// register.
+type BufferConversion int
+
+// The conversions, in the order of the numeric formats.
+const (
+	BufferConvertUNorm BufferConversion = iota
+	BufferConvertSNorm
+	BufferConvertUScaled
+	BufferConvertSScaled
+	BufferConvertUint
+	BufferConvertSint
+	bufferConvertReserved
+	BufferConvertFloat
+)
+
+// A BufferComponent describes how a register that a MUBUF or MTBUF
+// instruction loads or stores maps to the memory.
+type BufferComponent struct {
+	// InMemory is false if the register does not map to the memory. A load
+	// writes Value to such a register and a store ignores the register.
+	InMemory bool
+	Value    uint32
+
+	// Offset is the offset of the component from the location that a lane
+	// accesses.
+	Offset     uint64
+	ByteSize   uint64
+	Conversion BufferConversion
+}
+
+type bufferDataFormat struct {
+	size, count uint64
+}
+
+// bufferDataFormats lists the data formats that the buffer instructions
+// support.
+var bufferDataFormats = map[int]bufferDataFormat{
+	1:  {1, 1}, // 8
+	2:  {2, 1}, // 16
+	3:  {1, 2}, // 8_8
+	4:  {4, 1}, // 32
+	5:  {2, 2}, // 16_16
+	10: {1, 4}, // 8_8_8_8
+	11: {4, 2}, // 32_32
+	12: {2, 4}, // 16_16_16_16
+	13: {4, 3}, // 32_32_32
+	14: {4, 4}, // 32_32_32_32
+}
+
+// BufferComponents returns how each register that a MUBUF or MTBUF load or
+// store accesses maps to the memory. The format instructions take the data
+// format from the instruction (MTBUF) or from the resource (MUBUF), and the
+// format loads apply the destination selects of the resource.
+func BufferComponents(
+	inst *insts.Inst,
+	rsrc BufferResource,
+) []BufferComponent {
+	op, _ := inst.BufferOp()
+	if !op.IsFormat() {
+		return rawComponents(op)
+	}
+
+	comps := make([]BufferComponent, op.NumComponents)
+
+	dataFormat, numFormat := rsrc.DataFormat, rsrc.NumFormat
+	if inst.FormatType == insts.MTBUF {
+		dataFormat, numFormat = inst.DataFormat, inst.NumFormat
+	}
+
+	channels := bufferFormatChannels(dataFormat, numFormat)
+
+	for i := range comps {
+		if op.Kind == insts.BufferOpStore {
+			if i < len(channels) {
+				comps[i] = channels[i]
+			}
+
+			continue
+		}
+
+		comps[i] = selectBufferChannel(channels, rsrc.DstSel[i],
+			BufferConversion(numFormat))
+	}
+
+	return comps
+}
+
+// rawComponents returns the components of an instruction that is not a
+// format instruction. The components are consecutive in the memory.
+func rawComponents(op insts.BufferOp) []BufferComponent {
+	comps := make([]BufferComponent, op.NumComponents)
+
+	conversion := BufferConvertUint
+	if op.Signed {
+		conversion = BufferConvertSint
+	}
+
+	for i := range comps {
+		comps[i] = BufferComponent{
+			InMemory:   true,
+			Offset:     uint64(i * op.ComponentSize),
+			ByteSize:   uint64(op.ComponentSize),
+			Conversion: conversion,
+		}
+	}
+
+	return comps
+}
+
+func bufferFormatChannels(dataFormat, numFormat int) []BufferComponent {
+	format, found := bufferDataFormats[dataFormat]
+	if !found {
+		log.Panicf("buffer data format %d is not supported", dataFormat)
+	}
+
+	conversion := BufferConversion(numFormat)
+	if conversion == bufferConvertReserved ||
+		(conversion == BufferConvertFloat && format.size == 1) {
+		log.Panicf("buffer numeric format %d is not supported with "+
+			"data format %d", numFormat, dataFormat)
+	}
+
+	channels := make([]BufferComponent, format.count)
+	for i := range channels {
+		channels[i] = BufferComponent{
+			InMemory:   true,
+			Offset:     uint64(i) * format.size,
+			ByteSize:   format.size,
+			Conversion: conversion,
+		}
+	}
+
+	return channels
+}
+
+// selectBufferChannel applies a destination select, which picks 0, 1 or one
+// of the X, Y, Z and W channels of the memory. A channel that the data format
+// does not have reads as 0, except that W reads as 1.
+func selectBufferChannel(
+	channels []BufferComponent,
+	sel uint32,
+	conversion BufferConversion,
+) BufferComponent {
+	one := uint32(1)
+	if conversion != BufferConvertUint && conversion != BufferConvertSint {
+		one = math.Float32bits(1)
+	}
+
+	switch {
+	case sel == 1:
+		return BufferComponent{Value: one}
+	case sel >= 4 && int(sel-4) < len(channels):
+		return channels[sel-4]
+	case sel == 7:
+		return BufferComponent{Value: one}
+	}
+
+	return BufferComponent{}
+}
+
+// Load converts the bytes of the component in the memory to the value of the
+// register.
+func (c BufferComponent) Load(data []byte) uint32 {
+	if !c.InMemory {
+		return c.Value
+	}
+
+	raw := uint32(atomicBytesToUint64(data[:c.ByteSize]))
+	bits := 8 * c.ByteSize
+	signed := int32(raw<<(32-bits)) >> (32 - bits)
+	maxUnsigned := float32(uint64(1)<<bits - 1)
+	maxSigned := float32(uint64(1)<<(bits-1) - 1)
+
+	switch c.Conversion {
+	case BufferConvertUNorm:
+		return math.Float32bits(float32(raw) / maxUnsigned)
+	case BufferConvertSNorm:
+		return math.Float32bits(max(float32(signed)/maxSigned, -1))
+	case BufferConvertUScaled:
+		return math.Float32bits(float32(raw))
+	case BufferConvertSScaled:
+		return math.Float32bits(float32(signed))
+	case BufferConvertSint:
+		return uint32(signed)
+	case BufferConvertFloat:
+		if c.ByteSize == 2 {
+			return math.Float32bits(halfToFloat32(uint16(raw)))
+		}
+	}
+
+	return raw
+}
+
+// Store converts the value of a register to the bytes of the component in the
+// memory.
+func (c BufferComponent) Store(value uint32) []byte {
+	f := float64(math.Float32frombits(value))
+	bits := 8 * c.ByteSize
+	maxUnsigned := float64(uint64(1)<<bits - 1)
+	maxSigned := float64(uint64(1)<<(bits-1) - 1)
+	raw := value
+
+	switch c.Conversion {
+	case BufferConvertUNorm:
+		raw = uint32(math.Round(min(max(f, 0), 1) * maxUnsigned))
+	case BufferConvertSNorm:
+		raw = uint32(int32(math.Round(min(max(f, -1), 1) * maxSigned)))
+	case BufferConvertUScaled:
+		raw = uint32(int64(f))
+	case BufferConvertSScaled:
+		raw = uint32(int32(f))
+	case BufferConvertFloat:
+		if c.ByteSize == 2 {
+			raw = uint32(float32ToHalf(float32(f)))
+		}
+	}
+
+	data := insts.Uint32ToBytes(raw)
+
+	return data[:c.ByteSize]
+}
+
+// halfToFloat32 converts IEEE half-precision bits to a float32.
+func halfToFloat32(h uint16) float32 {
+	sign := uint32(h>>15) << 31
+	exp := uint32(h>>10) & 0x1f
+	frac := uint32(h) & 0x3ff
+
+	switch {
+	case exp == 0x1f:
+		return math.Float32frombits(sign | 0x7f800000 | frac<<13)
+	case exp != 0:
+		return math.Float32frombits(sign | (exp+112)<<23 | frac<<13)
+	}
+
+	// Zeros and denormals.
+	f := float32(frac) / (1 << 24)
+	if sign != 0 {
+		return -f
+	}
+
+	return f
+}
+
+// float32ToHalf converts a float32 to IEEE half-precision bits, rounding to
+// the nearest even value.
+func float32ToHalf(f float32) uint16 {
+	b := math.Float32bits(f)
+	sign := uint16(b>>16) & 0x8000
+	exp := int32(b>>23) & 0xff
+	frac := b & 0x7fffff
+
+	switch {
+	case exp == 0xff:
+		nan := uint16(0)
+		if frac != 0 {
+			nan = 0x200
+		}
+
+		return sign | 0x7c00 | nan
+	case exp-112 >= 0x1f:
+		return sign | 0x7c00
+	case exp-112 <= 0:
+		if exp-112 < -10 {
+			return sign
+		}
+
+		// Denormals keep the implicit bit and shift it into the fraction.
+		frac |= 0x800000
+		shift := uint32(14 - (exp - 112))
+		half := frac >> shift
+		rest := frac & (1<<shift - 1)
+
+		if rest > 1<<(shift-1) || (rest == 1<<(shift-1) && half&1 == 1) {
+			half++
+		}
+
+		return sign | uint16(half)
+	}
+
+	half := uint32(exp-112)<<10 | frac>>13
+	rest := frac & 0x1fff
+
+	if rest > 0x1000 || (rest == 0x1000 && half&1 == 1) {
+		half++
+	}
+
+	return sign | uint16(half)
+}
+
+// A ComponentAddressFunc returns the address of the byteSize bytes that are
+// offset bytes after the location that a lane accesses. The second return
+// value is false if the bytes are outside the memory that the lane can
+// access.
+type ComponentAddressFunc func(
+	laneID int,
+	offset, byteSize uint64,
+) (uint64, bool)
+
+// BufferComponentAddress returns the addresses that the lanes of a MUBUF or
+// MTBUF instruction access.
+func BufferComponentAddress(
+	state InstEmuState,
+	rsrc BufferResource,
+) ComponentAddressFunc {
+	lane := -1
+	access := BufferAccess{}
+
+	return func(laneID int, offset, byteSize uint64) (uint64, bool) {
+		if laneID != lane {
+			lane = laneID
+			access = BufferLaneAccess(state, rsrc, laneID)
+		}
+
+		if !rsrc.InRange(access, offset, byteSize) {
+			return 0, false
+		}
+
+		return rsrc.Address(access, offset), true
+	}
+}
+
+// RunBufferInst executes a MUBUF or MTBUF instruction on the storage.
+func RunBufferInst(state InstEmuState, storageAccessor StorageAccessor) {
+	inst := state.Inst()
+
+	if inst.LDS {
+		log.Panicf("buffer loads to the LDS are not supported")
+	}
+
+	op, ok := inst.BufferOp()
+	if !ok {
+		log.Panicf("Opcode %d for %s format is not implemented",
+			inst.Opcode, inst.FormatName)
+	}
+
+	if op.Kind == insts.BufferOpCacheControl {
+		// The emulator does not model caches.
+		return
+	}
+
+	rsrc := ReadBufferResource(state)
+	address := BufferComponentAddress(state, rsrc)
+
+	runComponentInst(state, storageAccessor, op,
+		BufferComponents(inst, rsrc), address)
+}
+
+// runComponentInst executes a load, a store or an atomic that accesses the
+// memory component by component.
+func runComponentInst(
+	state InstEmuState,
+	storageAccessor StorageAccessor,
+	op insts.BufferOp,
+	comps []BufferComponent,
+	address ComponentAddressFunc,
+) {
+	switch op.Kind {
+	case insts.BufferOpLoad:
+		runComponentLoad(state, storageAccessor, comps, address)
+	case insts.BufferOpStore:
+		runComponentStore(state, storageAccessor, comps, address)
+	case insts.BufferOpAtomic:
+		runComponentAtomic(state, storageAccessor, address)
+	}
+}
+
+func runComponentLoad(
+	state InstEmuState,
+	storageAccessor StorageAccessor,
+	comps []BufferComponent,
+	address ComponentAddressFunc,
+) {
+	inst := state.Inst()
+	pid := state.PID()
+	exec := state.EXEC()
+	result := make([]byte, 4*len(comps))
+
+	for i := 0; i < 64; i++ {
+		if exec&(1<<uint(i)) == 0 {
+			continue
+		}
+
+		for j, c := range comps {
+			value := c.Value
+			if c.InMemory {
+				value = 0
+				if addr, ok := address(i, c.Offset, c.ByteSize); ok {
+					value = c.Load(storageAccessor.Read(pid, addr, c.ByteSize))
+				}
+			}
+
+			binary.LittleEndian.PutUint32(result[4*j:], value)
+		}
+
+		state.WriteOperandBytes(inst.Dst, i, result)
+	}
+}
+
+func runComponentStore(
+	state InstEmuState,
+	storageAccessor StorageAccessor,
+	comps []BufferComponent,
+	address ComponentAddressFunc,
+) {
+	inst := state.Inst()
+	pid := state.PID()
+	exec := state.EXEC()
+
+	for i := 0; i < 64; i++ {
+		if exec&(1<<uint(i)) == 0 {
+			continue
+		}
+
+		data := state.ReadOperandBytes(inst.Data, i, 4*len(comps))
+
+		for j, c := range comps {
+			if !c.InMemory {
+				continue
+			}
+
+			addr, ok := address(i, c.Offset, c.ByteSize)
+			if !ok {
+				continue
+			}
+
+			storageAccessor.Write(pid, addr,
+				c.Store(insts.BytesToUint32(data[4*j:])))
+		}
+	}
+}
+
+// runComponentAtomic performs the atomic operation lane by lane. The lanes
+// that access outside the memory do not change the memory and return 0.
+func runComponentAtomic(
+	state InstEmuState,
+	storageAccessor StorageAccessor,
+	address ComponentAddressFunc,
+) {
+	inst := state.Inst()
+	atomic, _ := inst.AtomicInfo()
+	pid := state.PID()
+	exec := state.EXEC()
+	byteSize := uint64(atomic.ByteSize)
+
+	for i := 0; i < 64; i++ {
+		if exec&(1<<uint(i)) == 0 {
+			continue
+		}
+
+		old := make([]byte, byteSize)
+
+		if addr, ok := address(i, 0, byteSize); ok {
+			src, cmp := FlatAtomicOperands(state, atomic, i)
+			data := storageAccessor.Read(pid, addr, byteSize)
+			old = ApplyAtomic(atomic, data, src, cmp)
+			storageAccessor.Write(pid, addr, data)
+		}
+
+		if atomic.Return {
+			state.WriteOperandBytes(inst.Dst, i, old)
+		}
+	}
+}
@@ -57,6 +57,8 @@ func (u *ALU) Run(state emu.InstEmuState) {
 		u.runVOP3A(state)
 	case insts.VOP3b:
 		u.runVOP3B(state)
+	case insts.VOP3P:
+		u.runVOP3P(state)
 	case insts.VOPC:
 		u.runVOPC(state)
 	case insts.FLAT:
@@ -67,6 +69,8 @@ func (u *ALU) Run(state emu.InstEmuState) {
 		u.runSOPK(state)
 	case insts.DS:
 		u.runDS(state)
+	case insts.MUBUF, insts.MTBUF:
+		emu.RunBufferInst(state, u.storageAccessor)
 	default:
 		log.Panicf("Inst format %s is not supported", inst.Format.FormatName)
 	}
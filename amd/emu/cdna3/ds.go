@@ -30,10 +30,42 @@ func (u *ALU) runDS(state emu.InstEmuState) {
 	case 255:
 		u.runDSREADB128(state)
 	default:
+		if _, ok := inst.AtomicInfo(); ok {
+			u.runDSAtomic(state)
+			return
+		}
+
 		log.Panicf("Opcode %d for DS format is not implemented", inst.Opcode)
 	}
 }
 
+func (u *ALU) runDSAtomic(state emu.InstEmuState) {
+	inst := state.Inst()
+	atomic, _ := inst.AtomicInfo()
+	exec := state.EXEC()
+	lds := u.LDS()
+	size := uint32(atomic.ByteSize)
+
+	for i := 0; i < 64; i++ {
+		if exec&(1<<uint(i)) == 0 {
+			continue
+		}
+
+		addr := uint32(state.ReadOperand(inst.Addr, i)) + inst.Offset0
+		if addr+size > uint32(len(lds)) {
+			log.Panicf("%s: LDS address 0x%x + %d exceeds LDS size %d (lane %d)",
+				inst.InstName, addr, size, len(lds), i)
+		}
+
+		src, cmp := emu.DSAtomicOperands(state, atomic, i)
+		old := emu.ApplyAtomic(atomic, lds[addr:addr+size], src, cmp)
+
+		if atomic.Return {
+			state.WriteOperandBytes(inst.Dst, i, old)
+		}
+	}
+}
+
 func (u *ALU) runDSWRITEB32(state emu.InstEmuState) {
 	inst := state.Inst()
 	exec := state.EXEC()
@@ -61,6 +61,11 @@ func (u *ALU) flatAddrWithScalar(
 
 //nolint:gocyclo
 func (u *ALU) runFlat(state emu.InstEmuState) {
+	if emu.AccessesScratch(state) {
+		emu.RunScratchInst(state, u.storageAccessor)
+		return
+	}
+
 	inst := state.Inst()
 	switch inst.Opcode {
 	case 16:
@@ -84,6 +89,11 @@ func (u *ALU) runFlat(state emu.InstEmuState) {
 	case 31:
 		u.runFlatStoreDWordX4(state)
 	default:
+		if _, ok := inst.AtomicInfo(); ok {
+			u.runFlatAtomic(state)
+			return
+		}
+
 		log.Panicf("Opcode %d for FLAT format is not implemented", inst.Opcode)
 	}
 }
@@ -273,3 +283,30 @@ func (u *ALU) runFlatStoreDWordX4(state emu.InstEmuState) {
 		u.storageAccessor.Write(pid, addr, data)
 	}
 }
+
+// runFlatAtomic performs the atomic operation lane by lane, so that the lanes
+// that access the same address see the results of the lanes before them.
+func (u *ALU) runFlatAtomic(state emu.InstEmuState) {
+	inst := state.Inst()
+	atomic, _ := inst.AtomicInfo()
+	pid := state.PID()
+	exec := state.EXEC()
+	hasSAddr, scalarBase := u.flatPrecomputeScalarBase(state)
+
+	for i := 0; i < 64; i++ {
+		if exec&(1<<uint(i)) == 0 {
+			continue
+		}
+
+		addr := u.flatAddrWithScalar(state, i, hasSAddr, scalarBase)
+		src, cmp := emu.FlatAtomicOperands(state, atomic, i)
+
+		memory := u.storageAccessor.Read(pid, addr, uint64(atomic.ByteSize))
+		old := emu.ApplyAtomic(atomic, memory, src, cmp)
+		u.storageAccessor.Write(pid, addr, memory)
+
+		if atomic.Return {
+			state.WriteOperandBytes(inst.Dst, i, old)
+		}
+	}
+}
@@ -0,0 +1,252 @@
+package cdna3
+
+import (
+	"log"
+	"math"
+
+	"github.com/sarchlab/mgpusim/v4/amd/emu"
+	"github.com/sarchlab/mgpusim/v4/amd/insts"
+)
+
+// mfmaSrcType is the element type of the A and B matrices of an MFMA
+// instruction.
+type mfmaSrcType int
+
+const (
+	mfmaSrcF32 mfmaSrcType = iota
+	mfmaSrcF16
+	mfmaSrcBF16
+	mfmaSrcI8
+)
+
+// mfmaShape describes the M x N x K shape of an MFMA instruction. Multi-block
+// instructions compute blocks independent products at the same time.
+type mfmaShape struct {
+	m, n, k int
+	blocks  int
+	srcType mfmaSrcType
+}
+
+var mfmaShapes = map[insts.Opcode]mfmaShape{
+	0x40: {32, 32, 1, 2, mfmaSrcF32},   // v_mfma_f32_32x32x1_2b_f32
+	0x41: {16, 16, 1, 4, mfmaSrcF32},   // v_mfma_f32_16x16x1_4b_f32
+	0x42: {4, 4, 1, 16, mfmaSrcF32},    // v_mfma_f32_4x4x1_16b_f32
+	0x44: {32, 32, 2, 1, mfmaSrcF32},   // v_mfma_f32_32x32x2_f32
+	0x45: {16, 16, 4, 1, mfmaSrcF32},   // v_mfma_f32_16x16x4_f32
+	0x48: {32, 32, 4, 2, mfmaSrcF16},   // v_mfma_f32_32x32x4_2b_f16
+	0x49: {16, 16, 4, 4, mfmaSrcF16},   // v_mfma_f32_16x16x4_4b_f16
+	0x4a: {4, 4, 4, 16, mfmaSrcF16},    // v_mfma_f32_4x4x4_16b_f16
+	0x4c: {32, 32, 8, 1, mfmaSrcF16},   // v_mfma_f32_32x32x8_f16
+	0x4d: {16, 16, 16, 1, mfmaSrcF16},  // v_mfma_f32_16x16x16_f16
+	0x50: {32, 32, 4, 2, mfmaSrcI8},    // v_mfma_i32_32x32x4_2b_i8
+	0x51: {16, 16, 4, 4, mfmaSrcI8},    // v_mfma_i32_16x16x4_4b_i8
+	0x52: {4, 4, 4, 16, mfmaSrcI8},     // v_mfma_i32_4x4x4_16b_i8
+	0x56: {32, 32, 16, 1, mfmaSrcI8},   // v_mfma_i32_32x32x16_i8
+	0x57: {16, 16, 32, 1, mfmaSrcI8},   // v_mfma_i32_16x16x32_i8
+	0x63: {32, 32, 4, 2, mfmaSrcBF16},  // v_mfma_f32_32x32x4_2b_bf16
+	0x64: {16, 16, 4, 4, mfmaSrcBF16},  // v_mfma_f32_16x16x4_4b_bf16
+	0x65: {4, 4, 4, 16, mfmaSrcBF16},   // v_mfma_f32_4x4x4_16b_bf16
+	0x66: {32, 32, 8, 1, mfmaSrcBF16},  // v_mfma_f32_32x32x8_bf16
+	0x67: {16, 16, 16, 1, mfmaSrcBF16}, // v_mfma_f32_16x16x16_bf16
+}
+
+// runVOP3PMAI executes the instructions in the VOP3P-MAI opcode range, which
+// include the MFMA instructions and the AccVGPR moves.
+func (u *ALU) runVOP3PMAI(state emu.InstEmuState) {
+	inst := state.Inst()
+
+	switch inst.Opcode {
+	case 0x58:
+		u.runVACCVGPRReadB32(state)
+	case 0x59:
+		u.runVACCVGPRWriteB32(state)
+	default:
+		shape, ok := mfmaShapes[inst.Opcode]
+		if !ok {
+			log.Panicf("MFMA opcode %d is not implemented", inst.Opcode)
+		}
+		u.runMFMA(state, shape)
+	}
+}
+
+func (u *ALU) runVACCVGPRReadB32(state emu.InstEmuState) {
+	inst := state.Inst()
+	exec := state.EXEC()
+
+	for i := 0; i < 64; i++ {
+		if exec&(1<<uint(i)) == 0 {
+			continue
+		}
+
+		src := state.ReadOperand(inst.Src0, i)
+		state.WriteOperand(inst.Dst, i, uint64(uint32(src)))
+	}
+}
+
+func (u *ALU) runVACCVGPRWriteB32(state emu.InstEmuState) {
+	inst := state.Inst()
+	exec := state.EXEC()
+
+	for i := 0; i < 64; i++ {
+		if exec&(1<<uint(i)) == 0 {
+			continue
+		}
+
+		src := state.ReadOperand(inst.Src0, i)
+		state.WriteOperand(inst.Dst, i, uint64(uint32(src)))
+	}
+}
+
+// runMFMA computes D = A * B + C for each block of an MFMA instruction. The
+// A matrix (M x K) of block b is spread across the lanes so that lane l holds
+// row l % M, and the lanes that hold the same row split the K elements into
+// groups of kPerLane, followed by the lanes of the next block. B is laid out
+// the same way by column. The C and D matrices hold 4 consecutive rows per
+// register, interleaved across the lane groups, one block after another.
+// MFMA instructions ignore the EXEC mask.
+//
+// CBSZ and ABID broadcast the A matrix of block ABID to every block in each
+// group of 2^CBSZ blocks. BLGP selects the lanes that the B matrix is read
+// from.
+func (u *ALU) runMFMA(state emu.InstEmuState, shape mfmaShape) {
+	inst := state.Inst()
+
+	groups := 64 / (shape.m * shape.blocks)
+	kPerLane := shape.k / groups
+
+	a := make([][][]float64, shape.blocks)
+	b := make([][][]float64, shape.blocks)
+	for blk := 0; blk < shape.blocks; blk++ {
+		a[blk] = make([][]float64, shape.m)
+		b[blk] = make([][]float64, shape.k)
+		for i := range a[blk] {
+			a[blk][i] = make([]float64, shape.k)
+		}
+		for k := range b[blk] {
+			b[blk][k] = make([]float64, shape.n)
+		}
+	}
+
+	for lane := 0; lane < 64; lane++ {
+		srcA := state.ReadOperand(inst.Src0, lane)
+		srcB := state.ReadOperand(inst.Src1, mfmaBLGPLane(inst.Blgp, lane))
+		row := lane % shape.m
+		col := lane % shape.n
+		kBase := kPerLane * ((lane / shape.m) % groups)
+		blk := lane / (shape.m * groups)
+
+		for e := 0; e < kPerLane; e++ {
+			a[blk][row][kBase+e] = mfmaElement(srcA, e, shape.srcType)
+			b[blk][kBase+e][col] = mfmaElement(srcB, e, shape.srcType)
+		}
+	}
+
+	broadcastMask := (1 << inst.Cbsz) - 1
+
+	numRegs := shape.blocks * shape.m * shape.n / 64
+	for r := 0; r < numRegs; r++ {
+		src2 := mfmaRegOperand(inst.Src2, r)
+		dst := mfmaRegOperand(inst.Dst, r)
+
+		for lane := 0; lane < 64; lane++ {
+			blk, i, j := mfmaOutputIndex(shape, r, lane)
+			blkA := blk&^broadcastMask + inst.Abid
+			c := uint32(state.ReadOperand(src2, lane))
+
+			state.WriteOperand(dst, lane,
+				uint64(mfmaDot(a[blkA][i], b[blk], j, c, shape)))
+		}
+	}
+}
+
+// mfmaOutputIndex returns the block, row, and column of the D matrix element
+// that register r of the given lane holds. Blocks that are too small to fill
+// a register place one block per N lanes and one row per register.
+func mfmaOutputIndex(shape mfmaShape, r, lane int) (blk, i, j int) {
+	j = lane % shape.n
+
+	if shape.m*shape.n < 64 {
+		return lane / shape.n, r, j
+	}
+
+	regsPerBlock := shape.m * shape.n / 64
+	rr := r % regsPerBlock
+	i = (rr % 4) + 4*(lane/shape.n) + 4*(64/shape.n)*(rr/4)
+
+	return r / regsPerBlock, i, j
+}
+
+// mfmaBLGPLane returns the lane that the B matrix value of the given lane is
+// read from under the B-matrix lane group pattern blgp.
+func mfmaBLGPLane(blgp, lane int) int {
+	switch blgp {
+	case 0:
+		return lane
+	case 1:
+		// Broadcast lanes 0-31 to lanes 32-63.
+		return lane % 32
+	case 2:
+		// Broadcast lanes 32-63 to lanes 0-31.
+		return 32 + lane%32
+	case 3:
+		// Rotate all the lanes down by 16.
+		return (lane + 16) % 64
+	default:
+		// Broadcast one group of 16 lanes to all the lanes.
+		return 16*(blgp-4) + lane%16
+	}
+}
+
+func mfmaDot(
+	a []float64,
+	b [][]float64,
+	col int,
+	c uint32,
+	shape mfmaShape,
+) uint32 {
+	if shape.srcType == mfmaSrcI8 {
+		acc := emu.AsInt32(c)
+		for k := 0; k < shape.k; k++ {
+			acc += int32(a[k]) * int32(b[k][col])
+		}
+		return emu.Int32ToBits(acc)
+	}
+
+	acc := math.Float32frombits(c)
+	for k := 0; k < shape.k; k++ {
+		acc += float32(a[k]) * float32(b[k][col])
+	}
+	return math.Float32bits(acc)
+}
+
+// mfmaElement extracts the e-th packed element of an A or B source value.
+func mfmaElement(src uint64, e int, srcType mfmaSrcType) float64 {
+	switch srcType {
+	case mfmaSrcF32:
+		return float64(math.Float32frombits(uint32(src)))
+	case mfmaSrcF16:
+		return float64(float16ToFloat32(uint16(src >> (16 * e))))
+	case mfmaSrcBF16:
+		return float64(bfloat16ToFloat32(uint16(src >> (16 * e))))
+	case mfmaSrcI8:
+		return float64(int8(src >> (8 * e)))
+	}
+
+	panic("unknown MFMA source type")
+}
+
+// mfmaRegOperand returns the single register that is offset registers after
+// the first register of a register-range operand. Constants are returned as
+// they are.
+func mfmaRegOperand(o *insts.Operand, offset int) *insts.Operand {
+	if o.OperandType != insts.RegOperand {
+		return o
+	}
+
+	return &insts.Operand{
+		Code:        o.Code + offset,
+		OperandType: insts.RegOperand,
+		Register:    insts.Regs[o.Register.RegType+insts.RegType(offset)],
+		RegCount:    1,
+	}
+}
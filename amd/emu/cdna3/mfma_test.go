@@ -0,0 +1,268 @@
+package cdna3
+
+import (
+	"math"
+	"testing"
+
+	"github.com/sarchlab/mgpusim/v4/amd/insts"
+)
+
+// regMockInstState backs register operands with a per-register, per-lane
+// store, so that instructions that access individual registers of a register
+// range can be tested.
+type regMockInstState struct {
+	*mockInstState
+	regs map[insts.RegType]map[int]uint32
+}
+
+func newRegMockInstState() *regMockInstState {
+	return &regMockInstState{
+		mockInstState: newMockInstState(),
+		regs:          make(map[insts.RegType]map[int]uint32),
+	}
+}
+
+func (s *regMockInstState) ReadOperand(operand *insts.Operand, laneID int) uint64 {
+	if operand.OperandType != insts.RegOperand {
+		return s.mockInstState.ReadOperand(operand, laneID)
+	}
+
+	lo := uint64(s.reg(operand.Register.RegType, laneID))
+	if operand.RegCount < 2 {
+		return lo
+	}
+
+	hi := uint64(s.reg(operand.Register.RegType+1, laneID))
+	return lo | hi<<32
+}
+
+func (s *regMockInstState) WriteOperand(operand *insts.Operand, laneID int, value uint64) {
+	s.setReg(operand.Register.RegType, laneID, uint32(value))
+	if operand.RegCount >= 2 {
+		s.setReg(operand.Register.RegType+1, laneID, uint32(value>>32))
+	}
+}
+
+func (s *regMockInstState) reg(r insts.RegType, lane int) uint32 {
+	return s.regs[r][lane]
+}
+
+func (s *regMockInstState) setReg(r insts.RegType, lane int, v uint32) {
+	if s.regs[r] == nil {
+		s.regs[r] = make(map[int]uint32)
+	}
+	s.regs[r][lane] = v
+}
+
+func TestVOP3PMAIMFMAF32x16x16x4F32(t *testing.T) {
+	alu := NewALU(nil)
+	state := newRegMockInstState()
+	state.inst.FormatType = insts.VOP3P
+	state.inst.Opcode = 0x45
+	state.inst.Src0 = insts.NewVRegOperand(256, 0, 1)
+	state.inst.Src1 = insts.NewVRegOperand(257, 1, 1)
+	state.inst.Src2 = insts.NewARegOperand(256, 0, 4)
+	state.inst.Dst = insts.NewARegOperand(256, 0, 4)
+
+	a := func(i, k int) float32 { return float32(i + k) }
+	b := func(k, j int) float32 { return float32(k - j) }
+
+	for lane := 0; lane < 64; lane++ {
+		k := lane / 16
+		state.setReg(insts.V0, lane, math.Float32bits(a(lane%16, k)))
+		state.setReg(insts.V1, lane, math.Float32bits(b(k, lane%16)))
+		for r := 0; r < 4; r++ {
+			state.setReg(insts.A0+insts.RegType(r), lane, math.Float32bits(1))
+		}
+	}
+
+	alu.Run(state)
+
+	for lane := 0; lane < 64; lane++ {
+		for r := 0; r < 4; r++ {
+			i := 4*(lane/16) + r
+			j := lane % 16
+			expected := float32(1)
+			for k := 0; k < 4; k++ {
+				expected += a(i, k) * b(k, j)
+			}
+
+			got := math.Float32frombits(
+				state.reg(insts.A0+insts.RegType(r), lane))
+			if got != expected {
+				t.Fatalf("D[%d][%d]: expected %v, got %v", i, j, expected, got)
+			}
+		}
+	}
+}
+
+func TestVOP3PMAIMFMAI32x32x32x16I8(t *testing.T) {
+	alu := NewALU(nil)
+	state := newRegMockInstState()
+	state.inst.FormatType = insts.VOP3P
+	state.inst.Opcode = 0x56
+	state.inst.Src0 = insts.NewVRegOperand(256, 0, 2)
+	state.inst.Src1 = insts.NewVRegOperand(258, 2, 2)
+	state.inst.Src2 = insts.NewIntOperand(128, 0)
+	state.inst.Dst = insts.NewVRegOperand(260, 4, 16)
+
+	// A is all ones and B[k][j] = j - k, so D[i][j] = sum_k (j - k).
+	for lane := 0; lane < 64; lane++ {
+		j := lane % 32
+		kBase := 8 * (lane / 32)
+		var packedB uint64
+		for e := 0; e < 8; e++ {
+			packedB |= uint64(uint8(int8(j-(kBase+e)))) << (8 * e)
+		}
+		state.setReg(insts.V0, lane, 0x01010101)
+		state.setReg(insts.V1, lane, 0x01010101)
+		state.setReg(insts.V2, lane, uint32(packedB))
+		state.setReg(insts.V3, lane, uint32(packedB>>32))
+	}
+
+	alu.Run(state)
+
+	for lane := 0; lane < 64; lane++ {
+		j := lane % 32
+		expected := int32(16*j - 120)
+		for r := 0; r < 16; r++ {
+			got := int32(state.reg(insts.V4+insts.RegType(r), lane))
+			if got != expected {
+				t.Fatalf("lane %d reg %d: expected %d, got %d",
+					lane, r, expected, got)
+			}
+		}
+	}
+}
+
+// runMFMA4x4x1x16B runs v_mfma_f32_4x4x1_16b_f32 with A[b][i] = b + i and
+// B[b][j] = b - j and returns D[b][i][j].
+func runMFMA4x4x1x16B(cbsz, abid, blgp int) func(b, i, j int) float32 {
+	alu := NewALU(nil)
+	state := newRegMockInstState()
+	state.inst.FormatType = insts.VOP3P
+	state.inst.Opcode = 0x42
+	state.inst.Cbsz = cbsz
+	state.inst.Abid = abid
+	state.inst.Blgp = blgp
+	state.inst.Src0 = insts.NewVRegOperand(256, 0, 1)
+	state.inst.Src1 = insts.NewVRegOperand(257, 1, 1)
+	state.inst.Src2 = insts.NewIntOperand(128, 0)
+	state.inst.Dst = insts.NewVRegOperand(258, 2, 4)
+
+	for lane := 0; lane < 64; lane++ {
+		b := lane / 4
+		state.setReg(insts.V0, lane, math.Float32bits(float32(b+lane%4)))
+		state.setReg(insts.V1, lane, math.Float32bits(float32(b-lane%4)))
+	}
+
+	alu.Run(state)
+
+	return func(b, i, j int) float32 {
+		return math.Float32frombits(
+			state.reg(insts.V2+insts.RegType(i), 4*b+j))
+	}
+}
+
+func TestVOP3PMAIMFMAF32x4x4x1x16BF32(t *testing.T) {
+	d := runMFMA4x4x1x16B(0, 0, 0)
+
+	for b := 0; b < 16; b++ {
+		for i := 0; i < 4; i++ {
+			for j := 0; j < 4; j++ {
+				expected := float32((b + i) * (b - j))
+				if got := d(b, i, j); got != expected {
+					t.Fatalf("D[%d][%d][%d]: expected %v, got %v",
+						b, i, j, expected, got)
+				}
+			}
+		}
+	}
+}
+
+func TestVOP3PMAIMFMABroadcast(t *testing.T) {
+	// cbsz:2 abid:1 uses the A matrix of the second block of every group of
+	// 4 blocks. blgp:3 rotates the lanes of B down by 16, so block b reads
+	// the B matrix of block b + 4.
+	d := runMFMA4x4x1x16B(2, 1, 3)
+
+	for b := 0; b < 16; b++ {
+		blkA := b&^3 + 1
+		blkB := (b + 4) % 16
+		for i := 0; i < 4; i++ {
+			for j := 0; j < 4; j++ {
+				expected := float32((blkA + i) * (blkB - j))
+				if got := d(b, i, j); got != expected {
+					t.Fatalf("D[%d][%d][%d]: expected %v, got %v",
+						b, i, j, expected, got)
+				}
+			}
+		}
+	}
+}
+
+func TestVOP3PMAIMFMAF32x32x32x1x2BF32(t *testing.T) {
+	alu := NewALU(nil)
+	state := newRegMockInstState()
+	state.inst.FormatType = insts.VOP3P
+	state.inst.Opcode = 0x40
+	state.inst.Src0 = insts.NewVRegOperand(256, 0, 1)
+	state.inst.Src1 = insts.NewVRegOperand(257, 1, 1)
+	state.inst.Src2 = insts.NewIntOperand(128, 0)
+	state.inst.Dst = insts.NewARegOperand(256, 0, 32)
+
+	// A[b][i] = i + 1 and B[b][j] = b + 1.
+	for lane := 0; lane < 64; lane++ {
+		state.setReg(insts.V0, lane, math.Float32bits(float32(lane%32+1)))
+		state.setReg(insts.V1, lane, math.Float32bits(float32(lane/32+1)))
+	}
+
+	alu.Run(state)
+
+	for lane := 0; lane < 64; lane++ {
+		for r := 0; r < 32; r++ {
+			b := r / 16
+			i := (r % 4) + 4*(lane/32) + 8*((r%16)/4)
+			expected := float32((i + 1) * (b + 1))
+
+			got := math.Float32frombits(
+				state.reg(insts.A0+insts.RegType(r), lane))
+			if got != expected {
+				t.Fatalf("lane %d reg %d: expected %v, got %v",
+					lane, r, expected, got)
+			}
+		}
+	}
+}
+
+func TestVOP3PMAIAccVGPRWriteAndRead(t *testing.T) {
+	alu := NewALU(nil)
+	state := newRegMockInstState()
+	state.exec = 0x3
+	state.inst.FormatType = insts.VOP3P
+	state.inst.Opcode = 0x59
+	state.inst.Src0 = insts.NewVRegOperand(258, 2, 0)
+	state.inst.Dst = insts.NewARegOperand(1, 1, 0)
+	state.setReg(insts.V2, 0, 42)
+	state.setReg(insts.V2, 1, 43)
+	state.setReg(insts.V2, 2, 44)
+
+	alu.Run(state)
+
+	if state.reg(insts.A1, 0) != 42 || state.reg(insts.A1, 1) != 43 {
+		t.Fatalf("v_accvgpr_write_b32 did not write active lanes")
+	}
+	if state.reg(insts.A1, 2) != 0 {
+		t.Fatalf("v_accvgpr_write_b32 wrote an inactive lane")
+	}
+
+	state.inst.Opcode = 0x58
+	state.inst.Src0 = insts.NewARegOperand(257, 1, 0)
+	state.inst.Dst = insts.NewVRegOperand(3, 3, 0)
+
+	alu.Run(state)
+
+	if state.reg(insts.V3, 0) != 42 || state.reg(insts.V3, 1) != 43 {
+		t.Fatalf("v_accvgpr_read_b32 did not read active lanes")
+	}
+}
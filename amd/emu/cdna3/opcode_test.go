@@ -61,6 +61,8 @@ func (s *mockInstState) SetSCC(v byte)    { s.scc = v }
 func (s *mockInstState) PC() uint64       { return s.pc }
 func (s *mockInstState) SetPC(v uint64)   { s.pc = v }
 
+func (s *mockInstState) ScratchBase() uint64 { return 0 }
+
 // setOperand sets an operand value for a specific lane.
 func (s *mockInstState) setOperand(op *insts.Operand, lane int, value uint64) {
 	if s.operands[op] == nil {
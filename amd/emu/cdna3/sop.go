@@ -96,6 +96,8 @@ func (u *ALU) runSOPP(state emu.InstEmuState) {
 		u.runSCBRANCHEXECNZ(state)
 	case 12: // S_WAITCNT
 		// Do nothing
+	case 14, 15, 16, 17, 18: // S_SLEEP, S_SETPRIO, S_SENDMSG(HALT), S_TRAP
+		// Only change the timing of the wavefront.
 	default:
 		log.Panicf("Opcode %d for SOPP format is not implemented", inst.Opcode)
 	}
@@ -239,6 +239,35 @@ func float32ToFloat16(f float32) uint16 {
 	return (uint16(sign) << 15) | (f16exp << 10) | f16frac
 }
 
+// float16ToFloat32 converts IEEE half-precision bits to a float32.
+func float16ToFloat32(h uint16) float32 {
+	sign := uint32(h>>15) & 1
+	exp := uint32(h>>10) & 0x1F
+	frac := uint32(h) & 0x3FF
+
+	switch {
+	case exp == 0 && frac == 0:
+		return math.Float32frombits(sign << 31)
+	case exp == 0:
+		// Denormal
+		f := float32(frac) / 1024 / 16384
+		if sign != 0 {
+			f = -f
+		}
+		return f
+	case exp == 31:
+		return math.Float32frombits((sign << 31) | (0xFF << 23) | (frac << 13))
+	}
+
+	return math.Float32frombits(
+		(sign << 31) | ((exp - 15 + 127) << 23) | (frac << 13))
+}
+
+// bfloat16ToFloat32 converts bfloat16 bits to a float32.
+func bfloat16ToFloat32(b uint16) float32 {
+	return math.Float32frombits(uint32(b) << 16)
+}
+
 func (u *ALU) runVCVTF32F64(state emu.InstEmuState) {
 	inst := state.Inst()
 	exec := state.EXEC()
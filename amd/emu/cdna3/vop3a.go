@@ -4,7 +4,6 @@ import (
 	"log"
 	"math"
 	"sort"
-	"strings"
 
 	"github.com/sarchlab/mgpusim/v4/amd/bitops"
 	"github.com/sarchlab/mgpusim/v4/amd/emu"
@@ -135,12 +134,6 @@ func (u *ALU) runVOP3A(state emu.InstEmuState) {
 		u.runVMULLOU32(state)
 	case 646:
 		u.runVMULHIU32(state)
-	case 944:
-		u.runVPKFMAF32(state)
-	case 945:
-		u.runVPKMULF32(state)
-	case 946:
-		u.runVPKADDF32(state)
 	case 509:
 		u.runVLSHLADDU32(state)
 	case 510:
@@ -310,10 +303,6 @@ func (u *ALU) vop3aPreprocess(state emu.InstEmuState) {
 func (u *ALU) vop3aPostprocess(state emu.InstEmuState) {
 	inst := state.Inst()
 
-	if strings.HasPrefix(inst.InstName, "v_pk_") {
-		return
-	}
-
 	if inst.Omod != 0 {
 		log.Panic("Output modifiers are not supported.")
 	}
@@ -1171,153 +1160,3 @@ func (u *ALU) isDIVFIXUPF64Overflow(
 	return int64(exponentSrc2-exponentSrc1) < -1075 ||
 		exponentSrc1 == 2047
 }
-
-func (u *ALU) runVPKFMAF32(state emu.InstEmuState) { //nolint:funlen
-	inst := state.Inst()
-	exec := state.EXEC()
-
-	// VOP3P encoding for 3-source FMA:
-	// OpSel[0:2]: src0/src1/src2 word select for lower result
-	// OpSelHi[0:2]: src0/src1/src2 word select for upper result
-	// Neg field applies to both lo and hi halves.
-	op_sel := inst.OpSel
-	op_sel_hi := inst.OpSelHi
-
-	for i := 0; i < 64; i++ {
-		if exec&(1<<uint(i)) == 0 {
-			continue
-		}
-
-		src0Bits := state.ReadOperand(inst.Src0, i)
-		src1Bits := state.ReadOperand(inst.Src1, i)
-		src2Bits := state.ReadOperand(inst.Src2, i)
-
-		src0_lo := math.Float32frombits(uint32(src0Bits))
-		src0_hi := math.Float32frombits(uint32(src0Bits >> 32))
-		src1_lo := math.Float32frombits(uint32(src1Bits))
-		src1_hi := math.Float32frombits(uint32(src1Bits >> 32))
-		src2_lo := math.Float32frombits(uint32(src2Bits))
-		src2_hi := math.Float32frombits(uint32(src2Bits >> 32))
-
-		var a_lo, a_hi float32
-		var b_lo, b_hi float32
-		var c_lo, c_hi float32
-
-		if op_sel&1 != 0 { a_lo = src0_hi } else { a_lo = src0_lo }
-		if op_sel&2 != 0 { b_lo = src1_hi } else { b_lo = src1_lo }
-		if op_sel&4 != 0 { c_lo = src2_hi } else { c_lo = src2_lo }
-
-		if op_sel_hi&1 != 0 { a_hi = src0_hi } else { a_hi = src0_lo }
-		if op_sel_hi&2 != 0 { b_hi = src1_hi } else { b_hi = src1_lo }
-		if op_sel_hi&4 != 0 { c_hi = src2_hi } else { c_hi = src2_lo }
-
-		// Apply neg modifiers (neg_lo = neg_hi for VOP3P)
-		if inst.Src0Neg { a_lo = -a_lo; a_hi = -a_hi }
-		if inst.Src1Neg { b_lo = -b_lo; b_hi = -b_hi }
-		if inst.Src2Neg { c_lo = -c_lo; c_hi = -c_hi }
-
-		res_lo := a_lo * b_lo + c_lo
-		res_hi := a_hi * b_hi + c_hi
-
-		dstBits := uint64(math.Float32bits(res_lo)) | (uint64(math.Float32bits(res_hi)) << 32)
-		state.WriteOperand(inst.Dst, i, dstBits)
-	}
-}
-
-func (u *ALU) runVPKMULF32(state emu.InstEmuState) {
-	inst := state.Inst()
-	exec := state.EXEC()
-
-	// VOP3P encoding for 2-source packed f32 operations:
-	// OpSel[0] (bit 0): src0 word select for lower result (0=lo, 1=hi)
-	// OpSel[1] (bit 1): src1 word select for lower result (0=lo, 1=hi)
-	// OpSelHi[0] (bit 0): src0 word select for upper result (0=lo, 1=hi)
-	// OpSelHi[1] (bit 1): src1 word select for upper result (0=lo, 1=hi)
-	// Neg field (neg_lo) applies negation to both lo and hi halves.
-	op_sel := inst.OpSel
-	op_sel_hi := inst.OpSelHi
-
-	for i := 0; i < 64; i++ {
-		if exec&(1<<uint(i)) == 0 {
-			continue
-		}
-
-		src0Bits := state.ReadOperand(inst.Src0, i)
-		src1Bits := state.ReadOperand(inst.Src1, i)
-
-		src0_lo := math.Float32frombits(uint32(src0Bits))
-		src0_hi := math.Float32frombits(uint32(src0Bits >> 32))
-		src1_lo := math.Float32frombits(uint32(src1Bits))
-		src1_hi := math.Float32frombits(uint32(src1Bits >> 32))
-
-		var a_lo, b_lo float32
-		var a_hi, b_hi float32
-
-		// Lower result word select
-		if op_sel&1 != 0 { a_lo = src0_hi } else { a_lo = src0_lo }
-		if op_sel&2 != 0 { b_lo = src1_hi } else { b_lo = src1_lo }
-
-		// Upper result word select
-		if op_sel_hi&1 != 0 { a_hi = src0_hi } else { a_hi = src0_lo }
-		if op_sel_hi&2 != 0 { b_hi = src1_hi } else { b_hi = src1_lo }
-
-		// Apply neg modifiers (neg_lo = neg_hi for VOP3P)
-		if inst.Src0Neg { a_lo = -a_lo; a_hi = -a_hi }
-		if inst.Src1Neg { b_lo = -b_lo; b_hi = -b_hi }
-
-		res_lo := a_lo * b_lo
-		res_hi := a_hi * b_hi
-
-		dstBits := uint64(math.Float32bits(res_lo)) | (uint64(math.Float32bits(res_hi)) << 32)
-		state.WriteOperand(inst.Dst, i, dstBits)
-	}
-}
-
-func (u *ALU) runVPKADDF32(state emu.InstEmuState) {
-	inst := state.Inst()
-	exec := state.EXEC()
-
-	// VOP3P encoding for 2-source packed f32 operations:
-	// OpSel[0] (bit 0): src0 word select for lower result (0=lo, 1=hi)
-	// OpSel[1] (bit 1): src1 word select for lower result (0=lo, 1=hi)
-	// OpSelHi[0] (bit 0): src0 word select for upper result (0=lo, 1=hi)
-	// OpSelHi[1] (bit 1): src1 word select for upper result (0=lo, 1=hi)
-	// Neg field (neg_lo) applies negation to both lo and hi halves.
-	op_sel := inst.OpSel
-	op_sel_hi := inst.OpSelHi
-
-	for i := 0; i < 64; i++ {
-		if exec&(1<<uint(i)) == 0 {
-			continue
-		}
-
-		src0Bits := state.ReadOperand(inst.Src0, i)
-		src1Bits := state.ReadOperand(inst.Src1, i)
-
-		src0_lo := math.Float32frombits(uint32(src0Bits))
-		src0_hi := math.Float32frombits(uint32(src0Bits >> 32))
-		src1_lo := math.Float32frombits(uint32(src1Bits))
-		src1_hi := math.Float32frombits(uint32(src1Bits >> 32))
-
-		var a_lo, a_hi float32
-		var b_lo, b_hi float32
-
-		// Lower result word select
-		if op_sel&1 != 0 { a_lo = src0_hi } else { a_lo = src0_lo }
-		if op_sel&2 != 0 { b_lo = src1_hi } else { b_lo = src1_lo }
-
-		// Upper result word select
-		if op_sel_hi&1 != 0 { a_hi = src0_hi } else { a_hi = src0_lo }
-		if op_sel_hi&2 != 0 { b_hi = src1_hi } else { b_hi = src1_lo }
-
-		// Apply neg modifiers (neg_lo = neg_hi for VOP3P)
-		if inst.Src0Neg { a_lo = -a_lo; a_hi = -a_hi }
-		if inst.Src1Neg { b_lo = -b_lo; b_hi = -b_hi }
-
-		res_lo := a_lo + b_lo
-		res_hi := a_hi + b_hi
-
-		dstBits := uint64(math.Float32bits(res_lo)) | (uint64(math.Float32bits(res_hi)) << 32)
-		state.WriteOperand(inst.Dst, i, dstBits)
-	}
-}
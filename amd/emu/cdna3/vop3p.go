@@ -0,0 +1,372 @@
+package cdna3
+
+import (
+	"log"
+	"math"
+
+	"github.com/sarchlab/mgpusim/v4/amd/emu"
+	"github.com/sarchlab/mgpusim/v4/amd/insts"
+)
+
+// packedOp computes one half of a packed instruction from the selected halves
+// of the sources.
+type packedOp func(a, b, c uint64) uint64
+
+// operandReader reads the value of a source operand of a lane.
+type operandReader func(
+	state emu.InstEmuState,
+	src *insts.Operand,
+	laneID int,
+) uint64
+
+func readOperand(
+	state emu.InstEmuState,
+	src *insts.Operand,
+	laneID int,
+) uint64 {
+	return state.ReadOperand(src, laneID)
+}
+
+// readF16Operand reads a source operand that holds f16 values. The inline
+// float constants are f16 values that both halves of the source take, rather
+// than the f32 values that ReadOperand returns.
+func readF16Operand(
+	state emu.InstEmuState,
+	src *insts.Operand,
+	laneID int,
+) uint64 {
+	if src.OperandType == insts.FloatOperand {
+		h := uint64(float32ToFloat16(float32(src.FloatValue)))
+		return h | h<<16
+	}
+
+	return state.ReadOperand(src, laneID)
+}
+
+//nolint:gocyclo,funlen
+func (u *ALU) runVOP3P(state emu.InstEmuState) {
+	inst := state.Inst()
+
+	if inst.Opcode >= 0x40 {
+		u.runVOP3PMAI(state)
+		return
+	}
+
+	switch inst.Opcode {
+	case 0x00: // v_pk_mad_i16
+		u.runPackedInt16(state, func(a, b, c uint64) uint64 {
+			return uint64(int16(a)*int16(b) + int16(c))
+		})
+	case 0x01: // v_pk_mul_lo_u16
+		u.runPackedInt16(state, func(a, b, _ uint64) uint64 { return a * b })
+	case 0x02, 0x0a: // v_pk_add_i16, v_pk_add_u16
+		u.runPackedInt16(state, func(a, b, _ uint64) uint64 { return a + b })
+	case 0x03, 0x0b: // v_pk_sub_i16, v_pk_sub_u16
+		u.runPackedInt16(state, func(a, b, _ uint64) uint64 { return a - b })
+	case 0x04: // v_pk_lshlrev_b16
+		u.runPackedInt16(state, func(a, b, _ uint64) uint64 {
+			return b << (a & 0xf)
+		})
+	case 0x05: // v_pk_lshrrev_b16
+		u.runPackedInt16(state, func(a, b, _ uint64) uint64 {
+			return b >> (a & 0xf)
+		})
+	case 0x06: // v_pk_ashrrev_i16
+		u.runPackedInt16(state, func(a, b, _ uint64) uint64 {
+			return uint64(int16(b) >> (a & 0xf))
+		})
+	case 0x07: // v_pk_max_i16
+		u.runPackedInt16(state, func(a, b, _ uint64) uint64 {
+			return uint64(max(int16(a), int16(b)))
+		})
+	case 0x08: // v_pk_min_i16
+		u.runPackedInt16(state, func(a, b, _ uint64) uint64 {
+			return uint64(min(int16(a), int16(b)))
+		})
+	case 0x09: // v_pk_mad_u16
+		u.runPackedInt16(state, func(a, b, c uint64) uint64 { return a*b + c })
+	case 0x0c: // v_pk_max_u16
+		u.runPackedInt16(state, func(a, b, _ uint64) uint64 { return max(a, b) })
+	case 0x0d: // v_pk_min_u16
+		u.runPackedInt16(state, func(a, b, _ uint64) uint64 { return min(a, b) })
+	case 0x0e: // v_pk_fma_f16
+		u.runPackedF16(state, func(a, b, c float32) float32 { return a*b + c })
+	case 0x0f: // v_pk_add_f16
+		u.runPackedF16(state, func(a, b, _ float32) float32 { return a + b })
+	case 0x10: // v_pk_mul_f16
+		u.runPackedF16(state, func(a, b, _ float32) float32 { return a * b })
+	case 0x11: // v_pk_min_f16
+		u.runPackedF16(state, func(a, b, _ float32) float32 {
+			return float32(math.Min(float64(a), float64(b)))
+		})
+	case 0x12: // v_pk_max_f16
+		u.runPackedF16(state, func(a, b, _ float32) float32 {
+			return float32(math.Max(float64(a), float64(b)))
+		})
+	case 0x23:
+		u.runVDOT2F32F16(state)
+	case 0x26:
+		u.runVDotInt(state, 2, true)
+	case 0x27:
+		u.runVDotInt(state, 2, false)
+	case 0x28:
+		u.runVDotInt(state, 4, true)
+	case 0x29:
+		u.runVDotInt(state, 4, false)
+	case 0x30: // v_pk_fma_f32
+		u.runPackedF32(state, func(a, b, c float32) float32 { return a*b + c })
+	case 0x31: // v_pk_mul_f32
+		u.runPackedF32(state, func(a, b, _ float32) float32 { return a * b })
+	case 0x32: // v_pk_add_f32
+		u.runPackedF32(state, func(a, b, _ float32) float32 { return a + b })
+	case 0x33:
+		u.runVPKMOVB32(state)
+	default:
+		log.Panicf("Opcode %d for VOP3P format is not implemented", inst.Opcode)
+	}
+}
+
+// runPacked applies op to the low and the high halves of the sources, where
+// each half is width bits wide. OpSel selects the source halves that produce
+// the low half of the result, and OpSelHi selects those that produce the high
+// half. If signBit is not zero, the neg_lo and neg_hi modifiers flip the sign
+// bit of the selected halves. read reads the sources.
+func (u *ALU) runPacked(
+	state emu.InstEmuState,
+	width uint,
+	signBit uint64,
+	read operandReader,
+	op packedOp,
+) {
+	inst := state.Inst()
+	exec := state.EXEC()
+	mask := uint64(1)<<width - 1
+
+	srcs := []*insts.Operand{inst.Src0, inst.Src1, inst.Src2}
+
+	for i := 0; i < 64; i++ {
+		if exec&(1<<uint(i)) == 0 {
+			continue
+		}
+
+		var lo, hi [3]uint64
+		for n, src := range srcs {
+			if src == nil {
+				continue
+			}
+
+			value := read(state, src, i)
+			lo[n] = vop3pHalf(value, inst.OpSel, n, width)
+			hi[n] = vop3pHalf(value, inst.OpSelHi, n, width)
+
+			if inst.Neg&(1<<uint(n)) != 0 {
+				lo[n] ^= signBit
+			}
+
+			if inst.NegHi&(1<<uint(n)) != 0 {
+				hi[n] ^= signBit
+			}
+		}
+
+		resLo := op(lo[0], lo[1], lo[2]) & mask
+		resHi := op(hi[0], hi[1], hi[2]) & mask
+
+		state.WriteOperand(inst.Dst, i, resLo|resHi<<width)
+	}
+}
+
+// vop3pHalf returns the half of the source value that the selection bits pick
+// for source n.
+func vop3pHalf(value uint64, sel int, n int, width uint) uint64 {
+	if sel&(1<<uint(n)) != 0 {
+		value >>= width
+	}
+
+	return value & (uint64(1)<<width - 1)
+}
+
+func (u *ALU) runPackedInt16(state emu.InstEmuState, op packedOp) {
+	if state.Inst().Clamp {
+		log.Panic("Clamp is not supported for packed integer instructions.")
+	}
+
+	u.runPacked(state, 16, 0, readOperand, op)
+}
+
+func (u *ALU) runPackedF16(
+	state emu.InstEmuState,
+	op func(a, b, c float32) float32,
+) {
+	clamp := state.Inst().Clamp
+
+	u.runPacked(state, 16, 0x8000, readF16Operand, func(a, b, c uint64) uint64 {
+		res := op(float16ToFloat32(uint16(a)),
+			float16ToFloat32(uint16(b)),
+			float16ToFloat32(uint16(c)))
+		if clamp {
+			res = clampF32(res)
+		}
+
+		return uint64(float32ToFloat16(res))
+	})
+}
+
+func (u *ALU) runPackedF32(
+	state emu.InstEmuState,
+	op func(a, b, c float32) float32,
+) {
+	clamp := state.Inst().Clamp
+
+	u.runPacked(state, 32, 0x80000000, readOperand, func(a, b, c uint64) uint64 {
+		res := op(math.Float32frombits(uint32(a)),
+			math.Float32frombits(uint32(b)),
+			math.Float32frombits(uint32(c)))
+		if clamp {
+			res = clampF32(res)
+		}
+
+		return uint64(math.Float32bits(res))
+	})
+}
+
+func clampF32(f float32) float32 {
+	if f < 0 {
+		return 0
+	}
+
+	if f > 1 {
+		return 1
+	}
+
+	return f
+}
+
+// runVPKMOVB32 implements v_pk_mov_b32, which takes the low dword of the
+// result from src0 and the high dword from src1. OpSel picks the dword of each
+// source.
+func (u *ALU) runVPKMOVB32(state emu.InstEmuState) {
+	inst := state.Inst()
+	exec := state.EXEC()
+
+	for i := 0; i < 64; i++ {
+		if exec&(1<<uint(i)) == 0 {
+			continue
+		}
+
+		lo := vop3pHalf(state.ReadOperand(inst.Src0, i), inst.OpSel, 0, 32)
+		hi := vop3pHalf(state.ReadOperand(inst.Src1, i), inst.OpSel, 1, 32)
+
+		state.WriteOperand(inst.Dst, i, lo|hi<<32)
+	}
+}
+
+// runVDOT2F32F16 implements v_dot2_f32_f16.
+// D.f32 = S0.f16[0] * S1.f16[0] + S0.f16[1] * S1.f16[1] + S2.f32
+// OpSel picks the halves of the sources that form element 0, and OpSelHi
+// picks those that form element 1.
+func (u *ALU) runVDOT2F32F16(state emu.InstEmuState) {
+	inst := state.Inst()
+	exec := state.EXEC()
+
+	for i := 0; i < 64; i++ {
+		if exec&(1<<uint(i)) == 0 {
+			continue
+		}
+
+		src0 := readF16Operand(state, inst.Src0, i)
+		src1 := readF16Operand(state, inst.Src1, i)
+		src2 := uint32(state.ReadOperand(inst.Src2, i))
+
+		acc := math.Float32frombits(src2)
+		if inst.Src2Neg {
+			acc = -acc
+		}
+
+		for e := 0; e < 2; e++ {
+			a := dotF16Element(src0, e, 0, inst)
+			b := dotF16Element(src1, e, 1, inst)
+			acc += a * b
+		}
+
+		if inst.Clamp {
+			acc = clampF32(acc)
+		}
+
+		state.WriteOperand(inst.Dst, i, uint64(math.Float32bits(acc)))
+	}
+}
+
+// dotF16Element returns element e of source n of a dot instruction. The
+// op_sel and neg_lo modifiers apply to element 0, and op_sel_hi and neg_hi to
+// element 1.
+func dotF16Element(src uint64, e, n int, inst *insts.Inst) float32 {
+	sel, neg := inst.OpSel, inst.Neg
+	if e == 1 {
+		sel, neg = inst.OpSelHi, inst.NegHi
+	}
+
+	f := float16ToFloat32(uint16(vop3pHalf(src, sel, n, 16)))
+
+	if neg&(1<<uint(n)) != 0 {
+		f = -f
+	}
+
+	return f
+}
+
+// runVDotInt implements the integer dot instructions, which multiply the
+// numElem packed elements of src0 and src1 and add the products to src2. With
+// the clamp modifier, the result saturates instead of wrapping around.
+func (u *ALU) runVDotInt(state emu.InstEmuState, numElem int, signed bool) {
+	inst := state.Inst()
+	exec := state.EXEC()
+	width := uint(32 / numElem)
+
+	for i := 0; i < 64; i++ {
+		if exec&(1<<uint(i)) == 0 {
+			continue
+		}
+
+		src0 := state.ReadOperand(inst.Src0, i)
+		src1 := state.ReadOperand(inst.Src1, i)
+		src2 := uint32(state.ReadOperand(inst.Src2, i))
+
+		var acc int64
+		if signed {
+			acc = int64(int32(src2))
+		} else {
+			acc = int64(src2)
+		}
+
+		for e := 0; e < numElem; e++ {
+			a := dotIntElement(src0, e, width, signed)
+			b := dotIntElement(src1, e, width, signed)
+			acc += a * b
+		}
+
+		if inst.Clamp {
+			acc = saturateDot(acc, signed)
+		}
+
+		state.WriteOperand(inst.Dst, i, uint64(uint32(acc)))
+	}
+}
+
+func dotIntElement(src uint64, e int, width uint, signed bool) int64 {
+	v := (src >> (uint(e) * width)) & (uint64(1)<<width - 1)
+
+	if signed {
+		shift := 64 - width
+		return int64(v<<shift) >> shift
+	}
+
+	return int64(v)
+}
+
+func saturateDot(acc int64, signed bool) int64 {
+	if signed {
+		return max(math.MinInt32, min(math.MaxInt32, acc))
+	}
+
+	return max(0, min(math.MaxUint32, acc))
+}
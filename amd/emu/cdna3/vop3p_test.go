@@ -0,0 +1,203 @@
+package cdna3
+
+import (
+	"math"
+	"testing"
+
+	"github.com/sarchlab/mgpusim/v4/amd/insts"
+)
+
+func f16(f float32) uint64 {
+	return uint64(float32ToFloat16(f))
+}
+
+func TestVOP3PPKFMAF16OpSelAndNeg(t *testing.T) {
+	alu := NewALU(nil)
+	state := newMockInstState()
+	state.exec = 0x1
+	state.inst.FormatType = insts.VOP3P
+	state.inst.Opcode = 0x0e
+	state.inst.Src0 = &insts.Operand{}
+	state.inst.Src1 = &insts.Operand{}
+	state.inst.Src2 = &insts.Operand{}
+	state.inst.Dst = &insts.Operand{}
+
+	// The low result uses src0.hi, the high result uses src0.lo.
+	state.inst.OpSel = 0b001
+	state.inst.OpSelHi = 0b110
+	state.inst.Neg = 0b010
+	state.inst.NegHi = 0b100
+
+	state.setOperand(state.inst.Src0, 0, f16(2)|f16(3)<<16)
+	state.setOperand(state.inst.Src1, 0, f16(4)|f16(5)<<16)
+	state.setOperand(state.inst.Src2, 0, f16(1)|f16(0.5)<<16)
+
+	alu.Run(state)
+
+	result := state.ReadOperand(state.inst.Dst, 0)
+	lo := float16ToFloat32(uint16(result))
+	hi := float16ToFloat32(uint16(result >> 16))
+
+	// lo = 3 * -4 + 1, hi = 2 * 5 - 0.5
+	if lo != -11 || hi != 9.5 {
+		t.Errorf("v_pk_fma_f16: expected (-11, 9.5), got (%v, %v)", lo, hi)
+	}
+}
+
+func TestVOP3PPKAddU16Wraps(t *testing.T) {
+	alu := NewALU(nil)
+	state := newMockInstState()
+	state.exec = 0x1
+	state.inst.FormatType = insts.VOP3P
+	state.inst.Opcode = 0x0a
+	state.inst.Src0 = &insts.Operand{}
+	state.inst.Src1 = &insts.Operand{}
+	state.inst.Dst = &insts.Operand{}
+	state.inst.OpSelHi = 0b11
+
+	state.setOperand(state.inst.Src0, 0, 0xFFFF0001)
+	state.setOperand(state.inst.Src1, 0, 0x00020003)
+
+	alu.Run(state)
+
+	if got := state.ReadOperand(state.inst.Dst, 0); got != 0x00010004 {
+		t.Errorf("v_pk_add_u16: expected 0x00010004, got %#x", got)
+	}
+}
+
+func TestVOP3PPKMulF32(t *testing.T) {
+	alu := NewALU(nil)
+	state := newMockInstState()
+	state.exec = 0x1
+	state.inst.FormatType = insts.VOP3P
+	state.inst.Opcode = 0x31
+	state.inst.Src0 = &insts.Operand{}
+	state.inst.Src1 = &insts.Operand{}
+	state.inst.Dst = &insts.Operand{}
+	state.inst.OpSelHi = 0b11
+	state.inst.NegHi = 0b01
+
+	pack := func(lo, hi float32) uint64 {
+		return uint64(math.Float32bits(lo)) | uint64(math.Float32bits(hi))<<32
+	}
+	state.setOperand(state.inst.Src0, 0, pack(1.5, 2))
+	state.setOperand(state.inst.Src1, 0, pack(4, 8))
+
+	alu.Run(state)
+
+	if got := state.ReadOperand(state.inst.Dst, 0); got != pack(6, -16) {
+		t.Errorf("v_pk_mul_f32: expected %#x, got %#x", pack(6, -16), got)
+	}
+}
+
+func TestVOP3PDot2F32F16(t *testing.T) {
+	alu := NewALU(nil)
+	state := newMockInstState()
+	state.exec = 0x1
+	state.inst.FormatType = insts.VOP3P
+	state.inst.Opcode = 0x23
+	state.inst.Src0 = &insts.Operand{}
+	state.inst.Src1 = &insts.Operand{}
+	state.inst.Src2 = &insts.Operand{}
+	state.inst.Dst = &insts.Operand{}
+	state.inst.OpSelHi = 0b111
+
+	state.setOperand(state.inst.Src0, 0, f16(1.5)|f16(2)<<16)
+	state.setOperand(state.inst.Src1, 0, f16(2)|f16(-4)<<16)
+	state.setOperand(state.inst.Src2, 0, uint64(math.Float32bits(10)))
+
+	alu.Run(state)
+
+	got := math.Float32frombits(uint32(state.ReadOperand(state.inst.Dst, 0)))
+	if got != 5 {
+		t.Errorf("v_dot2_f32_f16: expected 5, got %v", got)
+	}
+}
+
+func TestVOP3PPKAddF16InlineConstant(t *testing.T) {
+	alu := NewALU(nil)
+	state := newMockInstState()
+	state.exec = 0x1
+	state.inst.FormatType = insts.VOP3P
+	state.inst.Opcode = 0x0f
+	state.inst.Src0 = &insts.Operand{}
+	state.inst.Src1 = &insts.Operand{
+		OperandType: insts.FloatOperand,
+		FloatValue:  1.0,
+	}
+	state.inst.Dst = &insts.Operand{}
+	state.inst.OpSelHi = 0b11
+
+	state.setOperand(state.inst.Src0, 0, f16(2)|f16(-3)<<16)
+
+	alu.Run(state)
+
+	result := state.ReadOperand(state.inst.Dst, 0)
+	lo := float16ToFloat32(uint16(result))
+	hi := float16ToFloat32(uint16(result >> 16))
+
+	if lo != 3 || hi != -2 {
+		t.Errorf("v_pk_add_f16: expected (3, -2), got (%v, %v)", lo, hi)
+	}
+}
+
+func TestVOP3PDot2F32F16OpSel(t *testing.T) {
+	alu := NewALU(nil)
+	state := newMockInstState()
+	state.exec = 0x1
+	state.inst.FormatType = insts.VOP3P
+	state.inst.Opcode = 0x23
+	state.inst.Src0 = &insts.Operand{}
+	state.inst.Src1 = &insts.Operand{
+		OperandType: insts.FloatOperand,
+		FloatValue:  0.5,
+	}
+	state.inst.Src2 = &insts.Operand{}
+	state.inst.Dst = &insts.Operand{}
+
+	// Element 0 takes src0.hi and element 1 takes src0.lo.
+	state.inst.OpSel = 0b001
+	state.inst.OpSelHi = 0b110
+	state.inst.NegHi = 0b001
+
+	state.setOperand(state.inst.Src0, 0, f16(6)|f16(4)<<16)
+	state.setOperand(state.inst.Src2, 0, uint64(math.Float32bits(1)))
+
+	alu.Run(state)
+
+	// 4 * 0.5 + -6 * 0.5 + 1
+	got := math.Float32frombits(uint32(state.ReadOperand(state.inst.Dst, 0)))
+	if got != 0 {
+		t.Errorf("v_dot2_f32_f16: expected 0, got %v", got)
+	}
+}
+
+func TestVOP3PDot4I32I8Clamp(t *testing.T) {
+	alu := NewALU(nil)
+	state := newMockInstState()
+	state.exec = 0x1
+	state.inst.FormatType = insts.VOP3P
+	state.inst.Opcode = 0x28
+	state.inst.Src0 = &insts.Operand{}
+	state.inst.Src1 = &insts.Operand{}
+	state.inst.Src2 = &insts.Operand{}
+	state.inst.Dst = &insts.Operand{}
+
+	// (-1)*2 + 3*4 added to the int32 max wraps, or saturates when clamped.
+	state.setOperand(state.inst.Src0, 0, 0x000003FF)
+	state.setOperand(state.inst.Src1, 0, 0x00000402)
+	state.setOperand(state.inst.Src2, 0, math.MaxInt32)
+
+	alu.Run(state)
+
+	if got := state.ReadOperand(state.inst.Dst, 0); got != 0x80000009 {
+		t.Errorf("v_dot4_i32_i8: expected 0x80000009, got %#x", got)
+	}
+
+	state.inst.Clamp = true
+	alu.Run(state)
+
+	if got := state.ReadOperand(state.inst.Dst, 0); got != math.MaxInt32 {
+		t.Errorf("v_dot4_i32_i8 clamp: expected saturation, got %#x", got)
+	}
+}
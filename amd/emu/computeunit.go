@@ -9,9 +9,11 @@ import (
 	"github.com/sarchlab/akita/v4/mem/mem"
 	"github.com/sarchlab/akita/v4/mem/vm"
 	"github.com/sarchlab/akita/v4/sim"
+	"github.com/sarchlab/mgpusim/v4/amd/cosim"
 	"github.com/sarchlab/mgpusim/v4/amd/insts"
 	"github.com/sarchlab/mgpusim/v4/amd/kernels"
 	"github.com/sarchlab/mgpusim/v4/amd/protocol"
+	"github.com/sarchlab/mgpusim/v4/amd/sanitizer"
 )
 
 type emulationEvent struct {
@@ -38,8 +40,25 @@ type ComputeUnit struct {
 
 	ToDispatcher sim.Port
 
+	// Sanitizer, if set, checks the vector memory accesses of each lane
+	// against the memory that the driver allocates.
+	Sanitizer *sanitizer.Sanitizer
+
+	// RaceDetector, if set, checks the LDS and global accesses of the
+	// wavefronts for data races.
+	RaceDetector *RaceDetector
+
+	// Debugger, if set, stops the wavefronts at breakpoints and lets a client
+	// inspect and modify them.
+	Debugger *Debugger
+
+	// CoSimChecker, if set, records the instructions, the writes, and the
+	// final states of the wavefronts to compare them with the timing model.
+	CoSimChecker *cosim.Checker
+
 	instCache         map[uint64]*insts.Inst
 	finishedMapWGReqs []string
+	fault             *protocol.MemoryFault
 }
 
 // ControlPort returns the port that can receive controlling messages from the
@@ -140,6 +159,10 @@ func (cu *ComputeUnit) runWG(
 	cu.initWfs(wg, req)
 
 	for !cu.isAllWfCompleted(wg) {
+		if cu.RaceDetector != nil {
+			cu.runWfsInterleaved(wg)
+		}
+
 		for _, wf := range cu.wfs[wg] {
 			cu.alu.SetLDS(wf.LDS)
 			cu.runWfUntilBarrier(wf)
@@ -147,6 +170,10 @@ func (cu *ComputeUnit) runWG(
 		cu.resolveBarrier(wg)
 	}
 
+	if cu.RaceDetector != nil {
+		cu.RaceDetector.completeWorkGroup(wg)
+	}
+
 	now := cu.TickingComponent.TickScheduler.CurrentTime()
 	evt := NewWGCompleteEvent(cu.Freq.NextTick(now), cu, req)
 	cu.Engine.Schedule(evt)
@@ -190,6 +217,8 @@ func (cu *ComputeUnit) initWfRegs(wf *Wavefront) {
 
 	SGPRPtr := 0
 	if co.EnableSgprPrivateSegmentBuffer {
+		copy(wf.SRegFile[SGPRPtr:SGPRPtr+16],
+			ScratchResourceDescriptor(wf.ScratchAddress))
 		SGPRPtr += 16
 	}
 
@@ -216,8 +245,8 @@ func (cu *ComputeUnit) initWfRegs(wf *Wavefront) {
 	}
 
 	if co.EnableSgprFlatScratchInit {
-		log.Printf("EnableSgprFlatScratchInit is not supported")
-		//fmt.Printf("s%d SGPRFlatScratchInit\n", SGPRPtr/4)
+		binary.LittleEndian.PutUint64(wf.SRegFile[SGPRPtr:SGPRPtr+8],
+			FlatScratchInit(wf.Wavefront))
 		SGPRPtr += 8
 	}
 
@@ -265,21 +294,27 @@ func (cu *ComputeUnit) initWfRegs(wf *Wavefront) {
 		binary.LittleEndian.PutUint32(wf.SRegFile[SGPRPtr:SGPRPtr+4],
 			uint32(wf.WG.IDZ))
 		//fmt.Printf("s%d WorkGroupIdZ\n", SGPRPtr/4)
-		// SGPRPtr += 4
+		SGPRPtr += 4
 	}
 
 	if co.EnableSgprWorkGroupInfo() {
-		log.Printf("EnableSgprPrivateSegmentSize is not supported")
-		// SGPRPtr += 4
+		log.Printf("EnableSgprWorkGroupInfo is not supported")
+		SGPRPtr += 4
 	}
 
 	if co.EnableSgprPrivateSegmentWaveByteOffset() {
-		log.Printf("EnableSgprPrivateSegentWaveByteOffset is not supported")
-		// SGPRPtr += 4
+		binary.LittleEndian.PutUint32(wf.SRegFile[SGPRPtr:SGPRPtr+4],
+			uint32(wf.ScratchWaveOffset))
+	}
+
+	if co.Version == insts.CodeObjectV5 {
+		// CDNA3 initializes FLAT_SCRATCH to the private memory of the
+		// wavefront, rather than letting the kernel compute it.
+		wf.FlatScratch = wf.ScratchBase()
 	}
 
 	var x, y, z int
-	for i := wf.FirstWiFlatID; i < wf.FirstWiFlatID+64; i++ {
+	for i := wf.FirstWiFlatID; i < wf.FirstWiFlatID+wf.LaneCount(); i++ {
 		z = i / (wf.WG.SizeX * wf.WG.SizeY)
 		y = i % (wf.WG.SizeX * wf.WG.SizeY) / wf.WG.SizeX
 		x = i % (wf.WG.SizeX * wf.WG.SizeY) % wf.WG.SizeX
@@ -314,46 +349,158 @@ func (cu *ComputeUnit) isAllWfCompleted(wg *kernels.WorkGroup) bool {
 	return true
 }
 
-func (cu *ComputeUnit) runWfUntilBarrier(wf *Wavefront) error {
-	if wf.Completed {
+// runWfsInterleaved runs the wavefronts of a work-group a few instructions at
+// a time in the random order that the race detector picks, until all the
+// wavefronts reach a barrier or complete. It returns right away if the race
+// detector does not randomize the interleaving.
+func (cu *ComputeUnit) runWfsInterleaved(wg *kernels.WorkGroup) {
+	for {
+		var ready []*Wavefront
+		for _, wf := range cu.wfs[wg] {
+			if !wf.Completed && !wf.AtBarrier {
+				ready = append(ready, wf)
+			}
+		}
+
+		if len(ready) == 0 {
+			return
+		}
+
+		i, numInsts := cu.RaceDetector.pickInterleaving(len(ready))
+		if i < 0 {
+			return
+		}
+
+		cu.alu.SetLDS(ready[i].LDS)
+		cu.runWfInsts(ready[i], numInsts)
+	}
+}
+
+func (cu *ComputeUnit) runWfUntilBarrier(wf *Wavefront) (err error) {
+	return cu.runWfInsts(wf, math.MaxInt)
+}
+
+// runWfInsts runs at most numInsts instructions of a wavefront. It stops
+// early if the wavefront reaches a barrier or completes.
+func (cu *ComputeUnit) runWfInsts(wf *Wavefront, numInsts int) (err error) {
+	if wf.Completed || wf.AtBarrier {
 		return nil
 	}
 
-	for {
-		pc := wf.PC()
-		inst, ok := cu.instCache[pc]
+	var pc uint64
+	var inst *insts.Inst
+
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+
+		fault, ok := r.(*protocol.MemoryFault)
 		if !ok {
-			instBuf := cu.storageAccessor.Read(wf.pid, pc, 8)
-			var err error
-			inst, err = cu.decoder.Decode(instBuf)
-			if err != nil {
-				log.Panicf("Failed to decode instruction at PC=0x%x: %v (bytes: %x)", pc, err, instBuf)
-			}
-			cu.instCache[pc] = inst
+			panic(r)
 		}
+
+		cu.abortWithFault(wf, pc, inst, fault)
+		err = fault
+	}()
+
+	for i := 0; i < numInsts; i++ {
+		pc = wf.PC()
+		inst = nil // Stays nil if the fetch faults.
+		inst = cu.fetchInst(wf, pc)
 		wf.inst = inst
 
+		if cu.Debugger != nil {
+			cu.Debugger.beforeInst(cu, wf)
+		}
+
 		wf.SetPC(wf.PC() + uint64(inst.ByteSize))
 
 		if inst.FormatType == insts.SOPP && inst.Opcode == 10 { // S_BARRIER
 			wf.AtBarrier = true
-			cu.logInst(wf, inst)
+			cu.completeInst(wf, pc)
 			break
 		}
 
 		if inst.FormatType == insts.SOPP && inst.Opcode == 1 { // S_ENDPGM
 			wf.Completed = true
-			cu.logInst(wf, inst)
+			cu.completeEndPgm(wf)
 			break
 		}
 
 		cu.executeInst(wf)
-		cu.logInst(wf, inst)
+		cu.completeInst(wf, pc)
 	}
 
 	return nil
 }
 
+func (cu *ComputeUnit) fetchInst(wf *Wavefront, pc uint64) *insts.Inst {
+	inst, ok := cu.instCache[pc]
+	if ok {
+		return inst
+	}
+
+	instBuf := cu.storageAccessor.Read(wf.pid, pc, 8)
+
+	inst, err := cu.decoder.Decode(instBuf)
+	if err != nil {
+		log.Panicf("Failed to decode instruction at PC=0x%x: %v (bytes: %x)", pc, err, instBuf)
+	}
+
+	cu.instCache[pc] = inst
+
+	return inst
+}
+
+// abortWithFault completes the work-group of a wavefront that accesses an
+// unmapped address. The fault is reported to the dispatcher together with the
+// completion of the work-group. The inst is nil if the wavefront fails to
+// fetch the instruction at pc.
+func (cu *ComputeUnit) abortWithFault(
+	wf *Wavefront,
+	pc uint64,
+	inst *insts.Inst,
+	fault *protocol.MemoryFault,
+) {
+	fault.CU = cu.Name()
+	fault.Wavefront = wf.UID
+	fault.PC = pc
+
+	if inst == nil {
+		fault.Access = protocol.MemoryAccessInstFetch
+	} else {
+		fault.Lane = FaultLane(wf, fault.Address)
+	}
+
+	for _, w := range cu.wfs[wf.WG] {
+		w.Completed = true
+		w.AtBarrier = false
+	}
+
+	if cu.fault == nil {
+		cu.fault = fault
+	}
+}
+
+func (cu *ComputeUnit) completeInst(wf *Wavefront, pc uint64) {
+	if cu.CoSimChecker != nil {
+		cu.recordCoSimStep(wf, pc)
+	}
+
+	cu.logInst(wf, wf.inst)
+}
+
+func (cu *ComputeUnit) completeEndPgm(wf *Wavefront) {
+	if cu.CoSimChecker != nil {
+		cu.CoSimChecker.AddEmulatedFinalState(wf.Wavefront,
+			CoSimState(wf, wf.CodeObject))
+	}
+
+	cu.logInst(wf, wf.inst)
+}
+
 func (cu *ComputeUnit) logInst(wf *Wavefront, inst *insts.Inst) {
 	ctx := sim.HookCtx{
 		Domain: cu,
@@ -364,7 +511,24 @@ func (cu *ComputeUnit) logInst(wf *Wavefront, inst *insts.Inst) {
 }
 
 func (cu *ComputeUnit) executeInst(wf *Wavefront) {
+	var writes []memRange
+	if cu.Sanitizer != nil {
+		writes = cu.sanitize(wf)
+	}
+
+	if cu.RaceDetector != nil {
+		cu.RaceDetector.observe(wf)
+	}
+
+	if cu.CoSimChecker != nil {
+		cu.recordCoSimWrites(wf)
+	}
+
 	cu.alu.Run(wf)
+
+	for _, w := range writes {
+		cu.Sanitizer.MarkInitialized(wf.pid, w.addr, w.size)
+	}
 }
 
 func (cu *ComputeUnit) resolveBarrier(wg *kernels.WorkGroup) {
@@ -378,6 +542,10 @@ func (cu *ComputeUnit) resolveBarrier(wg *kernels.WorkGroup) {
 		}
 		wf.AtBarrier = false
 	}
+
+	if cu.RaceDetector != nil {
+		cu.RaceDetector.barrier(cu.wfs[wg])
+	}
 }
 
 func (cu *ComputeUnit) handleWGCompleteEvent(evt *WGCompleteEvent) error {
@@ -401,11 +569,13 @@ func (cu *ComputeUnit) handleWGCompleteEvent(evt *WGCompleteEvent) error {
 		WithSrc(cu.ToDispatcher.AsRemote()).
 		WithDst(evt.Req.Src).
 		WithRspTo(cu.finishedMapWGReqs).
+		WithFault(cu.fault).
 		Build()
 
 	err := cu.ToDispatcher.Send(req)
 	if err == nil {
 		cu.finishedMapWGReqs = nil
+		cu.fault = nil
 	} else {
 		newEvent := NewWGCompleteEvent(cu.Freq.NextTick(evt.Time()),
 			cu, evt.Req)
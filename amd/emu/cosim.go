@@ -0,0 +1,153 @@
+package emu
+
+import (
+	"hash/fnv"
+
+	"github.com/sarchlab/mgpusim/v4/amd/cosim"
+	"github.com/sarchlab/mgpusim/v4/amd/insts"
+)
+
+// CoSimDigest summarizes the values that an instruction writes to the
+// registers, after the instruction executes. The digest covers the explicit
+// destinations and the SCC, VCC, and EXEC of the ALU instructions. It skips
+// the values that atomics return, since they depend on the order of the
+// wavefronts.
+func CoSimDigest(state InstEmuState, inst *insts.Inst) uint64 {
+	h := fnv.New64a()
+
+	for _, op := range coSimDsts(inst) {
+		numLanes := 1
+		if op.Register.IsVReg() || op.Register.IsAReg() {
+			numLanes = 64
+		}
+
+		for _, reg := range coSimRegs(op) {
+			n := reg.Register.ByteSize * max(reg.RegCount, 1)
+			for lane := 0; lane < numLanes; lane++ {
+				h.Write(state.ReadOperandBytes(reg, lane, n))
+			}
+		}
+	}
+
+	switch inst.FormatType {
+	case insts.SOP1, insts.SOP2, insts.SOPC, insts.SOPK:
+		h.Write([]byte{state.SCC()})
+		h.Write(insts.Uint64ToBytes(state.EXEC()))
+	case insts.VOP1, insts.VOP2, insts.VOPC, insts.VOP3a, insts.VOP3b,
+		insts.VOP3P:
+		h.Write(insts.Uint64ToBytes(state.VCC()))
+		h.Write(insts.Uint64ToBytes(state.EXEC()))
+	}
+
+	return h.Sum64()
+}
+
+// coSimDsts returns the registers that an instruction explicitly writes.
+func coSimDsts(inst *insts.Inst) []*insts.Operand {
+	var dsts []*insts.Operand
+
+	switch inst.FormatType {
+	case insts.SMEM:
+		dsts = []*insts.Operand{inst.Data}
+	case insts.DS:
+		if _, isWrite, ok := dsAccesses(inst); ok && !isWrite {
+			dsts = []*insts.Operand{inst.Dst}
+		}
+	case insts.FLAT, insts.MUBUF, insts.MTBUF:
+		if kind, ok := vectorMemOpKind(inst); ok && kind == insts.BufferOpLoad {
+			dsts = []*insts.Operand{inst.Dst}
+		}
+	case insts.SOPP:
+	default:
+		dsts = []*insts.Operand{inst.Dst, inst.SDst}
+	}
+
+	regs := dsts[:0]
+	for _, op := range dsts {
+		if op != nil && op.OperandType == insts.RegOperand &&
+			op.Register != nil {
+			regs = append(regs, op)
+		}
+	}
+
+	return regs
+}
+
+// coSimRegs splits an operand of general purpose registers into single
+// registers. The special registers stay whole.
+func coSimRegs(op *insts.Operand) []*insts.Operand {
+	if op.RegCount < 2 {
+		return []*insts.Operand{op}
+	}
+
+	var newOperand func(code, index, count int) *insts.Operand
+	switch {
+	case op.Register.IsSReg():
+		newOperand = insts.NewSRegOperand
+	case op.Register.IsVReg():
+		newOperand = insts.NewVRegOperand
+	case op.Register.IsAReg():
+		newOperand = insts.NewARegOperand
+	default:
+		return []*insts.Operand{op}
+	}
+
+	regs := make([]*insts.Operand, op.RegCount)
+	for i := range regs {
+		index := op.Register.RegIndex() + i
+		regs[i] = newOperand(index, index, 1)
+	}
+
+	return regs
+}
+
+// CoSimState returns the registers of a wavefront that a kernel uses.
+func CoSimState(
+	state InstEmuState,
+	co *insts.KernelCodeObject,
+) cosim.State {
+	s := cosim.State{
+		SGPRs: make([]uint32, min(int(co.WFSgprCount), 102)),
+		VGPRs: make([][64]uint32, min(int(co.WIVgprCount), 256)),
+		EXEC:  state.EXEC(),
+		VCC:   state.VCC(),
+		SCC:   state.SCC(),
+	}
+
+	for i := range s.SGPRs {
+		s.SGPRs[i] = uint32(state.ReadOperand(insts.NewSRegOperand(i, i, 1), 0))
+	}
+
+	for i := range s.VGPRs {
+		reg := insts.NewVRegOperand(i, i, 1)
+		for lane := 0; lane < 64; lane++ {
+			s.VGPRs[i][lane] = uint32(state.ReadOperand(reg, lane))
+		}
+	}
+
+	return s
+}
+
+// recordCoSimWrites tells the co-simulation checker about the memory that a
+// vector memory instruction is about to write.
+func (cu *ComputeUnit) recordCoSimWrites(wf *Wavefront) {
+	kind, ok := vectorMemOpKind(wf.inst)
+	if !ok || kind == insts.BufferOpLoad ||
+		kind == insts.BufferOpCacheControl {
+		return
+	}
+
+	forEachLaneAccess(wf, func(_ int, addr, size uint64) bool {
+		cu.CoSimChecker.AddEmulatedWrite(wf.Wavefront, wf.pid, addr, size)
+		return true
+	})
+}
+
+// recordCoSimStep tells the co-simulation checker about an instruction that
+// a wavefront has executed.
+func (cu *ComputeUnit) recordCoSimStep(wf *Wavefront, pc uint64) {
+	cu.CoSimChecker.AddEmulatedStep(wf.Wavefront, cosim.Step{
+		PC:     pc,
+		Digest: CoSimDigest(wf, wf.inst),
+	})
+}
@@ -0,0 +1,99 @@
+package emu
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/mgpusim/v4/amd/insts"
+	"github.com/sarchlab/mgpusim/v4/amd/kernels"
+)
+
+var _ = Describe("Co-simulation", func() {
+
+	var (
+		wf *Wavefront
+	)
+
+	BeforeEach(func() {
+		nativeWf := kernels.NewWavefront()
+		nativeWf.CodeObject = &insts.KernelCodeObject{
+			KernelCodeObjectMeta: &insts.KernelCodeObjectMeta{
+				WFSgprCount: 4,
+				WIVgprCount: 2,
+			},
+		}
+
+		wf = NewWavefront(nativeWf)
+	})
+
+	Context("digest", func() {
+		var inst *insts.Inst
+
+		BeforeEach(func() {
+			inst = insts.NewInst()
+			inst.FormatType = insts.VOP2
+			inst.Dst = insts.NewVRegOperand(1, 1, 1)
+		})
+
+		It("should cover every lane of the destination", func() {
+			digest := CoSimDigest(wf, inst)
+
+			wf.WriteReg(insts.VReg(1), 1, 63, insts.Uint32ToBytes(5))
+
+			Expect(CoSimDigest(wf, inst)).NotTo(Equal(digest))
+		})
+
+		It("should not cover the other registers", func() {
+			digest := CoSimDigest(wf, inst)
+
+			wf.WriteReg(insts.VReg(0), 1, 0, insts.Uint32ToBytes(5))
+
+			Expect(CoSimDigest(wf, inst)).To(Equal(digest))
+		})
+
+		It("should cover the VCC of vector instructions", func() {
+			digest := CoSimDigest(wf, inst)
+
+			wf.SetVCC(1)
+
+			Expect(CoSimDigest(wf, inst)).NotTo(Equal(digest))
+		})
+
+		It("should cover each register of a register range", func() {
+			inst.FormatType = insts.SMEM
+			inst.Data = insts.NewSRegOperand(2, 2, 2)
+			digest := CoSimDigest(wf, inst)
+
+			wf.WriteReg(insts.SReg(3), 1, 0, insts.Uint32ToBytes(5))
+
+			Expect(CoSimDigest(wf, inst)).NotTo(Equal(digest))
+		})
+
+		It("should not cover the data of stores", func() {
+			inst.FormatType = insts.FLAT
+			inst.Opcode = 28 // FLAT_STORE_DWORD
+			inst.Dst = nil
+			inst.Data = insts.NewVRegOperand(1, 1, 1)
+			digest := CoSimDigest(wf, inst)
+
+			wf.WriteReg(insts.VReg(1), 1, 0, insts.Uint32ToBytes(5))
+
+			Expect(CoSimDigest(wf, inst)).To(Equal(digest))
+		})
+	})
+
+	It("should read the registers that the kernel uses", func() {
+		wf.WriteReg(insts.SReg(3), 1, 0, insts.Uint32ToBytes(7))
+		wf.WriteReg(insts.VReg(1), 1, 62, insts.Uint32ToBytes(9))
+		wf.SetEXEC(0xff)
+		wf.SetSCC(1)
+
+		state := CoSimState(wf, wf.CodeObject)
+
+		Expect(state.SGPRs).To(HaveLen(4))
+		Expect(state.SGPRs[3]).To(Equal(uint32(7)))
+		Expect(state.VGPRs).To(HaveLen(2))
+		Expect(state.VGPRs[1][62]).To(Equal(uint32(9)))
+		Expect(state.EXEC).To(Equal(uint64(0xff)))
+		Expect(state.SCC).To(Equal(byte(1)))
+	})
+})
@@ -0,0 +1,645 @@
+package emu
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sarchlab/mgpusim/v4/amd/insts"
+	"github.com/sarchlab/mgpusim/v4/amd/protocol"
+)
+
+// A Debugger lets a client stop, inspect, and modify the wavefronts that the
+// emulated compute units execute. The client connects to a local socket and
+// sends one command per line. The debugger answers each command with one line
+// that starts with "ok", "error", "stopped", or "ended".
+//
+// The client can always manage the breakpoints:
+//
+//	break <kernel> <offset> [wg=<x>,<y>,<z>] [wf=<n>]
+//	delete <id>
+//	breakpoints
+//
+// A breakpoint stops the wavefronts of the kernel, or of any kernel if the
+// kernel is "*", before they execute the instruction that is offset bytes
+// after the first instruction of the kernel. The wg and wf conditions limit
+// the breakpoint to a work-group and to the n-th wavefront of the
+// work-group.
+//
+// The simulation waits for a client at the start. The client sets the
+// breakpoints and starts the simulation with "continue". The answer to
+// "continue" comes when a wavefront stops, or when the simulation ends. While
+// a wavefront is stopped, the client can use these commands:
+//
+//	info
+//	reg <name> [lane]
+//	setreg <name> [lane] <value>
+//	mem <address> <size>
+//	setmem <address> <hex bytes>
+//	lds <offset> <size>
+//	setlds <offset> <hex bytes>
+//	state
+//	step
+//	continue
+//
+// The register names are s<n>, v<n>, exec, vcc, scc, m0, and pc, which is
+// read-only. The vector registers require a lane. The state command answers
+// with the state of the wavefront in the JSON format of the ISA debugger web
+// viewer. The step command runs one instruction of the stopped wavefront;
+// other wavefronts may run in the meantime and stop at a breakpoint first.
+type Debugger struct {
+	listener net.Listener
+	requests chan *debugRequest
+	closed   chan struct{}
+	served   chan struct{}
+
+	lock        sync.Mutex
+	conn        net.Conn
+	breakpoints []*breakpoint
+	nextID      int
+	stepWf      *Wavefront
+	stopped     bool
+
+	// stopLock makes sure that only one wavefront is stopped at a time. It
+	// guards pending, which is the "continue" or the "step" command that
+	// waits for the next stop.
+	stopLock sync.Mutex
+	pending  *debugRequest
+}
+
+type breakpoint struct {
+	id     int
+	kernel string
+	offset uint64
+	wg     *[3]int
+	wf     int
+}
+
+func (b *breakpoint) String() string {
+	s := fmt.Sprintf("%d %s 0x%x", b.id, b.kernel, b.offset)
+	if b.wg != nil {
+		s += fmt.Sprintf(" wg=%d,%d,%d", b.wg[0], b.wg[1], b.wg[2])
+	}
+
+	if b.wf >= 0 {
+		s += fmt.Sprintf(" wf=%d", b.wf)
+	}
+
+	return s
+}
+
+func (b *breakpoint) matches(wf *Wavefront, offset uint64) bool {
+	if b.offset != offset {
+		return false
+	}
+
+	if b.kernel != "*" && b.kernel != kernelName(wf.CodeObject) {
+		return false
+	}
+
+	wg := wf.WG
+	if b.wg != nil && *b.wg != [3]int{wg.IDX, wg.IDY, wg.IDZ} {
+		return false
+	}
+
+	return b.wf < 0 || b.wf == wf.FirstWiFlatID/wf.LaneCount()
+}
+
+type debugRequest struct {
+	args  []string
+	reply chan string
+}
+
+// NewDebugger creates a Debugger that serves the clients that connect to the
+// listener, one client at a time.
+func NewDebugger(listener net.Listener) *Debugger {
+	d := &Debugger{
+		listener: listener,
+		requests: make(chan *debugRequest),
+		closed:   make(chan struct{}),
+		served:   make(chan struct{}),
+		stopped:  true,
+	}
+
+	go d.serve()
+
+	return d
+}
+
+// WaitForClient blocks until a client connects and sends "continue".
+func (d *Debugger) WaitForClient() {
+	d.stopLock.Lock()
+	defer d.stopLock.Unlock()
+
+	d.serveStop(nil, nil, "")
+}
+
+// Close stops serving the clients. The client that waits for the next stop
+// receives "ended" before Close returns.
+func (d *Debugger) Close() {
+	d.stopLock.Lock()
+	defer d.stopLock.Unlock()
+
+	if d.pending != nil {
+		d.pending.reply <- "ended"
+		d.pending = nil
+	}
+
+	close(d.closed)
+	d.listener.Close()
+
+	d.lock.Lock()
+	if d.conn != nil {
+		d.conn.SetReadDeadline(time.Now())
+	}
+	d.lock.Unlock()
+
+	<-d.served
+}
+
+func (d *Debugger) serve() {
+	defer close(d.served)
+
+	for {
+		conn, err := d.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		d.handleConn(conn)
+	}
+}
+
+func (d *Debugger) handleConn(conn net.Conn) {
+	d.lock.Lock()
+	d.conn = conn
+	d.lock.Unlock()
+
+	defer func() {
+		d.lock.Lock()
+		d.conn = nil
+		d.lock.Unlock()
+
+		conn.Close()
+	}()
+
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		args := strings.Fields(scanner.Text())
+		if len(args) == 0 {
+			continue
+		}
+
+		fmt.Fprintln(conn, d.handle(args))
+	}
+
+	select {
+	case <-d.closed:
+	default:
+		d.detach()
+	}
+}
+
+func (d *Debugger) handle(args []string) string {
+	switch args[0] {
+	case "break":
+		return d.addBreakpoint(args[1:])
+	case "delete":
+		return d.deleteBreakpoint(args[1:])
+	case "breakpoints":
+		return d.listBreakpoints()
+	}
+
+	d.lock.Lock()
+	stopped := d.stopped
+	d.lock.Unlock()
+
+	if !stopped {
+		return "error: the simulation is running"
+	}
+
+	return d.send(args)
+}
+
+func (d *Debugger) send(args []string) string {
+	req := &debugRequest{args: args, reply: make(chan string, 1)}
+
+	select {
+	case d.requests <- req:
+	case <-d.closed:
+		return "ended"
+	}
+
+	select {
+	case reply := <-req.reply:
+		return reply
+	case <-d.closed:
+		return "ended"
+	}
+}
+
+// detach removes the breakpoints of a client that disconnects and lets the
+// simulation run to the end.
+func (d *Debugger) detach() {
+	d.lock.Lock()
+	d.breakpoints = nil
+	d.stepWf = nil
+	stopped := d.stopped
+	d.lock.Unlock()
+
+	if stopped {
+		d.send([]string{"detach"})
+	}
+}
+
+func (d *Debugger) addBreakpoint(args []string) string {
+	if len(args) < 2 {
+		return "error: usage: break <kernel> <offset> [wg=<x>,<y>,<z>] [wf=<n>]"
+	}
+
+	offset, err := strconv.ParseUint(args[1], 0, 64)
+	if err != nil {
+		return "error: invalid offset " + args[1]
+	}
+
+	b := &breakpoint{kernel: args[0], offset: offset, wf: -1}
+	for _, cond := range args[2:] {
+		switch {
+		case strings.HasPrefix(cond, "wg="):
+			var wg [3]int
+			_, err = fmt.Sscanf(cond, "wg=%d,%d,%d", &wg[0], &wg[1], &wg[2])
+			b.wg = &wg
+		case strings.HasPrefix(cond, "wf="):
+			b.wf, err = strconv.Atoi(strings.TrimPrefix(cond, "wf="))
+		default:
+			return "error: unknown condition " + cond
+		}
+
+		if err != nil {
+			return "error: invalid condition " + cond
+		}
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.nextID++
+	b.id = d.nextID
+	d.breakpoints = append(d.breakpoints, b)
+
+	return fmt.Sprintf("ok %d", b.id)
+}
+
+func (d *Debugger) deleteBreakpoint(args []string) string {
+	if len(args) != 1 {
+		return "error: usage: delete <id>"
+	}
+
+	id, err := strconv.Atoi(args[0])
+	if err != nil {
+		return "error: invalid breakpoint " + args[0]
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	for i, b := range d.breakpoints {
+		if b.id == id {
+			d.breakpoints = append(d.breakpoints[:i], d.breakpoints[i+1:]...)
+			return "ok"
+		}
+	}
+
+	return "error: no breakpoint " + args[0]
+}
+
+func (d *Debugger) listBreakpoints() string {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	list := make([]string, len(d.breakpoints))
+	for i, b := range d.breakpoints {
+		list[i] = b.String()
+	}
+
+	return strings.TrimSpace("ok " + strings.Join(list, "; "))
+}
+
+// beforeInst stops a wavefront that is about to execute the instruction at pc
+// if the wavefront reaches a breakpoint or completes a step. It serves the
+// client until the client lets the wavefront continue.
+func (d *Debugger) beforeInst(cu *ComputeUnit, wf *Wavefront) {
+	reason, stop := d.shouldStop(wf)
+	if !stop {
+		return
+	}
+
+	d.stopLock.Lock()
+	defer d.stopLock.Unlock()
+
+	d.serveStop(cu, wf, reason)
+}
+
+func (d *Debugger) shouldStop(wf *Wavefront) (reason string, stop bool) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if d.stepWf == wf {
+		d.stepWf = nil
+		return "step", true
+	}
+
+	offset := wf.PC() - kernelEntry(wf)
+	for _, b := range d.breakpoints {
+		if b.matches(wf, offset) {
+			return fmt.Sprintf("breakpoint %d", b.id), true
+		}
+	}
+
+	return "", false
+}
+
+func kernelEntry(wf *Wavefront) uint64 {
+	return wf.Packet.KernelObject + wf.CodeObject.KernelCodeEntryByteOffset
+}
+
+// serveStop answers the commands of the client while a wavefront is stopped.
+// The wavefront is nil while the simulation waits for a client to start.
+func (d *Debugger) serveStop(cu *ComputeUnit, wf *Wavefront, reason string) {
+	d.lock.Lock()
+	d.stopped = true
+	d.lock.Unlock()
+
+	if d.pending != nil {
+		d.pending.reply <- "stopped " + reason + " " + describeStop(wf)
+		d.pending = nil
+	}
+
+	for {
+		var req *debugRequest
+		select {
+		case req = <-d.requests:
+		case <-d.closed:
+			return
+		}
+
+		switch req.args[0] {
+		case "continue", "step", "detach":
+			if req.args[0] == "step" && wf == nil {
+				req.reply <- "error: no wavefront is stopped"
+				continue
+			}
+
+			d.resume(wf, req)
+
+			return
+		default:
+			req.reply <- d.inspect(cu, wf, req.args)
+		}
+	}
+}
+
+func (d *Debugger) resume(wf *Wavefront, req *debugRequest) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.stopped = false
+
+	switch req.args[0] {
+	case "step":
+		d.stepWf = wf
+		d.pending = req
+	case "continue":
+		d.pending = req
+	case "detach":
+		req.reply <- "ok"
+	}
+}
+
+func describeStop(wf *Wavefront) string {
+	wg := wf.WG
+
+	return fmt.Sprintf("kernel=%s wg=%d,%d,%d wf=%d pc=0x%x offset=0x%x "+
+		"inst=%s",
+		kernelName(wf.CodeObject), wg.IDX, wg.IDY, wg.IDZ,
+		wf.FirstWiFlatID/wf.LaneCount(), wf.PC(), wf.PC()-kernelEntry(wf),
+		insts.NewInstPrinter(nil).Print(wf.inst))
+}
+
+func (d *Debugger) inspect(
+	cu *ComputeUnit,
+	wf *Wavefront,
+	args []string,
+) string {
+	if wf == nil {
+		return "error: no wavefront is stopped"
+	}
+
+	switch args[0] {
+	case "info":
+		return "ok " + describeStop(wf)
+	case "state":
+		return "ok " + wfStateJSON(wf)
+	case "reg":
+		return readDebugReg(wf, args[1:])
+	case "setreg":
+		return writeDebugReg(wf, args[1:])
+	case "mem", "setmem":
+		return accessDebugMem(cu, wf, args)
+	case "lds", "setlds":
+		return accessDebugLDS(wf, args)
+	}
+
+	return "error: unknown command " + args[0]
+}
+
+// parseDebugReg parses the name and the lane of a register. It returns the
+// arguments that follow them.
+func parseDebugReg(args []string) (name string, index, lane int, rest []string, err error) {
+	if len(args) == 0 {
+		return "", 0, 0, nil, fmt.Errorf("missing register")
+	}
+
+	name = args[0]
+	rest = args[1:]
+
+	switch {
+	case name == "exec", name == "vcc", name == "scc", name == "m0",
+		name == "pc":
+		return name, 0, 0, rest, nil
+	case strings.HasPrefix(name, "s"):
+		index, err = strconv.Atoi(name[1:])
+		if err != nil || index < 0 || index >= 102 {
+			return "", 0, 0, nil, fmt.Errorf("invalid register %s", name)
+		}
+
+		return "s", index, 0, rest, nil
+	case strings.HasPrefix(name, "v"):
+		index, err = strconv.Atoi(name[1:])
+		if err != nil || index < 0 || index >= 256 {
+			return "", 0, 0, nil, fmt.Errorf("invalid register %s", name)
+		}
+
+		if len(rest) == 0 {
+			return "", 0, 0, nil, fmt.Errorf("missing lane")
+		}
+
+		lane, err = strconv.Atoi(rest[0])
+		if err != nil || lane < 0 || lane >= 64 {
+			return "", 0, 0, nil, fmt.Errorf("invalid lane %s", rest[0])
+		}
+
+		return "v", index, lane, rest[1:], nil
+	}
+
+	return "", 0, 0, nil, fmt.Errorf("invalid register %s", name)
+}
+
+func readDebugReg(wf *Wavefront, args []string) string {
+	name, index, lane, _, err := parseDebugReg(args)
+	if err != nil {
+		return "error: " + err.Error()
+	}
+
+	var value uint64
+	switch name {
+	case "exec":
+		value = wf.EXEC()
+	case "vcc":
+		value = wf.VCC()
+	case "scc":
+		value = uint64(wf.SCC())
+	case "m0":
+		value = uint64(wf.M0)
+	case "pc":
+		value = wf.PC()
+	case "s":
+		value = uint64(wf.SRegValue(index))
+	case "v":
+		value = uint64(wf.VRegValue(lane, index))
+	}
+
+	return fmt.Sprintf("ok 0x%x", value)
+}
+
+func writeDebugReg(wf *Wavefront, args []string) string {
+	name, index, lane, rest, err := parseDebugReg(args)
+	if err != nil {
+		return "error: " + err.Error()
+	}
+
+	if len(rest) != 1 {
+		return "error: usage: setreg <name> [lane] <value>"
+	}
+
+	value, err := strconv.ParseUint(rest[0], 0, 64)
+	if err != nil {
+		return "error: invalid value " + rest[0]
+	}
+
+	switch name {
+	case "exec":
+		wf.SetEXEC(value)
+	case "vcc":
+		wf.SetVCC(value)
+	case "scc":
+		wf.SetSCC(byte(value & 1))
+	case "m0":
+		wf.M0 = uint32(value)
+	case "pc":
+		return "error: pc is read-only"
+	case "s":
+		wf.WriteReg(insts.SReg(index), 1, 0, insts.Uint32ToBytes(uint32(value)))
+	case "v":
+		wf.WriteReg(insts.VReg(index), 1, lane,
+			insts.Uint32ToBytes(uint32(value)))
+	}
+
+	return "ok"
+}
+
+func accessDebugMem(
+	cu *ComputeUnit,
+	wf *Wavefront,
+	args []string,
+) (reply string) {
+	if len(args) != 3 {
+		return "error: usage: mem <address> <size> or setmem <address> <hex bytes>"
+	}
+
+	addr, err := strconv.ParseUint(args[1], 0, 64)
+	if err != nil {
+		return "error: invalid address " + args[1]
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			fault, ok := r.(*protocol.MemoryFault)
+			if !ok {
+				panic(r)
+			}
+
+			reply = "error: " + fault.Error()
+		}
+	}()
+
+	if args[0] == "setmem" {
+		data, err := hex.DecodeString(args[2])
+		if err != nil {
+			return "error: invalid bytes " + args[2]
+		}
+
+		cu.storageAccessor.Write(wf.pid, addr, data)
+
+		return "ok"
+	}
+
+	size, err := strconv.ParseUint(args[2], 0, 64)
+	if err != nil || size > 4096 {
+		return "error: invalid size " + args[2]
+	}
+
+	return "ok " + hex.EncodeToString(cu.storageAccessor.Read(wf.pid, addr, size))
+}
+
+func accessDebugLDS(wf *Wavefront, args []string) string {
+	if len(args) != 3 {
+		return "error: usage: lds <offset> <size> or setlds <offset> <hex bytes>"
+	}
+
+	offset, err := strconv.ParseUint(args[1], 0, 64)
+	if err != nil {
+		return "error: invalid offset " + args[1]
+	}
+
+	var data []byte
+	if args[0] == "setlds" {
+		data, err = hex.DecodeString(args[2])
+		if err != nil {
+			return "error: invalid bytes " + args[2]
+		}
+	} else {
+		size, err := strconv.ParseUint(args[2], 0, 64)
+		if err != nil {
+			return "error: invalid size " + args[2]
+		}
+
+		data = make([]byte, size)
+	}
+
+	if offset+uint64(len(data)) > uint64(len(wf.LDS)) {
+		return fmt.Sprintf("error: the LDS has %d bytes", len(wf.LDS))
+	}
+
+	if args[0] == "setlds" {
+		copy(wf.LDS[offset:], data)
+		return "ok"
+	}
+
+	return "ok " + hex.EncodeToString(wf.LDS[offset:offset+uint64(len(data))])
+}
@@ -0,0 +1,180 @@
+package emu
+
+import (
+	"bufio"
+	"debug/elf"
+	"fmt"
+	"net"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/mgpusim/v4/amd/insts"
+	"github.com/sarchlab/mgpusim/v4/amd/kernels"
+)
+
+var _ = Describe("Debugger", func() {
+
+	var (
+		wf *Wavefront
+	)
+
+	BeforeEach(func() {
+		wg := kernels.NewWorkGroup()
+		wg.IDX, wg.IDY, wg.IDZ = 1, 2, 0
+
+		nativeWf := kernels.NewWavefront()
+		nativeWf.WG = wg
+		nativeWf.FirstWiFlatID = 64
+		nativeWf.Packet = &kernels.HsaKernelDispatchPacket{
+			KernelObject: 0x1000,
+		}
+		nativeWf.CodeObject = &insts.KernelCodeObject{
+			KernelCodeObjectMeta: &insts.KernelCodeObjectMeta{
+				KernelCodeEntryByteOffset: 0x100,
+			},
+			Symbol: &elf.Symbol{Name: "kernel"},
+		}
+
+		wf = NewWavefront(nativeWf)
+		wf.LDS = make([]byte, 16)
+		wf.SetPC(0x1108)
+
+		wf.inst = insts.NewInst()
+		wf.inst.FormatType = insts.SOPP
+		wf.inst.Opcode = 1
+		wf.inst.InstName = "s_endpgm"
+	})
+
+	Context("breakpoints", func() {
+		It("should match the kernel and the offset", func() {
+			b := &breakpoint{kernel: "kernel", offset: 8, wf: -1}
+
+			Expect(b.matches(wf, 8)).To(BeTrue())
+			Expect(b.matches(wf, 4)).To(BeFalse())
+		})
+
+		It("should match any kernel", func() {
+			b := &breakpoint{kernel: "*", offset: 8, wf: -1}
+
+			Expect(b.matches(wf, 8)).To(BeTrue())
+		})
+
+		It("should match the work-group and the wavefront", func() {
+			b := &breakpoint{kernel: "kernel", offset: 8,
+				wg: &[3]int{1, 2, 0}, wf: 1}
+			other := &breakpoint{kernel: "kernel", offset: 8,
+				wg: &[3]int{1, 2, 0}, wf: 0}
+
+			Expect(b.matches(wf, 8)).To(BeTrue())
+			Expect(other.matches(wf, 8)).To(BeFalse())
+		})
+	})
+
+	Context("registers", func() {
+		It("should write and read a scalar register", func() {
+			Expect(writeDebugReg(wf, []string{"s3", "0x2a"})).To(Equal("ok"))
+
+			Expect(readDebugReg(wf, []string{"s3"})).To(Equal("ok 0x2a"))
+		})
+
+		It("should write and read a vector register of a lane", func() {
+			Expect(writeDebugReg(wf, []string{"v1", "5", "7"})).To(Equal("ok"))
+
+			Expect(wf.VRegValue(5, 1)).To(Equal(uint32(7)))
+			Expect(readDebugReg(wf, []string{"v1", "5"})).To(Equal("ok 0x7"))
+		})
+
+		It("should require a lane for a vector register", func() {
+			Expect(readDebugReg(wf, []string{"v1"})).To(HavePrefix("error"))
+		})
+
+		It("should not write the pc", func() {
+			Expect(writeDebugReg(wf, []string{"pc", "0"})).To(HavePrefix("error"))
+		})
+	})
+
+	Context("LDS", func() {
+		It("should write and read the LDS", func() {
+			Expect(accessDebugLDS(wf, []string{"setlds", "4", "0a0b"})).
+				To(Equal("ok"))
+
+			Expect(wf.LDS[4:6]).To(Equal([]byte{0xa, 0xb}))
+			Expect(accessDebugLDS(wf, []string{"lds", "3", "4"})).
+				To(Equal("ok 000a0b00"))
+		})
+
+		It("should not access beyond the LDS", func() {
+			Expect(accessDebugLDS(wf, []string{"lds", "12", "8"})).
+				To(HavePrefix("error"))
+		})
+	})
+
+	Context("with a client", func() {
+		var (
+			debugger *Debugger
+			conn     net.Conn
+			reader   *bufio.Reader
+		)
+
+		request := func(line string) string {
+			fmt.Fprintln(conn, line)
+
+			reply, err := reader.ReadString('\n')
+			Expect(err).NotTo(HaveOccurred())
+
+			return strings.TrimSpace(reply)
+		}
+
+		BeforeEach(func() {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).NotTo(HaveOccurred())
+
+			debugger = NewDebugger(listener)
+
+			conn, err = net.Dial("tcp", listener.Addr().String())
+			Expect(err).NotTo(HaveOccurred())
+			reader = bufio.NewReader(conn)
+		})
+
+		AfterEach(func() {
+			conn.Close()
+		})
+
+		It("should stop a wavefront at a breakpoint", func() {
+			started := make(chan struct{})
+			go func() {
+				debugger.WaitForClient()
+				close(started)
+			}()
+
+			Expect(request("break kernel 0x8 wf=1")).To(Equal("ok 1"))
+			Expect(request("info")).To(HavePrefix("error"))
+
+			fmt.Fprintln(conn, "continue")
+			Eventually(started).Should(BeClosed())
+
+			finished := make(chan struct{})
+			go func() {
+				debugger.beforeInst(nil, wf)
+				close(finished)
+			}()
+
+			reply, err := reader.ReadString('\n')
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reply).To(HavePrefix(
+				"stopped breakpoint 1 kernel=kernel wg=1,2,0 wf=1 " +
+					"pc=0x1108 offset=0x8 inst=s_endpgm"))
+
+			Expect(request("setreg s0 9")).To(Equal("ok"))
+			Expect(request("reg s0")).To(Equal("ok 0x9"))
+
+			fmt.Fprintln(conn, "continue")
+			Eventually(finished).Should(BeClosed())
+
+			debugger.Close()
+
+			Expect(reader.ReadString('\n')).To(Equal("ended\n"))
+		})
+	})
+})
@@ -24,4 +24,8 @@ type InstEmuState interface {
 	SetSCC(v byte)
 	PC() uint64
 	SetPC(v uint64)
+
+	// ScratchBase returns the address of the private memory of the
+	// wavefront, or 0 if the kernel has no scratch segment.
+	ScratchBase() uint64
 }
@@ -7,6 +7,7 @@ import (
 
 	"github.com/sarchlab/akita/v4/sim"
 	"github.com/sarchlab/mgpusim/v4/amd/insts"
+	"github.com/sarchlab/mgpusim/v4/amd/kernels"
 	"github.com/tebeka/atexit"
 )
 
@@ -16,6 +17,10 @@ type ISADebugger struct {
 
 	isFirstEntry bool
 	// prevWf *Wavefront
+
+	// WGFilter selects the work-groups whose wavefronts are logged. The
+	// wavefronts of all the work-groups are logged if it is nil.
+	WGFilter kernels.WGFilterFunc
 }
 
 // NewISADebugger returns a new ISADebugger that keeps instruction log in logger
@@ -37,7 +42,7 @@ func (h *ISADebugger) Func(ctx sim.HookCtx) {
 		return
 	}
 
-	if wf.WG.IDX == 75 && wf.WG.IDY == 1 {
+	if h.WGFilter == nil || h.WGFilter(wf.Packet, wf.WG) {
 		h.logWholeWf(wf)
 	}
 
@@ -63,7 +68,15 @@ func (h *ISADebugger) logWholeWf(wf *Wavefront) {
 		output += ","
 	}
 
-	output += fmt.Sprintf("{")
+	output += wfStateJSON(wf)
+
+	h.Logger.Print(output)
+}
+
+// wfStateJSON returns the state of a wavefront as a JSON object, in the format
+// that the ISA debugger web viewer reads.
+func wfStateJSON(wf *Wavefront) string {
+	output := "{"
 	output += fmt.Sprintf(`"wg":[%d,%d,%d],"wf":%d,`,
 		wf.WG.IDX, wf.WG.IDY, wf.WG.IDZ, wf.FirstWiFlatID)
 	output += fmt.Sprintf(`"Inst":"%s",`, insts.NewInstPrinter(nil).Print(wf.Inst()))
@@ -109,7 +122,7 @@ func (h *ISADebugger) logWholeWf(wf *Wavefront) {
 	output += `,"LDS":`
 	output += fmt.Sprintf(`"%s"`, base64.StdEncoding.EncodeToString(wf.LDS))
 
-	output += fmt.Sprintf("}")
+	output += "}"
 
-	h.Logger.Print(output)
+	return output
 }
@@ -0,0 +1,66 @@
+package emu
+
+import (
+	"bytes"
+	"log"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/akita/v4/sim"
+	"github.com/sarchlab/mgpusim/v4/amd/insts"
+	"github.com/sarchlab/mgpusim/v4/amd/kernels"
+)
+
+var _ = Describe("ISADebugger", func() {
+	var (
+		buf      *bytes.Buffer
+		debugger *ISADebugger
+	)
+
+	newWf := func(idX, idY int) *Wavefront {
+		wg := kernels.NewWorkGroup()
+		wg.IDX, wg.IDY = idX, idY
+
+		nativeWf := kernels.NewWavefront()
+		nativeWf.WG = wg
+		nativeWf.CodeObject = &insts.KernelCodeObject{
+			KernelCodeObjectMeta: &insts.KernelCodeObjectMeta{},
+		}
+
+		wf := NewWavefront(nativeWf)
+		wf.inst = insts.NewInst()
+		wf.inst.FormatType = insts.SOPP
+		wf.inst.Opcode = 1
+		wf.inst.InstName = "s_endpgm"
+
+		return wf
+	}
+
+	BeforeEach(func() {
+		buf = new(bytes.Buffer)
+		debugger = NewISADebugger(log.New(buf, "", 0))
+	})
+
+	It("should log the wavefronts of all the work-groups", func() {
+		debugger.Func(sim.HookCtx{Item: newWf(0, 0)})
+		debugger.Func(sim.HookCtx{Item: newWf(75, 1)})
+
+		Expect(buf.String()).To(ContainSubstring(`"wg":[0,0,0]`))
+		Expect(buf.String()).To(ContainSubstring(`"wg":[75,1,0]`))
+	})
+
+	It("should only log the work-groups that the filter selects", func() {
+		debugger.WGFilter = func(
+			_ *kernels.HsaKernelDispatchPacket,
+			wg *kernels.WorkGroup,
+		) bool {
+			return wg.IDX == 75 && wg.IDY == 1
+		}
+
+		debugger.Func(sim.HookCtx{Item: newWf(0, 0)})
+		debugger.Func(sim.HookCtx{Item: newWf(75, 1)})
+
+		Expect(buf.String()).NotTo(ContainSubstring(`"wg":[0,0,0]`))
+		Expect(buf.String()).To(ContainSubstring(`"wg":[75,1,0]`))
+	})
+})
@@ -0,0 +1,135 @@
+package emu
+
+import (
+	"github.com/sarchlab/akita/v4/mem/vm"
+	"github.com/sarchlab/mgpusim/v4/amd/insts"
+	"github.com/sarchlab/mgpusim/v4/amd/protocol"
+)
+
+func newMemoryFault(
+	pid vm.PID,
+	addr uint64,
+	access protocol.MemoryAccessType,
+) *protocol.MemoryFault {
+	return &protocol.MemoryFault{
+		Lane:    -1,
+		PID:     pid,
+		Address: addr,
+		Access:  access,
+	}
+}
+
+// FaultLane returns the first active lane of a vector memory instruction that
+// accesses the address. It returns -1 if no lane accesses the address, which
+// is the case for the scalar memory instructions.
+func FaultLane(state InstEmuState, addr uint64) int {
+	lane := -1
+
+	forEachLaneAccess(state, func(i int, a, size uint64) bool {
+		if addr >= a && addr < a+size {
+			lane = i
+			return false
+		}
+
+		return true
+	})
+
+	return lane
+}
+
+// forEachLaneAccess visits the memory that each active lane of a vector
+// memory instruction accesses, in the order of the lanes, until visit
+// returns false.
+func forEachLaneAccess(
+	state InstEmuState,
+	visit func(lane int, addr, size uint64) bool,
+) {
+	address, comps := laneAccesses(state)
+	if address == nil {
+		return
+	}
+
+	exec := state.EXEC()
+	for i := 0; i < 64; i++ {
+		if exec&(1<<uint(i)) == 0 {
+			continue
+		}
+
+		for _, c := range comps {
+			if !c.InMemory {
+				continue
+			}
+
+			a, ok := address(i, c.Offset, c.ByteSize)
+			if ok && !visit(i, a, c.ByteSize) {
+				return
+			}
+		}
+	}
+}
+
+// laneAccesses returns how the lanes of a vector memory instruction access
+// the memory.
+func laneAccesses(
+	state InstEmuState,
+) (ComponentAddressFunc, []BufferComponent) {
+	inst := state.Inst()
+
+	var address ComponentAddressFunc
+	var comps []BufferComponent
+
+	switch inst.FormatType {
+	case insts.MUBUF, insts.MTBUF:
+		if _, ok := inst.BufferOp(); !ok {
+			return nil, nil
+		}
+
+		rsrc := ReadBufferResource(state)
+		address = BufferComponentAddress(state, rsrc)
+		comps = BufferComponents(inst, rsrc)
+	case insts.FLAT:
+		if _, ok := inst.FlatOp(); !ok {
+			return nil, nil
+		}
+
+		address = flatComponentAddress(state)
+		if AccessesScratch(state) {
+			address = ScratchComponentAddress(state)
+		}
+
+		comps = FlatComponents(inst)
+	default:
+		return nil, nil
+	}
+
+	if atomic, ok := inst.AtomicInfo(); ok {
+		comps = []BufferComponent{
+			{InMemory: true, ByteSize: uint64(atomic.ByteSize)},
+		}
+	}
+
+	return address, comps
+}
+
+// flatComponentAddress returns the addresses that the lanes of a FLAT or a
+// global instruction access.
+func flatComponentAddress(state InstEmuState) ComponentAddressFunc {
+	inst := state.Inst()
+
+	if inst.Seg != insts.FlatSegmentGlobal ||
+		inst.SAddr == nil || inst.SAddr.IntValue == 0x7F {
+		return func(laneID int, offset, _ uint64) (uint64, bool) {
+			return flatVAddr(state, laneID) + offset, true
+		}
+	}
+
+	reg := int(inst.SAddr.IntValue)
+	base := state.ReadOperand(insts.NewSRegOperand(reg, reg, 2), 0)
+
+	return func(laneID int, offset, _ uint64) (uint64, bool) {
+		vOffset := state.ReadOperand(inst.Addr, laneID) & 0xFFFFFFFF
+
+		return base + vOffset + uint64(int64(int32(inst.Offset0))) + offset,
+			true
+	}
+}
@@ -0,0 +1,107 @@
+package emu
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/akita/v4/mem/mem"
+	"github.com/sarchlab/akita/v4/mem/vm"
+	"github.com/sarchlab/mgpusim/v4/amd/insts"
+	"github.com/sarchlab/mgpusim/v4/amd/protocol"
+	"go.uber.org/mock/gomock"
+)
+
+var _ = Describe("Memory faults", func() {
+
+	var (
+		mockCtrl  *gomock.Controller
+		pageTable *MockPageTable
+
+		alu   *ALUImpl
+		state *mockInstState
+	)
+
+	const unmapped = uint64(0x100000)
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		pageTable = NewMockPageTable(mockCtrl)
+		pageTable.EXPECT().
+			Find(vm.PID(1), gomock.Any()).
+			DoAndReturn(func(pid vm.PID, addr uint64) (vm.Page, bool) {
+				if addr >= unmapped {
+					return vm.Page{}, false
+				}
+
+				return vm.Page{VAddr: addr >> 12 << 12,
+					PAddr: addr >> 12 << 12}, true
+			}).
+			AnyTimes()
+
+		storage := mem.NewStorage(1 * mem.MB)
+		alu = NewALU(NewStorageAccessor(storage, pageTable, 12, nil))
+
+		state = newMockInstState()
+		state.exec = 0xffffffffffffffff
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	It("should fault on FLAT_STORE_DWORD to an unmapped address", func() {
+		state.inst = insts.NewInst()
+		state.inst.FormatType = insts.FLAT
+		state.inst.Opcode = 28
+		state.inst.Addr = insts.NewVRegOperand(0, 0, 2)
+		state.inst.Data = insts.NewVRegOperand(4, 4, 1)
+
+		for i := 0; i < 64; i++ {
+			addr := uint64(i * 4)
+			if i >= 5 {
+				addr = unmapped + uint64(i*4)
+			}
+			state.WriteReg(insts.VReg(0), 2, i, insts.Uint64ToBytes(addr))
+		}
+
+		var fault *protocol.MemoryFault
+		func() {
+			defer func() {
+				fault, _ = recover().(*protocol.MemoryFault)
+			}()
+			alu.Run(state)
+		}()
+
+		Expect(fault).NotTo(BeNil())
+		Expect(fault.Address).To(Equal(unmapped + 20))
+		Expect(fault.Access).To(Equal(protocol.MemoryAccessWrite))
+		Expect(fault.PID).To(Equal(vm.PID(1)))
+		Expect(FaultLane(state, fault.Address)).To(Equal(5))
+	})
+
+	It("should find the lane of a global load with a scalar base", func() {
+		state.inst = insts.NewInst()
+		state.inst.FormatType = insts.FLAT
+		state.inst.Seg = insts.FlatSegmentGlobal
+		state.inst.Opcode = 21
+		state.inst.SAddr = insts.NewIntOperand(2, 2)
+		state.inst.Addr = insts.NewVRegOperand(0, 0, 1)
+		state.inst.Dst = insts.NewVRegOperand(4, 4, 2)
+		state.inst.Offset0 = 8
+
+		state.WriteReg(insts.SReg(2), 2, 0, insts.Uint64ToBytes(unmapped))
+		for i := 0; i < 64; i++ {
+			state.WriteReg(insts.VReg(0), 1, i,
+				insts.Uint32ToBytes(uint32(i*8)))
+		}
+
+		Expect(FaultLane(state, unmapped+8+7*8+4)).To(Equal(7))
+	})
+
+	It("should not find a lane for a scalar load", func() {
+		state.inst = insts.NewInst()
+		state.inst.FormatType = insts.SMEM
+		state.inst.Opcode = 0
+
+		Expect(FaultLane(state, unmapped)).To(Equal(-1))
+	})
+})
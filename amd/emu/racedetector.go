@@ -0,0 +1,548 @@
+package emu
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+	"sync"
+
+	"github.com/sarchlab/mgpusim/v4/amd/insts"
+	"github.com/sarchlab/mgpusim/v4/amd/kernels"
+)
+
+// A RaceSpace is the memory that two racing accesses share.
+type RaceSpace int
+
+// The memories that the race detector checks.
+const (
+	RaceSpaceLDS RaceSpace = iota
+	RaceSpaceGlobal
+)
+
+func (s RaceSpace) String() string {
+	switch s {
+	case RaceSpaceLDS:
+		return "LDS"
+	case RaceSpaceGlobal:
+		return "global memory"
+	}
+
+	return fmt.Sprintf("space %d", int(s))
+}
+
+// A RaceAccess is one of the two accesses of a data race.
+type RaceAccess struct {
+	Wavefront string
+	WorkGroup [3]int
+	PC        uint64
+	Inst      string
+	IsWrite   bool
+}
+
+func (a RaceAccess) String() string {
+	access := "read"
+	if a.IsWrite {
+		access = "write"
+	}
+
+	return fmt.Sprintf("%s by wavefront %s of work-group (%d, %d, %d) "+
+		"at pc 0x%x: %s", access, a.Wavefront,
+		a.WorkGroup[0], a.WorkGroup[1], a.WorkGroup[2], a.PC, a.Inst)
+}
+
+// A RaceReport describes two accesses of different wavefronts to the same
+// address that nothing orders, where at least one of the accesses is a write.
+// The detector reports a pair of instructions once and counts the bytes that
+// repeat the race.
+type RaceReport struct {
+	Space   RaceSpace
+	Address uint64
+	Kernel  string
+	First   RaceAccess
+	Second  RaceAccess
+	Count   int
+}
+
+func (r *RaceReport) String() string {
+	return fmt.Sprintf("data race in %s at 0x%x, kernel %s: %s, then %s "+
+		"(%d bytes)", r.Space, r.Address, r.Kernel, r.First, r.Second, r.Count)
+}
+
+type raceAccess struct {
+	wf       *Wavefront
+	pc       uint64
+	inst     *insts.Inst
+	epoch    int
+	seq      uint64
+	isWrite  bool
+	isAtomic bool
+}
+
+type raceRecord struct {
+	write, read raceAccess
+}
+
+// raceWfState keeps what orders the accesses of a wavefront.
+type raceWfState struct {
+	// pending is true if the wavefront has accessed the memory since its last
+	// s_waitcnt that waits for all the accesses to the memory.
+	pending [2]bool
+
+	// The accesses of the wavefront in the barrier epochs before
+	// releasedEpoch are visible to the other wavefronts of the work-group.
+	releasedEpoch [2]int
+
+	// releases are the sequence numbers of the global atomics that release
+	// the earlier global accesses of the wavefront.
+	releases []uint64
+
+	// acquired is the sequence number of the last release before the latest
+	// global atomic of the wavefront.
+	acquired uint64
+}
+
+type raceReportKey struct {
+	space         RaceSpace
+	kernel        string
+	firstPC       uint64
+	secondPC      uint64
+	firstIsWrite  bool
+	secondIsWrite bool
+}
+
+// A RaceDetector finds the data races between the wavefronts of the kernels
+// that emulated compute units execute.
+//
+// Two accesses of different wavefronts to the same byte race if at least one
+// of them is a write, they are not both atomics, and nothing orders them. An
+// S_BARRIER orders the LDS or global accesses that a wavefront makes before
+// the barrier if the wavefront waits for them with an s_waitcnt first. A
+// global atomic that a wavefront executes after waiting for its global
+// accesses releases them to the wavefronts that execute a global atomic
+// later, which covers the fence-and-flag idioms across work-groups.
+//
+// The detector keeps the last write and the last read of each byte. It drops
+// the global records when a compute unit starts the wavefronts of another
+// kernel, so the races of kernels that run at the same time on different GPUs
+// may go unreported.
+type RaceDetector struct {
+	lock sync.Mutex
+
+	rand *rand.Rand
+
+	packet      *kernels.HsaKernelDispatchPacket
+	seq         uint64
+	lastRelease uint64
+	global      map[uint64]*raceRecord
+	lds         map[*kernels.WorkGroup]map[uint64]*raceRecord
+	epochs      map[*kernels.WorkGroup]int
+	wfs         map[*Wavefront]*raceWfState
+
+	reports     []*RaceReport
+	reportIndex map[raceReportKey]*RaceReport
+}
+
+// NewRaceDetector creates a RaceDetector that keeps the order in which the
+// compute units run the wavefronts.
+func NewRaceDetector() *RaceDetector {
+	return &RaceDetector{
+		global:      make(map[uint64]*raceRecord),
+		lds:         make(map[*kernels.WorkGroup]map[uint64]*raceRecord),
+		epochs:      make(map[*kernels.WorkGroup]int),
+		wfs:         make(map[*Wavefront]*raceWfState),
+		reportIndex: make(map[raceReportKey]*RaceReport),
+	}
+}
+
+// RandomizeInterleaving lets the compute units interleave the wavefronts of
+// a work-group in a random order, a few instructions at a time, rather than
+// running each wavefront until a barrier. A different seed exposes the races
+// that depend on a different order.
+func (d *RaceDetector) RandomizeInterleaving(seed int64) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.rand = rand.New(rand.NewSource(seed))
+}
+
+// pickInterleaving picks one of n wavefronts and the number of instructions
+// that the wavefront runs. It returns -1 if the interleaving is not random.
+func (d *RaceDetector) pickInterleaving(n int) (wf, numInsts int) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if d.rand == nil {
+		return -1, 0
+	}
+
+	return d.rand.Intn(n), 1 + d.rand.Intn(8)
+}
+
+// Reports returns the races in the order that the detector finds them.
+func (d *RaceDetector) Reports() []RaceReport {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	reports := make([]RaceReport, len(d.reports))
+	for i, r := range d.reports {
+		reports[i] = *r
+	}
+
+	return reports
+}
+
+// Print writes the races to w, one race per line.
+func (d *RaceDetector) Print(w io.Writer) {
+	reports := d.Reports()
+	if len(reports) == 0 {
+		fmt.Fprintln(w, "race detector: no data race")
+		return
+	}
+
+	for _, r := range reports {
+		fmt.Fprintf(w, "race detector: %s\n", &r)
+	}
+}
+
+// observe checks the memory accesses of the instruction that a wavefront is
+// about to execute.
+func (d *RaceDetector) observe(wf *Wavefront) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if wf.Packet != d.packet {
+		d.startKernel(wf.Packet)
+	}
+
+	state := d.wfState(wf)
+	inst := wf.inst
+
+	switch inst.FormatType {
+	case insts.SOPP:
+		if inst.Opcode == 12 { // S_WAITCNT
+			state.pending[RaceSpaceGlobal] =
+				state.pending[RaceSpaceGlobal] && inst.VMCNT != 0
+			state.pending[RaceSpaceLDS] =
+				state.pending[RaceSpaceLDS] && inst.LKGMCNT != 0
+		}
+	case insts.DS:
+		d.observeLDS(wf, state)
+	case insts.FLAT, insts.MUBUF, insts.MTBUF:
+		d.observeGlobal(wf, state)
+	}
+}
+
+func (d *RaceDetector) startKernel(packet *kernels.HsaKernelDispatchPacket) {
+	d.packet = packet
+	d.seq = 0
+	d.lastRelease = 0
+	d.global = make(map[uint64]*raceRecord)
+	d.wfs = make(map[*Wavefront]*raceWfState)
+}
+
+func (d *RaceDetector) wfState(wf *Wavefront) *raceWfState {
+	state, ok := d.wfs[wf]
+	if !ok {
+		state = &raceWfState{}
+		d.wfs[wf] = state
+	}
+
+	return state
+}
+
+func (d *RaceDetector) observeLDS(wf *Wavefront, state *raceWfState) {
+	inst := wf.inst
+	comps, isWrite, ok := dsAccesses(inst)
+	if !ok {
+		return
+	}
+
+	records, ok := d.lds[wf.WG]
+	if !ok {
+		records = make(map[uint64]*raceRecord)
+		d.lds[wf.WG] = records
+	}
+
+	_, isAtomic := inst.AtomicInfo()
+	access := d.newAccess(wf, isWrite, isAtomic)
+
+	exec := wf.EXEC()
+	for lane := 0; lane < 64; lane++ {
+		if exec&(1<<uint(lane)) == 0 {
+			continue
+		}
+
+		base := uint64(uint32(wf.ReadOperand(inst.Addr, lane)))
+		for _, c := range comps {
+			d.accessBytes(records, RaceSpaceLDS, access,
+				base+c.Offset, c.ByteSize)
+		}
+	}
+
+	state.pending[RaceSpaceLDS] = true
+}
+
+func (d *RaceDetector) observeGlobal(wf *Wavefront, state *raceWfState) {
+	kind, ok := vectorMemOpKind(wf.inst)
+	if !ok || kind == insts.BufferOpCacheControl || AccessesScratch(wf) {
+		return
+	}
+
+	address, comps := laneAccesses(wf)
+	if address == nil {
+		return
+	}
+
+	d.seq++
+
+	isAtomic := kind == insts.BufferOpAtomic
+	if isAtomic {
+		state.acquired = d.lastRelease
+
+		if !state.pending[RaceSpaceGlobal] {
+			state.releases = append(state.releases, d.seq)
+			d.lastRelease = d.seq
+		}
+	}
+
+	access := d.newAccess(wf, kind != insts.BufferOpLoad, isAtomic)
+
+	exec := wf.EXEC()
+	for lane := 0; lane < 64; lane++ {
+		if exec&(1<<uint(lane)) == 0 {
+			continue
+		}
+
+		for _, c := range comps {
+			if !c.InMemory {
+				continue
+			}
+
+			addr, ok := address(lane, c.Offset, c.ByteSize)
+			if !ok {
+				continue
+			}
+
+			d.accessBytes(d.global, RaceSpaceGlobal, access, addr, c.ByteSize)
+		}
+	}
+
+	state.pending[RaceSpaceGlobal] = true
+}
+
+func (d *RaceDetector) newAccess(
+	wf *Wavefront,
+	isWrite, isAtomic bool,
+) raceAccess {
+	return raceAccess{
+		wf:       wf,
+		pc:       wf.PC() - uint64(wf.inst.ByteSize),
+		inst:     wf.inst,
+		epoch:    d.epochs[wf.WG],
+		seq:      d.seq,
+		isWrite:  isWrite,
+		isAtomic: isAtomic,
+	}
+}
+
+func (d *RaceDetector) accessBytes(
+	records map[uint64]*raceRecord,
+	space RaceSpace,
+	access raceAccess,
+	addr, size uint64,
+) {
+	for a := addr; a < addr+size; a++ {
+		record, ok := records[a]
+		if !ok {
+			record = &raceRecord{}
+			records[a] = record
+		}
+
+		d.accessByte(record, space, access, a)
+	}
+}
+
+func (d *RaceDetector) accessByte(
+	record *raceRecord,
+	space RaceSpace,
+	access raceAccess,
+	addr uint64,
+) {
+	if d.races(record.write, access, space) {
+		d.report(space, addr, record.write, access)
+	} else if access.isWrite && d.races(record.read, access, space) {
+		d.report(space, addr, record.read, access)
+	}
+
+	if access.isWrite {
+		record.write = access
+		record.read = raceAccess{}
+	} else {
+		record.read = access
+	}
+}
+
+func (d *RaceDetector) races(prev, curr raceAccess, space RaceSpace) bool {
+	if prev.wf == nil || prev.wf == curr.wf {
+		return false
+	}
+
+	if prev.isAtomic && curr.isAtomic {
+		return false
+	}
+
+	return !d.ordered(prev, curr.wf, space)
+}
+
+// ordered returns true if an earlier access of another wavefront is visible
+// to the wavefront.
+func (d *RaceDetector) ordered(
+	prev raceAccess,
+	wf *Wavefront,
+	space RaceSpace,
+) bool {
+	prevState, ok := d.wfs[prev.wf]
+	if !ok {
+		return true
+	}
+
+	if prev.wf.WG == wf.WG && prev.epoch < prevState.releasedEpoch[space] {
+		return true
+	}
+
+	if space != RaceSpaceGlobal {
+		return false
+	}
+
+	releases := prevState.releases
+	i := sort.Search(len(releases), func(i int) bool {
+		return releases[i] > prev.seq
+	})
+
+	return i < len(releases) && releases[i] <= d.wfs[wf].acquired
+}
+
+func (d *RaceDetector) report(
+	space RaceSpace,
+	addr uint64,
+	first, second raceAccess,
+) {
+	kernel := kernelName(second.wf.CodeObject)
+	key := raceReportKey{
+		space:         space,
+		kernel:        kernel,
+		firstPC:       first.pc,
+		secondPC:      second.pc,
+		firstIsWrite:  first.isWrite,
+		secondIsWrite: second.isWrite,
+	}
+
+	if r, ok := d.reportIndex[key]; ok {
+		r.Count++
+		return
+	}
+
+	r := &RaceReport{
+		Space:   space,
+		Address: addr,
+		Kernel:  kernel,
+		First:   raceAccessOf(first),
+		Second:  raceAccessOf(second),
+		Count:   1,
+	}
+	d.reports = append(d.reports, r)
+	d.reportIndex[key] = r
+}
+
+func raceAccessOf(a raceAccess) RaceAccess {
+	wg := a.wf.WG
+
+	return RaceAccess{
+		Wavefront: a.wf.UID,
+		WorkGroup: [3]int{wg.IDX, wg.IDY, wg.IDZ},
+		PC:        a.pc,
+		Inst:      insts.NewInstPrinter(nil).Print(a.inst),
+		IsWrite:   a.isWrite,
+	}
+}
+
+// barrier starts a new barrier epoch of a work-group, whose wavefronts all
+// wait at an S_BARRIER.
+func (d *RaceDetector) barrier(wfs []*Wavefront) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if len(wfs) == 0 {
+		return
+	}
+
+	wg := wfs[0].WG
+	d.epochs[wg]++
+
+	for _, wf := range wfs {
+		state := d.wfState(wf)
+		for space := range state.pending {
+			if !state.pending[space] {
+				state.releasedEpoch[space] = d.epochs[wg]
+			}
+		}
+	}
+}
+
+// completeWorkGroup drops the LDS records of a work-group.
+func (d *RaceDetector) completeWorkGroup(wg *kernels.WorkGroup) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	delete(d.lds, wg)
+	delete(d.epochs, wg)
+}
+
+// dsAccesses returns the parts of the LDS that each lane of a DS instruction
+// accesses, relative to the address in the address register.
+func dsAccesses(inst *insts.Inst) (comps []BufferComponent, isWrite, ok bool) {
+	one := func(size uint64) []BufferComponent {
+		return []BufferComponent{
+			{InMemory: true, Offset: uint64(inst.Offset0), ByteSize: size},
+		}
+	}
+
+	two := func(size uint64) []BufferComponent {
+		return []BufferComponent{
+			{InMemory: true, Offset: uint64(inst.Offset0) * size,
+				ByteSize: size},
+			{InMemory: true, Offset: uint64(inst.Offset1) * size,
+				ByteSize: size},
+		}
+	}
+
+	switch inst.Opcode {
+	case 13: // DS_WRITE_B32
+		return one(4), true, true
+	case 14: // DS_WRITE2_B32
+		return two(4), true, true
+	case 30: // DS_WRITE_B8
+		return one(1), true, true
+	case 54: // DS_READ_B32
+		return one(4), false, true
+	case 55: // DS_READ2_B32
+		return two(4), false, true
+	case 78: // DS_WRITE2_B64
+		return two(8), true, true
+	case 118: // DS_READ_B64
+		return one(8), false, true
+	case 119: // DS_READ2_B64
+		return two(8), false, true
+	case 223: // DS_WRITE_B128
+		return one(16), true, true
+	case 255: // DS_READ_B128
+		return one(16), false, true
+	}
+
+	if atomic, ok := inst.AtomicInfo(); ok {
+		return one(uint64(atomic.ByteSize)), true, true
+	}
+
+	return nil, false, false
+}
@@ -0,0 +1,214 @@
+package emu
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/mgpusim/v4/amd/insts"
+	"github.com/sarchlab/mgpusim/v4/amd/kernels"
+)
+
+var _ = Describe("RaceDetector", func() {
+
+	var (
+		detector *RaceDetector
+		packet   *kernels.HsaKernelDispatchPacket
+		co       *insts.KernelCodeObject
+	)
+
+	newWf := func(wg *kernels.WorkGroup) *Wavefront {
+		nativeWf := kernels.NewWavefront()
+		nativeWf.WG = wg
+		nativeWf.Packet = packet
+		nativeWf.CodeObject = co
+
+		wf := NewWavefront(nativeWf)
+		wf.SetEXEC(1)
+		wf.SetPC(0x108)
+
+		return wf
+	}
+
+	execute := func(wf *Wavefront, inst *insts.Inst) {
+		inst.ByteSize = 8
+		wf.inst = inst
+		detector.observe(wf)
+	}
+
+	dsInst := func(opcode insts.Opcode) *insts.Inst {
+		inst := insts.NewInst()
+		inst.FormatType = insts.DS
+		inst.Opcode = opcode
+		inst.Addr = insts.NewVRegOperand(0, 0, 1)
+		inst.Dst = insts.NewVRegOperand(4, 4, 1)
+		inst.Data = insts.NewVRegOperand(4, 4, 1)
+
+		return inst
+	}
+
+	flatInst := func(opcode insts.Opcode) *insts.Inst {
+		inst := insts.NewInst()
+		inst.FormatType = insts.FLAT
+		inst.Opcode = opcode
+		inst.Addr = insts.NewVRegOperand(0, 0, 2)
+		inst.Dst = insts.NewVRegOperand(4, 4, 1)
+		inst.Data = insts.NewVRegOperand(4, 4, 1)
+
+		return inst
+	}
+
+	waitcnt := func(vmcnt, lgkmcnt int) *insts.Inst {
+		inst := insts.NewInst()
+		inst.FormatType = insts.SOPP
+		inst.Opcode = 12
+		inst.VMCNT = vmcnt
+		inst.LKGMCNT = lgkmcnt
+
+		return inst
+	}
+
+	BeforeEach(func() {
+		detector = NewRaceDetector()
+		packet = &kernels.HsaKernelDispatchPacket{}
+		co = &insts.KernelCodeObject{}
+	})
+
+	Context("in the LDS", func() {
+		var (
+			wg    *kernels.WorkGroup
+			wfA   *Wavefront
+			wfB   *Wavefront
+			write *insts.Inst
+			read  *insts.Inst
+		)
+
+		BeforeEach(func() {
+			wg = kernels.NewWorkGroup()
+			wfA = newWf(wg)
+			wfB = newWf(wg)
+			write = dsInst(13) // DS_WRITE_B32
+			read = dsInst(54)  // DS_READ_B32
+		})
+
+		It("should report a read that no barrier orders", func() {
+			execute(wfA, write)
+			execute(wfB, read)
+
+			reports := detector.Reports()
+			Expect(reports).To(HaveLen(1))
+			Expect(reports[0].Space).To(Equal(RaceSpaceLDS))
+			Expect(reports[0].Address).To(Equal(uint64(0)))
+			Expect(reports[0].First.Wavefront).To(Equal(wfA.UID))
+			Expect(reports[0].First.IsWrite).To(BeTrue())
+			Expect(reports[0].First.PC).To(Equal(uint64(0x100)))
+			Expect(reports[0].Second.Wavefront).To(Equal(wfB.UID))
+			Expect(reports[0].Second.IsWrite).To(BeFalse())
+			Expect(reports[0].Count).To(Equal(4))
+		})
+
+		It("should not report a read after a waitcnt and a barrier", func() {
+			execute(wfA, write)
+			execute(wfA, waitcnt(15, 0))
+			detector.barrier([]*Wavefront{wfA, wfB})
+			execute(wfB, read)
+
+			Expect(detector.Reports()).To(BeEmpty())
+		})
+
+		It("should report a read after a barrier without a waitcnt", func() {
+			execute(wfA, write)
+			detector.barrier([]*Wavefront{wfA, wfB})
+			execute(wfB, read)
+
+			Expect(detector.Reports()).To(HaveLen(1))
+		})
+
+		It("should report a write after a read of another wavefront", func() {
+			execute(wfA, read)
+			execute(wfB, write)
+
+			reports := detector.Reports()
+			Expect(reports).To(HaveLen(1))
+			Expect(reports[0].First.IsWrite).To(BeFalse())
+			Expect(reports[0].Second.IsWrite).To(BeTrue())
+		})
+
+		It("should not report the accesses of the same wavefront", func() {
+			execute(wfA, write)
+			execute(wfA, read)
+
+			Expect(detector.Reports()).To(BeEmpty())
+		})
+	})
+
+	Context("in the global memory", func() {
+		var (
+			wfA, wfB *Wavefront
+		)
+
+		BeforeEach(func() {
+			wfA = newWf(kernels.NewWorkGroup())
+			wfB = newWf(kernels.NewWorkGroup())
+
+			for _, wf := range []*Wavefront{wfA, wfB} {
+				wf.WriteReg(insts.VReg(0), 2, 0, insts.Uint64ToBytes(0x1000))
+			}
+		})
+
+		It("should report the accesses of different work-groups", func() {
+			execute(wfA, flatInst(28)) // FLAT_STORE_DWORD
+			execute(wfB, flatInst(20)) // FLAT_LOAD_DWORD
+
+			reports := detector.Reports()
+			Expect(reports).To(HaveLen(1))
+			Expect(reports[0].Space).To(Equal(RaceSpaceGlobal))
+			Expect(reports[0].Address).To(Equal(uint64(0x1000)))
+		})
+
+		It("should not report atomics", func() {
+			execute(wfA, flatInst(66)) // FLAT_ATOMIC_ADD
+			execute(wfB, flatInst(66))
+
+			Expect(detector.Reports()).To(BeEmpty())
+		})
+
+		It("should not report a read that an atomic acquires", func() {
+			flag := flatInst(66) // FLAT_ATOMIC_ADD
+			flag.Addr = insts.NewVRegOperand(2, 2, 2)
+			for _, wf := range []*Wavefront{wfA, wfB} {
+				wf.WriteReg(insts.VReg(2), 2, 0, insts.Uint64ToBytes(0x2000))
+			}
+
+			execute(wfA, flatInst(28))
+			execute(wfA, waitcnt(0, 15))
+			execute(wfA, flag)
+			execute(wfB, flag)
+			execute(wfB, flatInst(20))
+
+			Expect(detector.Reports()).To(BeEmpty())
+		})
+
+		It("should not report the accesses of different kernels", func() {
+			execute(wfA, flatInst(28))
+			wfB.Packet = &kernels.HsaKernelDispatchPacket{}
+			execute(wfB, flatInst(20))
+
+			Expect(detector.Reports()).To(BeEmpty())
+		})
+	})
+
+	It("should keep the order of the wavefronts by default", func() {
+		i, _ := detector.pickInterleaving(4)
+
+		Expect(i).To(Equal(-1))
+	})
+
+	It("should pick a random wavefront with a seed", func() {
+		detector.RandomizeInterleaving(1)
+
+		i, numInsts := detector.pickInterleaving(4)
+
+		Expect(i).To(BeNumerically(">=", 0))
+		Expect(i).To(BeNumerically("<", 4))
+		Expect(numInsts).To(BeNumerically(">=", 1))
+	})
+})
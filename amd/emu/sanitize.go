@@ -0,0 +1,99 @@
+package emu
+
+import (
+	"github.com/sarchlab/mgpusim/v4/amd/insts"
+	"github.com/sarchlab/mgpusim/v4/amd/sanitizer"
+)
+
+type memRange struct {
+	addr, size uint64
+}
+
+// sanitize checks the accesses of the active lanes of a vector memory
+// instruction before the wavefront executes it. It returns the memory ranges
+// that the instruction writes, which become initialized once the instruction
+// executes.
+func (cu *ComputeUnit) sanitize(wf *Wavefront) []memRange {
+	kind, ok := vectorMemOpKind(wf.inst)
+	if !ok || kind == insts.BufferOpCacheControl {
+		return nil
+	}
+
+	isWrite := kind != insts.BufferOpLoad
+	isRead := kind != insts.BufferOpStore
+
+	var writes []memRange
+	forEachLaneAccess(wf, func(lane int, addr, size uint64) bool {
+		cu.checkAccess(wf, lane, addr, size, isRead, isWrite)
+
+		if isWrite {
+			writes = append(writes, memRange{addr: addr, size: size})
+		}
+
+		return true
+	})
+
+	return writes
+}
+
+// checkAccess reports the access of a lane if it is invalid. An atomic
+// instruction both reads and writes the memory.
+func (cu *ComputeUnit) checkAccess(
+	wf *Wavefront,
+	lane int,
+	addr, size uint64,
+	isRead, isWrite bool,
+) {
+	kind, invalid := cu.Sanitizer.Check(wf.pid, addr, size, !isRead)
+	if !invalid {
+		return
+	}
+
+	cu.Sanitizer.Report(sanitizer.Report{
+		Kind:     kind,
+		PID:      wf.pid,
+		Address:  addr,
+		IsWrite:  isWrite,
+		Kernel:   kernelName(wf.CodeObject),
+		WorkItem: workItemID(wf, lane),
+		PC:       wf.PC() - uint64(wf.inst.ByteSize),
+		Inst:     insts.NewInstPrinter(nil).Print(wf.inst),
+	})
+}
+
+func vectorMemOpKind(inst *insts.Inst) (insts.BufferOpKind, bool) {
+	switch inst.FormatType {
+	case insts.MUBUF, insts.MTBUF:
+		op, ok := inst.BufferOp()
+		return op.Kind, ok
+	case insts.FLAT:
+		op, ok := inst.FlatOp()
+		return op.Kind, ok
+	}
+
+	return 0, false
+}
+
+func kernelName(co *insts.KernelCodeObject) string {
+	if co.Symbol != nil && co.Symbol.Name != "" {
+		return co.Symbol.Name
+	}
+
+	return "unknown kernel"
+}
+
+// workItemID returns the ID of the work-item of a lane in the grid.
+func workItemID(wf *Wavefront, lane int) [3]int {
+	wg := wf.WG
+	i := wf.FirstWiFlatID + lane
+
+	x := i % (wg.SizeX * wg.SizeY) % wg.SizeX
+	y := i % (wg.SizeX * wg.SizeY) / wg.SizeX
+	z := i / (wg.SizeX * wg.SizeY)
+
+	return [3]int{
+		wg.IDX*wg.SizeX + x,
+		wg.IDY*wg.SizeY + y,
+		wg.IDZ*wg.SizeZ + z,
+	}
+}
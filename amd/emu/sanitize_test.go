@@ -0,0 +1,122 @@
+package emu
+
+import (
+	"debug/elf"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/akita/v4/mem/vm"
+	"github.com/sarchlab/mgpusim/v4/amd/insts"
+	"github.com/sarchlab/mgpusim/v4/amd/kernels"
+	"github.com/sarchlab/mgpusim/v4/amd/sanitizer"
+)
+
+var _ = Describe("Sanitizer", func() {
+
+	var (
+		san *sanitizer.Sanitizer
+		cu  *ComputeUnit
+		wf  *Wavefront
+	)
+
+	const base = uint64(0x10000)
+
+	BeforeEach(func() {
+		san = sanitizer.NewSanitizer()
+		san.Allocate(vm.PID(1), base, 64*4)
+
+		cu = &ComputeUnit{Sanitizer: san}
+
+		wg := kernels.NewWorkGroup()
+		wg.SizeX, wg.SizeY, wg.SizeZ = 128, 1, 1
+		wg.IDX = 2
+
+		nativeWf := kernels.NewWavefront()
+		nativeWf.WG = wg
+		nativeWf.FirstWiFlatID = 64
+		nativeWf.CodeObject = &insts.KernelCodeObject{
+			Symbol: &elf.Symbol{Name: "kernel"},
+		}
+
+		wf = NewWavefront(nativeWf)
+		wf.pid = 1
+		wf.SetEXEC(0xffffffffffffffff)
+
+		wf.inst = insts.NewInst()
+		wf.inst.FormatType = insts.FLAT
+		wf.inst.Addr = insts.NewVRegOperand(0, 0, 2)
+		wf.inst.ByteSize = 8
+		wf.SetPC(0x108)
+	})
+
+	setLaneAddrs := func(first uint64) {
+		for i := 0; i < 64; i++ {
+			wf.WriteReg(insts.VReg(0), 2, i,
+				insts.Uint64ToBytes(first+uint64(i*4)))
+		}
+	}
+
+	It("should report the first lane that loads out of bounds", func() {
+		wf.inst.Opcode = 20 // FLAT_LOAD_DWORD
+		wf.inst.Dst = insts.NewVRegOperand(4, 4, 1)
+		san.MarkInitialized(vm.PID(1), base, 64*4)
+		setLaneAddrs(base + 16*4)
+
+		writes := cu.sanitize(wf)
+
+		Expect(writes).To(BeEmpty())
+		reports := san.Reports()
+		Expect(reports).To(HaveLen(1))
+		Expect(reports[0].Kind).To(Equal(sanitizer.OutOfBounds))
+		Expect(reports[0].Address).To(Equal(base + 64*4))
+		Expect(reports[0].Kernel).To(Equal("kernel"))
+		Expect(reports[0].WorkItem).To(Equal([3]int{2*128 + 64 + 48, 0, 0}))
+		Expect(reports[0].PC).To(Equal(uint64(0x100)))
+		Expect(reports[0].Count).To(Equal(16))
+	})
+
+	It("should report the reads of uninitialized memory", func() {
+		wf.inst.Opcode = 20 // FLAT_LOAD_DWORD
+		wf.inst.Dst = insts.NewVRegOperand(4, 4, 1)
+		san.MarkInitialized(vm.PID(1), base, 32*4)
+		setLaneAddrs(base)
+
+		cu.sanitize(wf)
+
+		reports := san.Reports()
+		Expect(reports).To(HaveLen(1))
+		Expect(reports[0].Kind).To(Equal(sanitizer.UninitializedRead))
+		Expect(reports[0].Address).To(Equal(base + 32*4))
+		Expect(reports[0].Count).To(Equal(32))
+	})
+
+	It("should return the memory that a store writes", func() {
+		wf.inst.Opcode = 28 // FLAT_STORE_DWORD
+		wf.inst.Data = insts.NewVRegOperand(4, 4, 1)
+		wf.SetEXEC(0x3)
+		setLaneAddrs(base)
+
+		writes := cu.sanitize(wf)
+
+		Expect(san.Reports()).To(BeEmpty())
+		Expect(writes).To(Equal([]memRange{
+			{addr: base, size: 4},
+			{addr: base + 4, size: 4},
+		}))
+	})
+
+	It("should report the stores to freed memory", func() {
+		wf.inst.Opcode = 28 // FLAT_STORE_DWORD
+		wf.inst.Data = insts.NewVRegOperand(4, 4, 1)
+		san.Free(vm.PID(1), base)
+		setLaneAddrs(base)
+
+		cu.sanitize(wf)
+
+		reports := san.Reports()
+		Expect(reports).To(HaveLen(1))
+		Expect(reports[0].Kind).To(Equal(sanitizer.UseAfterFree))
+		Expect(reports[0].IsWrite).To(BeTrue())
+		Expect(reports[0].Count).To(Equal(64))
+	})
+})
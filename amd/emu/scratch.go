@@ -0,0 +1,217 @@
+package emu
+
+import (
+	"encoding/binary"
+	"log"
+
+	"github.com/sarchlab/mgpusim/v4/amd/insts"
+	"github.com/sarchlab/mgpusim/v4/amd/kernels"
+)
+
+// The apertures that map the LDS and the private memory into the flat
+// address space. An address in the private aperture is an offset into the
+// private memory of the work-item.
+const (
+	SharedApertureBase  = uint64(1) << 48
+	PrivateApertureBase = uint64(2) << 48
+	ApertureSize        = uint64(1) << 32
+)
+
+// ApertureRegValue returns the value of src_shared_base, src_shared_limit,
+// src_private_base or src_private_limit.
+func ApertureRegValue(reg *insts.Reg) uint64 {
+	switch reg.RegType {
+	case insts.SrcSharedBase:
+		return SharedApertureBase
+	case insts.SrcSharedLimit:
+		return SharedApertureBase + ApertureSize - 1
+	case insts.SrcPrivateBase:
+		return PrivateApertureBase
+	case insts.SrcPrivateLimit:
+		return PrivateApertureBase + ApertureSize - 1
+	}
+
+	log.Panicf("register %s is not an aperture register", reg.Name)
+
+	return 0
+}
+
+// ReadApertureReg returns the bytes of an aperture register. Reading one
+// register gives the low 32 bits.
+func ReadApertureReg(reg *insts.Reg, regCount int) []byte {
+	data := insts.Uint64ToBytes(ApertureRegValue(reg))
+	if regCount < 2 {
+		return data[:4]
+	}
+
+	return data[:8]
+}
+
+// inPrivateAperture returns true if a flat address points to the private
+// memory.
+func inPrivateAperture(addr uint64) bool {
+	return addr >= PrivateApertureBase &&
+		addr < PrivateApertureBase+ApertureSize
+}
+
+// ScratchResourceDescriptor returns the buffer resource descriptor of the
+// private segment buffer. The descriptor interleaves the private memory of
+// the 64 lanes of a wavefront every 4 bytes, so that the lanes that access
+// the same private variable access consecutive addresses.
+func ScratchResourceDescriptor(base uint64) []byte {
+	data := make([]byte, 16)
+
+	w1 := uint32(base>>32)&0xffff | 1<<31
+	w3 := uint32(4|5<<3|6<<6|7<<9) | // dst_sel XYZW
+		7<<12 | // BUF_NUM_FORMAT_FLOAT
+		4<<15 | // BUF_DATA_FORMAT_32
+		1<<19 | // element size 4
+		3<<21 | // index stride 64
+		1<<23 // add_tid_enable
+
+	binary.LittleEndian.PutUint32(data[0:], uint32(base))
+	binary.LittleEndian.PutUint32(data[4:], w1)
+	binary.LittleEndian.PutUint32(data[8:], 0xffffffff)
+	binary.LittleEndian.PutUint32(data[12:], w3)
+
+	return data
+}
+
+// FlatScratchInit returns the value of the flat scratch init SGPRs of a
+// wavefront. For GCN3 code objects, the low half is the offset of the scratch
+// segment in the scratch memory, which starts at the segment, and the high
+// half is the private segment size. Later code objects take the address of
+// the scratch segment. The kernel adds the private segment wave byte offset
+// to either of them.
+func FlatScratchInit(wf *kernels.Wavefront) uint64 {
+	if wf.CodeObject.Version == insts.CodeObjectV2 {
+		return uint64(wf.Packet.PrivateSegmentSize) << 32
+	}
+
+	return wf.ScratchAddress
+}
+
+// ScratchLaneOffset returns the offset into the private memory that a lane
+// of a FLAT instruction accesses. The second return value is false if the
+// lane does not access the private memory.
+func ScratchLaneOffset(state InstEmuState, laneID int) (uint64, bool) {
+	inst := state.Inst()
+
+	switch inst.Seg {
+	case insts.FlatSegmentScratch:
+		var offset uint32
+		if inst.SAddr.IntValue != 0x7F {
+			reg := int(inst.SAddr.IntValue)
+			offset = uint32(state.ReadOperand(
+				insts.NewSRegOperand(reg, reg, 1), laneID))
+		} else {
+			offset = uint32(state.ReadOperand(inst.Addr, laneID))
+		}
+
+		return uint64(offset + inst.Offset0), true
+	case insts.FlatSegmentFlat:
+		if inst.Addr.RegCount != 2 {
+			return 0, false
+		}
+
+		addr := flatVAddr(state, laneID)
+		if inPrivateAperture(addr) {
+			return addr - PrivateApertureBase, true
+		}
+	}
+
+	return 0, false
+}
+
+// flatVAddr returns the address of a FLAT instruction that takes a 64-bit
+// address from a VGPR pair.
+func flatVAddr(state InstEmuState, laneID int) uint64 {
+	inst := state.Inst()
+
+	return state.ReadOperand(inst.Addr, laneID) +
+		uint64(int64(int32(inst.Offset0)))
+}
+
+// AccessesScratch returns true if a FLAT instruction is a scratch
+// instruction or has an active lane that accesses the private aperture.
+func AccessesScratch(state InstEmuState) bool {
+	inst := state.Inst()
+	if inst.FormatType != insts.FLAT {
+		return false
+	}
+
+	switch inst.Seg {
+	case insts.FlatSegmentScratch:
+		return true
+	case insts.FlatSegmentFlat:
+		if state.ScratchBase() == 0 || inst.Addr.RegCount != 2 {
+			return false
+		}
+
+		exec := state.EXEC()
+		for i := 0; i < 64; i++ {
+			if exec&(1<<uint(i)) == 0 {
+				continue
+			}
+
+			if _, ok := ScratchLaneOffset(state, i); ok {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// ScratchComponentAddress returns the addresses that the lanes of a FLAT
+// instruction that accesses the scratch memory access. The lanes of a flat
+// instruction that do not point to the private aperture access their flat
+// addresses.
+func ScratchComponentAddress(state InstEmuState) ComponentAddressFunc {
+	base := state.ScratchBase()
+	if base == 0 {
+		log.Panicf("the kernel accesses the scratch memory, " +
+			"but it has no scratch segment")
+	}
+
+	rsrc := DecodeBufferResource(ScratchResourceDescriptor(base))
+
+	return func(laneID int, offset, byteSize uint64) (uint64, bool) {
+		laneOffset, private := ScratchLaneOffset(state, laneID)
+		if !private {
+			return flatVAddr(state, laneID) + offset, true
+		}
+
+		if byteSize > rsrc.ElementSize {
+			log.Panicf("accessing %d bytes of the scratch memory at once "+
+				"is not supported", byteSize)
+		}
+
+		access := BufferAccess{Index: uint64(laneID), Offset: laneOffset}
+
+		return rsrc.Address(access, offset), true
+	}
+}
+
+// FlatComponents returns how each register that a FLAT load or store
+// accesses maps to the memory.
+func FlatComponents(inst *insts.Inst) []BufferComponent {
+	op, _ := inst.FlatOp()
+
+	return rawComponents(op)
+}
+
+// RunScratchInst executes a FLAT instruction that accesses the scratch
+// memory.
+func RunScratchInst(state InstEmuState, storageAccessor StorageAccessor) {
+	inst := state.Inst()
+
+	op, ok := inst.FlatOp()
+	if !ok {
+		log.Panicf("Opcode %d for scratch instructions is not implemented",
+			inst.Opcode)
+	}
+
+	runComponentInst(state, storageAccessor, op,
+		FlatComponents(inst), ScratchComponentAddress(state))
+}
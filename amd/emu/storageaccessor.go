@@ -1,14 +1,16 @@
 package emu
 
 import (
-	"fmt"
 	"log"
 
 	"github.com/sarchlab/akita/v4/mem/mem"
 	"github.com/sarchlab/akita/v4/mem/vm"
+	"github.com/sarchlab/mgpusim/v4/amd/protocol"
 )
 
-// StorageAccessor provides memory access for ALU operations.
+// StorageAccessor provides memory access for ALU operations. Accessing an
+// address that is not mapped panics with a *protocol.MemoryFault, which the
+// compute unit recovers from.
 type StorageAccessor interface {
 	Read(pid vm.PID, vAddr, byteSize uint64) []byte
 	Write(pid vm.PID, vAddr uint64, data []byte)
@@ -37,11 +39,7 @@ func (a *storageAccessorImpl) Read(pid vm.PID, vAddr, byteSize uint64) []byte {
 
 		page, found := a.pageTable.Find(pid, currVAddr)
 		if !found {
-			panic(fmt.Sprintf(
-				"page not found in page table: "+
-					"pid=%d, vAddr=0x%x, origVAddr=0x%x, "+
-					"size=%d, offset=%d",
-				pid, currVAddr, vAddr, byteSize, offset))
+			panic(newMemoryFault(pid, currVAddr, protocol.MemoryAccessRead))
 		}
 		pAddr := page.PAddr + (currVAddr - page.VAddr)
 
@@ -79,7 +77,7 @@ func (a *storageAccessorImpl) Write(pid vm.PID, vAddr uint64, data []byte) {
 
 		page, found := a.pageTable.Find(pid, currVAddr)
 		if !found {
-			panic("page not found in page table")
+			panic(newMemoryFault(pid, currVAddr, protocol.MemoryAccessWrite))
 		}
 		pAddr := page.PAddr + (currVAddr - page.VAddr)
 
@@ -27,7 +27,13 @@ type Wavefront struct {
 	M0       uint32
 	SRegFile []byte
 	VRegFile []byte
+	ARegFile []byte
 	LDS      []byte
+
+	// FlatScratch is the FLAT_SCRATCH register. The emulator locates the
+	// private memory with ScratchBase, regardless of the value that the
+	// kernel writes to the register.
+	FlatScratch uint64
 }
 
 // NewWavefront returns the Wavefront that wraps the nativeWf
@@ -37,6 +43,7 @@ func NewWavefront(nativeWf *kernels.Wavefront) *Wavefront {
 
 	wf.SRegFile = make([]byte, 4*102)
 	wf.VRegFile = make([]byte, 4*64*256)
+	wf.ARegFile = make([]byte, 4*64*256)
 
 	return wf
 }
@@ -66,9 +73,10 @@ func (wf *Wavefront) EXEC() uint64 {
 	return wf.exec
 }
 
-// SetEXEC sets the exec mask
+// SetEXEC sets the exec mask. The bits beyond the lanes of a wave32
+// wavefront are always 0.
 func (wf *Wavefront) SetEXEC(v uint64) {
-	wf.exec = v
+	wf.exec = v & wf.LaneMask()
 }
 
 // SCC returns the scalar condition code
@@ -86,9 +94,11 @@ func (wf *Wavefront) VCC() uint64 {
 	return wf.vcc
 }
 
-// SetVCC sets the vector condition code
+// SetVCC sets the vector condition code. A wave32 wavefront only updates the
+// bits of its lanes, as the vector instructions only write VCC_LO.
 func (wf *Wavefront) SetVCC(v uint64) {
-	wf.vcc = v
+	mask := wf.LaneMask()
+	wf.vcc = wf.vcc&^mask | v&mask
 }
 
 // readFromRegFile reads a uint64 value from a register file byte slice at the
@@ -120,6 +130,11 @@ func (wf *Wavefront) readRegOperand(
 		return readFromRegFile(wf.SRegFile, offset, reg.ByteSize, regCount)
 	}
 
+	if reg.IsAReg() {
+		offset := laneID*256*4 + reg.RegIndex()*4
+		return readFromRegFile(wf.ARegFile, offset, reg.ByteSize, regCount)
+	}
+
 	switch reg.RegType {
 	case insts.SCC:
 		return uint64(wf.scc)
@@ -232,6 +247,12 @@ func (wf *Wavefront) VRegValue(lane int, i int) uint32 {
 	return insts.BytesToUint32(wf.VRegFile[offset : offset+4])
 }
 
+// ARegValue returns the value of a(i) of a certain lane
+func (wf *Wavefront) ARegValue(lane int, i int) uint32 {
+	offset := lane*1024 + i*4
+	return insts.BytesToUint32(wf.ARegFile[offset : offset+4])
+}
+
 // ReadReg returns the raw register value
 //
 //nolint:gocyclo
@@ -250,6 +271,9 @@ func (wf *Wavefront) ReadReg(reg *insts.Reg, regCount int, laneID int) []byte {
 	} else if reg.IsVReg() {
 		offset := laneID*256*4 + reg.RegIndex()*4
 		copy(value, wf.VRegFile[offset:offset+numBytes])
+	} else if reg.IsAReg() {
+		offset := laneID*256*4 + reg.RegIndex()*4
+		copy(value, wf.ARegFile[offset:offset+numBytes])
 	} else if reg.RegType == insts.SCC {
 		value[0] = wf.scc
 	} else if reg.RegType == insts.VCC {
@@ -266,6 +290,15 @@ func (wf *Wavefront) ReadReg(reg *insts.Reg, regCount int, laneID int) []byte {
 		copy(value, insts.Uint64ToBytes(wf.exec))
 	} else if reg.RegType == insts.M0 {
 		copy(value, insts.Uint32ToBytes(wf.M0))
+	} else if reg.RegType == insts.FlatSratchLo && regCount < 2 {
+		copy(value, insts.Uint32ToBytes(uint32(wf.FlatScratch)))
+	} else if reg.RegType == insts.FlatSratchHi && regCount < 2 {
+		copy(value, insts.Uint32ToBytes(uint32(wf.FlatScratch>>32)))
+	} else if reg.RegType == insts.FlatSratchLo ||
+		reg.RegType == insts.FlatSratch {
+		copy(value, insts.Uint64ToBytes(wf.FlatScratch))
+	} else if reg.IsApertureReg() {
+		copy(value, ReadApertureReg(reg, regCount))
 	} else if reg.Name == "vcclo" {
 		// Fallback for vcclo when RegType is not properly set
 		if regCount == 1 {
@@ -307,12 +340,15 @@ func (wf *Wavefront) WriteReg(
 	} else if reg.IsVReg() {
 		offset := laneID*256*4 + reg.RegIndex()*4
 		copy(wf.VRegFile[offset:offset+numBytes], data)
+	} else if reg.IsAReg() {
+		offset := laneID*256*4 + reg.RegIndex()*4
+		copy(wf.ARegFile[offset:offset+numBytes], data)
 	} else if reg.RegType == insts.SCC {
 		wf.scc = data[0]
 	} else if reg.RegType == insts.VCC {
-		wf.vcc = insts.BytesToUint64(data)
+		wf.SetVCC(insts.BytesToUint64(data))
 	} else if reg.RegType == insts.VCCLO && regCount == 2 {
-		wf.vcc = insts.BytesToUint64(data)
+		wf.SetVCC(insts.BytesToUint64(data))
 	} else if reg.RegType == insts.VCCLO && regCount == 1 {
 		wf.vcc &= uint64(0xFFFFFFFF00000000)
 		wf.vcc |= uint64(insts.BytesToUint32(data))
@@ -320,11 +356,20 @@ func (wf *Wavefront) WriteReg(
 		wf.vcc &= uint64(0xffffffff00000000)
 		wf.vcc |= uint64(insts.BytesToUint32(data)) << 32
 	} else if reg.RegType == insts.EXEC {
-		wf.exec = insts.BytesToUint64(data)
+		wf.SetEXEC(insts.BytesToUint64(data))
 	} else if reg.RegType == insts.EXECLO && regCount == 2 {
-		wf.exec = insts.BytesToUint64(data)
+		wf.SetEXEC(insts.BytesToUint64(data))
 	} else if reg.RegType == insts.M0 {
 		wf.M0 = insts.BytesToUint32(data)
+	} else if reg.RegType == insts.FlatSratchLo && regCount < 2 {
+		wf.FlatScratch &= uint64(0xffffffff00000000)
+		wf.FlatScratch |= uint64(insts.BytesToUint32(data))
+	} else if reg.RegType == insts.FlatSratchHi && regCount < 2 {
+		wf.FlatScratch &= uint64(0x00000000ffffffff)
+		wf.FlatScratch |= uint64(insts.BytesToUint32(data)) << 32
+	} else if reg.RegType == insts.FlatSratchLo ||
+		reg.RegType == insts.FlatSratch {
+		wf.FlatScratch = insts.BytesToUint64(data)
 	} else if reg.Name == "vcclo" {
 		// Fallback for vcclo when RegType is not properly set
 		if regCount <= 1 {
@@ -0,0 +1,60 @@
+package emu
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/mgpusim/v4/amd/insts"
+	"github.com/sarchlab/mgpusim/v4/amd/kernels"
+)
+
+var _ = Describe("Wave32 Wavefront", func() {
+	var (
+		alu *ALUImpl
+		wf  *Wavefront
+	)
+
+	BeforeEach(func() {
+		alu = NewALU(nil)
+		wf = NewWavefront(&kernels.Wavefront{WavefrontSize: 32})
+	})
+
+	It("should only use the lower 32 bits of EXEC", func() {
+		wf.SetEXEC(0xffffffffffffffff)
+		Expect(wf.EXEC()).To(Equal(uint64(0xffffffff)))
+
+		wf.WriteReg(insts.Regs[insts.EXECLO], 2, 0,
+			insts.Uint64ToBytes(0x1234567800000001))
+		Expect(wf.EXEC()).To(Equal(uint64(1)))
+	})
+
+	It("should not set the EXEC bits beyond the lanes with s_mov_b64", func() {
+		wf.inst = insts.NewInst()
+		wf.inst.FormatType = insts.SOP1
+		wf.inst.Opcode = 1 // s_mov_b64
+		wf.inst.Src0 = insts.NewIntOperand(193, -1)
+		wf.inst.Dst = insts.NewRegOperand(126, insts.EXECLO, 2)
+
+		alu.Run(wf)
+
+		Expect(wf.EXEC()).To(Equal(uint64(0xffffffff)))
+	})
+
+	It("should only write VCC_LO with v_cmp", func() {
+		wf.inst = insts.NewInst()
+		wf.inst.FormatType = insts.VOPC
+		wf.inst.Opcode = 0xC9 // v_cmp_lt_u32
+		wf.inst.Src0 = insts.NewVRegOperand(0, 0, 1)
+		wf.inst.Src1 = insts.NewVRegOperand(1, 1, 1)
+		wf.SetEXEC(0xffffffff)
+		wf.vcc = 0xabcd000000000000
+
+		for i := 0; i < 32; i++ {
+			wf.WriteReg(insts.VReg(0), 1, i, insts.Uint32ToBytes(uint32(i)))
+			wf.WriteReg(insts.VReg(1), 1, i, insts.Uint32ToBytes(16))
+		}
+
+		alu.Run(wf)
+
+		Expect(wf.VCC()).To(Equal(uint64(0xabcd00000000ffff)))
+	})
+})
@@ -0,0 +1,279 @@
+package insts
+
+import "math"
+
+// An AtomicOp is the read-modify-write operation that an atomic instruction
+// performs on a memory location.
+type AtomicOp int
+
+// The atomic operations.
+const (
+	AtomicOpSwap AtomicOp = iota
+	AtomicOpCmpSwap
+	AtomicOpAdd
+	AtomicOpSub
+	AtomicOpRSub
+	AtomicOpSMin
+	AtomicOpUMin
+	AtomicOpSMax
+	AtomicOpUMax
+	AtomicOpAnd
+	AtomicOpOr
+	AtomicOpXor
+	AtomicOpInc
+	AtomicOpDec
+	AtomicOpMskOr
+	AtomicOpAddF32
+	AtomicOpMinF32
+	AtomicOpMaxF32
+	AtomicOpAddF64
+	AtomicOpMinF64
+	AtomicOpMaxF64
+)
+
+var atomicOpNames = []string{
+	"swap", "cmpswap", "add", "sub", "rsub", "smin", "umin", "smax", "umax",
+	"and", "or", "xor", "inc", "dec", "mskor", "add_f32", "min_f32",
+	"max_f32", "add_f64", "min_f64", "max_f64",
+}
+
+func (op AtomicOp) String() string {
+	if op < 0 || int(op) >= len(atomicOpNames) {
+		return "unknown"
+	}
+
+	return atomicOpNames[op]
+}
+
+// Apply returns the value that the operation writes to the memory. The values
+// are byteSize (4 or 8) bytes wide and are stored in the low bits. For
+// cmpswap, cmp is the value compared with the memory; for mskor, cmp is the
+// mask of the bits to clear.
+//
+//nolint:gocyclo,funlen
+func (op AtomicOp) Apply(old, src, cmp uint64, byteSize int) uint64 {
+	mask := uint64(math.MaxUint64)
+	if byteSize == 4 {
+		mask = math.MaxUint32
+		old &= mask
+		src &= mask
+		cmp &= mask
+	}
+
+	var result uint64
+
+	switch op {
+	case AtomicOpSwap:
+		result = src
+	case AtomicOpCmpSwap:
+		result = old
+		if old == cmp {
+			result = src
+		}
+	case AtomicOpAdd:
+		result = old + src
+	case AtomicOpSub:
+		result = old - src
+	case AtomicOpRSub:
+		result = src - old
+	case AtomicOpSMin:
+		result = old
+		if signExtend(src, byteSize) < signExtend(old, byteSize) {
+			result = src
+		}
+	case AtomicOpUMin:
+		result = min(old, src)
+	case AtomicOpSMax:
+		result = old
+		if signExtend(src, byteSize) > signExtend(old, byteSize) {
+			result = src
+		}
+	case AtomicOpUMax:
+		result = max(old, src)
+	case AtomicOpAnd:
+		result = old & src
+	case AtomicOpOr:
+		result = old | src
+	case AtomicOpXor:
+		result = old ^ src
+	case AtomicOpInc:
+		result = old + 1
+		if old >= src {
+			result = 0
+		}
+	case AtomicOpDec:
+		result = old - 1
+		if old == 0 || old > src {
+			result = src
+		}
+	case AtomicOpMskOr:
+		result = (old &^ cmp) | src
+	case AtomicOpAddF32:
+		result = uint64(math.Float32bits(
+			math.Float32frombits(uint32(old)) +
+				math.Float32frombits(uint32(src))))
+	case AtomicOpMinF32:
+		result = uint64(math.Float32bits(float32(math.Min(
+			float64(math.Float32frombits(uint32(old))),
+			float64(math.Float32frombits(uint32(src)))))))
+	case AtomicOpMaxF32:
+		result = uint64(math.Float32bits(float32(math.Max(
+			float64(math.Float32frombits(uint32(old))),
+			float64(math.Float32frombits(uint32(src)))))))
+	case AtomicOpAddF64:
+		result = math.Float64bits(
+			math.Float64frombits(old) + math.Float64frombits(src))
+	case AtomicOpMinF64:
+		result = math.Float64bits(math.Min(
+			math.Float64frombits(old), math.Float64frombits(src)))
+	case AtomicOpMaxF64:
+		result = math.Float64bits(math.Max(
+			math.Float64frombits(old), math.Float64frombits(src)))
+	default:
+		panic("unknown atomic operation")
+	}
+
+	return result & mask
+}
+
+func signExtend(v uint64, byteSize int) int64 {
+	if byteSize == 4 {
+		return int64(int32(uint32(v)))
+	}
+
+	return int64(v)
+}
+
+// AtomicInfo describes how an atomic instruction accesses the memory.
+type AtomicInfo struct {
+	Op       AtomicOp
+	ByteSize int
+
+	// Return is true if the instruction writes the value of the memory before
+	// the operation to the destination registers.
+	Return bool
+}
+
+// AtomicInfo returns the atomic operation of a FLAT, MUBUF or DS instruction.
+// The second return value is false if the instruction is not atomic.
+func (i *Inst) AtomicInfo() (AtomicInfo, bool) {
+	switch i.FormatType {
+	case FLAT, MUBUF:
+		info, ok := flatAtomicInfo(i.Opcode)
+		info.Return = i.GlobalLevelCoherent
+		return info, ok
+	case DS:
+		return dsAtomicInfo(i.Opcode)
+	}
+
+	return AtomicInfo{}, false
+}
+
+var flatAtomicOps = []AtomicOp{
+	AtomicOpSwap, AtomicOpCmpSwap, AtomicOpAdd, AtomicOpSub, AtomicOpSMin,
+	AtomicOpUMin, AtomicOpSMax, AtomicOpUMax, AtomicOpAnd, AtomicOpOr,
+	AtomicOpXor, AtomicOpInc, AtomicOpDec,
+}
+
+func flatAtomicInfo(opcode Opcode) (AtomicInfo, bool) {
+	switch {
+	case opcode >= 64 && opcode <= 76:
+		return AtomicInfo{Op: flatAtomicOps[opcode-64], ByteSize: 4}, true
+	case opcode >= 96 && opcode <= 108:
+		return AtomicInfo{Op: flatAtomicOps[opcode-96], ByteSize: 8}, true
+	}
+
+	switch opcode {
+	case 77:
+		return AtomicInfo{Op: AtomicOpAddF32, ByteSize: 4}, true
+	case 79:
+		return AtomicInfo{Op: AtomicOpAddF64, ByteSize: 8}, true
+	case 80:
+		return AtomicInfo{Op: AtomicOpMinF64, ByteSize: 8}, true
+	case 81:
+		return AtomicInfo{Op: AtomicOpMaxF64, ByteSize: 8}, true
+	}
+
+	return AtomicInfo{}, false
+}
+
+// dsAtomicOps maps the opcodes of the 32-bit DS atomics without return to the
+// operations. The versions with return add 32 to the opcodes, and the 64-bit
+// versions add 64.
+var dsAtomicOps = map[Opcode]AtomicOp{
+	0:  AtomicOpAdd,
+	1:  AtomicOpSub,
+	2:  AtomicOpRSub,
+	3:  AtomicOpInc,
+	4:  AtomicOpDec,
+	5:  AtomicOpSMin,
+	6:  AtomicOpSMax,
+	7:  AtomicOpUMin,
+	8:  AtomicOpUMax,
+	9:  AtomicOpAnd,
+	10: AtomicOpOr,
+	11: AtomicOpXor,
+	12: AtomicOpMskOr,
+	13: AtomicOpSwap, // Only ds_wrxchg_rtn, as 13 is ds_write_b32.
+	16: AtomicOpCmpSwap,
+	17: AtomicOpCmpSwap,
+	18: AtomicOpMinF32,
+	19: AtomicOpMaxF32,
+	21: AtomicOpAddF32,
+}
+
+func dsAtomicInfo(opcode Opcode) (AtomicInfo, bool) {
+	info := AtomicInfo{ByteSize: 4}
+
+	base := opcode
+	if base >= 64 && base < 128 {
+		info.ByteSize = 8
+		base -= 64
+	}
+
+	if base >= 32 && base < 64 {
+		info.Return = true
+		base -= 32
+	}
+
+	if base == 13 && !info.Return {
+		return AtomicInfo{}, false
+	}
+
+	if info.ByteSize == 8 {
+		return ds64AtomicInfo(info, base)
+	}
+
+	op, found := dsAtomicOps[base]
+	if !found {
+		return AtomicInfo{}, false
+	}
+
+	info.Op = op
+
+	return info, true
+}
+
+// ds64AtomicInfo handles the 64-bit DS atomics, whose floating-point
+// operations do not follow the 32-bit opcodes.
+func ds64AtomicInfo(info AtomicInfo, base Opcode) (AtomicInfo, bool) {
+	switch base {
+	case 18:
+		info.Op = AtomicOpMinF64
+	case 19:
+		info.Op = AtomicOpMaxF64
+	case 21:
+		return AtomicInfo{}, false
+	case 28: // ds_add_f64 and ds_add_rtn_f64
+		info.Op = AtomicOpAddF64
+	default:
+		op, found := dsAtomicOps[base]
+		if !found {
+			return AtomicInfo{}, false
+		}
+
+		info.Op = op
+	}
+
+	return info, true
+}
@@ -0,0 +1,55 @@
+package insts_test
+
+import (
+	"math"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/mgpusim/v4/amd/insts"
+)
+
+var _ = Describe("AtomicOp", func() {
+	It("should wrap 32-bit results", func() {
+		Expect(insts.AtomicOpAdd.Apply(0xffffffff, 2, 0, 4)).
+			To(Equal(uint64(1)))
+		Expect(insts.AtomicOpAdd.Apply(0xffffffff, 2, 0, 8)).
+			To(Equal(uint64(0x100000001)))
+	})
+
+	It("should compare signed and unsigned values", func() {
+		Expect(insts.AtomicOpSMin.Apply(1, 0xffffffff, 0, 4)).
+			To(Equal(uint64(0xffffffff)))
+		Expect(insts.AtomicOpUMin.Apply(1, 0xffffffff, 0, 4)).
+			To(Equal(uint64(1)))
+		Expect(insts.AtomicOpSMax.Apply(1, 0xffffffff, 0, 8)).
+			To(Equal(uint64(0xffffffff)))
+	})
+
+	It("should swap only if the compare value matches", func() {
+		Expect(insts.AtomicOpCmpSwap.Apply(5, 9, 5, 4)).To(Equal(uint64(9)))
+		Expect(insts.AtomicOpCmpSwap.Apply(6, 9, 5, 4)).To(Equal(uint64(6)))
+	})
+
+	It("should wrap inc and dec at the source value", func() {
+		Expect(insts.AtomicOpInc.Apply(3, 4, 0, 4)).To(Equal(uint64(4)))
+		Expect(insts.AtomicOpInc.Apply(4, 4, 0, 4)).To(Equal(uint64(0)))
+		Expect(insts.AtomicOpDec.Apply(1, 4, 0, 4)).To(Equal(uint64(0)))
+		Expect(insts.AtomicOpDec.Apply(0, 4, 0, 4)).To(Equal(uint64(4)))
+		Expect(insts.AtomicOpDec.Apply(7, 4, 0, 4)).To(Equal(uint64(4)))
+	})
+
+	It("should clear the masked bits for mskor", func() {
+		Expect(insts.AtomicOpMskOr.Apply(0xff, 0x100, 0x0f, 4)).
+			To(Equal(uint64(0x1f0)))
+	})
+
+	It("should add floating-point values", func() {
+		result := insts.AtomicOpAddF32.Apply(
+			uint64(math.Float32bits(1.5)), uint64(math.Float32bits(2)), 0, 4)
+		Expect(math.Float32frombits(uint32(result))).To(Equal(float32(3.5)))
+
+		result = insts.AtomicOpAddF64.Apply(
+			math.Float64bits(1.5), math.Float64bits(2), 0, 8)
+		Expect(math.Float64frombits(result)).To(Equal(3.5))
+	})
+})
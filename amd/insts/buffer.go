@@ -0,0 +1,116 @@
+package insts
+
+// A BufferOpKind tells how a MUBUF or MTBUF instruction accesses the memory.
+type BufferOpKind int
+
+// The kinds of buffer instructions.
+const (
+	BufferOpLoad BufferOpKind = iota
+	BufferOpStore
+	BufferOpAtomic
+	BufferOpCacheControl
+)
+
+// BufferOp describes how a MUBUF or MTBUF instruction accesses the memory.
+type BufferOp struct {
+	Kind BufferOpKind
+
+	// NumComponents is the number of registers that the instruction loads or
+	// stores.
+	NumComponents int
+
+	// ComponentSize is the number of bytes that each component occupies in
+	// the memory. It is 0 for the format instructions, whose data format
+	// decides the layout of the memory.
+	ComponentSize int
+
+	// Signed is true if the loaded bytes or shorts are sign-extended.
+	Signed bool
+}
+
+// IsFormat returns true if the data format of the buffer resource or of the
+// instruction decides the layout of the memory.
+func (op BufferOp) IsFormat() bool {
+	return op.ComponentSize == 0
+}
+
+// BufferOp returns how a MUBUF or MTBUF instruction accesses the memory. The
+// second return value is false if the instruction is not a supported buffer
+// instruction.
+func (i *Inst) BufferOp() (BufferOp, bool) {
+	switch i.FormatType {
+	case MUBUF:
+		return mubufOp(i.Opcode)
+	case MTBUF:
+		return mtbufOp(i.Opcode)
+	}
+
+	return BufferOp{}, false
+}
+
+// FlatOp returns how a FLAT, global or scratch load, store or atomic
+// accesses the memory. These instructions share the opcodes of the MUBUF
+// instructions that are not format instructions.
+func (i *Inst) FlatOp() (BufferOp, bool) {
+	if i.FormatType != FLAT || i.Opcode < 16 {
+		return BufferOp{}, false
+	}
+
+	op, ok := mubufOp(i.Opcode)
+	if !ok || op.Kind == BufferOpCacheControl {
+		return BufferOp{}, false
+	}
+
+	return op, true
+}
+
+//nolint:gocyclo
+func mubufOp(opcode Opcode) (BufferOp, bool) {
+	switch {
+	case opcode <= 3:
+		return BufferOp{Kind: BufferOpLoad, NumComponents: int(opcode) + 1}, true
+	case opcode <= 7:
+		return BufferOp{Kind: BufferOpStore, NumComponents: int(opcode) - 3}, true
+	case opcode == 16 || opcode == 17:
+		return BufferOp{Kind: BufferOpLoad, NumComponents: 1,
+			ComponentSize: 1, Signed: opcode == 17}, true
+	case opcode == 18 || opcode == 19:
+		return BufferOp{Kind: BufferOpLoad, NumComponents: 1,
+			ComponentSize: 2, Signed: opcode == 19}, true
+	case opcode >= 20 && opcode <= 23:
+		return BufferOp{Kind: BufferOpLoad, NumComponents: int(opcode) - 19,
+			ComponentSize: 4}, true
+	case opcode == 24:
+		return BufferOp{Kind: BufferOpStore, NumComponents: 1,
+			ComponentSize: 1}, true
+	case opcode == 26:
+		return BufferOp{Kind: BufferOpStore, NumComponents: 1,
+			ComponentSize: 2}, true
+	case opcode >= 28 && opcode <= 31:
+		return BufferOp{Kind: BufferOpStore, NumComponents: int(opcode) - 27,
+			ComponentSize: 4}, true
+	case opcode == 40 || opcode == 41 || opcode == 62 || opcode == 63:
+		return BufferOp{Kind: BufferOpCacheControl}, true
+	}
+
+	if atomic, ok := flatAtomicInfo(opcode); ok {
+		return BufferOp{
+			Kind:          BufferOpAtomic,
+			NumComponents: atomic.ByteSize / 4,
+			ComponentSize: 4,
+		}, true
+	}
+
+	return BufferOp{}, false
+}
+
+func mtbufOp(opcode Opcode) (BufferOp, bool) {
+	switch {
+	case opcode <= 3:
+		return BufferOp{Kind: BufferOpLoad, NumComponents: int(opcode) + 1}, true
+	case opcode <= 7:
+		return BufferOp{Kind: BufferOpStore, NumComponents: int(opcode) - 3}, true
+	}
+
+	return BufferOp{}, false
+}
@@ -204,32 +204,104 @@ func (d *Disassembler) initializeDecodeTable() {
 	d.addInstType(&InstType{"flat_store_dwordx2", 29, FormatTable[FLAT], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
 	d.addInstType(&InstType{"flat_store_dwordx3", 30, FormatTable[FLAT], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
 	d.addInstType(&InstType{"flat_store_dwordx4", 31, FormatTable[FLAT], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
-	d.addInstType(&InstType{"flat_atomic_swap", 48, FormatTable[FLAT], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
-	d.addInstType(&InstType{"flat_atomic_cmpswap", 49, FormatTable[FLAT], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
-	d.addInstType(&InstType{"flat_atomic_add", 50, FormatTable[FLAT], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
-	d.addInstType(&InstType{"flat_atomic_sub", 51, FormatTable[FLAT], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
-	d.addInstType(&InstType{"flat_atomic_smin", 53, FormatTable[FLAT], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
-	d.addInstType(&InstType{"flat_atomic_umin", 54, FormatTable[FLAT], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
-	d.addInstType(&InstType{"flat_atomic_smax", 55, FormatTable[FLAT], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
-	d.addInstType(&InstType{"flag_atomic_umax", 56, FormatTable[FLAT], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
-	d.addInstType(&InstType{"flat_atomic_and", 57, FormatTable[FLAT], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
-	d.addInstType(&InstType{"flat_atomic_or", 58, FormatTable[FLAT], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
-	d.addInstType(&InstType{"flat_atomic_xor", 59, FormatTable[FLAT], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
-	d.addInstType(&InstType{"flat_atomic_inc", 60, FormatTable[FLAT], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
-	d.addInstType(&InstType{"flat_atomic_dec", 61, FormatTable[FLAT], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
-	d.addInstType(&InstType{"flat_atomic_swap_x2", 80, FormatTable[FLAT], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
-	d.addInstType(&InstType{"flat_atomic_cmpswap_x2", 81, FormatTable[FLAT], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
-	d.addInstType(&InstType{"flat_atomic_add_x2", 82, FormatTable[FLAT], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
-	d.addInstType(&InstType{"flat_atomic_sub_x2", 83, FormatTable[FLAT], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
-	d.addInstType(&InstType{"flat_atomic_smin_x2", 85, FormatTable[FLAT], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
-	d.addInstType(&InstType{"flat_atomic_umin_x2", 86, FormatTable[FLAT], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
-	d.addInstType(&InstType{"flat_atomic_smax_x2", 87, FormatTable[FLAT], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
-	d.addInstType(&InstType{"flat_atomic_umax_x2", 88, FormatTable[FLAT], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
-	d.addInstType(&InstType{"flat_atomic_and_x2", 89, FormatTable[FLAT], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
-	d.addInstType(&InstType{"flat_atomic_or_x2", 90, FormatTable[FLAT], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
-	d.addInstType(&InstType{"flat_atomic_xor_x2", 91, FormatTable[FLAT], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
-	d.addInstType(&InstType{"flat_atomic_inc_x2", 92, FormatTable[FLAT], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
-	d.addInstType(&InstType{"flat_atomic_dec_x2", 93, FormatTable[FLAT], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"flat_atomic_swap", 64, FormatTable[FLAT], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"flat_atomic_cmpswap", 65, FormatTable[FLAT], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"flat_atomic_add", 66, FormatTable[FLAT], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"flat_atomic_sub", 67, FormatTable[FLAT], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"flat_atomic_smin", 68, FormatTable[FLAT], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"flat_atomic_umin", 69, FormatTable[FLAT], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"flat_atomic_smax", 70, FormatTable[FLAT], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"flat_atomic_umax", 71, FormatTable[FLAT], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"flat_atomic_and", 72, FormatTable[FLAT], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"flat_atomic_or", 73, FormatTable[FLAT], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"flat_atomic_xor", 74, FormatTable[FLAT], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"flat_atomic_inc", 75, FormatTable[FLAT], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"flat_atomic_dec", 76, FormatTable[FLAT], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"flat_atomic_add_f32", 77, FormatTable[FLAT], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"flat_atomic_add_f64", 79, FormatTable[FLAT], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"flat_atomic_min_f64", 80, FormatTable[FLAT], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"flat_atomic_max_f64", 81, FormatTable[FLAT], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"flat_atomic_swap_x2", 96, FormatTable[FLAT], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"flat_atomic_cmpswap_x2", 97, FormatTable[FLAT], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"flat_atomic_add_x2", 98, FormatTable[FLAT], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"flat_atomic_sub_x2", 99, FormatTable[FLAT], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"flat_atomic_smin_x2", 100, FormatTable[FLAT], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"flat_atomic_umin_x2", 101, FormatTable[FLAT], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"flat_atomic_smax_x2", 102, FormatTable[FLAT], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"flat_atomic_umax_x2", 103, FormatTable[FLAT], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"flat_atomic_and_x2", 104, FormatTable[FLAT], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"flat_atomic_or_x2", 105, FormatTable[FLAT], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"flat_atomic_xor_x2", 106, FormatTable[FLAT], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"flat_atomic_inc_x2", 107, FormatTable[FLAT], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"flat_atomic_dec_x2", 108, FormatTable[FLAT], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+
+	// MUBUF instructions
+	d.addInstType(&InstType{"buffer_load_format_x", 0, FormatTable[MUBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"buffer_load_format_xy", 1, FormatTable[MUBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"buffer_load_format_xyz", 2, FormatTable[MUBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"buffer_load_format_xyzw", 3, FormatTable[MUBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"buffer_store_format_x", 4, FormatTable[MUBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"buffer_store_format_xy", 5, FormatTable[MUBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"buffer_store_format_xyz", 6, FormatTable[MUBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"buffer_store_format_xyzw", 7, FormatTable[MUBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"buffer_load_ubyte", 16, FormatTable[MUBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"buffer_load_sbyte", 17, FormatTable[MUBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"buffer_load_ushort", 18, FormatTable[MUBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"buffer_load_sshort", 19, FormatTable[MUBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"buffer_load_dword", 20, FormatTable[MUBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"buffer_load_dwordx2", 21, FormatTable[MUBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"buffer_load_dwordx3", 22, FormatTable[MUBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"buffer_load_dwordx4", 23, FormatTable[MUBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"buffer_store_byte", 24, FormatTable[MUBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"buffer_store_short", 26, FormatTable[MUBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"buffer_store_dword", 28, FormatTable[MUBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"buffer_store_dwordx2", 29, FormatTable[MUBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"buffer_store_dwordx3", 30, FormatTable[MUBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"buffer_store_dwordx4", 31, FormatTable[MUBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"buffer_wbl2", 40, FormatTable[MUBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"buffer_invl2", 41, FormatTable[MUBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"buffer_wbinvl1", 62, FormatTable[MUBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"buffer_wbinvl1_vol", 63, FormatTable[MUBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"buffer_atomic_swap", 64, FormatTable[MUBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"buffer_atomic_cmpswap", 65, FormatTable[MUBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"buffer_atomic_add", 66, FormatTable[MUBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"buffer_atomic_sub", 67, FormatTable[MUBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"buffer_atomic_smin", 68, FormatTable[MUBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"buffer_atomic_umin", 69, FormatTable[MUBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"buffer_atomic_smax", 70, FormatTable[MUBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"buffer_atomic_umax", 71, FormatTable[MUBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"buffer_atomic_and", 72, FormatTable[MUBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"buffer_atomic_or", 73, FormatTable[MUBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"buffer_atomic_xor", 74, FormatTable[MUBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"buffer_atomic_inc", 75, FormatTable[MUBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"buffer_atomic_dec", 76, FormatTable[MUBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"buffer_atomic_add_f32", 77, FormatTable[MUBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"buffer_atomic_add_f64", 79, FormatTable[MUBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"buffer_atomic_min_f64", 80, FormatTable[MUBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"buffer_atomic_max_f64", 81, FormatTable[MUBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"buffer_atomic_swap_x2", 96, FormatTable[MUBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"buffer_atomic_cmpswap_x2", 97, FormatTable[MUBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"buffer_atomic_add_x2", 98, FormatTable[MUBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"buffer_atomic_sub_x2", 99, FormatTable[MUBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"buffer_atomic_smin_x2", 100, FormatTable[MUBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"buffer_atomic_umin_x2", 101, FormatTable[MUBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"buffer_atomic_smax_x2", 102, FormatTable[MUBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"buffer_atomic_umax_x2", 103, FormatTable[MUBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"buffer_atomic_and_x2", 104, FormatTable[MUBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"buffer_atomic_or_x2", 105, FormatTable[MUBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"buffer_atomic_xor_x2", 106, FormatTable[MUBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"buffer_atomic_inc_x2", 107, FormatTable[MUBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"buffer_atomic_dec_x2", 108, FormatTable[MUBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+
+	// MTBUF instructions
+	d.addInstType(&InstType{"tbuffer_load_format_x", 0, FormatTable[MTBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"tbuffer_load_format_xy", 1, FormatTable[MTBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"tbuffer_load_format_xyz", 2, FormatTable[MTBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"tbuffer_load_format_xyzw", 3, FormatTable[MTBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"tbuffer_store_format_x", 4, FormatTable[MTBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"tbuffer_store_format_xy", 5, FormatTable[MTBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"tbuffer_store_format_xyz", 6, FormatTable[MTBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"tbuffer_store_format_xyzw", 7, FormatTable[MTBUF], 0, ExeUnitVMem, 32, 32, 32, 0, 0})
 
 	// SMEM instructions
 	d.addInstType(&InstType{"s_load_dword", 0, FormatTable[SMEM], 0, ExeUnitScalar, 32, 32, 32, 0, 0})
@@ -273,8 +345,8 @@ func (d *Disassembler) initializeDecodeTable() {
 	d.addInstType(&InstType{"s_sethalt", 13, FormatTable[SOPP], 0, ExeUnitSpecial, 32, 32, 32, 0, 0})
 	d.addInstType(&InstType{"s_sleep", 14, FormatTable[SOPP], 0, ExeUnitSpecial, 32, 32, 32, 0, 0})
 	d.addInstType(&InstType{"s_setprio", 15, FormatTable[SOPP], 0, ExeUnitSpecial, 32, 32, 32, 0, 0})
-	d.addInstType(&InstType{"s_sendmsg", 16, FormatTable[SOPP], 0, ExeUnitBranch, 32, 32, 32, 0, 0})
-	d.addInstType(&InstType{"s_sendmsghalt", 17, FormatTable[SOPP], 0, ExeUnitBranch, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"s_sendmsg", 16, FormatTable[SOPP], 0, ExeUnitSpecial, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"s_sendmsghalt", 17, FormatTable[SOPP], 0, ExeUnitSpecial, 32, 32, 32, 0, 0})
 	d.addInstType(&InstType{"s_trap", 18, FormatTable[SOPP], 0, ExeUnitSpecial, 32, 32, 32, 0, 0})
 	d.addInstType(&InstType{"s_icache_inv", 19, FormatTable[SOPP], 0, ExeUnitSpecial, 32, 32, 32, 0, 0})
 	d.addInstType(&InstType{"s_incperflevel", 20, FormatTable[SOPP], 0, ExeUnitSpecial, 32, 32, 32, 0, 0})
@@ -852,9 +924,6 @@ func (d *Disassembler) initializeDecodeTable() {
 	d.addInstType(&InstType{"v_ldexp_f64", 644, FormatTable[VOP3a], 0, ExeUnitVALU, 32, 32, 32, 32, 0})
 	d.addInstType(&InstType{"v_mul_lo_u32", 645, FormatTable[VOP3a], 0, ExeUnitVALU, 32, 32, 32, 0, 0})
 	d.addInstType(&InstType{"v_mul_hi_u32", 646, FormatTable[VOP3a], 0, ExeUnitVALU, 32, 32, 32, 0, 0})
-	d.addInstType(&InstType{"v_pk_fma_f32", 944, FormatTable[VOP3a], 0, ExeUnitVALU, 64, 64, 64, 64, 0})
-	d.addInstType(&InstType{"v_pk_mul_f32", 945, FormatTable[VOP3a], 0, ExeUnitVALU, 64, 64, 64, 0, 0})
-	d.addInstType(&InstType{"v_pk_add_f32", 946, FormatTable[VOP3a], 0, ExeUnitVALU, 64, 64, 64, 0, 0})
 	d.addInstType(&InstType{"v_mul_hi_i32", 647, FormatTable[VOP3a], 0, ExeUnitVALU, 32, 32, 32, 32, 0})
 	d.addInstType(&InstType{"v_ldexp_f32", 648, FormatTable[VOP3a], 0, ExeUnitVALU, 32, 32, 32, 32, 0})
 	d.addInstType(&InstType{"v_readlane_b32", 649, FormatTable[VOP3a], 0, ExeUnitVALU, 32, 32, 32, 32, 0})
@@ -880,6 +949,60 @@ func (d *Disassembler) initializeDecodeTable() {
 	d.addInstType(&InstType{"v_add3_u32", 511, FormatTable[VOP3a], 0, ExeUnitVALU, 32, 32, 32, 32, 0})
 	d.addInstType(&InstType{"v_lshl_add_u64", 520, FormatTable[VOP3a], 0, ExeUnitVALU, 64, 64, 32, 64, 0})
 
+	// CDNA3 VOP3P packed math instructions
+	d.addInstType(&InstType{"v_pk_mad_i16", 0, FormatTable[VOP3P], 0, ExeUnitVALU, 32, 32, 32, 32, 0})
+	d.addInstType(&InstType{"v_pk_mul_lo_u16", 1, FormatTable[VOP3P], 0, ExeUnitVALU, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"v_pk_add_i16", 2, FormatTable[VOP3P], 0, ExeUnitVALU, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"v_pk_sub_i16", 3, FormatTable[VOP3P], 0, ExeUnitVALU, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"v_pk_lshlrev_b16", 4, FormatTable[VOP3P], 0, ExeUnitVALU, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"v_pk_lshrrev_b16", 5, FormatTable[VOP3P], 0, ExeUnitVALU, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"v_pk_ashrrev_i16", 6, FormatTable[VOP3P], 0, ExeUnitVALU, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"v_pk_max_i16", 7, FormatTable[VOP3P], 0, ExeUnitVALU, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"v_pk_min_i16", 8, FormatTable[VOP3P], 0, ExeUnitVALU, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"v_pk_mad_u16", 9, FormatTable[VOP3P], 0, ExeUnitVALU, 32, 32, 32, 32, 0})
+	d.addInstType(&InstType{"v_pk_add_u16", 10, FormatTable[VOP3P], 0, ExeUnitVALU, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"v_pk_sub_u16", 11, FormatTable[VOP3P], 0, ExeUnitVALU, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"v_pk_max_u16", 12, FormatTable[VOP3P], 0, ExeUnitVALU, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"v_pk_min_u16", 13, FormatTable[VOP3P], 0, ExeUnitVALU, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"v_pk_fma_f16", 14, FormatTable[VOP3P], 0, ExeUnitVALU, 32, 32, 32, 32, 0})
+	d.addInstType(&InstType{"v_pk_add_f16", 15, FormatTable[VOP3P], 0, ExeUnitVALU, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"v_pk_mul_f16", 16, FormatTable[VOP3P], 0, ExeUnitVALU, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"v_pk_min_f16", 17, FormatTable[VOP3P], 0, ExeUnitVALU, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"v_pk_max_f16", 18, FormatTable[VOP3P], 0, ExeUnitVALU, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"v_dot2_f32_f16", 35, FormatTable[VOP3P], 0, ExeUnitVALU, 32, 32, 32, 32, 0})
+	d.addInstType(&InstType{"v_dot2_i32_i16", 38, FormatTable[VOP3P], 0, ExeUnitVALU, 32, 32, 32, 32, 0})
+	d.addInstType(&InstType{"v_dot2_u32_u16", 39, FormatTable[VOP3P], 0, ExeUnitVALU, 32, 32, 32, 32, 0})
+	d.addInstType(&InstType{"v_dot4_i32_i8", 40, FormatTable[VOP3P], 0, ExeUnitVALU, 32, 32, 32, 32, 0})
+	d.addInstType(&InstType{"v_dot4_u32_u8", 41, FormatTable[VOP3P], 0, ExeUnitVALU, 32, 32, 32, 32, 0})
+	d.addInstType(&InstType{"v_pk_fma_f32", 48, FormatTable[VOP3P], 0, ExeUnitVALU, 64, 64, 64, 64, 0})
+	d.addInstType(&InstType{"v_pk_mul_f32", 49, FormatTable[VOP3P], 0, ExeUnitVALU, 64, 64, 64, 0, 0})
+	d.addInstType(&InstType{"v_pk_add_f32", 50, FormatTable[VOP3P], 0, ExeUnitVALU, 64, 64, 64, 0, 0})
+	d.addInstType(&InstType{"v_pk_mov_b32", 51, FormatTable[VOP3P], 0, ExeUnitVALU, 64, 64, 64, 0, 0})
+
+	// CDNA3 VOP3P-MAI (matrix core) instructions
+	d.addInstType(&InstType{"v_mfma_f32_32x32x1_2b_f32", 64, FormatTable[VOP3P], 0, ExeUnitMatrix, 1024, 32, 32, 1024, 0})
+	d.addInstType(&InstType{"v_mfma_f32_16x16x1_4b_f32", 65, FormatTable[VOP3P], 0, ExeUnitMatrix, 512, 32, 32, 512, 0})
+	d.addInstType(&InstType{"v_mfma_f32_4x4x1_16b_f32", 66, FormatTable[VOP3P], 0, ExeUnitMatrix, 128, 32, 32, 128, 0})
+	d.addInstType(&InstType{"v_mfma_f32_32x32x2_f32", 68, FormatTable[VOP3P], 0, ExeUnitMatrix, 512, 32, 32, 512, 0})
+	d.addInstType(&InstType{"v_mfma_f32_16x16x4_f32", 69, FormatTable[VOP3P], 0, ExeUnitMatrix, 128, 32, 32, 128, 0})
+	d.addInstType(&InstType{"v_mfma_f32_32x32x4_2b_f16", 72, FormatTable[VOP3P], 0, ExeUnitMatrix, 1024, 64, 64, 1024, 0})
+	d.addInstType(&InstType{"v_mfma_f32_16x16x4_4b_f16", 73, FormatTable[VOP3P], 0, ExeUnitMatrix, 512, 64, 64, 512, 0})
+	d.addInstType(&InstType{"v_mfma_f32_4x4x4_16b_f16", 74, FormatTable[VOP3P], 0, ExeUnitMatrix, 128, 64, 64, 128, 0})
+	d.addInstType(&InstType{"v_mfma_f32_32x32x8_f16", 76, FormatTable[VOP3P], 0, ExeUnitMatrix, 512, 64, 64, 512, 0})
+	d.addInstType(&InstType{"v_mfma_f32_16x16x16_f16", 77, FormatTable[VOP3P], 0, ExeUnitMatrix, 128, 64, 64, 128, 0})
+	d.addInstType(&InstType{"v_mfma_i32_32x32x4_2b_i8", 80, FormatTable[VOP3P], 0, ExeUnitMatrix, 1024, 32, 32, 1024, 0})
+	d.addInstType(&InstType{"v_mfma_i32_16x16x4_4b_i8", 81, FormatTable[VOP3P], 0, ExeUnitMatrix, 512, 32, 32, 512, 0})
+	d.addInstType(&InstType{"v_mfma_i32_4x4x4_16b_i8", 82, FormatTable[VOP3P], 0, ExeUnitMatrix, 128, 32, 32, 128, 0})
+	d.addInstType(&InstType{"v_mfma_i32_32x32x16_i8", 86, FormatTable[VOP3P], 0, ExeUnitMatrix, 512, 64, 64, 512, 0})
+	d.addInstType(&InstType{"v_mfma_i32_16x16x32_i8", 87, FormatTable[VOP3P], 0, ExeUnitMatrix, 128, 64, 64, 128, 0})
+	d.addInstType(&InstType{"v_accvgpr_read_b32", 88, FormatTable[VOP3P], 0, ExeUnitVALU, 32, 32, 0, 0, 0})
+	d.addInstType(&InstType{"v_accvgpr_write_b32", 89, FormatTable[VOP3P], 0, ExeUnitVALU, 32, 32, 0, 0, 0})
+	d.addInstType(&InstType{"v_mfma_f32_32x32x4_2b_bf16", 99, FormatTable[VOP3P], 0, ExeUnitMatrix, 1024, 64, 64, 1024, 0})
+	d.addInstType(&InstType{"v_mfma_f32_16x16x4_4b_bf16", 100, FormatTable[VOP3P], 0, ExeUnitMatrix, 512, 64, 64, 512, 0})
+	d.addInstType(&InstType{"v_mfma_f32_4x4x4_16b_bf16", 101, FormatTable[VOP3P], 0, ExeUnitMatrix, 128, 64, 64, 128, 0})
+	d.addInstType(&InstType{"v_mfma_f32_32x32x8_bf16", 102, FormatTable[VOP3P], 0, ExeUnitMatrix, 512, 64, 64, 512, 0})
+	d.addInstType(&InstType{"v_mfma_f32_16x16x16_bf16", 103, FormatTable[VOP3P], 0, ExeUnitMatrix, 128, 64, 64, 128, 0})
+
 	// SOP1 Instructions
 	d.addInstType(&InstType{"s_mov_b32", 0, FormatTable[SOP1], 0, ExeUnitScalar, 32, 32, 0, 0, 0})
 	d.addInstType(&InstType{"s_mov_b64", 1, FormatTable[SOP1], 0, ExeUnitScalar, 64, 64, 0, 0, 0})
@@ -931,51 +1054,52 @@ func (d *Disassembler) initializeDecodeTable() {
 	d.addInstType(&InstType{"s_abs_i32", 48, FormatTable[SOP1], 0, ExeUnitScalar, 32, 32, 0, 0, 0})
 	d.addInstType(&InstType{"s_set_gpr_idx_idx", 49, FormatTable[SOP1], 0, ExeUnitScalar, 32, 32, 0, 0, 0})
 
-	d.addInstType(&InstType{"ds_add_u32", 0, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_sub_u32", 1, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_rsub_u32", 2, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_inc_u32", 3, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_dec_u32", 4, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_min_i32", 5, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_max_i32", 6, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_min_u32", 7, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_max_u32", 8, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_and_b32", 9, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_or_b32", 10, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_xor_b32", 11, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_mskor_b32", 12, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
+	d.addInstType(&InstType{"ds_add_u32", 0, FormatTable[DS], 0, ExeUnitLDS, 0, 32, 0, 0, 0})
+	d.addInstType(&InstType{"ds_sub_u32", 1, FormatTable[DS], 0, ExeUnitLDS, 0, 32, 0, 0, 0})
+	d.addInstType(&InstType{"ds_rsub_u32", 2, FormatTable[DS], 0, ExeUnitLDS, 0, 32, 0, 0, 0})
+	d.addInstType(&InstType{"ds_inc_u32", 3, FormatTable[DS], 0, ExeUnitLDS, 0, 32, 0, 0, 0})
+	d.addInstType(&InstType{"ds_dec_u32", 4, FormatTable[DS], 0, ExeUnitLDS, 0, 32, 0, 0, 0})
+	d.addInstType(&InstType{"ds_min_i32", 5, FormatTable[DS], 0, ExeUnitLDS, 0, 32, 0, 0, 0})
+	d.addInstType(&InstType{"ds_max_i32", 6, FormatTable[DS], 0, ExeUnitLDS, 0, 32, 0, 0, 0})
+	d.addInstType(&InstType{"ds_min_u32", 7, FormatTable[DS], 0, ExeUnitLDS, 0, 32, 0, 0, 0})
+	d.addInstType(&InstType{"ds_max_u32", 8, FormatTable[DS], 0, ExeUnitLDS, 0, 32, 0, 0, 0})
+	d.addInstType(&InstType{"ds_and_b32", 9, FormatTable[DS], 0, ExeUnitLDS, 0, 32, 0, 0, 0})
+	d.addInstType(&InstType{"ds_or_b32", 10, FormatTable[DS], 0, ExeUnitLDS, 0, 32, 0, 0, 0})
+	d.addInstType(&InstType{"ds_xor_b32", 11, FormatTable[DS], 0, ExeUnitLDS, 0, 32, 0, 0, 0})
+	d.addInstType(&InstType{"ds_mskor_b32", 12, FormatTable[DS], 0, ExeUnitLDS, 0, 32, 32, 0, 0})
 	d.addInstType(&InstType{"ds_write_b32", 13, FormatTable[DS], 0, ExeUnitLDS, 0, 32, 0, 0, 0})
 	d.addInstType(&InstType{"ds_write2_b32", 14, FormatTable[DS], 0, ExeUnitLDS, 0, 32, 32, 0, 0})
 	d.addInstType(&InstType{"ds_write2st64_b32", 15, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_cmpst_b32", 16, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_cmpst_f32", 17, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_min_f32", 18, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_max_f32", 19, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
+	d.addInstType(&InstType{"ds_cmpst_b32", 16, FormatTable[DS], 0, ExeUnitLDS, 0, 32, 32, 0, 0})
+	d.addInstType(&InstType{"ds_cmpst_f32", 17, FormatTable[DS], 0, ExeUnitLDS, 0, 32, 32, 0, 0})
+	d.addInstType(&InstType{"ds_min_f32", 18, FormatTable[DS], 0, ExeUnitLDS, 0, 32, 0, 0, 0})
+	d.addInstType(&InstType{"ds_max_f32", 19, FormatTable[DS], 0, ExeUnitLDS, 0, 32, 0, 0, 0})
 	d.addInstType(&InstType{"ds_nop ", 20, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_add_f32", 21, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
+	d.addInstType(&InstType{"ds_add_f32", 21, FormatTable[DS], 0, ExeUnitLDS, 0, 32, 0, 0, 0})
 	d.addInstType(&InstType{"ds_write_b8", 30, FormatTable[DS], 0, ExeUnitLDS, 0, 8, 0, 0, 0})
 	d.addInstType(&InstType{"ds_write_b16", 31, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_add_rtn_u32", 32, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_sub_rtn_u32", 33, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_rsub_rtn_u32", 34, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_inc_rtn_u32", 35, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_dec_rtn_u32", 36, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_min_rtn_i32", 37, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_max_rtn_i32", 38, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_min_rtn_u32", 39, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_max_rtn_u32", 40, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_and_rtn_b32", 41, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_or_rtn_b32", 42, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_xor_rtn_b32", 43, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_mskor_rtn_b32", 44, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_wrxchg_rtn_b32", 45, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
+	d.addInstType(&InstType{"ds_add_rtn_u32", 32, FormatTable[DS], 0, ExeUnitLDS, 32, 32, 0, 0, 0})
+	d.addInstType(&InstType{"ds_sub_rtn_u32", 33, FormatTable[DS], 0, ExeUnitLDS, 32, 32, 0, 0, 0})
+	d.addInstType(&InstType{"ds_rsub_rtn_u32", 34, FormatTable[DS], 0, ExeUnitLDS, 32, 32, 0, 0, 0})
+	d.addInstType(&InstType{"ds_inc_rtn_u32", 35, FormatTable[DS], 0, ExeUnitLDS, 32, 32, 0, 0, 0})
+	d.addInstType(&InstType{"ds_dec_rtn_u32", 36, FormatTable[DS], 0, ExeUnitLDS, 32, 32, 0, 0, 0})
+	d.addInstType(&InstType{"ds_min_rtn_i32", 37, FormatTable[DS], 0, ExeUnitLDS, 32, 32, 0, 0, 0})
+	d.addInstType(&InstType{"ds_max_rtn_i32", 38, FormatTable[DS], 0, ExeUnitLDS, 32, 32, 0, 0, 0})
+	d.addInstType(&InstType{"ds_min_rtn_u32", 39, FormatTable[DS], 0, ExeUnitLDS, 32, 32, 0, 0, 0})
+	d.addInstType(&InstType{"ds_max_rtn_u32", 40, FormatTable[DS], 0, ExeUnitLDS, 32, 32, 0, 0, 0})
+	d.addInstType(&InstType{"ds_and_rtn_b32", 41, FormatTable[DS], 0, ExeUnitLDS, 32, 32, 0, 0, 0})
+	d.addInstType(&InstType{"ds_or_rtn_b32", 42, FormatTable[DS], 0, ExeUnitLDS, 32, 32, 0, 0, 0})
+	d.addInstType(&InstType{"ds_xor_rtn_b32", 43, FormatTable[DS], 0, ExeUnitLDS, 32, 32, 0, 0, 0})
+	d.addInstType(&InstType{"ds_mskor_rtn_b32", 44, FormatTable[DS], 0, ExeUnitLDS, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"ds_wrxchg_rtn_b32", 45, FormatTable[DS], 0, ExeUnitLDS, 32, 32, 0, 0, 0})
 	d.addInstType(&InstType{"ds_wrxchg2_rtn_b32", 46, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
 	d.addInstType(&InstType{"ds_wrxchg2st64_rtn_b32", 47, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_cmpst_rtn_b32", 48, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_cmpst_rtn_f32", 49, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_min_rtn_f32", 50, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_max_rtn_f32", 51, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
+	d.addInstType(&InstType{"ds_cmpst_rtn_b32", 48, FormatTable[DS], 0, ExeUnitLDS, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"ds_cmpst_rtn_f32", 49, FormatTable[DS], 0, ExeUnitLDS, 32, 32, 32, 0, 0})
+	d.addInstType(&InstType{"ds_min_rtn_f32", 50, FormatTable[DS], 0, ExeUnitLDS, 32, 32, 0, 0, 0})
+	d.addInstType(&InstType{"ds_max_rtn_f32", 51, FormatTable[DS], 0, ExeUnitLDS, 32, 32, 0, 0, 0})
 	d.addInstType(&InstType{"ds_wrap_rtn_b32", 52, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
+	d.addInstType(&InstType{"ds_add_rtn_f32", 53, FormatTable[DS], 0, ExeUnitLDS, 32, 32, 0, 0, 0})
 	d.addInstType(&InstType{"ds_read_b32", 54, FormatTable[DS], 0, ExeUnitLDS, 32, 0, 0, 0, 0})
 	d.addInstType(&InstType{"ds_read2_b32", 55, FormatTable[DS], 0, ExeUnitLDS, 64, 0, 0, 0, 0})
 	d.addInstType(&InstType{"ds_read2st64_b32", 56, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
@@ -986,49 +1110,51 @@ func (d *Disassembler) initializeDecodeTable() {
 	d.addInstType(&InstType{"ds_swizzle_b32", 61, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
 	d.addInstType(&InstType{"ds_permute_b32", 62, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
 	d.addInstType(&InstType{"ds_bpermute_b32", 63, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_add_u64", 64, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_sub_u64", 65, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_rsub_u64", 66, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_inc_u64", 67, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_dec_u64", 68, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_min_i64", 69, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_max_i64", 70, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_min_u64", 71, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_max_u64", 72, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_and_b64", 73, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_or_b64", 74, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_xor_b64", 75, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_mskor_b64", 76, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
+	d.addInstType(&InstType{"ds_add_u64", 64, FormatTable[DS], 0, ExeUnitLDS, 0, 64, 0, 0, 0})
+	d.addInstType(&InstType{"ds_sub_u64", 65, FormatTable[DS], 0, ExeUnitLDS, 0, 64, 0, 0, 0})
+	d.addInstType(&InstType{"ds_rsub_u64", 66, FormatTable[DS], 0, ExeUnitLDS, 0, 64, 0, 0, 0})
+	d.addInstType(&InstType{"ds_inc_u64", 67, FormatTable[DS], 0, ExeUnitLDS, 0, 64, 0, 0, 0})
+	d.addInstType(&InstType{"ds_dec_u64", 68, FormatTable[DS], 0, ExeUnitLDS, 0, 64, 0, 0, 0})
+	d.addInstType(&InstType{"ds_min_i64", 69, FormatTable[DS], 0, ExeUnitLDS, 0, 64, 0, 0, 0})
+	d.addInstType(&InstType{"ds_max_i64", 70, FormatTable[DS], 0, ExeUnitLDS, 0, 64, 0, 0, 0})
+	d.addInstType(&InstType{"ds_min_u64", 71, FormatTable[DS], 0, ExeUnitLDS, 0, 64, 0, 0, 0})
+	d.addInstType(&InstType{"ds_max_u64", 72, FormatTable[DS], 0, ExeUnitLDS, 0, 64, 0, 0, 0})
+	d.addInstType(&InstType{"ds_and_b64", 73, FormatTable[DS], 0, ExeUnitLDS, 0, 64, 0, 0, 0})
+	d.addInstType(&InstType{"ds_or_b64", 74, FormatTable[DS], 0, ExeUnitLDS, 0, 64, 0, 0, 0})
+	d.addInstType(&InstType{"ds_xor_b64", 75, FormatTable[DS], 0, ExeUnitLDS, 0, 64, 0, 0, 0})
+	d.addInstType(&InstType{"ds_mskor_b64", 76, FormatTable[DS], 0, ExeUnitLDS, 0, 64, 64, 0, 0})
 	d.addInstType(&InstType{"ds_write_b64", 77, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
 	d.addInstType(&InstType{"ds_write2_b64", 78, FormatTable[DS], 0, ExeUnitLDS, 0, 64, 64, 0, 0})
 	d.addInstType(&InstType{"ds_write2st64_b64", 79, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_cmpst_b64", 80, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_cmpst_f64", 81, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_min_f64", 82, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_max_f64", 83, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_add_rtn_u64", 96, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_sub_rtn_u64", 97, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_rsub_rtn_u64", 98, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_inc_rtn_u64", 99, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_dec_rtn_u64", 100, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_min_rtn_i64", 101, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_max_rtn_i64", 102, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_min_rtn_u64", 103, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_max_rtn_u64", 104, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_and_rtn_b64", 105, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_or_rtn_b64", 106, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_xor_rtn_b64", 107, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_mskor_rtn_b64", 108, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_wrxchg_rtn_b64", 109, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
+	d.addInstType(&InstType{"ds_cmpst_b64", 80, FormatTable[DS], 0, ExeUnitLDS, 0, 64, 64, 0, 0})
+	d.addInstType(&InstType{"ds_cmpst_f64", 81, FormatTable[DS], 0, ExeUnitLDS, 0, 64, 64, 0, 0})
+	d.addInstType(&InstType{"ds_min_f64", 82, FormatTable[DS], 0, ExeUnitLDS, 0, 64, 0, 0, 0})
+	d.addInstType(&InstType{"ds_max_f64", 83, FormatTable[DS], 0, ExeUnitLDS, 0, 64, 0, 0, 0})
+	d.addInstType(&InstType{"ds_add_f64", 92, FormatTable[DS], 0, ExeUnitLDS, 0, 64, 0, 0, 0})
+	d.addInstType(&InstType{"ds_add_rtn_u64", 96, FormatTable[DS], 0, ExeUnitLDS, 64, 64, 0, 0, 0})
+	d.addInstType(&InstType{"ds_sub_rtn_u64", 97, FormatTable[DS], 0, ExeUnitLDS, 64, 64, 0, 0, 0})
+	d.addInstType(&InstType{"ds_rsub_rtn_u64", 98, FormatTable[DS], 0, ExeUnitLDS, 64, 64, 0, 0, 0})
+	d.addInstType(&InstType{"ds_inc_rtn_u64", 99, FormatTable[DS], 0, ExeUnitLDS, 64, 64, 0, 0, 0})
+	d.addInstType(&InstType{"ds_dec_rtn_u64", 100, FormatTable[DS], 0, ExeUnitLDS, 64, 64, 0, 0, 0})
+	d.addInstType(&InstType{"ds_min_rtn_i64", 101, FormatTable[DS], 0, ExeUnitLDS, 64, 64, 0, 0, 0})
+	d.addInstType(&InstType{"ds_max_rtn_i64", 102, FormatTable[DS], 0, ExeUnitLDS, 64, 64, 0, 0, 0})
+	d.addInstType(&InstType{"ds_min_rtn_u64", 103, FormatTable[DS], 0, ExeUnitLDS, 64, 64, 0, 0, 0})
+	d.addInstType(&InstType{"ds_max_rtn_u64", 104, FormatTable[DS], 0, ExeUnitLDS, 64, 64, 0, 0, 0})
+	d.addInstType(&InstType{"ds_and_rtn_b64", 105, FormatTable[DS], 0, ExeUnitLDS, 64, 64, 0, 0, 0})
+	d.addInstType(&InstType{"ds_or_rtn_b64", 106, FormatTable[DS], 0, ExeUnitLDS, 64, 64, 0, 0, 0})
+	d.addInstType(&InstType{"ds_xor_rtn_b64", 107, FormatTable[DS], 0, ExeUnitLDS, 64, 64, 0, 0, 0})
+	d.addInstType(&InstType{"ds_mskor_rtn_b64", 108, FormatTable[DS], 0, ExeUnitLDS, 64, 64, 64, 0, 0})
+	d.addInstType(&InstType{"ds_wrxchg_rtn_b64", 109, FormatTable[DS], 0, ExeUnitLDS, 64, 64, 0, 0, 0})
 	d.addInstType(&InstType{"ds_wrxchg2_rtn_b64", 110, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
 	d.addInstType(&InstType{"ds_wrxchg2st64_rtn_b64", 111, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_cmpst_rtn_b64", 112, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_cmpst_rtn_f64", 113, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_min_rtn_f64", 114, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
-	d.addInstType(&InstType{"ds_max_rtn_f64", 115, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
+	d.addInstType(&InstType{"ds_cmpst_rtn_b64", 112, FormatTable[DS], 0, ExeUnitLDS, 64, 64, 64, 0, 0})
+	d.addInstType(&InstType{"ds_cmpst_rtn_f64", 113, FormatTable[DS], 0, ExeUnitLDS, 64, 64, 64, 0, 0})
+	d.addInstType(&InstType{"ds_min_rtn_f64", 114, FormatTable[DS], 0, ExeUnitLDS, 64, 64, 0, 0, 0})
+	d.addInstType(&InstType{"ds_max_rtn_f64", 115, FormatTable[DS], 0, ExeUnitLDS, 64, 64, 0, 0, 0})
 	d.addInstType(&InstType{"ds_read_b64", 118, FormatTable[DS], 0, ExeUnitLDS, 64, 0, 0, 0, 0})
 	d.addInstType(&InstType{"ds_read2_b64", 119, FormatTable[DS], 0, ExeUnitLDS, 128, 0, 0, 0, 0})
 	d.addInstType(&InstType{"ds_read2st64_b64", 120, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
+	d.addInstType(&InstType{"ds_add_rtn_f64", 124, FormatTable[DS], 0, ExeUnitLDS, 64, 64, 0, 0, 0})
 	d.addInstType(&InstType{"ds_condxchg32_rtn_b64", 126, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
 	d.addInstType(&InstType{"ds_add_src2_u32", 128, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
 	d.addInstType(&InstType{"ds_sub_src2_u32", 129, FormatTable[DS], 0, ExeUnitLDS, 0, 0, 0, 0, 0})
@@ -404,17 +404,28 @@ func (d *Disassembler) decodeFLAT(inst *Inst, buf []byte) error {
 		inst.TextureFailEnable = true
 	}
 
+	// GCN3 leaves bits [15:14] as 0, which is the flat segment.
+	inst.Seg = FlatSegment(extractBits(bytesLo, 14, 15))
+
 	bits := int(extractBits(bytesHi, 0, 7))
 	// Decode SADDR (bits 16:22 of second dword)
 	// 0x7F = OFF (flat/global addressing with VGPR pair), otherwise scalar GPR pair
 	saddrBits := int(extractBits(bytesHi, 16, 22))
 	inst.SAddr = NewIntOperand(0, int64(saddrBits))
 
-	// SAddr handling is architecture-dependent:
+	// Scratch instructions take a 32-bit offset from either a VGPR or an
+	// SGPR. The VGPR is not used when SADDR is not OFF.
+	// Other than that, SAddr handling is architecture-dependent:
 	// - CDNA3 (GFX9+): SAddr=0x7F means OFF mode, any other value (including 0
 	//   for s[0:1]) is a valid scalar base register.
 	// - GCN3: SAddr=0x7F or SAddr=0 means OFF mode (VGPR pair as 64-bit address).
-	if d.IsCDNA3 {
+	if inst.Seg == FlatSegmentScratch {
+		if saddrBits != 0x7F {
+			inst.Addr = NewVRegOperand(bits, bits, 0)
+		} else {
+			inst.Addr = NewVRegOperand(bits, bits, 1)
+		}
+	} else if d.IsCDNA3 {
 		if saddrBits != 0x7F {
 			inst.Addr = NewVRegOperand(bits, bits, 1)
 		} else {
@@ -434,7 +445,7 @@ func (d *Disassembler) decodeFLAT(inst *Inst, buf []byte) error {
 	inst.Data = NewVRegOperand(bits, bits, 0)
 
 	switch inst.Opcode {
-	case 21, 29, 80, 81, 82, 83, 84, 85, 86, 87, 88, 89, 90, 91, 92, 93:
+	case 21, 29:
 		inst.Data.RegCount = 2
 		inst.Dst.RegCount = 2
 	case 22, 30:
@@ -444,6 +455,104 @@ func (d *Disassembler) decodeFLAT(inst *Inst, buf []byte) error {
 		inst.Data.RegCount = 4
 		inst.Dst.RegCount = 4
 	}
+
+	if atomic, ok := flatAtomicInfo(inst.Opcode); ok {
+		// Cmpswap carries both the new value and the value to compare with.
+		inst.Dst.RegCount = atomic.ByteSize / 4
+		inst.Data.RegCount = atomic.ByteSize / 4
+		if atomic.Op == AtomicOpCmpSwap {
+			inst.Data.RegCount *= 2
+		}
+	}
+
+	return nil
+}
+
+func (d *Disassembler) decodeMUBUF(inst *Inst, buf []byte) error {
+	bytesLo := binary.LittleEndian.Uint32(buf)
+	bytesHi := binary.LittleEndian.Uint32(buf[4:])
+
+	if extractBits(bytesLo, 16, 16) != 0 {
+		inst.LDS = true
+	}
+
+	if extractBits(bytesLo, 17, 17) != 0 {
+		inst.SystemLevelCoherent = true
+	}
+
+	return d.decodeBufferOperands(inst, bytesLo, bytesHi)
+}
+
+func (d *Disassembler) decodeMTBUF(inst *Inst, buf []byte) error {
+	bytesLo := binary.LittleEndian.Uint32(buf)
+	bytesHi := binary.LittleEndian.Uint32(buf[4:])
+
+	inst.DataFormat = int(extractBits(bytesLo, 19, 22))
+	inst.NumFormat = int(extractBits(bytesLo, 23, 25))
+
+	if extractBits(bytesHi, 22, 22) != 0 {
+		inst.SystemLevelCoherent = true
+	}
+
+	return d.decodeBufferOperands(inst, bytesLo, bytesHi)
+}
+
+// decodeBufferOperands decodes the fields that MUBUF and MTBUF instructions
+// share.
+func (d *Disassembler) decodeBufferOperands(
+	inst *Inst,
+	bytesLo, bytesHi uint32,
+) error {
+	inst.Offset0 = extractBits(bytesLo, 0, 11)
+	inst.OffsetEnable = extractBits(bytesLo, 12, 12) != 0
+	inst.IndexEnable = extractBits(bytesLo, 13, 13) != 0
+	inst.GlobalLevelCoherent = extractBits(bytesLo, 14, 14) != 0
+
+	if extractBits(bytesHi, 23, 23) != 0 {
+		inst.TextureFailEnable = true
+	}
+
+	addrCount := 0
+	if inst.OffsetEnable {
+		addrCount++
+	}
+
+	if inst.IndexEnable {
+		addrCount++
+	}
+
+	bits := int(extractBits(bytesHi, 0, 7))
+	inst.Addr = NewVRegOperand(bits, bits, addrCount)
+
+	bits = int(extractBits(bytesHi, 16, 20)) << 2
+	inst.Base = NewSRegOperand(bits, bits, 4)
+
+	var err error
+
+	inst.Offset, err = getOperand(uint16(extractBits(bytesHi, 24, 31)))
+	if err != nil {
+		return err
+	}
+
+	if inst.Offset.OperandType == RegOperand {
+		inst.Offset.RegCount = 1
+	}
+
+	op, ok := inst.BufferOp()
+	if !ok {
+		return fmt.Errorf("unsupported buffer instruction %s", inst.InstName)
+	}
+
+	bits = int(extractBits(bytesHi, 8, 15))
+	inst.Data = NewVRegOperand(bits, bits, op.NumComponents)
+	inst.Dst = NewVRegOperand(bits, bits, op.NumComponents)
+
+	if atomic, isAtomic := inst.AtomicInfo(); isAtomic &&
+		atomic.Op == AtomicOpCmpSwap {
+		// Cmpswap carries both the new value and the value to compare with.
+		inst.Data.RegCount *= 2
+	}
+
 	return nil
 }
 
@@ -645,22 +754,154 @@ func (d *Disassembler) decodeVOP3a(inst *Inst, buf []byte) error {
 	inst.Neg = int(extractBits(bytesHi, 29, 31))
 	d.parseNeg(inst, inst.Neg)
 
-	// For VOP3P packed instructions (944-946), extract OpSel and OpSelHi
-	if inst.Opcode == 944 {
-		// 3-source FMA: bits [13:11] = op_sel[2:0], bit [14] = op_sel_hi[2]
-		inst.OpSel = int(extractBits(bytesLo, 11, 13))
-		inst.OpSelHi = int(extractBits(bytesHi, 27, 28)) |
-			(int(extractBits(bytesLo, 14, 14)) << 2)
-	} else if inst.Opcode >= 945 && inst.Opcode <= 946 {
-		// 2-source MUL/ADD: bits [12:11] = op_sel[1:0],
-		// bits [14:13] = neg_hi[1:0] (ignored; neg_lo from Neg field applies to both halves)
-		inst.OpSel = int(extractBits(bytesLo, 11, 12))
-		inst.OpSelHi = int(extractBits(bytesHi, 27, 28))
+	return nil
+}
+
+func (d *Disassembler) decodeVOP3P(inst *Inst, buf []byte) error {
+	if isVOP3PMAIOpcode(inst.Opcode) {
+		return d.decodeVOP3PMAI(inst, buf)
+	}
+
+	bytesLo := binary.LittleEndian.Uint32(buf)
+	bytesHi := binary.LittleEndian.Uint32(buf[4:])
+
+	bits := int(extractBits(bytesLo, 0, 7))
+	inst.Dst = NewVRegOperand(bits, bits, 0)
+
+	inst.NegHi = int(extractBits(bytesLo, 8, 10))
+	inst.OpSel = int(extractBits(bytesLo, 11, 13))
+	inst.OpSelHi = int(extractBits(bytesHi, 27, 28)) |
+		(int(extractBits(bytesLo, 14, 14)) << 2)
+
+	if extractBits(bytesLo, 15, 15) != 0 {
+		inst.Clamp = true
+	}
+
+	inst.Src0, _ = getOperand(uint16(extractBits(bytesHi, 0, 8)))
+	inst.Src1, _ = getOperand(uint16(extractBits(bytesHi, 9, 17)))
+	if inst.SRC2Width != 0 {
+		inst.Src2, _ = getOperand(uint16(extractBits(bytesHi, 18, 26)))
+	}
+
+	inst.Neg = int(extractBits(bytesHi, 29, 31))
+	d.parseNeg(inst, inst.Neg)
+
+	// Packed F32 instructions operate on register pairs.
+	if inst.DSTWidth == 64 {
+		inst.Dst.RegCount = 2
+		inst.Src0.RegCount = 2
+		inst.Src1.RegCount = 2
+		if inst.Src2 != nil {
+			inst.Src2.RegCount = 2
+		}
+	}
+
+	return nil
+}
+
+// isVOP3PMAIOpcode returns true if the opcode falls in the VOP3P-MAI range
+// (VOP3P ops 0x40-0x7F), which hosts the MFMA and AccVGPR move instructions.
+func isVOP3PMAIOpcode(opcode Opcode) bool {
+	return opcode >= 0x40 && opcode <= 0x7F
+}
+
+func (d *Disassembler) decodeVOP3PMAI(inst *Inst, buf []byte) error {
+	bytesLo := binary.LittleEndian.Uint32(buf)
+	bytesHi := binary.LittleEndian.Uint32(buf[4:])
+
+	inst.Cbsz = int(extractBits(bytesLo, 8, 10))
+	inst.Abid = int(extractBits(bytesLo, 11, 14))
+	inst.AccCD = extractBits(bytesLo, 15, 15) != 0
+	acc := extractBits(bytesHi, 27, 28)
+	inst.Blgp = int(extractBits(bytesHi, 29, 31))
+
+	dstBits := int(extractBits(bytesLo, 0, 7))
+	inst.Src0, _ = getOperand(uint16(extractBits(bytesHi, 0, 8)))
+
+	switch inst.InstName {
+	case "v_accvgpr_read_b32":
+		inst.Dst = NewVRegOperand(dstBits, dstBits, 0)
+		inst.Src0 = toARegOperand(inst.Src0)
+		return nil
+	case "v_accvgpr_write_b32":
+		inst.Dst = NewARegOperand(dstBits, dstBits, 0)
+		return nil
+	}
+
+	inst.Src1, _ = getOperand(uint16(extractBits(bytesHi, 9, 17)))
+	inst.Src2, _ = getOperand(uint16(extractBits(bytesHi, 18, 26)))
+	inst.Dst = NewVRegOperand(dstBits, dstBits, 0)
+
+	if acc&0b01 != 0 {
+		inst.Src0 = toARegOperand(inst.Src0)
+	}
+
+	if acc&0b10 != 0 {
+		inst.Src1 = toARegOperand(inst.Src1)
+	}
+
+	if inst.AccCD {
+		inst.Dst = toARegOperand(inst.Dst)
+		inst.Src2 = toARegOperand(inst.Src2)
+	}
+
+	d.setRegCountFromWidth(inst.Src0, inst.SRC0Width)
+	d.setRegCountFromWidth(inst.Src1, inst.SRC1Width)
+	d.setRegCountFromWidth(inst.Src2, inst.SRC2Width)
+	d.setRegCountFromWidth(inst.Dst, inst.DSTWidth)
+
+	return checkMFMABroadcast(inst)
+}
+
+// mfmaBlocks is the number of blocks of the multi-block MFMA instructions.
+// The instructions that are not listed have a single block.
+var mfmaBlocks = map[string]int{
+	"v_mfma_f32_32x32x1_2b_f32":  2,
+	"v_mfma_f32_16x16x1_4b_f32":  4,
+	"v_mfma_f32_4x4x1_16b_f32":   16,
+	"v_mfma_f32_32x32x4_2b_f16":  2,
+	"v_mfma_f32_16x16x4_4b_f16":  4,
+	"v_mfma_f32_4x4x4_16b_f16":   16,
+	"v_mfma_i32_32x32x4_2b_i8":   2,
+	"v_mfma_i32_16x16x4_4b_i8":   4,
+	"v_mfma_i32_4x4x4_16b_i8":    16,
+	"v_mfma_f32_32x32x4_2b_bf16": 2,
+	"v_mfma_f32_16x16x4_4b_bf16": 4,
+	"v_mfma_f32_4x4x4_16b_bf16":  16,
+}
+
+// checkMFMABroadcast rejects CBSZ and ABID values that broadcast the A
+// matrix across more blocks than the instruction has, or from a block
+// outside of the broadcast group.
+func checkMFMABroadcast(inst *Inst) error {
+	blocks, ok := mfmaBlocks[inst.InstName]
+	if !ok {
+		blocks = 1
+	}
+
+	if 1<<inst.Cbsz > blocks {
+		return fmt.Errorf("%s: cbsz:%d exceeds the %d block(s) of the instruction",
+			inst.InstName, inst.Cbsz, blocks)
+	}
+
+	if inst.Abid >= 1<<inst.Cbsz {
+		return fmt.Errorf("%s: abid:%d is outside of the broadcast group of cbsz:%d",
+			inst.InstName, inst.Abid, inst.Cbsz)
 	}
 
 	return nil
 }
 
+// toARegOperand converts a VGPR operand to the AccVGPR with the same index.
+// Operands that are not VGPRs are returned unchanged.
+func toARegOperand(o *Operand) *Operand {
+	if o == nil || o.OperandType != RegOperand || !o.Register.IsVReg() {
+		return o
+	}
+
+	return NewARegOperand(o.Code, o.Register.RegIndex(), o.RegCount)
+}
+
 func (d *Disassembler) parseNeg(inst *Inst, neg int) {
 	if neg&0b001 > 0 {
 		inst.Src0Neg = true
@@ -764,6 +1005,10 @@ func (d *Disassembler) setRegCountFromWidth(operand *Operand, width int) {
 		operand.RegCount = 3
 	case 128:
 		operand.RegCount = 4
+	case 512:
+		operand.RegCount = 16
+	case 1024:
+		operand.RegCount = 32
 	default:
 		operand.RegCount = 1
 	}
@@ -813,6 +1058,10 @@ func (d *Disassembler) Decode(buf []byte) (*Inst, error) {
 		err = d.decodeVOP1(inst, buf)
 	case FLAT:
 		err = d.decodeFLAT(inst, buf)
+	case MUBUF:
+		err = d.decodeMUBUF(inst, buf)
+	case MTBUF:
+		err = d.decodeMTBUF(inst, buf)
 	case SOPP:
 		err = d.decodeSOPP(inst, buf)
 	case VOPC:
@@ -823,6 +1072,8 @@ func (d *Disassembler) Decode(buf []byte) (*Inst, error) {
 		err = d.decodeVOP3a(inst, buf)
 	case VOP3b:
 		err = d.decodeVOP3b(inst, buf)
+	case VOP3P:
+		err = d.decodeVOP3P(inst, buf)
 	case SOP1:
 		err = d.decodeSOP1(inst, buf)
 	case SOPK:
@@ -43,6 +43,40 @@ var _ = Describe("GCN3 Disassembler", func() {
 		Expect(printer.Print(inst)).To(Equal("s_waitcnt vmcnt(1) lgkmcnt(1)"))
 	})
 
+	It("should decode BF900003", func() {
+		buf := []byte{0x03, 0x00, 0x90, 0xbf}
+
+		inst, err := disassembler.Decode(buf)
+
+		Expect(err).To(BeNil())
+		Expect(printer.Print(inst)).
+			To(Equal("s_sendmsg sendmsg(MSG_GS_DONE, GS_OP_NOP)"))
+	})
+
+	It("should decode BF900122", func() {
+		buf := []byte{0x22, 0x01, 0x90, 0xbf}
+
+		inst, err := disassembler.Decode(buf)
+
+		Expect(err).To(BeNil())
+		Expect(printer.Print(inst)).
+			To(Equal("s_sendmsg sendmsg(MSG_GS, GS_OP_EMIT, 1)"))
+	})
+
+	It("should decode BF9000B3", func() {
+		buf := []byte{0xb3, 0x00, 0x90, 0xbf}
+
+		inst, err := disassembler.Decode(buf)
+
+		Expect(err).To(BeNil())
+		Expect(printer.Print(inst)).
+			To(Equal("s_sendmsg sendmsg(MSG_DEALLOC_VGPRS)"))
+
+		msg, ok := inst.SendMsg()
+		Expect(ok).To(BeTrue())
+		Expect(msg.ID).To(Equal(insts.MsgDeallocVGPRs))
+	})
+
 	It("should decode D81A0004 00000210", func() {
 		buf := []byte{0x04, 0x00, 0x1A, 0xd8, 0x10, 0x02, 0x00, 0x00}
 
@@ -336,4 +370,310 @@ var _ = Describe("CDNA3 Disassembler", func() {
 		Expect(err).To(BeNil())
 		Expect(printer.Print(inst)).To(Equal("global_store_dword v[0:1], v2, off"))
 	})
+
+	It("should decode DD0D0000 01000402 as flat_atomic_sub", func() {
+		buf := []byte{0x00, 0x00, 0x0d, 0xdd, 0x02, 0x04, 0x00, 0x01}
+
+		inst, err := disassembler.Decode(buf)
+
+		Expect(err).To(BeNil())
+		Expect(printer.Print(inst)).To(Equal("flat_atomic_sub v1, v[2:3], v4 glc"))
+		atomic, ok := inst.AtomicInfo()
+		Expect(ok).To(BeTrue())
+		Expect(atomic).To(Equal(insts.AtomicInfo{
+			Op: insts.AtomicOpSub, ByteSize: 4, Return: true}))
+	})
+
+	It("should decode DD850000 00000402 as flat_atomic_cmpswap_x2", func() {
+		buf := []byte{0x00, 0x00, 0x85, 0xdd, 0x02, 0x04, 0x00, 0x00}
+
+		inst, err := disassembler.Decode(buf)
+
+		Expect(err).To(BeNil())
+		Expect(printer.Print(inst)).To(Equal(
+			"flat_atomic_cmpswap_x2 v[0:1], v[2:3], v[4:7] glc"))
+	})
+
+	It("should decode DD3C8000 007F0402 as global_atomic_add_f64", func() {
+		buf := []byte{0x00, 0x80, 0x3c, 0xdd, 0x02, 0x04, 0x7f, 0x00}
+
+		inst, err := disassembler.Decode(buf)
+
+		Expect(err).To(BeNil())
+		Expect(printer.Print(inst)).To(Equal(
+			"global_atomic_add_f64 v[2:3], v[4:5], off"))
+		atomic, ok := inst.AtomicInfo()
+		Expect(ok).To(BeTrue())
+		Expect(atomic).To(Equal(insts.AtomicInfo{
+			Op: insts.AtomicOpAddF64, ByteSize: 8}))
+	})
+
+	It("should decode E0501FFF 80010100 as buffer_load_dword", func() {
+		buf := []byte{0xff, 0x1f, 0x50, 0xe0, 0x00, 0x01, 0x01, 0x80}
+
+		inst, err := disassembler.Decode(buf)
+
+		Expect(err).To(BeNil())
+		Expect(printer.Print(inst)).To(Equal(
+			"buffer_load_dword v1, v0, s[4:7], 0 offen offset:4095"))
+		op, ok := inst.BufferOp()
+		Expect(ok).To(BeTrue())
+		Expect(op).To(Equal(insts.BufferOp{
+			Kind: insts.BufferOpLoad, NumComponents: 1, ComponentSize: 4}))
+	})
+
+	It("should decode E0567000 03010102 as buffer_load_dwordx2", func() {
+		buf := []byte{0x00, 0x70, 0x56, 0xe0, 0x02, 0x01, 0x01, 0x03}
+
+		inst, err := disassembler.Decode(buf)
+
+		Expect(err).To(BeNil())
+		Expect(printer.Print(inst)).To(Equal(
+			"buffer_load_dwordx2 v[1:2], v[2:3], s[4:7], s3 idxen offen glc slc"))
+	})
+
+	It("should decode E1045000 80010100 as buffer_atomic_cmpswap", func() {
+		buf := []byte{0x00, 0x50, 0x04, 0xe1, 0x00, 0x01, 0x01, 0x80}
+
+		inst, err := disassembler.Decode(buf)
+
+		Expect(err).To(BeNil())
+		Expect(printer.Print(inst)).To(Equal(
+			"buffer_atomic_cmpswap v[1:2], v0, s[4:7], 0 offen glc"))
+		atomic, ok := inst.AtomicInfo()
+		Expect(ok).To(BeTrue())
+		Expect(atomic).To(Equal(insts.AtomicInfo{
+			Op: insts.AtomicOpCmpSwap, ByteSize: 4, Return: true}))
+	})
+
+	It("should decode EADA8008 01010100 as tbuffer_store_format_xy", func() {
+		buf := []byte{0x08, 0x80, 0xda, 0xea, 0x00, 0x01, 0x01, 0x01}
+
+		inst, err := disassembler.Decode(buf)
+
+		Expect(err).To(BeNil())
+		Expect(printer.Print(inst)).To(Equal(
+			"tbuffer_store_format_xy v[1:2], off, s[4:7], s1 " +
+				"format:[BUF_DATA_FORMAT_32_32,BUF_NUM_FORMAT_SINT] offset:8"))
+		Expect(inst.DataFormat).To(Equal(11))
+		Expect(inst.NumFormat).To(Equal(5))
+	})
+
+	It("should decode E0F80000 00000000 as buffer_wbinvl1", func() {
+		buf := []byte{0x00, 0x00, 0xf8, 0xe0, 0x00, 0x00, 0x00, 0x00}
+
+		inst, err := disassembler.Decode(buf)
+
+		Expect(err).To(BeNil())
+		Expect(printer.Print(inst)).To(Equal("buffer_wbinvl1"))
+	})
+
+	It("should decode DC504000 017F0002 as scratch_load_dword", func() {
+		buf := []byte{0x00, 0x40, 0x50, 0xdc, 0x02, 0x00, 0x7f, 0x01}
+
+		inst, err := disassembler.Decode(buf)
+
+		Expect(err).To(BeNil())
+		Expect(inst.Seg).To(Equal(insts.FlatSegmentScratch))
+		Expect(inst.Addr.RegCount).To(Equal(1))
+		Expect(printer.Print(inst)).To(Equal(
+			"scratch_load_dword v1, v2, off"))
+		op, ok := inst.FlatOp()
+		Expect(ok).To(BeTrue())
+		Expect(op).To(Equal(insts.BufferOp{
+			Kind: insts.BufferOpLoad, NumComponents: 1, ComponentSize: 4}))
+	})
+
+	It("should decode DC504004 01020000 as scratch_load_dword", func() {
+		buf := []byte{0x04, 0x40, 0x50, 0xdc, 0x00, 0x00, 0x02, 0x01}
+
+		inst, err := disassembler.Decode(buf)
+
+		Expect(err).To(BeNil())
+		Expect(inst.Addr.RegCount).To(Equal(0))
+		Expect(printer.Print(inst)).To(Equal(
+			"scratch_load_dword v1, off, s2 offset:4"))
+	})
+
+	It("should decode DC545FF8 047F0002 as scratch_load_dwordx2", func() {
+		buf := []byte{0xf8, 0x5f, 0x54, 0xdc, 0x02, 0x00, 0x7f, 0x04}
+
+		inst, err := disassembler.Decode(buf)
+
+		Expect(err).To(BeNil())
+		Expect(printer.Print(inst)).To(Equal(
+			"scratch_load_dwordx2 v[4:5], v2, off offset:-8"))
+	})
+
+	It("should decode DC7C4010 007F0403 as scratch_store_dwordx4", func() {
+		buf := []byte{0x10, 0x40, 0x7c, 0xdc, 0x03, 0x04, 0x7f, 0x00}
+
+		inst, err := disassembler.Decode(buf)
+
+		Expect(err).To(BeNil())
+		Expect(printer.Print(inst)).To(Equal(
+			"scratch_store_dwordx4 v3, v[4:7], off offset:16"))
+	})
+
+	It("should decode DC634000 007F0102 as scratch_store_byte", func() {
+		buf := []byte{0x00, 0x40, 0x63, 0xdc, 0x02, 0x01, 0x7f, 0x00}
+
+		inst, err := disassembler.Decode(buf)
+
+		Expect(err).To(BeNil())
+		Expect(printer.Print(inst)).To(Equal(
+			"scratch_store_byte v2, v1, off glc slc"))
+	})
+
+	It("should decode BE8001ED as s_mov_b64 from src_private_base", func() {
+		buf := []byte{0xed, 0x01, 0x80, 0xbe}
+
+		inst, err := disassembler.Decode(buf)
+
+		Expect(err).To(BeNil())
+		Expect(inst.Src0.Register.IsApertureReg()).To(BeTrue())
+		Expect(printer.Print(inst)).To(Equal(
+			"s_mov_b64 s[0:1], src_private_base"))
+	})
+
+	It("should decode D8400010 03000201 as ds_add_rtn_u32", func() {
+		buf := []byte{0x10, 0x00, 0x40, 0xd8, 0x01, 0x02, 0x00, 0x03}
+
+		inst, err := disassembler.Decode(buf)
+
+		Expect(err).To(BeNil())
+		Expect(printer.Print(inst)).To(Equal(
+			"ds_add_rtn_u32 v3, v1, v2 offset:16"))
+	})
+
+	It("should decode D8600000 04030201 as ds_cmpst_rtn_b32", func() {
+		buf := []byte{0x00, 0x00, 0x60, 0xd8, 0x01, 0x02, 0x03, 0x04}
+
+		inst, err := disassembler.Decode(buf)
+
+		Expect(err).To(BeNil())
+		Expect(printer.Print(inst)).To(Equal(
+			"ds_cmpst_rtn_b32 v4, v1, v2, v3"))
+		atomic, ok := inst.AtomicInfo()
+		Expect(ok).To(BeTrue())
+		Expect(atomic).To(Equal(insts.AtomicInfo{
+			Op: insts.AtomicOpCmpSwap, ByteSize: 4, Return: true}))
+	})
+
+	It("should decode D8F80000 04000201 as ds_add_rtn_f64", func() {
+		buf := []byte{0x00, 0x00, 0xf8, 0xd8, 0x01, 0x02, 0x00, 0x04}
+
+		inst, err := disassembler.Decode(buf)
+
+		Expect(err).To(BeNil())
+		Expect(printer.Print(inst)).To(Equal(
+			"ds_add_rtn_f64 v[4:5], v1, v[2:3]"))
+		atomic, ok := inst.AtomicInfo()
+		Expect(ok).To(BeTrue())
+		Expect(atomic).To(Equal(insts.AtomicInfo{
+			Op: insts.AtomicOpAddF64, ByteSize: 8, Return: true}))
+	})
+
+	It("should decode D3CC8000 04020500 as v_mfma_f32_32x32x8_f16", func() {
+		// v_mfma_f32_32x32x8_f16 a[0:15], v[0:1], v[2:3], a[0:15]
+		buf := []byte{0x00, 0x80, 0xCC, 0xD3, 0x00, 0x05, 0x02, 0x04}
+
+		inst, err := disassembler.Decode(buf)
+
+		Expect(err).To(BeNil())
+		Expect(inst.ExeUnit).To(Equal(insts.ExeUnitMatrix))
+		Expect(inst.AccCD).To(BeTrue())
+		Expect(printer.Print(inst)).To(Equal(
+			"v_mfma_f32_32x32x8_f16 a[0:15], v[0:1], v[2:3], a[0:15]"))
+	})
+
+	It("should decode D3C50004 04120300 as v_mfma_f32_16x16x4_f32", func() {
+		// v_mfma_f32_16x16x4_f32 v[4:7], v0, v1, v[4:7]
+		buf := []byte{0x04, 0x00, 0xC5, 0xD3, 0x00, 0x03, 0x12, 0x04}
+
+		inst, err := disassembler.Decode(buf)
+
+		Expect(err).To(BeNil())
+		Expect(inst.AccCD).To(BeFalse())
+		Expect(printer.Print(inst)).To(Equal(
+			"v_mfma_f32_16x16x4_f32 v[4:7], v0, v1, v[4:7]"))
+	})
+
+	It("should decode D3C19A00 24020300 as v_mfma_f32_16x16x1_4b_f32", func() {
+		// v_mfma_f32_16x16x1_4b_f32 a[0:15], v0, v1, a[0:15] cbsz:2 abid:3 blgp:1
+		buf := []byte{0x00, 0x9A, 0xC1, 0xD3, 0x00, 0x03, 0x02, 0x24}
+
+		inst, err := disassembler.Decode(buf)
+
+		Expect(err).To(BeNil())
+		Expect(inst.Cbsz).To(Equal(2))
+		Expect(inst.Abid).To(Equal(3))
+		Expect(inst.Blgp).To(Equal(1))
+		Expect(printer.Print(inst)).To(Equal(
+			"v_mfma_f32_16x16x1_4b_f32 a[0:15], v0, v1, a[0:15] " +
+				"cbsz:2 abid:3 blgp:1"))
+	})
+
+	It("should reject a broadcast across more blocks than the MFMA has", func() {
+		// v_mfma_f32_16x16x4_f32 a[0:3], v0, v1, a[0:3] cbsz:2 abid:3
+		buf := []byte{0x00, 0x9A, 0xC5, 0xD3, 0x00, 0x03, 0x02, 0x04}
+
+		_, err := disassembler.Decode(buf)
+
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should reject an abid outside of the broadcast group", func() {
+		// v_mfma_f32_32x32x1_2b_f32 a[0:31], v0, v1, a[0:31] cbsz:1 abid:2
+		buf := []byte{0x00, 0x91, 0xC0, 0xD3, 0x00, 0x03, 0x02, 0x04}
+
+		_, err := disassembler.Decode(buf)
+
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should decode D3D90001 00000102 as v_accvgpr_write_b32", func() {
+		// v_accvgpr_write_b32 a1, v2
+		buf := []byte{0x01, 0x00, 0xD9, 0xD3, 0x02, 0x01, 0x00, 0x00}
+
+		inst, err := disassembler.Decode(buf)
+
+		Expect(err).To(BeNil())
+		Expect(printer.Print(inst)).To(Equal("v_accvgpr_write_b32 a1, v2"))
+	})
+
+	It("should decode D3D80003 18000104 as v_accvgpr_read_b32", func() {
+		// v_accvgpr_read_b32 v3, a4
+		buf := []byte{0x03, 0x00, 0xD8, 0xD3, 0x04, 0x01, 0x00, 0x18}
+
+		inst, err := disassembler.Decode(buf)
+
+		Expect(err).To(BeNil())
+		Expect(printer.Print(inst)).To(Equal("v_accvgpr_read_b32 v3, a4"))
+	})
+
+	It("should decode D38E4800 540E0501 as v_pk_fma_f16", func() {
+		buf := []byte{0x00, 0x48, 0x8E, 0xD3, 0x01, 0x05, 0x0E, 0x54}
+
+		inst, err := disassembler.Decode(buf)
+
+		Expect(err).To(BeNil())
+		Expect(inst.FormatType).To(Equal(insts.VOP3P))
+		Expect(inst.OpSel).To(Equal(0b001))
+		Expect(inst.OpSelHi).To(Equal(0b110))
+		Expect(inst.Src1Neg).To(BeTrue())
+		Expect(printer.Print(inst)).To(Equal(
+			"v_pk_fma_f16 v0, v1, v2, v3 " +
+				"op_sel:[1,0,0] op_sel_hi:[0,1,1] neg_lo:[0,1,0]"))
+	})
+
+	It("should decode D3B20000 18020902 as v_pk_add_f32", func() {
+		buf := []byte{0x00, 0x00, 0xB2, 0xD3, 0x02, 0x09, 0x02, 0x18}
+
+		inst, err := disassembler.Decode(buf)
+
+		Expect(err).To(BeNil())
+		Expect(printer.Print(inst)).To(Equal(
+			"v_pk_add_f32 v[0:1], v[2:3], v[4:5]"))
+	})
 })
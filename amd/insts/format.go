@@ -15,6 +15,7 @@ const (
 	VOP1
 	VOP3a
 	VOP3b
+	VOP3P
 	VOPC
 	VINTRP
 	DS
@@ -55,6 +56,7 @@ func initFormatTable() {
 	FormatTable[SMEM] = &Format{SMEM, "smem", 0xC0000000, 0xFC000000, 8, 18, 25}
 	FormatTable[VOP3a] = &Format{VOP3a, "vop3a", 0xD0000000, 0xFC000000, 8, 16, 25}
 	FormatTable[VOP3b] = &Format{VOP3b, "vop3b", 0xD0000000, 0xFC000000, 8, 16, 25}
+	FormatTable[VOP3P] = &Format{VOP3P, "vop3p", 0xD3800000, 0xFF800000, 8, 16, 22}
 	FormatTable[VINTRP] = &Format{VINTRP, "vintrp", 0xC8000000, 0xFC000000, 4, 16, 17}
 	FormatTable[DS] = &Format{DS, "ds", 0xD8000000, 0xFC000000, 8, 17, 24}
 	FormatTable[MUBUF] = &Format{MUBUF, "mubuf", 0xE0000000, 0xFC000000, 8, 18, 24}
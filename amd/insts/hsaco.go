@@ -39,6 +39,10 @@ type KernelCodeObjectMeta struct {
 	GroupSegmentByteSize   uint32
 	PrivateSegmentByteSize uint32
 
+	// WavefrontSize is the number of lanes in each wavefront that the kernel
+	// is compiled for, either 32 or 64.
+	WavefrontSize uint32
+
 	// Kernel entry point offset (from start of code object)
 	// For V2/V3: typically 256 (instructions after header)
 	// For V5: typically 0 (instructions at start of .text)
@@ -400,6 +404,11 @@ func parseV2V3Header(data []byte) *KernelCodeObjectMeta {
 	// WGFBarrierCount at 80:84 (skip)
 	meta.WFSgprCount = binary.LittleEndian.Uint16(data[84:86])
 	meta.WIVgprCount = binary.LittleEndian.Uint16(data[86:88])
+	// Alignments at 100:103 (skip)
+	meta.WavefrontSize = 64
+	if data[103] == 5 { // log2 of the wavefront size
+		meta.WavefrontSize = 32
+	}
 
 	return meta
 }
@@ -414,22 +423,27 @@ func parseV5KernelDescriptor(data []byte) *KernelCodeObjectMeta {
 	// 8:12  - kernarg_size
 	// 12:16 - reserved
 	// 16:24 - kernel_code_entry_byte_offset
-	// 24:32 - reserved
-	// 32:40 - reserved
-	// 40:44 - compute_pgm_rsrc3
-	// 44:48 - compute_pgm_rsrc1
-	// 48:52 - compute_pgm_rsrc2
-	// 52:54 - kernel_code_properties
-	// 54:56 - kernarg_preload
-	// 56:60 - reserved
+	// 24:44 - reserved
+	// 44:48 - compute_pgm_rsrc3
+	// 48:52 - compute_pgm_rsrc1
+	// 52:56 - compute_pgm_rsrc2
+	// 56:58 - kernel_code_properties
+	// 58:60 - kernarg_preload
+	// 60:64 - reserved
 
 	meta.GroupSegmentByteSize = binary.LittleEndian.Uint32(data[0:4])
 	meta.PrivateSegmentByteSize = binary.LittleEndian.Uint32(data[4:8])
 	meta.KernargSegmentByteSize = uint64(binary.LittleEndian.Uint32(data[8:12]))
 	meta.KernelCodeEntryByteOffset = binary.LittleEndian.Uint64(data[16:24])
-	meta.ComputePgmRsrc3 = binary.LittleEndian.Uint32(data[40:44])
-	meta.ComputePgmRsrc1 = binary.LittleEndian.Uint32(data[44:48])
-	meta.ComputePgmRsrc2 = binary.LittleEndian.Uint32(data[48:52])
+	meta.ComputePgmRsrc3 = binary.LittleEndian.Uint32(data[44:48])
+	meta.ComputePgmRsrc1 = binary.LittleEndian.Uint32(data[48:52])
+	meta.ComputePgmRsrc2 = binary.LittleEndian.Uint32(data[52:56])
+
+	// Bit 10 of kernel_code_properties: enable_wavefront_size32
+	meta.WavefrontSize = 64
+	if binary.LittleEndian.Uint16(data[56:58])&(1<<10) != 0 {
+		meta.WavefrontSize = 32
+	}
 
 	// Derive WIVgprCount and WFSgprCount from ComputePgmRsrc1.
 	// These are "granulated" counts in the hardware register:
@@ -623,6 +637,7 @@ func (h *KernelCodeObjectMeta) Info() string {
 	s += fmt.Sprintf("\tGroup Segment Byte Size: %d\n", h.GroupSegmentByteSize)
 	s += fmt.Sprintf("\tPrivate Segment Byte Size: %d\n", h.PrivateSegmentByteSize)
 	s += fmt.Sprintf("\tKernarg Segment Byte Size: %d\n", h.KernargSegmentByteSize)
+	s += fmt.Sprintf("\tWavefront Size: %d\n", h.WavefrontSize)
 	s += fmt.Sprintf("\tRegisters:\n")
 	s += fmt.Sprintf("\t\tEnable SGPR Private Segment Buffer: %t\n", h.EnableSgprPrivateSegmentBuffer)
 	s += fmt.Sprintf("\t\tEnable SGPR Dispatch Ptr: %t\n", h.EnableSgprDispatchPtr)
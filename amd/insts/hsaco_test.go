@@ -129,6 +129,25 @@ func TestNewKernelCodeObjectFromEntireTextSection_V2V3(t *testing.T) {
 	if co.Data[0] != 0xAB || co.Data[1] != 0xCD {
 		t.Error("instruction data not correctly extracted from after header")
 	}
+	if co.WavefrontSize != 64 {
+		t.Errorf("expected wavefront size 64, got %d", co.WavefrontSize)
+	}
+}
+
+func TestNewKernelCodeObjectFromEntireTextSection_Wave32(t *testing.T) {
+	data := make([]byte, 512)
+	binary.LittleEndian.PutUint32(data[0:4], 1)     // CodeVersionMajor
+	binary.LittleEndian.PutUint32(data[4:8], 1)     // CodeVersionMinor
+	binary.LittleEndian.PutUint16(data[8:10], 1)    // MachineKind
+	binary.LittleEndian.PutUint16(data[10:12], 8)   // MachineVersionMajor
+	binary.LittleEndian.PutUint64(data[16:24], 256) // EntryOffset
+	data[103] = 5                                   // log2 of wavefront size
+
+	co := newKernelCodeObjectFromEntireTextSection(data)
+
+	if co.WavefrontSize != 32 {
+		t.Errorf("expected wavefront size 32, got %d", co.WavefrontSize)
+	}
 }
 
 func TestNewKernelCodeObjectFromEntireTextSection_NonV2V3(t *testing.T) {
@@ -147,6 +166,36 @@ func TestNewKernelCodeObjectFromEntireTextSection_NonV2V3(t *testing.T) {
 	}
 }
 
+func TestParseV5KernelDescriptor_Wave32(t *testing.T) {
+	data := make([]byte, 64)
+	binary.LittleEndian.PutUint32(data[48:52], 0x00ac0040) // compute_pgm_rsrc1
+	binary.LittleEndian.PutUint32(data[52:56], 1<<10)      // TG_SIZE_EN
+	binary.LittleEndian.PutUint16(data[56:58], 1<<10)      // wave32
+
+	meta := parseV5KernelDescriptor(data)
+
+	if meta.WavefrontSize != 32 {
+		t.Errorf("expected wavefront size 32, got %d", meta.WavefrontSize)
+	}
+	if meta.ComputePgmRsrc1 != 0x00ac0040 {
+		t.Errorf("expected rsrc1 0x00ac0040, got 0x%x", meta.ComputePgmRsrc1)
+	}
+	if meta.ComputePgmRsrc2&(1<<10) == 0 {
+		t.Errorf("expected TG_SIZE_EN in rsrc2, got 0x%x", meta.ComputePgmRsrc2)
+	}
+}
+
+func TestParseV5KernelDescriptor_TGSizeEnIsNotWave32(t *testing.T) {
+	data := make([]byte, 64)
+	binary.LittleEndian.PutUint32(data[52:56], 1<<10) // TG_SIZE_EN
+
+	meta := parseV5KernelDescriptor(data)
+
+	if meta.WavefrontSize != 64 {
+		t.Errorf("expected wavefront size 64, got %d", meta.WavefrontSize)
+	}
+}
+
 func TestLoadKernelCodeObjectFromFS_V5(t *testing.T) {
 	// Test loading a V5 (gfx942) kernel
 	co := LoadKernelCodeObjectFromFS(
@@ -163,6 +212,9 @@ func TestLoadKernelCodeObjectFromFS_V5(t *testing.T) {
 	if co.Symbol.Name != "StencilKernel" {
 		t.Errorf("expected symbol name 'StencilKernel', got '%s'", co.Symbol.Name)
 	}
+	if co.WavefrontSize != 64 {
+		t.Errorf("expected wavefront size 64, got %d", co.WavefrontSize)
+	}
 	// V5 kernel data should NOT have 256 bytes stripped
 	if co.Symbol.Size != uint64(len(co.Data)) {
 		t.Errorf("expected Data length (%d) to match symbol size (%d) for V5 kernel",
@@ -183,6 +235,9 @@ func TestLoadKernelCodeObjectFromFS_V2V3(t *testing.T) {
 	if co.Symbol == nil {
 		t.Error("expected symbol to be set")
 	}
+	if co.WavefrontSize != 64 {
+		t.Errorf("expected wavefront size 64, got %d", co.WavefrontSize)
+	}
 	// V2/V3 kernel data should have 256-byte header stripped
 	expectedLen := int(co.Symbol.Size) - 256
 	if len(co.Data) != expectedLen {
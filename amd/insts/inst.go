@@ -12,6 +12,19 @@ const (
 	ExeUnitLDS
 	ExeUnitGDS
 	ExeUnitSpecial
+	ExeUnitMatrix
+)
+
+// FlatSegment tells which address space a FLAT instruction accesses.
+type FlatSegment int
+
+// The segments of the FLAT instructions. The flat segment covers all the
+// address spaces, while the scratch and the global segments are the
+// scratch_* and the global_* instructions.
+const (
+	FlatSegmentFlat FlatSegment = iota
+	FlatSegmentScratch
+	FlatSegmentGlobal
 )
 
 // A InstType represents an instruction type. For example s_barrier instruction
@@ -53,8 +66,13 @@ type Inst struct {
 	Abs                 int
 	Omod                int
 	Neg                 int
-	OpSel               int // VOP3P: bits 11-14 for packed instructions
-	OpSelHi             int // VOP3P: bits 59-60 for packed instructions
+	OpSel               int  // VOP3P: selects the high half for the low result
+	OpSelHi             int  // VOP3P: selects the high half for the high result
+	NegHi               int  // VOP3P: negates the high half of each source
+	Cbsz                int  // VOP3P-MAI: broadcast control block size
+	Abid                int  // VOP3P-MAI: A-matrix broadcast identifier
+	Blgp                int  // VOP3P-MAI: B-matrix lane group pattern
+	AccCD               bool // VOP3P-MAI: Src2 and Dst are AccVGPRs
 	Offset0             uint32
 	Offset1             uint32
 	SystemLevelCoherent bool
@@ -63,6 +81,12 @@ type Inst struct {
 	Imm                 bool
 	Clamp               bool
 	GDS                 bool
+	OffsetEnable        bool // MUBUF/MTBUF: VADDR provides an offset
+	IndexEnable         bool // MUBUF/MTBUF: VADDR provides an index
+	LDS                 bool // MUBUF: the data goes to the LDS
+	DataFormat          int  // MTBUF: the data format of the memory
+	NumFormat           int  // MTBUF: the numeric format of the memory
+	Seg                 FlatSegment
 	VMCNT               int
 	LKGMCNT             int
 
@@ -89,4 +113,3 @@ func NewInst() *Inst {
 	i.InstType = new(InstType)
 	return i
 }
-
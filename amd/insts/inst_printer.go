@@ -41,12 +41,16 @@ func (p *InstPrinter) Print(i *Inst) string {
 		return p.vop3aString(i)
 	case VOP3b:
 		return p.vop3bString(i)
+	case VOP3P:
+		return p.vop3pString(i)
 	case SOP1:
 		return p.sop1String(i)
 	case SOPK:
 		return p.sopkString(i)
 	case DS:
 		return p.dsString(i)
+	case MUBUF, MTBUF:
+		return p.bufferString(i)
 	default:
 		return i.InstName
 	}
@@ -66,6 +70,10 @@ func (p *InstPrinter) vop1String(i *Inst) string {
 }
 
 func (p *InstPrinter) flatString(i *Inst) string {
+	if i.Seg == FlatSegmentScratch {
+		return p.scratchString(i)
+	}
+
 	var s string
 	instName := i.InstName
 
@@ -85,10 +93,65 @@ func (p *InstPrinter) flatString(i *Inst) string {
 		if isGlobal {
 			s += ", off"
 		}
+	} else if atomic, ok := i.AtomicInfo(); ok {
+		s = instName + " "
+		if atomic.Return {
+			s += i.Dst.String() + ", "
+		}
+
+		s += i.Addr.String() + ", " + i.Data.String()
+		if isGlobal {
+			s += ", off"
+		}
+
+		if atomic.Return {
+			s += " glc"
+		}
 	}
 	return s
 }
 
+// scratchString prints a scratch instruction, whose address is the sum of
+// either a VGPR or an SGPR and the immediate offset.
+func (p *InstPrinter) scratchString(i *Inst) string {
+	s := strings.Replace(i.InstName, "flat_", "scratch_", 1) + " "
+
+	op, _ := i.FlatOp()
+	if op.Kind != BufferOpStore {
+		s += i.Dst.String() + ", "
+	}
+
+	if i.Addr.RegCount == 0 {
+		s += "off"
+	} else {
+		s += i.Addr.String()
+	}
+
+	if op.Kind != BufferOpLoad {
+		s += ", " + i.Data.String()
+	}
+
+	if i.SAddr.IntValue == 0x7F {
+		s += ", off"
+	} else {
+		s += fmt.Sprintf(", s%d", i.SAddr.IntValue)
+	}
+
+	if i.Offset0 != 0 {
+		s += fmt.Sprintf(" offset:%d", int32(i.Offset0))
+	}
+
+	if i.GlobalLevelCoherent {
+		s += " glc"
+	}
+
+	if i.SystemLevelCoherent {
+		s += " slc"
+	}
+
+	return s
+}
+
 func (p *InstPrinter) smemString(i *Inst) string {
 	s := fmt.Sprintf("%s %s, %s, %#x",
 		i.InstName, i.Data.String(), i.Base.String(), uint16(i.Offset.IntValue))
@@ -105,6 +168,8 @@ func (p *InstPrinter) soppString(i *Inst) string {
 		operandStr = " " + i.SImm16.String()
 	} else if i.Opcode == 1 || i.Opcode == 10 {
 		// Does not print anything
+	} else if msg, ok := i.SendMsg(); ok {
+		operandStr = " " + msg.String()
 	} else {
 		operandStr = " " + i.SImm16.String()
 	}
@@ -240,6 +305,73 @@ func (p *InstPrinter) vop3aString(i *Inst) string {
 	return s
 }
 
+func (p *InstPrinter) vop3pString(i *Inst) string {
+	if isVOP3PMAIOpcode(i.Opcode) {
+		return p.vop3pMAIString(i)
+	}
+
+	numSrc := 2
+	s := fmt.Sprintf("%s %s, %s, %s",
+		i.InstName, i.Dst.String(), i.Src0.String(), i.Src1.String())
+
+	if i.Src2 != nil {
+		numSrc = 3
+		s += ", " + i.Src2.String()
+	}
+
+	allSet := 1<<uint(numSrc) - 1
+	s += vop3pModifierString("op_sel", i.OpSel, numSrc, 0)
+	s += vop3pModifierString("op_sel_hi", i.OpSelHi, numSrc, allSet)
+	s += vop3pModifierString("neg_lo", i.Neg, numSrc, 0)
+	s += vop3pModifierString("neg_hi", i.NegHi, numSrc, 0)
+
+	if i.Clamp {
+		s += " clamp"
+	}
+
+	return s
+}
+
+// vop3pModifierString prints a per-source modifier such as op_sel:[1,0], or
+// nothing if the modifier holds its default value.
+func vop3pModifierString(name string, bits, numSrc, defaultBits int) string {
+	mask := 1<<uint(numSrc) - 1
+	if bits&mask == defaultBits {
+		return ""
+	}
+
+	values := make([]string, numSrc)
+	for n := 0; n < numSrc; n++ {
+		values[n] = fmt.Sprintf("%d", (bits>>uint(n))&1)
+	}
+
+	return fmt.Sprintf(" %s:[%s]", name, strings.Join(values, ","))
+}
+
+func (p *InstPrinter) vop3pMAIString(i *Inst) string {
+	s := fmt.Sprintf("%s %s, %s", i.InstName, i.Dst.String(), i.Src0.String())
+
+	if i.Src1 == nil {
+		return s
+	}
+
+	s += fmt.Sprintf(", %s, %s", i.Src1.String(), i.Src2.String())
+
+	if i.Cbsz != 0 {
+		s += fmt.Sprintf(" cbsz:%d", i.Cbsz)
+	}
+
+	if i.Abid != 0 {
+		s += fmt.Sprintf(" abid:%d", i.Abid)
+	}
+
+	if i.Blgp != 0 {
+		s += fmt.Sprintf(" blgp:%d", i.Blgp)
+	}
+
+	return s
+}
+
 func (p *InstPrinter) vop3aInputOperandString(operand *Operand, neg, abs bool) string {
 	s := ""
 
@@ -293,9 +425,14 @@ func (p *InstPrinter) sopkString(i *Inst) string {
 
 func (p *InstPrinter) dsString(i *Inst) string {
 	s := i.InstName + " "
+	atomic, isAtomic := i.AtomicInfo()
 	switch i.Opcode {
 	case 54, 55, 56, 57, 58, 59, 60, 118, 119, 120, 254, 255:
 		s += i.Dst.String() + ", "
+	default:
+		if isAtomic && atomic.Return {
+			s += i.Dst.String() + ", "
+		}
 	}
 
 	s += i.Addr.String()
@@ -308,8 +445,9 @@ func (p *InstPrinter) dsString(i *Inst) string {
 		s += ", " + i.Data1.String()
 	}
 
-	switch i.Opcode {
-	case 13, 54, 254, 255:
+	switch {
+	case i.Opcode == 13 || i.Opcode == 54 || i.Opcode == 254 ||
+		i.Opcode == 255 || isAtomic:
 		if i.Offset0 > 0 {
 			s += fmt.Sprintf(" offset:%d", i.Offset0)
 		}
@@ -325,3 +463,88 @@ func (p *InstPrinter) dsString(i *Inst) string {
 
 	return s
 }
+
+func (p *InstPrinter) bufferString(i *Inst) string {
+	op, _ := i.BufferOp()
+	if op.Kind == BufferOpCacheControl {
+		return i.InstName
+	}
+
+	s := i.InstName + " "
+	if op.Kind == BufferOpLoad {
+		s += i.Dst.String()
+	} else {
+		s += i.Data.String()
+	}
+
+	if i.Addr.RegCount == 0 {
+		s += ", off"
+	} else {
+		s += ", " + i.Addr.String()
+	}
+
+	s += ", " + i.Base.String() + ", " + i.Offset.String()
+
+	if i.FormatType == MTBUF {
+		s += bufferFormatString(i.DataFormat, i.NumFormat)
+	}
+
+	if i.IndexEnable {
+		s += " idxen"
+	}
+
+	if i.OffsetEnable {
+		s += " offen"
+	}
+
+	if i.Offset0 > 0 {
+		s += fmt.Sprintf(" offset:%d", i.Offset0)
+	}
+
+	if i.GlobalLevelCoherent {
+		s += " glc"
+	}
+
+	if i.SystemLevelCoherent {
+		s += " slc"
+	}
+
+	if i.LDS {
+		s += " lds"
+	}
+
+	return s
+}
+
+var bufferDataFormatNames = []string{
+	"INVALID", "8", "16", "8_8", "32", "16_16", "10_11_11", "11_11_10",
+	"10_10_10_2", "2_10_10_10", "8_8_8_8", "32_32", "16_16_16_16", "32_32_32",
+	"32_32_32_32", "RESERVED_15",
+}
+
+var bufferNumFormatNames = []string{
+	"UNORM", "SNORM", "USCALED", "SSCALED", "UINT", "SINT", "RESERVED_6",
+	"FLOAT",
+}
+
+// bufferFormatString prints the format of an MTBUF instruction, leaving out
+// the default data format (8) and the default numeric format (UNORM).
+func bufferFormatString(dataFormat, numFormat int) string {
+	if dataFormat == 1 && numFormat == 0 {
+		return ""
+	}
+
+	s := " format:["
+	if dataFormat != 1 {
+		s += "BUF_DATA_FORMAT_" + bufferDataFormatNames[dataFormat]
+		if numFormat != 0 {
+			s += ","
+		}
+	}
+
+	if numFormat != 0 {
+		s += "BUF_NUM_FORMAT_" + bufferNumFormatNames[numFormat]
+	}
+
+	return s + "]"
+}
@@ -59,6 +59,16 @@ func NewVRegOperand(code int, index int, count int) *Operand {
 	return o
 }
 
+// NewARegOperand returns a new operand of accumulation vector register type
+func NewARegOperand(code int, index int, count int) *Operand {
+	o := new(Operand)
+	o.Code = code
+	o.OperandType = RegOperand
+	o.Register = Regs[A0+RegType(index)]
+	o.RegCount = count
+	return o
+}
+
 // NewIntOperand returns a new operand of an integer type
 func NewIntOperand(code int, value int64) *Operand {
 	o := new(Operand)
@@ -109,6 +119,11 @@ func (o Operand) regOperandToString() string {
 		} else if o.Register.IsVReg() {
 			return fmt.Sprintf("v[%d:%d]",
 				o.Register.RegIndex(), o.Register.RegIndex()+o.RegCount-1)
+		} else if o.Register.IsAReg() {
+			return fmt.Sprintf("a[%d:%d]",
+				o.Register.RegIndex(), o.Register.RegIndex()+o.RegCount-1)
+		} else if o.Register.IsApertureReg() {
+			return o.Register.Name
 		} else if strings.Contains(o.Register.Name, "lo") {
 			return o.Register.Name[:len(o.Register.Name)-2]
 		}
@@ -155,6 +170,8 @@ func getOperand(num uint16) (*Operand, error) {
 		return NewIntOperand(code, int64(num)-128), nil
 	case num >= 193 && num <= 208:
 		return NewIntOperand(code, 192-int64(num)), nil
+	case num >= 235 && num <= 238:
+		return NewRegOperand(code, SrcSharedBase+RegType(num-235), 0), nil
 	case num == 240:
 		return NewFloatOperand(code, 0.5), nil
 	case num == 241:
@@ -23,18 +23,36 @@ func (r *Reg) IsVReg() bool {
 	return r.RegType >= V0 && r.RegType <= V255
 }
 
+// AReg returns an accumulation vector register object given a certain index
+func AReg(index int) *Reg {
+	return Regs[A0+RegType(index)]
+}
+
+// IsAReg checks if a register is an accumulation vector register (AccVGPR)
+func (r *Reg) IsAReg() bool {
+	return r.RegType >= A0 && r.RegType <= A255
+}
+
 // IsSReg checks if a register is a scalar register
 func (r *Reg) IsSReg() bool {
 	return r.RegType >= S0 && r.RegType <= S101
 }
 
-// RegIndex returns the index of the index in the s-series or the v-series.
-// If the register is not s or v register, -1 is returned.
+// IsApertureReg checks if a register holds the base or the limit of the
+// shared or the private aperture.
+func (r *Reg) IsApertureReg() bool {
+	return r.RegType >= SrcSharedBase && r.RegType <= SrcPrivateLimit
+}
+
+// RegIndex returns the index of the index in the s-series, the v-series, or
+// the a-series. If the register is not s, v, or a register, -1 is returned.
 func (r *Reg) RegIndex() int {
 	if r.IsSReg() {
 		return int(r.RegType - S0)
 	} else if r.IsVReg() {
 		return int(r.RegType - V0)
+	} else if r.IsAReg() {
+		return int(r.RegType - A0)
 	}
 	return -1
 }
@@ -444,6 +462,266 @@ const (
 	VMCNT
 	EXPCNT
 	LGKMCNT
+	A0
+	A1
+	A2
+	A3
+	A4
+	A5
+	A6
+	A7
+	A8
+	A9
+	A10
+	A11
+	A12
+	A13
+	A14
+	A15
+	A16
+	A17
+	A18
+	A19
+	A20
+	A21
+	A22
+	A23
+	A24
+	A25
+	A26
+	A27
+	A28
+	A29
+	A30
+	A31
+	A32
+	A33
+	A34
+	A35
+	A36
+	A37
+	A38
+	A39
+	A40
+	A41
+	A42
+	A43
+	A44
+	A45
+	A46
+	A47
+	A48
+	A49
+	A50
+	A51
+	A52
+	A53
+	A54
+	A55
+	A56
+	A57
+	A58
+	A59
+	A60
+	A61
+	A62
+	A63
+	A64
+	A65
+	A66
+	A67
+	A68
+	A69
+	A70
+	A71
+	A72
+	A73
+	A74
+	A75
+	A76
+	A77
+	A78
+	A79
+	A80
+	A81
+	A82
+	A83
+	A84
+	A85
+	A86
+	A87
+	A88
+	A89
+	A90
+	A91
+	A92
+	A93
+	A94
+	A95
+	A96
+	A97
+	A98
+	A99
+	A100
+	A101
+	A102
+	A103
+	A104
+	A105
+	A106
+	A107
+	A108
+	A109
+	A110
+	A111
+	A112
+	A113
+	A114
+	A115
+	A116
+	A117
+	A118
+	A119
+	A120
+	A121
+	A122
+	A123
+	A124
+	A125
+	A126
+	A127
+	A128
+	A129
+	A130
+	A131
+	A132
+	A133
+	A134
+	A135
+	A136
+	A137
+	A138
+	A139
+	A140
+	A141
+	A142
+	A143
+	A144
+	A145
+	A146
+	A147
+	A148
+	A149
+	A150
+	A151
+	A152
+	A153
+	A154
+	A155
+	A156
+	A157
+	A158
+	A159
+	A160
+	A161
+	A162
+	A163
+	A164
+	A165
+	A166
+	A167
+	A168
+	A169
+	A170
+	A171
+	A172
+	A173
+	A174
+	A175
+	A176
+	A177
+	A178
+	A179
+	A180
+	A181
+	A182
+	A183
+	A184
+	A185
+	A186
+	A187
+	A188
+	A189
+	A190
+	A191
+	A192
+	A193
+	A194
+	A195
+	A196
+	A197
+	A198
+	A199
+	A200
+	A201
+	A202
+	A203
+	A204
+	A205
+	A206
+	A207
+	A208
+	A209
+	A210
+	A211
+	A212
+	A213
+	A214
+	A215
+	A216
+	A217
+	A218
+	A219
+	A220
+	A221
+	A222
+	A223
+	A224
+	A225
+	A226
+	A227
+	A228
+	A229
+	A230
+	A231
+	A232
+	A233
+	A234
+	A235
+	A236
+	A237
+	A238
+	A239
+	A240
+	A241
+	A242
+	A243
+	A244
+	A245
+	A246
+	A247
+	A248
+	A249
+	A250
+	A251
+	A252
+	A253
+	A254
+	A255
+	SrcSharedBase
+	SrcSharedLimit
+	SrcPrivateBase
+	SrcPrivateLimit
 )
 
 // Regs are a list of all registers
@@ -848,4 +1126,265 @@ var Regs = map[RegType]*Reg{
 	VMCNT:          {VMCNT, "vmcnt", 1, false},
 	EXPCNT:         {EXPCNT, "expcnt", 1, false},
 	LGKMCNT:        {LGKMCNT, "lgkmcnt", 1, false},
+	A0:             {A0, "a0", 4, false},
+	A1:             {A1, "a1", 4, false},
+	A2:             {A2, "a2", 4, false},
+	A3:             {A3, "a3", 4, false},
+	A4:             {A4, "a4", 4, false},
+	A5:             {A5, "a5", 4, false},
+	A6:             {A6, "a6", 4, false},
+	A7:             {A7, "a7", 4, false},
+	A8:             {A8, "a8", 4, false},
+	A9:             {A9, "a9", 4, false},
+	A10:            {A10, "a10", 4, false},
+	A11:            {A11, "a11", 4, false},
+	A12:            {A12, "a12", 4, false},
+	A13:            {A13, "a13", 4, false},
+	A14:            {A14, "a14", 4, false},
+	A15:            {A15, "a15", 4, false},
+	A16:            {A16, "a16", 4, false},
+	A17:            {A17, "a17", 4, false},
+	A18:            {A18, "a18", 4, false},
+	A19:            {A19, "a19", 4, false},
+	A20:            {A20, "a20", 4, false},
+	A21:            {A21, "a21", 4, false},
+	A22:            {A22, "a22", 4, false},
+	A23:            {A23, "a23", 4, false},
+	A24:            {A24, "a24", 4, false},
+	A25:            {A25, "a25", 4, false},
+	A26:            {A26, "a26", 4, false},
+	A27:            {A27, "a27", 4, false},
+	A28:            {A28, "a28", 4, false},
+	A29:            {A29, "a29", 4, false},
+	A30:            {A30, "a30", 4, false},
+	A31:            {A31, "a31", 4, false},
+	A32:            {A32, "a32", 4, false},
+	A33:            {A33, "a33", 4, false},
+	A34:            {A34, "a34", 4, false},
+	A35:            {A35, "a35", 4, false},
+	A36:            {A36, "a36", 4, false},
+	A37:            {A37, "a37", 4, false},
+	A38:            {A38, "a38", 4, false},
+	A39:            {A39, "a39", 4, false},
+	A40:            {A40, "a40", 4, false},
+	A41:            {A41, "a41", 4, false},
+	A42:            {A42, "a42", 4, false},
+	A43:            {A43, "a43", 4, false},
+	A44:            {A44, "a44", 4, false},
+	A45:            {A45, "a45", 4, false},
+	A46:            {A46, "a46", 4, false},
+	A47:            {A47, "a47", 4, false},
+	A48:            {A48, "a48", 4, false},
+	A49:            {A49, "a49", 4, false},
+	A50:            {A50, "a50", 4, false},
+	A51:            {A51, "a51", 4, false},
+	A52:            {A52, "a52", 4, false},
+	A53:            {A53, "a53", 4, false},
+	A54:            {A54, "a54", 4, false},
+	A55:            {A55, "a55", 4, false},
+	A56:            {A56, "a56", 4, false},
+	A57:            {A57, "a57", 4, false},
+	A58:            {A58, "a58", 4, false},
+	A59:            {A59, "a59", 4, false},
+	A60:            {A60, "a60", 4, false},
+	A61:            {A61, "a61", 4, false},
+	A62:            {A62, "a62", 4, false},
+	A63:            {A63, "a63", 4, false},
+	A64:            {A64, "a64", 4, false},
+	A65:            {A65, "a65", 4, false},
+	A66:            {A66, "a66", 4, false},
+	A67:            {A67, "a67", 4, false},
+	A68:            {A68, "a68", 4, false},
+	A69:            {A69, "a69", 4, false},
+	A70:            {A70, "a70", 4, false},
+	A71:            {A71, "a71", 4, false},
+	A72:            {A72, "a72", 4, false},
+	A73:            {A73, "a73", 4, false},
+	A74:            {A74, "a74", 4, false},
+	A75:            {A75, "a75", 4, false},
+	A76:            {A76, "a76", 4, false},
+	A77:            {A77, "a77", 4, false},
+	A78:            {A78, "a78", 4, false},
+	A79:            {A79, "a79", 4, false},
+	A80:            {A80, "a80", 4, false},
+	A81:            {A81, "a81", 4, false},
+	A82:            {A82, "a82", 4, false},
+	A83:            {A83, "a83", 4, false},
+	A84:            {A84, "a84", 4, false},
+	A85:            {A85, "a85", 4, false},
+	A86:            {A86, "a86", 4, false},
+	A87:            {A87, "a87", 4, false},
+	A88:            {A88, "a88", 4, false},
+	A89:            {A89, "a89", 4, false},
+	A90:            {A90, "a90", 4, false},
+	A91:            {A91, "a91", 4, false},
+	A92:            {A92, "a92", 4, false},
+	A93:            {A93, "a93", 4, false},
+	A94:            {A94, "a94", 4, false},
+	A95:            {A95, "a95", 4, false},
+	A96:            {A96, "a96", 4, false},
+	A97:            {A97, "a97", 4, false},
+	A98:            {A98, "a98", 4, false},
+	A99:            {A99, "a99", 4, false},
+	A100:           {A100, "a100", 4, false},
+	A101:           {A101, "a101", 4, false},
+	A102:           {A102, "a102", 4, false},
+	A103:           {A103, "a103", 4, false},
+	A104:           {A104, "a104", 4, false},
+	A105:           {A105, "a105", 4, false},
+	A106:           {A106, "a106", 4, false},
+	A107:           {A107, "a107", 4, false},
+	A108:           {A108, "a108", 4, false},
+	A109:           {A109, "a109", 4, false},
+	A110:           {A110, "a110", 4, false},
+	A111:           {A111, "a111", 4, false},
+	A112:           {A112, "a112", 4, false},
+	A113:           {A113, "a113", 4, false},
+	A114:           {A114, "a114", 4, false},
+	A115:           {A115, "a115", 4, false},
+	A116:           {A116, "a116", 4, false},
+	A117:           {A117, "a117", 4, false},
+	A118:           {A118, "a118", 4, false},
+	A119:           {A119, "a119", 4, false},
+	A120:           {A120, "a120", 4, false},
+	A121:           {A121, "a121", 4, false},
+	A122:           {A122, "a122", 4, false},
+	A123:           {A123, "a123", 4, false},
+	A124:           {A124, "a124", 4, false},
+	A125:           {A125, "a125", 4, false},
+	A126:           {A126, "a126", 4, false},
+	A127:           {A127, "a127", 4, false},
+	A128:           {A128, "a128", 4, false},
+	A129:           {A129, "a129", 4, false},
+	A130:           {A130, "a130", 4, false},
+	A131:           {A131, "a131", 4, false},
+	A132:           {A132, "a132", 4, false},
+	A133:           {A133, "a133", 4, false},
+	A134:           {A134, "a134", 4, false},
+	A135:           {A135, "a135", 4, false},
+	A136:           {A136, "a136", 4, false},
+	A137:           {A137, "a137", 4, false},
+	A138:           {A138, "a138", 4, false},
+	A139:           {A139, "a139", 4, false},
+	A140:           {A140, "a140", 4, false},
+	A141:           {A141, "a141", 4, false},
+	A142:           {A142, "a142", 4, false},
+	A143:           {A143, "a143", 4, false},
+	A144:           {A144, "a144", 4, false},
+	A145:           {A145, "a145", 4, false},
+	A146:           {A146, "a146", 4, false},
+	A147:           {A147, "a147", 4, false},
+	A148:           {A148, "a148", 4, false},
+	A149:           {A149, "a149", 4, false},
+	A150:           {A150, "a150", 4, false},
+	A151:           {A151, "a151", 4, false},
+	A152:           {A152, "a152", 4, false},
+	A153:           {A153, "a153", 4, false},
+	A154:           {A154, "a154", 4, false},
+	A155:           {A155, "a155", 4, false},
+	A156:           {A156, "a156", 4, false},
+	A157:           {A157, "a157", 4, false},
+	A158:           {A158, "a158", 4, false},
+	A159:           {A159, "a159", 4, false},
+	A160:           {A160, "a160", 4, false},
+	A161:           {A161, "a161", 4, false},
+	A162:           {A162, "a162", 4, false},
+	A163:           {A163, "a163", 4, false},
+	A164:           {A164, "a164", 4, false},
+	A165:           {A165, "a165", 4, false},
+	A166:           {A166, "a166", 4, false},
+	A167:           {A167, "a167", 4, false},
+	A168:           {A168, "a168", 4, false},
+	A169:           {A169, "a169", 4, false},
+	A170:           {A170, "a170", 4, false},
+	A171:           {A171, "a171", 4, false},
+	A172:           {A172, "a172", 4, false},
+	A173:           {A173, "a173", 4, false},
+	A174:           {A174, "a174", 4, false},
+	A175:           {A175, "a175", 4, false},
+	A176:           {A176, "a176", 4, false},
+	A177:           {A177, "a177", 4, false},
+	A178:           {A178, "a178", 4, false},
+	A179:           {A179, "a179", 4, false},
+	A180:           {A180, "a180", 4, false},
+	A181:           {A181, "a181", 4, false},
+	A182:           {A182, "a182", 4, false},
+	A183:           {A183, "a183", 4, false},
+	A184:           {A184, "a184", 4, false},
+	A185:           {A185, "a185", 4, false},
+	A186:           {A186, "a186", 4, false},
+	A187:           {A187, "a187", 4, false},
+	A188:           {A188, "a188", 4, false},
+	A189:           {A189, "a189", 4, false},
+	A190:           {A190, "a190", 4, false},
+	A191:           {A191, "a191", 4, false},
+	A192:           {A192, "a192", 4, false},
+	A193:           {A193, "a193", 4, false},
+	A194:           {A194, "a194", 4, false},
+	A195:           {A195, "a195", 4, false},
+	A196:           {A196, "a196", 4, false},
+	A197:           {A197, "a197", 4, false},
+	A198:           {A198, "a198", 4, false},
+	A199:           {A199, "a199", 4, false},
+	A200:           {A200, "a200", 4, false},
+	A201:           {A201, "a201", 4, false},
+	A202:           {A202, "a202", 4, false},
+	A203:           {A203, "a203", 4, false},
+	A204:           {A204, "a204", 4, false},
+	A205:           {A205, "a205", 4, false},
+	A206:           {A206, "a206", 4, false},
+	A207:           {A207, "a207", 4, false},
+	A208:           {A208, "a208", 4, false},
+	A209:           {A209, "a209", 4, false},
+	A210:           {A210, "a210", 4, false},
+	A211:           {A211, "a211", 4, false},
+	A212:           {A212, "a212", 4, false},
+	A213:           {A213, "a213", 4, false},
+	A214:           {A214, "a214", 4, false},
+	A215:           {A215, "a215", 4, false},
+	A216:           {A216, "a216", 4, false},
+	A217:           {A217, "a217", 4, false},
+	A218:           {A218, "a218", 4, false},
+	A219:           {A219, "a219", 4, false},
+	A220:           {A220, "a220", 4, false},
+	A221:           {A221, "a221", 4, false},
+	A222:           {A222, "a222", 4, false},
+	A223:           {A223, "a223", 4, false},
+	A224:           {A224, "a224", 4, false},
+	A225:           {A225, "a225", 4, false},
+	A226:           {A226, "a226", 4, false},
+	A227:           {A227, "a227", 4, false},
+	A228:           {A228, "a228", 4, false},
+	A229:           {A229, "a229", 4, false},
+	A230:           {A230, "a230", 4, false},
+	A231:           {A231, "a231", 4, false},
+	A232:           {A232, "a232", 4, false},
+	A233:           {A233, "a233", 4, false},
+	A234:           {A234, "a234", 4, false},
+	A235:           {A235, "a235", 4, false},
+	A236:           {A236, "a236", 4, false},
+	A237:           {A237, "a237", 4, false},
+	A238:           {A238, "a238", 4, false},
+	A239:           {A239, "a239", 4, false},
+	A240:           {A240, "a240", 4, false},
+	A241:           {A241, "a241", 4, false},
+	A242:           {A242, "a242", 4, false},
+	A243:           {A243, "a243", 4, false},
+	A244:           {A244, "a244", 4, false},
+	A245:           {A245, "a245", 4, false},
+	A246:           {A246, "a246", 4, false},
+	A247:           {A247, "a247", 4, false},
+	A248:           {A248, "a248", 4, false},
+	A249:           {A249, "a249", 4, false},
+	A250:           {A250, "a250", 4, false},
+	A251:           {A251, "a251", 4, false},
+	A252:           {A252, "a252", 4, false},
+	A253:           {A253, "a253", 4, false},
+	A254:           {A254, "a254", 4, false},
+	A255:           {A255, "a255", 4, false},
+
+	SrcSharedBase:   {SrcSharedBase, "src_shared_base", 4, false},
+	SrcSharedLimit:  {SrcSharedLimit, "src_shared_limit", 4, false},
+	SrcPrivateBase:  {SrcPrivateBase, "src_private_base", 4, false},
+	SrcPrivateLimit: {SrcPrivateLimit, "src_private_limit", 4, false},
 }
@@ -0,0 +1,108 @@
+package insts
+
+import "fmt"
+
+// A SendMsgID identifies the message that an s_sendmsg instruction sends.
+type SendMsgID int
+
+// The messages that the s_sendmsg instructions can send.
+const (
+	MsgInterrupt        SendMsgID = 1
+	MsgGS               SendMsgID = 2
+	MsgGSDone           SendMsgID = 3
+	MsgSaveWave         SendMsgID = 4
+	MsgStallWaveGen     SendMsgID = 5
+	MsgHaltWaves        SendMsgID = 6
+	MsgOrderedPSDone    SendMsgID = 7
+	MsgEarlyPrimDealloc SendMsgID = 8
+	MsgGSAllocReq       SendMsgID = 9
+	MsgGetDoorbell      SendMsgID = 10
+	MsgSysMsg           SendMsgID = 15
+
+	// MsgDeallocVGPRs releases the VGPRs of the wavefront before it ends. It
+	// uses the 8-bit message ID of the RDNA3 (gfx11) encoding.
+	MsgDeallocVGPRs SendMsgID = 0xb3
+)
+
+var sendMsgNames = map[SendMsgID]string{
+	MsgInterrupt:        "MSG_INTERRUPT",
+	MsgGS:               "MSG_GS",
+	MsgGSDone:           "MSG_GS_DONE",
+	MsgSaveWave:         "MSG_SAVEWAVE",
+	MsgStallWaveGen:     "MSG_STALL_WAVE_GEN",
+	MsgHaltWaves:        "MSG_HALT_WAVES",
+	MsgOrderedPSDone:    "MSG_ORDERED_PS_DONE",
+	MsgEarlyPrimDealloc: "MSG_EARLY_PRIM_DEALLOC",
+	MsgGSAllocReq:       "MSG_GS_ALLOC_REQ",
+	MsgGetDoorbell:      "MSG_GET_DOORBELL",
+	MsgSysMsg:           "MSG_SYSMSG",
+	MsgDeallocVGPRs:     "MSG_DEALLOC_VGPRS",
+}
+
+var gsOpNames = []string{"GS_OP_NOP", "GS_OP_CUT", "GS_OP_EMIT", "GS_OP_EMIT_CUT"}
+
+func (id SendMsgID) String() string {
+	name, ok := sendMsgNames[id]
+	if !ok {
+		return fmt.Sprintf("%d", int(id))
+	}
+
+	return name
+}
+
+// SendMsg is the message that an s_sendmsg or s_sendmsghalt instruction
+// sends.
+type SendMsg struct {
+	ID SendMsgID
+
+	// Op is the GS operation of MSG_GS and MSG_GS_DONE, or the operation of
+	// MSG_SYSMSG.
+	Op int
+
+	// StreamID is the GS stream of MSG_GS and MSG_GS_DONE.
+	StreamID int
+}
+
+// IsGS returns true if the message reports the progress of a geometry shader.
+func (m SendMsg) IsGS() bool {
+	return m.ID == MsgGS || m.ID == MsgGSDone
+}
+
+func (m SendMsg) String() string {
+	switch {
+	case m.IsGS() && m.Op != 0:
+		return fmt.Sprintf("sendmsg(%s, %s, %d)",
+			m.ID, gsOpNames[m.Op], m.StreamID)
+	case m.IsGS():
+		return fmt.Sprintf("sendmsg(%s, %s)", m.ID, gsOpNames[m.Op])
+	case m.ID == MsgSysMsg:
+		return fmt.Sprintf("sendmsg(%s, %d)", m.ID, m.Op)
+	default:
+		return fmt.Sprintf("sendmsg(%s)", m.ID)
+	}
+}
+
+// SendMsg returns the message that an s_sendmsg or s_sendmsghalt instruction
+// sends. The second return value is false if the instruction does not send a
+// message.
+func (i *Inst) SendMsg() (SendMsg, bool) {
+	if i.FormatType != SOPP || (i.Opcode != 16 && i.Opcode != 17) {
+		return SendMsg{}, false
+	}
+
+	imm := uint32(i.SImm16.IntValue)
+	if extractBits(imm, 0, 7) == uint32(MsgDeallocVGPRs) {
+		return SendMsg{ID: MsgDeallocVGPRs}, true
+	}
+
+	msg := SendMsg{ID: SendMsgID(extractBits(imm, 0, 3))}
+	switch {
+	case msg.IsGS():
+		msg.Op = int(extractBits(imm, 4, 5))
+		msg.StreamID = int(extractBits(imm, 8, 9))
+	case msg.ID == MsgSysMsg:
+		msg.Op = int(extractBits(imm, 4, 6))
+	}
+
+	return msg, true
+}
@@ -56,6 +56,16 @@ type Wavefront struct {
 	WG            *WorkGroup
 	InitExecMask  uint64
 
+	// WavefrontSize is the number of lanes of the wavefront. Zero means 64.
+	WavefrontSize int
+
+	// ScratchAddress and ScratchByteSize locate the scratch segment of the
+	// dispatch. The private memory of the wavefront starts ScratchWaveOffset
+	// bytes into the segment.
+	ScratchAddress    uint64
+	ScratchByteSize   uint64
+	ScratchWaveOffset uint64
+
 	WorkItems []*WorkItem
 	//for sampling
 	FinishTime sim.VTimeInSec
@@ -70,6 +80,25 @@ func NewWavefront() *Wavefront {
 	return wf
 }
 
+// LaneCount returns the number of lanes of the wavefront.
+func (wf *Wavefront) LaneCount() int {
+	if wf == nil || wf.WavefrontSize == 0 {
+		return 64
+	}
+
+	return wf.WavefrontSize
+}
+
+// LaneMask returns the bits of the EXEC and VCC masks that the lanes of the
+// wavefront use.
+func (wf *Wavefront) LaneMask() uint64 {
+	if wf.LaneCount() >= 64 {
+		return ^uint64(0)
+	}
+
+	return 1<<uint(wf.LaneCount()) - 1
+}
+
 // A WorkItem defines a set of vector registers.
 type WorkItem struct {
 	WG            *WorkGroup
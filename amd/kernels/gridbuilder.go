@@ -1,6 +1,10 @@
 package kernels
 
-import "github.com/sarchlab/mgpusim/v4/amd/insts"
+import (
+	"fmt"
+
+	"github.com/sarchlab/mgpusim/v4/amd/insts"
+)
 
 // WGFilterFunc is a filter
 type WGFilterFunc func(
@@ -14,6 +18,16 @@ type KernelLaunchInfo struct {
 	Packet     *HsaKernelDispatchPacket
 	PacketAddr uint64
 	WGFilter   WGFilterFunc
+
+	// ScratchAddr and ScratchSize locate the scratch segment that holds the
+	// private memory of the wavefronts.
+	ScratchAddr uint64
+	ScratchSize uint64
+
+	// WavefrontSize is the number of lanes of the wavefronts that the GPU
+	// runs. If it is not zero, it must match the wavefront size that the
+	// kernel is compiled for.
+	WavefrontSize int
 }
 
 // A GridBuilder is the unit that can build a grid and its internal structure
@@ -23,6 +37,10 @@ type GridBuilder interface {
 	NumWG() int
 	NextWG() *WorkGroup
 	Skip(n int)
+
+	// Seek lets NextWG continue from the work-group with the given IDs, so
+	// that the work-groups can be built out of order.
+	Seek(idx, idy, idz int)
 }
 
 // NewGridBuilder creates a default grid builder
@@ -37,16 +55,28 @@ type gridBuilderImpl struct {
 	packetAddr uint64
 	numWG      int
 
+	scratchAddr uint64
+	scratchSize uint64
+
 	xid, yid, zid int
 }
 
 func (b *gridBuilderImpl) SetKernel(
 	info KernelLaunchInfo,
 ) {
+	if info.WavefrontSize != 0 &&
+		info.WavefrontSize != KernelWavefrontSize(info.CodeObject) {
+		panic(fmt.Sprintf(
+			"the kernel is compiled for wave%d, but the GPU runs wave%d",
+			KernelWavefrontSize(info.CodeObject), info.WavefrontSize))
+	}
+
 	b.hsaco = info.CodeObject
 	b.packet = info.Packet
 	b.packetAddr = info.PacketAddr
 	b.filter = info.WGFilter
+	b.scratchAddr = info.ScratchAddr
+	b.scratchSize = info.ScratchSize
 	b.xid = 0
 	b.yid = 0
 	b.zid = 0
@@ -60,6 +90,12 @@ func (b *gridBuilderImpl) Skip(n int) {
 	}
 }
 
+func (b *gridBuilderImpl) Seek(idx, idy, idz int) {
+	b.xid = idx
+	b.yid = idy
+	b.zid = idz
+}
+
 func (b *gridBuilderImpl) countWG() {
 	x := int(b.packet.GridSizeX-1)/int(b.packet.WorkgroupSizeX) + 1
 	y := int(b.packet.GridSizeY-1)/int(b.packet.WorkgroupSizeY) + 1
@@ -143,6 +179,7 @@ func (b *gridBuilderImpl) NextWG() *WorkGroup {
 
 	b.spawnWorkItems(wg)
 	b.formWavefronts(wg)
+	b.placeScratch(wg)
 
 	return wg
 }
@@ -162,9 +199,25 @@ func (b *gridBuilderImpl) spawnWorkItems(wg *WorkGroup) {
 	}
 }
 
+// KernelWavefrontSize returns the number of lanes in each wavefront that the
+// kernel is compiled for. The kernels that do not record the wavefront size
+// run in wave64.
+func KernelWavefrontSize(co *insts.KernelCodeObject) int {
+	if co != nil && co.KernelCodeObjectMeta != nil && co.WavefrontSize > 0 {
+		return int(co.WavefrontSize)
+	}
+
+	return 64
+}
+
+// laneCount returns the number of lanes in each wavefront of the kernel.
+func (b *gridBuilderImpl) laneCount() int {
+	return KernelWavefrontSize(b.hsaco)
+}
+
 func (b *gridBuilderImpl) formWavefronts(wg *WorkGroup) {
 	var wf *Wavefront
-	wavefrontSize := 64
+	wavefrontSize := b.laneCount()
 	for i, wi := range wg.WorkItems {
 		wg := wi.WG
 		inWGID := wi.IDZ*wg.SizeX*wg.SizeY + wi.IDY*wg.SizeX + wi.IDX
@@ -175,6 +228,7 @@ func (b *gridBuilderImpl) formWavefronts(wg *WorkGroup) {
 			wf.Packet = b.packet
 			wf.PacketAddress = b.packetAddr
 			wf.WG = wg
+			wf.WavefrontSize = wavefrontSize
 			wg.Wavefronts = append(wg.Wavefronts, wf)
 		}
 		wf.WorkItems = append(wf.WorkItems, wi)
@@ -182,6 +236,17 @@ func (b *gridBuilderImpl) formWavefronts(wg *WorkGroup) {
 	}
 }
 
+// placeScratch points the wavefronts to the scratch segment. The dispatcher
+// picks the slot of each wavefront in the segment when it dispatches the
+// work-group, as the segment only holds the wavefronts that run at the same
+// time.
+func (b *gridBuilderImpl) placeScratch(wg *WorkGroup) {
+	for _, wf := range wg.Wavefronts {
+		wf.ScratchAddress = b.scratchAddr
+		wf.ScratchByteSize = b.scratchSize
+	}
+}
+
 func min(a, b int) int {
 	if a < b {
 		return a
@@ -192,4 +192,121 @@ var _ = Describe("GridBuilder", func() {
 
 		Expect(wg7).To(BeNil())
 	})
+
+	It("should seek to a work-group", func() {
+		codeObject := new(insts.KernelCodeObject)
+		packet := new(HsaKernelDispatchPacket)
+		packet.WorkgroupSizeX = 16
+		packet.WorkgroupSizeY = 16
+		packet.WorkgroupSizeZ = 1
+		packet.GridSizeX = 33
+		packet.GridSizeY = 17
+		packet.GridSizeZ = 1
+		builder.SetKernel(KernelLaunchInfo{
+			CodeObject: codeObject,
+			Packet:     packet,
+			PacketAddr: 0,
+		})
+
+		builder.Seek(1, 1, 0)
+		wg1 := builder.NextWG()
+		builder.Seek(2, 0, 0)
+		wg2 := builder.NextWG()
+		wg3 := builder.NextWG()
+
+		Expect(wg1.IDX).To(Equal(1))
+		Expect(wg1.IDY).To(Equal(1))
+		Expect(wg1.CurrSizeY).To(Equal(1))
+		Expect(wg2.IDX).To(Equal(2))
+		Expect(wg2.IDY).To(Equal(0))
+		Expect(wg2.CurrSizeX).To(Equal(1))
+		Expect(wg3.IDX).To(Equal(0))
+		Expect(wg3.IDY).To(Equal(1))
+	})
+
+	It("should point the wavefronts to the scratch segment", func() {
+		codeObject := new(insts.KernelCodeObject)
+		packet := new(HsaKernelDispatchPacket)
+		packet.WorkgroupSizeX = 128
+		packet.WorkgroupSizeY = 1
+		packet.WorkgroupSizeZ = 1
+		packet.GridSizeX = 256
+		packet.GridSizeY = 1
+		packet.GridSizeZ = 1
+		packet.PrivateSegmentSize = 20
+		builder.SetKernel(KernelLaunchInfo{
+			CodeObject:  codeObject,
+			Packet:      packet,
+			ScratchAddr: 0x10000,
+			ScratchSize: 4 * 2048,
+		})
+
+		Expect(packet.NumWavefrontsPerWG(64)).To(Equal(2))
+		Expect(packet.NumWavefronts(64)).To(Equal(4))
+		Expect(packet.NumWavefronts(32)).To(Equal(8))
+
+		builder.NextWG()
+		wg := builder.NextWG()
+
+		Expect(wg.Wavefronts).To(HaveLen(2))
+		for _, wf := range wg.Wavefronts {
+			Expect(wf.ScratchAddress).To(Equal(uint64(0x10000)))
+			Expect(wf.ScratchByteSize).To(Equal(uint64(4 * 2048)))
+		}
+
+		wg.Wavefronts[1].ScratchWaveOffset = 3 * 2048
+		Expect(wg.Wavefronts[1].ScratchBase()).
+			To(Equal(uint64(0x10000 + 3*2048)))
+	})
+
+	It("should build wave32 wavefronts", func() {
+		codeObject := new(insts.KernelCodeObject)
+		codeObject.KernelCodeObjectMeta = &insts.KernelCodeObjectMeta{
+			WavefrontSize: 32,
+		}
+		packet := new(HsaKernelDispatchPacket)
+		packet.WorkgroupSizeX = 128
+		packet.WorkgroupSizeY = 1
+		packet.WorkgroupSizeZ = 1
+		packet.GridSizeX = 128
+		packet.GridSizeY = 1
+		packet.GridSizeZ = 1
+		builder.SetKernel(KernelLaunchInfo{
+			CodeObject:    codeObject,
+			Packet:        packet,
+			WavefrontSize: 32,
+		})
+
+		wg := builder.NextWG()
+
+		Expect(wg.Wavefronts).To(HaveLen(4))
+		for i, wf := range wg.Wavefronts {
+			Expect(wf.LaneCount()).To(Equal(32))
+			Expect(wf.FirstWiFlatID).To(Equal(32 * i))
+			Expect(wf.InitExecMask).To(Equal(uint64(0xffffffff)))
+		}
+
+	})
+
+	It("should reject a kernel compiled for another wavefront size", func() {
+		codeObject := new(insts.KernelCodeObject)
+		codeObject.KernelCodeObjectMeta = &insts.KernelCodeObjectMeta{
+			WavefrontSize: 64,
+		}
+		packet := new(HsaKernelDispatchPacket)
+		packet.WorkgroupSizeX = 64
+		packet.WorkgroupSizeY = 1
+		packet.WorkgroupSizeZ = 1
+		packet.GridSizeX = 64
+		packet.GridSizeY = 1
+		packet.GridSizeZ = 1
+
+		Expect(func() {
+			builder.SetKernel(KernelLaunchInfo{
+				CodeObject:    codeObject,
+				Packet:        packet,
+				WavefrontSize: 32,
+			})
+		}).To(Panic())
+	})
 })
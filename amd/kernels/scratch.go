@@ -0,0 +1,47 @@
+package kernels
+
+// scratchWaveGranularity is the number of bytes that the scratch memory of a
+// wavefront is aligned to.
+const scratchWaveGranularity = 1024
+
+// ScratchWaveByteSize returns the number of bytes of scratch memory that a
+// wavefront needs, given the size of the private segment of each work-item.
+// The private memory of the lanes interleaves with a stride of 64 lanes, so
+// a wave32 wavefront takes as much scratch memory as a wave64 one.
+func ScratchWaveByteSize(privateSegmentSize uint32) uint64 {
+	size := uint64(privateSegmentSize) * 64
+
+	return (size + scratchWaveGranularity - 1) /
+		scratchWaveGranularity * scratchWaveGranularity
+}
+
+// ScratchBase returns the address of the private memory of the wavefront, or
+// 0 if the dispatch has no scratch segment.
+func (wf *Wavefront) ScratchBase() uint64 {
+	if wf == nil || wf.ScratchByteSize == 0 {
+		return 0
+	}
+
+	return wf.ScratchAddress + wf.ScratchWaveOffset
+}
+
+// NumWavefrontsPerWG returns the number of wavefronts in each work-group of
+// the packet, given the number of lanes in each wavefront.
+func (p *HsaKernelDispatchPacket) NumWavefrontsPerWG(laneCount int) int {
+	wgSize := int(p.WorkgroupSizeX) * int(p.WorkgroupSizeY) *
+		int(p.WorkgroupSizeZ)
+
+	return (wgSize-1)/laneCount + 1
+}
+
+// NumWavefronts returns the number of wavefronts that the packet launches,
+// given the number of lanes in each wavefront.
+func (p *HsaKernelDispatchPacket) NumWavefronts(laneCount int) int {
+	numWG := func(gridSize uint32, wgSize uint16) int {
+		return int(gridSize-1)/int(wgSize) + 1
+	}
+
+	return numWG(p.GridSizeX, p.WorkgroupSizeX) *
+		numWG(p.GridSizeY, p.WorkgroupSizeY) *
+		numWG(p.GridSizeZ, p.WorkgroupSizeZ) * p.NumWavefrontsPerWG(laneCount)
+}
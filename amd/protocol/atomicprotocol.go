@@ -0,0 +1,187 @@
+package protocol
+
+import (
+	"reflect"
+
+	"github.com/sarchlab/akita/v4/mem/vm"
+	"github.com/sarchlab/akita/v4/sim"
+	"github.com/sarchlab/mgpusim/v4/amd/insts"
+)
+
+// An AtomicReq asks the memory system to perform a read-modify-write
+// operation on a memory location atomically. The memory system responds with
+// a DataReadyRsp that carries the value before the operation and does not
+// perform another operation on the location until the requester sends an
+// AtomicDoneReq.
+type AtomicReq struct {
+	sim.MsgMeta
+
+	Address uint64
+	PID     vm.PID
+	Atomic  insts.AtomicInfo
+
+	// Value is the source value of the operation. For cmpswap, Cmp is the
+	// value to compare with; for mskor, Cmp is the mask of the bits to clear.
+	Value uint64
+	Cmp   uint64
+
+	// CanWaitForCoalesce is true if more requests of the same instruction
+	// follow the request.
+	CanWaitForCoalesce bool
+}
+
+// Meta returns the meta data associated with the message.
+func (r *AtomicReq) Meta() *sim.MsgMeta {
+	return &r.MsgMeta
+}
+
+// Clone returns a clone of the AtomicReq with different ID.
+func (r *AtomicReq) Clone() sim.Msg {
+	cloneMsg := *r
+	cloneMsg.ID = sim.GetIDGenerator().Generate()
+
+	return &cloneMsg
+}
+
+// GetAddress returns the address that the request is accessing.
+func (r *AtomicReq) GetAddress() uint64 {
+	return r.Address
+}
+
+// GetByteSize returns the number of bytes that the request is accessing.
+func (r *AtomicReq) GetByteSize() uint64 {
+	return uint64(r.Atomic.ByteSize)
+}
+
+// GetPID returns the process ID that the request is working on.
+func (r *AtomicReq) GetPID() vm.PID {
+	return r.PID
+}
+
+// AtomicReqBuilder can build atomic requests.
+type AtomicReqBuilder struct {
+	src, dst sim.RemotePort
+	pid      vm.PID
+	address  uint64
+	atomic   insts.AtomicInfo
+	value    uint64
+	cmp      uint64
+}
+
+// WithSrc sets the source of the request to build.
+func (b AtomicReqBuilder) WithSrc(src sim.RemotePort) AtomicReqBuilder {
+	b.src = src
+	return b
+}
+
+// WithDst sets the destination of the request to build.
+func (b AtomicReqBuilder) WithDst(dst sim.RemotePort) AtomicReqBuilder {
+	b.dst = dst
+	return b
+}
+
+// WithPID sets the PID of the request to build.
+func (b AtomicReqBuilder) WithPID(pid vm.PID) AtomicReqBuilder {
+	b.pid = pid
+	return b
+}
+
+// WithAddress sets the address of the request to build.
+func (b AtomicReqBuilder) WithAddress(address uint64) AtomicReqBuilder {
+	b.address = address
+	return b
+}
+
+// WithAtomic sets the operation and the size of the request to build.
+func (b AtomicReqBuilder) WithAtomic(atomic insts.AtomicInfo) AtomicReqBuilder {
+	b.atomic = atomic
+	return b
+}
+
+// WithValue sets the source value of the operation.
+func (b AtomicReqBuilder) WithValue(value uint64) AtomicReqBuilder {
+	b.value = value
+	return b
+}
+
+// WithCmp sets the compare value or the mask of the operation.
+func (b AtomicReqBuilder) WithCmp(cmp uint64) AtomicReqBuilder {
+	b.cmp = cmp
+	return b
+}
+
+// Build creates a new AtomicReq.
+func (b AtomicReqBuilder) Build() *AtomicReq {
+	r := &AtomicReq{}
+	r.ID = sim.GetIDGenerator().Generate()
+	r.Src = b.src
+	r.Dst = b.dst
+	r.Address = b.address
+	r.PID = b.pid
+	r.Atomic = b.atomic
+	r.Value = b.value
+	r.Cmp = b.cmp
+	r.TrafficBytes = 12 + 2*b.atomic.ByteSize
+	r.TrafficClass = reflect.TypeOf(AtomicReq{}).String()
+
+	return r
+}
+
+// An AtomicDoneReq tells the memory system that the requester has updated its
+// own copy of the location that an AtomicReq changed, so that the next atomic
+// operation on the location can start.
+type AtomicDoneReq struct {
+	sim.MsgMeta
+
+	AtomicReqID string
+}
+
+// Meta returns the meta data associated with the message.
+func (r *AtomicDoneReq) Meta() *sim.MsgMeta {
+	return &r.MsgMeta
+}
+
+// Clone returns a clone of the AtomicDoneReq with different ID.
+func (r *AtomicDoneReq) Clone() sim.Msg {
+	cloneMsg := *r
+	cloneMsg.ID = sim.GetIDGenerator().Generate()
+
+	return &cloneMsg
+}
+
+// AtomicDoneReqBuilder can build AtomicDoneReqs.
+type AtomicDoneReqBuilder struct {
+	src, dst    sim.RemotePort
+	atomicReqID string
+}
+
+// WithSrc sets the source of the request to build.
+func (b AtomicDoneReqBuilder) WithSrc(src sim.RemotePort) AtomicDoneReqBuilder {
+	b.src = src
+	return b
+}
+
+// WithDst sets the destination of the request to build.
+func (b AtomicDoneReqBuilder) WithDst(dst sim.RemotePort) AtomicDoneReqBuilder {
+	b.dst = dst
+	return b
+}
+
+// WithAtomicReqID sets the ID of the AtomicReq that is done.
+func (b AtomicDoneReqBuilder) WithAtomicReqID(id string) AtomicDoneReqBuilder {
+	b.atomicReqID = id
+	return b
+}
+
+// Build creates a new AtomicDoneReq.
+func (b AtomicDoneReqBuilder) Build() *AtomicDoneReq {
+	r := &AtomicDoneReq{}
+	r.ID = sim.GetIDGenerator().Generate()
+	r.Src = b.src
+	r.Dst = b.dst
+	r.AtomicReqID = b.atomicReqID
+	r.TrafficBytes = 4
+	r.TrafficClass = reflect.TypeOf(AtomicDoneReq{}).String()
+
+	return r
+}
@@ -10,6 +10,10 @@ import (
 // FlushReq requests the GPU to flush all the cache to the main memory
 type FlushReq struct {
 	sim.MsgMeta
+
+	// InvalidateAllCacheLines requests the GPU to invalidate the cache lines
+	// after writing them back, so that later accesses do not hit stale data.
+	InvalidateAllCacheLines bool
 }
 
 // Meta returns the meta data associated with the message.
@@ -45,6 +49,11 @@ type LaunchKernelReq struct {
 	PacketAddress uint64
 	CodeObject    *insts.KernelCodeObject
 	WGFilter      kernels.WGFilterFunc
+
+	// ScratchAddress and ScratchByteSize locate the scratch segment that
+	// holds the private memory of the wavefronts.
+	ScratchAddress  uint64
+	ScratchByteSize uint64
 }
 
 // Meta returns the meta data associated with the message.
@@ -77,6 +86,10 @@ type LaunchKernelRsp struct {
 	sim.MsgMeta
 
 	RspTo string
+
+	// Fault is the first memory fault of the kernel. The GPU aborts the
+	// kernel on a fault, so the kernel may not have run all the work-groups.
+	Fault *MemoryFault
 }
 
 // Meta returns the meta data associated with the message.
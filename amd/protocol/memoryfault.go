@@ -0,0 +1,65 @@
+package protocol
+
+import (
+	"fmt"
+
+	"github.com/sarchlab/akita/v4/mem/vm"
+)
+
+// MemoryAccessType tells how a faulting access uses the memory.
+type MemoryAccessType int
+
+// The types of memory accesses.
+const (
+	MemoryAccessRead MemoryAccessType = iota
+	MemoryAccessWrite
+	MemoryAccessInstFetch
+)
+
+func (t MemoryAccessType) String() string {
+	switch t {
+	case MemoryAccessRead:
+		return "read"
+	case MemoryAccessWrite:
+		return "write"
+	case MemoryAccessInstFetch:
+		return "instruction fetch"
+	}
+
+	return fmt.Sprintf("access type %d", int(t))
+}
+
+// A MemoryFault describes an access to an address that is not mapped in the
+// page table of the process. A fault aborts the kernel or the memory copy
+// that causes it and is reported to the driver.
+type MemoryFault struct {
+	// GPU is the ID of the GPU, as the driver numbers the GPUs. It is 0 if
+	// the fault happens in the driver.
+	GPU int
+
+	// CU and Wavefront name the compute unit and the wavefront that causes
+	// the fault. Both are empty if the fault happens in the driver.
+	CU        string
+	Wavefront string
+	PC        uint64
+
+	// Lane is the first lane that accesses the address. It is -1 if the
+	// access is not a per-lane access, such as a scalar load.
+	Lane int
+
+	PID     vm.PID
+	Address uint64
+	Access  MemoryAccessType
+}
+
+// Error describes the fault.
+func (f *MemoryFault) Error() string {
+	if f.CU == "" {
+		return fmt.Sprintf("memory access fault: %s at 0x%x, pid %d",
+			f.Access, f.Address, f.PID)
+	}
+
+	return fmt.Sprintf("memory access fault on GPU %d: %s at 0x%x, pid %d, "+
+		"%s, wavefront %s, pc 0x%x, lane %d",
+		f.GPU, f.Access, f.Address, f.PID, f.CU, f.Wavefront, f.PC, f.Lane)
+}
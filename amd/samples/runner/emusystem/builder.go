@@ -11,16 +11,28 @@ import (
 	"github.com/sarchlab/akita/v4/simulation"
 	"github.com/sarchlab/mgpusim/v4/amd/arch"
 	"github.com/sarchlab/mgpusim/v4/amd/driver"
+	"github.com/sarchlab/mgpusim/v4/amd/emu"
+	"github.com/sarchlab/mgpusim/v4/amd/kernels"
 	"github.com/sarchlab/mgpusim/v4/amd/samples/runner/emusystem/emugpu"
+	"github.com/sarchlab/mgpusim/v4/amd/sanitizer"
 )
 
 // Builder builds a hardware platform for emulation.
 type Builder struct {
-	simulation   *simulation.Simulation
-	numGPUs      int
-	log2PageSize uint64
-	debugISA     bool
-	archType     arch.Type
+	simulation         *simulation.Simulation
+	numGPUs            int
+	log2PageSize       uint64
+	debugISA           bool
+	debugISAFilter     kernels.WGFilterFunc
+	archType           arch.Type
+	wavefrontSize      int
+	placementPolicy    driver.PlacementPolicy
+	checkpointDir      string
+	checkpointInterval int
+	restore            bool
+	sanitizer          *sanitizer.Sanitizer
+	raceDetector       *emu.RaceDetector
+	debugger           *emu.Debugger
 
 	storage    *mem.Storage
 	pageTable  vm.PageTable
@@ -61,12 +73,73 @@ func (b Builder) WithDebugISA() Builder {
 	return b
 }
 
+// WithDebugISAWGFilter limits the dumped wavefront states to the work-groups
+// that the filter selects.
+func (b Builder) WithDebugISAWGFilter(f kernels.WGFilterFunc) Builder {
+	b.debugISAFilter = f
+	return b
+}
+
 // WithArchitecture sets the GPU architecture for emulation.
 func (b Builder) WithArchitecture(archType arch.Type) Builder {
 	b.archType = archType
 	return b
 }
 
+// WithWavefrontSize sets the number of work-items in each wavefront that the
+// GPUs run. The GPUs reject the kernels compiled for another wavefront size.
+// Zero lets the GPUs run the wavefront size of each kernel.
+func (b Builder) WithWavefrontSize(n int) Builder {
+	b.wavefrontSize = n
+	return b
+}
+
+// WithPlacementPolicy sets the policy that places the pages of distributed
+// and unified memory on the GPUs.
+func (b Builder) WithPlacementPolicy(p driver.PlacementPolicy) Builder {
+	b.placementPolicy = p
+	return b
+}
+
+// WithCheckpointDir sets the directory that the driver writes checkpoints to
+// and restores checkpoints from.
+func (b Builder) WithCheckpointDir(dir string) Builder {
+	b.checkpointDir = dir
+	return b
+}
+
+// WithCheckpointInterval writes a checkpoint after every numKernels kernels.
+func (b Builder) WithCheckpointInterval(numKernels int) Builder {
+	b.checkpointInterval = numKernels
+	return b
+}
+
+// WithRestore resumes the simulation from the checkpoint in the checkpoint
+// directory.
+func (b Builder) WithRestore() Builder {
+	b.restore = true
+	return b
+}
+
+// WithSanitizer checks the memory accesses of the kernels against the memory
+// that the driver allocates.
+func (b Builder) WithSanitizer(s *sanitizer.Sanitizer) Builder {
+	b.sanitizer = s
+	return b
+}
+
+// WithRaceDetector checks the wavefronts of the kernels for data races.
+func (b Builder) WithRaceDetector(d *emu.RaceDetector) Builder {
+	b.raceDetector = d
+	return b
+}
+
+// WithDebugger lets a client of the debugger stop and inspect the wavefronts.
+func (b Builder) WithDebugger(d *emu.Debugger) Builder {
+	b.debugger = d
+	return b
+}
+
 // Build builds the hardware platform.
 func (b Builder) Build() *sim.Domain {
 	domain := &sim.Domain{}
@@ -95,8 +168,10 @@ func (b Builder) Build() *sim.Domain {
 
 		cpPort := gpu.GetPortByName("CommandProcessor")
 		b.driver.RegisterGPU(cpPort, driver.DeviceProperties{
-			DRAMSize: 4 * mem.GB,
-			CUCount:  64,
+			DRAMSize:           4 * mem.GB,
+			CUCount:            64,
+			WavefrontSize:      b.wavefrontSize,
+			MaxWavefrontsPerCU: 40,
 		})
 		b.connection.PlugIn(cpPort)
 	}
@@ -116,10 +191,24 @@ func (b *Builder) createGPUBuilder(
 		WithPageTable(pageTable).
 		WithLog2PageSize(b.log2PageSize).
 		WithStorage(storage).
-		WithArchitecture(b.archType)
+		WithArchitecture(b.archType).
+		WithWavefrontSize(b.wavefrontSize)
 
 	if b.debugISA {
-		gpuBuilder = gpuBuilder.WithISADebugging()
+		gpuBuilder = gpuBuilder.WithISADebugging().
+			WithISADebugWGFilter(b.debugISAFilter)
+	}
+
+	if b.sanitizer != nil {
+		gpuBuilder = gpuBuilder.WithSanitizer(b.sanitizer)
+	}
+
+	if b.raceDetector != nil {
+		gpuBuilder = gpuBuilder.WithRaceDetector(b.raceDetector)
+	}
+
+	if b.debugger != nil {
+		gpuBuilder = gpuBuilder.WithDebugger(b.debugger)
 	}
 
 	return gpuBuilder
@@ -133,6 +222,24 @@ func (b *Builder) buildDriver(
 	gpuDriverBuilder := driver.MakeBuilder().
 		WithMagicMemoryCopyMiddleware()
 
+	if b.placementPolicy != nil {
+		gpuDriverBuilder = gpuDriverBuilder.WithPlacementPolicy(b.placementPolicy)
+	}
+
+	if b.checkpointDir != "" {
+		gpuDriverBuilder = gpuDriverBuilder.
+			WithCheckpointDir(b.checkpointDir).
+			WithCheckpointInterval(b.checkpointInterval)
+	}
+
+	if b.restore {
+		gpuDriverBuilder = gpuDriverBuilder.WithRestore()
+	}
+
+	if b.sanitizer != nil {
+		gpuDriverBuilder = gpuDriverBuilder.WithSanitizer(b.sanitizer)
+	}
+
 	gpuDriver := gpuDriverBuilder.
 		WithEngine(engine).
 		WithPageTable(pageTable).
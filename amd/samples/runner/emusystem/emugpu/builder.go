@@ -13,10 +13,13 @@ import (
 	"github.com/sarchlab/akita/v4/sim/directconnection"
 	"github.com/sarchlab/akita/v4/simulation"
 	"github.com/sarchlab/mgpusim/v4/amd/arch"
+	"github.com/sarchlab/mgpusim/v4/amd/cosim"
 	"github.com/sarchlab/mgpusim/v4/amd/driver"
 	"github.com/sarchlab/mgpusim/v4/amd/emu"
 	"github.com/sarchlab/mgpusim/v4/amd/emu/cdna3"
 	"github.com/sarchlab/mgpusim/v4/amd/insts"
+	"github.com/sarchlab/mgpusim/v4/amd/kernels"
+	"github.com/sarchlab/mgpusim/v4/amd/sanitizer"
 	"github.com/sarchlab/mgpusim/v4/amd/timing/cp"
 )
 
@@ -26,6 +29,7 @@ type Builder struct {
 	freq             sim.Freq
 	log2PageSize     uint64
 	enableISADebug   bool
+	isaDebugWGFilter kernels.WGFilterFunc
 	gpuName          string
 	gpu              *sim.Domain
 	engine           sim.Engine
@@ -37,6 +41,11 @@ type Builder struct {
 	driver           *driver.Driver
 	storage          *mem.Storage
 	archType         arch.Type
+	wavefrontSize    int
+	sanitizer        *sanitizer.Sanitizer
+	raceDetector     *emu.RaceDetector
+	debugger         *emu.Debugger
+	coSimChecker     *cosim.Checker
 }
 
 // MakeBuilder creates a new Builder with default parameters.
@@ -89,12 +98,55 @@ func (b Builder) WithISADebugging() Builder {
 	return b
 }
 
+// WithISADebugWGFilter limits the ISA debugging information to the
+// wavefronts of the work-groups that the filter selects.
+func (b Builder) WithISADebugWGFilter(f kernels.WGFilterFunc) Builder {
+	b.isaDebugWGFilter = f
+	return b
+}
+
 // WithArchitecture sets the GPU architecture for emulation.
 func (b Builder) WithArchitecture(archType arch.Type) Builder {
 	b.archType = archType
 	return b
 }
 
+// WithWavefrontSize sets the number of work-items in each wavefront that the
+// GPU runs. The GPU rejects the kernels compiled for another wavefront size.
+// Zero lets the GPU run the wavefront size of each kernel.
+func (b Builder) WithWavefrontSize(n int) Builder {
+	b.wavefrontSize = n
+	return b
+}
+
+// WithSanitizer lets the compute units check the memory accesses of each lane
+// with the sanitizer.
+func (b Builder) WithSanitizer(s *sanitizer.Sanitizer) Builder {
+	b.sanitizer = s
+	return b
+}
+
+// WithRaceDetector lets the compute units check the wavefronts for data races
+// with the race detector.
+func (b Builder) WithRaceDetector(d *emu.RaceDetector) Builder {
+	b.raceDetector = d
+	return b
+}
+
+// WithDebugger lets a client of the debugger stop and inspect the wavefronts
+// that the compute units execute.
+func (b Builder) WithDebugger(d *emu.Debugger) Builder {
+	b.debugger = d
+	return b
+}
+
+// WithCoSimChecker lets the compute units record the execution of the
+// wavefronts in the co-simulation checker.
+func (b Builder) WithCoSimChecker(c *cosim.Checker) Builder {
+	b.coSimChecker = c
+	return b
+}
+
 // Build builds the GPU.
 func (b Builder) Build(name string) *sim.Domain {
 	b.gpuName = name
@@ -122,6 +174,10 @@ func (b *Builder) buildComputeUnits() {
 			b.engine, disassembler, b.pageTable,
 			b.log2PageSize, b.gpuMem.Storage, nil, aluFactory,
 			isCDNA3)
+		computeUnit.Sanitizer = b.sanitizer
+		computeUnit.RaceDetector = b.raceDetector
+		computeUnit.Debugger = b.debugger
+		computeUnit.CoSimChecker = b.coSimChecker
 		b.simulation.RegisterComponent(computeUnit)
 
 		b.computeUnits = append(b.computeUnits, computeUnit)
@@ -133,6 +189,7 @@ func (b *Builder) buildComputeUnits() {
 				log.Fatal(err.Error())
 			}
 			isaDebugger := emu.NewISADebugger(log.New(isaDebug, "", 0))
+			isaDebugger.WGFilter = b.isaDebugWGFilter
 			computeUnit.AcceptHook(isaDebugger)
 		}
 	}
@@ -167,6 +224,7 @@ func (b *Builder) buildGPU() {
 	b.commandProcessor = cp.MakeBuilder().
 		WithEngine(b.engine).
 		WithFreq(b.freq).
+		WithWavefrontSize(b.wavefrontSize).
 		Build(b.gpuName + ".CommandProcessor")
 
 	b.simulation.RegisterComponent(b.commandProcessor)
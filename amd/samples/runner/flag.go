@@ -2,10 +2,16 @@ package runner
 
 import (
 	"flag"
+	"fmt"
+	"log"
 	"strconv"
 	"strings"
 
 	"github.com/sarchlab/mgpusim/v4/amd/arch"
+	"github.com/sarchlab/mgpusim/v4/amd/driver"
+	"github.com/sarchlab/mgpusim/v4/amd/kernels"
+	"github.com/sarchlab/mgpusim/v4/amd/timing/cp"
+	"github.com/sarchlab/mgpusim/v4/amd/timing/cu"
 )
 
 var timingFlag = flag.Bool("timing", false, "Run detailed timing simulation.")
@@ -14,11 +20,49 @@ var maxInstCount = flag.Uint64("max-inst", 0,
 var parallelFlag = flag.Bool("parallel", false,
 	"Run the simulation in parallel.")
 var isaDebug = flag.Bool("debug-isa", false, "Generate the ISA debugging file.")
-var archFlag = flag.String("arch", "gcn3", "GPU architecture: gcn3 or cdna3.")
+var isaDebugWGFlag = flag.String("debug-isa-wg", "",
+	`Only write the wavefronts of the work-group with the given ID, such as
+75,1,0, to the ISA debugging file. Works with -debug-isa.`)
+var debuggerFlag = flag.String("debugger", "",
+	"Wait for an interactive wavefront debugger to connect to the address, "+
+		"such as localhost:7777 or unix:/tmp/mgpusim.sock. Works only in "+
+		"emulation.")
+var archFlag = flag.String("arch", "gcn3",
+	"GPU architecture: gcn3, cdna3, or rdna3. The rdna3 architecture runs "+
+		"cdna3 kernels compiled for wave32 on dual-issue SIMD32 units.")
 var gpuTypeFlag = flag.String("gpu", "r9nano",
 	"GPU model for timing simulation: r9nano or mi300a.")
+var coalescerFlag = flag.String("coalescer", "ideal",
+	"How the CUs coalesce vector memory accesses in timing simulation: "+
+		"ideal, quarter-wave, or none.")
+var issuePolicyFlag = flag.String("issue-policy", cu.DefaultIssuePolicy,
+	"The policy that decides which wavefronts issue first in each SIMD in "+
+		"timing simulation: "+strings.Join(cu.IssuePolicyNames(), ", ")+".")
+var fetchPolicyFlag = flag.String("fetch-policy", "",
+	"The issue policy that also decides which wavefront fetches instructions "+
+		"first in timing simulation. By default, the wavefront that fetched "+
+		"the least recently fetches first.")
+var dispatchAlgFlag = flag.String("dispatch-alg", "round-robin",
+	"The algorithm that decides which CU each work-group is dispatched to "+
+		"in timing simulation: "+
+		strings.Join(cp.DispatchAlgorithmNames(), ", ")+".")
+var maxWGPerCUFlag = flag.Int("max-wg-per-cu", 1,
+	"The number of work-groups that the occupancy-capped dispatching "+
+		"algorithm can dispatch to each CU at the same time.")
 
 var verifyFlag = flag.Bool("verify", false, "Verify the emulation result.")
+var sanitizeFlag = flag.Bool("sanitize", false,
+	"Report the out-of-bounds, use-after-free, and uninitialized memory "+
+		"accesses of the kernels. Works only in emulation.")
+var detectRacesFlag = flag.Bool("detect-races", false,
+	"Report the data races between the wavefronts in the LDS and the "+
+		"global memory. Works only in emulation.")
+var raceSeedFlag = flag.Int64("race-seed", 0,
+	"Interleave the wavefronts in a random order that the seed decides, "+
+		"when detecting data races. 0 keeps the default order.")
+var coSimFlag = flag.Bool("cosim", false,
+	"Run each kernel in both the emulator and the timing model, and report "+
+		"the first instruction where a wavefront diverges. Implies -timing.")
 var memTracing = flag.Bool("trace-mem", false, "Generate memory trace")
 var instCountReportFlag = flag.Bool("report-inst-count", false,
 	"Report the number of instructions executed in each compute unit.")
@@ -39,6 +83,40 @@ var unifiedGPUFlag = flag.String("unified-gpus", "",
 Use a format like 1,2,3,4. Cannot coexist with -gpus.`)
 var useUnifiedMemoryFlag = flag.Bool("use-unified-memory", false,
 	"Run benchmark with Unified Memory or not")
+var pageMigrationFlag = flag.Bool("page-migration", false,
+	`Migrate unified memory pages to the GPU that accesses them. Only works
+with -timing.`)
+var pagePlacementFlag = flag.String("page-placement", "",
+	`The policy that places the pages of distributed and unified memory on the
+GPUs: block, round-robin, first-touch, or advised. First-touch enables
+-page-migration with -timing. Advised places the pages that the benchmark
+advises with MemAdvise on the preferred GPUs and the others in blocks.`)
+var checkpointDirFlag = flag.String("checkpoint-dir", "",
+	`The directory to write checkpoints to and to restore a checkpoint from. A
+checkpoint only holds the driver state, the page placement, and the memory
+content. The compute units, caches, and TLBs start cold after a restore.`)
+var checkpointIntervalFlag = flag.Int("checkpoint-interval", 0,
+	`Write a checkpoint to -checkpoint-dir after every given number of kernels.
+The GPU caches are flushed before each checkpoint.`)
+var restoreFlag = flag.Bool("restore", false,
+	`Resume the simulation from the checkpoint in -checkpoint-dir. The other
+arguments must be the same as the run that wrote the checkpoint, and the
+benchmark must generate the same input data.`)
+var timingStartKernelFlag = flag.Int("timing-start-kernel", 0,
+	`Emulate the kernels before the given kernel index and simulate the rest of
+the kernels in detail. Implies -timing.`)
+var kernelSamplingProfileFlag = flag.String("kernel-sampling-profile", "",
+	`Write the fingerprints of the kernel launches to the given file for
+-kernel-sampling. Only works without -timing.`)
+var kernelSamplingFlag = flag.String("kernel-sampling", "",
+	`Cluster the kernel launches in the given file written by
+-kernel-sampling-profile, simulate only the representative kernels of each
+cluster in detail, emulate the rest, and report the estimated kernel time.
+Implies -timing.`)
+var kernelSamplingThresholdFlag = flag.Float64("kernel-sampling-threshold", 0.1,
+	"The largest distance between the kernel launches in a cluster.")
+var kernelSamplingSamplesFlag = flag.Int("kernel-sampling-samples", 2,
+	"The number of kernels to simulate in detail in each cluster.")
 var reportAll = flag.Bool("report-all", false, "Report all metrics to .csv file.")
 var filenameFlag = flag.String("metric-file-name", "metrics",
 	"Modify the name of the output csv file.")
@@ -50,6 +128,8 @@ var bufferLevelTracePeriodFlag = flag.Float64("buffer-level-trace-period", 0.0,
 	"The period to dump the buffer level trace.")
 var simdBusyTimeTracerFlag = flag.Bool("report-busy-time", false, "Report SIMD Unit's busy time")
 var reportCPIStackFlag = flag.Bool("report-cpi-stack", false, "Report CPI stack")
+var issueSlotReportFlag = flag.Bool("report-issue-slots", false,
+	"Report how many issue slots of the SIMDs the issue policy uses.")
 var customPortForAkitaRTM = flag.Int("akitartm-port", 0,
 	`Custom port to host AkitaRTM. A 4-digit or 5-digit port number is required. If 
 this number is not given or a invalid number is given number, a random port 
@@ -79,6 +159,32 @@ var visTraceStartTime = flag.Float64("trace-vis-start", -1,
 var visTraceEndTime = flag.Float64("trace-vis-end", -1,
 	"The end time of collecting visualization traces. A negative number"+
 		"means that the trace will be collected to the end of the simulation.")
+var perfettoTraceFlag = flag.String("trace-perfetto", "",
+	`Write the kernel launches, memory copies, cache flushes, and work-group
+lifetimes of each GPU to the given file in the Chrome trace-event JSON format,
+which ui.perfetto.dev and chrome://tracing can open.`)
+
+func (r *Runner) parseCoSimFlag() {
+	r.CoSim = *coSimFlag
+	if !r.CoSim {
+		return
+	}
+
+	if r.TimingStartKernel > 0 {
+		log.Fatalf("-cosim does not work with -timing-start-kernel")
+	}
+
+	if r.KernelSampling != "" {
+		log.Fatalf("-cosim does not work with -kernel-sampling")
+	}
+
+	if r.DebuggerAddr != "" || r.Sanitize || r.DetectRaces {
+		log.Fatalf("-cosim does not work with -sanitize, -detect-races, " +
+			"or -debugger")
+	}
+
+	r.Timing = true
+}
 
 // parseFlag applies the runner flag to runner object
 func (r *Runner) parseFlag() *Runner {
@@ -101,12 +207,82 @@ func (r *Runner) parseSimulationFlags() {
 		r.Timing = true
 	}
 
+	if *timingStartKernelFlag > 0 {
+		r.Timing = true
+		r.TimingStartKernel = *timingStartKernelFlag
+	}
+
+	r.KernelSamplingProfile = *kernelSamplingProfileFlag
+	r.KernelSampling = *kernelSamplingFlag
+	if r.KernelSampling != "" {
+		r.Timing = true
+	}
+
+	if r.KernelSamplingProfile != "" && r.Timing {
+		log.Fatalf("-kernel-sampling-profile does not work with -timing")
+	}
+
+	if r.KernelSampling != "" && r.TimingStartKernel > 0 {
+		log.Fatalf("-kernel-sampling does not work with -timing-start-kernel")
+	}
+
+	r.Sanitize = *sanitizeFlag
+	if r.Sanitize && r.Timing {
+		log.Fatalf("-sanitize does not work with -timing")
+	}
+
+	r.DetectRaces = *detectRacesFlag
+	r.RaceSeed = *raceSeedFlag
+	if r.DetectRaces && r.Timing {
+		log.Fatalf("-detect-races does not work with -timing")
+	}
+
+	r.DebuggerAddr = *debuggerFlag
+	if r.DebuggerAddr != "" && r.Timing {
+		log.Fatalf("-debugger does not work with -timing")
+	}
+
+	r.parseCoSimFlag()
+
 	if *useUnifiedMemoryFlag {
 		r.UseUnifiedMemory = true
 	}
 
-	r.ArchType = parseArchFlag()
+	if *pageMigrationFlag {
+		r.PageMigration = true
+	}
+
+	r.PlacementPolicy = parsePagePlacementFlag()
+	_, firstTouch := r.PlacementPolicy.(driver.FirstTouchPlacement)
+	if firstTouch && r.Timing {
+		r.PageMigration = true
+	}
+
+	r.CheckpointDir = *checkpointDirFlag
+	r.CheckpointInterval = *checkpointIntervalFlag
+	r.Restore = *restoreFlag
+	if r.CheckpointInterval > 0 && r.CheckpointDir == "" {
+		log.Fatalf("-checkpoint-interval requires -checkpoint-dir")
+	}
+
+	if r.CheckpointDir != "" && r.CheckpointInterval == 0 && !r.Restore {
+		log.Fatalf("-checkpoint-dir requires -checkpoint-interval or -restore")
+	}
+
+	if r.Restore && r.CheckpointDir == "" {
+		log.Fatalf("-restore requires -checkpoint-dir")
+	}
+
+	r.PerfettoTrace = *perfettoTraceFlag
+
+	r.ArchConfig = parseArchFlag()
+	r.ArchType = r.ArchConfig.Type
 	r.GPUType = parseGPUTypeFlag()
+	r.Coalescer = parseCoalescerFlag()
+	r.IssuePolicy = parseIssuePolicyFlag()
+	r.FetchPolicy = parseFetchPolicyFlag()
+	r.DispatchAlg = parseDispatchAlgFlag()
+	r.MaxWGPerCU = *maxWGPerCUFlag
 }
 
 func (r *Runner) parseGPUFlag() {
@@ -144,15 +320,105 @@ func (r *Runner) gpuIDStringToList(gpuIDsString string) []int {
 	return gpuIDs
 }
 
-func parseArchFlag() arch.Type {
+func parseArchFlag() *arch.Config {
 	switch strings.ToLower(*archFlag) {
 	case "cdna3", "gfx942":
-		return arch.CDNA3
+		return arch.CDNA3Config
+	case "rdna3":
+		return arch.RDNA3Config
 	default:
-		return arch.GCN3
+		return arch.GCN3Config
+	}
+}
+
+func parsePagePlacementFlag() driver.PlacementPolicy {
+	switch strings.ToLower(*pagePlacementFlag) {
+	case "":
+		return nil
+	case "block":
+		return driver.BlockPlacement{}
+	case "round-robin":
+		return driver.RoundRobinPlacement{}
+	case "first-touch":
+		return driver.FirstTouchPlacement{}
+	case "advised":
+		return driver.AdvisedPlacement{}
+	default:
+		log.Fatalf("unknown page placement policy %s", *pagePlacementFlag)
+	}
+
+	return nil
+}
+
+func parseDebugISAWGFlag() kernels.WGFilterFunc {
+	if *isaDebugWGFlag == "" {
+		return nil
+	}
+
+	var x, y, z int
+	_, err := fmt.Sscanf(*isaDebugWGFlag, "%d,%d,%d", &x, &y, &z)
+	if err != nil {
+		log.Fatalf("invalid work-group ID %s", *isaDebugWGFlag)
+	}
+
+	return func(
+		_ *kernels.HsaKernelDispatchPacket,
+		wg *kernels.WorkGroup,
+	) bool {
+		return wg.IDX == x && wg.IDY == y && wg.IDZ == z
 	}
 }
 
 func parseGPUTypeFlag() string {
 	return strings.ToLower(*gpuTypeFlag)
 }
+
+func parseIssuePolicyFlag() string {
+	name := strings.ToLower(*issuePolicyFlag)
+	if _, err := cu.NewIssuePolicy(name); err != nil {
+		log.Fatal(err)
+	}
+
+	return name
+}
+
+func parseFetchPolicyFlag() string {
+	name := strings.ToLower(*fetchPolicyFlag)
+	if name == "" {
+		return ""
+	}
+
+	if _, err := cu.NewIssuePolicy(name); err != nil {
+		log.Fatal(err)
+	}
+
+	return name
+}
+
+func parseDispatchAlgFlag() string {
+	name := strings.ToLower(*dispatchAlgFlag)
+	for _, n := range cp.DispatchAlgorithmNames() {
+		if n == name {
+			return name
+		}
+	}
+
+	log.Fatalf("unknown dispatching algorithm %s", name)
+
+	return ""
+}
+
+func parseCoalescerFlag() cu.CoalescerFactory {
+	switch strings.ToLower(*coalescerFlag) {
+	case "", "ideal":
+		return nil
+	case "quarter-wave":
+		return cu.NewQuarterWaveCoalescer
+	case "none":
+		return cu.NewNoCoalescer
+	default:
+		log.Fatalf("unknown coalescer %s", *coalescerFlag)
+	}
+
+	return nil
+}
@@ -0,0 +1,103 @@
+package runner
+
+import (
+	"log"
+
+	"github.com/sarchlab/akita/v4/simulation"
+	"github.com/sarchlab/akita/v4/tracing"
+	"github.com/sarchlab/mgpusim/v4/amd/emu"
+	"github.com/sarchlab/mgpusim/v4/amd/sampling"
+)
+
+// attachKernelProfiler fingerprints the kernel launches during emulation.
+func attachKernelProfiler(s *simulation.Simulation) *sampling.KernelProfiler {
+	profiler := sampling.NewKernelProfiler()
+
+	tracing.CollectTrace(
+		s.GetComponentByName("Driver").(tracing.NamedHookable), profiler)
+
+	for _, comp := range s.Components() {
+		if cu, ok := comp.(*emu.ComputeUnit); ok {
+			cu.AcceptHook(profiler)
+		}
+	}
+
+	return profiler
+}
+
+// A kernelSampler simulates the representative kernels of the clusters in
+// the kernel profile and extrapolates the total kernel time.
+type kernelSampler struct {
+	launches []*sampling.KernelLaunch
+	clusters []*sampling.KernelCluster
+	timer    *sampling.KernelTimer
+}
+
+func newKernelSampler(
+	profile string,
+	threshold float64,
+	numSamples int,
+) *kernelSampler {
+	launches, err := sampling.ReadKernelProfile(profile)
+	if err != nil {
+		log.Fatalf("cannot read kernel profile: %v", err)
+	}
+
+	s := &kernelSampler{
+		launches: launches,
+		clusters: sampling.ClusterKernelLaunches(
+			launches, threshold, numSamples),
+	}
+
+	log.Printf("Kernel sampling: %d kernel launches in %d clusters, "+
+		"simulating %d kernels in detail.\n",
+		len(launches), len(s.clusters),
+		len(sampling.SelectSampledKernels(s.clusters)))
+
+	return s
+}
+
+func (s *kernelSampler) selector() sampling.SampledKernels {
+	return sampling.SelectSampledKernels(s.clusters)
+}
+
+func (s *kernelSampler) attachTimer(sim *simulation.Simulation) {
+	s.timer = sampling.NewKernelTimer(sim.GetEngine())
+
+	tracing.CollectTrace(
+		sim.GetComponentByName("Driver").(tracing.NamedHookable), s.timer)
+}
+
+func (s *kernelSampler) report(r *reporter) {
+	codeObjects := s.timer.CodeObjects()
+	if len(codeObjects) != len(s.launches) {
+		log.Fatalf("The program launched %d kernels, but the kernel profile "+
+			"has %d kernels.\n", len(codeObjects), len(s.launches))
+	}
+
+	for i, l := range s.launches {
+		if codeObjects[i] != l.CodeObject {
+			log.Fatalf("Kernel %d is %s, but it is %s in the kernel "+
+				"profile.\n", i, codeObjects[i], l.CodeObject)
+		}
+	}
+
+	e := sampling.EstimateKernelTime(s.launches, s.clusters, s.timer.Times())
+
+	log.Printf("Estimated kernel time: %.9f s +/- %.9f s (95%% confidence), "+
+		"%d of %d kernels simulated in detail.\n",
+		e.Time, e.ErrorBound, e.NumSimulated, e.NumKernels)
+
+	r.dataRecorder.InsertData(tableName, metric{
+		Location: "Driver",
+		What:     "estimated_kernel_time",
+		Value:    float64(e.Time),
+		Unit:     "second",
+	})
+	r.dataRecorder.InsertData(tableName, metric{
+		Location: "Driver",
+		What:     "estimated_kernel_time_error_bound",
+		Value:    float64(e.ErrorBound),
+		Unit:     "second",
+	})
+}
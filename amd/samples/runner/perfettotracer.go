@@ -0,0 +1,385 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/sarchlab/akita/v4/sim"
+	"github.com/sarchlab/akita/v4/simulation"
+	"github.com/sarchlab/akita/v4/tracing"
+	"github.com/sarchlab/mgpusim/v4/amd/protocol"
+	"github.com/sarchlab/mgpusim/v4/amd/sampling"
+)
+
+var gpuNameRegexp = regexp.MustCompile(`^GPU\[(\d+)\]`)
+
+// A perfettoSlice is a task that is shown as a slice on a track.
+type perfettoSlice struct {
+	name      string
+	category  string
+	args      map[string]interface{}
+	startTime sim.VTimeInSec
+	endTime   sim.VTimeInSec
+	numTasks  int
+	cmdKey    string
+}
+
+// A perfettoTrack is the timeline of a GPU or a component of a GPU. Slices
+// that overlap are placed on extra lanes below the track.
+type perfettoTrack struct {
+	name   string
+	gpu    int
+	slices []*perfettoSlice
+}
+
+// perfettoTracer records the kernel launches, memory copies, cache flushes,
+// and work-group lifetimes, and writes them in the Chrome trace-event format,
+// which ui.perfetto.dev and chrome://tracing can open.
+type perfettoTracer struct {
+	sync.Mutex
+	sim.TimeTeller
+
+	tracks      []*perfettoTrack
+	trackByName map[string]*perfettoTrack
+	inflight    map[string]*perfettoSlice
+	cmdSlices   map[string]*perfettoSlice
+}
+
+func newPerfettoTracer(timeTeller sim.TimeTeller) *perfettoTracer {
+	return &perfettoTracer{
+		TimeTeller:  timeTeller,
+		trackByName: make(map[string]*perfettoTrack),
+		inflight:    make(map[string]*perfettoSlice),
+		cmdSlices:   make(map[string]*perfettoSlice),
+	}
+}
+
+// attachPerfettoTracer collects the traces of the driver, the command
+// processors, the DMA engines, and the compute units.
+func attachPerfettoTracer(s *simulation.Simulation) *perfettoTracer {
+	t := newPerfettoTracer(s.GetEngine())
+
+	tracing.CollectTrace(
+		s.GetComponentByName("Driver").(tracing.NamedHookable), t)
+
+	for _, comp := range s.Components() {
+		name := comp.Name()
+		if gpuNameRegexp.FindString(name) == "" {
+			continue
+		}
+
+		if !strings.HasSuffix(name, ".CommandProcessor") &&
+			!strings.HasSuffix(name, ".DMA") &&
+			!strings.Contains(name, ".CU[") {
+			continue
+		}
+
+		hookable, ok := comp.(tracing.NamedHookable)
+		if !ok {
+			continue
+		}
+
+		// Registering the tracks in the order that the components are built
+		// keeps the CUs sorted on the timeline.
+		t.track(name)
+		tracing.CollectTrace(hookable, t)
+	}
+
+	return t
+}
+
+func (t *perfettoTracer) track(name string) *perfettoTrack {
+	track, found := t.trackByName[name]
+	if found {
+		return track
+	}
+
+	gpuName := gpuNameRegexp.FindStringSubmatch(name)
+	gpu, _ := strconv.Atoi(gpuName[1])
+
+	// The GPU track, which shows the requests from the driver, always comes
+	// first.
+	if name != gpuName[0] {
+		t.track(gpuName[0])
+	}
+
+	track = &perfettoTrack{name: name, gpu: gpu}
+	t.tracks = append(t.tracks, track)
+	t.trackByName[name] = track
+
+	return track
+}
+
+// StartTask starts a slice if the task is a kernel launch, a memory copy, a
+// cache flush, or a work-group.
+func (t *perfettoTracer) StartTask(task tracing.Task) {
+	msg, ok := task.Detail.(sim.Msg)
+	if !ok {
+		return
+	}
+
+	trackName := task.Location
+	cmdKey := ""
+	switch task.Kind {
+	case "req_in":
+	case "req_out":
+		// Only the requests from the driver are traced on the sender side.
+		if task.Location != "Driver" {
+			return
+		}
+
+		trackName = gpuNameRegexp.FindString(string(msg.Meta().Dst))
+		if trackName == "" {
+			return
+		}
+
+		// The driver splits a command into many requests, such as one
+		// request per page for memory copies. The requests of a command to
+		// the same GPU are shown as one slice.
+		cmdKey = task.ParentID + "@" + trackName
+	default:
+		return
+	}
+
+	slice := newPerfettoSlice(msg)
+	if slice == nil {
+		return
+	}
+
+	t.Lock()
+	defer t.Unlock()
+
+	if cmdSlice, found := t.cmdSlices[cmdKey]; found {
+		cmdSlice.numTasks++
+		t.inflight[task.ID] = cmdSlice
+		return
+	}
+
+	slice.startTime = t.CurrentTime()
+	slice.numTasks = 1
+	slice.cmdKey = cmdKey
+	t.inflight[task.ID] = slice
+	t.track(trackName).slices = append(t.track(trackName).slices, slice)
+
+	if cmdKey != "" {
+		t.cmdSlices[cmdKey] = slice
+	}
+}
+
+func newPerfettoSlice(msg sim.Msg) *perfettoSlice {
+	switch msg := msg.(type) {
+	case *protocol.LaunchKernelReq:
+		pkt := msg.Packet
+		return &perfettoSlice{
+			name:     sampling.CodeObjectName(msg.CodeObject),
+			category: "kernel",
+			args: map[string]interface{}{
+				"grid_size": []uint32{
+					pkt.GridSizeX, pkt.GridSizeY, pkt.GridSizeZ},
+				"wg_size": []uint16{
+					pkt.WorkgroupSizeX, pkt.WorkgroupSizeY,
+					pkt.WorkgroupSizeZ},
+			},
+		}
+	case *protocol.MemCopyH2DReq:
+		return &perfettoSlice{
+			name:     "MemCopyH2D",
+			category: "memcopy",
+			args: map[string]interface{}{
+				"address": fmt.Sprintf("0x%x", msg.DstAddress),
+				"bytes":   len(msg.SrcBuffer),
+			},
+		}
+	case *protocol.MemCopyD2HReq:
+		return &perfettoSlice{
+			name:     "MemCopyD2H",
+			category: "memcopy",
+			args: map[string]interface{}{
+				"address": fmt.Sprintf("0x%x", msg.SrcAddress),
+				"bytes":   len(msg.DstBuffer),
+			},
+		}
+	case *protocol.FlushReq:
+		return &perfettoSlice{
+			name:     "CacheFlush",
+			category: "flush",
+			args: map[string]interface{}{
+				"invalidate": msg.InvalidateAllCacheLines,
+			},
+		}
+	case *protocol.MapWGReq:
+		wg := msg.WorkGroup
+		return &perfettoSlice{
+			name:     fmt.Sprintf("WG[%d,%d,%d]", wg.IDX, wg.IDY, wg.IDZ),
+			category: "workgroup",
+			args: map[string]interface{}{
+				"kernel":         sampling.CodeObjectName(wg.CodeObject),
+				"num_wavefronts": len(wg.Wavefronts),
+			},
+		}
+	}
+
+	return nil
+}
+
+// StepTask does nothing.
+func (t *perfettoTracer) StepTask(_ tracing.Task) {}
+
+// AddMilestone does nothing.
+func (t *perfettoTracer) AddMilestone(_ tracing.Milestone) {}
+
+// EndTask ends the slice of the task.
+func (t *perfettoTracer) EndTask(task tracing.Task) {
+	t.Lock()
+	defer t.Unlock()
+
+	slice, found := t.inflight[task.ID]
+	if !found {
+		return
+	}
+
+	delete(t.inflight, task.ID)
+
+	slice.numTasks--
+	if slice.numTasks > 0 {
+		return
+	}
+
+	slice.endTime = t.CurrentTime()
+	delete(t.cmdSlices, slice.cmdKey)
+}
+
+// traceEvent is an event in the Chrome trace-event format. Times are in
+// microseconds.
+type traceEvent struct {
+	Name     string                 `json:"name"`
+	Category string                 `json:"cat,omitempty"`
+	Phase    string                 `json:"ph"`
+	Time     float64                `json:"ts"`
+	Duration float64                `json:"dur"`
+	PID      int                    `json:"pid"`
+	TID      int                    `json:"tid"`
+	Args     map[string]interface{} `json:"args,omitempty"`
+}
+
+// WriteFile writes the slices that have ended in the Chrome trace-event
+// format. Each GPU is a process, and each track is a thread of the process.
+func (t *perfettoTracer) WriteFile(path string) error {
+	t.Lock()
+	defer t.Unlock()
+
+	events := []traceEvent{}
+	nextTID := make(map[int]int)
+
+	for _, track := range t.tracks {
+		if _, found := nextTID[track.gpu]; !found {
+			events = append(events,
+				metadataEvent("process_name", track.gpu, 0,
+					"name", fmt.Sprintf("GPU[%d]", track.gpu)),
+				metadataEvent("process_sort_index", track.gpu, 0,
+					"sort_index", track.gpu))
+		}
+
+		for lane, slices := range packSlices(track.slices) {
+			tid := nextTID[track.gpu]
+			nextTID[track.gpu]++
+
+			events = append(events,
+				metadataEvent("thread_name", track.gpu, tid,
+					"name", laneName(track, lane)),
+				metadataEvent("thread_sort_index", track.gpu, tid,
+					"sort_index", tid))
+
+			for _, s := range slices {
+				events = append(events, traceEvent{
+					Name:     s.name,
+					Category: s.category,
+					Phase:    "X",
+					Time:     float64(s.startTime) * 1e6,
+					Duration: float64(s.endTime-s.startTime) * 1e6,
+					PID:      track.gpu,
+					TID:      tid,
+					Args:     s.args,
+				})
+			}
+		}
+	}
+
+	data, err := json.Marshal(map[string]interface{}{
+		"traceEvents":     events,
+		"displayTimeUnit": "ns",
+	})
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0644)
+}
+
+func metadataEvent(
+	name string,
+	pid, tid int,
+	key string,
+	value interface{},
+) traceEvent {
+	return traceEvent{
+		Name:  name,
+		Phase: "M",
+		PID:   pid,
+		TID:   tid,
+		Args:  map[string]interface{}{key: value},
+	}
+}
+
+func laneName(track *perfettoTrack, lane int) string {
+	name := strings.TrimPrefix(track.name, gpuNameRegexp.FindString(track.name))
+	name = strings.TrimPrefix(name, ".")
+	if name == "" {
+		name = "GPU"
+	}
+
+	if lane > 0 {
+		name = fmt.Sprintf("%s (%d)", name, lane+1)
+	}
+
+	return name
+}
+
+// packSlices places the slices that have ended on the fewest lanes, so that
+// the slices on a lane do not overlap. A track always has at least one lane.
+func packSlices(slices []*perfettoSlice) [][]*perfettoSlice {
+	sorted := make([]*perfettoSlice, 0, len(slices))
+	for _, s := range slices {
+		if s.numTasks == 0 {
+			sorted = append(sorted, s)
+		}
+	}
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].startTime < sorted[j].startTime
+	})
+
+	lanes := [][]*perfettoSlice{nil}
+	for _, s := range sorted {
+		placed := false
+		for i, lane := range lanes {
+			if len(lane) == 0 || lane[len(lane)-1].endTime <= s.startTime {
+				lanes[i] = append(lane, s)
+				placed = true
+				break
+			}
+		}
+
+		if !placed {
+			lanes = append(lanes, []*perfettoSlice{s})
+		}
+	}
+
+	return lanes
+}
@@ -0,0 +1,287 @@
+package runner
+
+import (
+	"debug/elf"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/akita/v4/sim"
+	"github.com/sarchlab/akita/v4/tracing"
+	"github.com/sarchlab/mgpusim/v4/amd/insts"
+	"github.com/sarchlab/mgpusim/v4/amd/kernels"
+	"github.com/sarchlab/mgpusim/v4/amd/protocol"
+)
+
+type fakeTimeTeller struct {
+	now sim.VTimeInSec
+}
+
+func (t *fakeTimeTeller) CurrentTime() sim.VTimeInSec {
+	return t.now
+}
+
+var _ = Describe("Perfetto Tracer", func() {
+	var (
+		timeTeller *fakeTimeTeller
+		tracer     *perfettoTracer
+	)
+
+	newSlice := func(start, end sim.VTimeInSec) *perfettoSlice {
+		return &perfettoSlice{name: "s", startTime: start, endTime: end}
+	}
+
+	newMemCopy := func(gpu string) *protocol.MemCopyH2DReq {
+		req := &protocol.MemCopyH2DReq{SrcBuffer: make([]byte, 4096)}
+		req.ID = sim.GetIDGenerator().Generate()
+		req.Dst = sim.RemotePort(gpu + ".CommandProcessor.ToDriver")
+
+		return req
+	}
+
+	newMapWG := func(idX int) *protocol.MapWGReq {
+		wg := &kernels.WorkGroup{
+			IDX: idX,
+			CodeObject: &insts.KernelCodeObject{
+				Symbol: &elf.Symbol{Name: "vec_add"},
+			},
+			Wavefronts: make([]*kernels.Wavefront, 4),
+		}
+
+		return protocol.MapWGReqBuilder{}.WithWG(wg).Build()
+	}
+
+	startTask := func(
+		id, parentID, kind, location string,
+		msg sim.Msg,
+		time sim.VTimeInSec,
+	) {
+		timeTeller.now = time
+		tracer.StartTask(tracing.Task{
+			ID:       id,
+			ParentID: parentID,
+			Kind:     kind,
+			Location: location,
+			Detail:   msg,
+		})
+	}
+
+	endTask := func(id string, time sim.VTimeInSec) {
+		timeTeller.now = time
+		tracer.EndTask(tracing.Task{ID: id})
+	}
+
+	BeforeEach(func() {
+		timeTeller = &fakeTimeTeller{}
+		tracer = newPerfettoTracer(timeTeller)
+	})
+
+	Context("packing slices", func() {
+		It("should always have a lane", func() {
+			lanes := packSlices(nil)
+
+			Expect(lanes).To(HaveLen(1))
+			Expect(lanes[0]).To(BeEmpty())
+		})
+
+		It("should place the slices that do not overlap on one lane", func() {
+			s0 := newSlice(2, 3)
+			s1 := newSlice(0, 1)
+			s2 := newSlice(1, 2)
+
+			lanes := packSlices([]*perfettoSlice{s0, s1, s2})
+
+			Expect(lanes).To(Equal([][]*perfettoSlice{{s1, s2, s0}}))
+		})
+
+		It("should place overlapping slices on the first free lane", func() {
+			s0 := newSlice(0, 4)
+			s1 := newSlice(1, 2)
+			s2 := newSlice(1, 3)
+			s3 := newSlice(2, 5)
+
+			lanes := packSlices([]*perfettoSlice{s0, s1, s2, s3})
+
+			Expect(lanes).To(Equal([][]*perfettoSlice{
+				{s0},
+				{s1, s3},
+				{s2},
+			}))
+		})
+
+		It("should skip the slices that have not ended", func() {
+			s0 := newSlice(0, 1)
+			s1 := newSlice(0, 0)
+			s1.numTasks = 1
+
+			lanes := packSlices([]*perfettoSlice{s0, s1})
+
+			Expect(lanes).To(Equal([][]*perfettoSlice{{s0}}))
+		})
+	})
+
+	Context("tracking tasks", func() {
+		It("should put the GPU track before its components", func() {
+			tracer.track("GPU[2].SA[0].CU[1]")
+			tracer.track("GPU[1]")
+
+			Expect(tracer.tracks).To(HaveLen(3))
+			Expect(tracer.tracks[0].name).To(Equal("GPU[2]"))
+			Expect(tracer.tracks[0].gpu).To(Equal(2))
+			Expect(tracer.tracks[1].name).To(Equal("GPU[2].SA[0].CU[1]"))
+			Expect(tracer.tracks[2].name).To(Equal("GPU[1]"))
+		})
+
+		It("should show the requests of a driver command as one slice",
+			func() {
+				startTask("r0", "cmd", "req_out", "Driver",
+					newMemCopy("GPU[1]"), 1)
+				startTask("r1", "cmd", "req_out", "Driver",
+					newMemCopy("GPU[1]"), 2)
+				endTask("r0", 3)
+
+				track := tracer.trackByName["GPU[1]"]
+				Expect(track.slices).To(HaveLen(1))
+				Expect(track.slices[0].numTasks).To(Equal(1))
+
+				endTask("r1", 4)
+
+				s := track.slices[0]
+				Expect(s.name).To(Equal("MemCopyH2D"))
+				Expect(s.category).To(Equal("memcopy"))
+				Expect(s.numTasks).To(Equal(0))
+				Expect(s.startTime).To(Equal(sim.VTimeInSec(1)))
+				Expect(s.endTime).To(Equal(sim.VTimeInSec(4)))
+				Expect(tracer.inflight).To(BeEmpty())
+				Expect(tracer.cmdSlices).To(BeEmpty())
+			})
+
+		It("should start a new slice for the next driver command", func() {
+			startTask("r0", "cmd0", "req_out", "Driver",
+				newMemCopy("GPU[1]"), 1)
+			endTask("r0", 2)
+			startTask("r1", "cmd0", "req_out", "Driver",
+				newMemCopy("GPU[1]"), 3)
+			endTask("r1", 4)
+
+			Expect(tracer.trackByName["GPU[1]"].slices).To(HaveLen(2))
+		})
+
+		It("should separate the requests of a command to different GPUs",
+			func() {
+				startTask("r0", "cmd", "req_out", "Driver",
+					newMemCopy("GPU[1]"), 1)
+				startTask("r1", "cmd", "req_out", "Driver",
+					newMemCopy("GPU[2]"), 1)
+
+				Expect(tracer.trackByName["GPU[1]"].slices).To(HaveLen(1))
+				Expect(tracer.trackByName["GPU[2]"].slices).To(HaveLen(1))
+			})
+
+		It("should trace the work-groups at the compute units", func() {
+			startTask("wg", "", "req_in", "GPU[1].SA[0].CU[0]",
+				newMapWG(3), 1)
+			endTask("wg", 2)
+
+			slices := tracer.trackByName["GPU[1].SA[0].CU[0]"].slices
+			Expect(slices).To(HaveLen(1))
+			Expect(slices[0].name).To(Equal("WG[3,0,0]"))
+			Expect(slices[0].args).To(HaveKeyWithValue("kernel", "vec_add"))
+			Expect(slices[0].args).To(HaveKeyWithValue("num_wavefronts", 4))
+		})
+
+		It("should ignore other tasks", func() {
+			startTask("r0", "cmd", "req_out", "GPU[1].CommandProcessor",
+				newMemCopy("GPU[1]"), 1)
+			startTask("r1", "cmd", "req_out", "Driver",
+				newMemCopy("Storage"), 1)
+			startTask("r2", "", "req_in", "GPU[1].CommandProcessor",
+				protocol.NewLaunchKernelRsp("", "", ""), 1)
+			startTask("r3", "", "kernel", "GPU[1].CommandProcessor",
+				newMapWG(0), 1)
+			tracer.StartTask(tracing.Task{ID: "r4", Kind: "req_in"})
+
+			Expect(tracer.tracks).To(BeEmpty())
+			Expect(tracer.inflight).To(BeEmpty())
+		})
+	})
+
+	Context("writing the file", func() {
+		readEvents := func() []map[string]interface{} {
+			path := filepath.Join(GinkgoT().TempDir(), "trace.json")
+			Expect(tracer.WriteFile(path)).To(Succeed())
+
+			data, err := os.ReadFile(path)
+			Expect(err).NotTo(HaveOccurred())
+
+			var trace struct {
+				TraceEvents     []map[string]interface{} `json:"traceEvents"`
+				DisplayTimeUnit string                   `json:"displayTimeUnit"`
+			}
+			Expect(json.Unmarshal(data, &trace)).To(Succeed())
+			Expect(trace.DisplayTimeUnit).To(Equal("ns"))
+
+			return trace.TraceEvents
+		}
+
+		It("should write an empty trace", func() {
+			Expect(readEvents()).To(BeEmpty())
+		})
+
+		It("should write the tracks as threads of the GPU processes", func() {
+			tracer.track("GPU[1].CommandProcessor")
+			startTask("r0", "cmd", "req_out", "Driver",
+				newMemCopy("GPU[1]"), 1e-6)
+			endTask("r0", 3e-6)
+			startTask("wg0", "", "req_in", "GPU[1].CommandProcessor",
+				newMapWG(0), 1e-6)
+			startTask("wg1", "", "req_in", "GPU[1].CommandProcessor",
+				newMapWG(1), 2e-6)
+			endTask("wg0", 4e-6)
+			endTask("wg1", 5e-6)
+			startTask("wg2", "", "req_in", "GPU[1].CommandProcessor",
+				newMapWG(2), 6e-6)
+
+			events := readEvents()
+
+			var names, slices []string
+			for _, e := range events {
+				Expect(e["pid"]).To(BeNumerically("==", 1))
+
+				switch e["ph"] {
+				case "M":
+					if e["name"] == "thread_name" {
+						args := e["args"].(map[string]interface{})
+						names = append(names, args["name"].(string))
+					}
+				case "X":
+					slices = append(slices, e["name"].(string))
+				}
+			}
+
+			Expect(names).To(Equal([]string{
+				"GPU", "CommandProcessor", "CommandProcessor (2)"}))
+			Expect(slices).To(Equal([]string{
+				"MemCopyH2D", "WG[0,0,0]", "WG[1,0,0]"}))
+
+			Expect(events[0]["name"]).To(Equal("process_name"))
+			Expect(events[0]["args"]).
+				To(HaveKeyWithValue("name", "GPU[1]"))
+			Expect(events[1]["name"]).To(Equal("process_sort_index"))
+
+			memCopy := events[4]
+			Expect(memCopy["name"]).To(Equal("MemCopyH2D"))
+			Expect(memCopy["cat"]).To(Equal("memcopy"))
+			Expect(memCopy["tid"]).To(BeNumerically("==", 0))
+			Expect(memCopy["ts"]).To(BeNumerically("~", 1, 1e-9))
+			Expect(memCopy["dur"]).To(BeNumerically("~", 2, 1e-9))
+			Expect(memCopy["args"]).To(HaveKeyWithValue("bytes", 4096.0))
+
+			wg1 := events[len(events)-1]
+			Expect(wg1["name"]).To(Equal("WG[1,0,0]"))
+			Expect(wg1["tid"]).To(BeNumerically("==", 2))
+		})
+	})
+})
@@ -64,6 +64,11 @@ type simdBusyTimeTracer struct {
 	simd   tracing.NamedHookable
 }
 
+type issueSlotCounter struct {
+	cu        *cu.ComputeUnit
+	scheduler *cu.SchedulerImpl
+}
+
 type cuCPIStackTracer struct {
 	cu     tracing.NamedHookable
 	tracer *cu.CPIStackTracer
@@ -82,6 +87,7 @@ type reporter struct {
 	rdmaTransactionCounters []*rdmaTransactionCountTracer
 	simdBusyTimeTracers     []*simdBusyTimeTracer
 	cuCPITraces             []*cuCPIStackTracer
+	issueSlotCounters       []*issueSlotCounter
 
 	ReportInstCount            bool
 	ReportCacheLatency         bool
@@ -115,6 +121,7 @@ func (r *reporter) injectTracers(s *simulation.Simulation) {
 	r.injectRDMAEngineTracer(s)
 	r.injectDRAMTracer(s)
 	r.injectSIMDBusyTimeTracer(s)
+	r.injectIssueSlotCounters(s)
 }
 
 func (r *reporter) injectKernelTimeTracer(s *simulation.Simulation) {
@@ -204,6 +211,30 @@ func (r *reporter) injectCUCPIHook(s *simulation.Simulation) {
 	}
 }
 
+func (r *reporter) injectIssueSlotCounters(s *simulation.Simulation) {
+	if !*reportAll && !*issueSlotReportFlag {
+		return
+	}
+
+	for _, comp := range s.Components() {
+		computeUnit, ok := comp.(*cu.ComputeUnit)
+		if !ok {
+			continue
+		}
+
+		scheduler, ok := computeUnit.Scheduler.(*cu.SchedulerImpl)
+		if !ok {
+			continue
+		}
+
+		r.issueSlotCounters = append(r.issueSlotCounters,
+			&issueSlotCounter{
+				cu:        computeUnit,
+				scheduler: scheduler,
+			})
+	}
+}
+
 func (r *reporter) injectCacheLatencyTracer(s *simulation.Simulation) {
 	if !*reportAll && !*cacheLatencyReportFlag {
 		return
@@ -357,6 +388,7 @@ func (r *reporter) report() {
 	r.reportInstCount()
 	r.reportCPIStack()
 	r.reportSIMDBusyTime()
+	r.reportIssueSlots()
 	r.reportCacheLatency()
 	r.reportCacheHitRate()
 	r.reportTLBHitRate()
@@ -497,6 +529,40 @@ func (r *reporter) reportSIMDBusyTime() {
 	}
 }
 
+func (r *reporter) reportIssueSlots() {
+	kernelTime := float64(r.kernelTimeTracer.tracer.BusyTime())
+	for _, c := range r.issueSlotCounters {
+		numCycle := kernelTime * float64(c.cu.Freq)
+		numSlots := numCycle * float64(len(c.cu.WfPools))
+		used := float64(c.scheduler.IssueSlotsUsed())
+		policy := c.scheduler.IssuePolicy()
+
+		r.dataRecorder.InsertData(
+			tableName,
+			metric{
+				Location: c.cu.Name(),
+				What:     "issue_slots_used." + policy,
+				Value:    used,
+				Unit:     "count",
+			},
+		)
+
+		if numSlots == 0 {
+			continue
+		}
+
+		r.dataRecorder.InsertData(
+			tableName,
+			metric{
+				Location: c.cu.Name(),
+				What:     "issue_slot_utilization." + policy,
+				Value:    used / numSlots,
+				Unit:     "fraction",
+			},
+		)
+	}
+}
+
 func (r *reporter) reportCacheLatency() {
 	for _, tracer := range r.cacheLatencyTracers {
 		if tracer.tracer.AverageTime() == 0 {
@@ -3,19 +3,27 @@ package runner
 
 import (
 	"log"
+	"net"
+	"os"
+	"strings"
 
 	// Enable profiling
 	_ "net/http/pprof"
 	"sync"
 
+	"github.com/sarchlab/akita/v4/mem/vm"
 	"github.com/sarchlab/akita/v4/sim"
 	"github.com/sarchlab/akita/v4/simulation"
 	"github.com/sarchlab/mgpusim/v4/amd/arch"
 	"github.com/sarchlab/mgpusim/v4/amd/benchmarks"
+	"github.com/sarchlab/mgpusim/v4/amd/cosim"
 	"github.com/sarchlab/mgpusim/v4/amd/driver"
+	"github.com/sarchlab/mgpusim/v4/amd/emu"
 	"github.com/sarchlab/mgpusim/v4/amd/samples/runner/emusystem"
 	"github.com/sarchlab/mgpusim/v4/amd/samples/runner/timingconfig"
 	"github.com/sarchlab/mgpusim/v4/amd/sampling"
+	"github.com/sarchlab/mgpusim/v4/amd/sanitizer"
+	"github.com/sarchlab/mgpusim/v4/amd/timing/cu"
 )
 
 type verificationPreEnablingBenchmark interface {
@@ -30,12 +38,45 @@ type Runner struct {
 	platform   *sim.Domain
 	reporter   *reporter
 
-	Timing           bool
-	Verify           bool
-	Parallel         bool
-	UseUnifiedMemory bool
-	ArchType         arch.Type
-	GPUType          string
+	Timing             bool
+	Verify             bool
+	Parallel           bool
+	UseUnifiedMemory   bool
+	PageMigration      bool
+	PlacementPolicy    driver.PlacementPolicy
+	CheckpointDir      string
+	CheckpointInterval int
+	Restore            bool
+	TimingStartKernel  int
+	ArchType           arch.Type
+	ArchConfig         *arch.Config
+	GPUType            string
+	Coalescer          cu.CoalescerFactory
+	IssuePolicy        string
+	FetchPolicy        string
+	DispatchAlg        string
+	MaxWGPerCU         int
+
+	KernelSamplingProfile string
+	KernelSampling        string
+	kernelProfiler        *sampling.KernelProfiler
+	kernelSampler         *kernelSampler
+
+	PerfettoTrace  string
+	perfettoTracer *perfettoTracer
+
+	Sanitize  bool
+	sanitizer *sanitizer.Sanitizer
+
+	DetectRaces  bool
+	RaceSeed     int64
+	raceDetector *emu.RaceDetector
+
+	DebuggerAddr string
+	debugger     *emu.Debugger
+
+	CoSim        bool
+	coSimChecker *cosim.Checker
 
 	GPUIDs     []int
 	benchmarks []benchmarks.Benchmark
@@ -57,6 +98,10 @@ func (r *Runner) Init() *Runner {
 
 	r.createUnifiedGPUs()
 
+	if r.PerfettoTrace != "" {
+		r.perfettoTracer = attachPerfettoTracer(r.simulation)
+	}
+
 	return r
 }
 
@@ -84,13 +129,68 @@ func (r *Runner) buildEmuPlatform() {
 	b := emusystem.MakeBuilder().
 		WithSimulation(r.simulation).
 		WithNumGPUs(r.GPUIDs[len(r.GPUIDs)-1]).
-		WithArchitecture(r.ArchType)
+		WithArchitecture(r.ArchType).
+		WithWavefrontSize(r.ArchConfig.WavefrontSize)
+
+	if r.PlacementPolicy != nil {
+		b = b.WithPlacementPolicy(r.PlacementPolicy)
+	}
+
+	if r.CheckpointDir != "" {
+		b = b.
+			WithCheckpointDir(r.CheckpointDir).
+			WithCheckpointInterval(r.CheckpointInterval)
+	}
+
+	if r.Restore {
+		b = b.WithRestore()
+	}
 
 	if *isaDebug {
-		b = b.WithDebugISA()
+		b = b.WithDebugISA().WithDebugISAWGFilter(parseDebugISAWGFlag())
+	}
+
+	if r.Sanitize {
+		r.sanitizer = sanitizer.NewSanitizer()
+		b = b.WithSanitizer(r.sanitizer)
+	}
+
+	if r.DetectRaces {
+		r.raceDetector = emu.NewRaceDetector()
+		if r.RaceSeed != 0 {
+			r.raceDetector.RandomizeInterleaving(r.RaceSeed)
+		}
+
+		b = b.WithRaceDetector(r.raceDetector)
+	}
+
+	if r.DebuggerAddr != "" {
+		r.debugger = emu.NewDebugger(listenDebugger(r.DebuggerAddr))
+		b = b.WithDebugger(r.debugger)
 	}
 
 	r.platform = b.Build()
+
+	if r.KernelSamplingProfile != "" {
+		r.kernelProfiler = attachKernelProfiler(r.simulation)
+	}
+}
+
+// listenDebugger listens on a TCP address, or on a Unix socket if the address
+// starts with "unix:".
+func listenDebugger(addr string) net.Listener {
+	network := "tcp"
+	if path, ok := strings.CutPrefix(addr, "unix:"); ok {
+		network = "unix"
+		addr = path
+	}
+
+	listener, err := net.Listen(network, addr)
+	if err != nil {
+		log.Fatalf("cannot listen for the debugger: %v", err)
+	}
+
+	return listener
 }
 
 func (r *Runner) buildTimingPlatform() {
@@ -99,14 +199,69 @@ func (r *Runner) buildTimingPlatform() {
 	b := timingconfig.MakeBuilder().
 		WithSimulation(r.simulation).
 		WithNumGPUs(r.GPUIDs[len(r.GPUIDs)-1]).
-		WithGPUType(r.GPUType)
+		WithGPUType(r.GPUType).
+		WithArchConfig(r.ArchConfig)
 
 	if *magicMemoryCopy {
 		b = b.WithMagicMemoryCopy()
 	}
 
+	if r.PageMigration {
+		b = b.WithPageMigration()
+	}
+
+	if r.PlacementPolicy != nil {
+		b = b.WithPlacementPolicy(r.PlacementPolicy)
+	}
+
+	if r.CheckpointDir != "" {
+		b = b.
+			WithCheckpointDir(r.CheckpointDir).
+			WithCheckpointInterval(r.CheckpointInterval)
+	}
+
+	if r.Restore {
+		b = b.WithRestore()
+	}
+
+	if r.TimingStartKernel > 0 {
+		b = b.WithFastForward(r.TimingStartKernel, r.ArchType)
+	}
+
+	if r.KernelSampling != "" {
+		r.kernelSampler = newKernelSampler(r.KernelSampling,
+			*kernelSamplingThresholdFlag, *kernelSamplingSamplesFlag)
+		b = b.WithKernelSelector(r.kernelSampler.selector(), r.ArchType)
+	}
+
+	if r.CoSim {
+		b = b.WithCoSimulation(r.ArchType)
+	}
+
+	if r.Coalescer != nil {
+		b = b.WithCoalescer(r.Coalescer)
+	}
+
+	if r.IssuePolicy != "" {
+		b = b.WithIssuePolicy(r.IssuePolicy)
+	}
+
+	if r.FetchPolicy != "" {
+		b = b.WithFetchPolicy(r.FetchPolicy)
+	}
+
+	if r.DispatchAlg != "" {
+		b = b.WithDispatchAlgorithm(r.DispatchAlg).
+			WithMaxWGPerCU(r.MaxWGPerCU)
+	}
+
 	r.platform = b.Build()
 	r.reporter = newReporter(r.simulation)
+	r.coSimChecker = r.Driver().CoSimChecker()
+
+	if r.kernelSampler != nil {
+		r.kernelSampler.attachTimer(r.simulation)
+	}
 }
 
 func (r *Runner) createUnifiedGPUs() {
@@ -143,6 +298,11 @@ func (r *Runner) AddBenchmarkWithoutSettingGPUsToUse(b benchmarks.Benchmark) {
 func (r *Runner) Run() {
 	r.Driver().Run()
 
+	if r.debugger != nil {
+		log.Printf("waiting for the debugger on %s", r.DebuggerAddr)
+		r.debugger.WaitForClient()
+	}
+
 	var wg sync.WaitGroup
 	for _, b := range r.benchmarks {
 		wg.Add(1)
@@ -163,6 +323,44 @@ func (r *Runner) Run() {
 	}
 	wg.Wait()
 
+	if r.Verify && r.PageMigration {
+		r.verifyPageMigration()
+	}
+
+	if r.kernelProfiler != nil {
+		err := r.kernelProfiler.WriteFile(r.KernelSamplingProfile)
+		if err != nil {
+			log.Fatalf("cannot write kernel profile: %v", err)
+		}
+	}
+
+	if r.kernelSampler != nil {
+		r.kernelSampler.report(r.reporter)
+	}
+
+	if r.sanitizer != nil {
+		r.sanitizer.Print(os.Stdout)
+	}
+
+	if r.raceDetector != nil {
+		r.raceDetector.Print(os.Stdout)
+	}
+
+	if r.debugger != nil {
+		r.debugger.Close()
+	}
+
+	if r.coSimChecker != nil {
+		r.coSimChecker.Print(os.Stdout)
+	}
+
+	if r.perfettoTracer != nil {
+		err := r.perfettoTracer.WriteFile(r.PerfettoTrace)
+		if err != nil {
+			log.Fatalf("cannot write perfetto trace: %v", err)
+		}
+	}
+
 	if r.reporter != nil {
 		r.reporter.report()
 		r.reporter.dataRecorder.Flush()
@@ -172,6 +370,40 @@ func (r *Runner) Run() {
 	r.simulation.Terminate()
 }
 
+// verifyPageMigration checks that every migrated page is now placed on the GPU
+// that requested it last.
+func (r *Runner) verifyPageMigration() {
+	migratedPages := r.Driver().MigratedPages()
+	if len(migratedPages) == 0 {
+		log.Fatalf("Page migration is enabled, but no page is migrated.\n")
+	}
+
+	type pageKey struct {
+		pid   vm.PID
+		vAddr uint64
+	}
+
+	lastGPU := make(map[pageKey]uint64)
+	for _, p := range migratedPages {
+		lastGPU[pageKey{p.PID, p.VAddr}] = p.GPUID
+	}
+
+	for key, gpuID := range lastGPU {
+		page, found := r.Driver().FindPage(key.pid, key.vAddr)
+		if !found {
+			log.Fatalf("Migrated page 0x%x is not in the page table.\n",
+				key.vAddr)
+		}
+
+		if page.DeviceID != gpuID || page.IsMigrating {
+			log.Fatalf("Page 0x%x is expected on GPU %d, but is on device %d.\n",
+				key.vAddr, gpuID, page.DeviceID)
+		}
+	}
+
+	log.Printf("Page migration passed! %d pages migrated.\n", len(lastGPU))
+}
+
 // Driver returns the GPU driver used by the current runner.
 func (r *Runner) Driver() *driver.Driver {
 	return r.simulation.GetComponentByName("Driver").(*driver.Driver)
@@ -0,0 +1,47 @@
+// A kernel in which each work-item writes the upper half of the exec mask,
+// which is zero in a wave32 wavefront, to out[global ID]. The kernel uses the
+// CDNA3 instructions, but its descriptor requests wave32 wavefronts, which the
+// assembler only allows for RDNA targets, so the descriptor is written out by
+// hand.
+//
+// Build with:
+//   llvm-mc -triple amdgcn-amd-amdhsa -mcpu=gfx90a -filetype=obj \
+//     wave32.s -o wave32.hsaco
+
+	.amdgcn_target "amdgcn-amd-amdhsa--gfx90a"
+
+	.text
+	.globl	write_exec_hi
+	.p2align	8
+	.type	write_exec_hi,@function
+write_exec_hi:
+	s_load_dwordx2 s[4:5], s[0:1], 0x0 // out
+	s_lshl_b32 s3, s2, 6                // workgroup ID * 64
+	v_add_u32_e32 v1, s3, v0            // global ID
+	v_lshlrev_b32_e32 v2, 2, v1
+	s_mov_b64 s[6:7], exec
+	v_mov_b32_e32 v1, s7                // upper half of exec
+	s_waitcnt lgkmcnt(0)
+	global_store_dword v2, v1, s[4:5]
+	s_endpgm
+.Lfunc_end0:
+	.size	write_exec_hi, .Lfunc_end0-write_exec_hi
+
+	.rodata
+	.p2align	6
+	.globl	write_exec_hi.kd
+	.type	write_exec_hi.kd,@object
+write_exec_hi.kd:
+	.long	0          // group_segment_fixed_size
+	.long	0          // private_segment_fixed_size
+	.long	8          // kernarg_size
+	.long	0          // reserved
+	.quad	write_exec_hi-write_exec_hi.kd
+	.zero	20         // reserved
+	.long	0          // compute_pgm_rsrc3: accum_offset 4
+	.long	0x00ac0040 // compute_pgm_rsrc1: register counts, float mode
+	.long	0x00000084 // compute_pgm_rsrc2: 2 user SGPRs, workgroup ID X
+	.short	0x0408     // kernel_code_properties: kernarg ptr, wave32
+	.short	0          // kernarg_preload
+	.long	0          // reserved
+	.size	write_exec_hi.kd, 64
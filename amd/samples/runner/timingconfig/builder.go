@@ -10,10 +10,15 @@ import (
 	"github.com/sarchlab/akita/v4/noc/networking/pcie"
 	"github.com/sarchlab/akita/v4/sim"
 	"github.com/sarchlab/akita/v4/simulation"
+	"github.com/sarchlab/akita/v4/tracing"
+	"github.com/sarchlab/mgpusim/v4/amd/arch"
+	"github.com/sarchlab/mgpusim/v4/amd/cosim"
 	"github.com/sarchlab/mgpusim/v4/amd/driver"
+	"github.com/sarchlab/mgpusim/v4/amd/samples/runner/emusystem/emugpu"
 	"github.com/sarchlab/mgpusim/v4/amd/samples/runner/timingconfig/gpubuilder"
 	"github.com/sarchlab/mgpusim/v4/amd/samples/runner/timingconfig/mi300a"
 	"github.com/sarchlab/mgpusim/v4/amd/samples/runner/timingconfig/r9nano"
+	"github.com/sarchlab/mgpusim/v4/amd/timing/cu"
 )
 
 // Builder builds a hardware platform for timing simulation.
@@ -27,13 +32,31 @@ type Builder struct {
 	gpuMemSize         uint64
 	log2PageSize       uint64
 	useMagicMemoryCopy bool
+	usePageMigration   bool
+	placementPolicy    driver.PlacementPolicy
+	checkpointDir      string
+	checkpointInterval int
+	restore            bool
+	numFastForward     int
+	kernelSelector     driver.KernelSelector
+	fastForwardArch    arch.Type
+	coSim              bool
+	coSimChecker       *cosim.Checker
 	gpuType            string
+	coalescerFactory   cu.CoalescerFactory
+	archConfig         *arch.Config
+	issuePolicy        string
+	fetchPolicy        string
+	dispatchAlg        string
+	maxWGPerCU         int
+	numWfSlotPerCU     int
 	switchLatency      int // PCIe/interconnect switch latency in cycles
 	d2hCycles          int
 	h2dCycles          int
 
 	platform          *sim.Domain
 	globalStorage     *mem.Storage
+	pageTable         vm.PageTable
 	rdmaAddressMapper *mem.BankedAddressPortMapper
 }
 
@@ -43,10 +66,12 @@ func MakeBuilder() Builder {
 		numGPUs:            1,
 		numCUPerSA:         4,
 		numSAPerGPU:        16,
+		numWfSlotPerCU:     40,
 		cpuMemSize:         4 * mem.GB,
 		gpuMemSize:         4 * mem.GB,
 		log2PageSize:       12,
 		useMagicMemoryCopy: false,
+		usePageMigration:   false,
 		gpuType:            "r9nano",
 		switchLatency:      140, // default PCIe Gen4
 		d2hCycles:          300,
@@ -72,12 +97,133 @@ func (b Builder) WithMagicMemoryCopy() Builder {
 	return b
 }
 
+// WithPageMigration enables migrating unified memory pages to the GPU that
+// accesses them. The MMU asks the driver to migrate a page, and the driver
+// moves the page with the page migration controllers of the GPUs.
+func (b Builder) WithPageMigration() Builder {
+	b.usePageMigration = true
+	return b
+}
+
+// WithPlacementPolicy sets the policy that places the pages of distributed
+// and unified memory on the GPUs. The first-touch policy requires page
+// migration.
+func (b Builder) WithPlacementPolicy(p driver.PlacementPolicy) Builder {
+	b.placementPolicy = p
+	return b
+}
+
+// WithCheckpointDir sets the directory that the driver writes checkpoints to
+// and restores checkpoints from.
+func (b Builder) WithCheckpointDir(dir string) Builder {
+	b.checkpointDir = dir
+	return b
+}
+
+// WithCheckpointInterval writes a checkpoint after every numKernels kernels.
+func (b Builder) WithCheckpointInterval(numKernels int) Builder {
+	b.checkpointInterval = numKernels
+	return b
+}
+
+// WithRestore resumes the simulation from the checkpoint in the checkpoint
+// directory.
+func (b Builder) WithRestore() Builder {
+	b.restore = true
+	return b
+}
+
+// WithFastForward runs the first numKernels kernels on emulated GPUs of the
+// given architecture and simulates the rest of the kernels in detail. The
+// emulated GPUs share the storage and the page table with the simulated GPUs.
+func (b Builder) WithFastForward(numKernels int, archType arch.Type) Builder {
+	b.numFastForward = numKernels
+	b.fastForwardArch = archType
+	return b
+}
+
+// WithKernelSelector runs the kernels that the selector does not pick on
+// emulated GPUs of the given architecture, like WithFastForward.
+func (b Builder) WithKernelSelector(
+	s driver.KernelSelector,
+	archType arch.Type,
+) Builder {
+	b.kernelSelector = s
+	b.fastForwardArch = archType
+	return b
+}
+
+func (b *Builder) fastForwards() bool {
+	return b.numFastForward > 0 || b.kernelSelector != nil
+}
+
+// WithCoSimulation runs each kernel on an emulated GPU of the given
+// architecture before the simulated GPU and compares the two runs. The driver
+// returns the checker with CoSimChecker.
+func (b Builder) WithCoSimulation(archType arch.Type) Builder {
+	b.coSim = true
+	b.fastForwardArch = archType
+	return b
+}
+
 // WithGPUType sets the GPU type for timing simulation (r9nano or mi300a).
 func (b Builder) WithGPUType(gpuType string) Builder {
 	b.gpuType = gpuType
 	return b
 }
 
+// WithCoalescer sets how the CUs combine the lanes of a vector memory
+// instruction into memory transactions.
+func (b Builder) WithCoalescer(factory cu.CoalescerFactory) Builder {
+	b.coalescerFactory = factory
+	return b
+}
+
+// WithIssuePolicy sets the name of the policy that decides which wavefronts
+// issue first in each SIMD of the CUs.
+func (b Builder) WithIssuePolicy(name string) Builder {
+	b.issuePolicy = name
+	return b
+}
+
+// WithFetchPolicy sets the name of the policy that decides which wavefront
+// fetches instructions first in the CUs.
+func (b Builder) WithFetchPolicy(name string) Builder {
+	b.fetchPolicy = name
+	return b
+}
+
+// WithDispatchAlgorithm sets the algorithm that the Command Processors use to
+// dispatch the work-groups to the CUs.
+func (b Builder) WithDispatchAlgorithm(name string) Builder {
+	b.dispatchAlg = name
+	return b
+}
+
+// WithMaxWGPerCU sets the number of work-groups that the "occupancy-capped"
+// dispatching algorithm can dispatch to each CU at the same time.
+func (b Builder) WithMaxWGPerCU(n int) Builder {
+	b.maxWGPerCU = n
+	return b
+}
+
+// WithArchConfig sets the architecture whose wavefront size and SIMD
+// configuration the CUs of the GPUs follow.
+func (b Builder) WithArchConfig(cfg *arch.Config) Builder {
+	b.archConfig = cfg
+	return b
+}
+
+// wavefrontSize returns the wavefront size that the GPUs run, or 0 if they
+// run the one of the kernels.
+func (b *Builder) wavefrontSize() int {
+	if b.archConfig == nil {
+		return 0
+	}
+
+	return b.archConfig.WavefrontSize
+}
+
 // Build builds the hardware platform.
 func (b Builder) Build() *sim.Domain {
 	b.adjustConfigForGPUType()
@@ -89,13 +235,22 @@ func (b Builder) Build() *sim.Domain {
 		uint64(b.numGPUs)*b.gpuMemSize + b.cpuMemSize)
 
 	mmuComp, pageTable := b.createMMU()
+	b.pageTable = pageTable
+
+	if b.coSim {
+		b.coSimChecker = cosim.NewChecker(pageTable, b.globalStorage)
+	}
+
 	gpuDriver := b.buildGPUDriver(pageTable)
 
 	gpuBuilder := b.createGPUBuilder(mmuComp)
 	pcieConnector, rootComplexID :=
 		b.createConnection(gpuDriver, mmuComp)
 
-	mmuComp.MigrationServiceProvider = gpuDriver.GetPortByName("MMU").AsRemote()
+	if b.usePageMigration {
+		mmuComp.MigrationServiceProvider =
+			gpuDriver.GetPortByName("MMU").AsRemote()
+	}
 
 	b.createRDMAAddrTable()
 	pmcAddressTable := b.createPMCPageTable()
@@ -107,6 +262,10 @@ func (b Builder) Build() *sim.Domain {
 
 	pcieConnector.EstablishRoute()
 
+	if b.coSim {
+		b.attachCoSimTracers()
+	}
+
 	return b.platform
 }
 
@@ -121,6 +280,7 @@ func (b *Builder) adjustConfigForGPUType() {
 	case "mi300a":
 		b.numCUPerSA = mi300a.NumCUPerShaderArray
 		b.numSAPerGPU = mi300a.NumShaderArray
+		b.numWfSlotPerCU = 32 // 4 SIMDs with 8 wavefront slots each
 		b.switchLatency = 15 // MI300A uses on-die Infinity Fabric, not PCIe
 		b.d2hCycles = 150  // MI300A Infinity Fabric latency (~83ns)
 		b.h2dCycles = 250  // MI300A command processing (~139ns)
@@ -154,6 +314,32 @@ func (b *Builder) buildGPUDriver(
 		gpuDriverBuilder = gpuDriverBuilder.WithMagicMemoryCopyMiddleware()
 	}
 
+	if b.placementPolicy != nil {
+		gpuDriverBuilder = gpuDriverBuilder.WithPlacementPolicy(b.placementPolicy)
+	}
+
+	if b.checkpointDir != "" {
+		gpuDriverBuilder = gpuDriverBuilder.
+			WithCheckpointDir(b.checkpointDir).
+			WithCheckpointInterval(b.checkpointInterval)
+	}
+
+	if b.restore {
+		gpuDriverBuilder = gpuDriverBuilder.WithRestore()
+	}
+
+	if b.numFastForward > 0 {
+		gpuDriverBuilder = gpuDriverBuilder.WithFastForward(b.numFastForward)
+	}
+
+	if b.kernelSelector != nil {
+		gpuDriverBuilder = gpuDriverBuilder.WithKernelSelector(b.kernelSelector)
+	}
+
+	if b.coSim {
+		gpuDriverBuilder = gpuDriverBuilder.WithCoSimulation(b.coSimChecker)
+	}
+
 	gpuDriver := gpuDriverBuilder.
 		WithEngine(b.simulation.GetEngine()).
 		WithPageTable(pageTable).
@@ -179,13 +365,25 @@ func (b *Builder) createGPUBuilder(
 			WithSimulation(b.simulation).
 			WithMMU(mmuComponent).
 			WithLog2PageSize(b.log2PageSize).
-			WithGlobalStorage(b.globalStorage)
+			WithGlobalStorage(b.globalStorage).
+			WithCoalescer(b.coalescerFactory).
+			WithArchConfig(b.archConfig).
+			WithIssuePolicy(b.issuePolicy).
+			WithFetchPolicy(b.fetchPolicy).
+			WithDispatchAlgorithm(b.dispatchAlg).
+			WithMaxWGPerCU(b.maxWGPerCU)
 	default:
 		return r9nano.MakeBuilder().
 			WithSimulation(b.simulation).
 			WithMMU(mmuComponent).
 			WithLog2PageSize(b.log2PageSize).
-			WithGlobalStorage(b.globalStorage)
+			WithGlobalStorage(b.globalStorage).
+			WithCoalescer(b.coalescerFactory).
+			WithArchConfig(b.archConfig).
+			WithIssuePolicy(b.issuePolicy).
+			WithFetchPolicy(b.fetchPolicy).
+			WithDispatchAlgorithm(b.dispatchAlg).
+			WithMaxWGPerCU(b.maxWGPerCU)
 	}
 }
 
@@ -266,27 +464,76 @@ func (b *Builder) createGPU(
 		WithGPUID(uint64(index)).
 		WithMemAddrOffset(memAddrOffset).
 		WithRDMAAddressMapper(b.rdmaAddressMapper).
+		WithDriver(gpuDriver.GetPortByName("GPU")).
 		Build(name)
 
 	gpuDriver.RegisterGPU(
 		gpu.GetPortByName("CommandProcessor"),
 		driver.DeviceProperties{
-			CUCount:  b.numCUPerSA * b.numSAPerGPU,
-			DRAMSize: b.gpuMemSize,
+			CUCount:            b.numCUPerSA * b.numSAPerGPU,
+			DRAMSize:           b.gpuMemSize,
+			WavefrontSize:      b.wavefrontSize(),
+			MaxWavefrontsPerCU: b.numWfSlotPerCU,
 		},
 	)
-	// gpu.CommandProcessor.Driver = gpuDriver.GetPortByName("GPU")
 
 	b.configRDMAEngine(gpu)
-	// b.configPMC(gpu, gpuDriver, pmcAddressTable)
+
+	if b.usePageMigration {
+		b.configPMC(gpu, gpuDriver, pmcAddressTable)
+	}
 
 	pcieConnector.PlugInDevice(pcieSwitchID, gpu.Ports())
 
+	if b.fastForwards() || b.coSim {
+		b.createEmulatedGPU(index, gpuDriver, pcieConnector, pcieSwitchID)
+	}
+
 	// b.gpus = append(b.gpus, gpu)
 
 	return gpu
 }
 
+// createEmulatedGPU creates the GPU that runs the fast-forwarded or
+// co-simulated kernels on behalf of the simulated GPU with the same index.
+func (b *Builder) createEmulatedGPU(
+	index int,
+	gpuDriver *driver.Driver,
+	pcieConnector *pcie.Connector,
+	pcieSwitchID int,
+) {
+	gpu := emugpu.MakeBuilder().
+		WithSimulation(b.simulation).
+		WithDriver(gpuDriver).
+		WithPageTable(b.pageTable).
+		WithLog2PageSize(b.log2PageSize).
+		WithStorage(b.globalStorage).
+		WithArchitecture(b.fastForwardArch).
+		WithCoSimChecker(b.coSimChecker).
+		Build(fmt.Sprintf("EmuGPU[%d]", index))
+
+	if b.coSim {
+		gpuDriver.RegisterCoSimGPU(gpu.GetPortByName("CommandProcessor"))
+	} else {
+		gpuDriver.RegisterFastForwardGPU(gpu.GetPortByName("CommandProcessor"))
+	}
+
+	pcieConnector.PlugInDevice(pcieSwitchID, gpu.Ports())
+}
+
+// attachCoSimTracers lets the compute units of the simulated GPUs check their
+// wavefronts with the co-simulation checker.
+func (b *Builder) attachCoSimTracers() {
+	for _, comp := range b.simulation.Components() {
+		computeUnit, ok := comp.(*cu.ComputeUnit)
+		if !ok {
+			continue
+		}
+
+		tracing.CollectTrace(computeUnit, cu.NewCoSimTracer(b.coSimChecker))
+	}
+}
+
 func (b *Builder) configRDMAEngine(
 	gpu *sim.Domain,
 ) {
@@ -295,15 +542,13 @@ func (b *Builder) configRDMAEngine(
 		gpu.GetPortByName("RDMAData").AsRemote())
 }
 
-// func (b *Builder) configPMC(
-// 	gpu *GPU,
-// 	gpuDriver *driver.Driver,
-// 	addrTable *mem.BankedAddressPortMapper,
-// ) {
-// 	gpu.PMC.RemotePMCAddressTable = addrTable
-// 	addrTable.LowModules = append(
-// 		addrTable.LowModules,
-// 		gpu.PMC.GetPortByName("Remote").AsRemote())
-// 	gpuDriver.RemotePMCPorts = append(
-// 		gpuDriver.RemotePMCPorts, gpu.PMC.GetPortByName("Remote"))
-// }
+func (b *Builder) configPMC(
+	gpu *sim.Domain,
+	gpuDriver *driver.Driver,
+	addrTable *mem.BankedAddressPortMapper,
+) {
+	pmcPort := gpu.GetPortByName("PageMigrationController")
+
+	addrTable.LowModules = append(addrTable.LowModules, pmcPort.AsRemote())
+	gpuDriver.RemotePMCPorts = append(gpuDriver.RemotePMCPorts, pmcPort)
+}
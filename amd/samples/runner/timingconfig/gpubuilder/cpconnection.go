@@ -0,0 +1,36 @@
+package gpubuilder
+
+import (
+	"fmt"
+
+	"github.com/sarchlab/akita/v4/sim"
+	"github.com/sarchlab/mgpusim/v4/amd/timing/cp"
+)
+
+// ConnectCPWithROBs lets the command processor discard and restart the
+// transactions in the reorder buffers of the shader arrays. The reorder
+// buffers follow the same control protocol as the address translators.
+func ConnectCPWithROBs(
+	commandProcessor *cp.CommandProcessor,
+	conn sim.Connection,
+	sas []*sim.Domain,
+	numCUPerSA int,
+) {
+	for _, sa := range sas {
+		ports := []sim.Port{
+			sa.GetPortByName("L1SROBCtrl"),
+			sa.GetPortByName("L1IROBCtrl"),
+		}
+
+		for i := range numCUPerSA {
+			ports = append(ports,
+				sa.GetPortByName(fmt.Sprintf("L1VROBCtrl[%d]", i)))
+		}
+
+		for _, port := range ports {
+			commandProcessor.AddressTranslators = append(
+				commandProcessor.AddressTranslators, port)
+			conn.PlugIn(port)
+		}
+	}
+}
@@ -12,5 +12,6 @@ type GPUBuilder interface {
 	WithGPUID(id uint64) GPUBuilder
 	WithMemAddrOffset(offset uint64) GPUBuilder
 	WithRDMAAddressMapper(mapper mem.AddressToPortMapper) GPUBuilder
+	WithDriver(driver sim.Port) GPUBuilder
 	Build(name string) *sim.Domain
 }
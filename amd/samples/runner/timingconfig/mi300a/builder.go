@@ -13,11 +13,14 @@ import (
 	"github.com/sarchlab/akita/v4/sim"
 	"github.com/sarchlab/akita/v4/sim/directconnection"
 	"github.com/sarchlab/akita/v4/simulation"
+	"github.com/sarchlab/mgpusim/v4/amd/arch"
 	"github.com/sarchlab/mgpusim/v4/amd/emu"
 	"github.com/sarchlab/mgpusim/v4/amd/emu/cdna3"
 	"github.com/sarchlab/mgpusim/v4/amd/samples/runner/timingconfig/gpubuilder"
 	"github.com/sarchlab/mgpusim/v4/amd/samples/runner/timingconfig/shaderarray"
 	"github.com/sarchlab/mgpusim/v4/amd/timing/cp"
+	"github.com/sarchlab/mgpusim/v4/amd/timing/cu"
+	"github.com/sarchlab/mgpusim/v4/amd/timing/mem/atomicunit"
 	"github.com/sarchlab/mgpusim/v4/amd/timing/pagemigrationcontroller"
 	"github.com/sarchlab/mgpusim/v4/amd/timing/rdma"
 )
@@ -50,6 +53,13 @@ type Builder struct {
 	globalStorage                  *mem.Storage
 	mmu                            *mmu.Comp
 	rdmaAddressMapper              mem.AddressToPortMapper
+	driver                         sim.Port
+	coalescerFactory               cu.CoalescerFactory
+	archConfig                     *arch.Config
+	issuePolicy                    string
+	fetchPolicy                    string
+	dispatchAlg                    string
+	maxWGPerCU                     int
 
 	gpu                *sim.Domain
 	cp                 *cp.CommandProcessor
@@ -65,6 +75,9 @@ type Builder struct {
 	l1AddressMapper    *mem.InterleavedAddressPortMapper
 	l1TLBAddressMapper *mem.SinglePortMapper
 	pmcAddressMapper   mem.AddressToPortMapper
+
+	atomicUnits         []*atomicunit.Comp
+	atomicAddressMapper *mem.InterleavedAddressPortMapper
 }
 
 // MakeBuilder creates a new builder with MI300A default configuration.
@@ -185,6 +198,54 @@ func (b Builder) WithRDMAAddressMapper(
 	return b
 }
 
+// WithCoalescer sets how the CUs combine the lanes of a vector memory
+// instruction into memory transactions.
+func (b Builder) WithCoalescer(factory cu.CoalescerFactory) Builder {
+	b.coalescerFactory = factory
+	return b
+}
+
+// WithIssuePolicy sets the name of the policy that decides which wavefronts
+// issue first in each SIMD of the CUs.
+func (b Builder) WithIssuePolicy(name string) Builder {
+	b.issuePolicy = name
+	return b
+}
+
+// WithFetchPolicy sets the name of the policy that decides which wavefront
+// fetches instructions first in the CUs.
+func (b Builder) WithFetchPolicy(name string) Builder {
+	b.fetchPolicy = name
+	return b
+}
+
+// WithDispatchAlgorithm sets the algorithm that the Command Processor uses to
+// dispatch the work-groups to the CUs.
+func (b Builder) WithDispatchAlgorithm(name string) Builder {
+	b.dispatchAlg = name
+	return b
+}
+
+// WithMaxWGPerCU sets the number of work-groups that the "occupancy-capped"
+// dispatching algorithm can dispatch to each CU at the same time.
+func (b Builder) WithMaxWGPerCU(n int) Builder {
+	b.maxWGPerCU = n
+	return b
+}
+
+// WithArchConfig lets the CUs run the wavefronts with the wavefront size, the
+// SIMD width, and the dual-issue setting of the architecture.
+func (b Builder) WithArchConfig(cfg *arch.Config) Builder {
+	b.archConfig = cfg
+	return b
+}
+
+// WithDriver sets the driver port that the command processor reports to.
+func (b Builder) WithDriver(driver sim.Port) gpubuilder.GPUBuilder {
+	b.driver = driver
+	return b
+}
+
 // Build builds the hardware platform.
 func (b Builder) Build(name string) *sim.Domain {
 	b.name = name
@@ -197,11 +258,19 @@ func (b Builder) Build(name string) *sim.Domain {
 	b.l1AddressMapper.HighAddress = b.memAddrOffset + b.dramSize
 	b.l1AddressMapper.UseAddressSpaceLimitation = true
 
+	b.atomicAddressMapper = mem.NewInterleavedAddressPortMapper(
+		1 << b.log2MemoryBankInterleavingSize,
+	)
+	b.atomicAddressMapper.LowAddress = b.memAddrOffset
+	b.atomicAddressMapper.HighAddress = b.memAddrOffset + b.dramSize
+	b.atomicAddressMapper.UseAddressSpaceLimitation = true
+
 	b.l1TLBAddressMapper = &mem.SinglePortMapper{}
 
 	b.buildSAs()
 	b.buildDRAMControllers()
 	b.buildL2Caches()
+	b.buildAtomicUnits()
 	b.buildCP()
 	b.buildL2TLB()
 
@@ -256,6 +325,8 @@ func (b *Builder) connectCP() {
 
 	b.connectCPWithCUs()
 	b.connectCPWithAddressTranslators()
+	gpubuilder.ConnectCPWithROBs(
+		b.cp, b.internalConn, b.sas, b.numCUPerShaderArray)
 	b.connectCPWithTLBs()
 	b.connectCPWithCaches()
 }
@@ -275,12 +346,22 @@ func (b *Builder) connectL1ToL2() {
 		l1ToL2Conn.PlugIn(l2.GetPortByName("Top"))
 	}
 
+	for _, au := range b.atomicUnits {
+		l1ToL2Conn.PlugIn(au.GetPortByName("Top"))
+		l1ToL2Conn.PlugIn(au.GetPortByName("Bottom"))
+	}
+
 	for _, sa := range b.sas {
 		for i := range b.numCUPerShaderArray {
 			l1ToL2Conn.PlugIn(
 				sa.GetPortByName(fmt.Sprintf("L1VCacheBottom[%d]", i)))
 		}
 
+		for i := range b.numCUPerShaderArray {
+			l1ToL2Conn.PlugIn(
+				sa.GetPortByName(fmt.Sprintf("L1VAddrTransAtomic[%d]", i)))
+		}
+
 		l1ToL2Conn.PlugIn(sa.GetPortByName("L1SCacheBottom"))
 		l1ToL2Conn.PlugIn(sa.GetPortByName("L1ICacheBottom"))
 	}
@@ -474,6 +555,10 @@ func (b *Builder) buildSAs() {
 		WithLog2PageSize(b.log2PageSize).
 		WithL1AddressMapper(b.l1AddressMapper).
 		WithL1TLBAddressMapper(b.l1TLBAddressMapper).
+		WithAtomicAddressMapper(b.atomicAddressMapper).
+		WithCoalescer(b.coalescerFactory).
+		WithIssuePolicy(b.issuePolicy).
+		WithFetchPolicy(b.fetchPolicy).
 		WithALUFactory(aluFactory).
 		WithWfPoolSize(8).
 		WithVGPRCount([]int{32768, 32768, 32768, 32768}).
@@ -486,7 +571,16 @@ func (b *Builder) buildSAs() {
 		WithL1VBankLatency(7).
 		WithMemPipelineBufferSize(64).
 		WithMaxCoalescingPenalty(3).
-		WithRegisterScoreboard(true)
+		WithRegisterScoreboard(true).
+		WithLDSBanks(32).
+		WithLDSBankWidth(4).
+		WithLDSBroadcast(true)
+
+	if b.archConfig != nil {
+		saBuilder = saBuilder.
+			WithNumSinglePrecisionUnits(b.archConfig.SIMDWidth).
+			WithDualIssue(b.archConfig.DualIssue)
+	}
 
 	for i := 0; i < b.numShaderArray; i++ {
 		saName := fmt.Sprintf("%s.SA[%d]", b.name, i)
@@ -531,6 +625,33 @@ func (b *Builder) buildL2Caches() {
 	}
 }
 
+// buildAtomicUnits creates an atomic unit in front of each L2 cache bank. The
+// atomic units perform the read-modify-write operations with the L2 cache, so
+// that the atomic operations from all the CUs are ordered at the L2 cache.
+func (b *Builder) buildAtomicUnits() {
+	auBuilder := atomicunit.MakeBuilder().
+		WithEngine(b.simulation.GetEngine()).
+		WithFreq(b.freq)
+
+	for i, l2 := range b.l2Caches {
+		name := fmt.Sprintf("%s.AtomicUnit[%d]", b.name, i)
+		l2Mapper := &mem.SinglePortMapper{
+			Port: l2.GetPortByName("Top").AsRemote(),
+		}
+		au := auBuilder.
+			WithMemoryProviderMapper(l2Mapper).
+			Build(name)
+
+		b.simulation.RegisterComponent(au)
+		b.atomicUnits = append(b.atomicUnits, au)
+
+		b.atomicAddressMapper.LowModules = append(
+			b.atomicAddressMapper.LowModules,
+			au.GetPortByName("Top").AsRemote(),
+		)
+	}
+}
+
 func (b *Builder) buildDRAMControllers() {
 	memBankSize := b.dramSize / uint64(b.numMemoryBank)
 	for i := 0; i < b.numMemoryBank; i++ {
@@ -595,8 +716,18 @@ func (b *Builder) buildDMAEngine() {
 	b.simulation.RegisterComponent(b.dmaEngine)
 }
 
+// wavefrontSize returns the wavefront size that the command processor forms,
+// or 0 to use the one of the kernels.
+func (b *Builder) wavefrontSize() int {
+	if b.archConfig == nil {
+		return 0
+	}
+
+	return b.archConfig.WavefrontSize
+}
+
 func (b *Builder) buildCP() {
-	b.cp = cp.MakeBuilder().
+	builder := cp.MakeBuilder().
 		WithEngine(b.simulation.GetEngine()).
 		WithVisTracer(b.simulation.GetVisTracer()).
 		WithFreq(b.freq).
@@ -604,7 +735,16 @@ func (b *Builder) buildCP() {
 		WithConstantKernelLaunchOverhead(5400).
 		WithSubsequentKernelLaunchOverhead(1800).
 		WithConstantKernelOverhead(1800).
-		Build(b.name + ".CommandProcessor")
+		WithDriver(b.driver).
+		WithWavefrontSize(b.wavefrontSize()).
+		WithNumCUPerShaderArray(b.numCUPerShaderArray).
+		WithMaxWGPerCU(b.maxWGPerCU)
+
+	if b.dispatchAlg != "" {
+		builder = builder.WithDispatchAlgorithm(b.dispatchAlg)
+	}
+
+	b.cp = builder.Build(b.name + ".CommandProcessor")
 
 	b.simulation.RegisterComponent(b.cp)
 
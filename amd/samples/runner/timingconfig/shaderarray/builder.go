@@ -7,13 +7,13 @@ import (
 	"github.com/sarchlab/akita/v4/mem/cache/writearound"
 	"github.com/sarchlab/akita/v4/mem/cache/writethrough"
 	"github.com/sarchlab/akita/v4/mem/mem"
-	"github.com/sarchlab/akita/v4/mem/vm/addresstranslator"
 	"github.com/sarchlab/akita/v4/mem/vm/tlb"
 	"github.com/sarchlab/akita/v4/sim"
 	"github.com/sarchlab/akita/v4/sim/directconnection"
 	"github.com/sarchlab/akita/v4/simulation"
 	"github.com/sarchlab/mgpusim/v4/amd/emu"
 	"github.com/sarchlab/mgpusim/v4/amd/timing/cu"
+	"github.com/sarchlab/mgpusim/v4/amd/timing/mem/addresstranslator"
 	"github.com/sarchlab/mgpusim/v4/amd/timing/rob"
 )
 
@@ -39,9 +39,18 @@ type Builder struct {
 	memPipelineBufferSize     int
 	maxCoalescingPenalty      int
 	registerScoreboard        bool
+	dualIssue                 bool
+	ldsBanks                  int
+	ldsBankWidth              int
+	ldsBroadcast              bool
 	l1AddressMapper           mem.AddressToPortMapper
 	l1TLBAddressMapper        mem.AddressToPortMapper
+	atomicAddressMapper       mem.AddressToPortMapper
 	aluFactory                emu.ALUFactory
+	coalescerFactory          cu.CoalescerFactory
+	issuePolicy               string
+	fetchPolicy               string
+	trapHandler               cu.TrapHandler
 
 	sa        *sim.Domain
 	cus       []*cu.ComputeUnit
@@ -81,6 +90,8 @@ func MakeBuilder() Builder {
 		freq:              1 * sim.GHz,
 		log2CacheLineSize: 6,
 		log2PageSize:      12,
+		ldsBankWidth:      4,
+		ldsBroadcast:      true,
 	}
 }
 
@@ -136,6 +147,15 @@ func (b Builder) WithL1TLBAddressMapper(
 	return b
 }
 
+// WithAtomicAddressMapper sets the mapper that finds the atomic unit that
+// services the atomic requests to an address.
+func (b Builder) WithAtomicAddressMapper(
+	atomicAddressMapper mem.AddressToPortMapper,
+) Builder {
+	b.atomicAddressMapper = atomicAddressMapper
+	return b
+}
+
 // WithWfPoolSize sets the wavefront pool size for the CU builder.
 func (b Builder) WithWfPoolSize(n int) Builder {
 	b.wfPoolSize = n
@@ -224,6 +244,62 @@ func (b Builder) WithRegisterScoreboard(enabled bool) Builder {
 	return b
 }
 
+// WithDualIssue lets each SIMD in the CUs issue two VALU instructions per
+// cycle.
+func (b Builder) WithDualIssue(enabled bool) Builder {
+	b.dualIssue = enabled
+	return b
+}
+
+// WithCoalescer sets how each CU combines the lanes of a vector memory
+// instruction into memory transactions.
+func (b Builder) WithCoalescer(factory cu.CoalescerFactory) Builder {
+	b.coalescerFactory = factory
+	return b
+}
+
+// WithIssuePolicy sets the name of the policy that decides which wavefronts
+// issue first in each SIMD of the CUs.
+func (b Builder) WithIssuePolicy(name string) Builder {
+	b.issuePolicy = name
+	return b
+}
+
+// WithFetchPolicy sets the name of the policy that decides which wavefront
+// fetches instructions first in the CUs.
+func (b Builder) WithFetchPolicy(name string) Builder {
+	b.fetchPolicy = name
+	return b
+}
+
+// WithTrapHandler sets the trap handler that runs when a wavefront in the
+// CUs executes s_trap.
+func (b Builder) WithTrapHandler(h cu.TrapHandler) Builder {
+	b.trapHandler = h
+	return b
+}
+
+// WithLDSBanks sets the number of LDS banks in each CU. Bank conflicts are not
+// modeled if it is 0.
+func (b Builder) WithLDSBanks(n int) Builder {
+	b.ldsBanks = n
+	return b
+}
+
+// WithLDSBankWidth sets the number of bytes that each LDS bank serves per
+// cycle.
+func (b Builder) WithLDSBankWidth(bytes int) Builder {
+	b.ldsBankWidth = bytes
+	return b
+}
+
+// WithLDSBroadcast sets whether lanes that access the same LDS word share one
+// bank access.
+func (b Builder) WithLDSBroadcast(enabled bool) Builder {
+	b.ldsBroadcast = enabled
+	return b
+}
+
 // Build builds the shader array.
 func (b Builder) Build(name string) *sim.Domain {
 	b.name = name
@@ -275,6 +351,8 @@ func (b *Builder) populateExternalPorts() {
 			b.l1vCaches[i].GetPortByName("Bottom"))
 		b.sa.AddPort(fmt.Sprintf("L1VTLBBottom[%d]", i),
 			b.l1vTLBs[i].GetPortByName("Bottom"))
+		b.sa.AddPort(fmt.Sprintf("L1VAddrTransAtomic[%d]", i),
+			b.l1vATs[i].GetPortByName("Atomic"))
 	}
 
 	b.sa.AddPort("L1SROBCtrl", b.l1sROB.GetPortByName("Control"))
@@ -482,6 +560,33 @@ func (b *Builder) buildCUs() {
 		cuBuilder = cuBuilder.WithRegisterScoreboard(true)
 	}
 
+	if b.dualIssue {
+		cuBuilder = cuBuilder.WithDualIssue(true)
+	}
+
+	if b.ldsBanks > 0 {
+		cuBuilder = cuBuilder.
+			WithLDSBanks(b.ldsBanks).
+			WithLDSBankWidth(b.ldsBankWidth).
+			WithLDSBroadcast(b.ldsBroadcast)
+	}
+
+	if b.coalescerFactory != nil {
+		cuBuilder = cuBuilder.WithCoalescer(b.coalescerFactory)
+	}
+
+	if b.issuePolicy != "" {
+		cuBuilder = cuBuilder.WithIssuePolicy(b.issuePolicy)
+	}
+
+	if b.fetchPolicy != "" {
+		cuBuilder = cuBuilder.WithFetchPolicy(b.fetchPolicy)
+	}
+
+	if b.trapHandler != nil {
+		cuBuilder = cuBuilder.WithTrapHandler(b.trapHandler)
+	}
+
 	for i := 0; i < b.numCUs; i++ {
 		cuName := fmt.Sprintf("%s.CU[%d]", b.name, i)
 		computeUnit := cuBuilder.Build(cuName)
@@ -526,7 +631,8 @@ func (b *Builder) buildL1VAddressTranslators() {
 		xlateMapper := &mem.SinglePortMapper{}
 		curr := base.
 			WithMemoryProviderMapper(memMapper).
-			WithTranslationProviderMapper(xlateMapper)
+			WithTranslationProviderMapper(xlateMapper).
+			WithAtomicProviderMapper(b.atomicAddressMapper)
 		at := curr.Build(name)
 		b.l1vATs = append(b.l1vATs, at)
 		b.l1vMemMappers = append(b.l1vMemMappers, memMapper)
@@ -0,0 +1,114 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/mgpusim/v4/amd/driver"
+	"github.com/sarchlab/mgpusim/v4/amd/insts"
+)
+
+// execHiBenchmark runs the wave32 kernel in testdata, in which each work-item
+// writes the upper half of its exec mask to the output buffer.
+type execHiBenchmark struct {
+	driver    *driver.Driver
+	hsacoPath string
+	gpus      []int
+	out       []uint32
+}
+
+func (b *execHiBenchmark) SelectGPU(gpus []int) {
+	b.gpus = gpus
+}
+
+func (b *execHiBenchmark) SetUnifiedMemory() {
+}
+
+func (b *execHiBenchmark) Run() {
+	co := insts.LoadKernelCodeObjectFromFS(b.hsacoPath, "write_exec_hi")
+	Expect(co.WavefrontSize).To(Equal(uint32(32)))
+
+	ctx := b.driver.Init()
+	b.driver.SelectGPU(ctx, b.gpus[0])
+
+	// Fill the output so that the work-items that do not run are caught.
+	for i := range b.out {
+		b.out[i] = 0xffffffff
+	}
+	dOut := b.driver.AllocateMemory(ctx, uint64(len(b.out)*4))
+	b.driver.MemCopyH2D(ctx, dOut, b.out)
+	args := struct{ Out driver.Ptr }{dOut}
+
+	err := b.driver.LaunchKernel(ctx, co,
+		[3]uint32{uint32(len(b.out)), 1, 1}, [3]uint16{64, 1, 1}, &args)
+	Expect(err).NotTo(HaveOccurred())
+
+	b.driver.MemCopyD2H(ctx, b.out, dOut)
+}
+
+func (b *execHiBenchmark) Verify() {
+	for i, execHi := range b.out {
+		Expect(execHi).To(BeZero(), "work-item %d", i)
+	}
+}
+
+var _ = Describe("Wave32 kernels", func() {
+	var (
+		origArch   string
+		origTiming bool
+		origRTM    bool
+		hsacoPath  string
+	)
+
+	BeforeEach(func() {
+		origArch = *archFlag
+		origTiming = *timingFlag
+		origRTM = *disableAkitaRTM
+
+		*archFlag = "rdna3"
+		*disableAkitaRTM = true
+
+		var err error
+		hsacoPath, err = filepath.Abs("testdata/wave32.hsaco")
+		Expect(err).NotTo(HaveOccurred())
+
+		// The timing simulation writes its results to the working directory.
+		wd, err := os.Getwd()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.Chdir(GinkgoT().TempDir())).To(Succeed())
+		DeferCleanup(os.Chdir, wd)
+	})
+
+	AfterEach(func() {
+		*archFlag = origArch
+		*timingFlag = origTiming
+		*disableAkitaRTM = origRTM
+	})
+
+	run := func() {
+		r := new(Runner).Init()
+		Expect(r.ArchConfig.WavefrontSize).To(Equal(32))
+
+		b := &execHiBenchmark{
+			driver:    r.Driver(),
+			hsacoPath: hsacoPath,
+			out:       make([]uint32, 256),
+		}
+		r.AddBenchmark(b)
+		r.Run()
+
+		b.Verify()
+	}
+
+	It("should run on the emulated RDNA3 GPU", func() {
+		run()
+	})
+
+	It("should run on the simulated RDNA3 GPU", func() {
+		*timingFlag = true
+
+		run()
+	})
+})
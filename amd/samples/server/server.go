@@ -2,6 +2,7 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"log"
 	"net/http"
 
@@ -9,6 +10,8 @@ import (
 	"github.com/sarchlab/mgpusim/v4/amd/server"
 )
 
+var portFlag = flag.Int("port", 8081, "The port that the server listens to.")
+
 func main() {
 	flag.Parse()
 
@@ -16,5 +19,5 @@ func main() {
 
 	server.MakeBuilder().WithDriver(runner.Driver()).Build()
 	server.RegisterHandlers()
-	log.Fatal(http.ListenAndServe(":8081", nil))
+	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", *portFlag), nil))
 }
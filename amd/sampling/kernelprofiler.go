@@ -0,0 +1,196 @@
+package sampling
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"sync"
+
+	"github.com/sarchlab/akita/v4/sim"
+	"github.com/sarchlab/akita/v4/tracing"
+	"github.com/sarchlab/mgpusim/v4/amd/driver"
+	"github.com/sarchlab/mgpusim/v4/amd/emu"
+	"github.com/sarchlab/mgpusim/v4/amd/insts"
+	"github.com/sarchlab/mgpusim/v4/amd/kernels"
+)
+
+var exeUnitNames = map[insts.ExeUnit]string{
+	insts.ExeUnitVALU:    "valu",
+	insts.ExeUnitScalar:  "scalar",
+	insts.ExeUnitVMem:    "vmem",
+	insts.ExeUnitBranch:  "branch",
+	insts.ExeUnitLDS:     "lds",
+	insts.ExeUnitGDS:     "gds",
+	insts.ExeUnitSpecial: "special",
+	insts.ExeUnitMatrix:  "matrix",
+}
+
+// A KernelLaunch is the fingerprint of a kernel launch. Kernels are indexed in
+// the order that the driver launches them.
+type KernelLaunch struct {
+	Index      int               `json:"index"`
+	CodeObject string            `json:"code_object"`
+	GridSize   [3]uint32         `json:"grid_size"`
+	WGSize     [3]uint16         `json:"wg_size"`
+	InstMix    map[string]uint64 `json:"inst_mix"`
+}
+
+// NumWGs returns the number of work-groups of the kernel launch.
+func (l *KernelLaunch) NumWGs() uint64 {
+	n := uint64(1)
+	for i := 0; i < 3; i++ {
+		if l.WGSize[i] == 0 {
+			continue
+		}
+
+		n *= (uint64(l.GridSize[i]) + uint64(l.WGSize[i]) - 1) /
+			uint64(l.WGSize[i])
+	}
+
+	return n
+}
+
+// NumInsts returns the number of wavefront instructions that the kernel
+// launch executes.
+func (l *KernelLaunch) NumInsts() uint64 {
+	n := uint64(0)
+	for _, count := range l.InstMix {
+		n += count
+	}
+
+	return n
+}
+
+// A KernelProfiler fingerprints kernel launches during emulation. It is a
+// tracer that should be attached to the driver, which reports the kernel
+// launches, and a hook that should be attached to the emulated compute units,
+// which report the executed instructions.
+type KernelProfiler struct {
+	lock     sync.Mutex
+	launches []*KernelLaunch
+	packets  map[*kernels.HsaKernelDispatchPacket]*KernelLaunch
+}
+
+// NewKernelProfiler creates a new KernelProfiler.
+func NewKernelProfiler() *KernelProfiler {
+	return &KernelProfiler{
+		packets: make(map[*kernels.HsaKernelDispatchPacket]*KernelLaunch),
+	}
+}
+
+// StartTask records the kernel launches.
+func (p *KernelProfiler) StartTask(task tracing.Task) {
+	switch cmd := task.Detail.(type) {
+	case *driver.LaunchKernelCommand:
+		p.addLaunch(cmd.CodeObject,
+			[]*kernels.HsaKernelDispatchPacket{cmd.Packet})
+	case *driver.LaunchUnifiedMultiGPUKernelCommand:
+		p.addLaunch(cmd.CodeObject, cmd.PacketArray)
+	}
+}
+
+func (p *KernelProfiler) addLaunch(
+	co *insts.KernelCodeObject,
+	packets []*kernels.HsaKernelDispatchPacket,
+) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	pkt := packets[0]
+	launch := &KernelLaunch{
+		Index:      len(p.launches),
+		CodeObject: CodeObjectName(co),
+		GridSize:   [3]uint32{pkt.GridSizeX, pkt.GridSizeY, pkt.GridSizeZ},
+		WGSize: [3]uint16{
+			pkt.WorkgroupSizeX, pkt.WorkgroupSizeY, pkt.WorkgroupSizeZ},
+		InstMix: make(map[string]uint64),
+	}
+	p.launches = append(p.launches, launch)
+
+	for _, pkt := range packets {
+		p.packets[pkt] = launch
+	}
+}
+
+// StepTask does nothing.
+func (p *KernelProfiler) StepTask(_ tracing.Task) {}
+
+// AddMilestone does nothing.
+func (p *KernelProfiler) AddMilestone(_ tracing.Milestone) {}
+
+// EndTask does nothing.
+func (p *KernelProfiler) EndTask(_ tracing.Task) {}
+
+// Func counts the instructions that the emulated compute units execute.
+func (p *KernelProfiler) Func(ctx sim.HookCtx) {
+	wf, ok := ctx.Item.(*emu.Wavefront)
+	if !ok {
+		return
+	}
+
+	inst := ctx.Detail.(*insts.Inst)
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	launch, found := p.packets[wf.Packet]
+	if !found {
+		return
+	}
+
+	launch.InstMix[exeUnitNames[inst.ExeUnit]]++
+}
+
+// Launches returns the kernel launches recorded so far.
+func (p *KernelProfiler) Launches() []*KernelLaunch {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.launches
+}
+
+// WriteFile writes the kernel launches recorded so far to a JSON file.
+func (p *KernelProfiler) WriteFile(path string) error {
+	data, err := json.MarshalIndent(p.Launches(), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0644)
+}
+
+// ReadKernelProfile reads the kernel launches that a KernelProfiler writes.
+func ReadKernelProfile(path string) ([]*KernelLaunch, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var launches []*KernelLaunch
+	err = json.Unmarshal(data, &launches)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, l := range launches {
+		if l.Index != i {
+			return nil, fmt.Errorf("kernel launch %d is out of order", l.Index)
+		}
+	}
+
+	return launches, nil
+}
+
+// CodeObjectName identifies a code object by its symbol name. Code objects
+// without a symbol are identified by the hash of their instructions.
+func CodeObjectName(co *insts.KernelCodeObject) string {
+	if co.Symbol != nil && co.Symbol.Name != "" {
+		return co.Symbol.Name
+	}
+
+	h := fnv.New64a()
+	h.Write(co.Data)
+
+	return fmt.Sprintf("kernel_%016x", h.Sum64())
+}
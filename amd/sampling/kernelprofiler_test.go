@@ -0,0 +1,127 @@
+package sampling
+
+import (
+	"debug/elf"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/akita/v4/sim"
+	"github.com/sarchlab/akita/v4/tracing"
+	"github.com/sarchlab/mgpusim/v4/amd/driver"
+	"github.com/sarchlab/mgpusim/v4/amd/emu"
+	"github.com/sarchlab/mgpusim/v4/amd/insts"
+	"github.com/sarchlab/mgpusim/v4/amd/kernels"
+)
+
+var _ = Describe("Kernel Profiler", func() {
+	var (
+		profiler *KernelProfiler
+	)
+
+	newPacket := func(gridSizeX uint32) *kernels.HsaKernelDispatchPacket {
+		return &kernels.HsaKernelDispatchPacket{
+			GridSizeX:      gridSizeX,
+			GridSizeY:      1,
+			GridSizeZ:      1,
+			WorkgroupSizeX: 64,
+			WorkgroupSizeY: 1,
+			WorkgroupSizeZ: 1,
+		}
+	}
+
+	launch := func(
+		co *insts.KernelCodeObject,
+		pkt *kernels.HsaKernelDispatchPacket,
+	) {
+		profiler.StartTask(tracing.Task{
+			ID: sim.GetIDGenerator().Generate(),
+			Detail: &driver.LaunchKernelCommand{
+				CodeObject: co,
+				Packet:     pkt,
+			},
+		})
+	}
+
+	execute := func(
+		pkt *kernels.HsaKernelDispatchPacket,
+		exeUnit insts.ExeUnit,
+	) {
+		wf := emu.NewWavefront(&kernels.Wavefront{Packet: pkt})
+		profiler.Func(sim.HookCtx{
+			Item:   wf,
+			Detail: &insts.Inst{InstType: &insts.InstType{ExeUnit: exeUnit}},
+		})
+	}
+
+	BeforeEach(func() {
+		profiler = NewKernelProfiler()
+	})
+
+	It("should fingerprint the kernel launches", func() {
+		co := &insts.KernelCodeObject{Symbol: &elf.Symbol{Name: "vec_add"}}
+		pkt0 := newPacket(256)
+		pkt1 := newPacket(128)
+
+		launch(co, pkt0)
+		launch(co, pkt1)
+		execute(pkt0, insts.ExeUnitVALU)
+		execute(pkt0, insts.ExeUnitVALU)
+		execute(pkt0, insts.ExeUnitVMem)
+		execute(pkt1, insts.ExeUnitScalar)
+		execute(newPacket(64), insts.ExeUnitScalar)
+
+		launches := profiler.Launches()
+
+		Expect(launches).To(HaveLen(2))
+		Expect(launches[0].Index).To(Equal(0))
+		Expect(launches[0].CodeObject).To(Equal("vec_add"))
+		Expect(launches[0].GridSize).To(Equal([3]uint32{256, 1, 1}))
+		Expect(launches[0].WGSize).To(Equal([3]uint16{64, 1, 1}))
+		Expect(launches[0].NumWGs()).To(Equal(uint64(4)))
+		Expect(launches[0].InstMix).
+			To(Equal(map[string]uint64{"valu": 2, "vmem": 1}))
+		Expect(launches[0].NumInsts()).To(Equal(uint64(3)))
+		Expect(launches[1].Index).To(Equal(1))
+		Expect(launches[1].InstMix).To(Equal(map[string]uint64{"scalar": 1}))
+	})
+
+	It("should read back the profile it writes", func() {
+		co := &insts.KernelCodeObject{Data: []byte{1, 2, 3, 4}}
+		pkt := newPacket(256)
+		launch(co, pkt)
+		execute(pkt, insts.ExeUnitLDS)
+		execute(pkt, insts.ExeUnitBranch)
+
+		path := filepath.Join(GinkgoT().TempDir(), "profile.json")
+		Expect(profiler.WriteFile(path)).To(Succeed())
+
+		launches, err := ReadKernelProfile(path)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(launches).To(Equal(profiler.Launches()))
+		Expect(fingerprint(launches[0])).
+			To(Equal(fingerprint(profiler.Launches()[0])))
+	})
+
+	It("should reject a profile with launches out of order", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "profile.json")
+		data := `[{"index": 1, "code_object": "k"}]`
+		Expect(os.WriteFile(path, []byte(data), 0644)).To(Succeed())
+
+		_, err := ReadKernelProfile(path)
+
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should name the code objects without symbols by their content",
+		func() {
+			co0 := &insts.KernelCodeObject{Data: []byte{1, 2, 3, 4}}
+			co1 := &insts.KernelCodeObject{Data: []byte{1, 2, 3, 4}}
+			co2 := &insts.KernelCodeObject{Data: []byte{4, 3, 2, 1}}
+
+			Expect(CodeObjectName(co0)).To(Equal(CodeObjectName(co1)))
+			Expect(CodeObjectName(co0)).NotTo(Equal(CodeObjectName(co2)))
+		})
+})
@@ -0,0 +1,376 @@
+package sampling
+
+import (
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/sarchlab/akita/v4/sim"
+	"github.com/sarchlab/akita/v4/tracing"
+	"github.com/sarchlab/mgpusim/v4/amd/driver"
+	"github.com/sarchlab/mgpusim/v4/amd/insts"
+)
+
+// A KernelCluster is a group of kernel launches of the same code object that
+// behave similarly. Only the sampled launches are simulated in detail.
+type KernelCluster struct {
+	CodeObject string
+	Members    []int
+	Samples    []int
+}
+
+// ClusterKernelLaunches groups the kernel launches so that the distance
+// between the fingerprint of every launch and the fingerprint of the center of
+// its cluster is no more than the threshold. The fingerprint is made of the
+// natural logarithms of the number of work-groups and the number of
+// instructions, and the fraction of each instruction category. So, a threshold
+// of 0.1 roughly allows the launches in a cluster to differ in size by 10%.
+// For each cluster, the numSamples launches closest to the center are sampled.
+func ClusterKernelLaunches(
+	launches []*KernelLaunch,
+	threshold float64,
+	numSamples int,
+) []*KernelCluster {
+	if numSamples < 1 {
+		numSamples = 1
+	}
+
+	var codeObjects []string
+	groups := make(map[string][]*KernelLaunch)
+	for _, l := range launches {
+		if _, found := groups[l.CodeObject]; !found {
+			codeObjects = append(codeObjects, l.CodeObject)
+		}
+
+		groups[l.CodeObject] = append(groups[l.CodeObject], l)
+	}
+
+	var clusters []*KernelCluster
+	for _, co := range codeObjects {
+		clusters = append(clusters,
+			clusterGroup(groups[co], threshold, numSamples)...)
+	}
+
+	return clusters
+}
+
+// clusterGroup uses farthest-point clustering. It keeps adding the launch that
+// is the farthest from all the existing centers as a new center until all the
+// launches are close enough to a center.
+func clusterGroup(
+	group []*KernelLaunch,
+	threshold float64,
+	numSamples int,
+) []*KernelCluster {
+	features := make([][]float64, len(group))
+	for i, l := range group {
+		features[i] = fingerprint(l)
+	}
+
+	centers := []int{0}
+	nearest := make([]int, len(group))
+	distances := make([]float64, len(group))
+	for i := range group {
+		distances[i] = distance(features[i], features[0])
+	}
+
+	for {
+		farthest := 0
+		for i := range group {
+			if distances[i] > distances[farthest] {
+				farthest = i
+			}
+		}
+
+		if distances[farthest] <= threshold {
+			break
+		}
+
+		centers = append(centers, farthest)
+		for i := range group {
+			d := distance(features[i], features[farthest])
+			if d < distances[i] {
+				distances[i] = d
+				nearest[i] = len(centers) - 1
+			}
+		}
+	}
+
+	clusters := make([]*KernelCluster, len(centers))
+	for i := range clusters {
+		clusters[i] = &KernelCluster{CodeObject: group[0].CodeObject}
+	}
+
+	for i, l := range group {
+		c := clusters[nearest[i]]
+		c.Members = append(c.Members, l.Index)
+	}
+
+	index := make(map[int]int)
+	for i, l := range group {
+		index[l.Index] = i
+	}
+
+	for _, c := range clusters {
+		candidates := append([]int(nil), c.Members...)
+		sort.SliceStable(candidates, func(a, b int) bool {
+			return distances[index[candidates[a]]] <
+				distances[index[candidates[b]]]
+		})
+
+		if len(candidates) > numSamples {
+			candidates = candidates[:numSamples]
+		}
+
+		sort.Ints(candidates)
+		c.Samples = candidates
+	}
+
+	return clusters
+}
+
+func fingerprint(l *KernelLaunch) []float64 {
+	numInsts := l.NumInsts()
+	f := []float64{
+		math.Log(float64(l.NumWGs()) + 1),
+		math.Log(float64(numInsts) + 1),
+	}
+
+	for unit := 0; unit < len(exeUnitNames); unit++ {
+		fraction := 0.0
+		if numInsts > 0 {
+			name := exeUnitNames[insts.ExeUnit(unit)]
+			fraction = float64(l.InstMix[name]) / float64(numInsts)
+		}
+
+		f = append(f, fraction)
+	}
+
+	return f
+}
+
+func distance(a, b []float64) float64 {
+	sum := 0.0
+	for i := range a {
+		sum += (a[i] - b[i]) * (a[i] - b[i])
+	}
+
+	return math.Sqrt(sum)
+}
+
+// SampledKernels is a set of kernel indices. It implements the
+// driver.KernelSelector interface.
+type SampledKernels map[int]bool
+
+// SelectSampledKernels returns the sampled kernels of all the clusters.
+func SelectSampledKernels(clusters []*KernelCluster) SampledKernels {
+	s := make(SampledKernels)
+	for _, c := range clusters {
+		for _, i := range c.Samples {
+			s[i] = true
+		}
+	}
+
+	return s
+}
+
+// SimulateInDetail returns true if the kernel is sampled.
+func (s SampledKernels) SimulateInDetail(kernelIndex int) bool {
+	return s[kernelIndex]
+}
+
+// A KernelTimer is a tracer that should be attached to the driver. It records
+// the code object and the execution time of every kernel launch.
+type KernelTimer struct {
+	timeTeller sim.TimeTeller
+
+	lock        sync.Mutex
+	codeObjects []string
+	times       []sim.VTimeInSec
+	inflight    map[string]int
+	startTimes  map[string]sim.VTimeInSec
+}
+
+// NewKernelTimer creates a new KernelTimer.
+func NewKernelTimer(timeTeller sim.TimeTeller) *KernelTimer {
+	return &KernelTimer{
+		timeTeller: timeTeller,
+		inflight:   make(map[string]int),
+		startTimes: make(map[string]sim.VTimeInSec),
+	}
+}
+
+// StartTask records the start time of a kernel launch.
+func (t *KernelTimer) StartTask(task tracing.Task) {
+	var name string
+	switch cmd := task.Detail.(type) {
+	case *driver.LaunchKernelCommand:
+		name = CodeObjectName(cmd.CodeObject)
+	case *driver.LaunchUnifiedMultiGPUKernelCommand:
+		name = CodeObjectName(cmd.CodeObject)
+	default:
+		return
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.inflight[task.ID] = len(t.times)
+	t.startTimes[task.ID] = t.timeTeller.CurrentTime()
+	t.codeObjects = append(t.codeObjects, name)
+	t.times = append(t.times, 0)
+}
+
+// StepTask does nothing.
+func (t *KernelTimer) StepTask(_ tracing.Task) {}
+
+// AddMilestone does nothing.
+func (t *KernelTimer) AddMilestone(_ tracing.Milestone) {}
+
+// EndTask records the execution time of a kernel launch.
+func (t *KernelTimer) EndTask(task tracing.Task) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	index, found := t.inflight[task.ID]
+	if !found {
+		return
+	}
+
+	t.times[index] = t.timeTeller.CurrentTime() - t.startTimes[task.ID]
+	delete(t.inflight, task.ID)
+	delete(t.startTimes, task.ID)
+}
+
+// CodeObjects returns the code object names of the kernel launches.
+func (t *KernelTimer) CodeObjects() []string {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.codeObjects
+}
+
+// Times returns the execution time of the kernel launches.
+func (t *KernelTimer) Times() []sim.VTimeInSec {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.times
+}
+
+// A KernelTimeEstimate is the estimated total execution time of all the
+// kernels, with the half width of the 95% confidence interval.
+type KernelTimeEstimate struct {
+	Time         sim.VTimeInSec
+	ErrorBound   sim.VTimeInSec
+	NumSimulated int
+	NumKernels   int
+}
+
+// EstimateKernelTime extrapolates the execution time of the sampled kernels
+// to all the kernels. In each cluster, the time per instruction of the sampled
+// kernels estimates the time of the other kernels. The error bound treats the
+// samples as a stratified random sample of the time per instruction. Clusters
+// with a single sample borrow the relative variance of the other clusters. If
+// no cluster has two samples, the error bound is infinite.
+//
+// The sampled kernels start with cold caches, so the estimate is biased
+// upwards for kernels that reuse the data of the kernels before them.
+func EstimateKernelTime(
+	launches []*KernelLaunch,
+	clusters []*KernelCluster,
+	times []sim.VTimeInSec,
+) KernelTimeEstimate {
+	e := KernelTimeEstimate{NumKernels: len(launches)}
+
+	type stratum struct {
+		unsampledInsts float64
+		numSamples     int
+		numMembers     int
+		meanRate       float64
+		rateVariance   float64
+	}
+
+	strata := make([]stratum, 0, len(clusters))
+	pooledCV2, numPooled := 0.0, 0
+	for _, c := range clusters {
+		s := stratum{numSamples: len(c.Samples), numMembers: len(c.Members)}
+		sampled := make(map[int]bool)
+		rates := make([]float64, 0, len(c.Samples))
+		for _, i := range c.Samples {
+			sampled[i] = true
+			e.NumSimulated++
+			e.Time += times[i]
+			rates = append(rates,
+				float64(times[i])/instWeight(launches[i]))
+		}
+
+		for _, i := range c.Members {
+			if !sampled[i] {
+				s.unsampledInsts += instWeight(launches[i])
+			}
+		}
+
+		s.meanRate, s.rateVariance = meanAndVariance(rates)
+		e.Time += sim.VTimeInSec(s.meanRate * s.unsampledInsts)
+
+		if s.numSamples > 1 && s.meanRate > 0 {
+			pooledCV2 += s.rateVariance / (s.meanRate * s.meanRate)
+			numPooled++
+		}
+
+		strata = append(strata, s)
+	}
+
+	variance := 0.0
+	for _, s := range strata {
+		if s.unsampledInsts == 0 {
+			continue
+		}
+
+		rateVariance := s.rateVariance
+		if s.numSamples < 2 {
+			if numPooled == 0 {
+				e.ErrorBound = sim.VTimeInSec(math.Inf(1))
+				return e
+			}
+
+			rateVariance = pooledCV2 / float64(numPooled) *
+				s.meanRate * s.meanRate
+		}
+
+		fpc := 1 - float64(s.numSamples)/float64(s.numMembers)
+		variance += s.unsampledInsts * s.unsampledInsts *
+			rateVariance / float64(s.numSamples) * fpc
+	}
+
+	e.ErrorBound = sim.VTimeInSec(1.96 * math.Sqrt(variance))
+
+	return e
+}
+
+func instWeight(l *KernelLaunch) float64 {
+	return math.Max(float64(l.NumInsts()), 1)
+}
+
+func meanAndVariance(values []float64) (mean, variance float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+
+	if len(values) < 2 {
+		return mean, 0
+	}
+
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	variance /= float64(len(values) - 1)
+
+	return mean, variance
+}
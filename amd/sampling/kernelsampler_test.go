@@ -0,0 +1,264 @@
+package sampling
+
+import (
+	"math"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/akita/v4/sim"
+)
+
+// newLaunch creates a kernel launch with numWG work-groups, each executing
+// 100 instructions, three quarters of which are VALU instructions.
+func newLaunch(index int, co string, numWG uint32) *KernelLaunch {
+	numInsts := uint64(numWG) * 100
+
+	return &KernelLaunch{
+		Index:      index,
+		CodeObject: co,
+		GridSize:   [3]uint32{numWG * 64, 1, 1},
+		WGSize:     [3]uint16{64, 1, 1},
+		InstMix: map[string]uint64{
+			"valu": numInsts / 4 * 3,
+			"vmem": numInsts / 4,
+		},
+	}
+}
+
+var _ = Describe("Kernel Sampler", func() {
+	Context("fingerprint", func() {
+		It("should be the same every time it is computed", func() {
+			l := newLaunch(0, "k", 100)
+			f := fingerprint(l)
+
+			for i := 0; i < 10; i++ {
+				Expect(fingerprint(l)).To(Equal(f))
+			}
+		})
+
+		It("should measure the size in log scale", func() {
+			f := fingerprint(newLaunch(0, "k", 100))
+
+			Expect(f[0]).To(BeNumerically("~", math.Log(101), 1e-9))
+			Expect(f[1]).To(BeNumerically("~", math.Log(10001), 1e-9))
+		})
+
+		It("should record the fraction of each instruction category", func() {
+			f := fingerprint(newLaunch(0, "k", 100))
+
+			Expect(f).To(HaveLen(2 + len(exeUnitNames)))
+
+			sum := 0.0
+			for _, fraction := range f[2:] {
+				sum += fraction
+			}
+			Expect(sum).To(BeNumerically("~", 1, 1e-9))
+		})
+
+		It("should not divide by zero for kernels without instructions",
+			func() {
+				l := &KernelLaunch{
+					GridSize: [3]uint32{64, 1, 1},
+					WGSize:   [3]uint16{64, 1, 1},
+				}
+
+				for _, v := range fingerprint(l) {
+					Expect(math.IsNaN(v)).To(BeFalse())
+				}
+			})
+	})
+
+	Context("clustering", func() {
+		It("should put the launches that differ in size by less than the "+
+			"threshold in one cluster", func() {
+			launches := []*KernelLaunch{
+				newLaunch(0, "k", 100),
+				newLaunch(1, "k", 103),
+				newLaunch(2, "k", 97),
+			}
+
+			clusters := ClusterKernelLaunches(launches, 0.1, 1)
+
+			Expect(clusters).To(HaveLen(1))
+			Expect(clusters[0].CodeObject).To(Equal("k"))
+			Expect(clusters[0].Members).To(Equal([]int{0, 1, 2}))
+			Expect(clusters[0].Samples).To(Equal([]int{0}))
+		})
+
+		It("should split the launches that differ in size by more than the "+
+			"threshold", func() {
+			launches := []*KernelLaunch{
+				newLaunch(0, "k", 100),
+				newLaunch(1, "k", 200),
+				newLaunch(2, "k", 101),
+				newLaunch(3, "k", 202),
+			}
+
+			clusters := ClusterKernelLaunches(launches, 0.1, 1)
+
+			Expect(clusters).To(HaveLen(2))
+			Expect(clusters[0].Members).To(Equal([]int{0, 2}))
+			Expect(clusters[1].Members).To(Equal([]int{1, 3}))
+		})
+
+		It("should split the launches with different instruction mixes",
+			func() {
+				other := newLaunch(1, "k", 100)
+				other.InstMix = map[string]uint64{"valu": 5000, "lds": 5000}
+				launches := []*KernelLaunch{newLaunch(0, "k", 100), other}
+
+				clusters := ClusterKernelLaunches(launches, 0.1, 1)
+
+				Expect(clusters).To(HaveLen(2))
+			})
+
+		It("should not mix code objects", func() {
+			launches := []*KernelLaunch{
+				newLaunch(0, "a", 100),
+				newLaunch(1, "b", 100),
+				newLaunch(2, "a", 100),
+			}
+
+			clusters := ClusterKernelLaunches(launches, 0.1, 1)
+
+			Expect(clusters).To(HaveLen(2))
+			Expect(clusters[0].CodeObject).To(Equal("a"))
+			Expect(clusters[0].Members).To(Equal([]int{0, 2}))
+			Expect(clusters[1].CodeObject).To(Equal("b"))
+			Expect(clusters[1].Members).To(Equal([]int{1}))
+		})
+
+		It("should sample the launches closest to the center", func() {
+			launches := []*KernelLaunch{
+				newLaunch(0, "k", 100),
+				newLaunch(1, "k", 105),
+				newLaunch(2, "k", 101),
+				newLaunch(3, "k", 100),
+			}
+
+			clusters := ClusterKernelLaunches(launches, 0.2, 3)
+
+			Expect(clusters).To(HaveLen(1))
+			Expect(clusters[0].Samples).To(Equal([]int{0, 2, 3}))
+		})
+
+		It("should sample at least one launch in each cluster", func() {
+			launches := []*KernelLaunch{
+				newLaunch(0, "k", 100),
+				newLaunch(1, "k", 100),
+			}
+
+			clusters := ClusterKernelLaunches(launches, 0.1, 0)
+
+			Expect(clusters[0].Samples).To(HaveLen(1))
+		})
+
+		It("should select the sampled kernels", func() {
+			clusters := []*KernelCluster{
+				{Members: []int{0, 2}, Samples: []int{0}},
+				{Members: []int{1, 3}, Samples: []int{3}},
+			}
+
+			s := SelectSampledKernels(clusters)
+
+			Expect(s.SimulateInDetail(0)).To(BeTrue())
+			Expect(s.SimulateInDetail(1)).To(BeFalse())
+			Expect(s.SimulateInDetail(2)).To(BeFalse())
+			Expect(s.SimulateInDetail(3)).To(BeTrue())
+		})
+	})
+
+	Context("time estimation", func() {
+		var launches []*KernelLaunch
+
+		BeforeEach(func() {
+			launches = []*KernelLaunch{
+				newLaunch(0, "k", 100),
+				newLaunch(1, "k", 100),
+				newLaunch(2, "k", 100),
+				newLaunch(3, "k", 100),
+			}
+		})
+
+		It("should extrapolate the time per instruction", func() {
+			clusters := []*KernelCluster{
+				{Members: []int{0, 1, 2, 3}, Samples: []int{0, 1}},
+			}
+			times := []sim.VTimeInSec{10, 12, 0, 0}
+
+			e := EstimateKernelTime(launches, clusters, times)
+
+			Expect(e.NumKernels).To(Equal(4))
+			Expect(e.NumSimulated).To(Equal(2))
+			Expect(float64(e.Time)).To(BeNumerically("~", 44, 1e-9))
+
+			// The unsampled launches have twice the instructions of one
+			// sample. The sample variance of the time per instruction is 2,
+			// in units of one launch, and the finite population correction
+			// is 1/2.
+			variance := 2.0 * 2.0 * 2.0 / 2 * 0.5
+			Expect(float64(e.ErrorBound)).
+				To(BeNumerically("~", 1.96*math.Sqrt(variance), 1e-9))
+		})
+
+		It("should scale the time by the number of instructions", func() {
+			launches[2] = newLaunch(2, "k", 300)
+			clusters := []*KernelCluster{
+				{Members: []int{0, 2}, Samples: []int{0}},
+				{Members: []int{1, 3}, Samples: []int{1, 3}},
+			}
+			times := []sim.VTimeInSec{10, 12, 0, 12}
+
+			e := EstimateKernelTime(launches, clusters, times)
+
+			Expect(float64(e.Time)).To(BeNumerically("~", 10+30+12+12, 1e-9))
+			Expect(float64(e.ErrorBound)).To(BeNumerically("~", 0, 1e-9))
+		})
+
+		It("should have no error if all the kernels are simulated", func() {
+			clusters := []*KernelCluster{
+				{Members: []int{0, 1}, Samples: []int{0, 1}},
+				{Members: []int{2, 3}, Samples: []int{2, 3}},
+			}
+			times := []sim.VTimeInSec{1, 2, 3, 4}
+
+			e := EstimateKernelTime(launches, clusters, times)
+
+			Expect(float64(e.Time)).To(BeNumerically("~", 10, 1e-9))
+			Expect(float64(e.ErrorBound)).To(Equal(0.0))
+		})
+
+		It("should borrow the relative variance of the other clusters",
+			func() {
+				clusters := []*KernelCluster{
+					{Members: []int{0, 1}, Samples: []int{0, 1}},
+					{Members: []int{2, 3}, Samples: []int{2}},
+				}
+				times := []sim.VTimeInSec{10, 12, 20, 0}
+
+				e := EstimateKernelTime(launches, clusters, times)
+
+				Expect(float64(e.Time)).To(BeNumerically("~", 62, 1e-9))
+
+				// The relative variance of the first cluster is 2/11^2. The
+				// second cluster has one unsampled launch with the time 20.
+				cv2 := 2.0 / (11 * 11)
+				variance := 20 * 20 * cv2 / 1 * 0.5
+				Expect(float64(e.ErrorBound)).
+					To(BeNumerically("~", 1.96*math.Sqrt(variance), 1e-9))
+			})
+
+		It("should have an infinite error if no cluster has two samples",
+			func() {
+				clusters := []*KernelCluster{
+					{Members: []int{0, 1, 2, 3}, Samples: []int{0}},
+				}
+				times := []sim.VTimeInSec{10, 0, 0, 0}
+
+				e := EstimateKernelTime(launches, clusters, times)
+
+				Expect(float64(e.Time)).To(BeNumerically("~", 40, 1e-9))
+				Expect(math.IsInf(float64(e.ErrorBound), 1)).To(BeTrue())
+			})
+	})
+})
@@ -0,0 +1,285 @@
+// Package sanitizer checks the memory accesses of emulated kernels against the
+// memory that the driver allocates. It reports the accesses outside any
+// allocation, the accesses to freed memory, and the reads of bytes that
+// neither a memory copy nor a kernel has written.
+package sanitizer
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/sarchlab/akita/v4/mem/vm"
+)
+
+// A Kind tells what is wrong with an access.
+type Kind int
+
+// The kinds of invalid accesses.
+const (
+	OutOfBounds Kind = iota
+	UseAfterFree
+	UninitializedRead
+)
+
+func (k Kind) String() string {
+	switch k {
+	case OutOfBounds:
+		return "out-of-bounds access"
+	case UseAfterFree:
+		return "use after free"
+	case UninitializedRead:
+		return "uninitialized read"
+	}
+
+	return fmt.Sprintf("kind %d", int(k))
+}
+
+// A Report describes an invalid access of a kernel. The sanitizer reports an
+// instruction once per kind. The report keeps the first access and counts
+// the lanes that repeat it.
+type Report struct {
+	Kind    Kind
+	PID     vm.PID
+	Address uint64
+	IsWrite bool
+
+	Kernel   string
+	WorkItem [3]int
+	PC       uint64
+	Inst     string
+
+	Count int
+}
+
+func (r *Report) String() string {
+	access := "read"
+	if r.IsWrite {
+		access = "write"
+	}
+
+	return fmt.Sprintf("%s: %s at 0x%x, pid %d, kernel %s, "+
+		"work-item (%d, %d, %d), pc 0x%x: %s (%d accesses)",
+		r.Kind, access, r.Address, r.PID, r.Kernel,
+		r.WorkItem[0], r.WorkItem[1], r.WorkItem[2], r.PC, r.Inst, r.Count)
+}
+
+type allocation struct {
+	addr, size  uint64
+	initialized []uint64
+}
+
+func newAllocation(addr, size uint64) *allocation {
+	return &allocation{
+		addr:        addr,
+		size:        size,
+		initialized: make([]uint64, (size+63)/64),
+	}
+}
+
+func (a *allocation) end() uint64 {
+	return a.addr + a.size
+}
+
+func (a *allocation) contains(addr uint64) bool {
+	return addr >= a.addr && addr < a.end()
+}
+
+func (a *allocation) markInitialized(start, end uint64) {
+	for b := start - a.addr; b < end-a.addr; b++ {
+		a.initialized[b/64] |= 1 << (b % 64)
+	}
+}
+
+func (a *allocation) isInitialized(start, end uint64) bool {
+	for b := start - a.addr; b < end-a.addr; b++ {
+		if a.initialized[b/64]&(1<<(b%64)) == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+type reportKey struct {
+	kernel string
+	pc     uint64
+	kind   Kind
+}
+
+// A Sanitizer keeps the allocations of each process. The driver updates the
+// sanitizer as it allocates, frees, and copies memory, and the emulated
+// compute units check the accesses of each lane. It is safe to use the
+// sanitizer from multiple goroutines.
+type Sanitizer struct {
+	lock sync.Mutex
+
+	allocations map[vm.PID][]*allocation
+	freed       map[vm.PID][]*allocation
+
+	reports     []*Report
+	reportIndex map[reportKey]*Report
+}
+
+// NewSanitizer creates a Sanitizer without any allocation.
+func NewSanitizer() *Sanitizer {
+	return &Sanitizer{
+		allocations: make(map[vm.PID][]*allocation),
+		freed:       make(map[vm.PID][]*allocation),
+		reportIndex: make(map[reportKey]*Report),
+	}
+}
+
+// Allocate records that the process allocates size bytes at addr. None of
+// the bytes is initialized.
+func (s *Sanitizer) Allocate(pid vm.PID, addr, size uint64) {
+	if size == 0 {
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.freed[pid] = removeOverlapping(s.freed[pid], addr, addr+size)
+
+	allocs := s.allocations[pid]
+	i := sort.Search(len(allocs), func(i int) bool {
+		return allocs[i].addr >= addr
+	})
+
+	allocs = append(allocs, nil)
+	copy(allocs[i+1:], allocs[i:])
+	allocs[i] = newAllocation(addr, size)
+	s.allocations[pid] = allocs
+}
+
+// Free records that the process frees the allocation that starts at addr.
+// Later accesses to the allocation are reported as uses after free.
+func (s *Sanitizer) Free(pid vm.PID, addr uint64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	allocs := s.allocations[pid]
+	for i, a := range allocs {
+		if a.addr == addr {
+			s.allocations[pid] = append(allocs[:i], allocs[i+1:]...)
+			s.freed[pid] = append(s.freed[pid], a)
+
+			return
+		}
+	}
+}
+
+// MarkInitialized records that the bytes in [addr, addr+size) are written.
+// The bytes outside any allocation are ignored.
+func (s *Sanitizer) MarkInitialized(pid vm.PID, addr, size uint64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	end := addr + size
+	for _, a := range s.allocations[pid] {
+		if a.addr >= end || a.end() <= addr {
+			continue
+		}
+
+		a.markInitialized(max(addr, a.addr), min(end, a.end()))
+	}
+}
+
+// Check returns what is wrong with accessing the bytes in [addr, addr+size).
+// The second return value is false if the access is valid.
+func (s *Sanitizer) Check(
+	pid vm.PID,
+	addr, size uint64,
+	isWrite bool,
+) (Kind, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	a := s.find(s.allocations[pid], addr)
+	if a == nil {
+		if s.find(s.freed[pid], addr) != nil {
+			return UseAfterFree, true
+		}
+
+		return OutOfBounds, true
+	}
+
+	if addr+size > a.end() {
+		return OutOfBounds, true
+	}
+
+	if !isWrite && !a.isInitialized(addr, addr+size) {
+		return UninitializedRead, true
+	}
+
+	return 0, false
+}
+
+func (s *Sanitizer) find(allocs []*allocation, addr uint64) *allocation {
+	for _, a := range allocs {
+		if a.contains(addr) {
+			return a
+		}
+	}
+
+	return nil
+}
+
+func removeOverlapping(allocs []*allocation, start, end uint64) []*allocation {
+	kept := allocs[:0]
+	for _, a := range allocs {
+		if a.addr < end && a.end() > start {
+			continue
+		}
+
+		kept = append(kept, a)
+	}
+
+	return kept
+}
+
+// Report records an invalid access. If the instruction of the same kernel
+// has already been reported with the same kind, only the count of the
+// earlier report increases.
+func (s *Sanitizer) Report(r Report) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	key := reportKey{kernel: r.Kernel, pc: r.PC, kind: r.Kind}
+	if existing, ok := s.reportIndex[key]; ok {
+		existing.Count++
+		return
+	}
+
+	r.Count = 1
+	s.reports = append(s.reports, &r)
+	s.reportIndex[key] = &r
+}
+
+// Reports returns the reports in the order that the accesses happen.
+func (s *Sanitizer) Reports() []Report {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	reports := make([]Report, len(s.reports))
+	for i, r := range s.reports {
+		reports[i] = *r
+	}
+
+	return reports
+}
+
+// Print writes the reports to w, one report per line.
+func (s *Sanitizer) Print(w io.Writer) {
+	reports := s.Reports()
+	if len(reports) == 0 {
+		fmt.Fprintln(w, "sanitizer: no invalid memory access")
+		return
+	}
+
+	for _, r := range reports {
+		fmt.Fprintf(w, "sanitizer: %s\n", &r)
+	}
+}
@@ -0,0 +1,443 @@
+// Package client is a reference client of the MGPUSim server. It mirrors the
+// HIP runtime API, so that host programs can run kernels on the simulator.
+package client
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+// A Stream is a sequence of commands that execute in order. Stream 0 is the
+// null stream.
+type Stream uint64
+
+// NullStream is the stream that the synchronous APIs use.
+const NullStream Stream = 0
+
+// A Module is a loaded HSACO file.
+type Module uint64
+
+// A Function is a kernel in a module.
+type Function uint64
+
+// An Event marks a point in a stream.
+type Event uint64
+
+// Dim3 is the size of a grid or a block.
+type Dim3 struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	Z int `json:"z"`
+}
+
+// A Client sends the API calls to the MGPUSim server.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+
+	mutex         sync.Mutex
+	pendingCopies map[Stream][]*pendingCopy
+}
+
+// A pendingCopy is an asynchronous device to host copy whose data has not
+// been collected.
+type pendingCopy struct {
+	handle uint64
+	dst    []byte
+}
+
+// New creates a client that connects to the server at the given URL, for
+// example, http://localhost:8081.
+func New(baseURL string) *Client {
+	return &Client{
+		baseURL:       baseURL,
+		httpClient:    &http.Client{},
+		pendingCopies: make(map[Stream][]*pendingCopy),
+	}
+}
+
+func (c *Client) do(req *http.Request, output interface{}) error {
+	rsp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+
+	body, err := io.ReadAll(rsp.Body)
+	if err != nil {
+		return err
+	}
+
+	if rsp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %d %s", req.URL.Path, rsp.StatusCode,
+			bytes.TrimSpace(body))
+	}
+
+	if output == nil {
+		return nil
+	}
+
+	return json.Unmarshal(body, output)
+}
+
+func (c *Client) post(path string, input, output interface{}) error {
+	data, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(
+		http.MethodPost, c.baseURL+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	return c.do(req, output)
+}
+
+// get sends the input as the data query parameter, which the original
+// endpoints of the server use.
+func (c *Client) get(path string, input, output interface{}) error {
+	u := c.baseURL + path
+
+	if input != nil {
+		data, err := json.Marshal(input)
+		if err != nil {
+			return err
+		}
+
+		u += "?data=" + url.QueryEscape(string(data))
+	}
+
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+
+	return c.do(req, output)
+}
+
+// GetDeviceCount returns the number of GPUs.
+func (c *Client) GetDeviceCount() (int, error) {
+	output := struct {
+		DeviceCount int `json:"device_count"`
+	}{}
+
+	err := c.get("/device_count", nil, &output)
+
+	return output.DeviceCount, err
+}
+
+// Malloc allocates GPU memory.
+func (c *Client) Malloc(size uint64) (uint64, error) {
+	output := struct {
+		Ptr uint64 `json:"ptr"`
+	}{}
+
+	err := c.get("/malloc", map[string]uint64{"size": size}, &output)
+
+	return output.Ptr, err
+}
+
+// Free frees GPU memory.
+func (c *Client) Free(ptr uint64) error {
+	return c.get(fmt.Sprintf("/free/%d", ptr), nil, nil)
+}
+
+// MemcpyHtoD copies data from the host to the GPU after all the commands in
+// all the streams complete.
+func (c *Client) MemcpyHtoD(dst uint64, src []byte) error {
+	return c.post("/memcopy_h2d", map[string]interface{}{
+		"ptr":  dst,
+		"data": base64.StdEncoding.EncodeToString(src),
+	}, nil)
+}
+
+// MemcpyDtoH copies data from the GPU to the host after all the commands in
+// all the streams complete.
+func (c *Client) MemcpyDtoH(dst []byte, src uint64) error {
+	output := struct {
+		Data string `json:"data"`
+	}{}
+
+	err := c.get("/memcopy_d2h", map[string]uint64{
+		"ptr":  src,
+		"size": uint64(len(dst)),
+	}, &output)
+	if err != nil {
+		return err
+	}
+
+	err = decodeInto(dst, output.Data)
+	if err != nil {
+		return err
+	}
+
+	return c.collectAll()
+}
+
+// Memset sets the bytes of GPU memory to a value after all the commands in all
+// the streams complete.
+func (c *Client) Memset(dst uint64, value byte, size uint64) error {
+	return c.post("/memset", map[string]interface{}{
+		"ptr":   dst,
+		"value": value,
+		"size":  size,
+	}, nil)
+}
+
+// MemsetAsync sets the bytes of GPU memory to a value in a stream.
+func (c *Client) MemsetAsync(
+	dst uint64,
+	value byte,
+	size uint64,
+	s Stream,
+) error {
+	return c.post("/memset_async", map[string]interface{}{
+		"ptr":    dst,
+		"value":  value,
+		"size":   size,
+		"stream": s,
+	}, nil)
+}
+
+// MemcpyHtoDAsync copies data from the host to the GPU in a stream. The data
+// is sent to the server when the function is called, so the host can reuse src
+// after the function returns.
+func (c *Client) MemcpyHtoDAsync(dst uint64, src []byte, s Stream) error {
+	return c.post("/memcopy_h2d_async", map[string]interface{}{
+		"ptr":    dst,
+		"data":   base64.StdEncoding.EncodeToString(src),
+		"stream": s,
+	}, nil)
+}
+
+// MemcpyDtoHAsync copies data from the GPU to the host in a stream. The data
+// is written to dst when the stream or the device is synchronized.
+func (c *Client) MemcpyDtoHAsync(dst []byte, src uint64, s Stream) error {
+	output := struct {
+		Copy uint64 `json:"copy"`
+	}{}
+
+	err := c.post("/memcopy_d2h_async", map[string]interface{}{
+		"ptr":    src,
+		"size":   len(dst),
+		"stream": s,
+	}, &output)
+	if err != nil {
+		return err
+	}
+
+	c.mutex.Lock()
+	c.pendingCopies[s] = append(c.pendingCopies[s],
+		&pendingCopy{handle: output.Copy, dst: dst})
+	c.mutex.Unlock()
+
+	return nil
+}
+
+// collect writes the data of the asynchronous copies in the stream to the
+// host buffers.
+func (c *Client) collect(s Stream) error {
+	c.mutex.Lock()
+	copies := c.pendingCopies[s]
+	delete(c.pendingCopies, s)
+	c.mutex.Unlock()
+
+	for _, pc := range copies {
+		output := struct {
+			Data string `json:"data"`
+		}{}
+
+		err := c.post("/memcopy_d2h_async_data",
+			map[string]uint64{"copy": pc.handle}, &output)
+		if err != nil {
+			return err
+		}
+
+		err = decodeInto(pc.dst, output.Data)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *Client) collectAll() error {
+	c.mutex.Lock()
+	streams := make([]Stream, 0, len(c.pendingCopies))
+	for s := range c.pendingCopies {
+		streams = append(streams, s)
+	}
+	c.mutex.Unlock()
+
+	for _, s := range streams {
+		err := c.collect(s)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func decodeInto(dst []byte, data string) error {
+	raw, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return err
+	}
+
+	if len(raw) != len(dst) {
+		return fmt.Errorf("expected %d bytes, but got %d bytes",
+			len(dst), len(raw))
+	}
+
+	copy(dst, raw)
+
+	return nil
+}
+
+// ModuleLoadData loads an HSACO file.
+func (c *Client) ModuleLoadData(image []byte) (Module, error) {
+	output := struct {
+		Module Module `json:"module"`
+	}{}
+
+	err := c.post("/module_load_data", map[string]string{
+		"image": base64.StdEncoding.EncodeToString(image),
+	}, &output)
+
+	return output.Module, err
+}
+
+// ModuleGetFunction finds a kernel in a module.
+func (c *Client) ModuleGetFunction(m Module, name string) (Function, error) {
+	output := struct {
+		Function Function `json:"function"`
+	}{}
+
+	err := c.post("/module_get_function", map[string]interface{}{
+		"module": m,
+		"name":   name,
+	}, &output)
+
+	return output.Function, err
+}
+
+// ModuleUnload unloads a module.
+func (c *Client) ModuleUnload(m Module) error {
+	return c.post("/module_unload", map[string]Module{"module": m}, nil)
+}
+
+// ModuleLaunchKernel launches a kernel in a stream. The args are the kernel
+// arguments as the kernel expects them in memory, including the hidden
+// arguments.
+func (c *Client) ModuleLaunchKernel(
+	f Function,
+	gridDim, blockDim Dim3,
+	sharedMemBytes int,
+	s Stream,
+	args []byte,
+) error {
+	return c.post("/module_launch_kernel", map[string]interface{}{
+		"function":         f,
+		"grid_dim":         gridDim,
+		"block_dim":        blockDim,
+		"shared_mem_bytes": sharedMemBytes,
+		"stream":           s,
+		"args":             base64.StdEncoding.EncodeToString(args),
+	}, nil)
+}
+
+// DeviceSynchronize waits for the commands in all the streams to complete.
+func (c *Client) DeviceSynchronize() error {
+	err := c.post("/device_synchronize", struct{}{}, nil)
+	if err != nil {
+		return err
+	}
+
+	return c.collectAll()
+}
+
+// StreamCreate creates a stream.
+func (c *Client) StreamCreate() (Stream, error) {
+	output := struct {
+		Stream Stream `json:"stream"`
+	}{}
+
+	err := c.post("/stream_create", struct{}{}, &output)
+
+	return output.Stream, err
+}
+
+// StreamSynchronize waits for the commands in a stream to complete.
+func (c *Client) StreamSynchronize(s Stream) error {
+	err := c.post("/stream_synchronize", map[string]Stream{"stream": s}, nil)
+	if err != nil {
+		return err
+	}
+
+	return c.collect(s)
+}
+
+// StreamDestroy waits for the commands in a stream to complete and destroys
+// the stream.
+func (c *Client) StreamDestroy(s Stream) error {
+	err := c.post("/stream_destroy", map[string]Stream{"stream": s}, nil)
+	if err != nil {
+		return err
+	}
+
+	return c.collect(s)
+}
+
+// EventCreate creates an event.
+func (c *Client) EventCreate() (Event, error) {
+	output := struct {
+		Event Event `json:"event"`
+	}{}
+
+	err := c.post("/event_create", struct{}{}, &output)
+
+	return output.Event, err
+}
+
+// EventRecord records an event in a stream.
+func (c *Client) EventRecord(e Event, s Stream) error {
+	return c.post("/event_record", map[string]uint64{
+		"event":  uint64(e),
+		"stream": uint64(s),
+	}, nil)
+}
+
+// EventSynchronize waits for an event to be recorded.
+func (c *Client) EventSynchronize(e Event) error {
+	return c.post("/event_synchronize", map[string]Event{"event": e}, nil)
+}
+
+// EventElapsedTime returns the simulated time between two recorded events in
+// milliseconds.
+func (c *Client) EventElapsedTime(start, stop Event) (float64, error) {
+	output := struct {
+		Milliseconds float64 `json:"milliseconds"`
+	}{}
+
+	err := c.post("/event_elapsed_time", map[string]Event{
+		"start": start,
+		"stop":  stop,
+	}, &output)
+
+	return output.Milliseconds, err
+}
+
+// EventDestroy destroys an event.
+func (c *Client) EventDestroy(e Event) error {
+	return c.post("/event_destroy", map[string]Event{"event": e}, nil)
+}
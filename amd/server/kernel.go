@@ -8,6 +8,7 @@ import (
 	"io/ioutil"
 	"net/http"
 
+	"github.com/sarchlab/mgpusim/v4/amd/driver"
 	"github.com/sarchlab/mgpusim/v4/amd/insts"
 )
 
@@ -54,8 +55,11 @@ func handleLaunchKernel(w http.ResponseWriter, r *http.Request) {
 		panic(err)
 	}
 
-	serverInstance.driver.LaunchKernel(
-		serverInstance.ctx,
+	q := serverInstance.nullStream
+	serverInstance.synchronizeDevice()
+
+	serverInstance.driver.EnqueueLaunchKernel(
+		q,
 		hsaCo,
 		[3]uint32{
 			uint32(dataJSON.NumBlocks.X * dataJSON.DimBlocks.X),
@@ -67,7 +71,84 @@ func handleLaunchKernel(w http.ResponseWriter, r *http.Request) {
 			uint16(dataJSON.DimBlocks.Y),
 			uint16(dataJSON.DimBlocks.Z),
 		},
-		rawArgs,
+		&driver.RawKernelArgs{
+			Data:           rawArgs,
+			DynamicLDSSize: uint32(dataJSON.SharedMemBytes),
+		},
+	)
+	serverInstance.driver.DrainCommandQueue(q)
+
+	err = q.Err()
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	w.Write([]byte("{}"))
+}
+
+type moduleLaunchKernelInput struct {
+	Function       uint64 `json:"function"`
+	Args           string `json:"args,omitempty"`
+	GridDim        dim3   `json:"grid_dim"`
+	BlockDim       dim3   `json:"block_dim"`
+	SharedMemBytes int    `json:"shared_mem_bytes,omitempty"`
+	Stream         uint64 `json:"stream,omitempty"`
+}
+
+func (d dim3) isValid() bool {
+	return d.X > 0 && d.Y > 0 && d.Z > 0
+}
+
+func handleModuleLaunchKernel(w http.ResponseWriter, r *http.Request) {
+	input := moduleLaunchKernelInput{}
+	if !readInput(w, r, &input) {
+		return
+	}
+
+	if !input.GridDim.isValid() || !input.BlockDim.isValid() ||
+		input.SharedMemBytes < 0 {
+		http.Error(w, "invalid launch configuration", 400)
+		return
+	}
+
+	rawArgs, err := base64.StdEncoding.DecodeString(input.Args)
+	if err != nil {
+		http.Error(w, "invalid input", 400)
+		return
+	}
+
+	serverInstance.mutex.Lock()
+	f, found := serverInstance.functions[input.Function]
+	serverInstance.mutex.Unlock()
+
+	if !found {
+		http.Error(w, "function does not exist", 404)
+		return
+	}
+
+	q := findStream(w, input.Stream)
+	if q == nil {
+		return
+	}
+
+	serverInstance.driver.EnqueueLaunchKernel(
+		q,
+		f.codeObject,
+		[3]uint32{
+			uint32(input.GridDim.X * input.BlockDim.X),
+			uint32(input.GridDim.Y * input.BlockDim.Y),
+			uint32(input.GridDim.Z * input.BlockDim.Z),
+		},
+		[3]uint16{
+			uint16(input.BlockDim.X),
+			uint16(input.BlockDim.Y),
+			uint16(input.BlockDim.Z),
+		},
+		&driver.RawKernelArgs{
+			Data:           rawArgs,
+			DynamicLDSSize: uint32(input.SharedMemBytes),
+		},
 	)
 
 	w.Write([]byte("{}"))
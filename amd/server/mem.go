@@ -1,6 +1,7 @@
 package server
 
 import (
+	"bytes"
 	"encoding/base64"
 	"encoding/json"
 	"io/ioutil"
@@ -47,12 +48,14 @@ func handleFree(w http.ResponseWriter, r *http.Request) {
 	ptr, err := strconv.ParseUint(ptrStr, 10, 64)
 	if err != nil {
 		http.Error(w, "ptr is not valid", 400)
+		return
 	}
 
 	err = serverInstance.driver.FreeMemory(
 		serverInstance.ctx, driver.Ptr(ptr))
 	if err != nil {
 		http.Error(w, "failed to free the memory", 400)
+		return
 	}
 
 	w.Write([]byte("{}"))
@@ -80,8 +83,10 @@ func handleMemcopyH2D(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "invalid input", 400)
 	}
 
-	serverInstance.driver.MemCopyH2D(serverInstance.ctx,
-		driver.Ptr(input.Ptr), rawData)
+	serverInstance.runSynchronously(func(q *driver.CommandQueue) {
+		serverInstance.driver.EnqueueMemCopyH2D(q,
+			driver.Ptr(input.Ptr), rawData)
+	})
 
 	output := "{}"
 	w.Write([]byte(output))
@@ -108,8 +113,10 @@ func handleMemcopyD2H(w http.ResponseWriter, r *http.Request) {
 
 	rawData := make([]byte, dataJSON.Size)
 
-	serverInstance.driver.MemCopyD2H(serverInstance.ctx,
-		rawData, driver.Ptr(dataJSON.Ptr))
+	serverInstance.runSynchronously(func(q *driver.CommandQueue) {
+		serverInstance.driver.EnqueueMemCopyD2H(q,
+			rawData, driver.Ptr(dataJSON.Ptr))
+	})
 
 	encodedData := base64.StdEncoding.EncodeToString(rawData)
 
@@ -121,3 +128,155 @@ func handleMemcopyD2H(w http.ResponseWriter, r *http.Request) {
 
 	w.Write(rspData)
 }
+
+// runSynchronously runs commands on the null stream after the commands in all
+// the streams complete, like the synchronous HIP APIs.
+func (s *server) runSynchronously(enqueue func(q *driver.CommandQueue)) {
+	s.synchronizeDevice()
+	enqueue(s.nullStream)
+	s.driver.DrainCommandQueue(s.nullStream)
+}
+
+type memsetInput struct {
+	Ptr    uint64 `json:"ptr"`
+	Value  uint8  `json:"value"`
+	Size   uint64 `json:"size"`
+	Stream uint64 `json:"stream,omitempty"`
+}
+
+func handleMemset(w http.ResponseWriter, r *http.Request) {
+	input := memsetInput{}
+	if !readInput(w, r, &input) {
+		return
+	}
+
+	serverInstance.runSynchronously(func(q *driver.CommandQueue) {
+		serverInstance.driver.EnqueueMemCopyH2D(q,
+			driver.Ptr(input.Ptr), memsetData(input))
+	})
+
+	w.Write([]byte("{}"))
+}
+
+func handleMemsetAsync(w http.ResponseWriter, r *http.Request) {
+	input := memsetInput{}
+	if !readInput(w, r, &input) {
+		return
+	}
+
+	q := findStream(w, input.Stream)
+	if q == nil {
+		return
+	}
+
+	serverInstance.driver.EnqueueMemCopyH2D(q,
+		driver.Ptr(input.Ptr), memsetData(input))
+
+	w.Write([]byte("{}"))
+}
+
+// memsetData returns the data that a memset writes. The simulator does not
+// have a memset command, so memsets are copies from the host.
+func memsetData(input memsetInput) []byte {
+	return bytes.Repeat([]byte{input.Value}, int(input.Size))
+}
+
+type memcopyH2DAsyncInput struct {
+	Ptr    uint64 `json:"ptr"`
+	Data   string `json:"data"`
+	Stream uint64 `json:"stream,omitempty"`
+}
+
+func handleMemcopyH2DAsync(w http.ResponseWriter, r *http.Request) {
+	input := memcopyH2DAsyncInput{}
+	if !readInput(w, r, &input) {
+		return
+	}
+
+	rawData, err := base64.StdEncoding.DecodeString(input.Data)
+	if err != nil {
+		http.Error(w, "invalid input", 400)
+		return
+	}
+
+	q := findStream(w, input.Stream)
+	if q == nil {
+		return
+	}
+
+	serverInstance.driver.EnqueueMemCopyH2D(q, driver.Ptr(input.Ptr), rawData)
+
+	w.Write([]byte("{}"))
+}
+
+// An asyncCopy is a device to host copy that a client has not collected the
+// data of.
+type asyncCopy struct {
+	data  []byte
+	event *driver.Event
+}
+
+type memcopyD2HAsyncInput struct {
+	Ptr    uint64 `json:"ptr"`
+	Size   uint64 `json:"size"`
+	Stream uint64 `json:"stream,omitempty"`
+}
+
+type memcopyD2HAsyncOutput struct {
+	Copy uint64 `json:"copy"`
+}
+
+func handleMemcopyD2HAsync(w http.ResponseWriter, r *http.Request) {
+	input := memcopyD2HAsyncInput{}
+	if !readInput(w, r, &input) {
+		return
+	}
+
+	q := findStream(w, input.Stream)
+	if q == nil {
+		return
+	}
+
+	c := &asyncCopy{
+		data:  make([]byte, input.Size),
+		event: driver.NewEvent(),
+	}
+	serverInstance.driver.EnqueueMemCopyD2H(q, c.data, driver.Ptr(input.Ptr))
+	serverInstance.driver.EnqueueRecordEvent(q, c.event)
+
+	serverInstance.mutex.Lock()
+	handle := serverInstance.newHandle()
+	serverInstance.asyncCopies[handle] = c
+	serverInstance.mutex.Unlock()
+
+	writeOutput(w, memcopyD2HAsyncOutput{Copy: handle})
+}
+
+type memcopyD2HAsyncDataInput struct {
+	Copy uint64 `json:"copy"`
+}
+
+// handleMemcopyD2HAsyncData waits for an asynchronous device to host copy to
+// complete and responds with the data. The data can be collected only once.
+func handleMemcopyD2HAsyncData(w http.ResponseWriter, r *http.Request) {
+	input := memcopyD2HAsyncDataInput{}
+	if !readInput(w, r, &input) {
+		return
+	}
+
+	serverInstance.mutex.Lock()
+	c, found := serverInstance.asyncCopies[input.Copy]
+	delete(serverInstance.asyncCopies, input.Copy)
+	serverInstance.mutex.Unlock()
+
+	if !found {
+		http.Error(w, "copy does not exist", 404)
+		return
+	}
+
+	serverInstance.driver.SynchronizeEvent(c.event)
+
+	writeOutput(w, memcopyD2HOutput{
+		Data: base64.StdEncoding.EncodeToString(c.data),
+	})
+}
@@ -0,0 +1,155 @@
+package server
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/base64"
+	"net/http"
+
+	"github.com/sarchlab/mgpusim/v4/amd/insts"
+)
+
+// A module is an HSACO file that a client uploads.
+type module struct {
+	elfFile   *elf.File
+	functions map[string]uint64
+}
+
+// A function is a kernel in a module.
+type function struct {
+	module     uint64
+	codeObject *insts.KernelCodeObject
+}
+
+type moduleLoadDataInput struct {
+	Image string `json:"image"`
+}
+
+type moduleOutput struct {
+	Module uint64 `json:"module"`
+}
+
+func handleModuleLoadData(w http.ResponseWriter, r *http.Request) {
+	input := moduleLoadDataInput{}
+	if !readInput(w, r, &input) {
+		return
+	}
+
+	image, err := base64.StdEncoding.DecodeString(input.Image)
+	if err != nil {
+		http.Error(w, "invalid input", 400)
+		return
+	}
+
+	elfFile, err := elf.NewFile(bytes.NewReader(image))
+	if err != nil || elfFile.Section(".text") == nil {
+		http.Error(w, "invalid image", 400)
+		return
+	}
+
+	serverInstance.mutex.Lock()
+	handle := serverInstance.newHandle()
+	serverInstance.modules[handle] = &module{
+		elfFile:   elfFile,
+		functions: make(map[string]uint64),
+	}
+	serverInstance.mutex.Unlock()
+
+	writeOutput(w, moduleOutput{Module: handle})
+}
+
+type moduleGetFunctionInput struct {
+	Module uint64 `json:"module"`
+	Name   string `json:"name"`
+}
+
+type functionOutput struct {
+	Function uint64 `json:"function"`
+}
+
+func handleModuleGetFunction(w http.ResponseWriter, r *http.Request) {
+	input := moduleGetFunctionInput{}
+	if !readInput(w, r, &input) {
+		return
+	}
+
+	serverInstance.mutex.Lock()
+	defer serverInstance.mutex.Unlock()
+
+	m, found := serverInstance.modules[input.Module]
+	if !found {
+		http.Error(w, "module does not exist", 404)
+		return
+	}
+
+	handle, found := m.functions[input.Name]
+	if !found {
+		if !m.hasKernel(input.Name) {
+			http.Error(w, "function does not exist", 404)
+			return
+		}
+
+		co := insts.LoadKernelCodeObjectFromELF(m.elfFile, input.Name)
+		serverInstance.driver.LoadCodeObject(serverInstance.ctx, co)
+
+		handle = serverInstance.newHandle()
+		m.functions[input.Name] = handle
+		serverInstance.functions[handle] = &function{
+			module:     input.Module,
+			codeObject: co,
+		}
+	}
+
+	writeOutput(w, functionOutput{Function: handle})
+}
+
+// hasKernel checks if the kernel can be loaded, so that loading the kernel
+// does not terminate the server. Files without a symbol table contain a
+// single kernel that can be loaded with any name.
+func (m *module) hasKernel(name string) bool {
+	symbols, err := m.elfFile.Symbols()
+	if err != nil {
+		return true
+	}
+
+	for _, sym := range symbols {
+		if sym.Section == elf.SHN_UNDEF ||
+			int(sym.Section) >= len(m.elfFile.Sections) {
+			continue
+		}
+
+		sec := m.elfFile.Sections[sym.Section]
+		if sec.Name == ".text" && sym.Size > 0 && sym.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+type moduleUnloadInput struct {
+	Module uint64 `json:"module"`
+}
+
+func handleModuleUnload(w http.ResponseWriter, r *http.Request) {
+	input := moduleUnloadInput{}
+	if !readInput(w, r, &input) {
+		return
+	}
+
+	serverInstance.mutex.Lock()
+	defer serverInstance.mutex.Unlock()
+
+	m, found := serverInstance.modules[input.Module]
+	if !found {
+		http.Error(w, "module does not exist", 404)
+		return
+	}
+
+	for _, handle := range m.functions {
+		delete(serverInstance.functions, handle)
+	}
+	delete(serverInstance.modules, input.Module)
+
+	w.Write([]byte("{}"))
+}
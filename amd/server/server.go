@@ -3,7 +3,9 @@
 package server
 
 import (
+	"encoding/json"
 	"net/http"
+	"sync"
 
 	"github.com/gorilla/mux"
 
@@ -13,6 +15,17 @@ import (
 type server struct {
 	driver *driver.Driver
 	ctx    *driver.Context
+
+	// The handles of the objects that the clients create. Handle 0 is never
+	// used, so that stream 0 can refer to the null stream.
+	mutex       sync.Mutex
+	nextHandle  uint64
+	modules     map[uint64]*module
+	functions   map[uint64]*function
+	nullStream  *driver.CommandQueue
+	streams     map[uint64]*driver.CommandQueue
+	events      map[uint64]*driver.Event
+	asyncCopies map[uint64]*asyncCopy
 }
 
 var serverInstance server
@@ -38,12 +51,24 @@ func (b Builder) WithDriver(d *driver.Driver) Builder {
 // to a port.
 func (b Builder) Build() {
 	serverInstance = server{
-		driver: b.driver,
+		driver:      b.driver,
+		modules:     make(map[uint64]*module),
+		functions:   make(map[uint64]*function),
+		streams:     make(map[uint64]*driver.CommandQueue),
+		events:      make(map[uint64]*driver.Event),
+		asyncCopies: make(map[uint64]*asyncCopy),
 	}
 
 	b.driver.Run()
 
 	serverInstance.ctx = serverInstance.driver.Init()
+	serverInstance.nullStream = serverInstance.driver.CreateCommandQueue(
+		serverInstance.ctx)
+}
+
+func (s *server) newHandle() uint64 {
+	s.nextHandle++
+	return s.nextHandle
 }
 
 // RegisterHandlers registers all the handlers of the MGPUSim server
@@ -52,8 +77,58 @@ func RegisterHandlers() {
 	r.HandleFunc("/device_count", handleDeviceCount)
 	r.HandleFunc("/device_properties/{id:[0-9]+}", handleDeviceProperties)
 	r.HandleFunc("/malloc", handleMalloc)
+	r.HandleFunc("/free/{ptr:[0-9]+}", handleFree)
 	r.HandleFunc("/memcopy_h2d", handleMemcopyH2D)
 	r.HandleFunc("/memcopy_d2h", handleMemcopyD2H)
 	r.HandleFunc("/launch_kernel", handleLaunchKernel)
+
+	r.HandleFunc("/memset", handleMemset).Methods("POST")
+	r.HandleFunc("/memset_async", handleMemsetAsync).Methods("POST")
+	r.HandleFunc("/memcopy_h2d_async", handleMemcopyH2DAsync).Methods("POST")
+	r.HandleFunc("/memcopy_d2h_async", handleMemcopyD2HAsync).Methods("POST")
+	r.HandleFunc("/memcopy_d2h_async_data", handleMemcopyD2HAsyncData).
+		Methods("POST")
+
+	r.HandleFunc("/module_load_data", handleModuleLoadData).Methods("POST")
+	r.HandleFunc("/module_get_function", handleModuleGetFunction).
+		Methods("POST")
+	r.HandleFunc("/module_unload", handleModuleUnload).Methods("POST")
+	r.HandleFunc("/module_launch_kernel", handleModuleLaunchKernel).
+		Methods("POST")
+
+	r.HandleFunc("/device_synchronize", handleDeviceSynchronize).
+		Methods("POST")
+	r.HandleFunc("/stream_create", handleStreamCreate).Methods("POST")
+	r.HandleFunc("/stream_synchronize", handleStreamSynchronize).
+		Methods("POST")
+	r.HandleFunc("/stream_destroy", handleStreamDestroy).Methods("POST")
+
+	r.HandleFunc("/event_create", handleEventCreate).Methods("POST")
+	r.HandleFunc("/event_record", handleEventRecord).Methods("POST")
+	r.HandleFunc("/event_synchronize", handleEventSynchronize).Methods("POST")
+	r.HandleFunc("/event_elapsed_time", handleEventElapsedTime).
+		Methods("POST")
+	r.HandleFunc("/event_destroy", handleEventDestroy).Methods("POST")
 	http.Handle("/", r)
 }
+
+// readInput decodes the JSON body of the request. It responds with an error
+// and returns false if the body is not valid.
+func readInput(w http.ResponseWriter, r *http.Request, input interface{}) bool {
+	err := json.NewDecoder(r.Body).Decode(input)
+	if err != nil {
+		http.Error(w, "invalid input", 400)
+		return false
+	}
+
+	return true
+}
+
+func writeOutput(w http.ResponseWriter, output interface{}) {
+	rspData, err := json.Marshal(output)
+	if err != nil {
+		panic(err)
+	}
+
+	w.Write(rspData)
+}
@@ -0,0 +1,255 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/sarchlab/mgpusim/v4/amd/driver"
+)
+
+type streamInput struct {
+	Stream uint64 `json:"stream"`
+}
+
+type streamOutput struct {
+	Stream uint64 `json:"stream"`
+}
+
+// stream returns the command queue of a stream. Stream 0 is the null stream.
+func (s *server) stream(handle uint64) (*driver.CommandQueue, bool) {
+	if handle == 0 {
+		return s.nullStream, true
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	q, found := s.streams[handle]
+
+	return q, found
+}
+
+// findStream responds with an error and returns nil if the stream does not
+// exist.
+func findStream(w http.ResponseWriter, handle uint64) *driver.CommandQueue {
+	q, found := serverInstance.stream(handle)
+	if !found {
+		http.Error(w, "stream does not exist", 404)
+		return nil
+	}
+
+	return q
+}
+
+// synchronizeDevice waits for the commands in all the streams to complete. It
+// returns the first memory fault of the streams.
+func (s *server) synchronizeDevice() error {
+	s.mutex.Lock()
+	queues := []*driver.CommandQueue{s.nullStream}
+	for _, q := range s.streams {
+		queues = append(queues, q)
+	}
+	s.mutex.Unlock()
+
+	var err error
+	for _, q := range queues {
+		s.driver.DrainCommandQueue(q)
+
+		if err == nil {
+			err = q.Err()
+		}
+	}
+
+	return err
+}
+
+func handleDeviceSynchronize(w http.ResponseWriter, r *http.Request) {
+	err := serverInstance.synchronizeDevice()
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	w.Write([]byte("{}"))
+}
+
+func handleStreamCreate(w http.ResponseWriter, r *http.Request) {
+	q := serverInstance.driver.CreateCommandQueue(serverInstance.ctx)
+
+	serverInstance.mutex.Lock()
+	handle := serverInstance.newHandle()
+	serverInstance.streams[handle] = q
+	serverInstance.mutex.Unlock()
+
+	writeOutput(w, streamOutput{Stream: handle})
+}
+
+func handleStreamSynchronize(w http.ResponseWriter, r *http.Request) {
+	input := streamInput{}
+	if !readInput(w, r, &input) {
+		return
+	}
+
+	q := findStream(w, input.Stream)
+	if q == nil {
+		return
+	}
+
+	serverInstance.driver.DrainCommandQueue(q)
+
+	err := q.Err()
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	w.Write([]byte("{}"))
+}
+
+func handleStreamDestroy(w http.ResponseWriter, r *http.Request) {
+	input := streamInput{}
+	if !readInput(w, r, &input) {
+		return
+	}
+
+	if input.Stream == 0 {
+		http.Error(w, "the null stream cannot be destroyed", 400)
+		return
+	}
+
+	q := findStream(w, input.Stream)
+	if q == nil {
+		return
+	}
+
+	serverInstance.driver.DrainCommandQueue(q)
+
+	serverInstance.mutex.Lock()
+	delete(serverInstance.streams, input.Stream)
+	serverInstance.mutex.Unlock()
+
+	w.Write([]byte("{}"))
+}
+
+type eventInput struct {
+	Event  uint64 `json:"event"`
+	Stream uint64 `json:"stream,omitempty"`
+}
+
+type eventOutput struct {
+	Event uint64 `json:"event"`
+}
+
+// findEvent responds with an error and returns nil if the event does not
+// exist.
+func findEvent(w http.ResponseWriter, handle uint64) *driver.Event {
+	serverInstance.mutex.Lock()
+	defer serverInstance.mutex.Unlock()
+
+	e, found := serverInstance.events[handle]
+	if !found {
+		http.Error(w, "event does not exist", 404)
+		return nil
+	}
+
+	return e
+}
+
+func handleEventCreate(w http.ResponseWriter, r *http.Request) {
+	serverInstance.mutex.Lock()
+	handle := serverInstance.newHandle()
+	serverInstance.events[handle] = driver.NewEvent()
+	serverInstance.mutex.Unlock()
+
+	writeOutput(w, eventOutput{Event: handle})
+}
+
+func handleEventRecord(w http.ResponseWriter, r *http.Request) {
+	input := eventInput{}
+	if !readInput(w, r, &input) {
+		return
+	}
+
+	e := findEvent(w, input.Event)
+	if e == nil {
+		return
+	}
+
+	q := findStream(w, input.Stream)
+	if q == nil {
+		return
+	}
+
+	serverInstance.driver.EnqueueRecordEvent(q, e)
+
+	w.Write([]byte("{}"))
+}
+
+func handleEventSynchronize(w http.ResponseWriter, r *http.Request) {
+	input := eventInput{}
+	if !readInput(w, r, &input) {
+		return
+	}
+
+	e := findEvent(w, input.Event)
+	if e == nil {
+		return
+	}
+
+	serverInstance.driver.SynchronizeEvent(e)
+
+	w.Write([]byte("{}"))
+}
+
+type eventElapsedTimeInput struct {
+	Start uint64 `json:"start"`
+	Stop  uint64 `json:"stop"`
+}
+
+type eventElapsedTimeOutput struct {
+	Milliseconds float64 `json:"milliseconds"`
+}
+
+func handleEventElapsedTime(w http.ResponseWriter, r *http.Request) {
+	input := eventElapsedTimeInput{}
+	if !readInput(w, r, &input) {
+		return
+	}
+
+	start := findEvent(w, input.Start)
+	if start == nil {
+		return
+	}
+
+	stop := findEvent(w, input.Stop)
+	if stop == nil {
+		return
+	}
+
+	startTime, startRecorded := start.Time()
+	stopTime, stopRecorded := stop.Time()
+	if !startRecorded || !stopRecorded {
+		http.Error(w, "event not recorded", 400)
+		return
+	}
+
+	writeOutput(w, eventElapsedTimeOutput{
+		Milliseconds: float64(stopTime-startTime) * 1000,
+	})
+}
+
+func handleEventDestroy(w http.ResponseWriter, r *http.Request) {
+	input := eventInput{}
+	if !readInput(w, r, &input) {
+		return
+	}
+
+	if findEvent(w, input.Event) == nil {
+		return
+	}
+
+	serverInstance.mutex.Lock()
+	delete(serverInstance.events, input.Event)
+	serverInstance.mutex.Unlock()
+
+	w.Write([]byte("{}"))
+}
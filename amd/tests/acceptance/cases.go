@@ -143,6 +143,11 @@ var benchmarks = []benchmark{
 			{gpus: []int{1, 2, 3, 4}, timing: false, parallel: true, unifiedGPU: true, unifiedMemory: true},
 			{gpus: []int{1, 2, 3, 4}, timing: true, parallel: false, unifiedGPU: true, unifiedMemory: true},
 			{gpus: []int{1, 2, 3, 4}, timing: true, parallel: true, unifiedGPU: true, unifiedMemory: true},
+			{gpus: []int{1, 2}, timing: true, parallel: false, unifiedGPU: false, unifiedMemory: true, pageMigration: true},
+			{gpus: []int{1, 2, 3, 4}, timing: true, parallel: false, unifiedGPU: false, unifiedMemory: true, pageMigration: true},
+			{gpus: []int{1, 2}, timing: true, parallel: false, unifiedGPU: false, unifiedMemory: true, pagePlacement: "round-robin"},
+			{gpus: []int{1, 2, 3, 4}, timing: true, parallel: false, unifiedGPU: false, unifiedMemory: true, pagePlacement: "first-touch"},
+			{gpus: []int{1, 2}, timing: true, parallel: false, unifiedGPU: false, unifiedMemory: false, timingStart: 1},
 		},
 	},
 	{
@@ -48,9 +48,12 @@ type benchmarkCase struct {
 	timing        bool
 	unifiedGPU    bool
 	unifiedMemory bool
+	pageMigration bool   // migrate unified memory pages to the accessing GPU
+	pagePlacement string // page placement policy, e.g., "round-robin"
 	parallel      bool
 	arch          string // GPU architecture: "gcn3" (default) or "cdna3"
 	gpuType       string // GPU model: "r9nano" (default) or "mi300a"
+	timingStart   int    // number of kernels to emulate before timing
 }
 
 func (b benchmark) compile() error {
@@ -154,6 +157,18 @@ func (b benchmark) populateArgs(c benchmarkCase) []string {
 		args = append(args, "-use-unified-memory=false")
 	}
 
+	if c.pageMigration {
+		args = append(args, "-page-migration")
+	}
+
+	if c.pagePlacement != "" {
+		args = append(args, "-page-placement="+c.pagePlacement)
+	}
+
+	if c.timingStart > 0 {
+		args = append(args, fmt.Sprintf("-timing-start-kernel=%d", c.timingStart))
+	}
+
 	if c.arch != "" {
 		args = append(args, "-arch="+c.arch)
 	}
@@ -0,0 +1,369 @@
+// Package main runs a FIR kernel on the MGPUSim server with the reference
+// client, so that the server protocol is tested end to end.
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/sarchlab/mgpusim/v4/amd/server/client"
+)
+
+var portFlag = flag.Int("port", 18081, "The port that the server listens to.")
+var timingFlag = flag.Bool("timing", false,
+	"Run the server with detailed timing simulation.")
+
+const (
+	serverPath = "../../samples/server"
+	hsacoPath  = "../../benchmarks/heteromark/fir/kernels.hsaco"
+	length     = 4096
+	numTaps    = 16
+	wgSize     = 256
+)
+
+func compileServer() error {
+	goExecutable, err := exec.LookPath("go")
+	if err != nil {
+		return err
+	}
+
+	cmd := &exec.Cmd{
+		Path:   goExecutable,
+		Dir:    serverPath,
+		Args:   []string{"go", "build"},
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+	}
+
+	fmt.Print(cmd.String())
+
+	if err := cmd.Run(); err != nil {
+		color.Red("\tFailed")
+		return err
+	}
+
+	color.Green("\tSucceed")
+
+	return nil
+}
+
+func startServer() (*exec.Cmd, *client.Client, error) {
+	cmd := &exec.Cmd{
+		Path: serverPath + "/server",
+		Dir:  serverPath,
+		Args: []string{
+			"server",
+			fmt.Sprintf("-port=%d", *portFlag),
+			fmt.Sprintf("-timing=%t", *timingFlag),
+			"-disable-rtm",
+		},
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+	}
+
+	fmt.Print(cmd.String())
+
+	err := cmd.Start()
+	if err != nil {
+		color.Red("\tFailed")
+		return nil, nil, err
+	}
+
+	c := client.New(fmt.Sprintf("http://localhost:%d", *portFlag))
+	for i := 0; i < 600; i++ {
+		_, err = c.GetDeviceCount()
+		if err == nil {
+			color.Green("\tSucceed")
+			return cmd, c, nil
+		}
+
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	color.Red("\tFailed")
+	_ = cmd.Process.Kill()
+
+	return nil, nil, fmt.Errorf("server does not respond: %v", err)
+}
+
+func float32sToBytes(data []float32) []byte {
+	buf := bytes.NewBuffer(nil)
+	_ = binary.Write(buf, binary.LittleEndian, data)
+
+	return buf.Bytes()
+}
+
+func bytesToFloat32s(data []byte) []float32 {
+	out := make([]float32, len(data)/4)
+	_ = binary.Read(bytes.NewReader(data), binary.LittleEndian, out)
+
+	return out
+}
+
+type firKernelArgs struct {
+	Output              uint64
+	Filter              uint64
+	Input               uint64
+	History             uint64
+	NumTaps             uint32
+	Padding             uint32
+	HiddenGlobalOffsetX int64
+	HiddenGlobalOffsetY int64
+	HiddenGlobalOffsetZ int64
+}
+
+type firBuffers struct {
+	output, filter, input, history uint64
+}
+
+func malloc(c *client.Client) (b firBuffers, err error) {
+	ptrs := []*uint64{&b.output, &b.filter, &b.input, &b.history}
+	sizes := []uint64{length * 4, numTaps * 4, length * 4, numTaps * 4}
+
+	for i, ptr := range ptrs {
+		*ptr, err = c.Malloc(sizes[i])
+		if err != nil {
+			return b, err
+		}
+	}
+
+	return b, nil
+}
+
+func runFIR(c *client.Client) error {
+	image, err := os.ReadFile(hsacoPath)
+	if err != nil {
+		return err
+	}
+
+	m, err := c.ModuleLoadData(image)
+	if err != nil {
+		return err
+	}
+
+	f, err := c.ModuleGetFunction(m, "FIR")
+	if err != nil {
+		return err
+	}
+
+	_, err = c.ModuleGetFunction(m, "NotAKernel")
+	if err == nil {
+		return fmt.Errorf("got a function that does not exist")
+	}
+
+	buffers, err := malloc(c)
+	if err != nil {
+		return err
+	}
+
+	input := make([]float32, length)
+	for i := range input {
+		input[i] = float32(i%37) / 7
+	}
+
+	filter := make([]float32, numTaps)
+	for i := range filter {
+		filter[i] = float32(i+1) / numTaps
+	}
+
+	err = c.Memset(buffers.history, 0, numTaps*4)
+	if err != nil {
+		return err
+	}
+
+	s, err := c.StreamCreate()
+	if err != nil {
+		return err
+	}
+
+	start, err := c.EventCreate()
+	if err != nil {
+		return err
+	}
+
+	stop, err := c.EventCreate()
+	if err != nil {
+		return err
+	}
+
+	err = c.MemsetAsync(buffers.output, 0xff, length*4, s)
+	if err != nil {
+		return err
+	}
+
+	err = c.MemcpyHtoDAsync(buffers.input, float32sToBytes(input), s)
+	if err != nil {
+		return err
+	}
+
+	err = c.MemcpyHtoDAsync(buffers.filter, float32sToBytes(filter), s)
+	if err != nil {
+		return err
+	}
+
+	args := bytes.NewBuffer(nil)
+	_ = binary.Write(args, binary.LittleEndian, firKernelArgs{
+		Output:  buffers.output,
+		Filter:  buffers.filter,
+		Input:   buffers.input,
+		History: buffers.history,
+		NumTaps: numTaps,
+	})
+
+	err = c.EventRecord(start, s)
+	if err != nil {
+		return err
+	}
+
+	err = c.ModuleLaunchKernel(f,
+		client.Dim3{X: length / wgSize, Y: 1, Z: 1},
+		client.Dim3{X: wgSize, Y: 1, Z: 1},
+		0, s, args.Bytes())
+	if err != nil {
+		return err
+	}
+
+	err = c.EventRecord(stop, s)
+	if err != nil {
+		return err
+	}
+
+	output := make([]byte, length*4)
+	err = c.MemcpyDtoHAsync(output, buffers.output, s)
+	if err != nil {
+		return err
+	}
+
+	err = c.EventSynchronize(stop)
+	if err != nil {
+		return err
+	}
+
+	ms, err := c.EventElapsedTime(start, stop)
+	if err != nil {
+		return err
+	}
+
+	if ms <= 0 {
+		return fmt.Errorf("the kernel takes %f ms", ms)
+	}
+
+	err = c.StreamSynchronize(s)
+	if err != nil {
+		return err
+	}
+
+	err = verifyFIR(input, filter, bytesToFloat32s(output))
+	if err != nil {
+		return err
+	}
+
+	err = verifyMemset(c, buffers.history)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range []client.Event{start, stop} {
+		err = c.EventDestroy(e)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = c.StreamDestroy(s)
+	if err != nil {
+		return err
+	}
+
+	err = c.ModuleUnload(m)
+	if err != nil {
+		return err
+	}
+
+	for _, ptr := range []uint64{
+		buffers.output, buffers.filter, buffers.input, buffers.history,
+	} {
+		err = c.Free(ptr)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func verifyFIR(input, filter, output []float32) error {
+	for i := range output {
+		var sum float32
+
+		for j := 0; j < numTaps; j++ {
+			if i < j {
+				continue
+			}
+			sum += input[i-j] * filter[j]
+		}
+
+		if math.Abs(float64(sum-output[i])) >= 1e-5 {
+			return fmt.Errorf("at position %d, expected %f, but get %f",
+				i, sum, output[i])
+		}
+	}
+
+	return nil
+}
+
+func verifyMemset(c *client.Client, ptr uint64) error {
+	err := c.Memset(ptr, 0x5a, numTaps*4)
+	if err != nil {
+		return err
+	}
+
+	data := make([]byte, numTaps*4)
+	err = c.MemcpyDtoH(data, ptr)
+	if err != nil {
+		return err
+	}
+
+	for i, b := range data {
+		if b != 0x5a {
+			return fmt.Errorf("at byte %d, expected 0x5a, but get 0x%02x",
+				i, b)
+		}
+	}
+
+	return nil
+}
+
+func main() {
+	flag.Parse()
+
+	err := compileServer()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	server, c, err := startServer()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Print("FIR through the server")
+	err = runFIR(c)
+	_ = server.Process.Kill()
+	_ = server.Wait()
+
+	if err != nil {
+		color.Red("\tFailed")
+		log.Fatal(err)
+	}
+
+	color.Green("\tSucceed")
+}
@@ -26,6 +26,11 @@ type Builder struct {
 	constantKernelOverhead         int
 	subsequentKernelLaunchOverhead int
 	wgScalingThreshold             int
+	wavefrontSize                  int
+	dispatchAlg                    string
+	dispatchAlgFactory             DispatchAlgorithmFactory
+	numCUPerShaderArray            int
+	maxWGPerCU                     int
 }
 
 // MakeBuilder creates a new builder with default configuration values.
@@ -33,6 +38,7 @@ func MakeBuilder() Builder {
 	b := Builder{
 		freq:           1 * sim.GHz,
 		numDispatchers: 8,
+		dispatchAlg:    "round-robin",
 	}
 	return b
 }
@@ -113,6 +119,54 @@ func (b Builder) WithWGScalingThreshold(n int) Builder {
 	return b
 }
 
+// WithWavefrontSize sets the number of work-items in each wavefront that the
+// GPU runs, such as 32 on RDNA-style GPUs. The dispatchers reject the kernels
+// compiled for another wavefront size. Zero lets the GPU run the wavefront
+// size of each kernel.
+func (b Builder) WithWavefrontSize(n int) Builder {
+	b.wavefrontSize = n
+	return b
+}
+
+// WithDispatchAlgorithm sets the algorithm that the dispatchers use to
+// decide which CU each work-group goes to. It must be one of the
+// DispatchAlgorithmNames. Default is "round-robin".
+func (b Builder) WithDispatchAlgorithm(name string) Builder {
+	for _, n := range DispatchAlgorithmNames() {
+		if n == name {
+			b.dispatchAlg = name
+			return b
+		}
+	}
+
+	panic("unknown dispatching algorithm " + name)
+}
+
+// WithDispatchAlgorithmFactory sets the factory that creates a custom
+// dispatching algorithm for each dispatcher. It replaces the algorithm that
+// WithDispatchAlgorithm selects.
+func (b Builder) WithDispatchAlgorithmFactory(
+	factory DispatchAlgorithmFactory,
+) Builder {
+	b.dispatchAlgFactory = factory
+	return b
+}
+
+// WithNumCUPerShaderArray sets the number of CUs that share the L1 caches of
+// a shader array. The "clustered" algorithm keeps the work-groups with
+// neighboring IDs in a shader array.
+func (b Builder) WithNumCUPerShaderArray(n int) Builder {
+	b.numCUPerShaderArray = n
+	return b
+}
+
+// WithMaxWGPerCU sets the number of work-groups that the "occupancy-capped"
+// algorithm can dispatch to each CU at the same time.
+func (b Builder) WithMaxWGPerCU(n int) Builder {
+	b.maxWGPerCU = n
+	return b
+}
+
 // Build builds a new Command Processor
 func (b Builder) Build(name string) *CommandProcessor {
 	cp := new(CommandProcessor)
@@ -170,19 +224,32 @@ func (b Builder) buildDispatchers(cp *CommandProcessor) {
 	cuResourcePool := resource.NewCUResourcePool()
 	builder := dispatching.MakeBuilder().
 		WithCP(cp).
-		WithAlg("round-robin").
+		WithAlg(b.dispatchAlg).
 		WithCUResourcePool(cuResourcePool).
 		WithDispatchingPort(cp.ToCUs).
 		WithRespondingPort(cp.ToDriver).
 		WithMonitor(b.monitor).
 		WithConstantKernelLaunchOverhead(b.constantKernelLaunchOverhead).
 		WithSubsequentKernelLaunchOverhead(b.subsequentKernelLaunchOverhead).
-		WithWGScalingThreshold(b.wgScalingThreshold)
+		WithWGScalingThreshold(b.wgScalingThreshold).
+		WithWavefrontSize(b.wavefrontSize)
 
 	if b.constantKernelOverhead > 0 {
 		builder = builder.WithConstantKernelOverhead(b.constantKernelOverhead)
 	}
 
+	if b.dispatchAlgFactory != nil {
+		builder = builder.WithAlgFactory(b.dispatchAlgFactory)
+	}
+
+	if b.numCUPerShaderArray > 0 {
+		builder = builder.WithNumCUPerCluster(b.numCUPerShaderArray)
+	}
+
+	if b.maxWGPerCU > 0 {
+		builder = builder.WithMaxWGPerCU(b.maxWGPerCU)
+	}
+
 	for i := 0; i < b.numDispatchers; i++ {
 		disp := builder.Build(fmt.Sprintf("%s.Dispatcher%d", cp.Name(), i))
 
@@ -0,0 +1,50 @@
+package cp
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/mgpusim/v4/amd/timing/cp/internal/dispatching"
+	"go.uber.org/mock/gomock"
+)
+
+var _ = Describe("Builder", func() {
+	var (
+		mockCtrl *gomock.Controller
+		engine   *MockEngine
+	)
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		engine = NewMockEngine(mockCtrl)
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	It("should create an algorithm for each dispatcher with the factory",
+		func() {
+			var pools []CUResourcePool
+
+			MakeBuilder().
+				WithEngine(engine).
+				WithFreq(1).
+				WithDispatchAlgorithmFactory(
+					func(pool CUResourcePool) DispatchAlgorithm {
+						pools = append(pools, pool)
+						return dispatching.NewRoundRobinAlgorithm(pool)
+					}).
+				Build("CP")
+
+			Expect(pools).To(HaveLen(8))
+			for _, pool := range pools {
+				Expect(pool).To(BeIdenticalTo(pools[0]))
+			}
+		})
+
+	It("should panic on unknown dispatching algorithms", func() {
+		Expect(func() {
+			MakeBuilder().WithDispatchAlgorithm("unknown")
+		}).To(Panic())
+	})
+})
@@ -9,6 +9,7 @@ import (
 	"github.com/sarchlab/akita/v4/mem/mem"
 	"github.com/sarchlab/akita/v4/mem/vm/tlb"
 	"github.com/sarchlab/akita/v4/sim"
+	"github.com/sarchlab/akita/v4/tracing"
 	"github.com/sarchlab/mgpusim/v4/amd/protocol"
 	"github.com/sarchlab/mgpusim/v4/amd/timing/cp/internal/dispatching"
 	"github.com/sarchlab/mgpusim/v4/amd/timing/pagemigrationcontroller"
@@ -16,6 +17,17 @@ import (
 	"go.uber.org/mock/gomock"
 )
 
+// taskEndRecorder records the IDs of the tasks that end.
+type taskEndRecorder struct {
+	endedTasks []string
+}
+
+func (r *taskEndRecorder) Func(ctx sim.HookCtx) {
+	if ctx.Pos == tracing.HookPosTaskEnd {
+		r.endedTasks = append(r.endedTasks, ctx.Item.(tracing.Task).ID)
+	}
+}
+
 var _ = Describe("CommandProcessor", func() {
 
 	var (
@@ -185,6 +197,25 @@ var _ = Describe("CommandProcessor", func() {
 		Expect(madeProgress).To(BeFalse())
 	})
 
+	It("should complete a flush right away if there is no cache", func() {
+		commandProcessor.L1ICaches = nil
+		commandProcessor.L1SCaches = nil
+		commandProcessor.L1VCaches = nil
+		commandProcessor.L2Caches = nil
+		hook := &taskEndRecorder{}
+		commandProcessor.AcceptHook(hook)
+		req := protocol.NewFlushReq(driver, commandProcessor.ToDriver)
+
+		toDriver.EXPECT().Send(gomock.AssignableToTypeOf(&sim.GeneralRsp{}))
+		toDriver.EXPECT().RetrieveIncoming()
+
+		madeProgress := commandProcessor.middleware.processFlushReq(req)
+
+		Expect(madeProgress).To(BeTrue())
+		Expect(hook.endedTasks).To(ConsistOf(
+			tracing.MsgIDAtReceiver(req, commandProcessor)))
+	})
+
 	It("should handle a RDMA drain req from driver", func() {
 		nilPort := NewMockPort(mockCtrl)
 		nilPort.EXPECT().AsRemote().AnyTimes()
@@ -132,22 +132,27 @@ func (m *cpMiddleware) processFlushReq(
 	}
 
 	for _, port := range m.L1ICaches {
-		m.flushCache(port)
+		m.flushCache(port, req.InvalidateAllCacheLines)
 	}
 
 	for _, port := range m.L1SCaches {
-		m.flushCache(port)
+		m.flushCache(port, req.InvalidateAllCacheLines)
 	}
 
 	for _, port := range m.L1VCaches {
-		m.flushCache(port)
+		m.flushCache(port, req.InvalidateAllCacheLines)
 	}
 
 	for _, port := range m.L2Caches {
-		m.flushCache(port)
+		m.flushCache(port, req.InvalidateAllCacheLines)
 	}
 
 	m.currFlushRequest = req
+	m.ToDriver.RetrieveIncoming()
+
+	tracing.TraceReqReceive(req, m.CommandProcessor)
+
+	// Without caches to wait for, the flush completes right away.
 	if m.numCacheACK == 0 {
 		rsp := sim.GeneralRspBuilder{}.
 			WithSrc(m.ToDriver.AsRemote()).
@@ -155,11 +160,9 @@ func (m *cpMiddleware) processFlushReq(
 			WithOriginalReq(req).
 			Build()
 		m.ToDriver.Send(rsp)
-	}
-
-	m.ToDriver.RetrieveIncoming()
 
-	tracing.TraceReqReceive(req, m.CommandProcessor)
+		tracing.TraceReqComplete(req, m.CommandProcessor)
+	}
 
 	return true
 }
@@ -211,11 +214,16 @@ func (m *cpMiddleware) cloneMemCopyD2HReq(
 	return &cloned
 }
 
-func (m *cpMiddleware) flushCache(port sim.Port) {
-	flushReq := cache.FlushReqBuilder{}.
+func (m *cpMiddleware) flushCache(port sim.Port, invalidate bool) {
+	builder := cache.FlushReqBuilder{}.
 		WithSrc(m.ToCaches.AsRemote()).
-		WithDst(port.AsRemote()).
-		Build()
+		WithDst(port.AsRemote())
+
+	if invalidate {
+		builder = builder.InvalidateAllCacheLines()
+	}
+
+	flushReq := builder.Build()
 
 	err := m.ToCaches.Send(flushReq)
 	if err != nil {
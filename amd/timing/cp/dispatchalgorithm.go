@@ -0,0 +1,49 @@
+package cp
+
+import (
+	"github.com/sarchlab/mgpusim/v4/amd/kernels"
+	"github.com/sarchlab/mgpusim/v4/amd/timing/cp/internal/dispatching"
+	"github.com/sarchlab/mgpusim/v4/amd/timing/cp/internal/resource"
+)
+
+// A DispatchAlgorithm decides which CU each work-group of a kernel is
+// dispatched to. Each dispatcher of the Command Processor has its own
+// algorithm.
+type DispatchAlgorithm = dispatching.Algorithm
+
+// A DispatchAlgorithmFactory creates the dispatching algorithm of a
+// dispatcher from the CU resource pool that all the dispatchers share.
+type DispatchAlgorithmFactory = dispatching.AlgorithmFactory
+
+// A DispatchLocation is where a DispatchAlgorithm dispatches a work-group to.
+type DispatchLocation = dispatching.Location
+
+// A CUResourcePool tracks the resources of the CUs that the Command Processor
+// dispatches work-groups to.
+type CUResourcePool = resource.CUResourcePool
+
+// A CUResource reserves the resources of a CU for work-groups.
+type CUResource = resource.CUResource
+
+// A DispatchableCU is a CU that work-groups can be dispatched to.
+type DispatchableCU = resource.DispatchableCU
+
+// A WfLocation is where a wavefront is placed in a CU.
+type WfLocation = resource.WfLocation
+
+// NewDispatchLocation returns the location of a work-group that reserves the
+// resources of the CU with the given ID in the CU resource pool.
+func NewDispatchLocation(
+	cuID int,
+	cu CUResource,
+	wg *kernels.WorkGroup,
+	wfLocations []WfLocation,
+) DispatchLocation {
+	return dispatching.NewLocation(cuID, cu, wg, wfLocations)
+}
+
+// DispatchAlgorithmNames returns the names of the built-in dispatching
+// algorithms.
+func DispatchAlgorithmNames() []string {
+	return dispatching.AlgorithmNames()
+}
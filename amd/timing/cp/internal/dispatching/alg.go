@@ -7,16 +7,41 @@ import (
 	"github.com/sarchlab/mgpusim/v4/amd/timing/cp/internal/resource"
 )
 
-type dispatchLocation struct {
-	valid     bool
-	cuID      int
-	cu        sim.RemotePort
-	wg        *kernels.WorkGroup
-	locations []protocol.WfDispatchLocation
+// A Location is where a work-group is dispatched to.
+type Location struct {
+	// Valid is false if the work-group cannot be dispatched.
+	Valid     bool
+	CUID      int
+	CU        sim.RemotePort
+	WG        *kernels.WorkGroup
+	Locations []protocol.WfDispatchLocation
 }
 
-// algorithm defines the CTA scheduling scheme.
-type algorithm interface {
+// NewLocation returns the location of a work-group that reserves resources
+// on the CU with the given ID in the CU resource pool.
+func NewLocation(
+	cuID int,
+	cu resource.CUResource,
+	wg *kernels.WorkGroup,
+	wfLocations []resource.WfLocation,
+) Location {
+	location := Location{
+		Valid: true,
+		CU:    cu.DispatchingPort(),
+		CUID:  cuID,
+		WG:    wg,
+	}
+
+	location.Locations = make([]protocol.WfDispatchLocation, len(wfLocations))
+	for i, l := range wfLocations {
+		location.Locations[i] = protocol.WfDispatchLocation(l)
+	}
+
+	return location
+}
+
+// Algorithm defines the CTA scheduling scheme.
+type Algorithm interface {
 	// RegisterCU notifies the algorithm about the existence of the a cu.
 	RegisterCU(cu resource.DispatchableCU)
 
@@ -31,8 +56,12 @@ type algorithm interface {
 
 	// Next returns the information about where the next workgroup can be
 	// dispatched.
-	Next() (location dispatchLocation)
+	Next() (location Location)
 
 	// FreeResources marks the dispatched resources available.
-	FreeResources(location dispatchLocation)
+	FreeResources(location Location)
 }
+
+// An AlgorithmFactory creates the algorithm of a dispatcher. The dispatchers
+// of a Command Processor share the CU resource pool.
+type AlgorithmFactory func(cuPool resource.CUResourcePool) Algorithm
@@ -4,7 +4,6 @@ import (
 	"github.com/sarchlab/akita/v4/monitoring"
 	"github.com/sarchlab/akita/v4/sim"
 	"github.com/sarchlab/akita/v4/tracing"
-	"github.com/sarchlab/mgpusim/v4/amd/kernels"
 	"github.com/sarchlab/mgpusim/v4/amd/protocol"
 	"github.com/sarchlab/mgpusim/v4/amd/timing/cp/internal/resource"
 )
@@ -14,6 +13,9 @@ type Builder struct {
 	cp                           tracing.NamedHookable
 	cuResourcePool               resource.CUResourcePool
 	alg                          string
+	algFactory                   AlgorithmFactory
+	numCUPerCluster              int
+	maxWGPerCU                   int
 	respondingPort               sim.Port
 	dispatchingPort              sim.Port
 	monitor                      *monitoring.Monitor
@@ -21,12 +23,15 @@ type Builder struct {
 	constantKernelLaunchOverhead   int
 	subsequentKernelLaunchOverhead int
 	wgScalingThreshold             int
+	wavefrontSize                  int
 }
 
 // MakeBuilder creates a builder with default dispatching configurations.
 func MakeBuilder() Builder {
 	b := Builder{
 		alg:                           "partition",
+		numCUPerCluster:               4,
+		maxWGPerCU:                    1,
 		constantKernelOverhead:         3600,
 		subsequentKernelLaunchOverhead: 1800,
 		wgScalingThreshold:             128,
@@ -60,15 +65,50 @@ func (b Builder) WithDispatchingPort(p sim.Port) Builder {
 	return b
 }
 
-// WithAlg sets the dispatching algorithm.
+// AlgorithmNames returns the names of the dispatching algorithms that
+// WithAlg accepts.
+func AlgorithmNames() []string {
+	return []string{
+		"round-robin",
+		"greedy",
+		"partition",
+		"clustered",
+		"occupancy-capped",
+		"z-order",
+	}
+}
+
+// WithAlg sets the dispatching algorithm by name. It must be one of the
+// AlgorithmNames.
 func (b Builder) WithAlg(alg string) Builder {
-	switch alg {
-	case "round-robin", "greedy", "partition":
-		b.alg = alg
-	default:
-		panic("unknown dispatching algorithm " + alg)
+	for _, name := range AlgorithmNames() {
+		if name == alg {
+			b.alg = alg
+			return b
+		}
 	}
 
+	panic("unknown dispatching algorithm " + alg)
+}
+
+// WithAlgFactory sets the factory that creates the dispatching algorithm. It
+// replaces the algorithm that WithAlg selects.
+func (b Builder) WithAlgFactory(factory AlgorithmFactory) Builder {
+	b.algFactory = factory
+	return b
+}
+
+// WithNumCUPerCluster sets the number of CUs that share an L1 cache, which the
+// clustered algorithm dispatches the neighboring work-groups to.
+func (b Builder) WithNumCUPerCluster(n int) Builder {
+	b.numCUPerCluster = n
+	return b
+}
+
+// WithMaxWGPerCU sets the number of work-groups that the occupancy-capped
+// algorithm can dispatch to each CU at the same time.
+func (b Builder) WithMaxWGPerCU(n int) Builder {
+	b.maxWGPerCU = n
 	return b
 }
 
@@ -108,6 +148,14 @@ func (b Builder) WithWGScalingThreshold(n int) Builder {
 	return b
 }
 
+// WithWavefrontSize sets the number of work-items in each wavefront that the
+// GPU runs. The dispatcher rejects the kernels compiled for another wavefront
+// size. Zero uses the wavefront size of the kernel.
+func (b Builder) WithWavefrontSize(n int) Builder {
+	b.wavefrontSize = n
+	return b
+}
+
 // Build creates a dispatcher.
 func (b Builder) Build(name string) Dispatcher {
 	d := &DispatcherImpl{
@@ -115,7 +163,7 @@ func (b Builder) Build(name string) Dispatcher {
 		cp:              b.cp,
 		respondingPort:  b.respondingPort,
 		dispatchingPort: b.dispatchingPort,
-		inflightWGs:     make(map[string]dispatchLocation),
+		inflightWGs:     make(map[string]Location),
 		originalReqs:    make(map[string]*protocol.MapWGReq),
 		latencyTable: []int{
 			0,           // 0 WFs
@@ -128,27 +176,34 @@ func (b Builder) Build(name string) Dispatcher {
 		constantKernelLaunchOverhead:   b.constantKernelLaunchOverhead,
 		subsequentKernelLaunchOverhead: b.subsequentKernelLaunchOverhead,
 		wgScalingThreshold:             b.wgScalingThreshold,
+		wavefrontSize:                  b.wavefrontSize,
 		monitor:                        b.monitor,
 	}
 
+	d.alg = b.buildAlg()
+
+	return d
+}
+
+func (b Builder) buildAlg() Algorithm {
+	if b.algFactory != nil {
+		return b.algFactory(b.cuResourcePool)
+	}
+
 	switch b.alg {
 	case "round-robin":
-		d.alg = &roundRobinAlgorithm{
-			gridBuilder: kernels.NewGridBuilder(),
-			cuPool:      b.cuResourcePool,
-		}
+		return NewRoundRobinAlgorithm(b.cuResourcePool)
 	case "greedy":
-		d.alg = &greedyAlgorithm{
-			gridBuilder: kernels.NewGridBuilder(),
-			cuPool:      b.cuResourcePool,
-		}
+		return NewGreedyAlgorithm(b.cuResourcePool)
 	case "partition":
-		d.alg = &partitionAlgorithm{
-			cuPool: b.cuResourcePool,
-		}
+		return NewPartitionAlgorithm(b.cuResourcePool)
+	case "clustered":
+		return NewClusteredAlgorithm(b.cuResourcePool, b.numCUPerCluster)
+	case "occupancy-capped":
+		return NewOccupancyCappedAlgorithm(b.cuResourcePool, b.maxWGPerCU)
+	case "z-order":
+		return NewZOrderAlgorithm(b.cuResourcePool)
 	default:
 		panic("unknown dispatching algorithm " + b.alg)
 	}
-
-	return d
 }
@@ -0,0 +1,163 @@
+package dispatching
+
+import (
+	"github.com/sarchlab/mgpusim/v4/amd/kernels"
+	"github.com/sarchlab/mgpusim/v4/amd/timing/cp/internal/resource"
+)
+
+type cluster struct {
+	gridBuilder  kernels.GridBuilder
+	firstCU      int
+	numCU        int
+	nextCU       int
+	currWG       *kernels.WorkGroup
+	dispatchedWG int
+}
+
+// clusteredAlgorithm splits the work-groups into a contiguous range for each
+// cluster of CUs that share an L1 cache, such as the CUs of a shader array,
+// so that the work-groups with neighboring IDs run on the same cluster. The
+// work-groups of a cluster go to its CUs in turns. A cluster that runs out of
+// work-groups takes the work-groups that the other clusters cannot dispatch.
+type clusteredAlgorithm struct {
+	cuPool          resource.CUResourcePool
+	numCUPerCluster int
+
+	clusters        []*cluster
+	nextCluster     int
+	numWG           int
+	numDispatchedWG int
+	numWGPerCluster int
+}
+
+// NewClusteredAlgorithm creates an algorithm that dispatches the work-groups
+// with neighboring IDs to the same cluster of CUs. The CUs in the resource
+// pool are grouped into clusters of numCUPerCluster CUs in their order of
+// registration.
+func NewClusteredAlgorithm(
+	cuPool resource.CUResourcePool,
+	numCUPerCluster int,
+) Algorithm {
+	if numCUPerCluster <= 0 {
+		panic("the number of CUs per cluster must be positive")
+	}
+
+	return &clusteredAlgorithm{
+		cuPool:          cuPool,
+		numCUPerCluster: numCUPerCluster,
+	}
+}
+
+// RegisterCU allows the clusteredAlgorithm to dispatch work-group to the CU.
+func (a *clusteredAlgorithm) RegisterCU(cu resource.DispatchableCU) {
+	a.cuPool.RegisterCU(cu)
+}
+
+// StartNewKernel lets the algorithms to start dispatching a new kernel.
+func (a *clusteredAlgorithm) StartNewKernel(info kernels.KernelLaunchInfo) {
+	a.numDispatchedWG = 0
+	a.nextCluster = 0
+
+	gb := kernels.NewGridBuilder()
+	gb.SetKernel(info)
+	a.numWG = gb.NumWG()
+
+	numCU := a.cuPool.NumCU()
+	numCluster := (numCU-1)/a.numCUPerCluster + 1
+	a.numWGPerCluster = (a.numWG-1)/numCluster + 1
+
+	a.clusters = nil
+	for i := 0; i < numCluster; i++ {
+		c := &cluster{
+			gridBuilder: kernels.NewGridBuilder(),
+			firstCU:     i * a.numCUPerCluster,
+			numCU:       a.numCUPerCluster,
+		}
+
+		if c.firstCU+c.numCU > numCU {
+			c.numCU = numCU - c.firstCU
+		}
+
+		c.gridBuilder.SetKernel(info)
+		c.gridBuilder.Skip(i * a.numWGPerCluster)
+
+		a.clusters = append(a.clusters, c)
+	}
+}
+
+// NumWG returns the number of work-groups in the currently-dispatching
+// work-group.
+func (a *clusteredAlgorithm) NumWG() int {
+	return a.numWG
+}
+
+// HasNext check if there are more work-groups to dispatch.
+func (a *clusteredAlgorithm) HasNext() bool {
+	return a.numDispatchedWG < a.numWG
+}
+
+// Next finds the location to dispatch the next work-group.
+func (a *clusteredAlgorithm) Next() (location Location) {
+	if !a.HasNext() {
+		return Location{}
+	}
+
+	for index := range a.clusters {
+		i := (index + a.nextCluster) % len(a.clusters)
+		c := a.clusters[i]
+
+		wgToDispatch, wgFromCluster := a.nextWG(i)
+		if wgToDispatch == nil {
+			continue
+		}
+
+		for j := 0; j < c.numCU; j++ {
+			cuID := c.firstCU + (c.nextCU+j)%c.numCU
+			cu := a.cuPool.GetCU(cuID)
+
+			locations, ok := cu.ReserveResourceForWG(wgToDispatch)
+			if !ok {
+				continue
+			}
+
+			c.nextCU = (cuID - c.firstCU + 1) % c.numCU
+
+			a.clusters[wgFromCluster].currWG = nil
+			a.clusters[wgFromCluster].dispatchedWG++
+			a.numDispatchedWG++
+
+			a.nextCluster = i + 1
+
+			return NewLocation(cuID, cu, wgToDispatch, locations)
+		}
+	}
+
+	return Location{}
+}
+
+func (a *clusteredAlgorithm) nextWG(clusterIndex int) (
+	*kernels.WorkGroup, int,
+) {
+	c := a.clusters[clusterIndex]
+
+	if c.currWG == nil && c.dispatchedWG < a.numWGPerCluster {
+		c.currWG = c.gridBuilder.NextWG()
+	}
+
+	if c.currWG != nil {
+		return c.currWG, clusterIndex
+	}
+
+	for i, other := range a.clusters {
+		if other.currWG != nil {
+			return other.currWG, i
+		}
+	}
+
+	return nil, 0
+}
+
+// FreeResources marks the dispatched location to be available.
+func (a *clusteredAlgorithm) FreeResources(location Location) {
+	a.cuPool.GetCU(location.CUID).FreeResourcesForWG(location.WG)
+}
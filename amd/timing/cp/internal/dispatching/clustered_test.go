@@ -0,0 +1,148 @@
+package dispatching
+
+import (
+	"strconv"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/akita/v4/sim"
+	"github.com/sarchlab/mgpusim/v4/amd/kernels"
+	"github.com/sarchlab/mgpusim/v4/amd/timing/cp/internal/resource"
+	"go.uber.org/mock/gomock"
+)
+
+var _ = Describe("Clustered Algorithm", func() {
+	var (
+		ctrl         *gomock.Controller
+		gridBuilder0 *MockGridBuilder
+		gridBuilder1 *MockGridBuilder
+		pool         *MockCUResourcePool
+		cus          []*MockCUResource
+		alg          *clusteredAlgorithm
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		gridBuilder0 = NewMockGridBuilder(ctrl)
+		gridBuilder1 = NewMockGridBuilder(ctrl)
+
+		cus = make([]*MockCUResource, 4)
+		for i := 0; i < 4; i++ {
+			cus[i] = NewMockCUResource(ctrl)
+			cus[i].EXPECT().DispatchingPort().
+				Return(sim.RemotePort("CUPort" + strconv.Itoa(i))).
+				AnyTimes()
+		}
+
+		pool = NewMockCUResourcePool(ctrl)
+		pool.EXPECT().NumCU().Return(len(cus)).AnyTimes()
+		pool.EXPECT().
+			GetCU(gomock.Any()).
+			DoAndReturn(func(i int) resource.CUResource {
+				return cus[i]
+			}).
+			AnyTimes()
+
+		alg = &clusteredAlgorithm{
+			clusters: []*cluster{
+				{
+					gridBuilder: gridBuilder0,
+					firstCU:     0,
+					numCU:       2,
+				},
+				{
+					gridBuilder: gridBuilder1,
+					firstCU:     2,
+					numCU:       2,
+				},
+			},
+			cuPool:          pool,
+			numCUPerCluster: 2,
+			numWG:           16,
+			numWGPerCluster: 8,
+		}
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	It("should check if there are more work-groups to generate", func() {
+		alg.numDispatchedWG = 16
+
+		hasNext := alg.HasNext()
+
+		Expect(hasNext).To(BeFalse())
+	})
+
+	It("should dispatch to the CUs of a cluster in turns", func() {
+		wg := kernels.NewWorkGroup()
+
+		alg.clusters[1].nextCU = 1
+		alg.nextCluster = 1
+		gridBuilder1.EXPECT().NextWG().Return(wg)
+		cus[3].EXPECT().ReserveResourceForWG(wg).
+			Return([]resource.WfLocation{}, true)
+
+		location := alg.Next()
+
+		Expect(location.Valid).To(BeTrue())
+		Expect(location.CUID).To(Equal(3))
+		Expect(alg.clusters[1].nextCU).To(Equal(0))
+		Expect(alg.clusters[1].dispatchedWG).To(Equal(1))
+		Expect(alg.clusters[1].currWG).To(BeNil())
+		Expect(alg.numDispatchedWG).To(Equal(1))
+		Expect(alg.nextCluster).To(Equal(2))
+	})
+
+	It("should try the next CU of the cluster", func() {
+		wg := kernels.NewWorkGroup()
+
+		gridBuilder0.EXPECT().NextWG().Return(wg)
+		cus[0].EXPECT().ReserveResourceForWG(wg).
+			Return([]resource.WfLocation{}, false)
+		cus[1].EXPECT().ReserveResourceForWG(wg).
+			Return([]resource.WfLocation{}, true)
+
+		location := alg.Next()
+
+		Expect(location.Valid).To(BeTrue())
+		Expect(location.CUID).To(Equal(1))
+		Expect(alg.clusters[0].nextCU).To(Equal(0))
+	})
+
+	It("should return invalid location when dispatch is not possible", func() {
+		wg := kernels.NewWorkGroup()
+
+		alg.clusters[1].dispatchedWG = 8
+		gridBuilder0.EXPECT().NextWG().Return(wg)
+		for i := 0; i < 4; i++ {
+			cus[i].EXPECT().ReserveResourceForWG(wg).
+				Return([]resource.WfLocation{}, false)
+		}
+
+		location := alg.Next()
+
+		Expect(location.Valid).To(BeFalse())
+		Expect(alg.clusters[0].currWG).To(BeIdenticalTo(wg))
+		Expect(alg.numDispatchedWG).To(Equal(0))
+	})
+
+	It("should take the work-groups of other clusters", func() {
+		wg := kernels.NewWorkGroup()
+
+		alg.nextCluster = 1
+		alg.clusters[1].dispatchedWG = 8
+		alg.clusters[0].currWG = wg
+		cus[2].EXPECT().ReserveResourceForWG(wg).
+			Return([]resource.WfLocation{}, true)
+
+		location := alg.Next()
+
+		Expect(location.Valid).To(BeTrue())
+		Expect(location.CUID).To(Equal(2))
+		Expect(alg.clusters[0].dispatchedWG).To(Equal(1))
+		Expect(alg.clusters[0].currWG).To(BeNil())
+		Expect(alg.numDispatchedWG).To(Equal(1))
+	})
+})
@@ -31,14 +31,16 @@ type DispatcherImpl struct {
 	name                   string
 	respondingPort         sim.Port
 	dispatchingPort        sim.Port
-	alg                    algorithm
+	alg                    Algorithm
 	dispatching            *protocol.LaunchKernelReq
-	currWG                 dispatchLocation
+	currWG                 Location
 	cycleLeft              int
 	numDispatchedWGs       int
 	numCompletedWGs        int
-	inflightWGs            map[string]dispatchLocation
+	inflightWGs            map[string]Location
 	originalReqs           map[string]*protocol.MapWGReq
+	scratch                *scratchSlots
+	fault                  *protocol.MemoryFault
 	latencyTable                 []int
 	constantKernelOverhead              int
 	constantKernelLaunchOverhead        int
@@ -46,6 +48,7 @@ type DispatcherImpl struct {
 	firstKernelLaunched                 bool
 	prevKernelWGCount                   int
 	wgScalingThreshold                  int
+	wavefrontSize                       int
 
 	monitor     *monitoring.Monitor
 	progressBar *monitoring.ProgressBar
@@ -71,12 +74,16 @@ func (d *DispatcherImpl) StartDispatching(req *protocol.LaunchKernelReq) {
 	d.mustNotBeDispatchingAnotherKernel()
 
 	d.alg.StartNewKernel(kernels.KernelLaunchInfo{
-		CodeObject: req.CodeObject,
-		Packet:     req.Packet,
-		PacketAddr: req.PacketAddress,
-		WGFilter:   req.WGFilter,
+		CodeObject:    req.CodeObject,
+		Packet:        req.Packet,
+		PacketAddr:    req.PacketAddress,
+		WGFilter:      req.WGFilter,
+		ScratchAddr:   req.ScratchAddress,
+		ScratchSize:   req.ScratchByteSize,
+		WavefrontSize: d.wavefrontSize,
 	})
 	d.dispatching = req
+	d.scratch = newScratchSlots(req)
 
 	d.numDispatchedWGs = 0
 	d.numCompletedWGs = 0
@@ -120,7 +127,7 @@ func (d *DispatcherImpl) Tick() (madeProgress bool) {
 	if d.dispatching != nil {
 		if d.kernelCompleted() {
 			madeProgress = d.completeKernel() || madeProgress
-		} else {
+		} else if d.fault == nil {
 			// Dispatch up to 8 WGs per cycle
 			for i := 0; i < 8; i++ {
 				progress := d.dispatchNextWG()
@@ -164,7 +171,7 @@ func (d *DispatcherImpl) processMessagesFromCU() bool {
 				if ok {
 					count += 1
 					///sampling
-					d.collectSamplingData(location.locations)
+					d.collectSamplingData(location.Locations)
 				}
 			}
 
@@ -174,9 +181,14 @@ func (d *DispatcherImpl) processMessagesFromCU() bool {
 				log.Panic("In emulation all finished WGs from more than one dispatcher")
 			}
 
+			if msg.Fault != nil {
+				d.abortKernel(msg.Fault)
+			}
+
 			for _, rspToID := range msg.RspTo {
 				location := d.inflightWGs[rspToID]
 				d.alg.FreeResources(location)
+				d.scratch.release(location.WG)
 				delete(d.inflightWGs, rspToID)
 				d.numCompletedWGs++
 				if d.numCompletedWGs == d.alg.NumWG() {
@@ -203,12 +215,26 @@ func (d *DispatcherImpl) processMessagesFromCU() bool {
 	return madeProgress
 }
 
+// abortKernel stops dispatching the work-groups of a kernel that causes a
+// memory fault. The kernel completes when the in-flight work-groups complete.
+func (d *DispatcherImpl) abortKernel(fault *protocol.MemoryFault) {
+	if d.fault == nil {
+		d.fault = fault
+	}
+
+	if d.currWG.Valid {
+		d.alg.FreeResources(d.currWG)
+		d.scratch.release(d.currWG.WG)
+		d.currWG.Valid = false
+	}
+}
+
 func (d *DispatcherImpl) kernelCompleted() bool {
-	if d.currWG.valid {
+	if d.currWG.Valid {
 		return false
 	}
 
-	if d.alg.HasNext() {
+	if d.fault == nil && d.alg.HasNext() {
 		return false
 	}
 
@@ -225,11 +251,13 @@ func (d *DispatcherImpl) completeKernel() (
 	req := d.dispatching
 
 	rsp := protocol.NewLaunchKernelRsp(req.Dst, req.Src, req.ID)
+	rsp.Fault = d.fault
 
 	err := d.respondingPort.Send(rsp)
 	if err == nil {
 		d.prevKernelWGCount = d.numDispatchedWGs
 		d.dispatching = nil
+		d.fault = nil
 
 		if d.monitor != nil {
 			d.monitor.CompleteProgressBar(d.progressBar)
@@ -244,35 +272,36 @@ func (d *DispatcherImpl) completeKernel() (
 }
 
 func (d *DispatcherImpl) dispatchNextWG() (madeProgress bool) {
-	if !d.currWG.valid {
-		if !d.alg.HasNext() {
+	if !d.currWG.Valid {
+		if !d.alg.HasNext() || !d.scratch.canHoldWG() {
 			return false
 		}
 		d.currWG = d.alg.Next()
-		if !d.currWG.valid {
+		if !d.currWG.Valid {
 			return false
 		}
+		d.scratch.place(d.currWG.WG)
 	}
 
 	reqBuilder := protocol.MapWGReqBuilder{}.
 		WithSrc(d.dispatchingPort.AsRemote()).
-		WithDst(d.currWG.cu).
+		WithDst(d.currWG.CU).
 		WithPID(d.dispatching.PID).
-		WithWG(d.currWG.wg)
-	for _, l := range d.currWG.locations {
+		WithWG(d.currWG.WG)
+	for _, l := range d.currWG.Locations {
 		reqBuilder = reqBuilder.AddWf(l)
 	}
 	req := reqBuilder.Build()
 	err := d.dispatchingPort.Send(req)
 
-	// fmt.Printf("%.10f, %d, %d\n", now, d.currWG.wg.IDX, d.currWG.cuID)
+	// fmt.Printf("%.10f, %d, %d\n", now, d.currWG.WG.IDX, d.currWG.CUID)
 
 	if err == nil {
-		d.currWG.valid = false
+		d.currWG.Valid = false
 		d.numDispatchedWGs++
 		d.inflightWGs[req.ID] = d.currWG
 		d.originalReqs[req.ID] = req
-		d.cycleLeft = d.latencyTable[len(d.currWG.locations)]
+		d.cycleLeft = d.latencyTable[len(d.currWG.Locations)]
 
 		if d.progressBar != nil {
 			d.progressBar.IncrementInProgress(1)
@@ -92,13 +92,13 @@ var _ = Describe("Dispatcher", func() {
 		dispatcher.dispatching = req
 
 		alg.EXPECT().HasNext().Return(true).AnyTimes()
-		firstCall := alg.EXPECT().Next().Return(dispatchLocation{
-			valid:     true,
-			cu:        nilPort.AsRemote(),
-			locations: make([]protocol.WfDispatchLocation, 1),
+		firstCall := alg.EXPECT().Next().Return(Location{
+			Valid:     true,
+			CU:        nilPort.AsRemote(),
+			Locations: make([]protocol.WfDispatchLocation, 1),
 		})
-		alg.EXPECT().Next().Return(dispatchLocation{
-			valid: false,
+		alg.EXPECT().Next().Return(Location{
+			Valid: false,
 		}).After(firstCall).AnyTimes()
 		dispatchingPort.EXPECT().PeekIncoming().Return(nil).AnyTimes()
 		dispatchingPort.EXPECT().Send(gomock.Any()).Return(nil)
@@ -106,11 +106,58 @@ var _ = Describe("Dispatcher", func() {
 		madeProgress := dispatcher.Tick()
 
 		Expect(madeProgress).To(BeTrue())
-		Expect(dispatcher.currWG.valid).To(BeFalse())
+		Expect(dispatcher.currWG.Valid).To(BeFalse())
 		Expect(dispatcher.numDispatchedWGs).To(Equal(1))
 		Expect(dispatcher.inflightWGs).To(HaveLen(1))
 	})
 
+	It("should give each wavefront a free scratch slot", func() {
+		nilPort := NewMockPort(ctrl)
+		nilPort.EXPECT().AsRemote().AnyTimes()
+
+		req := protocol.NewLaunchKernelReq(nilPort, respondingPort)
+		req.Packet = &kernels.HsaKernelDispatchPacket{
+			WorkgroupSizeX:     64,
+			WorkgroupSizeY:     1,
+			WorkgroupSizeZ:     1,
+			PrivateSegmentSize: 16,
+		}
+		req.ScratchByteSize = 2 * 1024
+		dispatcher.dispatching = req
+		dispatcher.scratch = newScratchSlots(req)
+
+		wgs := []*kernels.WorkGroup{
+			{Wavefronts: []*kernels.Wavefront{kernels.NewWavefront()}},
+			{Wavefronts: []*kernels.Wavefront{kernels.NewWavefront()}},
+		}
+		alg.EXPECT().HasNext().Return(true).AnyTimes()
+		for _, wg := range wgs {
+			alg.EXPECT().Next().Return(Location{
+				Valid:     true,
+				CU:        nilPort.AsRemote(),
+				WG:        wg,
+				Locations: make([]protocol.WfDispatchLocation, 1),
+			})
+		}
+		dispatchingPort.EXPECT().PeekIncoming().Return(nil).AnyTimes()
+		dispatchingPort.EXPECT().Send(gomock.Any()).Return(nil).Times(2)
+
+		dispatcher.Tick()
+		dispatcher.cycleLeft = 0
+		dispatcher.Tick()
+		dispatcher.cycleLeft = 0
+		dispatcher.Tick()
+
+		Expect(wgs[0].Wavefronts[0].ScratchWaveOffset).To(Equal(uint64(0)))
+		Expect(wgs[1].Wavefronts[0].ScratchWaveOffset).To(Equal(uint64(1024)))
+		Expect(dispatcher.numDispatchedWGs).To(Equal(2))
+		Expect(dispatcher.scratch.canHoldWG()).To(BeFalse())
+
+		dispatcher.scratch.release(wgs[0])
+
+		Expect(dispatcher.scratch.canHoldWG()).To(BeTrue())
+	})
+
 	It("should wait until cycle left becomes 0", func() {
 		nilPort := NewMockPort(ctrl)
 		nilPort.EXPECT().AsRemote().AnyTimes()
@@ -134,15 +181,15 @@ var _ = Describe("Dispatcher", func() {
 
 		dispatchingPort.EXPECT().PeekIncoming().Return(nil)
 		alg.EXPECT().HasNext().Return(true).AnyTimes()
-		alg.EXPECT().Next().Return(dispatchLocation{
-			valid: false,
-			cu:    nilPort.AsRemote(),
+		alg.EXPECT().Next().Return(Location{
+			Valid: false,
+			CU:    nilPort.AsRemote(),
 		})
 
 		madeProgress := dispatcher.Tick()
 
 		Expect(madeProgress).To(BeFalse())
-		Expect(dispatcher.currWG.valid).To(BeFalse())
+		Expect(dispatcher.currWG.Valid).To(BeFalse())
 		Expect(dispatcher.numDispatchedWGs).To(Equal(0))
 	})
 
@@ -155,9 +202,9 @@ var _ = Describe("Dispatcher", func() {
 
 		dispatchingPort.EXPECT().PeekIncoming().Return(nil)
 		alg.EXPECT().HasNext().Return(true).AnyTimes()
-		alg.EXPECT().Next().Return(dispatchLocation{
-			valid: true,
-			cu:    nilPort.AsRemote(),
+		alg.EXPECT().Next().Return(Location{
+			Valid: true,
+			CU:    nilPort.AsRemote(),
 		})
 		dispatchingPort.EXPECT().
 			Send(gomock.Any()).
@@ -166,7 +213,7 @@ var _ = Describe("Dispatcher", func() {
 		madeProgress := dispatcher.Tick()
 
 		Expect(madeProgress).To(BeFalse())
-		Expect(dispatcher.currWG.valid).To(BeTrue())
+		Expect(dispatcher.currWG.Valid).To(BeTrue())
 		Expect(dispatcher.numDispatchedWGs).To(Equal(0))
 	})
 
@@ -196,7 +243,7 @@ var _ = Describe("Dispatcher", func() {
 		dispatcher.dispatching = req
 
 		mapWGReq := protocol.MapWGReqBuilder{}.Build()
-		location := dispatchLocation{}
+		location := Location{}
 		dispatcher.inflightWGs[mapWGReq.ID] = location
 		dispatcher.originalReqs[mapWGReq.ID] = mapWGReq
 
@@ -235,7 +282,7 @@ var _ = Describe("Dispatcher", func() {
 		dispatcher.dispatching = req
 
 		mapWGReq := protocol.MapWGReqBuilder{}.Build()
-		location := dispatchLocation{}
+		location := Location{}
 		dispatcher.inflightWGs[mapWGReq.ID] = location
 		dispatcher.originalReqs[mapWGReq.ID] = mapWGReq
 
@@ -267,6 +314,57 @@ var _ = Describe("Dispatcher", func() {
 			To(Equal(dispatcher.constantKernelOverhead))
 	})
 
+	It("should stop dispatching after a memory fault", func() {
+		nilPort := NewMockPort(ctrl)
+		nilPort.EXPECT().AsRemote().AnyTimes()
+
+		req := protocol.NewLaunchKernelReq(nilPort, respondingPort)
+		dispatcher.dispatching = req
+
+		mapWGReq := protocol.MapWGReqBuilder{}.Build()
+		location := Location{}
+		dispatcher.inflightWGs[mapWGReq.ID] = location
+		dispatcher.originalReqs[mapWGReq.ID] = mapWGReq
+		currWG := Location{Valid: true}
+		dispatcher.currWG = currWG
+
+		fault := &protocol.MemoryFault{Address: 0x1000}
+		wgCompletionMsg := protocol.WGCompletionMsgBuilder{}.
+			WithRspTo([]string{mapWGReq.ID}).
+			WithFault(fault).
+			Build()
+
+		dispatcher.numDispatchedWGs = 2
+		dispatcher.numCompletedWGs = 0
+
+		alg.EXPECT().HasNext().Return(true).AnyTimes()
+		alg.EXPECT().NumWG().Return(64)
+		alg.EXPECT().FreeResources(location)
+		alg.EXPECT().FreeResources(currWG)
+		dispatchingPort.EXPECT().
+			Send(gomock.Any()).
+			Return(sim.NewSendError())
+
+		firstPeek := dispatchingPort.EXPECT().
+			PeekIncoming().
+			Return(wgCompletionMsg)
+		dispatchingPort.EXPECT().
+			PeekIncoming().
+			Return(nil).
+			After(firstPeek).
+			AnyTimes()
+		dispatchingPort.EXPECT().
+			RetrieveIncoming()
+
+		dispatcher.Tick()
+		madeProgress := dispatcher.Tick()
+
+		Expect(madeProgress).To(BeFalse())
+		Expect(dispatcher.fault).To(BeIdenticalTo(fault))
+		Expect(dispatcher.currWG.Valid).To(BeFalse())
+		Expect(dispatcher.numDispatchedWGs).To(Equal(2))
+	})
+
 	It(`should ignore response if the request is not sent by the 
 	dispatcher`, func() {
 		nilPort := NewMockPort(ctrl)
@@ -316,6 +414,35 @@ var _ = Describe("Dispatcher", func() {
 		Expect(dispatcher.dispatching).To(BeNil())
 	})
 
+	It("should send the memory fault of an aborted kernel", func() {
+		nilPort := NewMockPort(ctrl)
+		nilPort.EXPECT().AsRemote().AnyTimes()
+
+		req := protocol.NewLaunchKernelReq(nilPort, respondingPort)
+		dispatcher.dispatching = req
+
+		fault := &protocol.MemoryFault{Address: 0x1000}
+		dispatcher.fault = fault
+		dispatcher.numDispatchedWGs = 2
+		dispatcher.numCompletedWGs = 2
+
+		alg.EXPECT().HasNext().Return(true).AnyTimes()
+		dispatchingPort.EXPECT().PeekIncoming().Return(nil)
+		respondingPort.EXPECT().
+			Send(gomock.Any()).
+			Do(func(msg sim.Msg) {
+				rsp := msg.(*protocol.LaunchKernelRsp)
+				Expect(rsp.Fault).To(BeIdenticalTo(fault))
+			}).
+			Return(nil)
+
+		madeProgress := dispatcher.Tick()
+
+		Expect(madeProgress).To(BeTrue())
+		Expect(dispatcher.dispatching).To(BeNil())
+		Expect(dispatcher.fault).To(BeNil())
+	})
+
 	It("should wait if response is failed to send", func() {
 		nilPort := NewMockPort(ctrl)
 		nilPort.EXPECT().AsRemote().AnyTimes()
@@ -11,7 +11,7 @@ import (
 //go:generate mockgen -destination "mock_resource_test.go" -package $GOPACKAGE -write_package_comment=false github.com/sarchlab/mgpusim/v4/amd/timing/cp/internal/resource CUResourcePool,CUResource
 //go:generate mockgen -destination "mock_sim_test.go" -package $GOPACKAGE -write_package_comment=false github.com/sarchlab/akita/v4/sim Port
 //go:generate mockgen -destination "mock_tracing_test.go" -package $GOPACKAGE -write_package_comment=false github.com/sarchlab/akita/v4/tracing NamedHookable
-//go:generate mockgen -source alg.go -destination mock_alg.go -package $GOPACKAGE -mock_names=algorithm=MockAlgorithm
+//go:generate mockgen -source alg.go -destination mock_alg.go -package $GOPACKAGE -mock_names=Algorithm=MockAlgorithm
 
 func TestDispatching(t *testing.T) {
 	RegisterFailHandler(Fail)
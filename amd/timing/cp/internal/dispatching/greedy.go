@@ -2,7 +2,6 @@ package dispatching
 
 import (
 	"github.com/sarchlab/mgpusim/v4/amd/kernels"
-	"github.com/sarchlab/mgpusim/v4/amd/protocol"
 	"github.com/sarchlab/mgpusim/v4/amd/timing/cp/internal/resource"
 )
 
@@ -15,6 +14,15 @@ type greedyAlgorithm struct {
 	numDispatchedWGs int
 }
 
+// NewGreedyAlgorithm creates an algorithm that fills a CU before moving to
+// the next CU.
+func NewGreedyAlgorithm(cuPool resource.CUResourcePool) Algorithm {
+	return &greedyAlgorithm{
+		gridBuilder: kernels.NewGridBuilder(),
+		cuPool:      cuPool,
+	}
+}
+
 // RegisterCU allows the greedyAlgorithm to dispatch work-group to the CU.
 func (a *greedyAlgorithm) RegisterCU(cu resource.DispatchableCU) {
 	a.cuPool.RegisterCU(cu)
@@ -38,7 +46,7 @@ func (a *greedyAlgorithm) HasNext() bool {
 }
 
 // Next finds the location to dispatch the next work-group.
-func (a *greedyAlgorithm) Next() (location dispatchLocation) {
+func (a *greedyAlgorithm) Next() (location Location) {
 	if a.currWG == nil {
 		a.currWG = a.gridBuilder.NextWG()
 	}
@@ -49,17 +57,7 @@ func (a *greedyAlgorithm) Next() (location dispatchLocation) {
 
 		locations, ok := cu.ReserveResourceForWG(a.currWG)
 		if ok {
-			dispatch := dispatchLocation{
-				valid: true,
-				cu:    cu.DispatchingPort(),
-				cuID:  cuID,
-				wg:    a.currWG,
-			}
-			dispatch.locations =
-				make([]protocol.WfDispatchLocation, len(locations))
-			for i, localtion := range locations {
-				dispatch.locations[i] = protocol.WfDispatchLocation(localtion)
-			}
+			dispatch := NewLocation(cuID, cu, a.currWG, locations)
 
 			a.currWG = nil
 			a.numDispatchedWGs++
@@ -68,10 +66,10 @@ func (a *greedyAlgorithm) Next() (location dispatchLocation) {
 		}
 	}
 
-	return dispatchLocation{}
+	return Location{}
 }
 
 // FreeResources marks the dispatched location to be available.
-func (a *greedyAlgorithm) FreeResources(location dispatchLocation) {
-	a.cuPool.GetCU(location.cuID).FreeResourcesForWG(location.wg)
+func (a *greedyAlgorithm) FreeResources(location Location) {
+	a.cuPool.GetCU(location.CUID).FreeResourcesForWG(location.WG)
 }
@@ -80,7 +80,7 @@ var _ = Describe("Greedy Algorithm", func() {
 
 		location := alg.Next()
 
-		Expect(location.valid).To(BeTrue())
+		Expect(location.Valid).To(BeTrue())
 		Expect(alg.numDispatchedWGs).To(Equal(1))
 	})
 
@@ -96,7 +96,7 @@ var _ = Describe("Greedy Algorithm", func() {
 
 		location := alg.Next()
 
-		Expect(location.valid).To(BeFalse())
+		Expect(location.Valid).To(BeFalse())
 		Expect(alg.numDispatchedWGs).To(Equal(0))
 	})
 })
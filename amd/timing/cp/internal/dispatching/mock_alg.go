@@ -3,7 +3,7 @@
 //
 // Generated by this command:
 //
-//	mockgen -source alg.go -destination mock_alg.go -package dispatching -mock_names=algorithm=MockAlgorithm
+//	mockgen -source alg.go -destination mock_alg.go -package dispatching -mock_names=Algorithm=MockAlgorithm
 //
 
 // Package dispatching is a generated GoMock package.
@@ -17,7 +17,7 @@ import (
 	gomock "go.uber.org/mock/gomock"
 )
 
-// MockAlgorithm is a mock of algorithm interface.
+// MockAlgorithm is a mock of Algorithm interface.
 type MockAlgorithm struct {
 	ctrl     *gomock.Controller
 	recorder *MockAlgorithmMockRecorder
@@ -42,7 +42,7 @@ func (m *MockAlgorithm) EXPECT() *MockAlgorithmMockRecorder {
 }
 
 // FreeResources mocks base method.
-func (m *MockAlgorithm) FreeResources(location dispatchLocation) {
+func (m *MockAlgorithm) FreeResources(location Location) {
 	m.ctrl.T.Helper()
 	m.ctrl.Call(m, "FreeResources", location)
 }
@@ -68,10 +68,10 @@ func (mr *MockAlgorithmMockRecorder) HasNext() *gomock.Call {
 }
 
 // Next mocks base method.
-func (m *MockAlgorithm) Next() dispatchLocation {
+func (m *MockAlgorithm) Next() Location {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Next")
-	ret0, _ := ret[0].(dispatchLocation)
+	ret0, _ := ret[0].(Location)
 	return ret0
 }
 
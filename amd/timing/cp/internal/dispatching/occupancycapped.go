@@ -0,0 +1,101 @@
+package dispatching
+
+import (
+	"github.com/sarchlab/mgpusim/v4/amd/kernels"
+	"github.com/sarchlab/mgpusim/v4/amd/timing/cp/internal/resource"
+)
+
+// occupancyCappedAlgorithm dispatches the work-groups to the CUs in turns,
+// but keeps at most maxWGPerCU work-groups of the kernel on each CU at the
+// same time, even if the CU has the resources for more.
+type occupancyCappedAlgorithm struct {
+	gridBuilder kernels.GridBuilder
+	cuPool      resource.CUResourcePool
+	maxWGPerCU  int
+
+	currWG           *kernels.WorkGroup
+	nextCU           int
+	numDispatchedWGs int
+	numWGOnCU        map[int]int
+}
+
+// NewOccupancyCappedAlgorithm creates an algorithm that dispatches the
+// work-groups to the CUs in turns, with at most maxWGPerCU work-groups on
+// each CU.
+func NewOccupancyCappedAlgorithm(
+	cuPool resource.CUResourcePool,
+	maxWGPerCU int,
+) Algorithm {
+	if maxWGPerCU <= 0 {
+		panic("the maximum number of work-groups per CU must be positive")
+	}
+
+	return &occupancyCappedAlgorithm{
+		gridBuilder: kernels.NewGridBuilder(),
+		cuPool:      cuPool,
+		maxWGPerCU:  maxWGPerCU,
+		numWGOnCU:   make(map[int]int),
+	}
+}
+
+// RegisterCU allows the occupancyCappedAlgorithm to dispatch work-group to
+// the CU.
+func (a *occupancyCappedAlgorithm) RegisterCU(cu resource.DispatchableCU) {
+	a.cuPool.RegisterCU(cu)
+}
+
+// StartNewKernel lets the algorithms to start dispatching a new kernel.
+func (a *occupancyCappedAlgorithm) StartNewKernel(
+	info kernels.KernelLaunchInfo,
+) {
+	a.numDispatchedWGs = 0
+	a.gridBuilder.SetKernel(info)
+}
+
+// NumWG returns the number of work-groups in the currently-dispatching
+// work-group.
+func (a *occupancyCappedAlgorithm) NumWG() int {
+	return a.gridBuilder.NumWG()
+}
+
+// HasNext check if there are more work-groups to dispatch.
+func (a *occupancyCappedAlgorithm) HasNext() bool {
+	return a.numDispatchedWGs < a.gridBuilder.NumWG()
+}
+
+// Next finds the location to dispatch the next work-group.
+func (a *occupancyCappedAlgorithm) Next() (location Location) {
+	if a.currWG == nil {
+		a.currWG = a.gridBuilder.NextWG()
+	}
+
+	for i := 0; i < a.cuPool.NumCU(); i++ {
+		cuID := (a.nextCU + i) % a.cuPool.NumCU()
+		if a.numWGOnCU[cuID] >= a.maxWGPerCU {
+			continue
+		}
+
+		cu := a.cuPool.GetCU(cuID)
+
+		locations, ok := cu.ReserveResourceForWG(a.currWG)
+		if ok {
+			a.nextCU = (cuID + 1) % a.cuPool.NumCU()
+
+			dispatch := NewLocation(cuID, cu, a.currWG, locations)
+
+			a.numWGOnCU[cuID]++
+			a.currWG = nil
+			a.numDispatchedWGs++
+
+			return dispatch
+		}
+	}
+
+	return Location{}
+}
+
+// FreeResources marks the dispatched location to be available.
+func (a *occupancyCappedAlgorithm) FreeResources(location Location) {
+	a.cuPool.GetCU(location.CUID).FreeResourcesForWG(location.WG)
+	a.numWGOnCU[location.CUID]--
+}
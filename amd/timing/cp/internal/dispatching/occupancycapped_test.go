@@ -0,0 +1,109 @@
+package dispatching
+
+import (
+	"strconv"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/akita/v4/sim"
+	"github.com/sarchlab/mgpusim/v4/amd/kernels"
+	"github.com/sarchlab/mgpusim/v4/amd/timing/cp/internal/resource"
+	"go.uber.org/mock/gomock"
+)
+
+var _ = Describe("Occupancy Capped Algorithm", func() {
+	var (
+		ctrl        *gomock.Controller
+		gridBuilder *MockGridBuilder
+		pool        *MockCUResourcePool
+		cus         []*MockCUResource
+		alg         *occupancyCappedAlgorithm
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		gridBuilder = NewMockGridBuilder(ctrl)
+
+		cus = make([]*MockCUResource, 2)
+		for i := 0; i < 2; i++ {
+			cus[i] = NewMockCUResource(ctrl)
+			cus[i].EXPECT().DispatchingPort().
+				Return(sim.RemotePort("CUPort" + strconv.Itoa(i))).
+				AnyTimes()
+		}
+
+		pool = NewMockCUResourcePool(ctrl)
+		pool.EXPECT().NumCU().Return(len(cus)).AnyTimes()
+		pool.EXPECT().
+			GetCU(gomock.Any()).
+			DoAndReturn(func(i int) resource.CUResource {
+				return cus[i]
+			}).
+			AnyTimes()
+
+		alg = &occupancyCappedAlgorithm{
+			gridBuilder: gridBuilder,
+			cuPool:      pool,
+			maxWGPerCU:  2,
+			numWGOnCU:   make(map[int]int),
+		}
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	It("should dispatch next wg", func() {
+		wg := kernels.NewWorkGroup()
+
+		gridBuilder.EXPECT().NextWG().Return(wg)
+		cus[0].EXPECT().ReserveResourceForWG(wg).
+			Return([]resource.WfLocation{}, true)
+
+		location := alg.Next()
+
+		Expect(location.Valid).To(BeTrue())
+		Expect(location.CUID).To(Equal(0))
+		Expect(alg.numWGOnCU[0]).To(Equal(1))
+		Expect(alg.numDispatchedWGs).To(Equal(1))
+	})
+
+	It("should skip the CUs that reach the cap", func() {
+		wg := kernels.NewWorkGroup()
+
+		alg.numWGOnCU[0] = 2
+		gridBuilder.EXPECT().NextWG().Return(wg)
+		cus[1].EXPECT().ReserveResourceForWG(wg).
+			Return([]resource.WfLocation{}, true)
+
+		location := alg.Next()
+
+		Expect(location.Valid).To(BeTrue())
+		Expect(location.CUID).To(Equal(1))
+		Expect(alg.numWGOnCU[1]).To(Equal(1))
+	})
+
+	It("should return invalid location when all CUs reach the cap", func() {
+		wg := kernels.NewWorkGroup()
+
+		alg.numWGOnCU[0] = 2
+		alg.numWGOnCU[1] = 2
+		gridBuilder.EXPECT().NextWG().Return(wg)
+
+		location := alg.Next()
+
+		Expect(location.Valid).To(BeFalse())
+		Expect(alg.numDispatchedWGs).To(Equal(0))
+	})
+
+	It("should free the work-group slot of the CU", func() {
+		wg := kernels.NewWorkGroup()
+
+		alg.numWGOnCU[1] = 2
+		cus[1].EXPECT().FreeResourcesForWG(wg)
+
+		alg.FreeResources(Location{Valid: true, CUID: 1, WG: wg})
+
+		Expect(alg.numWGOnCU[1]).To(Equal(1))
+	})
+})
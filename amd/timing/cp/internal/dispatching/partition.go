@@ -2,7 +2,6 @@ package dispatching
 
 import (
 	"github.com/sarchlab/mgpusim/v4/amd/kernels"
-	"github.com/sarchlab/mgpusim/v4/amd/protocol"
 	"github.com/sarchlab/mgpusim/v4/amd/timing/cp/internal/resource"
 )
 
@@ -26,6 +25,14 @@ type partitionAlgorithm struct {
 	initialized bool
 }
 
+// NewPartitionAlgorithm creates an algorithm that splits the work-groups into
+// a contiguous range for each CU.
+func NewPartitionAlgorithm(cuPool resource.CUResourcePool) Algorithm {
+	return &partitionAlgorithm{
+		cuPool: cuPool,
+	}
+}
+
 // RegisterCU allows the partitionAlgorithm to dispatch work-group to the CU.
 func (a *partitionAlgorithm) RegisterCU(cu resource.DispatchableCU) {
 	a.cuPool.RegisterCU(cu)
@@ -68,9 +75,9 @@ func (a *partitionAlgorithm) HasNext() bool {
 }
 
 // Next finds the location to dispatch the next work-group.
-func (a *partitionAlgorithm) Next() (location dispatchLocation) {
+func (a *partitionAlgorithm) Next() (location Location) {
 	if a.allWGDispatched() {
-		return dispatchLocation{}
+		return Location{}
 	}
 
 	for index := range a.partitions {
@@ -84,18 +91,7 @@ func (a *partitionAlgorithm) Next() (location dispatchLocation) {
 		cu := a.cuPool.GetCU(i)
 		locations, ok := cu.ReserveResourceForWG(wgToDispatch)
 		if ok {
-			dispatch := dispatchLocation{
-				valid: true,
-				cu:    cu.DispatchingPort(),
-				cuID:  i,
-				wg:    wgToDispatch,
-			}
-
-			dispatch.locations =
-				make([]protocol.WfDispatchLocation, len(locations))
-			for i, localtion := range locations {
-				dispatch.locations[i] = protocol.WfDispatchLocation(localtion)
-			}
+			dispatch := NewLocation(i, cu, wgToDispatch, locations)
 
 			a.currWGs[wgFromPartition] = nil
 			a.partitions[wgFromPartition].dispatchedWG++
@@ -107,7 +103,7 @@ func (a *partitionAlgorithm) Next() (location dispatchLocation) {
 		}
 	}
 
-	return dispatchLocation{}
+	return Location{}
 }
 
 func (a *partitionAlgorithm) nextWG(partitionIndex int) (
@@ -147,6 +143,6 @@ func (a *partitionAlgorithm) noWGInPartition(partitionIndex int) bool {
 }
 
 // FreeResources marks the dispatched location to be available.
-func (a *partitionAlgorithm) FreeResources(location dispatchLocation) {
-	a.cuPool.GetCU(location.cuID).FreeResourcesForWG(location.wg)
+func (a *partitionAlgorithm) FreeResources(location Location) {
+	a.cuPool.GetCU(location.CUID).FreeResourcesForWG(location.WG)
 }
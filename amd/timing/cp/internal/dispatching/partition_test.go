@@ -81,7 +81,7 @@ var _ = Describe("Partition Algorithm", func() {
 
 		location := alg.Next()
 
-		Expect(location.valid).To(BeTrue())
+		Expect(location.Valid).To(BeTrue())
 		Expect(alg.partitions[0].dispatchedWG).To(Equal(1))
 		Expect(alg.currWGs[0]).To(BeNil())
 		Expect(alg.numDispatchedWG).To(Equal(1))
@@ -100,7 +100,7 @@ var _ = Describe("Partition Algorithm", func() {
 
 		location := alg.Next()
 
-		Expect(location.valid).To(BeFalse())
+		Expect(location.Valid).To(BeFalse())
 		Expect(alg.partitions[0].dispatchedWG).To(Equal(0))
 		Expect(alg.numDispatchedWG).To(Equal(0))
 	})
@@ -117,8 +117,8 @@ var _ = Describe("Partition Algorithm", func() {
 
 		location := alg.Next()
 
-		Expect(location.valid).To(BeTrue())
-		Expect(location.cuID).To(Equal(1))
+		Expect(location.Valid).To(BeTrue())
+		Expect(location.CUID).To(Equal(1))
 		Expect(alg.partitions[0].dispatchedWG).To(Equal(1))
 		Expect(alg.currWGs[0]).To(BeNil())
 		Expect(alg.numDispatchedWG).To(Equal(1))
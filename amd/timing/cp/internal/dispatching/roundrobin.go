@@ -2,7 +2,6 @@ package dispatching
 
 import (
 	"github.com/sarchlab/mgpusim/v4/amd/kernels"
-	"github.com/sarchlab/mgpusim/v4/amd/protocol"
 	"github.com/sarchlab/mgpusim/v4/amd/timing/cp/internal/resource"
 )
 
@@ -17,6 +16,15 @@ type roundRobinAlgorithm struct {
 	numDispatchedWGs int
 }
 
+// NewRoundRobinAlgorithm creates an algorithm that dispatches the
+// work-groups to the CUs in turns.
+func NewRoundRobinAlgorithm(cuPool resource.CUResourcePool) Algorithm {
+	return &roundRobinAlgorithm{
+		gridBuilder: kernels.NewGridBuilder(),
+		cuPool:      cuPool,
+	}
+}
+
 // RegisterCU allows the roundRobinAlgorithm to dispatch work-group to the CU.
 func (a *roundRobinAlgorithm) RegisterCU(cu resource.DispatchableCU) {
 	a.cuPool.RegisterCU(cu)
@@ -40,7 +48,7 @@ func (a *roundRobinAlgorithm) HasNext() bool {
 }
 
 // Next finds the location to dispatch the next work-group.
-func (a *roundRobinAlgorithm) Next() (location dispatchLocation) {
+func (a *roundRobinAlgorithm) Next() (location Location) {
 	if a.currWG == nil {
 		a.currWG = a.gridBuilder.NextWG()
 	}
@@ -53,17 +61,7 @@ func (a *roundRobinAlgorithm) Next() (location dispatchLocation) {
 		if ok {
 			a.nextCU = (cuID + 1) % a.cuPool.NumCU()
 
-			dispatch := dispatchLocation{
-				valid: true,
-				cu:    cu.DispatchingPort(),
-				cuID:  cuID,
-				wg:    a.currWG,
-			}
-			dispatch.locations =
-				make([]protocol.WfDispatchLocation, len(locations))
-			for i, localtion := range locations {
-				dispatch.locations[i] = protocol.WfDispatchLocation(localtion)
-			}
+			dispatch := NewLocation(cuID, cu, a.currWG, locations)
 
 			a.currWG = nil
 			a.numDispatchedWGs++
@@ -72,10 +70,10 @@ func (a *roundRobinAlgorithm) Next() (location dispatchLocation) {
 		}
 	}
 
-	return dispatchLocation{}
+	return Location{}
 }
 
 // FreeResources marks the dispatched location to be available.
-func (a *roundRobinAlgorithm) FreeResources(location dispatchLocation) {
-	a.cuPool.GetCU(location.cuID).FreeResourcesForWG(location.wg)
+func (a *roundRobinAlgorithm) FreeResources(location Location) {
+	a.cuPool.GetCU(location.CUID).FreeResourcesForWG(location.WG)
 }
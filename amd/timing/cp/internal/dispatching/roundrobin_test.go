@@ -82,7 +82,7 @@ var _ = Describe("Round Robin Algorithm", func() {
 
 		location := alg.Next()
 
-		Expect(location.valid).To(BeTrue())
+		Expect(location.Valid).To(BeTrue())
 		Expect(alg.numDispatchedWGs).To(Equal(1))
 	})
 
@@ -100,7 +100,7 @@ var _ = Describe("Round Robin Algorithm", func() {
 
 		location := alg.Next()
 
-		Expect(location.valid).To(BeFalse())
+		Expect(location.Valid).To(BeFalse())
 		Expect(alg.numDispatchedWGs).To(Equal(0))
 	})
 })
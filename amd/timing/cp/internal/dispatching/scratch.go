@@ -0,0 +1,76 @@
+package dispatching
+
+import (
+	"github.com/sarchlab/mgpusim/v4/amd/kernels"
+	"github.com/sarchlab/mgpusim/v4/amd/protocol"
+)
+
+// scratchSlots hands out the slots of the scratch segment of a kernel to the
+// wavefronts. A slot holds the private memory of one wavefront. The slots of
+// a work-group return to the pool when the work-group completes, so the
+// segment only needs to hold the wavefronts that run at the same time.
+type scratchSlots struct {
+	slotSize uint64
+	wfPerWG  int
+	free     []int
+}
+
+// newScratchSlots returns the slots of the scratch segment of the kernel, or
+// nil if the kernel does not use private memory.
+func newScratchSlots(req *protocol.LaunchKernelReq) *scratchSlots {
+	if req.ScratchByteSize == 0 || req.Packet.PrivateSegmentSize == 0 {
+		return nil
+	}
+
+	laneCount := kernels.KernelWavefrontSize(req.CodeObject)
+	s := &scratchSlots{
+		slotSize: kernels.ScratchWaveByteSize(req.Packet.PrivateSegmentSize),
+		wfPerWG:  req.Packet.NumWavefrontsPerWG(laneCount),
+	}
+
+	numSlots := int(req.ScratchByteSize / s.slotSize)
+	if numSlots < s.wfPerWG {
+		panic("the scratch segment cannot hold a work-group")
+	}
+
+	s.free = make([]int, numSlots)
+	for i := range s.free {
+		s.free[i] = numSlots - 1 - i
+	}
+
+	return s
+}
+
+// canHoldWG checks if there are enough free slots for a work-group.
+func (s *scratchSlots) canHoldWG() bool {
+	if s == nil {
+		return true
+	}
+
+	return len(s.free) >= s.wfPerWG
+}
+
+// place gives each wavefront of the work-group a free slot.
+func (s *scratchSlots) place(wg *kernels.WorkGroup) {
+	if s == nil {
+		return
+	}
+
+	for _, wf := range wg.Wavefronts {
+		slot := s.free[len(s.free)-1]
+		s.free = s.free[:len(s.free)-1]
+
+		wf.ScratchWaveOffset = uint64(slot) * s.slotSize
+	}
+}
+
+// release returns the slots of the wavefronts of the work-group.
+func (s *scratchSlots) release(wg *kernels.WorkGroup) {
+	if s == nil {
+		return
+	}
+
+	for _, wf := range wg.Wavefronts {
+		s.free = append(s.free, int(wf.ScratchWaveOffset/s.slotSize))
+	}
+}
@@ -0,0 +1,151 @@
+package dispatching
+
+import (
+	"sort"
+
+	"github.com/sarchlab/mgpusim/v4/amd/kernels"
+	"github.com/sarchlab/mgpusim/v4/amd/timing/cp/internal/resource"
+)
+
+// zOrderAlgorithm dispatches the work-groups of each XY layer of the grid in
+// the Z-order (Morton order) of their X and Y IDs, so that the work-groups
+// that run at the same time cover square tiles of a 2D grid rather than a
+// few rows. The work-groups go to the CUs in turns.
+type zOrderAlgorithm struct {
+	gridBuilder kernels.GridBuilder
+	cuPool      resource.CUResourcePool
+
+	numWGX, numWGY int
+	order          []int
+
+	currWG           *kernels.WorkGroup
+	nextCU           int
+	numDispatchedWGs int
+}
+
+// NewZOrderAlgorithm creates an algorithm that dispatches the work-groups of
+// 2D grids in Z-order.
+func NewZOrderAlgorithm(cuPool resource.CUResourcePool) Algorithm {
+	return &zOrderAlgorithm{
+		gridBuilder: kernels.NewGridBuilder(),
+		cuPool:      cuPool,
+	}
+}
+
+// RegisterCU allows the zOrderAlgorithm to dispatch work-group to the CU.
+func (a *zOrderAlgorithm) RegisterCU(cu resource.DispatchableCU) {
+	a.cuPool.RegisterCU(cu)
+}
+
+// StartNewKernel lets the algorithms to start dispatching a new kernel.
+func (a *zOrderAlgorithm) StartNewKernel(info kernels.KernelLaunchInfo) {
+	a.numDispatchedWGs = 0
+	a.gridBuilder.SetKernel(info)
+
+	packet := info.Packet
+	a.numWGX = int(packet.GridSizeX-1)/int(packet.WorkgroupSizeX) + 1
+	a.numWGY = int(packet.GridSizeY-1)/int(packet.WorkgroupSizeY) + 1
+	numWGZ := int(packet.GridSizeZ-1)/int(packet.WorkgroupSizeZ) + 1
+
+	a.order = make([]int, 0, a.numWGX*a.numWGY*numWGZ)
+	for z := 0; z < numWGZ; z++ {
+		for y := 0; y < a.numWGY; y++ {
+			for x := 0; x < a.numWGX; x++ {
+				wg := kernels.WorkGroup{IDX: x, IDY: y, IDZ: z}
+				if info.WGFilter != nil && !info.WGFilter(packet, &wg) {
+					continue
+				}
+
+				a.order = append(a.order, a.index(x, y, z))
+			}
+		}
+	}
+
+	sort.SliceStable(a.order, func(i, j int) bool {
+		return a.zOrderLess(a.order[i], a.order[j])
+	})
+}
+
+func (a *zOrderAlgorithm) index(x, y, z int) int {
+	return (z*a.numWGY+y)*a.numWGX + x
+}
+
+func (a *zOrderAlgorithm) ids(index int) (x, y, z int) {
+	x = index % a.numWGX
+	y = index / a.numWGX % a.numWGY
+	z = index / a.numWGX / a.numWGY
+
+	return x, y, z
+}
+
+func (a *zOrderAlgorithm) zOrderLess(i, j int) bool {
+	xi, yi, zi := a.ids(i)
+	xj, yj, zj := a.ids(j)
+
+	if zi != zj {
+		return zi < zj
+	}
+
+	return mortonCode(xi, yi) < mortonCode(xj, yj)
+}
+
+// mortonCode interleaves the bits of x and y, with the bits of x in the even
+// positions.
+func mortonCode(x, y int) uint64 {
+	return spreadBits(uint32(x)) | spreadBits(uint32(y))<<1
+}
+
+func spreadBits(v uint32) uint64 {
+	b := uint64(v)
+	b = (b | b<<16) & 0x0000ffff0000ffff
+	b = (b | b<<8) & 0x00ff00ff00ff00ff
+	b = (b | b<<4) & 0x0f0f0f0f0f0f0f0f
+	b = (b | b<<2) & 0x3333333333333333
+	b = (b | b<<1) & 0x5555555555555555
+
+	return b
+}
+
+// NumWG returns the number of work-groups in the currently-dispatching
+// work-group.
+func (a *zOrderAlgorithm) NumWG() int {
+	return a.gridBuilder.NumWG()
+}
+
+// HasNext check if there are more work-groups to dispatch.
+func (a *zOrderAlgorithm) HasNext() bool {
+	return a.numDispatchedWGs < a.gridBuilder.NumWG()
+}
+
+// Next finds the location to dispatch the next work-group.
+func (a *zOrderAlgorithm) Next() (location Location) {
+	if a.currWG == nil {
+		x, y, z := a.ids(a.order[a.numDispatchedWGs])
+		a.gridBuilder.Seek(x, y, z)
+		a.currWG = a.gridBuilder.NextWG()
+	}
+
+	for i := 0; i < a.cuPool.NumCU(); i++ {
+		cuID := (a.nextCU + i) % a.cuPool.NumCU()
+		cu := a.cuPool.GetCU(cuID)
+
+		locations, ok := cu.ReserveResourceForWG(a.currWG)
+		if ok {
+			a.nextCU = (cuID + 1) % a.cuPool.NumCU()
+
+			dispatch := NewLocation(cuID, cu, a.currWG, locations)
+
+			a.currWG = nil
+			a.numDispatchedWGs++
+
+			return dispatch
+		}
+	}
+
+	return Location{}
+}
+
+// FreeResources marks the dispatched location to be available.
+func (a *zOrderAlgorithm) FreeResources(location Location) {
+	a.cuPool.GetCU(location.CUID).FreeResourcesForWG(location.WG)
+}
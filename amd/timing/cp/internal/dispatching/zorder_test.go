@@ -0,0 +1,105 @@
+package dispatching
+
+import (
+	"strconv"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/akita/v4/sim"
+	"github.com/sarchlab/mgpusim/v4/amd/kernels"
+	"github.com/sarchlab/mgpusim/v4/amd/timing/cp/internal/resource"
+	"go.uber.org/mock/gomock"
+)
+
+var _ = Describe("Z-Order Algorithm", func() {
+	var (
+		ctrl        *gomock.Controller
+		gridBuilder *MockGridBuilder
+		pool        *MockCUResourcePool
+		cus         []*MockCUResource
+		alg         *zOrderAlgorithm
+		packet      *kernels.HsaKernelDispatchPacket
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		gridBuilder = NewMockGridBuilder(ctrl)
+
+		cus = make([]*MockCUResource, 2)
+		for i := 0; i < 2; i++ {
+			cus[i] = NewMockCUResource(ctrl)
+			cus[i].EXPECT().DispatchingPort().
+				Return(sim.RemotePort("CUPort" + strconv.Itoa(i))).
+				AnyTimes()
+		}
+
+		pool = NewMockCUResourcePool(ctrl)
+		pool.EXPECT().NumCU().Return(len(cus)).AnyTimes()
+		pool.EXPECT().
+			GetCU(gomock.Any()).
+			DoAndReturn(func(i int) resource.CUResource {
+				return cus[i]
+			}).
+			AnyTimes()
+
+		alg = &zOrderAlgorithm{
+			gridBuilder: gridBuilder,
+			cuPool:      pool,
+		}
+
+		packet = new(kernels.HsaKernelDispatchPacket)
+		packet.WorkgroupSizeX = 16
+		packet.WorkgroupSizeY = 16
+		packet.WorkgroupSizeZ = 1
+		packet.GridSizeX = 64
+		packet.GridSizeY = 32
+		packet.GridSizeZ = 1
+
+		gridBuilder.EXPECT().SetKernel(gomock.Any())
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	It("should order the work-groups in Z-order", func() {
+		alg.StartNewKernel(kernels.KernelLaunchInfo{Packet: packet})
+
+		Expect(alg.order).To(Equal([]int{0, 1, 4, 5, 2, 3, 6, 7}))
+	})
+
+	It("should skip the filtered work-groups", func() {
+		alg.StartNewKernel(kernels.KernelLaunchInfo{
+			Packet: packet,
+			WGFilter: func(
+				_ *kernels.HsaKernelDispatchPacket,
+				wg *kernels.WorkGroup,
+			) bool {
+				return wg.IDX != 1
+			},
+		})
+
+		Expect(alg.order).To(Equal([]int{0, 4, 2, 3, 6, 7}))
+	})
+
+	It("should dispatch the work-groups in Z-order", func() {
+		wg := kernels.NewWorkGroup()
+
+		alg.StartNewKernel(kernels.KernelLaunchInfo{Packet: packet})
+		alg.numDispatchedWGs = 2
+		alg.nextCU = 1
+
+		gridBuilder.EXPECT().Seek(0, 1, 0)
+		gridBuilder.EXPECT().NextWG().Return(wg)
+		cus[1].EXPECT().ReserveResourceForWG(wg).
+			Return([]resource.WfLocation{}, true)
+
+		location := alg.Next()
+
+		Expect(location.Valid).To(BeTrue())
+		Expect(location.CUID).To(Equal(1))
+		Expect(location.WG).To(BeIdenticalTo(wg))
+		Expect(alg.nextCU).To(Equal(0))
+		Expect(alg.numDispatchedWGs).To(Equal(3))
+	})
+})
@@ -101,8 +101,12 @@ func (u *BranchUnit) runWriteStage() bool {
 	return true
 }
 
-// Flush clear the unit
+// Flush clears the unit. The branch in the write stage has already set the PC
+// of the wavefront, so it is retired. Dropping it would leave the wavefront
+// with an instruction buffer that does not match the new PC.
 func (u *BranchUnit) Flush() {
+	u.runWriteStage()
+
 	u.toRead = nil
 	u.toWrite = nil
 	u.toExec = nil
@@ -88,6 +88,8 @@ var _ = Describe("Branch Unit", func() {
 
 		bu.Flush()
 
+		Expect(wave3.State).To(Equal(wavefront.WfReady))
+		Expect(wave3.InstBuffer).To(HaveLen(0))
 		Expect(bu.toRead).To(BeNil())
 		Expect(bu.toWrite).To(BeNil())
 		Expect(bu.toExec).To(BeNil())
@@ -0,0 +1,212 @@
+package cu
+
+import (
+	"log"
+
+	"github.com/sarchlab/akita/v4/mem/mem"
+	"github.com/sarchlab/mgpusim/v4/amd/emu"
+	"github.com/sarchlab/mgpusim/v4/amd/insts"
+	"github.com/sarchlab/mgpusim/v4/amd/protocol"
+	"github.com/sarchlab/mgpusim/v4/amd/timing/wavefront"
+)
+
+// generateBufferTransactions creates the memory transactions of a MUBUF or
+// MTBUF instruction. The components that fall outside the buffer do not
+// access the memory.
+func (c defaultCoalescer) generateBufferTransactions(
+	wf *wavefront.Wavefront,
+) []VectorMemAccessInfo {
+	op, _ := wf.Inst().BufferOp()
+	rsrc := emu.ReadBufferResource(wf)
+	comps := emu.BufferComponents(wf.Inst(), rsrc)
+
+	return c.generateComponentTransactions(wf, op, comps,
+		emu.BufferComponentAddress(wf, rsrc))
+}
+
+// generateScratchTransactions creates the memory transactions of a FLAT
+// instruction that accesses the scratch memory, whose lanes interleave every
+// 4 bytes.
+func (c defaultCoalescer) generateScratchTransactions(
+	wf *wavefront.Wavefront,
+) []VectorMemAccessInfo {
+	op, ok := wf.Inst().FlatOp()
+	if !ok {
+		log.Panicf("Opcode %d for scratch instructions is not supported",
+			wf.Inst().Opcode)
+	}
+
+	return c.generateComponentTransactions(wf, op, emu.FlatComponents(wf.Inst()),
+		emu.ScratchComponentAddress(wf))
+}
+
+// generateComponentTransactions creates the memory transactions of an
+// instruction that accesses the memory component by component.
+func (c defaultCoalescer) generateComponentTransactions(
+	wf *wavefront.Wavefront,
+	op insts.BufferOp,
+	comps []emu.BufferComponent,
+	address emu.ComponentAddressFunc,
+) []VectorMemAccessInfo {
+	switch op.Kind {
+	case insts.BufferOpLoad:
+		return c.generateComponentReadTransactions(wf, comps, address)
+	case insts.BufferOpStore:
+		reqs := c.generateComponentWriteReqs(wf, comps, address)
+		return c.generateWriteTransactions(wf, reqs)
+	case insts.BufferOpAtomic:
+		return c.generateComponentAtomicTransactions(wf, address)
+	}
+
+	return nil
+}
+
+func (c defaultCoalescer) generateComponentReadTransactions(
+	wf *wavefront.Wavefront,
+	comps []emu.BufferComponent,
+	address emu.ComponentAddressFunc,
+) []VectorMemAccessInfo {
+	exec := wf.EXEC()
+	inst := wf.Inst()
+	reqs := []*mem.ReadReq{}
+	transactions := []VectorMemAccessInfo{}
+	groupFirstReq := 0
+
+	for i := uint(0); i < uint(wf.LaneCount()); i++ {
+		first := c.firstReqOfGroup(i, len(reqs), &groupFirstReq)
+		if !laneMasked(exec, i) {
+			continue
+		}
+
+		for j := range comps {
+			comp := &comps[j]
+			if !comp.InMemory {
+				continue
+			}
+
+			addr, ok := address(int(i), comp.Offset, comp.ByteSize)
+			if !ok {
+				continue
+			}
+
+			req := c.findOrCreateReadReq(&reqs, first, addr)
+
+			if len(transactions) < len(reqs) {
+				transactions = append(transactions, VectorMemAccessInfo{
+					Read:      req,
+					Wavefront: wf,
+					Inst:      wf.DynamicInst(),
+				})
+			}
+
+			t := &transactions[c.readReqIndex(reqs, req)]
+			t.laneInfo = append(t.laneInfo, vectorMemAccessLaneInfo{
+				laneID:                int(i),
+				reg:                   insts.VReg(inst.Dst.Register.RegIndex() + j),
+				regCount:              1,
+				addrOffsetInCacheLine: c.addrOffsetInCacheLine(addr),
+				bufferComponent:       comp,
+			})
+		}
+	}
+
+	return transactions
+}
+
+func (c defaultCoalescer) readReqIndex(
+	reqs []*mem.ReadReq,
+	req *mem.ReadReq,
+) int {
+	for i, r := range reqs {
+		if r == req {
+			return i
+		}
+	}
+
+	panic("request not found")
+}
+
+func (c defaultCoalescer) generateComponentWriteReqs(
+	wf *wavefront.Wavefront,
+	comps []emu.BufferComponent,
+	address emu.ComponentAddressFunc,
+) []*mem.WriteReq {
+	exec := wf.EXEC()
+	inst := wf.Inst()
+	reqs := []*mem.WriteReq{}
+	groupFirstReq := 0
+
+	for i := uint(0); i < uint(wf.LaneCount()); i++ {
+		first := c.firstReqOfGroup(i, len(reqs), &groupFirstReq)
+		if !laneMasked(exec, i) {
+			continue
+		}
+
+		data := wf.ReadOperandBytes(inst.Data, int(i), 4*len(comps))
+
+		for j, comp := range comps {
+			if !comp.InMemory {
+				continue
+			}
+
+			addr, ok := address(int(i), comp.Offset, comp.ByteSize)
+			if !ok {
+				continue
+			}
+
+			value := insts.BytesToUint32(data[4*j:])
+			c.findOrCreateWriteReq(&reqs, first, addr, comp.Store(value))
+		}
+	}
+
+	return reqs
+}
+
+// generateComponentAtomicTransactions creates a request for each active lane
+// that accesses the memory.
+func (c defaultCoalescer) generateComponentAtomicTransactions(
+	wf *wavefront.Wavefront,
+	address emu.ComponentAddressFunc,
+) []VectorMemAccessInfo {
+	exec := wf.EXEC()
+	inst := wf.Inst()
+	atomic, _ := inst.AtomicInfo()
+	transactions := []VectorMemAccessInfo{}
+
+	for i := uint(0); i < uint(wf.LaneCount()); i++ {
+		if !laneMasked(exec, i) {
+			continue
+		}
+
+		addr, ok := address(int(i), 0, uint64(atomic.ByteSize))
+		if !ok {
+			continue
+		}
+
+		src, cmp := emu.FlatAtomicOperands(wf, atomic, int(i))
+		req := protocol.AtomicReqBuilder{}.
+			WithAddress(addr).
+			WithAtomic(atomic).
+			WithValue(src).
+			WithCmp(cmp).
+			Build()
+
+		transaction := VectorMemAccessInfo{
+			Atomic:    req,
+			Wavefront: wf,
+			Inst:      wf.DynamicInst(),
+		}
+
+		if atomic.Return {
+			transaction.laneInfo = []vectorMemAccessLaneInfo{{
+				laneID:   int(i),
+				reg:      inst.Dst.Register,
+				regCount: atomic.ByteSize / 4,
+			}}
+		}
+
+		transactions = append(transactions, transaction)
+	}
+
+	return transactions
+}
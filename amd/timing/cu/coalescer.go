@@ -2,8 +2,15 @@ package cu
 
 import "github.com/sarchlab/mgpusim/v4/amd/timing/wavefront"
 
-// Coalescer can generate memory access instructions from instruction, register
-// values.
-type coalescer interface {
-	generateMemTransactions(wf *wavefront.Wavefront) []VectorMemAccessInfo
+// A Coalescer converts the lanes of a vector memory instruction into memory
+// transactions. The built-in coalescers are created by NewIdealCoalescer,
+// NewQuarterWaveCoalescer, and NewNoCoalescer. A custom coalescer can wrap one
+// of them to post-process the transactions.
+type Coalescer interface {
+	GenerateMemTransactions(wf *wavefront.Wavefront) []VectorMemAccessInfo
 }
+
+// A CoalescerFactory creates a coalescer for a compute unit whose vector
+// memory accesses are in cache lines of 2^log2CacheLineSize bytes. The
+// constructors of the built-in coalescers are CoalescerFactories.
+type CoalescerFactory func(log2CacheLineSize uint64) Coalescer
@@ -46,8 +46,11 @@ type ComputeUnit struct {
 	ScalarUnit       SubComponent
 	SIMDUnit         []SubComponent
 	LDSUnit          SubComponent
+	MatrixDecoder    SubComponent
+	MatrixCoreUnit   []SubComponent
 	SRegFile         RegisterFile
 	VRegFile         []RegisterFile
+	ARegFile         []RegisterFile
 
 	InstMem          sim.Port
 	ScalarMem        sim.Port
@@ -138,6 +141,12 @@ func (cu *ComputeUnit) runPipeline() bool {
 			madeProgress = simdUnit.Run() || madeProgress
 		}
 		madeProgress = cu.VectorDecoder.Run() || madeProgress
+		for _, matrixCoreUnit := range cu.MatrixCoreUnit {
+			madeProgress = matrixCoreUnit.Run() || madeProgress
+		}
+		if cu.MatrixDecoder != nil {
+			madeProgress = cu.MatrixDecoder.Run() || madeProgress
+		}
 		madeProgress = cu.LDSUnit.Run() || madeProgress
 		madeProgress = cu.LDSDecoder.Run() || madeProgress
 		madeProgress = cu.VectorMemUnit.Run() || madeProgress
@@ -292,6 +301,15 @@ func (cu *ComputeUnit) flushInternalComponents() {
 	}
 
 	cu.VectorDecoder.Flush()
+
+	for _, matrixCoreUnit := range cu.MatrixCoreUnit {
+		matrixCoreUnit.Flush()
+	}
+
+	if cu.MatrixDecoder != nil {
+		cu.MatrixDecoder.Flush()
+	}
+
 	cu.LDSUnit.Flush()
 	cu.LDSDecoder.Flush()
 	cu.VectorMemDecoder.Flush()
@@ -610,6 +628,11 @@ func (cu *ComputeUnit) handleVectorDataLoadReturn(
 			matchIdx = i
 			break
 		}
+
+		if info.Atomic != nil && info.Atomic.ID == rsp.RespondTo {
+			matchIdx = i
+			break
+		}
 	}
 	if matchIdx < 0 {
 		return
@@ -619,10 +642,17 @@ func (cu *ComputeUnit) handleVectorDataLoadReturn(
 	cu.InFlightVectorMemAccess = append(
 		cu.InFlightVectorMemAccess[:matchIdx],
 		cu.InFlightVectorMemAccess[matchIdx+1:]...)
-	tracing.TraceReqFinalize(info.Read, cu)
+
+	canWaitForCoalesce := false
+	if info.Atomic != nil {
+		canWaitForCoalesce = info.Atomic.CanWaitForCoalesce
+		tracing.TraceReqFinalize(info.Atomic, cu)
+	} else {
+		canWaitForCoalesce = info.Read.CanWaitForCoalesce
+		tracing.TraceReqFinalize(info.Read, cu)
+	}
 
 	wf := info.Wavefront
-	inst := info.Inst
 
 	for _, laneInfo := range info.laneInfo {
 		offset := laneInfo.addrOffsetInCacheLine
@@ -631,10 +661,10 @@ func (cu *ComputeUnit) handleVectorDataLoadReturn(
 		access.Reg = laneInfo.reg
 		access.RegCount = laneInfo.regCount
 		access.LaneID = laneInfo.laneID
-		if inst.FormatType == insts.FLAT && inst.Opcode == 16 { // FLAT_LOAD_UBYTE
-			access.Data = insts.Uint32ToBytes(uint32(rsp.Data[offset]))
-		} else if inst.FormatType == insts.FLAT && inst.Opcode == 18 {
-			access.Data = insts.Uint32ToBytes(uint32(rsp.Data[offset]))
+		if comp := laneInfo.bufferComponent; comp != nil {
+			var data [4]byte
+			copy(data[:], rsp.Data[min(offset, uint64(len(rsp.Data))):])
+			access.Data = insts.Uint32ToBytes(comp.Load(data[:]))
 		} else {
 			end := offset + uint64(4*laneInfo.regCount)
 			if end > uint64(len(rsp.Data)) {
@@ -648,7 +678,7 @@ func (cu *ComputeUnit) handleVectorDataLoadReturn(
 		cu.VRegFile[wf.SIMDID].Write(access)
 	}
 
-	if !info.Read.CanWaitForCoalesce {
+	if !canWaitForCoalesce {
 		wf.OutstandingVectorMemAccess--
 		if info.Inst.FormatType == insts.FLAT {
 			wf.OutstandingScalarMemAccess--
@@ -751,6 +781,8 @@ func (cu *ComputeUnit) execUnitToString(u insts.ExeUnit) string {
 		return "GDS"
 	case insts.ExeUnitSpecial:
 		return "Special"
+	case insts.ExeUnitMatrix:
+		return "Matrix"
 	}
 	panic("unknown exec unit")
 }
@@ -839,6 +871,15 @@ func (cu *ComputeUnit) sendVectorShadowBufferAccesses() bool {
 				cu.shadowInFlightVectorMemAccess = cu.shadowInFlightVectorMemAccess[1:]
 				return true
 			}
+		} else if info.Atomic != nil {
+			req := info.Atomic
+			req.ID = sim.GetIDGenerator().Generate()
+			err := cu.ToVectorMem.Send(req)
+			if err == nil {
+				cu.InFlightVectorMemAccess = append(cu.InFlightVectorMemAccess, info)
+				cu.shadowInFlightVectorMemAccess = cu.shadowInFlightVectorMemAccess[1:]
+				return true
+			}
 		}
 	}
 	return false
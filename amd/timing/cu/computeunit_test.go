@@ -339,10 +339,10 @@ var _ = Describe("ComputeUnit", func() {
 			info.Wavefront = wf
 			info.Inst = inst
 			info.laneInfo = []vectorMemAccessLaneInfo{
-				{0, insts.VReg(0), 1, 0},
-				{1, insts.VReg(0), 1, 4},
-				{2, insts.VReg(0), 1, 8},
-				{3, insts.VReg(0), 1, 12},
+				{0, insts.VReg(0), 1, 0, nil},
+				{1, insts.VReg(0), 1, 4, nil},
+				{2, insts.VReg(0), 1, 8, nil},
+				{3, insts.VReg(0), 1, 12, nil},
 			}
 			cu.InFlightVectorMemAccess = append(
 				cu.InFlightVectorMemAccess, info)
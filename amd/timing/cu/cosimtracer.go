@@ -0,0 +1,120 @@
+package cu
+
+import (
+	"github.com/sarchlab/akita/v4/tracing"
+	"github.com/sarchlab/mgpusim/v4/amd/cosim"
+	"github.com/sarchlab/mgpusim/v4/amd/emu"
+	"github.com/sarchlab/mgpusim/v4/amd/insts"
+	"github.com/sarchlab/mgpusim/v4/amd/timing/wavefront"
+)
+
+// A CoSimTracer checks the instructions that the wavefronts of a compute unit
+// complete against the emulator. The instructions are numbered in the order
+// that the scheduler issues them, and are checked when they complete, after
+// they write the registers. The state of a wavefront is checked when the
+// wavefront issues S_ENDPGM and all its earlier instructions complete.
+type CoSimTracer struct {
+	checker *cosim.Checker
+
+	insts map[string]*coSimInst
+	wfs   map[*wavefront.Wavefront]*coSimWf
+}
+
+type coSimInst struct {
+	wf    *wavefront.Wavefront
+	inst  *insts.Inst
+	index int
+	pc    uint64
+}
+
+type coSimWf struct {
+	numIssued   int
+	numInFlight int
+	ended       bool
+}
+
+// NewCoSimTracer creates a CoSimTracer that reports to the checker.
+func NewCoSimTracer(checker *cosim.Checker) *CoSimTracer {
+	return &CoSimTracer{
+		checker: checker,
+		insts:   make(map[string]*coSimInst),
+		wfs:     make(map[*wavefront.Wavefront]*coSimWf),
+	}
+}
+
+// StartTask numbers the instructions that the scheduler issues.
+func (t *CoSimTracer) StartTask(task tracing.Task) {
+	if task.Kind != "inst" {
+		return
+	}
+
+	detail := task.Detail.(map[string]interface{})
+	wf := detail["wf"].(*wavefront.Wavefront)
+	inst := detail["inst"].(*wavefront.Inst)
+
+	state, ok := t.wfs[wf]
+	if !ok {
+		state = &coSimWf{}
+		t.wfs[wf] = state
+	}
+
+	if inst.FormatType == insts.SOPP && inst.Opcode == 1 { // S_ENDPGM
+		state.ended = true
+		t.checkFinalState(wf, state)
+
+		return
+	}
+
+	t.insts[task.ID] = &coSimInst{
+		wf:    wf,
+		inst:  inst.Inst,
+		index: state.numIssued,
+		pc:    wf.PC(),
+	}
+	state.numIssued++
+	state.numInFlight++
+}
+
+// StepTask does nothing.
+func (t *CoSimTracer) StepTask(_ tracing.Task) {
+	// Do nothing.
+}
+
+// AddMilestone does nothing.
+func (t *CoSimTracer) AddMilestone(_ tracing.Milestone) {
+	// Do nothing.
+}
+
+// EndTask checks the instruction that completes.
+func (t *CoSimTracer) EndTask(task tracing.Task) {
+	issued, ok := t.insts[task.ID]
+	if !ok {
+		return
+	}
+
+	delete(t.insts, task.ID)
+
+	wf := issued.wf
+	t.checker.CheckSimulatedStep(wf.Wavefront, issued.index, cosim.Step{
+		PC:     issued.pc,
+		Digest: emu.CoSimDigest(wf, issued.inst),
+	}, issued.inst)
+
+	state := t.wfs[wf]
+	state.numInFlight--
+	t.checkFinalState(wf, state)
+}
+
+func (t *CoSimTracer) checkFinalState(
+	wf *wavefront.Wavefront,
+	state *coSimWf,
+) {
+	if !state.ended || state.numInFlight > 0 {
+		return
+	}
+
+	t.checker.CheckSimulatedFinalState(wf.Wavefront, state.numIssued,
+		emu.CoSimState(wf, wf.CodeObject))
+
+	delete(t.wfs, wf)
+}
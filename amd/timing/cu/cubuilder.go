@@ -30,6 +30,18 @@ type Builder struct {
 
 	maxCoalescingPenalty int
 	registerScoreboard   bool
+	dualIssue            bool
+	coalescerFactory     CoalescerFactory
+	issuePolicy          string
+	fetchPolicy          string
+	trapHandler          TrapHandler
+
+	matrixCoreLatencies map[string]int
+
+	ldsLatency   int
+	ldsBanks     int
+	ldsBankWidth int
+	ldsBroadcast bool
 
 	decoder    emu.Decoder
 	alu        emu.ALU
@@ -57,6 +69,11 @@ func MakeBuilder() Builder {
 	b.vecMemTransPipelineStages = 10
 	b.vecMemTransPipelineWidth = 1
 	b.memPipelineBufferSize = 8
+	b.matrixCoreLatencies = DefaultMatrixCoreLatencies()
+	b.ldsLatency = defaultLDSLatency
+	b.ldsBankWidth = 4
+	b.ldsBroadcast = true
+	b.issuePolicy = DefaultIssuePolicy
 
 	return b
 }
@@ -178,6 +195,45 @@ func (b Builder) WithMaxCoalescingPenalty(n int) Builder {
 	return b
 }
 
+// WithCoalescer sets how the vector memory unit combines the lanes of an
+// instruction into memory transactions. Default is NewIdealCoalescer.
+func (b Builder) WithCoalescer(factory CoalescerFactory) Builder {
+	b.coalescerFactory = factory
+	return b
+}
+
+// WithIssuePolicy sets the name of the policy that decides which wavefronts
+// issue first in each SIMD. The policy must be registered with
+// RegisterIssuePolicy. Default is DefaultIssuePolicy.
+func (b Builder) WithIssuePolicy(name string) Builder {
+	if _, err := NewIssuePolicy(name); err != nil {
+		panic(err)
+	}
+
+	b.issuePolicy = name
+	return b
+}
+
+// WithFetchPolicy sets the name of the policy that decides which wavefront
+// fetches instructions first. The policy is selected from the issue policies
+// registered with RegisterIssuePolicy. By default, the wavefront that fetched
+// the least recently fetches first.
+func (b Builder) WithFetchPolicy(name string) Builder {
+	if _, err := NewIssuePolicy(name); err != nil {
+		panic(err)
+	}
+
+	b.fetchPolicy = name
+	return b
+}
+
+// WithTrapHandler sets the trap handler that runs when a wavefront executes
+// s_trap. Without a trap handler, s_trap does not stall the wavefront.
+func (b Builder) WithTrapHandler(h TrapHandler) Builder {
+	b.trapHandler = h
+	return b
+}
+
 // WithRegisterScoreboard enables or disables the register scoreboard and
 // SIMD pipelining feature. When enabled, the CU tracks per-wavefront
 // register availability to detect RAW hazards and allows multiple
@@ -187,6 +243,57 @@ func (b Builder) WithRegisterScoreboard(enabled bool) Builder {
 	return b
 }
 
+// WithDualIssue lets each SIMD unit issue two VALU instructions per cycle
+// from different wavefronts, as the dual-issue SIMD32 units of an RDNA
+// work-group processor (WGP) do. Combine it with 32 single-precision units
+// per SIMD to model a WGP.
+func (b Builder) WithDualIssue(enabled bool) Builder {
+	b.dualIssue = enabled
+	return b
+}
+
+// WithMatrixCoreLatency sets the number of cycles that an MFMA instruction
+// occupies the matrix core. The defaults follow the CDNA3 pass counts.
+func (b Builder) WithMatrixCoreLatency(instName string, cycles int) Builder {
+	latencies := make(map[string]int, len(b.matrixCoreLatencies)+1)
+	for name, c := range b.matrixCoreLatencies {
+		latencies[name] = c
+	}
+	latencies[instName] = cycles
+
+	b.matrixCoreLatencies = latencies
+	return b
+}
+
+// WithLDSLatency sets the number of cycles of a DS instruction that does not
+// have bank conflicts. Default is 14.
+func (b Builder) WithLDSLatency(cycles int) Builder {
+	b.ldsLatency = cycles
+	return b
+}
+
+// WithLDSBanks sets the number of LDS banks. DS reads and writes whose lanes
+// access different words in the same bank take one extra cycle per conflict.
+// Default is 0, which disables bank conflict modeling.
+func (b Builder) WithLDSBanks(n int) Builder {
+	b.ldsBanks = n
+	return b
+}
+
+// WithLDSBankWidth sets the number of bytes that an LDS bank serves per cycle.
+// Default is 4.
+func (b Builder) WithLDSBankWidth(bytes int) Builder {
+	b.ldsBankWidth = bytes
+	return b
+}
+
+// WithLDSBroadcast sets whether lanes that access the same LDS word share one
+// bank access rather than conflicting. Default is true.
+func (b Builder) WithLDSBroadcast(enabled bool) Builder {
+	b.ldsBroadcast = enabled
+	return b
+}
+
 // Build returns a newly constructed compute unit according to the
 // configuration.
 func (b Builder) Build(name string) *ComputeUnit {
@@ -211,6 +318,7 @@ func (b Builder) Build(name string) *ComputeUnit {
 	b.equipScheduler(cu)
 	b.equipScalarUnits(cu)
 	b.equipSIMDUnits(cu)
+	b.equipMatrixCoreUnits(cu)
 	b.equipLDSUnit(cu)
 	b.equipVectorMemoryUnit(cu)
 	b.equipRegisterFiles(cu)
@@ -233,10 +341,26 @@ func (b Builder) Build(name string) *ComputeUnit {
 func (b *Builder) equipScheduler(cu *ComputeUnit) {
 	fetchArbitor := new(FetchArbiter)
 	fetchArbitor.InstBufByteSize = 256
-	issueArbitor := new(IssueArbiter)
+	if b.fetchPolicy != "" {
+		fetchPolicy, err := NewIssuePolicy(b.fetchPolicy)
+		if err != nil {
+			panic(err)
+		}
+		fetchArbitor = NewFetchArbiterWithPolicy(256, fetchPolicy)
+	}
+	policy, err := NewIssuePolicy(b.issuePolicy)
+	if err != nil {
+		panic(err)
+	}
+	issueArbitor := NewIssueArbiterWithPolicy(policy)
 	issueArbitor.scoreboardEnabled = b.registerScoreboard
+	if b.dualIssue {
+		issueArbitor.valuIssueWidth = 2
+	}
 	scheduler := NewScheduler(cu, fetchArbitor, issueArbitor)
 	scheduler.scoreboardEnabled = b.registerScoreboard
+	scheduler.issuePolicy = b.issuePolicy
+	scheduler.trapHandler = b.trapHandler
 	cu.Scheduler = scheduler
 }
 
@@ -261,9 +385,14 @@ func (b *Builder) equipSIMDUnits(cu *ComputeUnit) {
 		simdUnit := NewSIMDUnit(cu, name, b.alu)
 		simdUnit.NumSinglePrecisionUnit = b.numSinglePrecisionUnits
 		simdUnit.scoreboardEnabled = b.registerScoreboard
-		if b.registerScoreboard {
+		simdUnit.dualIssue = b.dualIssue
+		if simdUnit.pipelined() {
 			simdUnit.pipelineCapacity = 1
-			simdUnit.pipelineSlots = make([]*simdPipelineSlot, 0, 1)
+			if b.dualIssue {
+				simdUnit.pipelineCapacity = 2
+			}
+			simdUnit.pipelineSlots = make([]*simdPipelineSlot, 0,
+				simdUnit.pipelineCapacity)
 		}
 		if b.enableVisTracing {
 			tracing.CollectTrace(simdUnit, b.visTracer)
@@ -273,11 +402,30 @@ func (b *Builder) equipSIMDUnits(cu *ComputeUnit) {
 	}
 }
 
+func (b *Builder) equipMatrixCoreUnits(cu *ComputeUnit) {
+	matrixDecoder := NewDecodeUnit(cu)
+	cu.MatrixDecoder = matrixDecoder
+	for i := 0; i < b.simdCount; i++ {
+		name := fmt.Sprintf(b.name+".MatrixCore%d", i)
+		matrixCoreUnit := NewMatrixCoreUnit(cu, name, b.alu)
+		matrixCoreUnit.Latencies = b.matrixCoreLatencies
+		if b.enableVisTracing {
+			tracing.CollectTrace(matrixCoreUnit, b.visTracer)
+		}
+		matrixDecoder.AddExecutionUnit(matrixCoreUnit)
+		cu.MatrixCoreUnit = append(cu.MatrixCoreUnit, matrixCoreUnit)
+	}
+}
+
 func (b *Builder) equipLDSUnit(cu *ComputeUnit) {
 	ldsDecoder := NewDecodeUnit(cu)
 	cu.LDSDecoder = ldsDecoder
 
 	ldsUnit := NewLDSUnit(cu, b.alu)
+	ldsUnit.Latency = b.ldsLatency
+	ldsUnit.NumBanks = b.ldsBanks
+	ldsUnit.BankWidth = b.ldsBankWidth
+	ldsUnit.Broadcast = b.ldsBroadcast
 	cu.LDSUnit = ldsUnit
 
 	for i := 0; i < b.simdCount; i++ {
@@ -289,9 +437,12 @@ func (b *Builder) equipVectorMemoryUnit(cu *ComputeUnit) {
 	vectorMemDecoder := NewDecodeUnit(cu)
 	cu.VectorMemDecoder = vectorMemDecoder
 
-	coalescer := &defaultCoalescer{
-		log2CacheLineSize: b.log2CachelineSize,
+	coalescerFactory := b.coalescerFactory
+	if coalescerFactory == nil {
+		coalescerFactory = NewIdealCoalescer
 	}
+
+	coalescer := coalescerFactory(b.log2CachelineSize)
 	vectorMemoryUnit := NewVectorMemoryUnit(cu, coalescer)
 	vectorMemoryUnit.maxCoalescingPenalty = b.maxCoalescingPenalty
 	cu.VectorMemUnit = vectorMemoryUnit
@@ -334,5 +485,10 @@ func (b *Builder) equipRegisterFiles(cu *ComputeUnit) {
 	for i := 0; i < b.simdCount; i++ {
 		vRegFile := NewSimpleRegisterFile(uint64(b.vgprCount[i]*4), 1024)
 		cu.VRegFile = append(cu.VRegFile, vRegFile)
+
+		// AccVGPRs are kept in a separate file with the same layout as the
+		// VGPRs, addressed with the wavefront's VGPR offset.
+		aRegFile := NewSimpleRegisterFile(uint64(b.vgprCount[i]*4), 1024)
+		cu.ARegFile = append(cu.ARegFile, aRegFile)
 	}
 }
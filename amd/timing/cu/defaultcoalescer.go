@@ -1,134 +1,171 @@
 package cu
 
 import (
+	"log"
+
 	"github.com/sarchlab/akita/v4/mem/mem"
+	"github.com/sarchlab/mgpusim/v4/amd/emu"
 	"github.com/sarchlab/mgpusim/v4/amd/insts"
+	"github.com/sarchlab/mgpusim/v4/amd/protocol"
 	"github.com/sarchlab/mgpusim/v4/amd/timing/wavefront"
 )
 
+// A defaultCoalescer combines the accesses to the same cache line into one
+// transaction. Only the lanes in the same group of laneGroupSize lanes are
+// combined, and atomics always get one transaction for each lane. A
+// laneGroupSize of 0 combines all the lanes of the wavefront, whether it has
+// 32 or 64 lanes.
 type defaultCoalescer struct {
 	log2CacheLineSize uint64
+	laneGroupSize     int
 }
 
-func (c defaultCoalescer) generateMemTransactions(
-	wf *wavefront.Wavefront,
-) []VectorMemAccessInfo {
-	c.mustBeAFlatLoadOrStore(wf)
-	var transactions []VectorMemAccessInfo
-	if c.isLoadInst(wf.Inst()) {
-		reqs := c.generateReadReqs(wf)
-		transactions = c.generateReadTransactions(wf, reqs)
-	} else {
-		reqs := c.generateWriteReqs(wf)
-		transactions = c.generateWriteTransactions(wf, reqs)
+// NewIdealCoalescer creates a coalescer that combines all the lanes of an
+// instruction that access the same cache line. It is the default coalescer of
+// the compute units.
+func NewIdealCoalescer(log2CacheLineSize uint64) Coalescer {
+	return &defaultCoalescer{
+		log2CacheLineSize: log2CacheLineSize,
+		laneGroupSize:     0,
 	}
-	return transactions
 }
 
-func (c defaultCoalescer) mustBeAFlatLoadOrStore(
-	wf *wavefront.Wavefront,
-) {
-	if wf.Inst().FormatType != insts.FLAT {
-		panic("must be a flat instruction")
+// NewQuarterWaveCoalescer creates a coalescer that only combines the lanes in
+// the same quarter of the wavefront, as the GCN hardware processes 16 lanes
+// per cycle.
+func NewQuarterWaveCoalescer(log2CacheLineSize uint64) Coalescer {
+	return &defaultCoalescer{
+		log2CacheLineSize: log2CacheLineSize,
+		laneGroupSize:     16,
 	}
+}
 
-	if wf.Inst().Opcode < 16 || wf.Inst().Opcode > 31 {
-		panic("must be a load or store instruction")
+// NewNoCoalescer creates a coalescer that gives each lane its own
+// transactions. It is a baseline for the studies of memory divergence.
+func NewNoCoalescer(log2CacheLineSize uint64) Coalescer {
+	return &defaultCoalescer{
+		log2CacheLineSize: log2CacheLineSize,
+		laneGroupSize:     1,
 	}
 }
 
-func (c defaultCoalescer) generateReadReqs(
+func (c defaultCoalescer) GenerateMemTransactions(
 	wf *wavefront.Wavefront,
-) []*mem.ReadReq {
-	exec := wf.EXEC()
-	inst := wf.Inst()
-	reqs := []*mem.ReadReq{}
-	regCount := c.instRegCount(inst)
+) []VectorMemAccessInfo {
+	if _, ok := wf.Inst().BufferOp(); ok {
+		return c.generateBufferTransactions(wf)
+	}
 
-	for i := uint(0); i < 64; i++ {
-		if !laneMasked(exec, i) {
-			continue
-		}
+	if emu.AccessesScratch(wf) {
+		return c.generateScratchTransactions(wf)
+	}
 
-		addr := c.readFlatAddr(wf, int(i))
-		for j := 0; j < regCount; j++ {
-			c.findOrCreateReadReq(&reqs, addr+uint64(4*j))
-		}
+	if _, ok := wf.Inst().AtomicInfo(); ok {
+		return c.generateAtomicTransactions(wf)
 	}
 
-	return reqs
+	return c.generateFlatTransactions(wf)
 }
 
-func (c defaultCoalescer) generateWriteReqs(
+// generateFlatTransactions creates the memory transactions of a FLAT or a
+// global load or store.
+func (c defaultCoalescer) generateFlatTransactions(
 	wf *wavefront.Wavefront,
-) []*mem.WriteReq {
-	exec := wf.EXEC()
-	inst := wf.Inst()
-	reqs := []*mem.WriteReq{}
+) []VectorMemAccessInfo {
+	op, ok := wf.Inst().FlatOp()
+	if !ok {
+		log.Panicf("Opcode %d for FLAT instructions is not supported",
+			wf.Inst().Opcode)
+	}
 
-	for i := uint(0); i < 64; i++ {
-		if !laneMasked(exec, i) {
-			continue
-		}
+	return c.generateComponentTransactions(wf, op, emu.FlatComponents(wf.Inst()),
+		func(laneID int, offset, _ uint64) (uint64, bool) {
+			return c.readFlatAddr(wf, laneID) + offset, true
+		})
+}
 
-		addr := c.readFlatAddr(wf, int(i))
-		regCount := uint(c.instRegCount(inst))
-		for j := uint(0); j < regCount; j++ {
-			dataReg := insts.NewVRegOperand(
-				inst.Data.Register.RegIndex()+int(j),
-				inst.Data.Register.RegIndex()+int(j),
-				1,
-			)
-			dataVal := uint32(wf.ReadOperand(dataReg, int(i)))
-			c.findOrCreateWriteReq(&reqs, addr+uint64(j*4),
-				insts.Uint32ToBytes(dataVal))
-		}
+// firstReqOfGroup returns the number of requests that the lanes before the
+// group of the lane generate. The lane can only be combined with the requests
+// after them.
+func (c defaultCoalescer) firstReqOfGroup(
+	laneID uint,
+	numReqs int,
+	groupFirstReq *int,
+) int {
+	if laneID == 0 ||
+		(c.laneGroupSize > 0 && int(laneID)%c.laneGroupSize == 0) {
+		*groupFirstReq = numReqs
 	}
 
-	return reqs
+	return *groupFirstReq
 }
 
-func (c defaultCoalescer) generateReadTransactions(
+func (c defaultCoalescer) generateWriteTransactions(
 	wf *wavefront.Wavefront,
-	reqs []*mem.ReadReq,
+	reqs []*mem.WriteReq,
 ) []VectorMemAccessInfo {
 	transactions := []VectorMemAccessInfo{}
 	for _, req := range reqs {
 		transaction := VectorMemAccessInfo{
-			Read:      req,
+			Write:     req,
 			Wavefront: wf,
 			Inst:      wf.DynamicInst(),
 		}
 
-		c.addLaneInfo(&transaction, wf)
-
 		transactions = append(transactions, transaction)
 	}
 	return transactions
 }
 
-func (c defaultCoalescer) generateWriteTransactions(
+// generateAtomicTransactions creates a request for each active lane, as the
+// lanes that access the same address cannot be combined into one operation.
+func (c defaultCoalescer) generateAtomicTransactions(
 	wf *wavefront.Wavefront,
-	reqs []*mem.WriteReq,
 ) []VectorMemAccessInfo {
+	exec := wf.EXEC()
+	inst := wf.Inst()
+	atomic, _ := inst.AtomicInfo()
 	transactions := []VectorMemAccessInfo{}
-	for _, req := range reqs {
+
+	for i := uint(0); i < uint(wf.LaneCount()); i++ {
+		if !laneMasked(exec, i) {
+			continue
+		}
+
+		src, cmp := emu.FlatAtomicOperands(wf, atomic, int(i))
+		req := protocol.AtomicReqBuilder{}.
+			WithAddress(c.readFlatAddr(wf, int(i))).
+			WithAtomic(atomic).
+			WithValue(src).
+			WithCmp(cmp).
+			Build()
+
 		transaction := VectorMemAccessInfo{
-			Write:     req,
+			Atomic:    req,
 			Wavefront: wf,
 			Inst:      wf.DynamicInst(),
 		}
 
+		if atomic.Return {
+			transaction.laneInfo = []vectorMemAccessLaneInfo{{
+				laneID:   int(i),
+				reg:      inst.Dst.Register,
+				regCount: atomic.ByteSize / 4,
+			}}
+		}
+
 		transactions = append(transactions, transaction)
 	}
+
 	return transactions
 }
 
 func (c defaultCoalescer) findOrCreateReadReq(
 	reqs *[]*mem.ReadReq,
+	first int,
 	addr uint64,
 ) *mem.ReadReq {
-	for _, req := range *reqs {
+	for _, req := range (*reqs)[first:] {
 		if c.isInSameCacheLine(addr, req.Address) {
 			return req
 		}
@@ -144,10 +181,11 @@ func (c defaultCoalescer) findOrCreateReadReq(
 
 func (c defaultCoalescer) findOrCreateWriteReq(
 	reqs *[]*mem.WriteReq,
+	first int,
 	addr uint64,
 	data []byte,
 ) *mem.WriteReq {
-	for _, req := range *reqs {
+	for _, req := range (*reqs)[first:] {
 		if c.isInSameCacheLine(addr, req.Address) {
 			c.mergeDataWithReq(req, addr, data)
 			return req
@@ -193,37 +231,6 @@ func (c defaultCoalescer) addressRangeMustFallInReq(
 	}
 }
 
-func (c defaultCoalescer) addLaneInfo(
-	transaction *VectorMemAccessInfo,
-	wf *wavefront.Wavefront,
-) {
-	exec := wf.EXEC()
-	inst := wf.Inst()
-	req := transaction.Read
-	regCount := c.instRegCount(inst)
-
-	for i := uint(0); i < 64; i++ {
-		if !laneMasked(exec, i) {
-			continue
-		}
-
-		for j := 0; j < regCount; j++ {
-			addr := c.readFlatAddr(wf, int(i)) + uint64(j*4)
-			reg := insts.VReg(inst.Dst.Register.RegIndex() + j)
-			if c.isInSameCacheLine(addr, req.Address) {
-				laneInfo := vectorMemAccessLaneInfo{
-					laneID:                int(i),
-					reg:                   reg,
-					regCount:              1,
-					addrOffsetInCacheLine: c.addrOffsetInCacheLine(addr),
-				}
-				transaction.laneInfo = append(
-					transaction.laneInfo, laneInfo)
-			}
-		}
-	}
-}
-
 func (c defaultCoalescer) readFlatAddr(
 	wf *wavefront.Wavefront,
 	laneID int,
@@ -273,27 +280,6 @@ func (c defaultCoalescer) addrOffsetInCacheLine(addr uint64) uint64 {
 	return addr & ((1 << c.log2CacheLineSize) - 1)
 }
 
-func (c defaultCoalescer) isLoadInst(inst *insts.Inst) bool {
-	return inst.Opcode >= 6 && inst.Opcode <= 23
-}
-
-func (c defaultCoalescer) instRegCount(inst *insts.Inst) int {
-	switch inst.Opcode {
-	case 16, 17, 18, 19, 20:
-		return 1
-	case 24, 25, 26, 27, 28:
-		return 1
-	case 21, 29:
-		return 2
-	case 22, 30:
-		return 3
-	case 23, 31:
-		return 4
-	default:
-		panic("not supported opcode")
-	}
-}
-
 func laneMasked(exec uint64, laneID uint) bool {
 	return exec&(1<<laneID) > 0
 }
@@ -4,6 +4,7 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/sarchlab/mgpusim/v4/amd/insts"
+	"github.com/sarchlab/mgpusim/v4/amd/kernels"
 	"github.com/sarchlab/mgpusim/v4/amd/timing/wavefront"
 )
 
@@ -18,6 +19,7 @@ var _ = Describe("Default Coalescer", func() {
 		wf = wavefront.NewWavefront(nil)
 		c = defaultCoalescer{
 			log2CacheLineSize: 6,
+			laneGroupSize:     0,
 		}
 		regAccessor = newMockRegFileAccessor()
 		wf.RegAccessor = regAccessor
@@ -39,7 +41,7 @@ var _ = Describe("Default Coalescer", func() {
 				insts.Uint64ToBytes(0x1000)[:8])
 		}
 
-		memTransactions := c.generateMemTransactions(wf)
+		memTransactions := c.GenerateMemTransactions(wf)
 
 		Expect(memTransactions).To(HaveLen(1))
 		Expect(memTransactions[0].laneInfo).To(HaveLen(64))
@@ -60,7 +62,7 @@ var _ = Describe("Default Coalescer", func() {
 				insts.Uint64ToBytes(uint64(0x1000+i*4))[:8])
 		}
 
-		memTransactions := c.generateMemTransactions(wf)
+		memTransactions := c.GenerateMemTransactions(wf)
 
 		Expect(memTransactions).To(HaveLen(4))
 		Expect(memTransactions[0].laneInfo).To(HaveLen(16))
@@ -84,7 +86,7 @@ var _ = Describe("Default Coalescer", func() {
 				insts.Uint64ToBytes(uint64(0x1004+i*4))[:8])
 		}
 
-		memTransactions := c.generateMemTransactions(wf)
+		memTransactions := c.GenerateMemTransactions(wf)
 
 		Expect(memTransactions).To(HaveLen(5))
 		Expect(memTransactions[0].laneInfo).To(HaveLen(29))
@@ -94,6 +96,109 @@ var _ = Describe("Default Coalescer", func() {
 		Expect(memTransactions[4].laneInfo).To(HaveLen(3))
 	})
 
+	It("should only coalesce the lanes in the same quarter-wave", func() {
+		c.laneGroupSize = 16
+
+		inst := insts.NewInst()
+		inst.FormatType = insts.FLAT
+		inst.Opcode = 20 // flat_load_dword
+		inst.Dst = insts.NewVRegOperand(0, 0, 1)
+		inst.Addr = insts.NewVRegOperand(2, 2, 2)
+		wf.SetDynamicInst(wavefront.NewInst(inst))
+		wf.SetEXEC(0xffffffffffffffff)
+
+		for i := 0; i < 64; i++ {
+			addrReg := insts.VReg(2)
+			regAccessor.setRegValue(addrReg, 2, i, wf.VRegOffset,
+				insts.Uint64ToBytes(0x1000)[:8])
+		}
+
+		memTransactions := c.GenerateMemTransactions(wf)
+
+		Expect(memTransactions).To(HaveLen(4))
+		for i, t := range memTransactions {
+			Expect(t.Read.Address).To(Equal(uint64(0x1000)))
+			Expect(t.laneInfo).To(HaveLen(16))
+			Expect(t.laneInfo[0].laneID).To(Equal(16 * i))
+		}
+	})
+
+	It("should not coalesce if each lane is a group", func() {
+		c.laneGroupSize = 1
+
+		inst := insts.NewInst()
+		inst.FormatType = insts.FLAT
+		inst.Opcode = 20 // flat_load_dword
+		inst.Dst = insts.NewVRegOperand(0, 0, 1)
+		inst.Addr = insts.NewVRegOperand(2, 2, 2)
+		wf.SetDynamicInst(wavefront.NewInst(inst))
+		wf.SetEXEC(0xffffffffffffffff)
+
+		for i := 0; i < 64; i++ {
+			addrReg := insts.VReg(2)
+			regAccessor.setRegValue(addrReg, 2, i, wf.VRegOffset,
+				insts.Uint64ToBytes(uint64(0x1000+i*4))[:8])
+		}
+
+		memTransactions := c.GenerateMemTransactions(wf)
+
+		Expect(memTransactions).To(HaveLen(64))
+		for i, t := range memTransactions {
+			Expect(t.laneInfo).To(HaveLen(1))
+			Expect(t.laneInfo[0].laneID).To(Equal(i))
+		}
+	})
+
+	It("should coalesce all the lanes of a wave32 wavefront", func() {
+		wf = wavefront.NewWavefront(&kernels.Wavefront{WavefrontSize: 32})
+		wf.RegAccessor = regAccessor
+
+		inst := insts.NewInst()
+		inst.FormatType = insts.FLAT
+		inst.Opcode = 20 // flat_load_dword
+		inst.Dst = insts.NewVRegOperand(0, 0, 1)
+		inst.Addr = insts.NewVRegOperand(2, 2, 2)
+		wf.SetDynamicInst(wavefront.NewInst(inst))
+		wf.SetEXEC(0xffffffffffffffff)
+
+		for i := 0; i < 32; i++ {
+			addrReg := insts.VReg(2)
+			regAccessor.setRegValue(addrReg, 2, i, wf.VRegOffset,
+				insts.Uint64ToBytes(uint64(0x1000+i*2))[:8])
+		}
+
+		memTransactions := c.GenerateMemTransactions(wf)
+
+		Expect(memTransactions).To(HaveLen(1))
+		Expect(memTransactions[0].laneInfo).To(HaveLen(32))
+		Expect(memTransactions[0].laneInfo[31].laneID).To(Equal(31))
+	})
+
+	It("should coalesce a global byte load with a scalar base", func() {
+		inst := insts.NewInst()
+		inst.FormatType = insts.FLAT
+		inst.Seg = insts.FlatSegmentGlobal
+		inst.Opcode = 16 // global_load_ubyte
+		inst.Dst = insts.NewVRegOperand(0, 0, 1)
+		inst.Addr = insts.NewVRegOperand(2, 2, 1)
+		inst.SAddr = insts.NewIntOperand(4, 4)
+		wf.SetDynamicInst(wavefront.NewInst(inst))
+		wf.SetEXEC(0xffffffffffffffff)
+
+		regAccessor.setRegValue(insts.SReg(4), 2, 0, wf.SRegOffset,
+			insts.Uint64ToBytes(0x2000))
+		for i := 0; i < 64; i++ {
+			regAccessor.setRegValue(insts.VReg(2), 1, i, wf.VRegOffset,
+				insts.Uint32ToBytes(uint32(i)))
+		}
+
+		memTransactions := c.GenerateMemTransactions(wf)
+
+		Expect(memTransactions).To(HaveLen(1))
+		Expect(memTransactions[0].Read.Address).To(Equal(uint64(0x2000)))
+		Expect(memTransactions[0].laneInfo).To(HaveLen(64))
+	})
+
 	It("should coalesce store instructions", func() {
 		inst := insts.NewInst()
 		inst.FormatType = insts.FLAT
@@ -113,8 +218,112 @@ var _ = Describe("Default Coalescer", func() {
 				insts.Uint32ToBytes(1))
 		}
 
-		memTransactions := c.generateMemTransactions(wf)
+		memTransactions := c.GenerateMemTransactions(wf)
+
+		Expect(memTransactions).To(HaveLen(4))
+	})
+
+	It("should generate a request for each lane of an atomic", func() {
+		inst := insts.NewInst()
+		inst.FormatType = insts.FLAT
+		inst.Opcode = 65 // flat_atomic_cmpswap
+		inst.GlobalLevelCoherent = true
+		inst.Dst = insts.NewVRegOperand(0, 0, 1)
+		inst.Addr = insts.NewVRegOperand(2, 2, 2)
+		inst.Data = insts.NewVRegOperand(4, 4, 2)
+		wf.SetDynamicInst(wavefront.NewInst(inst))
+		wf.SetEXEC(0x5)
+
+		for i := 0; i < 3; i++ {
+			addrReg := insts.VReg(2)
+			regAccessor.setRegValue(addrReg, 2, i, wf.VRegOffset,
+				insts.Uint64ToBytes(0x1000)[:8])
+
+			dataReg := insts.VReg(4)
+			data := append(insts.Uint32ToBytes(uint32(i)),
+				insts.Uint32ToBytes(7)...)
+			regAccessor.setRegValue(dataReg, 2, i, wf.VRegOffset, data)
+		}
+
+		memTransactions := c.GenerateMemTransactions(wf)
+
+		Expect(memTransactions).To(HaveLen(2))
+		Expect(memTransactions[0].Read).To(BeNil())
+		Expect(memTransactions[1].Atomic.Address).To(Equal(uint64(0x1000)))
+		Expect(memTransactions[1].Atomic.Value).To(Equal(uint64(2)))
+		Expect(memTransactions[1].Atomic.Cmp).To(Equal(uint64(7)))
+		Expect(memTransactions[1].laneInfo).To(HaveLen(1))
+		Expect(memTransactions[1].laneInfo[0].laneID).To(Equal(2))
+	})
+
+	It("should read the components of a buffer load in range", func() {
+		inst := insts.NewInst()
+		inst.FormatType = insts.MUBUF
+		inst.Opcode = 20 // buffer_load_dword
+		inst.OffsetEnable = true
+		inst.Dst = insts.NewVRegOperand(0, 0, 1)
+		inst.Addr = insts.NewVRegOperand(2, 2, 1)
+		inst.Base = insts.NewSRegOperand(4, 4, 4)
+		inst.Offset = insts.NewIntOperand(128, 0)
+		wf.SetDynamicInst(wavefront.NewInst(inst))
+		wf.SetEXEC(0xffffffffffffffff)
+
+		// A raw buffer at 0x1000 with 128 bytes.
+		rsrc := append(insts.Uint64ToBytes(0x1000),
+			insts.Uint64ToBytes(128)...)
+		regAccessor.setRegValue(insts.SReg(4), 4, 0, wf.SRegOffset, rsrc)
+
+		for i := 0; i < 64; i++ {
+			addrReg := insts.VReg(2)
+			regAccessor.setRegValue(addrReg, 1, i, wf.VRegOffset,
+				insts.Uint32ToBytes(uint32(4*i)))
+		}
+
+		memTransactions := c.GenerateMemTransactions(wf)
+
+		// Only the first 32 lanes are in range.
+		Expect(memTransactions).To(HaveLen(2))
+		Expect(memTransactions[0].Read.Address).To(Equal(uint64(0x1000)))
+		Expect(memTransactions[1].Read.Address).To(Equal(uint64(0x1040)))
+		for _, t := range memTransactions {
+			Expect(t.laneInfo).To(HaveLen(16))
+			for _, laneInfo := range t.laneInfo {
+				Expect(laneInfo.bufferComponent).NotTo(BeNil())
+			}
+		}
+	})
+
+	It("should swizzle the lanes of a scratch load", func() {
+		wf = wavefront.NewWavefront(&kernels.Wavefront{
+			ScratchAddress:    0x10000,
+			ScratchByteSize:   0x1000,
+			ScratchWaveOffset: 0x400,
+		})
+		wf.RegAccessor = regAccessor
+
+		inst := insts.NewInst()
+		inst.FormatType = insts.FLAT
+		inst.Seg = insts.FlatSegmentScratch
+		inst.Opcode = 20 // scratch_load_dword
+		inst.Dst = insts.NewVRegOperand(0, 0, 1)
+		inst.Addr = insts.NewVRegOperand(2, 2, 1)
+		inst.SAddr = insts.NewIntOperand(0, 0x7f)
+		wf.SetDynamicInst(wavefront.NewInst(inst))
+		wf.SetEXEC(0xffffffffffffffff)
+
+		// All the lanes read their own copy of the same private variable.
+		for i := 0; i < 64; i++ {
+			regAccessor.setRegValue(insts.VReg(2), 1, i, wf.VRegOffset,
+				insts.Uint32ToBytes(8))
+		}
+
+		memTransactions := c.GenerateMemTransactions(wf)
 
 		Expect(memTransactions).To(HaveLen(4))
+		for i, t := range memTransactions {
+			Expect(t.Read.Address).To(Equal(uint64(0x10600 + 64*i)))
+			Expect(t.laneInfo).To(HaveLen(16))
+			Expect(t.laneInfo[0].laneID).To(Equal(16 * i))
+		}
 	})
 })
@@ -11,12 +11,34 @@ import (
 // instructions
 type FetchArbiter struct {
 	InstBufByteSize int
+
+	policy     IssuePolicy
+	lastSIMDID int
+	arbitrated []int
+}
+
+// NewFetchArbiterWithPolicy returns a newly created FetchArbiter that takes
+// the SIMDs in turns and fetches for the wavefront that the policy gives the
+// highest priority in the SIMD.
+func NewFetchArbiterWithPolicy(
+	instBufByteSize int,
+	policy IssuePolicy,
+) *FetchArbiter {
+	a := new(FetchArbiter)
+	a.InstBufByteSize = instBufByteSize
+	a.policy = policy
+	return a
 }
 
-// Arbitrate decide which wavefront can fetch the next instruction
+// Arbitrate decide which wavefront can fetch the next instruction. Without a
+// policy, the wavefront that fetched the least recently fetches.
 func (a *FetchArbiter) Arbitrate(
 	wfPools []*WavefrontPool,
 ) []*wavefront.Wavefront {
+	if a.policy != nil {
+		return a.arbitrateWithPolicy(wfPools)
+	}
+
 	list := make([]*wavefront.Wavefront, 0, 1)
 
 	oldestTime := sim.VTimeInSec(math.MaxFloat64)
@@ -43,6 +65,40 @@ func (a *FetchArbiter) Arbitrate(
 	return list
 }
 
+func (a *FetchArbiter) arbitrateWithPolicy(
+	wfPools []*WavefrontPool,
+) []*wavefront.Wavefront {
+	a.arbitrated = a.arbitrated[:0]
+
+	for i := 0; i < len(wfPools); i++ {
+		simdID := (a.lastSIMDID + i) % len(wfPools)
+		a.arbitrated = append(a.arbitrated, simdID)
+
+		for _, wf := range a.policy.Order(simdID, wfPools[simdID].wfs) {
+			wf.RLock()
+			canFetch := a.canFetchFromWF(wf)
+			wf.RUnlock()
+
+			if canFetch {
+				a.lastSIMDID = (simdID + 1) % len(wfPools)
+				return []*wavefront.Wavefront{wf}
+			}
+		}
+	}
+
+	return []*wavefront.Wavefront{}
+}
+
+// Acted tells the policy the wavefronts that send the fetch requests.
+func (a *FetchArbiter) Acted(wfs []*wavefront.Wavefront) {
+	if a.policy == nil {
+		return
+	}
+
+	reportActed(a.policy, a.arbitrated, wfs)
+	a.arbitrated = a.arbitrated[:0]
+}
+
 func (a *FetchArbiter) canFetchFromWF(wf *wavefront.Wavefront) bool {
 	if wf.IsFetching {
 		return false
@@ -50,4 +50,37 @@ var _ = Describe("FetchArbiter", func() {
 		Expect(len(wfs)).To(Equal(1))
 		Expect(wfs[0].LastFetchTime).To(Equal(sim.VTimeInSec(9.5)))
 	})
+
+	It("should take the SIMDs in turns with a policy", func() {
+		policy, err := NewIssuePolicy("lrr")
+		Expect(err).NotTo(HaveOccurred())
+		arbiter = NewFetchArbiterWithPolicy(256, policy)
+
+		wfs := make([]*wavefront.Wavefront, 0)
+		for i := 0; i < 4; i++ {
+			wf := new(wavefront.Wavefront)
+			wf.Wavefront = new(kernels.Wavefront)
+			wf.SIMDID = i % 2
+			wf.State = wavefront.WfReady
+			wfPools[wf.SIMDID].AddWf(wf)
+			wfs = append(wfs, wf)
+		}
+		wfs[2].InstBuffer = make([]byte, arbiter.InstBufByteSize)
+
+		fetch := func() []*wavefront.Wavefront {
+			selected := arbiter.Arbitrate(wfPools)
+			arbiter.Acted(selected)
+			return selected
+		}
+
+		Expect(fetch()).To(Equal(wfs[0:1]))
+		Expect(fetch()).To(Equal(wfs[1:2]))
+		Expect(fetch()).To(Equal(wfs[0:1]))
+
+		// A wavefront that fails to fetch keeps the highest priority.
+		Expect(arbiter.Arbitrate(wfPools)).To(Equal(wfs[3:4]))
+		arbiter.Acted(nil)
+		Expect(fetch()).To(Equal(wfs[0:1]))
+		Expect(fetch()).To(Equal(wfs[3:4]))
+	})
 })
@@ -8,6 +8,7 @@ import (
 	"github.com/sarchlab/akita/v4/sim"
 	"github.com/sarchlab/akita/v4/tracing"
 	"github.com/sarchlab/mgpusim/v4/amd/insts"
+	"github.com/sarchlab/mgpusim/v4/amd/kernels"
 	"github.com/sarchlab/mgpusim/v4/amd/timing/wavefront"
 	"github.com/tebeka/atexit"
 )
@@ -20,6 +21,10 @@ type ISADebugger struct {
 	cu            *ComputeUnit
 	executingInst map[string]tracing.Task
 	// prevWf *Wavefront
+
+	// WGFilter selects the work-groups whose wavefronts are logged. The
+	// wavefronts of all the work-groups are logged if it is nil.
+	WGFilter kernels.WGFilterFunc
 }
 
 // NewISADebugger returns a new ISADebugger that keeps instruction log in logger
@@ -49,6 +54,10 @@ func (h *ISADebugger) StartTask(task tracing.Task) {
 		return
 	}
 
+	if h.WGFilter != nil && !h.WGFilter(wf.Packet, wf.WG.WorkGroup) {
+		return
+	}
+
 	h.executingInst[task.ID] = task
 }
 
@@ -74,9 +83,7 @@ func (h *ISADebugger) EndTask(task tracing.Task) {
 	wf := detail["wf"].(*wavefront.Wavefront)
 	inst := detail["inst"].(*wavefront.Inst).Inst
 
-	if wf.WG.IDX == 75 && wf.WG.IDY == 1 {
-		h.logWholeWf(inst, wf)
-	}
+	h.logWholeWf(inst, wf)
 
 	delete(h.executingInst, task.ID)
 }
@@ -1,25 +1,48 @@
 package cu
 
-import "github.com/sarchlab/mgpusim/v4/amd/timing/wavefront"
+import (
+	"sort"
+
+	"github.com/sarchlab/mgpusim/v4/amd/insts"
+	"github.com/sarchlab/mgpusim/v4/amd/timing/wavefront"
+)
 
 // An IssueArbiter decides which wavefront can issue instruction
 type IssueArbiter struct {
 	lastSIMDID        int
 	scoreboardEnabled bool
+
+	// valuIssueWidth is the number of VALU instructions that each SIMD can
+	// issue in a cycle. Zero means 1.
+	valuIssueWidth int
+
+	policy     IssuePolicy
+	arbitrated []int
 }
 
-// NewIssueArbiter returns a newly created IssueArbiter
+// NewIssueArbiter returns a newly created IssueArbiter that issues from the
+// oldest wavefronts first.
 func NewIssueArbiter() *IssueArbiter {
+	return NewIssueArbiterWithPolicy(oldestFirstPolicy{})
+}
+
+// NewIssueArbiterWithPolicy returns a newly created IssueArbiter that orders
+// the wavefronts in each SIMD with the policy.
+func NewIssueArbiterWithPolicy(policy IssuePolicy) *IssueArbiter {
 	a := new(IssueArbiter)
 	a.lastSIMDID = 0
+	a.policy = policy
 	return a
 }
 
 // Arbitrate will take a round-robin fashion at SIMD level. For wavefronts
-// in each SIMD, oldest first.
+// in each SIMD, the wavefronts with a higher s_setprio priority issue first,
+// and the issue policy decides the priority among the others.
 func (a *IssueArbiter) Arbitrate(
 	wfPools []*WavefrontPool,
 ) []*wavefront.Wavefront {
+	a.arbitrated = a.arbitrated[:0]
+
 	if a.isAllWfPoolsEmpty(wfPools) {
 		return []*wavefront.Wavefront{}
 	}
@@ -27,10 +50,12 @@ func (a *IssueArbiter) Arbitrate(
 	wfToIssue := make([]*wavefront.Wavefront, 0)
 	for i := 0; i < len(wfPools); i++ {
 		simdID := (a.lastSIMDID + i) % len(wfPools)
+		a.arbitrated = append(a.arbitrated, simdID)
 
-		typeMask := make([]bool, 7)
+		issued := make([]int, 8)
+		selected := make([]*wavefront.Wavefront, 0)
 		wfPool := wfPools[simdID]
-		for _, wf := range wfPool.wfs {
+		for _, wf := range prioritize(a.policy.Order(simdID, wfPool.wfs)) {
 			if wf.State != wavefront.WfReady || wf.InstToIssue == nil {
 				continue
 			}
@@ -42,11 +67,14 @@ func (a *IssueArbiter) Arbitrate(
 				}
 			}
 
-			if typeMask[wf.InstToIssue.ExeUnit] == false {
-				wfToIssue = append(wfToIssue, wf)
-				typeMask[wf.InstToIssue.ExeUnit] = true
+			unit := wf.InstToIssue.ExeUnit
+			if issued[unit] < a.issueWidth(unit) {
+				selected = append(selected, wf)
+				issued[unit]++
 			}
 		}
+
+		wfToIssue = append(wfToIssue, selected...)
 	}
 
 	a.lastSIMDID = (a.lastSIMDID + 1) % len(wfPools)
@@ -54,6 +82,47 @@ func (a *IssueArbiter) Arbitrate(
 	return wfToIssue
 }
 
+// Acted tells the issue policy the wavefronts that issue instructions, since
+// the scheduler cannot issue the wavefronts whose execution unit is busy.
+func (a *IssueArbiter) Acted(wfs []*wavefront.Wavefront) {
+	reportActed(a.policy, a.arbitrated, wfs)
+	a.arbitrated = a.arbitrated[:0]
+}
+
+// prioritize moves the wavefronts with a higher s_setprio priority to the
+// front, keeping the order of the wavefronts with the same priority.
+func prioritize(wfs []*wavefront.Wavefront) []*wavefront.Wavefront {
+	allDefault := true
+	for _, wf := range wfs {
+		if wf.Priority != 0 {
+			allDefault = false
+			break
+		}
+	}
+
+	if allDefault {
+		return wfs
+	}
+
+	ordered := make([]*wavefront.Wavefront, len(wfs))
+	copy(ordered, wfs)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].Priority > ordered[j].Priority
+	})
+
+	return ordered
+}
+
+// issueWidth returns the number of instructions that each SIMD can issue to
+// an execution unit in a cycle.
+func (a *IssueArbiter) issueWidth(unit insts.ExeUnit) int {
+	if unit == insts.ExeUnitVALU && a.valuIssueWidth > 1 {
+		return a.valuIssueWidth
+	}
+
+	return 1
+}
+
 func (a *IssueArbiter) moveToNextSIMD(wfPools []*WavefrontPool) {
 	a.lastSIMDID++
 	if a.lastSIMDID >= len(wfPools) {
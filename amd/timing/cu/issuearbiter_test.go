@@ -100,4 +100,42 @@ var _ = Describe("IssueArbiter", func() {
 		Expect(issueCandidate).To(ContainElement(BeIdenticalTo(wf2)))
 		Expect(issueCandidate).To(ContainElement(BeIdenticalTo(wf3)))
 	})
+
+	It("should issue two VALU instructions per SIMD when dual-issuing", func() {
+		arbiter.valuIssueWidth = 2
+
+		wfs := make([]*wavefront.Wavefront, 0)
+		for i := 0; i < 3; i++ {
+			wf := new(wavefront.Wavefront)
+			wf.State = wavefront.WfReady
+			wf.InstToIssue = wavefront.NewInst(insts.NewInst())
+			wf.InstToIssue.ExeUnit = insts.ExeUnitVALU
+			wfs = append(wfs, wf)
+			wfPools[0].AddWf(wf)
+		}
+
+		issueCandidate := arbiter.Arbitrate(wfPools)
+
+		Expect(issueCandidate).To(HaveLen(2))
+		Expect(issueCandidate).To(ContainElement(BeIdenticalTo(wfs[0])))
+		Expect(issueCandidate).To(ContainElement(BeIdenticalTo(wfs[1])))
+	})
+
+	It("should issue from the wavefront with a higher priority first", func() {
+		wfs := make([]*wavefront.Wavefront, 0)
+		for i := 0; i < 3; i++ {
+			wf := new(wavefront.Wavefront)
+			wf.State = wavefront.WfReady
+			wf.InstToIssue = wavefront.NewInst(insts.NewInst())
+			wf.InstToIssue.ExeUnit = insts.ExeUnitVALU
+			wfs = append(wfs, wf)
+			wfPools[0].AddWf(wf)
+		}
+		wfs[2].Priority = 1
+
+		issueCandidate := arbiter.Arbitrate(wfPools)
+
+		Expect(issueCandidate).To(HaveLen(1))
+		Expect(issueCandidate[0]).To(BeIdenticalTo(wfs[2]))
+	})
 })
@@ -0,0 +1,310 @@
+package cu
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/sarchlab/mgpusim/v4/amd/timing/wavefront"
+)
+
+// An IssuePolicy decides the priority of the wavefronts in a SIMD when the
+// issue arbiter selects the wavefronts to issue instructions, or when the fetch
+// arbiter selects the wavefront to fetch instructions.
+type IssuePolicy interface {
+	// Order returns the wavefronts in the wavefront pool of the SIMD that can
+	// compete for the issue slots, from the highest priority to the lowest.
+	// The wavefronts that are not returned cannot issue in the cycle.
+	Order(simdID int, wfs []*wavefront.Wavefront) []*wavefront.Wavefront
+
+	// Selected tells the policy the wavefronts of the SIMD that the arbiter
+	// selects and that issue or fetch in the cycle.
+	Selected(simdID int, wfs []*wavefront.Wavefront)
+}
+
+// An IssuePolicyFactory creates a new IssuePolicy for a compute unit.
+type IssuePolicyFactory func() IssuePolicy
+
+// DefaultIssuePolicy is the name of the policy that the compute units use if
+// no policy is selected.
+const DefaultIssuePolicy = "oldest"
+
+var issuePolicies = map[string]IssuePolicyFactory{
+	"oldest": func() IssuePolicy {
+		return oldestFirstPolicy{}
+	},
+	"gto": func() IssuePolicy {
+		return &greedyThenOldestPolicy{}
+	},
+	"lrr": func() IssuePolicy {
+		return &looseRoundRobinPolicy{}
+	},
+	"two-level": func() IssuePolicy {
+		return &twoLevelPolicy{activeSetSize: 4}
+	},
+	"criticality": func() IssuePolicy {
+		return &criticalityAwarePolicy{}
+	},
+}
+
+// RegisterIssuePolicy adds an issue policy that the compute units can select
+// by name. Registering a name twice replaces the earlier policy.
+func RegisterIssuePolicy(name string, factory IssuePolicyFactory) {
+	issuePolicies[name] = factory
+}
+
+// IssuePolicyNames returns the names of the registered issue policies in
+// alphabetical order.
+func IssuePolicyNames() []string {
+	names := make([]string, 0, len(issuePolicies))
+	for name := range issuePolicies {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// NewIssuePolicy creates the issue policy that is registered with the name.
+func NewIssuePolicy(name string) (IssuePolicy, error) {
+	factory, ok := issuePolicies[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown issue policy %s", name)
+	}
+
+	return factory(), nil
+}
+
+// oldestFirstPolicy gives the wavefronts that arrive at the SIMD earlier a
+// higher priority.
+type oldestFirstPolicy struct{}
+
+func (oldestFirstPolicy) Order(
+	_ int,
+	wfs []*wavefront.Wavefront,
+) []*wavefront.Wavefront {
+	return wfs
+}
+
+func (oldestFirstPolicy) Selected(int, []*wavefront.Wavefront) {}
+
+// greedyThenOldestPolicy keeps issuing from the same wavefront until it
+// cannot issue, and then switches to the oldest wavefront that can.
+type greedyThenOldestPolicy struct {
+	greedy []*wavefront.Wavefront
+}
+
+func (p *greedyThenOldestPolicy) Order(
+	simdID int,
+	wfs []*wavefront.Wavefront,
+) []*wavefront.Wavefront {
+	greedy := wfOfSIMD(p.greedy, simdID)
+	if greedy == nil || !containsWf(wfs, greedy) {
+		return wfs
+	}
+
+	ordered := make([]*wavefront.Wavefront, 0, len(wfs))
+	ordered = append(ordered, greedy)
+	for _, wf := range wfs {
+		if wf != greedy {
+			ordered = append(ordered, wf)
+		}
+	}
+
+	return ordered
+}
+
+func (p *greedyThenOldestPolicy) Selected(
+	simdID int,
+	wfs []*wavefront.Wavefront,
+) {
+	if len(wfs) == 0 {
+		return
+	}
+
+	greedy := wfOfSIMD(p.greedy, simdID)
+	for _, wf := range wfs {
+		if wf == greedy {
+			return
+		}
+	}
+
+	p.greedy = setWfOfSIMD(p.greedy, simdID, wfs[0])
+}
+
+// looseRoundRobinPolicy gives the highest priority to the wavefront after the
+// one that issued last, so that the wavefronts take turns to issue.
+type looseRoundRobinPolicy struct {
+	last []*wavefront.Wavefront
+}
+
+func (p *looseRoundRobinPolicy) Order(
+	simdID int,
+	wfs []*wavefront.Wavefront,
+) []*wavefront.Wavefront {
+	last := wfOfSIMD(p.last, simdID)
+
+	start := 0
+	for i, wf := range wfs {
+		if wf == last {
+			start = i + 1
+			break
+		}
+	}
+
+	ordered := make([]*wavefront.Wavefront, 0, len(wfs))
+	for i := range wfs {
+		ordered = append(ordered, wfs[(start+i)%len(wfs)])
+	}
+
+	return ordered
+}
+
+func (p *looseRoundRobinPolicy) Selected(
+	simdID int,
+	wfs []*wavefront.Wavefront,
+) {
+	if len(wfs) == 0 {
+		return
+	}
+
+	p.last = setWfOfSIMD(p.last, simdID, wfs[len(wfs)-1])
+}
+
+// twoLevelPolicy only lets the wavefronts in a small active set of each SIMD
+// issue. A wavefront that waits for the vector memory leaves the active set,
+// and the oldest pending wavefront takes its place.
+type twoLevelPolicy struct {
+	activeSetSize int
+	active        [][]*wavefront.Wavefront
+}
+
+func (p *twoLevelPolicy) Order(
+	simdID int,
+	wfs []*wavefront.Wavefront,
+) []*wavefront.Wavefront {
+	for len(p.active) <= simdID {
+		p.active = append(p.active, nil)
+	}
+
+	active := make([]*wavefront.Wavefront, 0, p.activeSetSize)
+	for _, wf := range p.active[simdID] {
+		if containsWf(wfs, wf) && !p.waitsForMemory(wf) {
+			active = append(active, wf)
+		}
+	}
+
+	for _, wf := range wfs {
+		if len(active) >= p.activeSetSize {
+			break
+		}
+
+		if !containsWf(active, wf) && !p.waitsForMemory(wf) {
+			active = append(active, wf)
+		}
+	}
+
+	p.active[simdID] = active
+
+	return active
+}
+
+func (p *twoLevelPolicy) Selected(int, []*wavefront.Wavefront) {}
+
+func (p *twoLevelPolicy) waitsForMemory(wf *wavefront.Wavefront) bool {
+	return wf.State == wavefront.WfRunning && wf.OutstandingVectorMemAccess > 0
+}
+
+// criticalityAwarePolicy gives the wavefronts that have stalled for more
+// cycles a higher priority, so that the slowest wavefronts of a work-group,
+// which the other wavefronts wait for at the barriers, catch up.
+type criticalityAwarePolicy struct {
+	stallCycles []map[*wavefront.Wavefront]int
+}
+
+func (p *criticalityAwarePolicy) Order(
+	simdID int,
+	wfs []*wavefront.Wavefront,
+) []*wavefront.Wavefront {
+	for len(p.stallCycles) <= simdID {
+		p.stallCycles = append(p.stallCycles, nil)
+	}
+
+	history := make(map[*wavefront.Wavefront]int, len(wfs))
+	for _, wf := range wfs {
+		history[wf] = p.stallCycles[simdID][wf]
+	}
+
+	p.stallCycles[simdID] = history
+
+	ordered := make([]*wavefront.Wavefront, len(wfs))
+	copy(ordered, wfs)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return history[ordered[i]] > history[ordered[j]]
+	})
+
+	return ordered
+}
+
+func (p *criticalityAwarePolicy) Selected(
+	simdID int,
+	wfs []*wavefront.Wavefront,
+) {
+	history := p.stallCycles[simdID]
+	for wf := range history {
+		if !containsWf(wfs, wf) {
+			history[wf]++
+		}
+	}
+}
+
+// reportActed tells the policy the wavefronts of each arbitrated SIMD that
+// take the action.
+func reportActed(
+	policy IssuePolicy,
+	arbitrated []int,
+	wfs []*wavefront.Wavefront,
+) {
+	for _, simdID := range arbitrated {
+		acted := make([]*wavefront.Wavefront, 0, len(wfs))
+		for _, wf := range wfs {
+			if wf.SIMDID == simdID {
+				acted = append(acted, wf)
+			}
+		}
+
+		policy.Selected(simdID, acted)
+	}
+}
+
+func wfOfSIMD(wfs []*wavefront.Wavefront, simdID int) *wavefront.Wavefront {
+	if simdID >= len(wfs) {
+		return nil
+	}
+
+	return wfs[simdID]
+}
+
+func setWfOfSIMD(
+	wfs []*wavefront.Wavefront,
+	simdID int,
+	wf *wavefront.Wavefront,
+) []*wavefront.Wavefront {
+	for len(wfs) <= simdID {
+		wfs = append(wfs, nil)
+	}
+
+	wfs[simdID] = wf
+
+	return wfs
+}
+
+func containsWf(wfs []*wavefront.Wavefront, wf *wavefront.Wavefront) bool {
+	for _, w := range wfs {
+		if w == wf {
+			return true
+		}
+	}
+
+	return false
+}
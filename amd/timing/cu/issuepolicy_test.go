@@ -0,0 +1,117 @@
+package cu
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/mgpusim/v4/amd/insts"
+	"github.com/sarchlab/mgpusim/v4/amd/timing/wavefront"
+)
+
+var _ = Describe("IssuePolicy", func() {
+	var (
+		wfPools []*WavefrontPool
+		wfs     []*wavefront.Wavefront
+	)
+
+	BeforeEach(func() {
+		wfPools = []*WavefrontPool{NewWavefrontPool(10)}
+		wfs = make([]*wavefront.Wavefront, 0)
+		for i := 0; i < 4; i++ {
+			wf := new(wavefront.Wavefront)
+			wf.State = wavefront.WfReady
+			wf.InstToIssue = wavefront.NewInst(insts.NewInst())
+			wf.InstToIssue.ExeUnit = insts.ExeUnitVALU
+			wfs = append(wfs, wf)
+			wfPools[0].AddWf(wf)
+		}
+	})
+
+	newArbiter := func(name string) *IssueArbiter {
+		policy, err := NewIssuePolicy(name)
+		Expect(err).NotTo(HaveOccurred())
+		return NewIssueArbiterWithPolicy(policy)
+	}
+
+	// issue lets all the wavefronts that the arbiter selects issue.
+	issue := func(arbiter WfArbiter) []*wavefront.Wavefront {
+		selected := arbiter.Arbitrate(wfPools)
+		arbiter.Acted(selected)
+		return selected
+	}
+
+	It("should list the built-in policies", func() {
+		Expect(IssuePolicyNames()).To(ContainElements(
+			"criticality", "gto", "lrr", "oldest", "two-level"))
+	})
+
+	It("should fail to create an unknown policy", func() {
+		_, err := NewIssuePolicy("unknown")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should issue from the oldest wavefront", func() {
+		arbiter := newArbiter("oldest")
+
+		Expect(issue(arbiter)).To(Equal(wfs[0:1]))
+		Expect(issue(arbiter)).To(Equal(wfs[0:1]))
+	})
+
+	It("should keep issuing from the same wavefront with gto", func() {
+		arbiter := newArbiter("gto")
+
+		wfs[0].State = wavefront.WfRunning
+		Expect(issue(arbiter)).To(Equal(wfs[1:2]))
+
+		wfs[0].State = wavefront.WfReady
+		Expect(issue(arbiter)).To(Equal(wfs[1:2]))
+
+		wfs[1].State = wavefront.WfRunning
+		Expect(issue(arbiter)).To(Equal(wfs[0:1]))
+	})
+
+	It("should let the wavefronts take turns with lrr", func() {
+		arbiter := newArbiter("lrr")
+
+		Expect(issue(arbiter)).To(Equal(wfs[0:1]))
+		Expect(issue(arbiter)).To(Equal(wfs[1:2]))
+		Expect(issue(arbiter)).To(Equal(wfs[2:3]))
+		Expect(issue(arbiter)).To(Equal(wfs[3:4]))
+		Expect(issue(arbiter)).To(Equal(wfs[0:1]))
+	})
+
+	It("should only issue from the active set with two-level", func() {
+		policy := &twoLevelPolicy{activeSetSize: 2}
+		arbiter := NewIssueArbiterWithPolicy(policy)
+
+		wfs[0].State = wavefront.WfRunning
+		wfs[1].State = wavefront.WfRunning
+		Expect(issue(arbiter)).To(BeEmpty())
+
+		wfs[0].OutstandingVectorMemAccess = 1
+		Expect(issue(arbiter)).To(Equal(wfs[2:3]))
+		Expect(policy.active[0]).To(Equal([]*wavefront.Wavefront{
+			wfs[1], wfs[2],
+		}))
+	})
+
+	It("should issue from the most stalled wavefront with criticality", func() {
+		arbiter := newArbiter("criticality")
+
+		wfs[2].State = wavefront.WfRunning
+		Expect(issue(arbiter)).To(Equal(wfs[0:1]))
+		Expect(issue(arbiter)).To(Equal(wfs[1:2]))
+
+		wfs[2].State = wavefront.WfReady
+		Expect(issue(arbiter)).To(Equal(wfs[2:3]))
+	})
+
+	It("should only learn from the wavefronts that issue", func() {
+		arbiter := newArbiter("lrr")
+
+		Expect(arbiter.Arbitrate(wfPools)).To(Equal(wfs[0:1]))
+		arbiter.Acted(nil)
+
+		Expect(issue(arbiter)).To(Equal(wfs[0:1]))
+		Expect(issue(arbiter)).To(Equal(wfs[1:2]))
+	})
+})
@@ -2,15 +2,34 @@ package cu
 
 import (
 	"github.com/sarchlab/mgpusim/v4/amd/emu"
+	"github.com/sarchlab/mgpusim/v4/amd/insts"
 	"github.com/sarchlab/mgpusim/v4/amd/timing/wavefront"
 )
 
+// defaultLDSLatency is the number of cycles of a DS instruction that does not
+// have bank conflicts.
+const defaultLDSLatency = 14
+
 // A LDSUnit performs Scalar operations
 type LDSUnit struct {
 	cu *ComputeUnit
 
 	alu emu.ALU
 
+	// Latency is the number of cycles of a DS instruction that does not have
+	// bank conflicts.
+	Latency int
+
+	// NumBanks is the number of LDS banks. Bank conflicts are not modeled if
+	// it is 0.
+	NumBanks int
+
+	// BankWidth is the number of bytes that a bank serves per cycle.
+	BankWidth int
+
+	// Broadcast lets lanes that access the same word share one bank access.
+	Broadcast bool
+
 	toRead    *wavefront.Wavefront
 	toExec    *wavefront.Wavefront
 	toWrite   *wavefront.Wavefront
@@ -28,6 +47,9 @@ func NewLDSUnit(
 	u := new(LDSUnit)
 	u.cu = cu
 	u.alu = alu
+	u.Latency = defaultLDSLatency
+	u.BankWidth = 4
+	u.Broadcast = true
 	return u
 }
 
@@ -79,9 +101,11 @@ func (u *LDSUnit) runExecStage() bool {
 	}
 
 	if u.cycleLeft == 0 {
+		// Addresses must be read before the instruction executes, as a read
+		// may overwrite its own address register.
+		u.cycleLeft = u.Latency + u.bankConflictCycles(u.toExec)
 		u.alu.SetLDS(u.toExec.WG.LDS)
 		u.alu.Run(u.toExec)
-		u.cycleLeft = 14
 		return true
 	}
 
@@ -106,10 +130,138 @@ func (u *LDSUnit) runWriteStage() bool {
 	return true
 }
 
-// Flush clears the unit
+// Flush clears the unit. A DS instruction accesses the LDS when it enters the
+// exec stage, so the instructions that are waiting for the latency or for the
+// write stage are retired. Running them again after the pipeline restarts
+// would repeat their writes and atomics.
 func (u *LDSUnit) Flush() {
+	if u.toExec != nil && u.cycleLeft > 0 {
+		u.toWrite = u.toExec
+	}
+	u.runWriteStage()
+
 	u.toRead = nil
 	u.toExec = nil
 	u.toWrite = nil
 	u.cycleLeft = 0
 }
+
+// ldsAccess describes how a DS instruction accesses the LDS.
+type ldsAccess struct {
+	bytes uint32 // bytes accessed at each address
+
+	// read2/write2 instructions access two addresses per lane, at offset0
+	// and offset1 scaled by offsetScale.
+	dualAddr    bool
+	offsetScale uint32
+}
+
+var ldsAccesses = map[insts.Opcode]ldsAccess{
+	13:  {4, false, 1},  // ds_write_b32
+	14:  {4, true, 4},   // ds_write2_b32
+	15:  {4, true, 256}, // ds_write2st64_b32
+	30:  {1, false, 1},  // ds_write_b8
+	31:  {2, false, 1},  // ds_write_b16
+	54:  {4, false, 1},  // ds_read_b32
+	55:  {4, true, 4},   // ds_read2_b32
+	56:  {4, true, 256}, // ds_read2st64_b32
+	57:  {1, false, 1},  // ds_read_i8
+	58:  {1, false, 1},  // ds_read_u8
+	59:  {2, false, 1},  // ds_read_i16
+	60:  {2, false, 1},  // ds_read_u16
+	77:  {8, false, 1},  // ds_write_b64
+	78:  {8, true, 8},   // ds_write2_b64
+	79:  {8, true, 512}, // ds_write2st64_b64
+	118: {8, false, 1},  // ds_read_b64
+	119: {8, true, 8},   // ds_read2_b64
+	120: {8, true, 512}, // ds_read2st64_b64
+	222: {12, false, 1}, // ds_write_b96
+	223: {16, false, 1}, // ds_write_b128
+	254: {12, false, 1}, // ds_read_b96
+	255: {16, false, 1}, // ds_read_b128
+}
+
+// ldsAccessOf returns how a DS instruction accesses the LDS. The atomics come
+// from the decoded atomic operation rather than the table, as each of them
+// accesses one location of its size per lane.
+func ldsAccessOf(inst *insts.Inst) (ldsAccess, bool) {
+	if access, ok := ldsAccesses[inst.Opcode]; ok {
+		return access, true
+	}
+
+	if info, ok := inst.AtomicInfo(); ok {
+		return ldsAccess{uint32(info.ByteSize), false, 1}, true
+	}
+
+	return ldsAccess{}, false
+}
+
+// bankConflictCycles returns the number of extra cycles that the bank
+// conflicts of a DS read or write add. Each bank serves one word per cycle, so
+// the access takes as many cycles as the most-accessed bank has words. The
+// extra cycles are counted over an access that spreads evenly across banks.
+func (u *LDSUnit) bankConflictCycles(wave *wavefront.Wavefront) int {
+	inst := wave.Inst()
+	if u.NumBanks == 0 || inst == nil || inst.FormatType != insts.DS {
+		return 0
+	}
+
+	access, ok := ldsAccessOf(inst)
+	if !ok {
+		return 0
+	}
+
+	bankWidth := uint32(u.BankWidth)
+	bankAccesses := make([]int, u.NumBanks)
+	accessedWords := make(map[uint32]bool)
+	numAccesses := 0
+	exec := wave.EXEC()
+
+	for i := 0; i < wave.LaneCount(); i++ {
+		if !laneMasked(exec, uint(i)) {
+			continue
+		}
+
+		for _, addr := range u.laneAddrs(wave, inst, access, i) {
+			firstWord := addr / bankWidth
+			lastWord := (addr + access.bytes - 1) / bankWidth
+
+			for w := firstWord; w <= lastWord; w++ {
+				if u.Broadcast && accessedWords[w] {
+					continue
+				}
+
+				accessedWords[w] = true
+				bankAccesses[w%uint32(u.NumBanks)]++
+				numAccesses++
+			}
+		}
+	}
+
+	maxBankAccesses := 0
+	for _, n := range bankAccesses {
+		maxBankAccesses = max(maxBankAccesses, n)
+	}
+
+	minCycles := (numAccesses + u.NumBanks - 1) / u.NumBanks
+
+	return maxBankAccesses - minCycles
+}
+
+func (u *LDSUnit) laneAddrs(
+	wave *wavefront.Wavefront,
+	inst *insts.Inst,
+	access ldsAccess,
+	laneID int,
+) []uint32 {
+	base := uint32(wave.ReadOperand(inst.Addr, laneID))
+
+	if !access.dualAddr {
+		return []uint32{base + inst.Offset0}
+	}
+
+	return []uint32{
+		base + inst.Offset0*access.offsetScale,
+		base + inst.Offset1*access.offsetScale,
+	}
+}
@@ -4,6 +4,7 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/sarchlab/mgpusim/v4/amd/insts"
+	"github.com/sarchlab/mgpusim/v4/amd/kernels"
 	"github.com/sarchlab/mgpusim/v4/amd/timing/wavefront"
 )
 
@@ -120,10 +121,124 @@ var _ = Describe("LDS Unit", func() {
 
 		bu.Flush()
 
+		Expect(wave3.State).To(Equal(wavefront.WfReady))
+		Expect(wave3.PC()).To(Equal(uint64(0x140)))
 		Expect(bu.toRead).To(BeNil())
 		Expect(bu.toWrite).To(BeNil())
 		Expect(bu.toExec).To(BeNil())
 		Expect(bu.cycleLeft).To(Equal(0))
 
 	})
+
+	Context("bank conflicts", func() {
+		var (
+			wave        *wavefront.Wavefront
+			regAccessor *mockRegFileAccessor
+		)
+
+		BeforeEach(func() {
+			bu.NumBanks = 32
+
+			wave = wavefront.NewWavefront(nil)
+			regAccessor = newMockRegFileAccessor()
+			wave.RegAccessor = regAccessor
+			wave.SetEXEC(0xffffffffffffffff)
+			wave.WG = wavefront.NewWorkGroup(nil, nil)
+
+			inst := insts.NewInst()
+			inst.FormatType = insts.DS
+			inst.Opcode = 54 // ds_read_b32
+			inst.Addr = insts.NewVRegOperand(0, 0, 1)
+			wave.SetDynamicInst(wavefront.NewInst(inst))
+		})
+
+		setAddrs := func(addr func(lane int) uint32) {
+			for i := 0; i < 64; i++ {
+				regAccessor.setRegValue(insts.VReg(0), 1, i, wave.VRegOffset,
+					insts.Uint32ToBytes(addr(i)))
+			}
+		}
+
+		It("should not add cycles for consecutive words", func() {
+			setAddrs(func(lane int) uint32 { return uint32(lane * 4) })
+
+			Expect(bu.bankConflictCycles(wave)).To(Equal(0))
+		})
+
+		It("should serialize lanes that access the same bank", func() {
+			setAddrs(func(lane int) uint32 { return uint32(lane * 128) })
+
+			Expect(bu.bankConflictCycles(wave)).To(Equal(62))
+		})
+
+		It("should not add cycles if the rows are padded", func() {
+			setAddrs(func(lane int) uint32 { return uint32(lane * 132) })
+
+			Expect(bu.bankConflictCycles(wave)).To(Equal(0))
+		})
+
+		It("should broadcast the same word", func() {
+			setAddrs(func(lane int) uint32 { return 0x40 })
+
+			Expect(bu.bankConflictCycles(wave)).To(Equal(0))
+
+			bu.Broadcast = false
+			Expect(bu.bankConflictCycles(wave)).To(Equal(62))
+		})
+
+		It("should count both addresses of read2", func() {
+			wave.Inst().Opcode = 55 // ds_read2_b32
+			wave.Inst().Offset0 = 0
+			wave.Inst().Offset1 = 32
+			setAddrs(func(lane int) uint32 { return uint32(lane * 4) })
+
+			Expect(bu.bankConflictCycles(wave)).To(Equal(0))
+
+			wave.Inst().Offset1 = 1
+			setAddrs(func(lane int) uint32 { return uint32(lane * 8) })
+
+			Expect(bu.bankConflictCycles(wave)).To(Equal(0))
+
+			// Every lane accesses banks 0 and 1.
+			setAddrs(func(lane int) uint32 { return uint32(lane * 256) })
+
+			Expect(bu.bankConflictCycles(wave)).To(Equal(60))
+		})
+
+		It("should count the atomics", func() {
+			wave.Inst().Opcode = 0 // ds_add_u32
+			setAddrs(func(lane int) uint32 { return uint32(lane * 128) })
+
+			Expect(bu.bankConflictCycles(wave)).To(Equal(62))
+
+			// Every lane accesses banks 0 and 1.
+			wave.Inst().Opcode = 96 // ds_add_rtn_u64
+
+			Expect(bu.bankConflictCycles(wave)).To(Equal(60))
+		})
+
+		It("should only count the lanes of a wave32 wavefront", func() {
+			wave.Wavefront = &kernels.Wavefront{WavefrontSize: 32}
+			setAddrs(func(lane int) uint32 { return uint32(lane * 128) })
+
+			Expect(bu.bankConflictCycles(wave)).To(Equal(31))
+		})
+
+		It("should not model bank conflicts without banks", func() {
+			bu.NumBanks = 0
+			setAddrs(func(lane int) uint32 { return uint32(lane * 128) })
+
+			Expect(bu.bankConflictCycles(wave)).To(Equal(0))
+		})
+
+		It("should add the conflict cycles to the latency", func() {
+			setAddrs(func(lane int) uint32 { return uint32(lane * 128) })
+			bu.toExec = wave
+
+			bu.Run()
+
+			Expect(alu.wfExecuted).To(BeIdenticalTo(wave))
+			Expect(bu.cycleLeft).To(Equal(14 + 62))
+		})
+	})
 })
@@ -0,0 +1,157 @@
+package cu
+
+import (
+	"log"
+
+	"github.com/sarchlab/akita/v4/sim"
+	"github.com/sarchlab/akita/v4/tracing"
+	"github.com/sarchlab/mgpusim/v4/amd/emu"
+	"github.com/sarchlab/mgpusim/v4/amd/timing/wavefront"
+)
+
+// DefaultMatrixCoreLatencies returns the number of cycles that each MFMA
+// instruction occupies the matrix core on CDNA3. Each pass takes 4 cycles.
+// Every MFMA instruction that the decoder knows must have an entry.
+func DefaultMatrixCoreLatencies() map[string]int {
+	return map[string]int{
+		"v_mfma_f32_32x32x1_2b_f32":  64,
+		"v_mfma_f32_16x16x1_4b_f32":  32,
+		"v_mfma_f32_4x4x1_16b_f32":   8,
+		"v_mfma_f32_32x32x2_f32":     64,
+		"v_mfma_f32_16x16x4_f32":     32,
+		"v_mfma_f32_32x32x4_2b_f16":  64,
+		"v_mfma_f32_16x16x4_4b_f16":  32,
+		"v_mfma_f32_4x4x4_16b_f16":   8,
+		"v_mfma_f32_32x32x8_f16":     32,
+		"v_mfma_f32_16x16x16_f16":    16,
+		"v_mfma_i32_32x32x4_2b_i8":   64,
+		"v_mfma_i32_16x16x4_4b_i8":   32,
+		"v_mfma_i32_4x4x4_16b_i8":    8,
+		"v_mfma_i32_32x32x16_i8":     32,
+		"v_mfma_i32_16x16x32_i8":     16,
+		"v_mfma_f32_32x32x4_2b_bf16": 64,
+		"v_mfma_f32_16x16x4_4b_bf16": 32,
+		"v_mfma_f32_4x4x4_16b_bf16":  8,
+		"v_mfma_f32_32x32x8_bf16":    32,
+		"v_mfma_f32_16x16x16_bf16":   16,
+	}
+}
+
+// A MatrixCoreUnit executes the MFMA instructions of the wavefronts on one
+// SIMD. Each instruction occupies the unit for a latency that depends on the
+// shape of the instruction.
+type MatrixCoreUnit struct {
+	sim.HookableBase
+
+	cu *ComputeUnit
+
+	name string
+
+	alu emu.ALU
+
+	// Latencies maps the instruction name to the number of cycles that the
+	// instruction occupies the unit.
+	Latencies map[string]int
+
+	toExec    *wavefront.Wavefront
+	cycleLeft int
+
+	isIdle bool
+}
+
+// NewMatrixCoreUnit creates a new matrix core unit, injecting the dependency
+// of the compute unit.
+func NewMatrixCoreUnit(
+	cu *ComputeUnit,
+	name string,
+	alu emu.ALU,
+) *MatrixCoreUnit {
+	u := new(MatrixCoreUnit)
+	u.name = name
+	u.cu = cu
+	u.alu = alu
+	u.Latencies = DefaultMatrixCoreLatencies()
+
+	return u
+}
+
+// CanAcceptWave checks if the unit is executing another instruction.
+func (u *MatrixCoreUnit) CanAcceptWave() bool {
+	return u.toExec == nil
+}
+
+// IsIdle checks if the unit is executing another instruction.
+func (u *MatrixCoreUnit) IsIdle() bool {
+	u.isIdle = (u.toExec == nil)
+	return u.isIdle
+}
+
+// AcceptWave starts executing the MFMA instruction of the wavefront.
+func (u *MatrixCoreUnit) AcceptWave(wave *wavefront.Wavefront) {
+	u.toExec = wave
+	u.cycleLeft = u.latency(wave.Inst().InstName)
+	u.logPipelineTask(u.toExec.DynamicInst(), false)
+}
+
+func (u *MatrixCoreUnit) latency(instName string) int {
+	cycles, ok := u.Latencies[instName]
+	if !ok {
+		log.Panicf("no matrix core latency for %s", instName)
+	}
+
+	return cycles
+}
+
+// Run counts down the latency of the current instruction and commits its
+// result when the latency is over.
+func (u *MatrixCoreUnit) Run() bool {
+	if u.toExec == nil {
+		return false
+	}
+
+	u.cycleLeft--
+	if u.cycleLeft > 0 {
+		return true
+	}
+
+	u.alu.Run(u.toExec)
+	u.cu.UpdatePCAndSetReady(u.toExec)
+
+	u.logPipelineTask(u.toExec.DynamicInst(), true)
+	u.cu.logInstTask(u.toExec, u.toExec.DynamicInst(), true)
+
+	u.toExec = nil
+	return true
+}
+
+// Flush clears the unit.
+func (u *MatrixCoreUnit) Flush() {
+	u.toExec = nil
+}
+
+func (u *MatrixCoreUnit) logPipelineTask(
+	inst *wavefront.Inst,
+	completed bool,
+) {
+	if completed {
+		tracing.EndTask(
+			inst.ID+"_matrix_core_exec",
+			u,
+		)
+		return
+	}
+
+	tracing.StartTask(
+		inst.ID+"_matrix_core_exec",
+		inst.ID,
+		u,
+		"pipeline",
+		u.cu.execUnitToString(inst.ExeUnit),
+		nil,
+	)
+}
+
+// Name names the unit
+func (u *MatrixCoreUnit) Name() string {
+	return u.name
+}
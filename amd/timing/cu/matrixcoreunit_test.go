@@ -0,0 +1,113 @@
+package cu
+
+import (
+	"encoding/binary"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/mgpusim/v4/amd/insts"
+	"github.com/sarchlab/mgpusim/v4/amd/timing/wavefront"
+)
+
+var _ = Describe("Matrix Core Unit", func() {
+
+	var (
+		cu  *ComputeUnit
+		mu  *MatrixCoreUnit
+		alu *mockALU
+	)
+
+	BeforeEach(func() {
+		cu = NewComputeUnit("CU", nil)
+		alu = new(mockALU)
+		mu = NewMatrixCoreUnit(cu, "matrix_core", alu)
+	})
+
+	It("should not allow accepting wavefront if executing an instruction", func() {
+		mu.toExec = new(wavefront.Wavefront)
+		Expect(mu.CanAcceptWave()).To(BeFalse())
+	})
+
+	It("should use the per-shape latency", func() {
+		wave := new(wavefront.Wavefront)
+		inst := wavefront.NewInst(insts.NewInst())
+		inst.InstName = "v_mfma_f32_16x16x16_f16"
+		wave.SetDynamicInst(inst)
+
+		mu.AcceptWave(wave)
+
+		Expect(mu.toExec).To(BeIdenticalTo(wave))
+		Expect(mu.cycleLeft).To(Equal(16))
+	})
+
+	It("should have a latency for every decoded MFMA instruction", func() {
+		names := mfmaInstNames()
+		Expect(names).NotTo(BeEmpty())
+
+		for _, name := range names {
+			Expect(mu.Latencies).To(HaveKey(name))
+		}
+	})
+
+	It("should panic on instructions without a latency", func() {
+		wave := new(wavefront.Wavefront)
+		inst := wavefront.NewInst(insts.NewInst())
+		inst.InstName = "v_mfma_unknown"
+		wave.SetDynamicInst(inst)
+
+		Expect(func() { mu.AcceptWave(wave) }).To(Panic())
+	})
+
+	It("should run", func() {
+		wave := new(wavefront.Wavefront)
+		inst := wavefront.NewInst(insts.NewInst())
+		inst.ByteSize = 8
+		wave.InstBuffer = make([]byte, 256)
+		wave.InstBufferStartPC = 0x100
+		wave.SetDynamicInst(inst)
+		wave.SetPC(0x138)
+		wave.State = wavefront.WfRunning
+
+		mu.toExec = wave
+		mu.cycleLeft = 2
+
+		mu.Run()
+		Expect(mu.toExec).To(BeIdenticalTo(wave))
+
+		mu.Run()
+		Expect(wave.State).To(Equal(wavefront.WfReady))
+		Expect(wave.PC()).To(Equal(uint64(0x140)))
+		Expect(mu.toExec).To(BeNil())
+		Expect(alu.wfExecuted).To(BeIdenticalTo(wave))
+	})
+
+	It("should flush", func() {
+		mu.toExec = new(wavefront.Wavefront)
+
+		mu.Flush()
+
+		Expect(mu.toExec).To(BeNil())
+	})
+})
+
+// mfmaInstNames decodes every opcode of the VOP3P-MAI range and returns the
+// names of the instructions that run on the matrix core.
+func mfmaInstNames() []string {
+	disassembler := insts.NewDisassembler()
+	names := []string{}
+
+	for opcode := uint32(0x40); opcode <= 0x7f; opcode++ {
+		buf := make([]byte, 8)
+		binary.LittleEndian.PutUint32(buf, 0xD3800000|opcode<<16)
+		binary.LittleEndian.PutUint32(buf[4:], 0x04020100)
+
+		inst, err := disassembler.Decode(buf)
+		if err != nil || inst.ExeUnit != insts.ExeUnitMatrix {
+			continue
+		}
+
+		names = append(names, inst.InstName)
+	}
+
+	return names
+}
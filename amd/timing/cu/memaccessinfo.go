@@ -2,7 +2,9 @@ package cu
 
 import (
 	"github.com/sarchlab/akita/v4/mem/mem"
+	"github.com/sarchlab/mgpusim/v4/amd/emu"
 	"github.com/sarchlab/mgpusim/v4/amd/insts"
+	"github.com/sarchlab/mgpusim/v4/amd/protocol"
 	"github.com/sarchlab/mgpusim/v4/amd/timing/wavefront"
 )
 
@@ -11,6 +13,10 @@ type vectorMemAccessLaneInfo struct {
 	reg                   *insts.Reg
 	regCount              int
 	addrOffsetInCacheLine uint64
+
+	// bufferComponent converts the bytes of a buffer load to the value of
+	// the register. It is nil for the other instructions.
+	bufferComponent *emu.BufferComponent
 }
 
 // VectorMemAccessInfo defines access info
@@ -18,6 +24,7 @@ type VectorMemAccessInfo struct {
 	ID        string
 	Read      *mem.ReadReq
 	Write     *mem.WriteReq
+	Atomic    *protocol.AtomicReq
 	Wavefront *wavefront.Wavefront
 	Inst      *wavefront.Inst
 	laneInfo  []vectorMemAccessLaneInfo
@@ -3,6 +3,7 @@ package cu
 import (
 	"log"
 
+	"github.com/sarchlab/mgpusim/v4/amd/emu"
 	"github.com/sarchlab/mgpusim/v4/amd/insts"
 	"github.com/sarchlab/mgpusim/v4/amd/timing/wavefront"
 )
@@ -46,14 +47,27 @@ func (a *CURegFileAccessor) ReadReg(
 		return insts.Uint32ToBytes(uint32(a.WF.EXEC()))
 	case insts.M0:
 		return insts.Uint32ToBytes(a.WF.M0)
+	case insts.FlatSratch, insts.FlatSratchLo:
+		if regCount >= 2 || reg.RegType == insts.FlatSratch {
+			return insts.Uint64ToBytes(a.WF.FlatScratch)
+		}
+		return insts.Uint32ToBytes(uint32(a.WF.FlatScratch))
+	case insts.FlatSratchHi:
+		return insts.Uint32ToBytes(uint32(a.WF.FlatScratch >> 32))
+	}
+
+	if reg.IsApertureReg() {
+		return emu.ReadApertureReg(reg, regCount)
 	}
 
-	// Handle regular SReg and VReg via register files
+	// Handle regular SReg, VReg, and AReg via register files
 	var regFile RegisterFile
 	if reg.IsSReg() {
 		regFile = a.CU.SRegFile
 	} else if reg.IsVReg() {
 		regFile = a.CU.VRegFile[a.WF.SIMDID]
+	} else if reg.IsAReg() {
+		regFile = a.CU.ARegFile[a.WF.SIMDID]
 	} else {
 		// Fallback by name for vcclo/vcchi
 		if reg.Name == "vcclo" {
@@ -130,14 +144,30 @@ func (a *CURegFileAccessor) WriteReg(
 	case insts.M0:
 		a.WF.M0 = insts.BytesToUint32(data)
 		return
+	case insts.FlatSratch, insts.FlatSratchLo:
+		if regCount >= 2 || len(data) >= 8 {
+			a.WF.FlatScratch = insts.BytesToUint64(padTo8(data))
+		} else {
+			lo := uint64(insts.BytesToUint32(data))
+			hi := a.WF.FlatScratch & 0xFFFFFFFF00000000
+			a.WF.FlatScratch = hi | lo
+		}
+		return
+	case insts.FlatSratchHi:
+		hi := uint64(insts.BytesToUint32(data)) << 32
+		lo := a.WF.FlatScratch & 0x00000000FFFFFFFF
+		a.WF.FlatScratch = hi | lo
+		return
 	}
 
-	// Handle regular SReg and VReg via register files
+	// Handle regular SReg, VReg, and AReg via register files
 	var regFile RegisterFile
 	if reg.IsSReg() {
 		regFile = a.CU.SRegFile
 	} else if reg.IsVReg() {
 		regFile = a.CU.VRegFile[a.WF.SIMDID]
+	} else if reg.IsAReg() {
+		regFile = a.CU.ARegFile[a.WF.SIMDID]
 	} else {
 		// Fallback by name for vcclo/vcchi
 		if reg.Name == "vcclo" {
@@ -75,7 +75,7 @@ func (r *SimpleRegisterFile) getRegOffset(reg *insts.Reg, offset int, laneID int
 		return reg.RegIndex()*4 + offset
 	}
 
-	if reg.IsVReg() {
+	if reg.IsVReg() || reg.IsAReg() {
 		regOffset := reg.RegIndex()*4 + laneID*r.ByteSizePerLane + offset
 		return regOffset
 	}
@@ -233,8 +233,12 @@ func (u *ScalarUnit) sendRequest() bool {
 	return madeProgress
 }
 
-// Flush clears the unit
+// Flush clears the unit. The instruction in the write stage has already
+// updated the registers, so it is retired. Otherwise, the wavefront would run
+// it again and, for example, increment a counter twice.
 func (u *ScalarUnit) Flush() {
+	u.runWriteStage()
+
 	u.toRead = nil
 	u.toExec = nil
 	u.toWrite = nil
@@ -297,6 +297,7 @@ var _ = Describe("Scalar Unit", func() {
 
 		bu.Flush()
 
+		Expect(wave.State).To(Equal(wavefront.WfReady))
 		Expect(bu.toRead).To(BeNil())
 		Expect(bu.toWrite).To(BeNil())
 		Expect(bu.toExec).To(BeNil())
@@ -36,6 +36,12 @@ type SchedulerImpl struct {
 
 	scoreboardEnabled bool
 
+	issuePolicy    string
+	issueSlotsUsed uint64
+
+	trapHandler     TrapHandler
+	stallCyclesLeft map[*wavefront.Wavefront]int
+
 	isPaused bool
 }
 
@@ -56,6 +62,8 @@ func NewScheduler(
 
 	s.stopTickingAfterNCyclesNoProgress = 4
 
+	s.stallCyclesLeft = make(map[*wavefront.Wavefront]int)
+
 	return s
 }
 
@@ -143,6 +151,7 @@ func (s *SchedulerImpl) wfHasAtLeast4BytesInInstBuffer(wf *wavefront.Wavefront)
 func (s *SchedulerImpl) DoFetch() bool {
 	madeProgress := false
 	wfs := s.fetchArbiter.Arbitrate(s.cu.WfPools)
+	fetched := make([]*wavefront.Wavefront, 0, len(wfs))
 
 	fetchLimit := min(4, len(wfs))
 	for idx := 0; idx < fetchLimit; idx++ {
@@ -169,6 +178,7 @@ func (s *SchedulerImpl) DoFetch() bool {
 			info.Address = addr
 			s.cu.InFlightInstFetch = append(s.cu.InFlightInstFetch, info)
 			wf.IsFetching = true
+			fetched = append(fetched, wf)
 
 			madeProgress = true
 
@@ -178,6 +188,8 @@ func (s *SchedulerImpl) DoFetch() bool {
 		}
 	}
 
+	s.fetchArbiter.Acted(fetched)
+
 	return madeProgress
 }
 
@@ -187,10 +199,16 @@ func (s *SchedulerImpl) DoIssue() bool {
 	madeProgress := false
 
 	if s.isPaused == false {
+		simdIssued := make([]bool, len(s.cu.WfPools))
 		wfs := s.issueArbiter.Arbitrate(s.cu.WfPools)
+		issued := make([]*wavefront.Wavefront, 0, len(wfs))
 		for _, wf := range wfs {
 			if wf.InstToIssue.ExeUnit == insts.ExeUnitSpecial {
-				madeProgress = s.issueToInternal(wf) || madeProgress
+				if s.issueToInternal(wf) {
+					issued = append(issued, wf)
+					s.markIssueSlotUsed(simdIssued, wf)
+					madeProgress = true
+				}
 
 				continue
 			}
@@ -213,14 +231,44 @@ func (s *SchedulerImpl) DoIssue() bool {
 				unit.AcceptWave(wf)
 				wf.State = wavefront.WfRunning
 				//s.removeStaleInstBuffer(wf)
+				issued = append(issued, wf)
+				s.markIssueSlotUsed(simdIssued, wf)
 
 				madeProgress = true
 			}
 		}
+
+		s.issueArbiter.Acted(issued)
 	}
 	return madeProgress
 }
 
+// markIssueSlotUsed counts the issue slot of the SIMD of the wavefront as used
+// in the cycle, if no other wavefront of the SIMD has issued.
+func (s *SchedulerImpl) markIssueSlotUsed(
+	simdIssued []bool,
+	wf *wavefront.Wavefront,
+) {
+	if wf.SIMDID >= len(simdIssued) || simdIssued[wf.SIMDID] {
+		return
+	}
+
+	simdIssued[wf.SIMDID] = true
+	s.issueSlotsUsed++
+}
+
+// IssuePolicy returns the name of the policy that decides which wavefronts
+// issue first.
+func (s *SchedulerImpl) IssuePolicy() string {
+	return s.issuePolicy
+}
+
+// IssueSlotsUsed returns the number of cycles, summed over the SIMDs, in which
+// a SIMD issues at least one instruction.
+func (s *SchedulerImpl) IssueSlotsUsed() uint64 {
+	return s.issueSlotsUsed
+}
+
 func (s *SchedulerImpl) issueToInternal(wf *wavefront.Wavefront) bool {
 	wf.SetDynamicInst(wf.InstToIssue)
 	wf.InstToIssue = nil
@@ -245,6 +293,8 @@ func (s *SchedulerImpl) getUnitToIssueTo(u insts.ExeUnit) SubComponent {
 		return s.cu.VectorMemDecoder
 	case insts.ExeUnitScalar:
 		return s.cu.ScalarDecoder
+	case insts.ExeUnitMatrix:
+		return s.cu.MatrixDecoder
 	default:
 		log.Panic("not sure where to dispatch the instruction")
 	}
@@ -278,6 +328,14 @@ func (s *SchedulerImpl) EvaluateInternalInst() bool {
 			}
 		case 12: // S_WAITCNT
 			instProgress, instCompleted = s.evalSWaitCnt(executing)
+		case 14: // S_SLEEP
+			instProgress, instCompleted = s.evalSSleep(executing)
+		case 15: // S_SETPRIO
+			instProgress, instCompleted = s.evalSSetPrio(executing)
+		case 16, 17: // S_SENDMSG, S_SENDMSGHALT
+			instProgress, instCompleted = s.evalSSendMsg(executing)
+		case 18: // S_TRAP
+			instProgress, instCompleted = s.evalSTrap(executing)
 		default:
 			// The program has to make progress
 			s.cu.UpdatePCAndSetReady(executing)
@@ -539,6 +597,86 @@ func (s *SchedulerImpl) evalSWaitCnt(
 	return false, false
 }
 
+// evalSSleep idles the wavefront for 64 cycles for each unit of SIMM16[6:0].
+func (s *SchedulerImpl) evalSSleep(
+	wf *wavefront.Wavefront,
+) (madeProgress bool, instCompleted bool) {
+	cycles := 64 * int(wf.Inst().SImm16.IntValue&0x7f)
+	return s.stallInternalInst(wf, cycles)
+}
+
+// evalSSetPrio sets the priority that the issue arbiter gives the wavefront
+// to SIMM16[1:0].
+func (s *SchedulerImpl) evalSSetPrio(
+	wf *wavefront.Wavefront,
+) (madeProgress bool, instCompleted bool) {
+	wf.Priority = int(wf.Inst().SImm16.IntValue & 0x3)
+	s.cu.UpdatePCAndSetReady(wf)
+
+	return true, true
+}
+
+// evalSSendMsg sends the message of s_sendmsg and s_sendmsghalt. As no other
+// component consumes the messages of compute kernels, such as
+// MSG_DEALLOC_VGPRS or the GS messages, the message only shows up as a step
+// of the instruction in the traces. The wavefronts that s_sendmsghalt halts
+// are resumed right away, as there is no host to resume them.
+func (s *SchedulerImpl) evalSSendMsg(
+	wf *wavefront.Wavefront,
+) (madeProgress bool, instCompleted bool) {
+	msg, ok := wf.Inst().SendMsg()
+	if !ok {
+		log.Panicf("instruction %s does not send a message",
+			wf.Inst().InstName)
+	}
+
+	tracing.AddTaskStep(wf.DynamicInst().ID, s.cu, msg.String())
+	s.cu.UpdatePCAndSetReady(wf)
+
+	return true, true
+}
+
+// evalSTrap runs the trap handler with the trap ID in SIMM16[7:0]. The
+// wavefront stalls for the cycles that the trap handler takes. Without a
+// trap handler, s_trap completes right away.
+func (s *SchedulerImpl) evalSTrap(
+	wf *wavefront.Wavefront,
+) (madeProgress bool, instCompleted bool) {
+	_, trapping := s.stallCyclesLeft[wf]
+	if trapping || s.trapHandler == nil {
+		return s.stallInternalInst(wf, 0)
+	}
+
+	trapID := int(wf.Inst().SImm16.IntValue & 0xff)
+	cycles := s.trapHandler.HandleTrap(wf, trapID)
+
+	return s.stallInternalInst(wf, cycles)
+}
+
+// stallInternalInst keeps the wavefront in the internal instruction for the
+// given number of cycles, counting from the first cycle that the instruction
+// is evaluated. Counting down the cycles is progress, so that the compute
+// unit keeps ticking until the wavefront wakes up.
+func (s *SchedulerImpl) stallInternalInst(
+	wf *wavefront.Wavefront,
+	cycles int,
+) (madeProgress bool, instCompleted bool) {
+	left, stalling := s.stallCyclesLeft[wf]
+	if !stalling {
+		left = cycles
+	}
+
+	if left <= 0 {
+		delete(s.stallCyclesLeft, wf)
+		s.cu.UpdatePCAndSetReady(wf)
+		return true, true
+	}
+
+	s.stallCyclesLeft[wf] = left - 1
+
+	return true, false
+}
+
 // Pause pauses
 func (s *SchedulerImpl) Pause() {
 	s.isPaused = true
@@ -553,4 +691,5 @@ func (s *SchedulerImpl) Resume() {
 func (s *SchedulerImpl) Flush() {
 	s.barrierBuffer = nil
 	s.internalExecuting = nil
+	s.stallCyclesLeft = make(map[*wavefront.Wavefront]int)
 }
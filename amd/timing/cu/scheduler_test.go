@@ -14,6 +14,7 @@ import (
 
 type mockWfArbitor struct {
 	wfsToReturn [][]*wavefront.Wavefront
+	acted       [][]*wavefront.Wavefront
 }
 
 func newMockWfArbitor() *mockWfArbitor {
@@ -31,6 +32,10 @@ func (m *mockWfArbitor) Arbitrate([]*WavefrontPool) []*wavefront.Wavefront {
 	return wfs
 }
 
+func (m *mockWfArbitor) Acted(wfs []*wavefront.Wavefront) {
+	m.acted = append(m.acted, wfs)
+}
+
 type mockCUComponent struct {
 	canAccept    bool
 	isIdle       bool
@@ -57,6 +62,19 @@ func (c *mockCUComponent) Flush() {
 
 }
 
+type mockTrapHandler struct {
+	cycles  int
+	trapIDs []int
+}
+
+func (h *mockTrapHandler) HandleTrap(
+	_ *wavefront.Wavefront,
+	trapID int,
+) int {
+	h.trapIDs = append(h.trapIDs, trapID)
+	return h.cycles
+}
+
 var _ = Describe("Scheduler", func() {
 	var (
 		mockCtrl         *gomock.Controller
@@ -145,6 +163,8 @@ var _ = Describe("Scheduler", func() {
 
 		Expect(cu.InFlightInstFetch).To(HaveLen(1))
 		Expect(wf.IsFetching).To(BeTrue())
+		Expect(fetchArbitor.acted).To(Equal(
+			[][]*wavefront.Wavefront{{wf}}))
 	})
 
 	It("should wait if fetch failed", func() {
@@ -166,6 +186,8 @@ var _ = Describe("Scheduler", func() {
 
 		//Expect(cu.inFlightMemAccess).To(HaveLen(0))
 		Expect(wf.IsFetching).To(BeFalse())
+		Expect(fetchArbitor.acted).To(Equal(
+			[][]*wavefront.Wavefront{{}}))
 	})
 
 	It("should issue", func() {
@@ -217,6 +239,29 @@ var _ = Describe("Scheduler", func() {
 		Expect(wfs[2].InstToIssue).To(BeNil())
 		Expect(wfs[3].InstToIssue).To(BeNil())
 		Expect(wfs[4].InstToIssue).NotTo(BeNil())
+
+		// The issued instructions all come from SIMD 0, using one issue slot.
+		Expect(scheduler.IssueSlotsUsed()).To(Equal(uint64(1)))
+		Expect(issueArbitor.acted).To(Equal(
+			[][]*wavefront.Wavefront{wfs[0:4]}))
+	})
+
+	It("should not use an issue slot if the unit is busy", func() {
+		wf := new(wavefront.Wavefront)
+		wf.Wavefront = kernels.NewWavefront()
+		wf.State = wavefront.WfReady
+		wf.InstToIssue = wavefront.NewInst(insts.NewInst())
+		wf.InstToIssue.ExeUnit = insts.ExeUnitVALU
+		vectorDecoder.canAccept = false
+		issueArbitor.wfsToReturn = append(issueArbitor.wfsToReturn,
+			[]*wavefront.Wavefront{wf})
+
+		scheduler.DoIssue()
+
+		Expect(wf.State).To(Equal(wavefront.WfReady))
+		Expect(scheduler.IssueSlotsUsed()).To(BeZero())
+		Expect(issueArbitor.acted).To(Equal(
+			[][]*wavefront.Wavefront{{}}))
 	})
 
 	It("should issue internal instruction", func() {
@@ -291,6 +336,87 @@ var _ = Describe("Scheduler", func() {
 		Expect(wf.State).To(Equal(wavefront.WfReady))
 	})
 
+	Context("when running the SOPP instructions that only change timing", func() {
+		var wf *wavefront.Wavefront
+
+		BeforeEach(func() {
+			wf = new(wavefront.Wavefront)
+			wf.SetDynamicInst(wavefront.NewInst(insts.NewInst()))
+			wf.DynamicInst().Format = insts.FormatTable[insts.SOPP]
+			wf.DynamicInst().FormatType = insts.SOPP
+			wf.DynamicInst().ByteSize = 4
+			wf.State = wavefront.WfRunning
+			wf.SetPC(10)
+
+			scheduler.internalExecuting = []*wavefront.Wavefront{wf}
+		})
+
+		It("should sleep for 64 cycles per unit of s_sleep", func() {
+			wf.DynamicInst().Opcode = 14 // S_SLEEP
+			wf.DynamicInst().SImm16 = insts.NewIntOperand(0, 2)
+
+			for i := 0; i < 128; i++ {
+				Expect(scheduler.EvaluateInternalInst()).To(BeTrue())
+				Expect(scheduler.internalExecuting).To(ContainElement(wf))
+			}
+
+			Expect(scheduler.EvaluateInternalInst()).To(BeTrue())
+			Expect(scheduler.internalExecuting).NotTo(ContainElement(wf))
+			Expect(wf.State).To(Equal(wavefront.WfReady))
+			Expect(wf.PC()).To(Equal(uint64(14)))
+		})
+
+		It("should set the priority with s_setprio", func() {
+			wf.DynamicInst().Opcode = 15 // S_SETPRIO
+			wf.DynamicInst().SImm16 = insts.NewIntOperand(0, 2)
+
+			scheduler.EvaluateInternalInst()
+
+			Expect(wf.Priority).To(Equal(2))
+			Expect(scheduler.internalExecuting).NotTo(ContainElement(wf))
+			Expect(wf.State).To(Equal(wavefront.WfReady))
+		})
+
+		It("should send the message of s_sendmsg", func() {
+			wf.DynamicInst().Opcode = 16 // S_SENDMSG
+			wf.DynamicInst().SImm16 = insts.NewIntOperand(0, 0xb3)
+
+			scheduler.EvaluateInternalInst()
+
+			Expect(scheduler.internalExecuting).NotTo(ContainElement(wf))
+			Expect(wf.State).To(Equal(wavefront.WfReady))
+			Expect(wf.PC()).To(Equal(uint64(14)))
+		})
+
+		It("should not stall on s_trap without a trap handler", func() {
+			wf.DynamicInst().Opcode = 18 // S_TRAP
+			wf.DynamicInst().SImm16 = insts.NewIntOperand(0, 2)
+
+			scheduler.EvaluateInternalInst()
+
+			Expect(scheduler.internalExecuting).NotTo(ContainElement(wf))
+			Expect(wf.State).To(Equal(wavefront.WfReady))
+		})
+
+		It("should stall while the trap handler runs", func() {
+			handler := &mockTrapHandler{cycles: 3}
+			scheduler.trapHandler = handler
+			wf.DynamicInst().Opcode = 18 // S_TRAP
+			wf.DynamicInst().SImm16 = insts.NewIntOperand(0, 2)
+
+			for i := 0; i < 3; i++ {
+				scheduler.EvaluateInternalInst()
+				Expect(scheduler.internalExecuting).To(ContainElement(wf))
+			}
+
+			scheduler.EvaluateInternalInst()
+
+			Expect(handler.trapIDs).To(Equal([]int{2}))
+			Expect(scheduler.internalExecuting).NotTo(ContainElement(wf))
+			Expect(wf.State).To(Equal(wavefront.WfReady))
+		})
+	})
+
 	Context("when running END_PGM", func() {
 		It("should not terminate wavefront if there are pending memory requests", func() {
 			wf := new(wavefront.Wavefront)
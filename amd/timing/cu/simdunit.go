@@ -28,12 +28,16 @@ type SIMDUnit struct {
 	toExec    *wavefront.Wavefront
 	cycleLeft int
 
-	// Pipeline mode fields (used when scoreboardEnabled)
+	// Pipeline mode fields (used when scoreboardEnabled or dualIssue)
 	pipelineSlots    []*simdPipelineSlot
 	pipelineCapacity int
 
 	scoreboardEnabled bool
 
+	// dualIssue lets the unit start a second instruction while one executes,
+	// as the SIMD32 units of the RDNA work-group processors do.
+	dualIssue bool
+
 	NumSinglePrecisionUnit int
 
 	isIdle bool
@@ -58,7 +62,7 @@ func NewSIMDUnit(
 
 // CanAcceptWave checks if the buffer of the read stage is occupied or not
 func (u *SIMDUnit) CanAcceptWave() bool {
-	if u.scoreboardEnabled {
+	if u.pipelined() {
 		return len(u.pipelineSlots) < u.pipelineCapacity
 	}
 	return u.toExec == nil
@@ -66,7 +70,7 @@ func (u *SIMDUnit) CanAcceptWave() bool {
 
 // IsIdle checks if the buffer of the read stage is occupied or not
 func (u *SIMDUnit) IsIdle() bool {
-	if u.scoreboardEnabled {
+	if u.pipelined() {
 		u.isIdle = len(u.pipelineSlots) == 0
 		return u.isIdle
 	}
@@ -74,14 +78,21 @@ func (u *SIMDUnit) IsIdle() bool {
 	return u.isIdle
 }
 
+func (u *SIMDUnit) pipelined() bool {
+	return u.scoreboardEnabled || u.dualIssue
+}
+
 // AcceptWave moves one wavefront into the read buffer of the branch unit
 func (u *SIMDUnit) AcceptWave(wave *wavefront.Wavefront) {
-	cycleLeft := 64 / u.NumSinglePrecisionUnit
+	lanesPerCycle := u.NumSinglePrecisionUnit
 	if strings.Contains(wave.Inst().InstName, "f64") {
-		cycleLeft = 64 / (u.NumSinglePrecisionUnit / 2)
+		lanesPerCycle /= 2
 	}
 
-	if u.scoreboardEnabled {
+	// A wave32 instruction takes half the cycles of a wave64 one.
+	cycleLeft := (wave.LaneCount() + lanesPerCycle - 1) / lanesPerCycle
+
+	if u.pipelined() {
 		slot := &simdPipelineSlot{
 			wf:        wave,
 			cycleLeft: cycleLeft,
@@ -98,7 +109,7 @@ func (u *SIMDUnit) AcceptWave(wave *wavefront.Wavefront) {
 
 // Run executes three pipeline stages that are controlled by the SIMDUnit
 func (u *SIMDUnit) Run() bool {
-	if u.scoreboardEnabled {
+	if u.pipelined() {
 		return u.runPipelined()
 	}
 	return u.runExecStage()
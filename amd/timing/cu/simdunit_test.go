@@ -4,6 +4,7 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/sarchlab/mgpusim/v4/amd/insts"
+	"github.com/sarchlab/mgpusim/v4/amd/kernels"
 	"github.com/sarchlab/mgpusim/v4/amd/timing/wavefront"
 )
 
@@ -44,6 +45,30 @@ var _ = Describe("SIMD Unit", func() {
 		Expect(bu.cycleLeft).To(Equal(4))
 	})
 
+	It("should take half the cycles for a wave32 instruction", func() {
+		wave := wavefront.NewWavefront(&kernels.Wavefront{WavefrontSize: 32})
+		inst := wavefront.NewInst(insts.NewInst())
+		wave.SetDynamicInst(inst)
+		bu.AcceptWave(wave)
+		Expect(bu.cycleLeft).To(Equal(2))
+	})
+
+	It("should accept a second wave when dual-issuing", func() {
+		bu.dualIssue = true
+		bu.pipelineCapacity = 2
+
+		for i := 0; i < 2; i++ {
+			Expect(bu.CanAcceptWave()).To(BeTrue())
+
+			wave := new(wavefront.Wavefront)
+			wave.SetDynamicInst(wavefront.NewInst(insts.NewInst()))
+			bu.AcceptWave(wave)
+		}
+
+		Expect(bu.CanAcceptWave()).To(BeFalse())
+		Expect(bu.pipelineSlots).To(HaveLen(2))
+	})
+
 	It("should run", func() {
 		wave := new(wavefront.Wavefront)
 		inst := wavefront.NewInst(insts.NewInst())
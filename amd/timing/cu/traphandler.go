@@ -0,0 +1,12 @@
+package cu
+
+import "github.com/sarchlab/mgpusim/v4/amd/timing/wavefront"
+
+// A TrapHandler runs the trap handler for the wavefronts that execute s_trap.
+type TrapHandler interface {
+	// HandleTrap is called when the wavefront executes s_trap with the trap
+	// ID. It returns the number of cycles that the trap handler takes, during
+	// which the wavefront stalls. The wavefront continues after the s_trap
+	// instruction when the trap handler returns.
+	HandleTrap(wf *wavefront.Wavefront, trapID int) int
+}
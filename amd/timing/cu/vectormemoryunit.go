@@ -6,6 +6,7 @@ import (
 	"github.com/sarchlab/akita/v4/pipelining"
 	"github.com/sarchlab/akita/v4/sim"
 	"github.com/sarchlab/akita/v4/tracing"
+	"github.com/sarchlab/mgpusim/v4/amd/emu"
 	"github.com/sarchlab/mgpusim/v4/amd/insts"
 	"github.com/sarchlab/mgpusim/v4/amd/timing/wavefront"
 )
@@ -23,7 +24,7 @@ func (i vectorMemInst) TaskID() string {
 type VectorMemoryUnit struct {
 	cu *ComputeUnit
 
-	coalescer coalescer
+	coalescer Coalescer
 
 	numInstInFlight         uint64
 	numTransactionInFlight  uint64
@@ -44,7 +45,7 @@ type VectorMemoryUnit struct {
 // NewVectorMemoryUnit creates a new Vector Memory Unit.
 func NewVectorMemoryUnit(
 	cu *ComputeUnit,
-	coalescer coalescer,
+	coalescer Coalescer,
 ) *VectorMemoryUnit {
 	u := new(VectorMemoryUnit)
 	u.cu = cu
@@ -160,6 +161,11 @@ func (u *VectorMemoryUnit) execute() (madeProgress bool) {
 		if !ok {
 			return false
 		}
+	case insts.MUBUF, insts.MTBUF:
+		ok := u.executeBufferInsts(wave)
+		if !ok {
+			return false
+		}
 	default:
 		log.Panicf("running inst %s in vector memory unit is not supported",
 			insts.NewInstPrinter(nil).Print(inst))
@@ -182,6 +188,10 @@ func (u *VectorMemoryUnit) executeFlatInsts(
 	case 24, 25, 26, 27, 28, 29, 30, 31:
 		return u.executeFlatStore(wavefront)
 	default:
+		if _, ok := inst.AtomicInfo(); ok {
+			return u.executeFlatAtomic(wavefront)
+		}
+
 		log.Panicf("Opcode %d for format FLAT is not supported.", inst.Opcode)
 	}
 
@@ -191,7 +201,7 @@ func (u *VectorMemoryUnit) executeFlatInsts(
 func (u *VectorMemoryUnit) executeFlatLoad(
 	wave *wavefront.Wavefront,
 ) bool {
-	transactions := u.coalescer.generateMemTransactions(wave)
+	transactions := u.coalescer.GenerateMemTransactions(wave)
 
 	if len(transactions) == 0 {
 		u.cu.logInstTask(
@@ -229,7 +239,7 @@ func (u *VectorMemoryUnit) executeFlatLoad(
 func (u *VectorMemoryUnit) executeFlatStore(
 	wave *wavefront.Wavefront,
 ) bool {
-	transactions := u.coalescer.generateMemTransactions(wave)
+	transactions := u.coalescer.GenerateMemTransactions(wave)
 
 	if len(transactions) == 0 {
 		u.cu.logInstTask(
@@ -263,6 +273,158 @@ func (u *VectorMemoryUnit) executeFlatStore(
 	return true
 }
 
+func (u *VectorMemoryUnit) executeFlatAtomic(
+	wave *wavefront.Wavefront,
+) bool {
+	transactions := u.coalescer.GenerateMemTransactions(wave)
+
+	if len(transactions) == 0 {
+		u.cu.logInstTask(
+			wave,
+			wave.DynamicInst(),
+			true,
+		)
+		return true
+	}
+
+	if len(transactions)+len(u.cu.InFlightVectorMemAccess) >
+		u.cu.InFlightVectorMemAccessLimit {
+		return false
+	}
+
+	wave.OutstandingVectorMemAccess++
+	wave.OutstandingScalarMemAccess++
+
+	for i, t := range transactions {
+		u.cu.InFlightVectorMemAccess = append(u.cu.InFlightVectorMemAccess, t)
+		if i != len(transactions)-1 {
+			t.Atomic.CanWaitForCoalesce = true
+		}
+		lowModule := u.cu.VectorMemModules.Find(t.Atomic.Address)
+		t.Atomic.Dst = lowModule
+		t.Atomic.Src = u.cu.ToVectorMem.AsRemote()
+		t.Atomic.PID = wave.PID()
+		u.transactionsWaiting = append(u.transactionsWaiting, t)
+	}
+
+	return true
+}
+
+// executeBufferInsts sends the transactions of a MUBUF or MTBUF instruction.
+// Unlike FLAT instructions, buffer instructions never access the LDS, so they
+// only count as outstanding vector memory accesses.
+func (u *VectorMemoryUnit) executeBufferInsts(
+	wave *wavefront.Wavefront,
+) bool {
+	inst := wave.DynamicInst()
+
+	op, ok := inst.BufferOp()
+	if !ok {
+		log.Panicf("Opcode %d for format %s is not supported.",
+			inst.Opcode, inst.FormatName)
+	}
+
+	if inst.LDS {
+		log.Panicf("buffer loads to the LDS are not supported")
+	}
+
+	if op.Kind == insts.BufferOpCacheControl {
+		u.cu.logInstTask(wave, inst, true)
+		return true
+	}
+
+	transactions := u.coalescer.GenerateMemTransactions(wave)
+
+	if len(transactions)+len(u.cu.InFlightVectorMemAccess) >
+		u.cu.InFlightVectorMemAccessLimit {
+		return false
+	}
+
+	u.writeBufferRegsNotInMemory(wave)
+
+	if len(transactions) == 0 {
+		u.cu.logInstTask(wave, inst, true)
+		return true
+	}
+
+	wave.OutstandingVectorMemAccess++
+
+	for i, t := range transactions {
+		u.cu.InFlightVectorMemAccess = append(u.cu.InFlightVectorMemAccess, t)
+		canWaitForCoalesce := i != len(transactions)-1
+
+		switch {
+		case t.Read != nil:
+			t.Read.CanWaitForCoalesce = canWaitForCoalesce
+			t.Read.Dst = u.cu.VectorMemModules.Find(t.Read.Address)
+			t.Read.Src = u.cu.ToVectorMem.AsRemote()
+			t.Read.PID = wave.PID()
+		case t.Write != nil:
+			t.Write.CanWaitForCoalesce = canWaitForCoalesce
+			t.Write.Dst = u.cu.VectorMemModules.Find(t.Write.Address)
+			t.Write.Src = u.cu.ToVectorMem.AsRemote()
+			t.Write.PID = wave.PID()
+		default:
+			t.Atomic.CanWaitForCoalesce = canWaitForCoalesce
+			t.Atomic.Dst = u.cu.VectorMemModules.Find(t.Atomic.Address)
+			t.Atomic.Src = u.cu.ToVectorMem.AsRemote()
+			t.Atomic.PID = wave.PID()
+		}
+
+		u.transactionsWaiting = append(u.transactionsWaiting, t)
+	}
+
+	return true
+}
+
+// writeBufferRegsNotInMemory writes the registers of a buffer load or a
+// returning buffer atomic that do not come from the memory, which are the
+// constants that the destination selects pick and the components outside
+// the buffer.
+func (u *VectorMemoryUnit) writeBufferRegsNotInMemory(
+	wave *wavefront.Wavefront,
+) {
+	inst := wave.Inst()
+	op, _ := inst.BufferOp()
+	atomic, isAtomic := inst.AtomicInfo()
+
+	if op.Kind == insts.BufferOpStore || (isAtomic && !atomic.Return) {
+		return
+	}
+
+	rsrc := emu.ReadBufferResource(wave)
+	comps := emu.BufferComponents(inst, rsrc)
+	exec := wave.EXEC()
+
+	for i := 0; i < 64; i++ {
+		if !laneMasked(exec, uint(i)) {
+			continue
+		}
+
+		access := emu.BufferLaneAccess(wave, rsrc, i)
+
+		if isAtomic {
+			if !rsrc.InRange(access, 0, uint64(atomic.ByteSize)) {
+				wave.WriteOperandBytes(inst.Dst, i, make([]byte, atomic.ByteSize))
+			}
+
+			continue
+		}
+
+		for j, comp := range comps {
+			if comp.InMemory &&
+				rsrc.InRange(access, comp.Offset, comp.ByteSize) {
+				continue
+			}
+
+			// The components in the memory have a Value of 0.
+			reg := inst.Dst.Register.RegIndex() + j
+			wave.WriteOperandBytes(insts.NewVRegOperand(reg, reg, 1), i,
+				insts.Uint32ToBytes(comp.Value))
+		}
+	}
+}
+
 func (u *VectorMemoryUnit) sendRequest() bool {
 	madeProgress := false
 	for i := 0; i < 16; i++ {
@@ -273,9 +435,12 @@ func (u *VectorMemoryUnit) sendRequest() bool {
 
 		var req sim.Msg
 		info := item.(VectorMemAccessInfo)
-		if info.Read != nil {
+		switch {
+		case info.Read != nil:
 			req = info.Read
-		} else {
+		case info.Atomic != nil:
+			req = info.Atomic
+		default:
 			req = info.Write
 		}
 
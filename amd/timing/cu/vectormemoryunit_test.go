@@ -15,7 +15,7 @@ var _ = Describe("Vector Memory Unit", func() {
 	var (
 		mockCtrl            *gomock.Controller
 		cu                  *ComputeUnit
-		coalescer           *Mockcoalescer
+		coalescer           *MockCoalescer
 		vecMemUnit          *VectorMemoryUnit
 		vectorMem           *MockPort
 		toVectorMem         *MockPort
@@ -28,7 +28,7 @@ var _ = Describe("Vector Memory Unit", func() {
 	BeforeEach(func() {
 		mockCtrl = gomock.NewController(GinkgoT())
 		cu = NewComputeUnit("CU", nil)
-		coalescer = NewMockcoalescer(mockCtrl)
+		coalescer = NewMockCoalescer(mockCtrl)
 		vecMemUnit = NewVectorMemoryUnit(cu, coalescer)
 		toVectorMem = NewMockPort(mockCtrl)
 		instPipeline = NewMockPipeline(mockCtrl)
@@ -90,7 +90,7 @@ var _ = Describe("Vector Memory Unit", func() {
 				Build()
 			transactions[i].Read = read
 		}
-		coalescer.EXPECT().generateMemTransactions(wave).Return(transactions)
+		coalescer.EXPECT().GenerateMemTransactions(wave).Return(transactions)
 		gomock.InOrder(
 			instBuffer.EXPECT().Peek().Return(vectorMemInst{wavefront: wave}),
 			instBuffer.EXPECT().Pop().Return(vectorMemInst{wavefront: wave}),
@@ -125,7 +125,7 @@ var _ = Describe("Vector Memory Unit", func() {
 				Build()
 			transactions[i].Write = write
 		}
-		coalescer.EXPECT().generateMemTransactions(wave).Return(transactions)
+		coalescer.EXPECT().GenerateMemTransactions(wave).Return(transactions)
 		gomock.InOrder(
 			instBuffer.EXPECT().Peek().Return(vectorMemInst{wavefront: wave}),
 			instBuffer.EXPECT().Pop().Return(vectorMemInst{wavefront: wave}),
@@ -6,4 +6,8 @@ import "github.com/sarchlab/mgpusim/v4/amd/timing/wavefront"
 // in a list of wavefront pools
 type WfArbiter interface {
 	Arbitrate(wfpools []*WavefrontPool) []*wavefront.Wavefront
+
+	// Acted tells the arbiter the wavefronts, among the ones selected by the
+	// last Arbitrate call, that take the action.
+	Acted(wfs []*wavefront.Wavefront)
 }
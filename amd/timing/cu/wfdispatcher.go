@@ -3,6 +3,7 @@ package cu
 import (
 	"log"
 
+	"github.com/sarchlab/mgpusim/v4/amd/emu"
 	"github.com/sarchlab/mgpusim/v4/amd/insts"
 	"github.com/sarchlab/mgpusim/v4/amd/protocol"
 	"github.com/sarchlab/mgpusim/v4/amd/timing/wavefront"
@@ -65,8 +66,12 @@ func (d *WfDispatcherImpl) initRegisters(wf *wavefront.Wavefront) {
 
 	SGPRPtr := 0
 	if co.EnableSgprPrivateSegmentBuffer {
-		// log.Printf("EnableSgprPrivateSegmentBuffer is not supported")
-		// fmt.Printf("s%d SGPRPrivateSegmentBuffer\n", SGPRPtr/4)
+		d.cu.SRegFile.Write(RegisterAccess{
+			0, insts.SReg(SGPRPtr / 4), 4, 0, wf.SRegOffset,
+			emu.ScratchResourceDescriptor(wf.ScratchAddress),
+			false,
+		})
+
 		SGPRPtr += 16
 	}
 
@@ -105,14 +110,22 @@ func (d *WfDispatcherImpl) initRegisters(wf *wavefront.Wavefront) {
 	}
 
 	if co.EnableSgprFlatScratchInit {
-		log.Printf("EnableSgprFlatScratchInit is not supported")
-		// fmt.Printf("s%d SGPRFlatScratchInit\n", SGPRPtr/4)
+		d.cu.SRegFile.Write(RegisterAccess{
+			0, insts.SReg(SGPRPtr / 4), 2, 0, wf.SRegOffset,
+			insts.Uint64ToBytes(emu.FlatScratchInit(wf.Wavefront)),
+			false,
+		})
+
 		SGPRPtr += 8
 	}
 
 	if co.EnableSgprPrivateSegmentSize {
-		log.Printf("EnableSgprPrivateSegmentSize is not supported")
-		// fmt.Printf("s%d SGPRPrivateSegmentSize\n", SGPRPtr/4)
+		d.cu.SRegFile.Write(RegisterAccess{
+			0, insts.SReg(SGPRPtr / 4), 1, 0, wf.SRegOffset,
+			insts.Uint32ToBytes(pkt.PrivateSegmentSize),
+			false,
+		})
+
 		SGPRPtr += 4
 	}
 
@@ -191,21 +204,30 @@ func (d *WfDispatcherImpl) initRegisters(wf *wavefront.Wavefront) {
 		})
 
 		// fmt.Printf("s%d WorkGroupIdZ\n", SGPRPtr/4)
-		// SGPRPtr += 4
+		SGPRPtr += 4
 	}
 
 	if co.EnableSgprWorkGroupInfo() {
-		log.Printf("EnableSgprPrivateSegmentSize is not supported")
-		// SGPRPtr += 4
+		log.Printf("EnableSgprWorkGroupInfo is not supported")
+		SGPRPtr += 4
 	}
 
 	if co.EnableSgprPrivateSegmentWaveByteOffset() {
-		log.Printf("EnableSgprPrivateSegentWaveByteOffset is not supported")
-		// SGPRPtr += 4
+		d.cu.SRegFile.Write(RegisterAccess{
+			0, insts.SReg(SGPRPtr / 4), 1, 0, wf.SRegOffset,
+			insts.Uint32ToBytes(uint32(wf.ScratchWaveOffset)),
+			false,
+		})
+	}
+
+	if co.Version == insts.CodeObjectV5 {
+		// CDNA3 initializes FLAT_SCRATCH to the private memory of the
+		// wavefront, rather than letting the kernel compute it.
+		wf.FlatScratch = wf.ScratchBase()
 	}
 
 	var x, y, z int
-	for i := wf.FirstWiFlatID; i < wf.FirstWiFlatID+64; i++ {
+	for i := wf.FirstWiFlatID; i < wf.FirstWiFlatID+wf.LaneCount(); i++ {
 		z = i / (wf.WG.SizeX * wf.WG.SizeY)
 		y = i % (wf.WG.SizeX * wf.WG.SizeY) / wf.WG.SizeX
 		x = i % (wf.WG.SizeX * wf.WG.SizeY) % wf.WG.SizeX
@@ -8,6 +8,8 @@ import (
 	"github.com/sarchlab/akita/v4/mem/vm"
 	"github.com/sarchlab/akita/v4/sim"
 	"github.com/sarchlab/akita/v4/tracing"
+	"github.com/sarchlab/mgpusim/v4/amd/emu"
+	"github.com/sarchlab/mgpusim/v4/amd/protocol"
 )
 
 type transaction struct {
@@ -20,6 +22,12 @@ type transaction struct {
 type reqToBottom struct {
 	reqFromTop  mem.AccessReq
 	reqToBottom mem.AccessReq
+
+	// atomicReq is the atomic request that the atomic unit still holds while
+	// reqToBottom writes the result to the memory provider. atomicOld is the
+	// value before the operation, which the request from the top receives.
+	atomicReq *protocol.AtomicReq
+	atomicOld []byte
 }
 
 // Comp is an AddressTranslator that forwards the read/write requests with
@@ -30,6 +38,7 @@ type Comp struct {
 
 	topPort         sim.Port
 	bottomPort      sim.Port
+	atomicPort      sim.Port
 	translationPort sim.Port
 	ctrlPort        sim.Port
 
@@ -37,6 +46,7 @@ type Comp struct {
 	deviceID              uint64
 	numReqPerCycle        int
 	memoryPortMapper      mem.AddressToPortMapper
+	atomicPortMapper      mem.AddressToPortMapper
 	translationPortMapper mem.AddressToPortMapper
 
 	isFlushing bool
@@ -74,7 +84,8 @@ func (m *middleware) runPipeline() bool {
 	madeProgress := false
 
 	for i := 0; i < m.numReqPerCycle; i++ {
-		madeProgress = m.respond() || madeProgress
+		madeProgress = m.respond(m.bottomPort) || madeProgress
+		madeProgress = m.respond(m.atomicPort) || madeProgress
 	}
 
 	for i := 0; i < m.numReqPerCycle; i++ {
@@ -154,7 +165,8 @@ func (m *middleware) parseTranslation() bool {
 			translatedReq := m.createTranslatedReq(
 				reqFromTop, t.translationRsp.Page)
 
-			err := m.bottomPort.Send(translatedReq)
+			port := m.portToBottom(translatedReq)
+			err := port.Send(translatedReq)
 			if err != nil {
 				return false
 			}
@@ -162,7 +174,7 @@ func (m *middleware) parseTranslation() bool {
 			tracing.AddMilestone(
 				tracing.MsgIDAtReceiver(translatedReq, m.Comp),
 				tracing.MilestoneKindNetworkBusy,
-				m.bottomPort.Name(),
+				port.Name(),
 				m.Comp.Name(),
 				m.Comp,
 			)
@@ -214,7 +226,8 @@ func (m *middleware) parseTranslation() bool {
 	translatedReq := m.createTranslatedReq(
 		reqFromTop, transaction.translationRsp.Page)
 
-	err := m.bottomPort.Send(translatedReq)
+	port := m.portToBottom(translatedReq)
+	err := port.Send(translatedReq)
 	if err != nil {
 		return false
 	}
@@ -222,7 +235,7 @@ func (m *middleware) parseTranslation() bool {
 	tracing.AddMilestone(
 		tracing.MsgIDAtReceiver(translatedReq, m.Comp),
 		tracing.MilestoneKindNetworkBusy,
-		m.bottomPort.Name(),
+		port.Name(),
 		m.Comp.Name(),
 		m.Comp,
 	)
@@ -255,9 +268,20 @@ func (m *middleware) parseTranslation() bool {
 	return true
 }
 
+// portToBottom returns the port that sends the translated request. Atomic
+// requests bypass the memory provider, which may be a cache that cannot
+// perform the atomic operations. writeAtomicResult updates the cache later.
+func (m *middleware) portToBottom(req mem.AccessReq) sim.Port {
+	if _, ok := req.(*protocol.AtomicReq); ok {
+		return m.atomicPort
+	}
+
+	return m.bottomPort
+}
+
 //nolint:funlen,gocyclo
-func (m *middleware) respond() bool {
-	rsp := m.bottomPort.PeekIncoming()
+func (m *middleware) respond(bottomPort sim.Port) bool {
+	rsp := bottomPort.PeekIncoming()
 	if rsp == nil {
 		return false
 	}
@@ -275,6 +299,10 @@ func (m *middleware) respond() bool {
 		reqInBottom = m.isReqInBottomByID(rsp.RespondTo)
 		if reqInBottom {
 			reqToBottomCombo = m.findReqToBottomByID(rsp.RespondTo)
+			if _, ok := reqToBottomCombo.reqToBottom.(*protocol.AtomicReq); ok {
+				return m.writeAtomicResult(reqToBottomCombo, rsp)
+			}
+
 			reqFromTop = reqToBottomCombo.reqFromTop
 			drToTop := mem.DataReadyRspBuilder{}.
 				WithSrc(m.topPort.AsRemote()).
@@ -295,12 +323,26 @@ func (m *middleware) respond() bool {
 		reqInBottom = m.isReqInBottomByID(rsp.RespondTo)
 		if reqInBottom {
 			reqToBottomCombo = m.findReqToBottomByID(rsp.RespondTo)
+			if reqToBottomCombo.atomicReq != nil &&
+				!m.releaseAtomic(reqToBottomCombo) {
+				return false
+			}
+
 			reqFromTop = reqToBottomCombo.reqFromTop
 			rspToTop = mem.WriteDoneRspBuilder{}.
 				WithSrc(m.topPort.AsRemote()).
 				WithDst(reqFromTop.Meta().Src).
 				WithRspTo(reqFromTop.Meta().ID).
 				Build()
+
+			if reqToBottomCombo.atomicOld != nil {
+				rspToTop = mem.DataReadyRspBuilder{}.
+					WithSrc(m.topPort.AsRemote()).
+					WithDst(reqFromTop.Meta().Src).
+					WithRspTo(reqFromTop.Meta().ID).
+					WithData(reqToBottomCombo.atomicOld).
+					Build()
+			}
 			tracing.AddMilestone(
 				tracing.MsgIDAtReceiver(reqFromTop, m.Comp),
 				tracing.MilestoneKindSubTask,
@@ -333,7 +375,75 @@ func (m *middleware) respond() bool {
 		tracing.TraceReqComplete(reqToBottomCombo.reqFromTop, m.Comp)
 	}
 
-	m.bottomPort.RetrieveIncoming()
+	bottomPort.RetrieveIncoming()
+
+	return true
+}
+
+// writeAtomicResult writes the result of an atomic operation through the
+// memory provider. The atomic unit changes the memory below the memory
+// provider, so a cache there would keep serving the value before the
+// operation. The write updates the cache line if the cache holds it.
+func (m *middleware) writeAtomicResult(
+	combo reqToBottom,
+	rsp *mem.DataReadyRsp,
+) bool {
+	atomic := combo.reqToBottom.(*protocol.AtomicReq)
+
+	data := make([]byte, len(rsp.Data))
+	copy(data, rsp.Data)
+	emu.ApplyAtomic(atomic.Atomic, data, atomic.Value, atomic.Cmp)
+
+	write := mem.WriteReqBuilder{}.
+		WithSrc(m.bottomPort.AsRemote()).
+		WithDst(m.memoryPortMapper.Find(atomic.Address)).
+		WithAddress(atomic.Address).
+		WithData(data).
+		WithPID(0).
+		Build()
+
+	err := m.bottomPort.Send(write)
+	if err != nil {
+		return false
+	}
+
+	m.removeReqToBottomByID(atomic.ID)
+	m.inflightReqToBottom = append(m.inflightReqToBottom,
+		reqToBottom{
+			reqFromTop:  combo.reqFromTop,
+			reqToBottom: write,
+			atomicReq:   atomic,
+			atomicOld:   rsp.Data,
+		})
+
+	m.atomicPort.RetrieveIncoming()
+
+	tracing.TraceReqFinalize(atomic, m.Comp)
+	tracing.TraceReqInitiate(write, m.Comp,
+		tracing.MsgIDAtReceiver(combo.reqFromTop, m.Comp))
+
+	return true
+}
+
+// releaseAtomic tells the atomic unit that the result of the atomic operation
+// has been written through the memory provider.
+func (m *middleware) releaseAtomic(combo reqToBottom) bool {
+	done := protocol.AtomicDoneReqBuilder{}.
+		WithSrc(m.atomicPort.AsRemote()).
+		WithDst(combo.atomicReq.Dst).
+		WithAtomicReqID(combo.atomicReq.ID).
+		Build()
+
+	err := m.atomicPort.Send(done)
+	if err != nil {
+		return false
+	}
+
+	for i := range m.inflightReqToBottom {
+		if m.inflightReqToBottom[i].reqToBottom == combo.reqToBottom {
+			m.inflightReqToBottom[i].atomicReq = nil
+		}
+	}
 
 	return true
 }
@@ -347,6 +457,8 @@ func (m *middleware) createTranslatedReq(
 		return m.createTranslatedReadReq(req, page)
 	case *mem.WriteReq:
 		return m.createTranslatedWriteReq(req, page)
+	case *protocol.AtomicReq:
+		return m.createTranslatedAtomicReq(req, page)
 	default:
 		log.Panicf("cannot translate request of type %s", reflect.TypeOf(req))
 		return nil
@@ -392,6 +504,37 @@ func (m *middleware) createTranslatedWriteReq(
 	return clone
 }
 
+func (m *middleware) createTranslatedAtomicReq(
+	req *protocol.AtomicReq,
+	page vm.Page,
+) *protocol.AtomicReq {
+	if m.atomicPortMapper == nil {
+		log.Panicf("address translator %s cannot send atomic requests",
+			m.Name())
+	}
+
+	offset := req.Address % (1 << m.log2PageSize)
+	addr := page.PAddr + offset
+
+	dst := m.atomicPortMapper.Find(addr)
+	if dst == "" {
+		log.Panicf("no atomic unit serves address 0x%x", addr)
+	}
+
+	clone := protocol.AtomicReqBuilder{}.
+		WithSrc(m.atomicPort.AsRemote()).
+		WithDst(dst).
+		WithAddress(addr).
+		WithPID(0).
+		WithAtomic(req.Atomic).
+		WithValue(req.Value).
+		WithCmp(req.Cmp).
+		Build()
+	clone.CanWaitForCoalesce = req.CanWaitForCoalesce
+
+	return clone
+}
+
 func (m *middleware) addrToPageID(addr uint64) uint64 {
 	return (addr >> m.log2PageSize) << m.log2PageSize
 }
@@ -512,6 +655,9 @@ func (m *middleware) handleRestartReq(
 	for m.bottomPort.RetrieveIncoming() != nil {
 	}
 
+	for m.atomicPort.RetrieveIncoming() != nil {
+	}
+
 	for m.translationPort.RetrieveIncoming() != nil {
 	}
 
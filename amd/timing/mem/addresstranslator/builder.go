@@ -16,6 +16,7 @@ type Builder struct {
 	deviceID       uint64
 
 	memPortMapper             mem.AddressToPortMapper
+	atomicPortMapper          mem.AddressToPortMapper
 	memPortMapperType         string
 	memRemotePorts            []sim.RemotePort
 	translationPortMapper     mem.AddressToPortMapper
@@ -86,6 +87,14 @@ func (b Builder) WithMemoryProviderMapper(f mem.AddressToPortMapper) Builder {
 	return b
 }
 
+// WithAtomicProviderMapper sets the mapper that can find the atomic unit that
+// performs an atomic request according to the physical address. Atomic
+// requests are sent through the Atomic port, bypassing the memory providers.
+func (b Builder) WithAtomicProviderMapper(f mem.AddressToPortMapper) Builder {
+	b.atomicPortMapper = f
+	return b
+}
+
 // WithMemoryProviderType sets the type of the memory provider mapper. The
 // mapper can find the remote port that can provide the memory service according
 // to the virtual address. The type can be "single" or "interleaved".
@@ -161,6 +170,7 @@ func (b Builder) Build(name string) *Comp {
 	b.setupMemoryPortMapper(t)
 	b.setupTranslationPortMapper(t)
 
+	t.atomicPortMapper = b.atomicPortMapper
 	t.numReqPerCycle = b.numReqPerCycle
 	t.log2PageSize = b.log2PageSize
 	t.deviceID = b.deviceID
@@ -235,6 +245,10 @@ func (b Builder) createPorts(name string, t *Comp) {
 		name+".BottomPort")
 	t.AddPort("Bottom", t.bottomPort)
 
+	t.atomicPort = sim.NewPort(t, b.numReqPerCycle, b.numReqPerCycle,
+		name+".AtomicPort")
+	t.AddPort("Atomic", t.atomicPort)
+
 	t.translationPort = sim.NewPort(t, b.numReqPerCycle, b.numReqPerCycle,
 		name+".TranslationPort")
 	t.AddPort("Translation", t.translationPort)
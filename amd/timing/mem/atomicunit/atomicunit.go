@@ -0,0 +1,281 @@
+package atomicunit
+
+import (
+	"log"
+	"reflect"
+
+	"github.com/sarchlab/akita/v4/mem/mem"
+	"github.com/sarchlab/akita/v4/sim"
+	"github.com/sarchlab/akita/v4/tracing"
+	"github.com/sarchlab/mgpusim/v4/amd/emu"
+	"github.com/sarchlab/mgpusim/v4/amd/protocol"
+)
+
+type transactionState int
+
+const (
+	transactionWaiting transactionState = iota
+	transactionReading
+	transactionWriting
+	transactionResponding
+	transactionHolding
+)
+
+type transaction struct {
+	state transactionState
+	req   *protocol.AtomicReq
+	read  *mem.ReadReq
+	write *mem.WriteReq
+	old   []byte
+}
+
+// Comp is an AtomicUnit that services the atomic requests with the read and
+// write requests to the cache below it.
+type Comp struct {
+	*sim.TickingComponent
+	sim.MiddlewareHolder
+
+	topPort    sim.Port
+	bottomPort sim.Port
+
+	numReqPerCycle   int
+	maxTransactions  int
+	log2BlockSize    uint64
+	memoryPortMapper mem.AddressToPortMapper
+
+	transactions []*transaction
+}
+
+// Tick updates the state of the AtomicUnit.
+func (c *Comp) Tick() bool {
+	return c.MiddlewareHolder.Tick()
+}
+
+type middleware struct {
+	*Comp
+}
+
+// Tick processes the responses before the requests so that the transactions
+// that complete release their slots in the same cycle.
+func (m *middleware) Tick() bool {
+	madeProgress := false
+
+	for i := 0; i < m.numReqPerCycle; i++ {
+		madeProgress = m.respond() || madeProgress
+	}
+
+	for i := 0; i < m.numReqPerCycle; i++ {
+		madeProgress = m.parseBottom() || madeProgress
+	}
+
+	madeProgress = m.sendReads() || madeProgress
+
+	for i := 0; i < m.numReqPerCycle; i++ {
+		madeProgress = m.acceptReq() || madeProgress
+	}
+
+	return madeProgress
+}
+
+func (m *middleware) acceptReq() bool {
+	item := m.topPort.PeekIncoming()
+	if item == nil {
+		return false
+	}
+
+	switch req := item.(type) {
+	case *protocol.AtomicReq:
+		if len(m.transactions) >= m.maxTransactions {
+			return false
+		}
+
+		m.transactions = append(m.transactions, &transaction{req: req})
+
+		tracing.TraceReqReceive(req, m.Comp)
+	case *protocol.AtomicDoneReq:
+		m.release(req)
+	default:
+		log.Panicf("atomic unit cannot handle request of type %s",
+			reflect.TypeOf(item))
+	}
+
+	m.topPort.RetrieveIncoming()
+
+	return true
+}
+
+// release removes the transaction that the requester is done with, so that
+// the next atomic operation on the same block can start.
+func (m *middleware) release(done *protocol.AtomicDoneReq) {
+	for i, t := range m.transactions {
+		if t.state == transactionHolding && t.req.ID == done.AtomicReqID {
+			m.transactions = append(m.transactions[:i], m.transactions[i+1:]...)
+
+			tracing.TraceReqComplete(t.req, m.Comp)
+
+			return
+		}
+	}
+
+	log.Panicf("no atomic request %s to release", done.AtomicReqID)
+}
+
+// sendReads starts the transactions that do not access the same location as
+// any earlier transaction, so that the atomic operations on a location are
+// performed one at a time and in order.
+func (m *middleware) sendReads() bool {
+	madeProgress := false
+	numSent := 0
+
+	for i, t := range m.transactions {
+		if numSent >= m.numReqPerCycle {
+			break
+		}
+
+		if t.state != transactionWaiting || m.hasEarlierConflict(i) {
+			continue
+		}
+
+		read := mem.ReadReqBuilder{}.
+			WithSrc(m.bottomPort.AsRemote()).
+			WithDst(m.memoryPortMapper.Find(t.req.Address)).
+			WithAddress(t.req.Address).
+			WithByteSize(t.req.GetByteSize()).
+			WithPID(t.req.PID).
+			Build()
+
+		err := m.bottomPort.Send(read)
+		if err != nil {
+			break
+		}
+
+		t.read = read
+		t.state = transactionReading
+		numSent++
+		madeProgress = true
+
+		tracing.TraceReqInitiate(read, m.Comp,
+			tracing.MsgIDAtReceiver(t.req, m.Comp))
+	}
+
+	return madeProgress
+}
+
+func (m *middleware) hasEarlierConflict(index int) bool {
+	blk := m.blockID(m.transactions[index].req.Address)
+
+	for _, t := range m.transactions[:index] {
+		if m.blockID(t.req.Address) == blk {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (m *middleware) blockID(addr uint64) uint64 {
+	return addr >> m.log2BlockSize
+}
+
+func (m *middleware) parseBottom() bool {
+	item := m.bottomPort.PeekIncoming()
+	if item == nil {
+		return false
+	}
+
+	switch rsp := item.(type) {
+	case *mem.DataReadyRsp:
+		if !m.writeBack(rsp) {
+			return false
+		}
+	case *mem.WriteDoneRsp:
+		t := m.findTransaction(func(t *transaction) bool {
+			return t.write != nil && t.write.ID == rsp.RespondTo
+		})
+		t.state = transactionResponding
+
+		tracing.TraceReqFinalize(t.write, m.Comp)
+	default:
+		log.Panicf("atomic unit cannot handle response of type %s",
+			reflect.TypeOf(item))
+	}
+
+	m.bottomPort.RetrieveIncoming()
+
+	return true
+}
+
+// writeBack applies the atomic operation to the data read from the memory and
+// writes the result to the memory.
+func (m *middleware) writeBack(rsp *mem.DataReadyRsp) bool {
+	t := m.findTransaction(func(t *transaction) bool {
+		return t.read != nil && t.read.ID == rsp.RespondTo
+	})
+
+	data := make([]byte, len(rsp.Data))
+	copy(data, rsp.Data)
+	old := emu.ApplyAtomic(t.req.Atomic, data, t.req.Value, t.req.Cmp)
+
+	write := mem.WriteReqBuilder{}.
+		WithSrc(m.bottomPort.AsRemote()).
+		WithDst(m.memoryPortMapper.Find(t.req.Address)).
+		WithAddress(t.req.Address).
+		WithData(data).
+		WithPID(t.req.PID).
+		Build()
+
+	err := m.bottomPort.Send(write)
+	if err != nil {
+		return false
+	}
+
+	t.old = old
+	t.write = write
+	t.state = transactionWriting
+
+	tracing.TraceReqFinalize(t.read, m.Comp)
+	tracing.TraceReqInitiate(write, m.Comp,
+		tracing.MsgIDAtReceiver(t.req, m.Comp))
+
+	return true
+}
+
+// respond sends the old value to the requester. The transaction holds the
+// block until the requester is done with it.
+func (m *middleware) respond() bool {
+	for _, t := range m.transactions {
+		if t.state != transactionResponding {
+			continue
+		}
+
+		rsp := mem.DataReadyRspBuilder{}.
+			WithSrc(m.topPort.AsRemote()).
+			WithDst(t.req.Src).
+			WithRspTo(t.req.ID).
+			WithData(t.old).
+			Build()
+
+		err := m.topPort.Send(rsp)
+		if err != nil {
+			return false
+		}
+
+		t.state = transactionHolding
+
+		return true
+	}
+
+	return false
+}
+
+func (m *middleware) findTransaction(
+	match func(t *transaction) bool,
+) *transaction {
+	for _, t := range m.transactions {
+		if match(t) {
+			return t
+		}
+	}
+
+	panic("transaction not found")
+}
@@ -0,0 +1,16 @@
+package atomicunit
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+//go:generate mockgen -write_package_comment=false -package=$GOPACKAGE -destination=mock_sim_test.go github.com/sarchlab/akita/v4/sim Port,Engine
+//go:generate mockgen -write_package_comment=false -package=$GOPACKAGE -destination=mock_mem_test.go github.com/sarchlab/akita/v4/mem/mem AddressToPortMapper
+
+func TestAtomicUnit(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Atomic Unit Suite")
+}
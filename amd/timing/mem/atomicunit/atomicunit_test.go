@@ -0,0 +1,339 @@
+package atomicunit
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/akita/v4/mem/mem"
+	"github.com/sarchlab/akita/v4/sim"
+	"github.com/sarchlab/mgpusim/v4/amd/insts"
+	"github.com/sarchlab/mgpusim/v4/amd/protocol"
+	"go.uber.org/mock/gomock"
+)
+
+var _ = Describe("AtomicUnit", func() {
+	var (
+		mockCtrl   *gomock.Controller
+		engine     *MockEngine
+		topPort    *MockPort
+		bottomPort *MockPort
+		mapper     *MockAddressToPortMapper
+		comp       *Comp
+		m          *middleware
+	)
+
+	newAtomic := func(
+		addr uint64,
+		op insts.AtomicOp,
+		value, cmp uint64,
+	) *protocol.AtomicReq {
+		return protocol.AtomicReqBuilder{}.
+			WithSrc(sim.RemotePort("CU")).
+			WithDst(sim.RemotePort("AtomicUnit.TopPort")).
+			WithAddress(addr).
+			WithAtomic(insts.AtomicInfo{Op: op, ByteSize: 4, Return: true}).
+			WithValue(value).
+			WithCmp(cmp).
+			Build()
+	}
+
+	addTransaction := func(
+		req *protocol.AtomicReq,
+		state transactionState,
+	) *transaction {
+		t := &transaction{req: req, state: state}
+		m.transactions = append(m.transactions, t)
+
+		return t
+	}
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		engine = NewMockEngine(mockCtrl)
+		topPort = NewMockPort(mockCtrl)
+		bottomPort = NewMockPort(mockCtrl)
+		mapper = NewMockAddressToPortMapper(mockCtrl)
+
+		topPort.EXPECT().AsRemote().
+			Return(sim.RemotePort("AtomicUnit.TopPort")).AnyTimes()
+		bottomPort.EXPECT().AsRemote().
+			Return(sim.RemotePort("AtomicUnit.BottomPort")).AnyTimes()
+		mapper.EXPECT().Find(gomock.Any()).
+			Return(sim.RemotePort("L2")).AnyTimes()
+
+		comp = MakeBuilder().
+			WithEngine(engine).
+			WithMaxTransactions(4).
+			WithMemoryProviderMapper(mapper).
+			Build("AtomicUnit")
+		comp.topPort = topPort
+		comp.bottomPort = bottomPort
+		m = &middleware{Comp: comp}
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	It("should accept atomic requests", func() {
+		req := newAtomic(0x100, insts.AtomicOpAdd, 1, 0)
+		topPort.EXPECT().PeekIncoming().Return(req)
+		topPort.EXPECT().RetrieveIncoming().Return(req)
+
+		madeProgress := m.acceptReq()
+
+		Expect(madeProgress).To(BeTrue())
+		Expect(m.transactions).To(HaveLen(1))
+		Expect(m.transactions[0].req).To(BeIdenticalTo(req))
+		Expect(m.transactions[0].state).To(Equal(transactionWaiting))
+	})
+
+	It("should not accept requests when the transactions are full", func() {
+		for i := 0; i < 4; i++ {
+			addTransaction(newAtomic(uint64(i)*0x100, insts.AtomicOpAdd, 1, 0),
+				transactionWaiting)
+		}
+
+		req := newAtomic(0x400, insts.AtomicOpAdd, 1, 0)
+		topPort.EXPECT().PeekIncoming().Return(req)
+
+		madeProgress := m.acceptReq()
+
+		Expect(madeProgress).To(BeFalse())
+		Expect(m.transactions).To(HaveLen(4))
+	})
+
+	It("should panic on requests that are not atomic", func() {
+		read := mem.ReadReqBuilder{}.WithAddress(0x100).Build()
+		topPort.EXPECT().PeekIncoming().Return(read)
+
+		Expect(func() { m.acceptReq() }).To(Panic())
+	})
+
+	It("should serialize the atomics on the same block", func() {
+		t0 := addTransaction(newAtomic(0x100, insts.AtomicOpAdd, 1, 0),
+			transactionWaiting)
+		t1 := addTransaction(newAtomic(0x104, insts.AtomicOpAdd, 1, 0),
+			transactionWaiting)
+		t2 := addTransaction(newAtomic(0x200, insts.AtomicOpAdd, 1, 0),
+			transactionWaiting)
+
+		Expect(m.hasEarlierConflict(0)).To(BeFalse())
+		Expect(m.hasEarlierConflict(1)).To(BeTrue())
+		Expect(m.hasEarlierConflict(2)).To(BeFalse())
+
+		var reads []*mem.ReadReq
+		bottomPort.EXPECT().Send(gomock.Any()).
+			DoAndReturn(func(msg sim.Msg) *sim.SendError {
+				reads = append(reads, msg.(*mem.ReadReq))
+				return nil
+			}).Times(2)
+
+		madeProgress := m.sendReads()
+
+		Expect(madeProgress).To(BeTrue())
+		Expect(reads).To(HaveLen(2))
+		Expect(reads[0].Address).To(Equal(uint64(0x100)))
+		Expect(reads[0].AccessByteSize).To(Equal(uint64(4)))
+		Expect(reads[1].Address).To(Equal(uint64(0x200)))
+		Expect(t0.state).To(Equal(transactionReading))
+		Expect(t0.read).To(BeIdenticalTo(reads[0]))
+		Expect(t1.state).To(Equal(transactionWaiting))
+		Expect(t2.state).To(Equal(transactionReading))
+	})
+
+	It("should start an atomic after the earlier one on the block completes",
+		func() {
+			t0 := addTransaction(newAtomic(0x100, insts.AtomicOpAdd, 1, 0),
+				transactionResponding)
+			t0.old = []byte{1, 0, 0, 0}
+			t1 := addTransaction(newAtomic(0x104, insts.AtomicOpAdd, 1, 0),
+				transactionWaiting)
+
+			done := protocol.AtomicDoneReqBuilder{}.
+				WithAtomicReqID(t0.req.ID).
+				Build()
+
+			topPort.EXPECT().Send(gomock.Any()).Return(nil)
+			topPort.EXPECT().PeekIncoming().Return(done)
+			topPort.EXPECT().RetrieveIncoming().Return(done)
+			bottomPort.EXPECT().Send(gomock.Any()).Return(nil)
+
+			m.respond()
+			m.sendReads()
+
+			Expect(t0.state).To(Equal(transactionHolding))
+			Expect(t1.state).To(Equal(transactionWaiting))
+
+			m.acceptReq()
+			m.sendReads()
+
+			Expect(m.transactions).To(ConsistOf(t1))
+			Expect(t1.state).To(Equal(transactionReading))
+		})
+
+	It("should release the block when the transactions are full", func() {
+		var t0 *transaction
+		for i := 0; i < 4; i++ {
+			t := addTransaction(
+				newAtomic(uint64(i)*0x100, insts.AtomicOpAdd, 1, 0),
+				transactionHolding)
+			if i == 0 {
+				t0 = t
+			}
+		}
+
+		done := protocol.AtomicDoneReqBuilder{}.
+			WithAtomicReqID(t0.req.ID).
+			Build()
+		topPort.EXPECT().PeekIncoming().Return(done)
+		topPort.EXPECT().RetrieveIncoming().Return(done)
+
+		Expect(m.acceptReq()).To(BeTrue())
+		Expect(m.transactions).To(HaveLen(3))
+		Expect(m.transactions).NotTo(ContainElement(t0))
+	})
+
+	It("should panic if a done request matches no held transaction", func() {
+		t := addTransaction(newAtomic(0x100, insts.AtomicOpAdd, 1, 0),
+			transactionWriting)
+		done := protocol.AtomicDoneReqBuilder{}.
+			WithAtomicReqID(t.req.ID).
+			Build()
+		topPort.EXPECT().PeekIncoming().Return(done)
+
+		Expect(func() { m.acceptReq() }).To(Panic())
+	})
+
+	It("should stop sending reads if the bottom port is busy", func() {
+		t0 := addTransaction(newAtomic(0x100, insts.AtomicOpAdd, 1, 0),
+			transactionWaiting)
+		bottomPort.EXPECT().Send(gomock.Any()).Return(sim.NewSendError())
+
+		madeProgress := m.sendReads()
+
+		Expect(madeProgress).To(BeFalse())
+		Expect(t0.state).To(Equal(transactionWaiting))
+		Expect(t0.read).To(BeNil())
+	})
+
+	Context("when the data is read", func() {
+		var (
+			t     *transaction
+			write *mem.WriteReq
+		)
+
+		readData := func(op insts.AtomicOp, value, cmp uint64, data []byte) {
+			t = addTransaction(newAtomic(0x100, op, value, cmp),
+				transactionReading)
+			t.read = mem.ReadReqBuilder{}.
+				WithAddress(0x100).
+				WithByteSize(4).
+				Build()
+			rsp := mem.DataReadyRspBuilder{}.
+				WithRspTo(t.read.ID).
+				WithData(data).
+				Build()
+
+			bottomPort.EXPECT().PeekIncoming().Return(rsp)
+			bottomPort.EXPECT().RetrieveIncoming().Return(rsp)
+			bottomPort.EXPECT().Send(gomock.Any()).
+				DoAndReturn(func(msg sim.Msg) *sim.SendError {
+					write = msg.(*mem.WriteReq)
+					return nil
+				})
+
+			Expect(m.parseBottom()).To(BeTrue())
+		}
+
+		It("should write back the result and keep the old value", func() {
+			readData(insts.AtomicOpAdd, 5, 0, []byte{10, 0, 0, 0})
+
+			Expect(write.Address).To(Equal(uint64(0x100)))
+			Expect(write.Data).To(Equal([]byte{15, 0, 0, 0}))
+			Expect(t.old).To(Equal([]byte{10, 0, 0, 0}))
+			Expect(t.write).To(BeIdenticalTo(write))
+			Expect(t.state).To(Equal(transactionWriting))
+		})
+
+		It("should swap if the compare value matches", func() {
+			readData(insts.AtomicOpCmpSwap, 9, 7, []byte{7, 0, 0, 0})
+
+			Expect(write.Data).To(Equal([]byte{9, 0, 0, 0}))
+			Expect(t.old).To(Equal([]byte{7, 0, 0, 0}))
+		})
+
+		It("should not swap if the compare value does not match", func() {
+			readData(insts.AtomicOpCmpSwap, 9, 3, []byte{7, 0, 0, 0})
+
+			Expect(write.Data).To(Equal([]byte{7, 0, 0, 0}))
+			Expect(t.old).To(Equal([]byte{7, 0, 0, 0}))
+		})
+	})
+
+	It("should wait if the write cannot be sent", func() {
+		t := addTransaction(newAtomic(0x100, insts.AtomicOpAdd, 5, 0),
+			transactionReading)
+		t.read = mem.ReadReqBuilder{}.WithAddress(0x100).WithByteSize(4).Build()
+		rsp := mem.DataReadyRspBuilder{}.
+			WithRspTo(t.read.ID).
+			WithData([]byte{10, 0, 0, 0}).
+			Build()
+
+		bottomPort.EXPECT().PeekIncoming().Return(rsp)
+		bottomPort.EXPECT().Send(gomock.Any()).Return(sim.NewSendError())
+
+		madeProgress := m.parseBottom()
+
+		Expect(madeProgress).To(BeFalse())
+		Expect(t.state).To(Equal(transactionReading))
+		Expect(t.old).To(BeNil())
+		Expect(rsp.Data).To(Equal([]byte{10, 0, 0, 0}))
+	})
+
+	It("should respond after the write is done", func() {
+		t := addTransaction(newAtomic(0x100, insts.AtomicOpAdd, 5, 0),
+			transactionWriting)
+		t.old = []byte{10, 0, 0, 0}
+		t.write = mem.WriteReqBuilder{}.WithAddress(0x100).Build()
+		done := mem.WriteDoneRspBuilder{}.WithRspTo(t.write.ID).Build()
+
+		bottomPort.EXPECT().PeekIncoming().Return(done)
+		bottomPort.EXPECT().RetrieveIncoming().Return(done)
+
+		Expect(m.parseBottom()).To(BeTrue())
+		Expect(t.state).To(Equal(transactionResponding))
+
+		var rsp *mem.DataReadyRsp
+		topPort.EXPECT().Send(gomock.Any()).
+			DoAndReturn(func(msg sim.Msg) *sim.SendError {
+				rsp = msg.(*mem.DataReadyRsp)
+				return nil
+			})
+
+		Expect(m.respond()).To(BeTrue())
+		Expect(rsp.RespondTo).To(Equal(t.req.ID))
+		Expect(rsp.Dst).To(Equal(sim.RemotePort("CU")))
+		Expect(rsp.Data).To(Equal([]byte{10, 0, 0, 0}))
+		Expect(m.transactions).To(ConsistOf(t))
+		Expect(t.state).To(Equal(transactionHolding))
+	})
+
+	It("should keep the transaction if the response cannot be sent", func() {
+		t := addTransaction(newAtomic(0x100, insts.AtomicOpAdd, 5, 0),
+			transactionResponding)
+		t.old = []byte{10, 0, 0, 0}
+		topPort.EXPECT().Send(gomock.Any()).Return(sim.NewSendError())
+
+		Expect(m.respond()).To(BeFalse())
+		Expect(m.transactions).To(ConsistOf(t))
+	})
+
+	It("should panic if a response matches no transaction", func() {
+		addTransaction(newAtomic(0x100, insts.AtomicOpAdd, 5, 0),
+			transactionWriting)
+		done := mem.WriteDoneRspBuilder{}.WithRspTo("unknown").Build()
+		bottomPort.EXPECT().PeekIncoming().Return(done)
+
+		Expect(func() { m.parseBottom() }).To(Panic())
+	})
+})
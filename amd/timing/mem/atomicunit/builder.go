@@ -0,0 +1,91 @@
+package atomicunit
+
+import (
+	"github.com/sarchlab/akita/v4/mem/mem"
+	"github.com/sarchlab/akita/v4/sim"
+)
+
+// A Builder can create atomic units.
+type Builder struct {
+	engine sim.Engine
+	freq   sim.Freq
+
+	numReqPerCycle   int
+	maxTransactions  int
+	log2BlockSize    uint64
+	memoryPortMapper mem.AddressToPortMapper
+}
+
+// MakeBuilder creates a new builder.
+func MakeBuilder() Builder {
+	return Builder{
+		freq:            1 * sim.GHz,
+		numReqPerCycle:  4,
+		maxTransactions: 64,
+		log2BlockSize:   3,
+	}
+}
+
+// WithEngine sets the engine to be used by the atomic units.
+func (b Builder) WithEngine(engine sim.Engine) Builder {
+	b.engine = engine
+	return b
+}
+
+// WithFreq sets the frequency of the atomic units.
+func (b Builder) WithFreq(freq sim.Freq) Builder {
+	b.freq = freq
+	return b
+}
+
+// WithNumReqPerCycle sets the number of requests that the atomic units can
+// process in each cycle.
+func (b Builder) WithNumReqPerCycle(n int) Builder {
+	b.numReqPerCycle = n
+	return b
+}
+
+// WithMaxTransactions sets the number of atomic requests that an atomic unit
+// can hold at the same time.
+func (b Builder) WithMaxTransactions(n int) Builder {
+	b.maxTransactions = n
+	return b
+}
+
+// WithLog2BlockSize sets the granularity, as a power of 2, at which the atomic
+// requests are serialized. Two atomic requests to the same block are
+// performed one after the other.
+func (b Builder) WithLog2BlockSize(n uint64) Builder {
+	b.log2BlockSize = n
+	return b
+}
+
+// WithMemoryProviderMapper sets the mapper that can find the cache that the
+// atomic units read from and write to.
+func (b Builder) WithMemoryProviderMapper(f mem.AddressToPortMapper) Builder {
+	b.memoryPortMapper = f
+	return b
+}
+
+// Build returns a new AtomicUnit.
+func (b Builder) Build(name string) *Comp {
+	c := &Comp{}
+	c.TickingComponent = sim.NewTickingComponent(name, b.engine, b.freq, c)
+
+	c.numReqPerCycle = b.numReqPerCycle
+	c.maxTransactions = b.maxTransactions
+	c.log2BlockSize = b.log2BlockSize
+	c.memoryPortMapper = b.memoryPortMapper
+
+	c.topPort = sim.NewPort(c, b.numReqPerCycle, b.numReqPerCycle,
+		name+".TopPort")
+	c.AddPort("Top", c.topPort)
+
+	c.bottomPort = sim.NewPort(c, b.numReqPerCycle, b.numReqPerCycle,
+		name+".BottomPort")
+	c.AddPort("Bottom", c.bottomPort)
+
+	c.AddMiddleware(&middleware{Comp: c})
+
+	return c
+}
@@ -0,0 +1,7 @@
+// Package atomicunit implements a component that performs the atomic
+// read-modify-write requests on behalf of a cache bank. It reads the memory
+// location from the cache, computes the new value, and writes the value back
+// to the cache. It does not start another atomic request to the same location
+// until the requester reports that it has updated its own copy of the
+// location.
+package atomicunit
@@ -7,6 +7,7 @@ import (
 	"github.com/sarchlab/akita/v4/mem/mem"
 	"github.com/sarchlab/akita/v4/sim"
 	"github.com/sarchlab/akita/v4/tracing"
+	"github.com/sarchlab/mgpusim/v4/amd/protocol"
 )
 
 type transaction struct {
@@ -269,6 +270,8 @@ func (b *ReorderBuffer) duplicateReq(req mem.AccessReq) mem.AccessReq {
 		return b.duplicateReadReq(req)
 	case *mem.WriteReq:
 		return b.duplicateWriteReq(req)
+	case *protocol.AtomicReq:
+		return b.duplicateAtomicReq(req)
 	default:
 		panic("unsupported type")
 	}
@@ -293,6 +296,22 @@ func (b *ReorderBuffer) duplicateWriteReq(req *mem.WriteReq) *mem.WriteReq {
 		Build()
 }
 
+func (b *ReorderBuffer) duplicateAtomicReq(
+	req *protocol.AtomicReq,
+) *protocol.AtomicReq {
+	dup := protocol.AtomicReqBuilder{}.
+		WithAddress(req.Address).
+		WithPID(req.PID).
+		WithAtomic(req.Atomic).
+		WithValue(req.Value).
+		WithCmp(req.Cmp).
+		WithDst(b.BottomUnit).
+		Build()
+	dup.CanWaitForCoalesce = req.CanWaitForCoalesce
+
+	return dup
+}
+
 func (b *ReorderBuffer) duplicateRsp(
 	rsp mem.AccessRsp,
 	rspTo string,
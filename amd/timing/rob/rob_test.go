@@ -5,6 +5,7 @@ import (
 	. "github.com/onsi/gomega"
 	"github.com/sarchlab/akita/v4/mem/mem"
 	"github.com/sarchlab/akita/v4/sim"
+	"github.com/sarchlab/mgpusim/v4/amd/protocol"
 	"go.uber.org/mock/gomock"
 )
 
@@ -108,6 +109,23 @@ var _ = Describe("Reorder Buffer", func() {
 		})
 	})
 
+	It("should keep the coalescing hint of an atomic request", func() {
+		atomic := protocol.AtomicReqBuilder{}.
+			WithAddress(0x100).
+			WithValue(1).
+			WithCmp(2).
+			Build()
+		atomic.CanWaitForCoalesce = true
+
+		dup := rob.duplicateReq(atomic).(*protocol.AtomicReq)
+
+		Expect(dup.Address).To(Equal(uint64(0x100)))
+		Expect(dup.Value).To(Equal(uint64(1)))
+		Expect(dup.Cmp).To(Equal(uint64(2)))
+		Expect(dup.CanWaitForCoalesce).To(BeTrue())
+		Expect(dup.ID).NotTo(Equal(atomic.ID))
+	})
+
 	Context("parse bottom", func() {
 		var (
 			writeFromTop *mem.WriteReq
@@ -60,11 +60,18 @@ type Wavefront struct {
 	M0   uint32
 	scc  byte
 
+	// FlatScratch is the FLAT_SCRATCH register.
+	FlatScratch uint64
+
 	RegAccessor RegFileAccessor
 
 	OutstandingScalarMemAccess int
 	OutstandingVectorMemAccess int
 
+	// Priority is the priority that s_setprio sets, from 0 to 3. The
+	// wavefronts with a higher priority issue first.
+	Priority int
+
 	// ScoreboardData holds per-wavefront register scoreboard state.
 	// When register scoreboard is enabled, this contains a *cu.Scoreboard
 	// (stored as interface{} to avoid circular imports).
@@ -130,9 +137,10 @@ func (wf *Wavefront) EXEC() uint64 {
 	return wf.exec
 }
 
-// SetEXEC sets the exec mask
+// SetEXEC sets the exec mask. The bits beyond the lanes of a wave32
+// wavefront are always 0.
 func (wf *Wavefront) SetEXEC(v uint64) {
-	wf.exec = v
+	wf.exec = v & wf.LaneMask()
 }
 
 // VCC returns the vector condition code
@@ -140,9 +148,11 @@ func (wf *Wavefront) VCC() uint64 {
 	return wf.vcc
 }
 
-// SetVCC sets the vector condition code
+// SetVCC sets the vector condition code. A wave32 wavefront only updates the
+// bits of its lanes, as the vector instructions only write VCC_LO.
 func (wf *Wavefront) SetVCC(v uint64) {
-	wf.vcc = v
+	mask := wf.LaneMask()
+	wf.vcc = wf.vcc&^mask | v&mask
 }
 
 // SCC returns the scalar condition code
@@ -160,7 +170,7 @@ func (wf *Wavefront) ReadOperand(operand *insts.Operand, laneID int) uint64 {
 	switch operand.OperandType {
 	case insts.RegOperand:
 		waveOffset := wf.SRegOffset
-		if operand.Register.IsVReg() {
+		if operand.Register.IsVReg() || operand.Register.IsAReg() {
 			waveOffset = wf.VRegOffset
 		}
 		buf := wf.RegAccessor.ReadReg(operand.Register, operand.RegCount, laneID, waveOffset)
@@ -194,7 +204,7 @@ func (wf *Wavefront) WriteOperand(operand *insts.Operand, laneID int, value uint
 	}
 
 	waveOffset := wf.SRegOffset
-	if operand.Register.IsVReg() {
+	if operand.Register.IsVReg() || operand.Register.IsAReg() {
 		waveOffset = wf.VRegOffset
 	}
 
@@ -207,7 +217,7 @@ func (wf *Wavefront) ReadOperandBytes(operand *insts.Operand, laneID int, byteCo
 	switch operand.OperandType {
 	case insts.RegOperand:
 		waveOffset := wf.SRegOffset
-		if operand.Register.IsVReg() {
+		if operand.Register.IsVReg() || operand.Register.IsAReg() {
 			waveOffset = wf.VRegOffset
 		}
 		buf := wf.RegAccessor.ReadReg(operand.Register, operand.RegCount, laneID, waveOffset)
@@ -237,7 +247,7 @@ func (wf *Wavefront) WriteOperandBytes(operand *insts.Operand, laneID int, data
 	}
 
 	waveOffset := wf.SRegOffset
-	if operand.Register.IsVReg() {
+	if operand.Register.IsVReg() || operand.Register.IsAReg() {
 		waveOffset = wf.VRegOffset
 	}
 
@@ -52,8 +52,21 @@ func (b *BenchmarkBuilder) generateKernelTrace(meta tracereader.TraceExecMeta) *
 		tb := nvidiaconfig.Threadblock{}
 		tb.WarpsCount = kernelTrace.Threadblock(i).WarpsCount()
 		for j := int64(0); j < tb.WarpsCount; j++ {
+			warpTrace := kernelTrace.Threadblock(i).Warp(j)
 			warp := nvidiaconfig.Warp{}
-			warp.InstructionsCount = kernelTrace.Threadblock(i).Warp(j).InstructionsCount()
+			warp.InstructionsCount = warpTrace.InstructionsCount()
+			for _, inst := range warpTrace.Instructions {
+				warp.Instructions = append(warp.Instructions, nvidiaconfig.Instruction{
+					PC:       inst.PC,
+					Mask:     inst.Mask,
+					OpCode:   inst.OpCode,
+					DestRegs: inst.DestRegs,
+					SrcRegs:  inst.SrcRegs,
+
+					MemWidth:     inst.MemWidth,
+					MemAddresses: inst.MemAddresses,
+				})
+			}
 			tb.Warps = append(tb.Warps, warp)
 		}
 		kernel.Threadblocks = append(kernel.Threadblocks, tb)
@@ -3,9 +3,13 @@ package gpu
 import (
 	"fmt"
 
+	"github.com/sarchlab/akita/v4/mem/cache/writeback"
+	"github.com/sarchlab/akita/v4/mem/dram"
+	"github.com/sarchlab/akita/v4/mem/mem"
 	"github.com/sarchlab/akita/v4/sim"
 	"github.com/sarchlab/akita/v4/sim/directconnection"
 	"github.com/sarchlab/mgpusim/v4/nvidia/sm"
+	"github.com/sarchlab/mgpusim/v4/nvidia/subcore"
 	"github.com/tebeka/atexit"
 )
 
@@ -13,8 +17,15 @@ type GPUBuilder struct {
 	engine sim.Engine
 	freq   sim.Freq
 
-	smsCount           int64
-	subcoresCountPerSM int64
+	smsCount             int64
+	subcoresCountPerSM   int64
+	warpsCountPerSubcore int64
+	schedulingPolicy     subcore.SchedulingPolicy
+
+	memoryBanksCount int
+	l2CacheSize      uint64
+	dramSize         uint64
+	l2AddressMapper  *mem.InterleavedAddressPortMapper
 }
 
 func (b *GPUBuilder) WithEngine(engine sim.Engine) *GPUBuilder {
@@ -37,7 +48,44 @@ func (b *GPUBuilder) WithSubcoresCountPerSM(count int64) *GPUBuilder {
 	return b
 }
 
+// WithWarpsCountPerSubcore sets the number of warps that each subcore can
+// hold at the same time.
+func (b *GPUBuilder) WithWarpsCountPerSubcore(count int64) *GPUBuilder {
+	b.warpsCountPerSubcore = count
+	return b
+}
+
+// WithSchedulingPolicy sets the warp scheduling policy of the subcores.
+func (b *GPUBuilder) WithSchedulingPolicy(
+	policy subcore.SchedulingPolicy,
+) *GPUBuilder {
+	b.schedulingPolicy = policy
+	return b
+}
+
+// WithMemoryBanksCount sets the number of L2 cache banks, which is also the
+// number of DRAM controllers. The default is 40.
+func (b *GPUBuilder) WithMemoryBanksCount(count int) *GPUBuilder {
+	b.memoryBanksCount = count
+	return b
+}
+
+// WithL2CacheSize sets the total size of the L2 cache banks in bytes. The
+// default is 40 MB.
+func (b *GPUBuilder) WithL2CacheSize(size uint64) *GPUBuilder {
+	b.l2CacheSize = size
+	return b
+}
+
+// WithDRAMSize sets the total size of the DRAM in bytes. The default is 40 GB.
+func (b *GPUBuilder) WithDRAMSize(size uint64) *GPUBuilder {
+	b.dramSize = size
+	return b
+}
+
 func (b *GPUBuilder) Build(name string) *GPU {
+	b.setMemoryDefaults()
+
 	g := &GPU{
 		ID:  sim.GetIDGenerator().Generate(),
 		SMs: make(map[string]*sm.SM),
@@ -45,8 +93,13 @@ func (b *GPUBuilder) Build(name string) *GPU {
 
 	g.TickingComponent = sim.NewTickingComponent(name, b.engine, b.freq, g)
 	b.buildPortsForGPU(g, name)
+
+	b.l2AddressMapper = mem.NewInterleavedAddressPortMapper(1 << log2CacheLineSize)
 	sms := b.buildSMs(name)
 	b.connectGPUWithSMs(g, sms)
+	b.buildDRAMs(g, name)
+	b.buildL2Caches(g, name)
+	b.connectMemory(g, sms, name)
 
 	atexit.Register(g.LogStatus)
 
@@ -64,7 +117,10 @@ func (b *GPUBuilder) buildSMs(gpuName string) []*sm.SM {
 	smBuilder := new(sm.SMBuilder).
 		WithEngine(b.engine).
 		WithFreq(b.freq).
-		WithSubcoresCount(b.subcoresCountPerSM)
+		WithSubcoresCount(b.subcoresCountPerSM).
+		WithWarpsCountPerSubcore(b.warpsCountPerSubcore).
+		WithSchedulingPolicy(b.schedulingPolicy).
+		WithL2AddressMapper(b.l2AddressMapper)
 
 	sms := []*sm.SM{}
 	for i := int64(0); i < b.smsCount; i++ {
@@ -80,7 +136,7 @@ func (b *GPUBuilder) connectGPUWithSMs(gpu *GPU, sms []*sm.SM) {
 	// conn.PlugIn(gpu.toSMs, 4)
 	conn := directconnection.MakeBuilder().
 		WithEngine(b.engine).
-		WithFreq(1 * sim.GHz).
+		WithFreq(b.freq).
 		Build("GPUToSMs")
 	conn.PlugIn(gpu.toSMs)
 
@@ -95,3 +151,135 @@ func (b *GPUBuilder) connectGPUWithSMs(gpu *GPU, sms []*sm.SM) {
 		conn.PlugIn(sm.GetPortByName(fmt.Sprintf("%s.ToGPU", sms[i].Name())))
 	}
 }
+
+const log2CacheLineSize = 7
+
+// The traces record the virtual addresses of the traced process, which the
+// DRAM controllers use as physical addresses. The storage covers the 48-bit
+// address space and only allocates the pages that the kernels touch.
+const traceAddressSpaceSize = 256 * mem.TB
+
+func (b *GPUBuilder) setMemoryDefaults() {
+	if b.memoryBanksCount == 0 {
+		b.memoryBanksCount = 40
+	}
+
+	if b.l2CacheSize == 0 {
+		b.l2CacheSize = 40 * mem.MB
+	}
+
+	if b.dramSize == 0 {
+		b.dramSize = 40 * mem.GB
+	}
+}
+
+// buildDRAMs builds one HBM2 channel controller for each memory bank. The
+// controllers run at the clock of the GPU, so the timing parameters are in GPU
+// cycles. Each channel moves 32 bytes per cycle, which gives the 40 channels
+// about 1.8 TB/s at 1410 MHz.
+func (b *GPUBuilder) buildDRAMs(g *GPU, gpuName string) {
+	storage := mem.NewStorage(traceAddressSpaceSize)
+
+	dramCol := 64
+	dramRow := 16384
+	dramDeviceWidth := 128
+	dramBank := 4
+	dramBankGroup := 4
+	dramBusWidth := 128
+	dramRankSize := uint64(dramCol * dramRow * dramDeviceWidth * dramBank)
+	channelSize := b.dramSize / uint64(b.memoryBanksCount)
+	dramRank := int(channelSize * 8 / dramRankSize)
+
+	dramBuilder := dram.MakeBuilder().
+		WithEngine(b.engine).
+		WithFreq(b.freq).
+		WithProtocol(dram.HBM).
+		WithGlobalStorage(storage).
+		WithBurstLength(4).
+		WithDeviceWidth(dramDeviceWidth).
+		WithBusWidth(dramBusWidth).
+		WithNumChannel(1).
+		WithNumRank(dramRank).
+		WithNumBankGroup(dramBankGroup).
+		WithNumBank(dramBank).
+		WithNumCol(dramCol).
+		WithNumRow(dramRow).
+		WithCommandQueueSize(8).
+		WithTransactionQueueSize(32).
+		WithTCL(16).
+		WithTCWL(5).
+		WithTRCDRD(16).
+		WithTRCDWR(16).
+		WithTRP(16).
+		WithTRAS(39).
+		WithTREFI(5500).
+		WithTRRDS(4).
+		WithTRRDL(6).
+		WithTWTRS(4).
+		WithTWTRL(8).
+		WithTWR(16).
+		WithTCCDS(2).
+		WithTCCDL(4).
+		WithTRTRS(0).
+		WithTRTP(4).
+		WithTPPD(2)
+
+	for i := 0; i < b.memoryBanksCount; i++ {
+		d := dramBuilder.Build(fmt.Sprintf("%s.DRAM(%d)", gpuName, i))
+		g.DRAMs = append(g.DRAMs, d)
+	}
+}
+
+// buildL2Caches builds the L2 cache banks. The addresses are interleaved
+// across the banks at the cache line granularity.
+func (b *GPUBuilder) buildL2Caches(g *GPU, gpuName string) {
+	l2Builder := writeback.MakeBuilder().
+		WithEngine(b.engine).
+		WithFreq(b.freq).
+		WithLog2BlockSize(log2CacheLineSize).
+		WithWayAssociativity(16).
+		WithByteSize(b.l2CacheSize / uint64(b.memoryBanksCount)).
+		WithNumMSHREntry(256).
+		WithNumReqPerCycle(4).
+		WithBankLatency(80).
+		WithDirectoryLatency(2).
+		WithMaxInflightFetch(256).
+		WithMaxInflightEviction(256)
+
+	for i := 0; i < b.memoryBanksCount; i++ {
+		l2 := l2Builder.
+			WithInterleaving(1, b.memoryBanksCount, i).
+			WithAddressMapperType("single").
+			WithRemotePorts(g.DRAMs[i].GetPortByName("Top").AsRemote()).
+			Build(fmt.Sprintf("%s.L2Cache(%d)", gpuName, i))
+
+		g.L2Caches = append(g.L2Caches, l2)
+		b.l2AddressMapper.LowModules = append(b.l2AddressMapper.LowModules,
+			l2.GetPortByName("Top").AsRemote())
+	}
+}
+
+func (b *GPUBuilder) connectMemory(g *GPU, sms []*sm.SM, gpuName string) {
+	l1ToL2Conn := directconnection.MakeBuilder().
+		WithEngine(b.engine).
+		WithFreq(b.freq).
+		Build(fmt.Sprintf("%s.L1ToL2", gpuName))
+
+	for _, sm := range sms {
+		l1ToL2Conn.PlugIn(sm.L1DCache.GetPortByName("Bottom"))
+	}
+
+	for _, l2 := range g.L2Caches {
+		l1ToL2Conn.PlugIn(l2.GetPortByName("Top"))
+	}
+
+	l2ToDRAMConn := directconnection.MakeBuilder().
+		WithEngine(b.engine).
+		WithFreq(b.freq).
+		Build(fmt.Sprintf("%s.L2ToDRAM", gpuName))
+
+	for i, l2 := range g.L2Caches {
+		l2ToDRAMConn.PlugIn(l2.GetPortByName("Bottom"))
+		l2ToDRAMConn.PlugIn(g.DRAMs[i].GetPortByName("Top"))
+	}
+}
@@ -5,6 +5,8 @@ import (
 
 	log "github.com/sirupsen/logrus"
 
+	"github.com/sarchlab/akita/v4/mem/cache/writeback"
+	"github.com/sarchlab/akita/v4/mem/dram"
 	"github.com/sarchlab/akita/v4/sim"
 	"github.com/sarchlab/mgpusim/v4/nvidia/message"
 	"github.com/sarchlab/mgpusim/v4/nvidia/nvidiaconfig"
@@ -24,6 +26,11 @@ type GPU struct {
 	SMs     map[string]*sm.SM
 	freeSMs []*sm.SM
 
+	// The L2 cache banks and the DRAM controllers. Each L2 cache bank sends
+	// its misses to the DRAM controller with the same index.
+	L2Caches []*writeback.Comp
+	DRAMs    []*dram.Comp
+
 	undispatchedThreadblocks    []*nvidiaconfig.Threadblock
 	unfinishedThreadblocksCount int64
 
@@ -139,7 +146,7 @@ func (g *GPU) dispatchThreadblocksToSMs() bool {
 	g.freeSMs = g.freeSMs[1:]
 	g.undispatchedThreadblocks = g.undispatchedThreadblocks[1:]
 
-	return false
+	return true
 }
 
 func (g *GPU) LogStatus() {
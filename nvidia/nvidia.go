@@ -9,19 +9,22 @@ import (
 	"github.com/sarchlab/mgpusim/v4/nvidia/benchmark"
 	"github.com/sarchlab/mgpusim/v4/nvidia/platform"
 	"github.com/sarchlab/mgpusim/v4/nvidia/runner"
+	"github.com/sarchlab/mgpusim/v4/nvidia/subcore"
 	"github.com/tebeka/atexit"
 
 	log "github.com/sirupsen/logrus"
 )
 
 type Params struct {
-	TraceDir *string
+	TraceDir  *string
+	Scheduler *string
 }
 
 // get trace directory from parameter
 func parseFlags() *Params {
 	params := &Params{
-		TraceDir: flag.String("trace-dir", "data/simple-trace-example", "The directory that contains the trace files"),
+		TraceDir:  flag.String("trace-dir", "data/simple-trace-example", "The directory that contains the trace files"),
+		Scheduler: flag.String("scheduler", "gto", "The warp scheduling policy of the subcores: gto or lrr"),
 	}
 
 	flag.Parse()
@@ -39,7 +42,8 @@ func main() {
 
 	// A100
 	platform := new(platform.A100PlatformBuilder).
-		WithFreq(1 * sim.Hz).
+		WithFreq(1410 * sim.MHz).
+		WithSchedulingPolicy(subcore.ParseSchedulingPolicy(*params.Scheduler)).
 		Build()
 
 	runner := new(runner.RunnerBuilder).
@@ -16,4 +16,59 @@ type Warp struct {
 }
 
 type Instruction struct {
+	PC       int32
+	Mask     int64
+	OpCode   *Opcode
+	DestRegs []*Register
+	SrcRegs  []*Register
+
+	// MemWidth is the number of bytes that each active thread accesses, and
+	// MemAddresses are the addresses of the active threads.
+	MemWidth     int32
+	MemAddresses []uint64
+}
+
+// ExecUnit returns the functional unit that executes the instruction. The
+// instructions without an opcode run on the ALU.
+func (i *Instruction) ExecUnit() ExecUnit {
+	if i.OpCode == nil {
+		return ExecUnitALU
+	}
+
+	return i.OpCode.ExecUnit()
+}
+
+// Latency returns the number of cycles until the result of the instruction
+// can be used.
+func (i *Instruction) Latency() int32 {
+	if i.OpCode == nil {
+		return 1
+	}
+
+	return i.OpCode.Latency()
+}
+
+// InitiationInterval returns the number of cycles that the instruction
+// occupies its functional unit.
+func (i *Instruction) InitiationInterval() int32 {
+	if i.OpCode == nil {
+		return 1
+	}
+
+	return i.OpCode.InitiationInterval()
+}
+
+// MemSpace returns the memory that the instruction accesses.
+func (i *Instruction) MemSpace() MemSpace {
+	if i.OpCode == nil || len(i.MemAddresses) == 0 {
+		return MemSpaceNone
+	}
+
+	return i.OpCode.MemSpace()
+}
+
+// IsStore returns true if the instruction writes to the memory without
+// reading it.
+func (i *Instruction) IsStore() bool {
+	return i.OpCode != nil && i.OpCode.IsStore()
 }
@@ -1,6 +1,10 @@
 package nvidiaconfig
 
-import log "github.com/sirupsen/logrus"
+import (
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
 
 // VariableType [todo] how to construct these?
 type VariableType int32
@@ -21,18 +25,74 @@ const (
 	IMADMOVU32
 )
 
+// ExecUnit is the functional unit of a subcore that executes an instruction.
+type ExecUnit int32
+
+const (
+	ExecUnitALU ExecUnit = iota
+	ExecUnitFMA
+	ExecUnitSFU
+	ExecUnitLSU
+	ExecUnitTensor
+	ExecUnitsCount
+)
+
+func (u ExecUnit) String() string {
+	switch u {
+	case ExecUnitALU:
+		return "ALU"
+	case ExecUnitFMA:
+		return "FMA"
+	case ExecUnitSFU:
+		return "SFU"
+	case ExecUnitLSU:
+		return "LSU"
+	case ExecUnitTensor:
+		return "Tensor"
+	}
+
+	return "Unknown"
+}
+
+// MemSpace is the memory that an instruction accesses with the addresses in
+// the trace.
+type MemSpace int32
+
+const (
+	MemSpaceNone MemSpace = iota
+	MemSpaceGlobal
+	MemSpaceShared
+)
+
 type Opcode struct {
 	rawText string
 	opType  OpCodeType
 	varType VariableType
+
+	unit               ExecUnit
+	latency            int32
+	initiationInterval int32
+
+	memSpace MemSpace
+	isStore  bool
 }
 
+// NewOpcode looks up an opcode by its SASS text, such as "IMAD.WIDE". The
+// opcodes that are not in the table by their full text are looked up by their
+// mnemonic without the modifiers.
 func NewOpcode(rawText string) *Opcode {
 	op, ok := opcodeTable[rawText]
 	if !ok {
-		op = Opcode{rawText, OpCodeError, VariableError}
+		mnemonic := strings.SplitN(rawText, ".", 2)[0]
+		op, ok = opcodeTable[mnemonic]
+		op.rawText = rawText
+	}
+
+	if !ok {
+		op = Opcode{rawText: rawText, opType: OpCodeError, varType: VariableError}
 		log.WithField("opcode", rawText).Panic("Unknown opcode")
 	}
+
 	return &op
 }
 
@@ -48,10 +108,132 @@ func (op *Opcode) VariableType() VariableType {
 	return op.varType
 }
 
+// ExecUnit returns the functional unit that executes the opcode.
+func (op *Opcode) ExecUnit() ExecUnit {
+	return op.unit
+}
+
+// Latency returns the number of cycles from issuing the opcode until the
+// result can be used.
+func (op *Opcode) Latency() int32 {
+	return op.latency
+}
+
+// InitiationInterval returns the number of cycles that the opcode occupies
+// its functional unit before the unit accepts the next instruction.
+func (op *Opcode) InitiationInterval() int32 {
+	return op.initiationInterval
+}
+
+// MemSpace returns the memory that the opcode accesses.
+func (op *Opcode) MemSpace() MemSpace {
+	return op.memSpace
+}
+
+// IsStore returns true if the opcode writes to the memory without reading it.
+func (op *Opcode) IsStore() bool {
+	return op.isStore
+}
+
 var opcodeTable map[string]Opcode
 
+// The latencies and the initiation intervals follow the Accel-Sim
+// configuration of the A100. The latencies of the loads and stores only apply
+// when the subcore does not send them to the memory hierarchy.
+//
+//nolint:funlen
 func init() {
 	opcodeTable = make(map[string]Opcode)
 
-	opcodeTable["IMAD.MOV.U32"] = Opcode{"IMAD.MOV.U32", IMADMOVU32, VariableINT32}
+	addOpcodes(ExecUnitALU, 4, 2,
+		// Integer
+		"BMSK", "BREV", "FLO", "IABS", "IADD", "IADD3", "IADD32I", "IDP",
+		"IDP4A", "IMNMX", "ISCADD", "ISETP", "LEA", "LOP", "LOP3", "LOP32I",
+		"POPC", "SHF", "SHL", "SHR", "VABSDIFF", "VABSDIFF4",
+		// Single precision comparisons
+		"FCHK", "FMNMX", "FSEL", "FSET", "FSETP", "F2FP",
+		// Movement
+		"MOV", "MOV32I", "MOVM", "PRMT", "SEL", "SGXT", "SHFL",
+		// Predicates
+		"PLOP3", "PSETP", "P2R", "R2P",
+		// Uniform datapath
+		"R2UR", "S2UR", "UBMSK", "UBREV", "UCLEA", "UFLO", "UIADD3", "UIMAD",
+		"UISETP", "ULDC", "ULEA", "ULOP", "ULOP3", "ULOP32I", "UMOV", "UP2UR",
+		"UPLOP3", "UPOPC", "UPRMT", "UPSETP", "UR2UP", "USEL", "USGXT",
+		"USHF", "USHL", "USHR", "VOTEU",
+		// Control
+		"BMOV", "BPT", "BRA", "BREAK", "BRX", "BRXU", "BSSY", "BSYNC", "CALL",
+		"EXIT", "JMP", "JMX", "JMXU", "KILL", "NANOSLEEP", "RET", "RPCMOV",
+		"RTT", "WARPSYNC", "YIELD",
+		// Miscellaneous
+		"B2R", "BAR", "CS2R", "DEPBAR", "GETLMEMBASE", "LEPC", "NOP",
+		"PMTRIG", "R2B", "S2R", "SETCTAID", "SETLMEMBASE", "VOTE", "MATCH",
+		"QSPC")
+
+	addOpcodes(ExecUnitFMA, 4, 2,
+		"FADD", "FADD32I", "FFMA", "FFMA32I", "FMUL", "FMUL32I", "FSWZADD",
+		"HADD2", "HADD2_32I", "HFMA2", "HFMA2_32I", "HMUL2", "HMUL2_32I",
+		"HSET2", "HSETP2", "HMNMX2", "IMAD", "IMUL", "IMUL32I")
+
+	addOpcodes(ExecUnitFMA, 8, 4, "DADD", "DFMA", "DMUL", "DSETP")
+
+	addOpcodes(ExecUnitSFU, 21, 8,
+		"MUFU", "F2F", "F2I", "I2F", "I2I", "I2IP", "FRND")
+
+	addOpcodes(ExecUnitTensor, 32, 16, "HMMA", "IMMA", "BMMA", "DMMA")
+
+	addMemOpcodes(MemSpaceGlobal, false, 290,
+		"LD", "LDG", "LDL", "ATOM", "ATOMG", "LDGSTS")
+	addMemOpcodes(MemSpaceGlobal, true, 290, "ST", "STG", "STL", "RED")
+	addMemOpcodes(MemSpaceShared, false, 23, "LDS", "ATOMS", "LDSM")
+	addMemOpcodes(MemSpaceShared, true, 23, "STS")
+
+	addOpcodes(ExecUnitLSU, 290, 4,
+		"LDGDEPBAR", "ARRIVES", "CCTL", "CCTLL", "CCTLT", "ERRBAR", "MEMBAR",
+		"TEX", "TLD", "TLD4", "TMML", "TXD", "TXQ", "SUATOM", "SULD", "SURED",
+		"SUST")
+
+	addOpcodes(ExecUnitLSU, 20, 4, "LDC")
+
+	opcodeTable["IMAD.MOV.U32"] = Opcode{
+		rawText:            "IMAD.MOV.U32",
+		opType:             IMADMOVU32,
+		varType:            VariableINT32,
+		unit:               ExecUnitFMA,
+		latency:            4,
+		initiationInterval: 2,
+	}
+}
+
+func addOpcodes(
+	unit ExecUnit,
+	latency, initiationInterval int32,
+	mnemonics ...string,
+) {
+	for _, m := range mnemonics {
+		opcodeTable[m] = Opcode{
+			rawText:            m,
+			unit:               unit,
+			latency:            latency,
+			initiationInterval: initiationInterval,
+		}
+	}
+}
+
+// addMemOpcodes adds the LSU opcodes that access the memory with the
+// addresses in the trace.
+func addMemOpcodes(
+	space MemSpace,
+	isStore bool,
+	latency int32,
+	mnemonics ...string,
+) {
+	addOpcodes(ExecUnitLSU, latency, 4, mnemonics...)
+
+	for _, m := range mnemonics {
+		op := opcodeTable[m]
+		op.memSpace = space
+		op.isStore = isStore
+		opcodeTable[m] = op
+	}
 }
@@ -38,7 +38,7 @@ var registerTable map[string]Register
 func init() {
 	registerTable = make(map[string]Register)
 
-	for i := 0; i < 32; i++ {
+	for i := 0; i < 255; i++ {
 		registerTable[fmt.Sprintf("R%d", i)] = Register{fmt.Sprintf("R%d", i), int32(i), false}
 	}
 	registerTable["R255"] = Register{"R255", 255, true}
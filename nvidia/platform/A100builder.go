@@ -5,13 +5,17 @@ import (
 
 	log "github.com/sirupsen/logrus"
 
+	"github.com/sarchlab/akita/v4/mem/mem"
 	"github.com/sarchlab/akita/v4/sim"
 	"github.com/sarchlab/mgpusim/v4/nvidia/driver"
 	"github.com/sarchlab/mgpusim/v4/nvidia/gpu"
+	"github.com/sarchlab/mgpusim/v4/nvidia/subcore"
 )
 
 type A100PlatformBuilder struct {
 	freq sim.Freq
+
+	schedulingPolicy subcore.SchedulingPolicy
 }
 
 func (b *A100PlatformBuilder) WithFreq(freq sim.Freq) *A100PlatformBuilder {
@@ -19,11 +23,21 @@ func (b *A100PlatformBuilder) WithFreq(freq sim.Freq) *A100PlatformBuilder {
 	return b
 }
 
+// WithSchedulingPolicy sets the warp scheduling policy of the subcores. The
+// default is GTO.
+func (b *A100PlatformBuilder) WithSchedulingPolicy(
+	policy subcore.SchedulingPolicy,
+) *A100PlatformBuilder {
+	b.schedulingPolicy = policy
+	return b
+}
+
 func (b *A100PlatformBuilder) Build() *Platform {
 	b.freqMustBeSet()
 
 	p := new(Platform)
 	p.Engine = sim.NewSerialEngine()
+	p.Freq = b.freq
 	p.Driver = new(driver.DriverBuilder).
 		WithEngine(p.Engine).
 		WithFreq(b.freq).
@@ -33,7 +47,12 @@ func (b *A100PlatformBuilder) Build() *Platform {
 		WithEngine(p.Engine).
 		WithFreq(b.freq).
 		WithSMsCount(108).
-		WithSubcoresCountPerSM(4)
+		WithSubcoresCountPerSM(4).
+		WithWarpsCountPerSubcore(16).
+		WithSchedulingPolicy(b.schedulingPolicy).
+		WithMemoryBanksCount(40).
+		WithL2CacheSize(40 * mem.MB).
+		WithDRAMSize(40 * mem.GB)
 	gpuCount := 1
 	for i := 0; i < gpuCount; i++ {
 		gpu := gpuDriver.Build(fmt.Sprintf("GPU(%d)", i))
@@ -8,6 +8,7 @@ import (
 
 type Platform struct {
 	Engine  sim.Engine
+	Freq    sim.Freq
 	Driver  *driver.Driver
 	Devices []*gpu.GPU
 }
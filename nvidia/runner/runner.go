@@ -1,6 +1,8 @@
 package runner
 
 import (
+	"fmt"
+
 	"github.com/sarchlab/akita/v4/sim"
 	"github.com/sarchlab/mgpusim/v4/nvidia/benchmark"
 	"github.com/sarchlab/mgpusim/v4/nvidia/driver"
@@ -27,6 +29,39 @@ func (r *Runner) Run() {
 	r.Driver().TickLater()
 	r.Engine().Run()
 	// 	r.Engine().Finished()
+
+	r.printReport()
+}
+
+// Cycles returns the number of cycles that the platform runs the benchmarks.
+func (r *Runner) Cycles() uint64 {
+	return r.platform.Freq.Cycle(r.Engine().CurrentTime())
+}
+
+// InstsCount returns the number of instructions that the subcores issue.
+func (r *Runner) InstsCount() int64 {
+	count := int64(0)
+	for _, gpu := range r.platform.Devices {
+		for _, sm := range gpu.SMs {
+			for _, subcore := range sm.Subcores {
+				count += subcore.GetTotalInstsCount()
+			}
+		}
+	}
+
+	return count
+}
+
+func (r *Runner) printReport() {
+	cycles := r.Cycles()
+	insts := r.InstsCount()
+
+	ipc := 0.0
+	if cycles > 0 {
+		ipc = float64(insts) / float64(cycles)
+	}
+
+	fmt.Printf("Cycles: %d\nInstructions: %d\nIPC: %.3f\n", cycles, insts, ipc)
 }
 
 func (r *Runner) Driver() *driver.Driver {
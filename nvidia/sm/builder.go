@@ -3,6 +3,9 @@ package sm
 import (
 	"fmt"
 
+	"github.com/sarchlab/akita/v4/mem/cache/writearound"
+	"github.com/sarchlab/akita/v4/mem/idealmemcontroller"
+	"github.com/sarchlab/akita/v4/mem/mem"
 	"github.com/sarchlab/akita/v4/sim"
 	"github.com/sarchlab/akita/v4/sim/directconnection"
 	"github.com/sarchlab/mgpusim/v4/nvidia/subcore"
@@ -13,7 +16,13 @@ type SMBuilder struct {
 	engine sim.Engine
 	freq   sim.Freq
 
-	subcoresCount int64
+	subcoresCount        int64
+	warpsCountPerSubcore int64
+	schedulingPolicy     subcore.SchedulingPolicy
+
+	l1DCacheSize     uint64
+	l2AddressMapper  mem.AddressToPortMapper
+	sharedMemLatency int
 }
 
 func (b *SMBuilder) WithEngine(engine sim.Engine) *SMBuilder {
@@ -31,16 +40,69 @@ func (b *SMBuilder) WithSubcoresCount(count int64) *SMBuilder {
 	return b
 }
 
+// WithWarpsCountPerSubcore sets the number of warps that each subcore can
+// hold at the same time. The default is 16.
+func (b *SMBuilder) WithWarpsCountPerSubcore(count int64) *SMBuilder {
+	b.warpsCountPerSubcore = count
+	return b
+}
+
+// WithSchedulingPolicy sets the warp scheduling policy of the subcores.
+func (b *SMBuilder) WithSchedulingPolicy(
+	policy subcore.SchedulingPolicy,
+) *SMBuilder {
+	b.schedulingPolicy = policy
+	return b
+}
+
+// WithL1DCacheSize sets the size of the L1 data cache in bytes. The default is
+// 128 KB.
+func (b *SMBuilder) WithL1DCacheSize(size uint64) *SMBuilder {
+	b.l1DCacheSize = size
+	return b
+}
+
+// WithL2AddressMapper sets how the L1 data cache finds the L2 cache bank of an
+// address.
+func (b *SMBuilder) WithL2AddressMapper(
+	mapper mem.AddressToPortMapper,
+) *SMBuilder {
+	b.l2AddressMapper = mapper
+	return b
+}
+
+// WithSharedMemLatency sets the number of cycles that the shared memory takes
+// to serve a request. The default is 20.
+func (b *SMBuilder) WithSharedMemLatency(latency int) *SMBuilder {
+	b.sharedMemLatency = latency
+	return b
+}
+
 func (b *SMBuilder) Build(name string) *SM {
+	if b.warpsCountPerSubcore == 0 {
+		b.warpsCountPerSubcore = 16
+	}
+
+	if b.l1DCacheSize == 0 {
+		b.l1DCacheSize = 128 * mem.KB
+	}
+
+	if b.sharedMemLatency == 0 {
+		b.sharedMemLatency = 20
+	}
+
 	s := &SM{
-		ID:       sim.GetIDGenerator().Generate(),
-		Subcores: make(map[string]*subcore.Subcore),
+		ID:            sim.GetIDGenerator().Generate(),
+		Subcores:      make(map[string]*subcore.Subcore),
+		freeWarpSlots: make(map[string]int64),
 	}
 
 	s.TickingComponent = sim.NewTickingComponent(name, b.engine, b.freq, s)
 	b.buildPortsForSM(s, name)
 	subcores := b.buildSubcores(name)
 	b.connectSMwithSubcores(s, subcores)
+	b.buildMemory(s, name)
+	b.connectSubcoresWithMemory(s, subcores, name)
 
 	atexit.Register(s.LogStatus)
 
@@ -57,7 +119,8 @@ func (b *SMBuilder) buildPortsForSM(sm *SM, name string) {
 func (b *SMBuilder) buildSubcores(smName string) []*subcore.Subcore {
 	subcoreBuilder := new(subcore.SubcoreBuilder).
 		WithEngine(b.engine).
-		WithFreq(b.freq)
+		WithFreq(b.freq).
+		WithSchedulingPolicy(b.schedulingPolicy)
 	subcores := []*subcore.Subcore{}
 	for i := int64(0); i < b.subcoresCount; i++ {
 		subcore := subcoreBuilder.Build(fmt.Sprintf("%s.Subcore(%d)", smName, i))
@@ -70,7 +133,7 @@ func (b *SMBuilder) buildSubcores(smName string) []*subcore.Subcore {
 func (b *SMBuilder) connectSMwithSubcores(sm *SM, subcores []*subcore.Subcore) {
 	conn := directconnection.MakeBuilder().
 		WithEngine(b.engine).
-		WithFreq(1 * sim.GHz).
+		WithFreq(b.freq).
 		Build("SMToSubcores")
 
 	conn.PlugIn(sm.toSubcores)
@@ -78,10 +141,64 @@ func (b *SMBuilder) connectSMwithSubcores(sm *SM, subcores []*subcore.Subcore) {
 	for i := range subcores {
 		subcore := subcores[i]
 
-		sm.freeSubcores = append(sm.freeSubcores, subcore)
+		sm.subcoreList = append(sm.subcoreList, subcore)
+		sm.freeWarpSlots[subcore.ID] = b.warpsCountPerSubcore
 		sm.Subcores[subcore.ID] = subcore
 
 		subcore.SetSMRemotePort(sm.toSubcores)
 		conn.PlugIn(subcore.GetPortByName(fmt.Sprintf("%s.ToSM", subcore.Name())))
 	}
 }
+
+// buildMemory builds the L1 data cache and the shared memory. The L1 data cache
+// does not allocate the lines that the SM writes, and sends the writes to the
+// L2 cache.
+func (b *SMBuilder) buildMemory(sm *SM, name string) {
+	l1Builder := writearound.MakeBuilder().
+		WithEngine(b.engine).
+		WithFreq(b.freq).
+		WithLog2BlockSize(7).
+		WithWayAssociativity(4).
+		WithTotalByteSize(b.l1DCacheSize).
+		WithNumBanks(4).
+		WithBankLatency(20).
+		WithNumMSHREntry(256).
+		WithNumReqsPerCycle(4).
+		WithMaxNumConcurrentTrans(256)
+	if b.l2AddressMapper != nil {
+		l1Builder = l1Builder.WithAddressToPortMapper(b.l2AddressMapper)
+	}
+
+	sm.L1DCache = l1Builder.Build(fmt.Sprintf("%s.L1DCache", name))
+
+	sm.SharedMemory = idealmemcontroller.MakeBuilder().
+		WithEngine(b.engine).
+		WithFreq(b.freq).
+		WithLatency(b.sharedMemLatency).
+		WithWidth(1).
+		WithNewStorage(subcore.SharedMemoryWindow).
+		WithTopBufSize(64).
+		Build(fmt.Sprintf("%s.SharedMemory", name))
+}
+
+func (b *SMBuilder) connectSubcoresWithMemory(
+	sm *SM,
+	subcores []*subcore.Subcore,
+	name string,
+) {
+	conn := directconnection.MakeBuilder().
+		WithEngine(b.engine).
+		WithFreq(b.freq).
+		Build(fmt.Sprintf("%s.SubcoresToMem", name))
+
+	l1DTop := sm.L1DCache.GetPortByName("Top")
+	sharedMemTop := sm.SharedMemory.GetPortByName("Top")
+	conn.PlugIn(l1DTop)
+	conn.PlugIn(sharedMemTop)
+
+	for _, subcore := range subcores {
+		subcore.SetL1DRemotePort(l1DTop)
+		subcore.SetSharedMemRemotePort(sharedMemTop)
+		conn.PlugIn(subcore.GetPortByName(fmt.Sprintf("%s.ToMem", subcore.Name())))
+	}
+}
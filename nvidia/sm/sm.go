@@ -5,6 +5,8 @@ import (
 
 	log "github.com/sirupsen/logrus"
 
+	"github.com/sarchlab/akita/v4/mem/cache/writearound"
+	"github.com/sarchlab/akita/v4/mem/idealmemcontroller"
 	"github.com/sarchlab/akita/v4/sim"
 	"github.com/sarchlab/mgpusim/v4/nvidia/message"
 	"github.com/sarchlab/mgpusim/v4/nvidia/nvidiaconfig"
@@ -22,9 +24,16 @@ type SM struct {
 	toGPU       sim.Port
 	toGPURemote sim.Port
 
-	toSubcores   sim.Port
-	Subcores     map[string]*subcore.Subcore
-	freeSubcores []*subcore.Subcore
+	toSubcores    sim.Port
+	Subcores      map[string]*subcore.Subcore
+	subcoreList   []*subcore.Subcore
+	freeWarpSlots map[string]int64
+	nextSubcore   int
+
+	// L1DCache serves the global memory accesses of the subcores, and
+	// SharedMemory serves the shared memory accesses.
+	L1DCache     *writearound.Comp
+	SharedMemory *idealmemcontroller.Comp
 
 	undispatchedWarps    []*nvidiaconfig.Warp
 	unfinishedWarpsCount int64
@@ -89,7 +98,7 @@ func (s *SM) processSMMsg(msg *message.DeviceToSMMsg) {
 
 func (s *SM) processSubcoreSubcoresg(msg *message.SubcoreToSMMsg) {
 	if msg.WarpFinished {
-		s.freeSubcores = append(s.freeSubcores, s.Subcores[msg.SubcoreID])
+		s.freeWarpSlots[msg.SubcoreID]++
 		s.unfinishedWarpsCount--
 		if s.unfinishedWarpsCount == 0 {
 			s.finishedThreadblocksCount++
@@ -120,12 +129,18 @@ func (s *SM) reportFinishedKernels() bool {
 	return true
 }
 
+// dispatchThreadblocksToSubcores assigns the warps to the subcores in a
+// round-robin order, skipping the subcores that have no free warp slot.
 func (s *SM) dispatchThreadblocksToSubcores() bool {
-	if len(s.freeSubcores) == 0 || len(s.undispatchedWarps) == 0 {
+	if len(s.undispatchedWarps) == 0 {
+		return false
+	}
+
+	subcore := s.subcoreWithFreeSlot()
+	if subcore == nil {
 		return false
 	}
 
-	subcore := s.freeSubcores[0]
 	warp := s.undispatchedWarps[0]
 
 	msg := &message.SMToSubcoreMsg{
@@ -139,10 +154,23 @@ func (s *SM) dispatchThreadblocksToSubcores() bool {
 		return false
 	}
 
-	s.freeSubcores = s.freeSubcores[1:]
+	s.freeWarpSlots[subcore.ID]--
+	s.nextSubcore = (s.nextSubcore + 1) % len(s.subcoreList)
 	s.undispatchedWarps = s.undispatchedWarps[1:]
 
-	return false
+	return true
+}
+
+func (s *SM) subcoreWithFreeSlot() *subcore.Subcore {
+	for n := range s.subcoreList {
+		i := (s.nextSubcore + n) % len(s.subcoreList)
+		if s.freeWarpSlots[s.subcoreList[i].ID] > 0 {
+			s.nextSubcore = i
+			return s.subcoreList[i]
+		}
+	}
+
+	return nil
 }
 
 func (s *SM) GetTotalWarpsCount() int64 {
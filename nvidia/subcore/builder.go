@@ -10,6 +10,8 @@ import (
 type SubcoreBuilder struct {
 	engine sim.Engine
 	freq   sim.Freq
+
+	schedulingPolicy SchedulingPolicy
 }
 
 func (b *SubcoreBuilder) WithEngine(engine sim.Engine) *SubcoreBuilder {
@@ -22,14 +24,27 @@ func (b *SubcoreBuilder) WithFreq(freq sim.Freq) *SubcoreBuilder {
 	return b
 }
 
+// WithSchedulingPolicy sets how the warp scheduler picks the warp to issue
+// from. The default is GTO.
+func (b *SubcoreBuilder) WithSchedulingPolicy(
+	policy SchedulingPolicy,
+) *SubcoreBuilder {
+	b.schedulingPolicy = policy
+	return b
+}
+
 func (b *SubcoreBuilder) Build(name string) *Subcore {
 	s := &Subcore{
-		ID: sim.GetIDGenerator().Generate(),
+		ID:           sim.GetIDGenerator().Generate(),
+		scheduler:    newWarpScheduler(b.schedulingPolicy),
+		memReqOwners: make(map[string]*inFlightInst),
 	}
 
 	s.TickingComponent = sim.NewTickingComponent(name, b.engine, b.freq, s)
 	s.toSM = sim.NewPort(s, 4, 4, fmt.Sprintf("%s.ToSM", name))
 	s.AddPort(fmt.Sprintf("%s.ToSM", name), s.toSM)
+	s.toMem = sim.NewPort(s, 64, 64, fmt.Sprintf("%s.ToMem", name))
+	s.AddPort(fmt.Sprintf("%s.ToMem", name), s.toMem)
 
 	atexit.Register(s.LogStatus)
 
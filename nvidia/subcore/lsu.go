@@ -0,0 +1,219 @@
+package subcore
+
+import (
+	log "github.com/sirupsen/logrus"
+
+	"github.com/sarchlab/akita/v4/mem/mem"
+	"github.com/sarchlab/akita/v4/sim"
+	"github.com/sarchlab/mgpusim/v4/nvidia/nvidiaconfig"
+)
+
+const (
+	// The L1 data cache coalesces the accesses of a warp into 128-byte lines
+	// and fetches the 32-byte sectors that the warp touches.
+	cacheLineSize = 128
+	sectorSize    = 32
+
+	// The shared memory has 32 banks that are 4 bytes wide.
+	sharedMemBanksCount = 32
+	sharedMemBankWidth  = 4
+)
+
+// SharedMemoryWindow is the size of the address range that the shared memory
+// serves. The shared memory addresses in the traces are mapped into the
+// window by their lower bits.
+const SharedMemoryWindow = 256 * mem.KB
+
+// SetL1DRemotePort sets the port of the L1 data cache that serves the global
+// memory accesses.
+func (s *Subcore) SetL1DRemotePort(remote sim.Port) {
+	s.toL1DRemote = remote
+}
+
+// SetSharedMemRemotePort sets the port of the shared memory of the SM.
+func (s *Subcore) SetSharedMemRemotePort(remote sim.Port) {
+	s.toSharedMemRemote = remote
+}
+
+// sendsToMemory returns true if the instruction waits for the memory
+// hierarchy instead of completing after a fixed latency.
+func (s *Subcore) sendsToMemory(inst *nvidiaconfig.Instruction) bool {
+	switch inst.MemSpace() {
+	case nvidiaconfig.MemSpaceGlobal:
+		return s.toL1DRemote != nil
+	case nvidiaconfig.MemSpaceShared:
+		return s.toSharedMemRemote != nil
+	}
+
+	return false
+}
+
+// issueMemoryAccess turns the accesses of the active threads into requests
+// to the memory hierarchy. The instruction completes when all the requests
+// return.
+func (s *Subcore) issueMemoryAccess(f *inFlightInst) {
+	var reqs []mem.AccessReq
+
+	switch f.inst.MemSpace() {
+	case nvidiaconfig.MemSpaceGlobal:
+		reqs = s.coalesceGlobalAccess(f.inst)
+	case nvidiaconfig.MemSpaceShared:
+		reqs = s.splitSharedAccess(f.inst)
+	}
+
+	for _, req := range reqs {
+		s.memReqOwners[req.Meta().ID] = f
+	}
+
+	f.pendingMemReqs = len(reqs)
+	s.memReqsToSend = append(s.memReqsToSend, reqs...)
+}
+
+// coalesceGlobalAccess generates one request for each 128-byte line that the
+// warp accesses, covering the sectors of the line that the threads touch. An
+// access that crosses a line boundary adds the sectors of every line it
+// touches.
+func (s *Subcore) coalesceGlobalAccess(
+	inst *nvidiaconfig.Instruction,
+) []mem.AccessReq {
+	type sectorRange struct {
+		first, last uint64
+	}
+
+	lines := []uint64{}
+	ranges := make(map[uint64]*sectorRange)
+
+	addSectors := func(line, first, last uint64) {
+		r, ok := ranges[line]
+		if !ok {
+			lines = append(lines, line)
+			ranges[line] = &sectorRange{first: first, last: last}
+
+			return
+		}
+
+		r.first = min(r.first, first)
+		r.last = max(r.last, last)
+	}
+
+	for _, addr := range inst.MemAddresses {
+		end := addr + uint64(max(inst.MemWidth, 1)) - 1
+		line := addr / cacheLineSize * cacheLineSize
+		for ; line <= end; line += cacheLineSize {
+			first := (max(addr, line) - line) / sectorSize
+			last := (min(end, line+cacheLineSize-1) - line) / sectorSize
+			addSectors(line, first, last)
+		}
+	}
+
+	reqs := make([]mem.AccessReq, 0, len(lines))
+	for _, line := range lines {
+		r := ranges[line]
+		addr := line + r.first*sectorSize
+		size := (r.last - r.first + 1) * sectorSize
+		reqs = append(reqs, s.newMemReq(inst, s.toL1DRemote, addr, size))
+	}
+
+	return reqs
+}
+
+// splitSharedAccess generates one request for each pass that the shared
+// memory needs to serve the warp. The threads that access different words in
+// the same bank conflict and are served in different passes.
+func (s *Subcore) splitSharedAccess(
+	inst *nvidiaconfig.Instruction,
+) []mem.AccessReq {
+	passes := [][]uint64{}
+	wordsInBank := make(map[uint64]map[uint64]bool)
+
+	for _, addr := range inst.MemAddresses {
+		addr %= SharedMemoryWindow
+		word := addr / sharedMemBankWidth
+		bank := word % sharedMemBanksCount
+
+		if wordsInBank[bank] == nil {
+			wordsInBank[bank] = make(map[uint64]bool)
+		}
+
+		if wordsInBank[bank][word] {
+			continue
+		}
+
+		pass := len(wordsInBank[bank])
+		wordsInBank[bank][word] = true
+
+		if pass == len(passes) {
+			passes = append(passes, []uint64{})
+		}
+		passes[pass] = append(passes[pass], addr)
+	}
+
+	reqs := make([]mem.AccessReq, 0, len(passes))
+	for _, pass := range passes {
+		addr := pass[0] / sharedMemBankWidth * sharedMemBankWidth
+		reqs = append(reqs,
+			s.newMemReq(inst, s.toSharedMemRemote, addr, sharedMemBankWidth))
+	}
+
+	return reqs
+}
+
+func (s *Subcore) newMemReq(
+	inst *nvidiaconfig.Instruction,
+	dst sim.Port,
+	addr, size uint64,
+) mem.AccessReq {
+	if inst.IsStore() {
+		return mem.WriteReqBuilder{}.
+			WithSrc(s.toMem.AsRemote()).
+			WithDst(dst.AsRemote()).
+			WithAddress(addr).
+			WithData(make([]byte, size)).
+			Build()
+	}
+
+	return mem.ReadReqBuilder{}.
+		WithSrc(s.toMem.AsRemote()).
+		WithDst(dst.AsRemote()).
+		WithAddress(addr).
+		WithByteSize(size).
+		Build()
+}
+
+func (s *Subcore) sendMemReqs() bool {
+	if len(s.memReqsToSend) == 0 {
+		return false
+	}
+
+	err := s.toMem.Send(s.memReqsToSend[0])
+	if err != nil {
+		return false
+	}
+
+	s.memReqsToSend = s.memReqsToSend[1:]
+
+	return true
+}
+
+func (s *Subcore) processMemRsp() bool {
+	msg := s.toMem.PeekIncoming()
+	if msg == nil {
+		return false
+	}
+
+	rsp, ok := msg.(mem.AccessRsp)
+	if !ok {
+		log.WithField("function", "processMemRsp").Panic("Unhandled message type")
+	}
+
+	f, found := s.memReqOwners[rsp.GetRspTo()]
+	if !found {
+		log.WithField("req", rsp.GetRspTo()).Panic("Unknown memory request")
+	}
+
+	delete(s.memReqOwners, rsp.GetRspTo())
+	f.pendingMemReqs--
+	s.toMem.RetrieveIncoming()
+
+	return true
+}
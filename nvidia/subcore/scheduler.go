@@ -0,0 +1,92 @@
+package subcore
+
+import log "github.com/sirupsen/logrus"
+
+// SchedulingPolicy decides which warp a subcore issues from when several
+// warps are ready.
+type SchedulingPolicy int
+
+const (
+	// GTO (greedy-then-oldest) keeps issuing from the same warp until it
+	// stalls, and then issues from the oldest ready warp.
+	GTO SchedulingPolicy = iota
+	// LRR (loose round-robin) issues from the next ready warp after the warp
+	// that issued last.
+	LRR
+)
+
+// ParseSchedulingPolicy converts "gto" or "lrr" to a policy.
+func ParseSchedulingPolicy(name string) SchedulingPolicy {
+	switch name {
+	case "gto":
+		return GTO
+	case "lrr":
+		return LRR
+	}
+
+	log.WithField("policy", name).Panic("Unknown scheduling policy")
+
+	return GTO
+}
+
+type warpScheduler interface {
+	// pick returns the index of the warp to issue from, or -1 if no warp is
+	// ready. The warps are in the order that they arrive at the subcore.
+	pick(warps []*warpState, isReady func(*warpState) bool) int
+}
+
+func newWarpScheduler(policy SchedulingPolicy) warpScheduler {
+	switch policy {
+	case GTO:
+		return &gtoScheduler{}
+	case LRR:
+		return &lrrScheduler{}
+	}
+
+	log.WithField("policy", policy).Panic("Unknown scheduling policy")
+
+	return nil
+}
+
+type gtoScheduler struct {
+	last *warpState
+}
+
+func (s *gtoScheduler) pick(
+	warps []*warpState,
+	isReady func(*warpState) bool,
+) int {
+	for i, w := range warps {
+		if w == s.last && isReady(w) {
+			return i
+		}
+	}
+
+	for i, w := range warps {
+		if isReady(w) {
+			s.last = w
+			return i
+		}
+	}
+
+	return -1
+}
+
+type lrrScheduler struct {
+	next int
+}
+
+func (s *lrrScheduler) pick(
+	warps []*warpState,
+	isReady func(*warpState) bool,
+) int {
+	for n := 0; n < len(warps); n++ {
+		i := (s.next + n) % len(warps)
+		if isReady(warps[i]) {
+			s.next = i + 1
+			return i
+		}
+	}
+
+	return -1
+}
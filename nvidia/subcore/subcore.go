@@ -3,10 +3,19 @@ package subcore
 import (
 	log "github.com/sirupsen/logrus"
 
+	"github.com/sarchlab/akita/v4/mem/mem"
 	"github.com/sarchlab/akita/v4/sim"
 	"github.com/sarchlab/mgpusim/v4/nvidia/message"
+	"github.com/sarchlab/mgpusim/v4/nvidia/nvidiaconfig"
 )
 
+// A Subcore issues at most one instruction per cycle from the warps that the
+// SM assigns to it. The warp scheduler picks a warp whose next instruction
+// does not read or write a register that an in-flight instruction writes,
+// and whose functional unit is free. An instruction occupies its unit for
+// the initiation interval of its opcode, and its destination registers become
+// available after the latency of its opcode. The loads and stores that have
+// addresses in the trace complete when the memory hierarchy responds.
 type Subcore struct {
 	*sim.TickingComponent
 
@@ -17,9 +26,36 @@ type Subcore struct {
 	toSM       sim.Port
 	toSMRemote sim.Port
 
-	unfinishedInstsCount int64
+	toMem             sim.Port
+	toL1DRemote       sim.Port
+	toSharedMemRemote sim.Port
+
+	warps         []*warpState
+	scheduler     warpScheduler
+	inFlightInsts []*inFlightInst
+	unitFreeCycle [nvidiaconfig.ExecUnitsCount]uint64
+
+	memReqsToSend []mem.AccessReq
+	memReqOwners  map[string]*inFlightInst
 
 	finishedWarpsCount int64
+
+	issueCycles int64
+	stallCycles int64
+}
+
+type warpState struct {
+	warp        nvidiaconfig.Warp
+	nextInst    int
+	pendingRegs map[int32]int
+	inFlight    int
+}
+
+type inFlightInst struct {
+	warp           *warpState
+	inst           *nvidiaconfig.Instruction
+	doneCycle      uint64
+	pendingMemReqs int
 }
 
 func (s *Subcore) SetSMRemotePort(remote sim.Port) {
@@ -29,11 +65,25 @@ func (s *Subcore) SetSMRemotePort(remote sim.Port) {
 func (s *Subcore) Tick() bool {
 	madeProgress := false
 	madeProgress = s.reportFinishedWarps() || madeProgress
-	madeProgress = s.run() || madeProgress
+	madeProgress = s.processMemRsp() || madeProgress
+	madeProgress = s.writeback() || madeProgress
+	madeProgress = s.issue() || madeProgress
+	madeProgress = s.sendMemReqs() || madeProgress
 	madeProgress = s.processSMInput() || madeProgress
-	// warps can be switched, but ignore now
 
-	return madeProgress
+	// The in-flight instructions complete in later cycles. The instructions
+	// that wait for the memory wake the subcore up with the responses.
+	return madeProgress || s.hasTimedInsts()
+}
+
+func (s *Subcore) hasTimedInsts() bool {
+	for _, f := range s.inFlightInsts {
+		if f.pendingMemReqs == 0 {
+			return true
+		}
+	}
+
+	return false
 }
 
 func (s *Subcore) processSMInput() bool {
@@ -53,19 +103,132 @@ func (s *Subcore) processSMInput() bool {
 }
 
 func (s *Subcore) processSMMsg(msg *message.SMToSubcoreMsg) {
-	s.unfinishedInstsCount = msg.Warp.InstructionsCount
-	s.instsCount += msg.Warp.InstructionsCount
+	w := &warpState{
+		warp:        msg.Warp,
+		pendingRegs: make(map[int32]int),
+	}
+	s.warps = append(s.warps, w)
+	s.retireIfFinished(w)
+
 	s.toSM.RetrieveIncoming()
 }
 
-func (s *Subcore) run() bool {
-	if s.unfinishedInstsCount == 0 {
+func (s *Subcore) currentCycle() uint64 {
+	return s.Freq.Cycle(s.CurrentTime())
+}
+
+// writeback releases the registers of the instructions that complete.
+func (s *Subcore) writeback() bool {
+	cycle := s.currentCycle()
+	madeProgress := false
+
+	remaining := s.inFlightInsts[:0]
+	for _, f := range s.inFlightInsts {
+		if f.doneCycle > cycle || f.pendingMemReqs > 0 {
+			remaining = append(remaining, f)
+			continue
+		}
+
+		for _, r := range f.inst.DestRegs {
+			if r.IsZeroRegister() {
+				continue
+			}
+
+			f.warp.pendingRegs[r.ID()]--
+			if f.warp.pendingRegs[r.ID()] == 0 {
+				delete(f.warp.pendingRegs, r.ID())
+			}
+		}
+
+		f.warp.inFlight--
+		s.retireIfFinished(f.warp)
+		madeProgress = true
+	}
+
+	s.inFlightInsts = remaining
+
+	return madeProgress
+}
+
+func (s *Subcore) retireIfFinished(w *warpState) {
+	if w.nextInst < len(w.warp.Instructions) || w.inFlight > 0 {
+		return
+	}
+
+	for i, other := range s.warps {
+		if other == w {
+			s.warps = append(s.warps[:i], s.warps[i+1:]...)
+			break
+		}
+	}
+
+	s.finishedWarpsCount++
+}
+
+func (s *Subcore) issue() bool {
+	if len(s.warps) == 0 {
 		return false
 	}
 
-	s.unfinishedInstsCount--
-	if s.unfinishedInstsCount == 0 {
-		s.finishedWarpsCount++
+	cycle := s.currentCycle()
+	i := s.scheduler.pick(s.warps, func(w *warpState) bool {
+		return s.canIssue(w, cycle)
+	})
+
+	if i < 0 {
+		s.stallCycles++
+		return false
+	}
+
+	w := s.warps[i]
+	inst := &w.warp.Instructions[w.nextInst]
+	w.nextInst++
+
+	s.unitFreeCycle[inst.ExecUnit()] = cycle + uint64(inst.InitiationInterval())
+
+	for _, r := range inst.DestRegs {
+		if !r.IsZeroRegister() {
+			w.pendingRegs[r.ID()]++
+		}
+	}
+
+	f := &inFlightInst{
+		warp:      w,
+		inst:      inst,
+		doneCycle: cycle + uint64(inst.Latency()),
+	}
+	if s.sendsToMemory(inst) {
+		f.doneCycle = cycle
+		s.issueMemoryAccess(f)
+	}
+
+	w.inFlight++
+	s.inFlightInsts = append(s.inFlightInsts, f)
+
+	s.instsCount++
+	s.issueCycles++
+
+	return true
+}
+
+// canIssue checks the scoreboard and the functional unit of the next
+// instruction of a warp.
+func (s *Subcore) canIssue(w *warpState, cycle uint64) bool {
+	if w.nextInst >= len(w.warp.Instructions) {
+		return false
+	}
+
+	inst := &w.warp.Instructions[w.nextInst]
+	if s.unitFreeCycle[inst.ExecUnit()] > cycle {
+		return false
+	}
+
+	for _, regs := range [][]*nvidiaconfig.Register{inst.SrcRegs, inst.DestRegs} {
+		for _, r := range regs {
+			if !r.IsZeroRegister() && w.pendingRegs[r.ID()] > 0 {
+				return false
+			}
+		}
 	}
 
 	return true
@@ -97,9 +260,23 @@ func (s *Subcore) GetTotalInstsCount() int64 {
 	return s.instsCount
 }
 
+// GetIssueCycles returns the number of cycles in which the subcore issues an
+// instruction.
+func (s *Subcore) GetIssueCycles() int64 {
+	return s.issueCycles
+}
+
+// GetStallCycles returns the number of cycles in which the subcore has warps
+// but none of them can issue.
+func (s *Subcore) GetStallCycles() int64 {
+	return s.stallCycles
+}
+
 func (s *Subcore) LogStatus() {
 	log.WithFields(log.Fields{
 		"subcore_id":        s.ID,
 		"total_insts_count": s.instsCount,
+		"issue_cycles":      s.issueCycles,
+		"stall_cycles":      s.stallCycles,
 	}).Info("Subcore status")
 }
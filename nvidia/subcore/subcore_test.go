@@ -0,0 +1,184 @@
+package subcore
+
+import (
+	"testing"
+
+	"github.com/sarchlab/akita/v4/mem/mem"
+	"github.com/sarchlab/akita/v4/sim"
+	"github.com/sarchlab/mgpusim/v4/nvidia/nvidiaconfig"
+)
+
+func newTestWarp(opcodes ...string) *warpState {
+	w := &warpState{pendingRegs: make(map[int32]int)}
+	for _, op := range opcodes {
+		w.warp.Instructions = append(w.warp.Instructions,
+			nvidiaconfig.Instruction{OpCode: nvidiaconfig.NewOpcode(op)})
+	}
+
+	return w
+}
+
+func TestGTOKeepsIssuingFromTheSameWarp(t *testing.T) {
+	s := &gtoScheduler{}
+	warps := []*warpState{newTestWarp(), newTestWarp()}
+	ready := map[*warpState]bool{warps[0]: true, warps[1]: true}
+	isReady := func(w *warpState) bool { return ready[w] }
+
+	if i := s.pick(warps, isReady); i != 0 {
+		t.Errorf("expected the oldest warp, got %d", i)
+	}
+
+	ready[warps[0]] = false
+	if i := s.pick(warps, isReady); i != 1 {
+		t.Errorf("expected warp 1 when warp 0 stalls, got %d", i)
+	}
+
+	ready[warps[0]] = true
+	if i := s.pick(warps, isReady); i != 1 {
+		t.Errorf("expected warp 1 to keep issuing, got %d", i)
+	}
+}
+
+func TestLRRRotatesAmongWarps(t *testing.T) {
+	s := &lrrScheduler{}
+	warps := []*warpState{newTestWarp(), newTestWarp(), newTestWarp()}
+	isReady := func(w *warpState) bool { return w != warps[1] }
+
+	picks := []int{}
+	for n := 0; n < 3; n++ {
+		picks = append(picks, s.pick(warps, isReady))
+	}
+
+	if picks[0] != 0 || picks[1] != 2 || picks[2] != 0 {
+		t.Errorf("expected warps 0, 2, 0, got %v", picks)
+	}
+}
+
+func TestScoreboardBlocksDependentInstructions(t *testing.T) {
+	s := &Subcore{}
+	w := newTestWarp("FADD")
+	w.warp.Instructions[0].SrcRegs = []*nvidiaconfig.Register{
+		nvidiaconfig.NewRegister("R4"), nvidiaconfig.NewRegister("R255"),
+	}
+
+	if !s.canIssue(w, 0) {
+		t.Errorf("expected the instruction to issue")
+	}
+
+	w.pendingRegs[4] = 1
+	if s.canIssue(w, 0) {
+		t.Errorf("expected the instruction to wait for R4")
+	}
+}
+
+func TestBusyUnitBlocksIssue(t *testing.T) {
+	s := &Subcore{}
+	s.unitFreeCycle[nvidiaconfig.ExecUnitSFU] = 8
+	w := newTestWarp("MUFU.EX2")
+
+	if s.canIssue(w, 7) {
+		t.Errorf("expected the instruction to wait for the SFU")
+	}
+
+	if !s.canIssue(w, 8) {
+		t.Errorf("expected the instruction to issue when the SFU is free")
+	}
+}
+
+func newTestMemInst(opcode string, width int32, addrs ...uint64) *nvidiaconfig.Instruction {
+	return &nvidiaconfig.Instruction{
+		OpCode:       nvidiaconfig.NewOpcode(opcode),
+		MemWidth:     width,
+		MemAddresses: addrs,
+	}
+}
+
+func newTestMemSubcore() *Subcore {
+	s := new(SubcoreBuilder).WithEngine(sim.NewSerialEngine()).
+		WithFreq(1 * sim.GHz).Build("Subcore")
+	s.SetL1DRemotePort(s.toSM)
+	s.SetSharedMemRemotePort(s.toSM)
+
+	return s
+}
+
+func TestGlobalAccessCoalescesIntoSectorsOfLines(t *testing.T) {
+	s := newTestMemSubcore()
+	addrs := []uint64{}
+	for i := uint64(0); i < 32; i++ {
+		addrs = append(addrs, 0x1040+i*4)
+	}
+
+	reqs := s.coalesceGlobalAccess(newTestMemInst("LDG.E", 4, addrs...))
+
+	if len(reqs) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(reqs))
+	}
+
+	first := reqs[0].(*mem.ReadReq)
+	second := reqs[1].(*mem.ReadReq)
+	if first.Address != 0x1040 || first.AccessByteSize != 64 {
+		t.Errorf("expected 64 bytes at 0x1040, got %d bytes at %#x",
+			first.AccessByteSize, first.Address)
+	}
+	if second.Address != 0x1080 || second.AccessByteSize != 64 {
+		t.Errorf("expected 64 bytes at 0x1080, got %d bytes at %#x",
+			second.AccessByteSize, second.Address)
+	}
+}
+
+func TestGlobalAccessSplitsAtLineBoundaries(t *testing.T) {
+	s := newTestMemSubcore()
+
+	reqs := s.coalesceGlobalAccess(
+		newTestMemInst("LDG.E.128", 16, 0x1078, 0x10f8))
+
+	expected := []struct {
+		addr, size uint64
+	}{
+		{0x1060, 32},
+		{0x1080, 128},
+		{0x1100, 32},
+	}
+	if len(reqs) != len(expected) {
+		t.Fatalf("expected %d requests, got %d", len(expected), len(reqs))
+	}
+
+	for i, e := range expected {
+		req := reqs[i].(*mem.ReadReq)
+		if req.Address != e.addr || req.AccessByteSize != e.size {
+			t.Errorf("request %d: expected %d bytes at %#x, got %d bytes at %#x",
+				i, e.size, e.addr, req.AccessByteSize, req.Address)
+		}
+	}
+}
+
+func TestStoresGenerateWriteRequests(t *testing.T) {
+	s := newTestMemSubcore()
+
+	reqs := s.coalesceGlobalAccess(newTestMemInst("STG.E", 4, 0x2000, 0x2004))
+
+	write, ok := reqs[0].(*mem.WriteReq)
+	if len(reqs) != 1 || !ok {
+		t.Fatalf("expected a write request, got %v", reqs)
+	}
+	if len(write.Data) != 32 {
+		t.Errorf("expected a 32-byte sector, got %d bytes", len(write.Data))
+	}
+}
+
+func TestSharedAccessSplitsBankConflicts(t *testing.T) {
+	s := newTestMemSubcore()
+
+	noConflict := s.splitSharedAccess(
+		newTestMemInst("LDS", 4, 0, 4, 8, 12, 0))
+	if len(noConflict) != 1 {
+		t.Errorf("expected 1 pass, got %d", len(noConflict))
+	}
+
+	twoWay := s.splitSharedAccess(
+		newTestMemInst("LDS", 4, 0, 128, 4, 132, 256+8))
+	if len(twoWay) != 2 {
+		t.Errorf("expected 2 passes, got %d", len(twoWay))
+	}
+}
@@ -3,6 +3,7 @@ package tracereader
 import (
 	"bufio"
 	"fmt"
+	"math/bits"
 	"os"
 	"strconv"
 	"strings"
@@ -155,7 +156,7 @@ func extractInst(text string) *Instruction {
 		inst.DestRegs = append(inst.DestRegs, nvidiaconfig.NewRegister(elems[2+i+1]))
 	}
 
-	//inst.OpCode = nvidia.NewOpcode(elems[3+int(inst.DestNum)])
+	inst.OpCode = nvidiaconfig.NewOpcode(elems[3+int(inst.DestNum)])
 
 	fmt.Sscanf(elems[4+int(inst.DestNum)], "%d", &inst.SrcNum)
 	for i := 0; i < int(inst.SrcNum); i++ {
@@ -166,24 +167,68 @@ func extractInst(text string) *Instruction {
 	return inst
 }
 
-// [todo]: understand memory format
+// updateInstMemoryPart parses the memory width and the addresses of the
+// active threads. The addresses are in one of three formats:
+//
+//	0: the address of each active thread
+//	1: the address of the first active thread and a stride
+//	2: the address of the first active thread and the delta of each following
+//	   active thread from the previous one
 func updateInstMemoryPart(inst *Instruction, elems []string) {
 	fmt.Sscanf(elems[0], "%d", &inst.MemWidth)
 
 	if inst.MemWidth != 0 {
 		fmt.Sscanf(elems[1], "%d", &inst.AddressCompress)
-		fmt.Sscanf(elems[2], "%x", &inst.MemAddress)
+		inst.MemAddress = parseMemAddress(elems[2])
 
-		if inst.AddressCompress == 1 {
+		switch inst.AddressCompress {
+		case 0:
+			for _, s := range elems[2 : len(elems)-1] {
+				inst.MemAddresses = append(inst.MemAddresses, uint64(parseMemAddress(s)))
+			}
+		case 1:
 			fmt.Sscanf(elems[3], "%d", &inst.MemAddressSuffix1)
-		} else if inst.AddressCompress == 2 {
+			inst.MemAddresses = expandStridedAddresses(inst)
+		case 2:
 			for _, s := range elems[3 : len(elems)-1] {
 				s32, _ := strconv.Atoi(s)
 				inst.MemAddressSuffix2 = append(inst.MemAddressSuffix2, int32(s32))
 			}
+			inst.MemAddresses = expandDeltaAddresses(inst)
 		}
 	}
 
 	imm, _ := strconv.Atoi(elems[len(elems)-1])
 	inst.Immediate = int64(imm)
 }
+
+func parseMemAddress(s string) int64 {
+	addr, err := strconv.ParseUint(s, 0, 64)
+	if err != nil {
+		log.WithError(err).WithField("address", s).Panic("Failed to parse address")
+	}
+
+	return int64(addr)
+}
+
+func expandStridedAddresses(inst *Instruction) []uint64 {
+	addrs := []uint64{}
+	addr := inst.MemAddress
+	for i := 0; i < bits.OnesCount64(uint64(inst.Mask)); i++ {
+		addrs = append(addrs, uint64(addr))
+		addr += int64(inst.MemAddressSuffix1)
+	}
+
+	return addrs
+}
+
+func expandDeltaAddresses(inst *Instruction) []uint64 {
+	addrs := []uint64{uint64(inst.MemAddress)}
+	addr := inst.MemAddress
+	for _, delta := range inst.MemAddressSuffix2 {
+		addr += int64(delta)
+		addrs = append(addrs, uint64(addr))
+	}
+
+	return addrs
+}
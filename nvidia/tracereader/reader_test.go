@@ -40,6 +40,29 @@ var _ = Describe("Read Traces from VectorAdd Traces Version 5.0", func() {
 		})
 	})
 
+	Describe("Opcodes", func() {
+		It("should map the opcodes to the functional units", func() {
+			insts := trace.Threadblock(0).Warp(0).Instructions
+
+			Expect(insts[0].OpCode.String()).To(Equal("MOV"))
+			Expect(insts[0].OpCode.ExecUnit()).To(Equal(nvidiaconfig.ExecUnitALU))
+			Expect(insts[10].OpCode.String()).To(Equal("LDG.E"))
+			Expect(insts[10].OpCode.ExecUnit()).To(Equal(nvidiaconfig.ExecUnitLSU))
+			Expect(insts[13].OpCode.ExecUnit()).To(Equal(nvidiaconfig.ExecUnitFMA))
+		})
+	})
+
+	Describe("Memory Addresses", func() {
+		It("should expand the strided addresses of the active threads", func() {
+			inst := trace.Threadblock(0).Warp(0).Instructions[10]
+
+			Expect(inst.MemWidth).To(Equal(int32(4)))
+			Expect(inst.MemAddresses).To(HaveLen(32))
+			Expect(inst.MemAddresses[0]).To(Equal(uint64(0x7fb0fc430e00)))
+			Expect(inst.MemAddresses[31]).To(Equal(uint64(0x7fb0fc430e7c)))
+		})
+	})
+
 	Describe("Insts Count", func() {
 		It("should count 26601 instructions", func() {
 			instCount := 0
@@ -61,6 +61,10 @@ type Instruction struct {
 	MemAddressSuffix1 int32
 	MemAddressSuffix2 []int32
 	Immediate         int64
+
+	// MemAddresses are the addresses that the active threads access, in the
+	// order of the threads.
+	MemAddresses []uint64
 }
 
 // Shaoyu: Maybe we can parse the attrs in order and avoid using swicth-case here